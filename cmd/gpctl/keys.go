@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// keysCommands are the subcommands of "gpctl keys".
+//
+//nolint:gochecknoglobals
+var keysCommands = map[string]func(context.Context, *session, []string) error{
+	"list":   keysList,
+	"create": keysCreate,
+}
+
+// keysList prints the repository's deploy keys.
+func keysList(ctx context.Context, s *session, args []string) error {
+	if err := s.parseFlags(flag.NewFlagSet("keys list", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	repo, err := s.getRepository(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := repo.DeployKeys().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	infos := make([]gitprovider.DeployKeyInfo, 0, len(keys))
+	for _, key := range keys {
+		infos = append(infos, key.Get())
+	}
+	return s.printJSON(infos)
+}
+
+// keysCreate reconciles a deploy key with the public key read from a file.
+func keysCreate(ctx context.Context, s *session, args []string) error {
+	fs := flag.NewFlagSet("keys create", flag.ContinueOnError)
+	name := fs.String("name", "", "the deploy key name")
+	keyFile := fs.String("key-file", "", "the file containing the public key")
+	readOnly := fs.Bool("read-only", true, "only allow the key to pull")
+	if err := s.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *name == "" || *keyFile == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	key, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	repo, err := s.getRepository(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, actionTaken, err := repo.DeployKeys().Reconcile(ctx, gitprovider.DeployKeyInfo{
+		Name:     *name,
+		Key:      key,
+		ReadOnly: readOnly,
+	})
+	if err != nil {
+		return err
+	}
+	if actionTaken {
+		fmt.Fprintf(s.stderr, "deploy key %q reconciled\n", *name)
+	} else {
+		fmt.Fprintf(s.stderr, "deploy key %q is up to date\n", *name)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// gpctl is a small command-line tool built on top of the high-level gitprovider interfaces.
+// It serves both as a living example of how to use the library, and as a manual testing
+// tool when implementing a new provider.
+//
+// Usage:
+//
+//	gpctl [flags] <command> <subcommand> <repository-url> [flags]
+//
+// Commands:
+//
+//	repo get|create|reconcile|export
+//	keys list|create
+//	prs list|create
+//
+// The token is read from the -token flag, or from the GITPROVIDER_TOKEN environment variable.
+// Destructive API calls, e.g. recreating a changed deploy key, are refused unless -allow-destructive
+// is given.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const tokenVariable = "GITPROVIDER_TOKEN" // #nosec G101
+
+// errUsage is returned when the command line can't be parsed, after the usage has been printed.
+var errUsage = errors.New("invalid usage")
+
+// globalOptions holds the flags shared by all commands.
+type globalOptions struct {
+	provider  string
+	domain    string
+	token     string
+	tokenType string
+	username  string
+	userRepo  bool
+	// allowDestructive enables the API calls that delete or recreate resources
+	allowDestructive bool
+}
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run parses args and executes the requested command, writing results to stdout.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	opts := globalOptions{}
+	fs := flag.NewFlagSet("gpctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.provider, "provider", "github", "the Git provider: github, gitlab or stash")
+	fs.StringVar(&opts.domain, "domain", "", "the provider domain, derived from the repository URL if unset")
+	fs.StringVar(&opts.token, "token", os.Getenv(tokenVariable), "the access token, defaults to $"+tokenVariable)
+	fs.StringVar(&opts.tokenType, "token-type", "", `the GitLab token type, "oauth2" or a personal access token if unset`)
+	fs.StringVar(&opts.username, "username", "", "the username to authenticate with, required for stash")
+	fs.BoolVar(&opts.userRepo, "user", false, "treat the repository as owned by a user instead of an organization")
+	fs.BoolVar(&opts.allowDestructive, "allow-destructive", false, "allow API calls deleting resources, e.g. recreating changed deploy keys")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: gpctl [flags] <repo|keys|prs> <subcommand> <repository-url> [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
+	}
+
+	if fs.NArg() < 3 {
+		fs.Usage()
+		return errUsage
+	}
+	command, subcommand, repoURL, rest := fs.Arg(0), fs.Arg(1), fs.Arg(2), fs.Args()[3:]
+
+	var cmd func(context.Context, *session, []string) error
+	switch command {
+	case "repo":
+		cmd = repoCommands[subcommand]
+	case "keys":
+		cmd = keysCommands[subcommand]
+	case "prs":
+		cmd = prsCommands[subcommand]
+	}
+	if cmd == nil {
+		fmt.Fprintf(stderr, "unknown command %q\n", command+" "+subcommand)
+		fs.Usage()
+		return errUsage
+	}
+
+	s, err := newSession(opts, repoURL, stdout, stderr)
+	if err != nil {
+		return err
+	}
+	return cmd(ctx, s, rest)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestRun_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "missing arguments",
+			args: []string{"repo", "get"},
+		},
+		{
+			name: "unknown command",
+			args: []string{"repo", "frobnicate", "https://github.com/fluxcd/flux2"},
+		},
+		{
+			name: "unknown flag",
+			args: []string{"-frobnicate", "repo", "get", "https://github.com/fluxcd/flux2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := run(context.Background(), tt.args, &stdout, &stderr)
+			if !errors.Is(err, errUsage) {
+				t.Errorf("run() error = %v, want %v", err, errUsage)
+			}
+			if stderr.Len() == 0 {
+				t.Error("run() didn't print the usage")
+			}
+		})
+	}
+}
+
+func Test_parseRepositoryRef(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		userRepo bool
+		want     gitprovider.RepositoryRef
+	}{
+		{
+			name: "organization repository",
+			url:  "https://gitlab.com/fluxcd/engineering/flux2",
+			want: gitprovider.OrgRepositoryRef{
+				OrganizationRef: gitprovider.OrganizationRef{Domain: "gitlab.com", Organization: "fluxcd", SubOrganizations: []string{"engineering"}},
+				RepositoryName:  "flux2",
+			},
+		},
+		{
+			name:     "user repository",
+			url:      "https://github.com/dinosk/flux2",
+			userRepo: true,
+			want: gitprovider.UserRepositoryRef{
+				UserRef:        gitprovider.UserRef{Domain: "github.com", UserLogin: "dinosk"},
+				RepositoryName: "flux2",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRepositoryRef(tt.url, tt.userRepo)
+			if err != nil {
+				t.Fatalf("parseRepositoryRef() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRepositoryRef() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readRepositorySpec(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	if err := os.WriteFile(valid, []byte(`{"url":"https://github.com/fluxcd/flux2","spec":{"description":"Flux v2","visibility":"public"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"url":"https://github.com/fluxcd/flux2","spec":{"visibility":"everyone"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := readRepositorySpec(valid)
+	if err != nil {
+		t.Fatalf("readRepositorySpec() error = %v", err)
+	}
+	if *spec.Spec.Description != "Flux v2" || *spec.Spec.Visibility != gitprovider.RepositoryVisibilityPublic {
+		t.Errorf("readRepositorySpec() = %+v", spec.Spec)
+	}
+
+	if _, err := readRepositorySpec(invalid); err == nil {
+		t.Error("readRepositorySpec() expected a validation error for an unknown visibility")
+	}
+}
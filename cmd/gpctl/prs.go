@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// prsCommands are the subcommands of "gpctl prs".
+//
+//nolint:gochecknoglobals
+var prsCommands = map[string]func(context.Context, *session, []string) error{
+	"list":   prsList,
+	"create": prsCreate,
+}
+
+// prsList prints the repository's pull requests.
+func prsList(ctx context.Context, s *session, args []string) error {
+	if err := s.parseFlags(flag.NewFlagSet("prs list", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	repo, err := s.getRepository(ctx)
+	if err != nil {
+		return err
+	}
+	prs, err := repo.PullRequests().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	infos := make([]gitprovider.PullRequestInfo, 0, len(prs))
+	for _, pr := range prs {
+		infos = append(infos, pr.Get())
+	}
+	return s.printJSON(infos)
+}
+
+// prsCreate opens a pull request from the head branch into the base branch.
+func prsCreate(ctx context.Context, s *session, args []string) error {
+	fs := flag.NewFlagSet("prs create", flag.ContinueOnError)
+	title := fs.String("title", "", "the pull request title")
+	head := fs.String("head", "", "the branch containing the changes")
+	base := fs.String("base", "", "the branch to merge into, defaults to the default branch")
+	description := fs.String("description", "", "the pull request description")
+	if err := s.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *title == "" || *head == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	repo, err := s.getRepository(ctx)
+	if err != nil {
+		return err
+	}
+	if *base == "" {
+		if branch := repo.Get().DefaultBranch; branch != nil {
+			*base = *branch
+		}
+	}
+
+	pr, err := repo.PullRequests().Create(ctx, *title, *head, *base, *description)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.stderr, "created pull request %d: %s\n", pr.Get().Number, pr.Get().WebURL)
+	return nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// repoCommands are the subcommands of "gpctl repo".
+//
+//nolint:gochecknoglobals
+var repoCommands = map[string]func(context.Context, *session, []string) error{
+	"get":       repoGet,
+	"create":    repoCreate,
+	"reconcile": repoReconcile,
+	"export":    repoExport,
+}
+
+// repositorySpec is the exported desired state of a repository, as consumed by "repo reconcile".
+type repositorySpec struct {
+	URL  string                     `json:"url"`
+	Spec gitprovider.RepositoryInfo `json:"spec"`
+}
+
+// repoGet prints the repository's high-level information and clone URLs.
+func repoGet(ctx context.Context, s *session, args []string) error {
+	if err := s.parseFlags(flag.NewFlagSet("repo get", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	repo, err := s.getRepository(ctx)
+	if err != nil {
+		return err
+	}
+	return s.printJSON(struct {
+		URL      string                     `json:"url"`
+		CloneURL string                     `json:"cloneURL"`
+		Info     gitprovider.RepositoryInfo `json:"info"`
+	}{
+		URL:      repo.Repository().String(),
+		CloneURL: repo.Repository().GetCloneURL(gitprovider.TransportTypeHTTPS),
+		Info:     repo.Get(),
+	})
+}
+
+// repoCreate creates the repository.
+func repoCreate(ctx context.Context, s *session, args []string) error {
+	fs := flag.NewFlagSet("repo create", flag.ContinueOnError)
+	description := fs.String("description", "", "the repository description")
+	visibility := fs.String("visibility", string(gitprovider.RepositoryVisibilityPrivate), "the repository visibility: public, internal or private")
+	autoInit := fs.Bool("auto-init", true, "initialize the repository with a README")
+	if err := s.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	info := gitprovider.RepositoryInfo{
+		Description: description,
+		Visibility:  gitprovider.RepositoryVisibilityVar(gitprovider.RepositoryVisibility(*visibility)),
+	}
+	createOpts := &gitprovider.RepositoryCreateOptions{AutoInit: autoInit}
+
+	var repo gitprovider.UserRepository
+	var err error
+	switch ref := s.ref.(type) {
+	case gitprovider.OrgRepositoryRef:
+		repo, err = s.client.OrgRepositories().Create(ctx, ref, info, createOpts)
+	case gitprovider.UserRepositoryRef:
+		repo, err = s.client.UserRepositories().Create(ctx, ref, info, createOpts)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.stderr, "created %s\n", repo.Repository().String())
+	return nil
+}
+
+// repoReconcile makes the repository match the spec read from a file, as written by "repo export".
+func repoReconcile(ctx context.Context, s *session, args []string) error {
+	fs := flag.NewFlagSet("repo reconcile", flag.ContinueOnError)
+	file := fs.String("f", "", "the spec file written by repo export, - for stdin")
+	if err := s.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *file == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	spec, err := readRepositorySpec(*file)
+	if err != nil {
+		return err
+	}
+
+	var actionTaken bool
+	switch ref := s.ref.(type) {
+	case gitprovider.OrgRepositoryRef:
+		_, actionTaken, err = s.client.OrgRepositories().Reconcile(ctx, ref, spec.Spec)
+	case gitprovider.UserRepositoryRef:
+		_, actionTaken, err = s.client.UserRepositories().Reconcile(ctx, ref, spec.Spec)
+	}
+	if err != nil {
+		return err
+	}
+	if actionTaken {
+		fmt.Fprintf(s.stderr, "reconciled %s\n", s.ref.String())
+	} else {
+		fmt.Fprintf(s.stderr, "%s is up to date\n", s.ref.String())
+	}
+	return nil
+}
+
+// repoExport prints the repository's desired state, in the format accepted by "repo reconcile".
+func repoExport(ctx context.Context, s *session, args []string) error {
+	if err := s.parseFlags(flag.NewFlagSet("repo export", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	repo, err := s.getRepository(ctx)
+	if err != nil {
+		return err
+	}
+	return s.printJSON(repositorySpec{
+		URL:  repo.Repository().String(),
+		Spec: repo.Get(),
+	})
+}
+
+// readRepositorySpec decodes a repositorySpec from the given file, or stdin if file is "-".
+func readRepositorySpec(file string) (*repositorySpec, error) {
+	f := os.Stdin
+	if file != "-" {
+		var err error
+		if f, err = os.Open(file); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+	}
+
+	spec := &repositorySpec{}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(spec); err != nil {
+		return nil, fmt.Errorf("failed to decode spec %q: %w", file, err)
+	}
+	return spec, spec.Spec.ValidateInfo()
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/github"
+	"github.com/fluxcd/go-git-providers/gitlab"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/stash"
+)
+
+// session holds the client and repository reference a command operates on.
+type session struct {
+	client gitprovider.Client
+	ref    gitprovider.RepositoryRef
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// newSession parses the repository URL, and creates a client for the provider it is hosted on.
+func newSession(opts globalOptions, repoURL string, stdout, stderr io.Writer) (*session, error) {
+	ref, err := parseRepositoryRef(repoURL, opts.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := opts.domain
+	if domain == "" {
+		domain = ref.GetDomain()
+	}
+
+	client, err := newClient(opts, domain)
+	if err != nil {
+		return nil, err
+	}
+	return &session{client: client, ref: ref, stdout: stdout, stderr: stderr}, nil
+}
+
+// parseRepositoryRef parses an HTTPS repository URL into an organization or user repository reference.
+func parseRepositoryRef(repoURL string, userRepo bool) (gitprovider.RepositoryRef, error) {
+	if userRepo {
+		ref, err := gitprovider.ParseUserRepositoryURL(repoURL)
+		if err != nil {
+			return nil, err
+		}
+		return *ref, nil
+	}
+	ref, err := gitprovider.ParseOrgRepositoryURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return *ref, nil
+}
+
+// newClient creates a gitprovider.Client for the given provider and domain.
+func newClient(opts globalOptions, domain string) (gitprovider.Client, error) {
+	clientOpts := []gitprovider.ClientOption{
+		gitprovider.WithDomain(domain),
+		gitprovider.WithDestructiveAPICalls(opts.allowDestructive),
+	}
+
+	switch opts.provider {
+	case "github":
+		if opts.token != "" {
+			clientOpts = append(clientOpts, gitprovider.WithOAuth2Token(opts.token))
+		}
+		return github.NewClient(clientOpts...)
+	case "gitlab":
+		return gitlab.NewClient(opts.token, opts.tokenType, clientOpts...)
+	case "stash":
+		if !strings.Contains(domain, "://") {
+			clientOpts[0] = gitprovider.WithDomain("https://" + domain)
+		}
+		return stash.NewStashClient(opts.username, opts.token, clientOpts...)
+	default:
+		return nil, fmt.Errorf("unknown provider %q", opts.provider)
+	}
+}
+
+// getRepository returns the repository the session operates on.
+func (s *session) getRepository(ctx context.Context) (gitprovider.UserRepository, error) {
+	switch ref := s.ref.(type) {
+	case gitprovider.OrgRepositoryRef:
+		return s.client.OrgRepositories().Get(ctx, ref)
+	case gitprovider.UserRepositoryRef:
+		return s.client.UserRepositories().Get(ctx, ref)
+	default:
+		return nil, fmt.Errorf("unexpected repository reference %T", s.ref)
+	}
+}
+
+// parseFlags parses the flags of a subcommand, printing its usage to stderr on failure.
+func (s *session) parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(s.stderr)
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// printJSON writes v as indented JSON to stdout.
+func (s *session) printJSON(v interface{}) error {
+	enc := json.NewEncoder(s.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
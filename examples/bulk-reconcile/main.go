@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// bulk-reconcile makes a set of GitHub organization repositories match the desired state
+// given in a JSON file, and reports which repositories were changed. With -metrics-file, the
+// outcomes are also written in the Prometheus text format, e.g. for the textfile collector of
+// the node exporter.
+//
+// Usage:
+//
+//	GITHUB_TOKEN=<token> go run ./examples/bulk-reconcile -f repos.json [-metrics-file reconcile.prom]
+//
+// where repos.json contains e.g.:
+//
+//	[{"url": "https://github.com/my-org/my-repo", "spec": {"description": "My repo", "visibility": "private"}}]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/fluxcd/go-git-providers/github"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// repositorySpec is the desired state of a single repository.
+type repositorySpec struct {
+	URL  string                     `json:"url"`
+	Spec gitprovider.RepositoryInfo `json:"spec"`
+}
+
+// result is the outcome of reconciling a single repository.
+type result struct {
+	URL         string
+	ActionTaken bool
+	Err         error
+}
+
+func main() {
+	file := flag.String("f", "repos.json", "the file containing the desired repository states")
+	metricsFile := flag.String("metrics-file", "", "the file to write the reconcile outcome metrics to")
+	flag.Parse()
+
+	specs, err := readSpecs(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	c, err := github.NewClient(
+		gitprovider.WithOAuth2Token(os.Getenv(github.TokenVariable)),
+		gitprovider.WithDestructiveAPICalls(true),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	reg := prometheus.NewRegistry()
+	metrics, err := gitprovider.NewReconcileMetrics(reg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	errs := &gitprovider.AggregateError{}
+	for _, r := range reconcileAll(context.Background(), c, specs, metrics) {
+		errs.Add(r.URL, r.Err)
+		switch {
+		case r.Err != nil:
+			fmt.Printf("%s: failed: %v\n", r.URL, r.Err)
+		case r.ActionTaken:
+			fmt.Printf("%s: updated\n", r.URL)
+		default:
+			fmt.Printf("%s: up to date\n", r.URL)
+		}
+	}
+	if *metricsFile != "" {
+		if err := prometheus.WriteToTextfile(*metricsFile, reg); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// readSpecs reads the list of desired repository states from the given file.
+func readSpecs(file string) ([]repositorySpec, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeSpecs(f)
+}
+
+// decodeSpecs decodes and validates a list of desired repository states.
+func decodeSpecs(r io.Reader) ([]repositorySpec, error) {
+	var specs []repositorySpec
+	if err := json.NewDecoder(r).Decode(&specs); err != nil {
+		return nil, err
+	}
+	for _, s := range specs {
+		if err := s.Spec.ValidateInfo(); err != nil {
+			return nil, fmt.Errorf("invalid spec for %s: %w", s.URL, err)
+		}
+	}
+	return specs, nil
+}
+
+// reconcileAll reconciles every repository, continuing past failures so that one broken
+// repository doesn't block the others. The outcomes are counted in metrics.
+func reconcileAll(ctx context.Context, c gitprovider.Client, specs []repositorySpec, metrics *gitprovider.ReconcileMetrics) []result {
+	results := make([]result, 0, len(specs))
+	for _, s := range specs {
+		r := result{URL: s.URL}
+		ref, err := gitprovider.ParseOrgRepositoryURL(s.URL)
+		if err != nil {
+			r.Err = err
+			metrics.Observe("Repository", gitprovider.ReconcileOutcomeFailed)
+			results = append(results, r)
+			continue
+		}
+		r.ActionTaken, r.Err = metrics.Reconcile(ctx, "Repository", func(ctx context.Context) (bool, error) {
+			_, actionTaken, err := c.OrgRepositories().Reconcile(ctx, *ref, s.Spec)
+			return actionTaken, err
+		})
+		results = append(results, r)
+	}
+	return results
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// fakeClient only implements OrgRepositories, which is all reconcileAll needs.
+type fakeClient struct {
+	gitprovider.Client
+	repos *fakeOrgRepositories
+}
+
+func (c *fakeClient) OrgRepositories() gitprovider.OrgRepositoriesClient {
+	return c.repos
+}
+
+// fakeOrgRepositories reports a change for every repository not in upToDate, and fails for those in broken.
+type fakeOrgRepositories struct {
+	gitprovider.OrgRepositoriesClient
+	upToDate map[string]bool
+	broken   map[string]bool
+}
+
+func (c *fakeOrgRepositories) Reconcile(_ context.Context, r gitprovider.OrgRepositoryRef, _ gitprovider.RepositoryInfo, _ ...gitprovider.RepositoryReconcileOption) (gitprovider.OrgRepository, bool, error) {
+	if c.broken[r.RepositoryName] {
+		return nil, false, gitprovider.ErrNotFound
+	}
+	return nil, !c.upToDate[r.RepositoryName], nil
+}
+
+func Test_reconcileAll(t *testing.T) {
+	specs, err := decodeSpecs(strings.NewReader(`[
+		{"url": "https://github.com/fluxcd/flux2", "spec": {"description": "Flux v2"}},
+		{"url": "https://github.com/fluxcd/go-git-providers", "spec": {"visibility": "public"}},
+		{"url": "https://github.com/fluxcd/gone", "spec": {}},
+		{"url": "not a url", "spec": {}}
+	]`))
+	if err != nil {
+		t.Fatalf("decodeSpecs() error = %v", err)
+	}
+
+	c := &fakeClient{repos: &fakeOrgRepositories{
+		upToDate: map[string]bool{"go-git-providers": true},
+		broken:   map[string]bool{"gone": true},
+	}}
+	reg := prometheus.NewPedanticRegistry()
+	metrics, err := gitprovider.NewReconcileMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := reconcileAll(context.Background(), c, specs, metrics)
+	if len(results) != 4 {
+		t.Fatalf("reconcileAll() returned %d results, want 4", len(results))
+	}
+	if !results[0].ActionTaken || results[0].Err != nil {
+		t.Errorf("reconcileAll() = %+v, want an update", results[0])
+	}
+	if results[1].ActionTaken || results[1].Err != nil {
+		t.Errorf("reconcileAll() = %+v, want no change", results[1])
+	}
+	if !errors.Is(results[2].Err, gitprovider.ErrNotFound) {
+		t.Errorf("reconcileAll() = %+v, want %v", results[2], gitprovider.ErrNotFound)
+	}
+	if results[3].Err == nil {
+		t.Errorf("reconcileAll() = %+v, want a URL parsing error", results[3])
+	}
+
+	// The fake client doesn't record changes, so they are counted as updates
+	want := `
+# HELP gitprovider_reconcile_outcomes_total Number of Reconcile() calls, by resource (e.g. "Repository") and outcome (created, updated, unchanged or failed).
+# TYPE gitprovider_reconcile_outcomes_total counter
+gitprovider_reconcile_outcomes_total{outcome="failed",resource="Repository"} 2
+gitprovider_reconcile_outcomes_total{outcome="unchanged",resource="Repository"} 1
+gitprovider_reconcile_outcomes_total{outcome="updated",resource="Repository"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_decodeSpecs_invalid(t *testing.T) {
+	_, err := decodeSpecs(strings.NewReader(`[{"url": "https://github.com/fluxcd/flux2", "spec": {"visibility": "everyone"}}]`))
+	if err == nil {
+		t.Error("decodeSpecs() expected an error for an unknown visibility")
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// pr-bot proposes a file change to a GitHub repository: it creates a branch off the base
+// branch, commits the file to it, and opens a pull request.
+//
+// Usage:
+//
+//	GITHUB_TOKEN=<token> go run ./examples/pr-bot -repo https://github.com/my-org/my-repo \
+//		-branch update-config -path config.yaml -content-file ./config.yaml
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fluxcd/go-git-providers/github"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// change is a single-file change proposed through a pull request.
+type change struct {
+	Branch  string
+	Base    string
+	Path    string
+	Content string
+	Title   string
+}
+
+func main() {
+	repoURL := flag.String("repo", "", "the repository URL")
+	c := change{}
+	flag.StringVar(&c.Branch, "branch", "", "the branch to create for the change")
+	flag.StringVar(&c.Base, "base", "", "the branch to merge into, defaults to the default branch")
+	flag.StringVar(&c.Path, "path", "", "the path of the file to change")
+	contentFile := flag.String("content-file", "", "the file holding the new content")
+	flag.StringVar(&c.Title, "title", "Automated update", "the pull request title")
+	flag.Parse()
+
+	if err := run(context.Background(), *repoURL, *contentFile, c); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, repoURL, contentFile string, c change) error {
+	content, err := os.ReadFile(contentFile)
+	if err != nil {
+		return err
+	}
+	c.Content = string(content)
+
+	ref, err := gitprovider.ParseOrgRepositoryURL(repoURL)
+	if err != nil {
+		return err
+	}
+	client, err := github.NewClient(gitprovider.WithOAuth2Token(os.Getenv(github.TokenVariable)))
+	if err != nil {
+		return err
+	}
+	repo, err := client.OrgRepositories().Get(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	pr, err := proposeChange(ctx, repo, c)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("opened pull request %d: %s\n", pr.Get().Number, pr.Get().WebURL)
+	return nil
+}
+
+// proposeChange creates the change's branch at the head of the base branch, commits the
+// file to it, and opens a pull request into the base branch.
+func proposeChange(ctx context.Context, repo gitprovider.UserRepository, c change) (gitprovider.PullRequest, error) {
+	if c.Base == "" {
+		if branch := repo.Get().DefaultBranch; branch != nil {
+			c.Base = *branch
+		}
+	}
+
+	head, err := repo.Commits().ListPage(ctx, c.Base, 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the head of %s: %w", c.Base, err)
+	}
+	if len(head) == 0 {
+		return nil, fmt.Errorf("branch %s has no commits", c.Base)
+	}
+
+	if err := repo.Branches().Create(ctx, c.Branch, head[0].Get().Sha); err != nil {
+		return nil, fmt.Errorf("failed to create branch %s: %w", c.Branch, err)
+	}
+
+	_, err = repo.Commits().Create(ctx, c.Branch, c.Title, []gitprovider.CommitFile{
+		{Path: &c.Path, Content: &c.Content},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit %s: %w", c.Path, err)
+	}
+
+	return repo.PullRequests().Create(ctx, c.Title, c.Branch, c.Base, fmt.Sprintf("Updates `%s`.", c.Path))
+}
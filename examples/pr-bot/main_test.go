@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// fakeRepository records the calls proposeChange makes.
+type fakeRepository struct {
+	gitprovider.UserRepository
+	branches map[string]string
+	commits  map[string][]gitprovider.CommitFile
+	prs      []string
+}
+
+func (r *fakeRepository) Get() gitprovider.RepositoryInfo {
+	return gitprovider.RepositoryInfo{DefaultBranch: gitprovider.StringVar("main")}
+}
+
+func (r *fakeRepository) Commits() gitprovider.CommitClient           { return fakeCommits{r: r} }
+func (r *fakeRepository) Branches() gitprovider.BranchClient          { return fakeBranches{r: r} }
+func (r *fakeRepository) PullRequests() gitprovider.PullRequestClient { return fakePullRequests{r: r} }
+
+type fakeCommits struct {
+	gitprovider.CommitClient
+	r *fakeRepository
+}
+
+func (c fakeCommits) ListPage(_ context.Context, branch string, _, _ int, _ ...gitprovider.CommitListOption) ([]gitprovider.Commit, error) {
+	return []gitprovider.Commit{fakeCommit{sha: c.r.branches[branch]}}, nil
+}
+
+func (c fakeCommits) Create(_ context.Context, branch, _ string, files []gitprovider.CommitFile) (gitprovider.Commit, error) {
+	c.r.commits[branch] = append(c.r.commits[branch], files...)
+	return fakeCommit{sha: "new"}, nil
+}
+
+type fakeCommit struct{ sha string }
+
+func (c fakeCommit) APIObject() interface{}      { return nil }
+func (c fakeCommit) Get() gitprovider.CommitInfo { return gitprovider.CommitInfo{Sha: c.sha} }
+
+type fakeBranches struct {
+	gitprovider.BranchClient
+	r *fakeRepository
+}
+
+func (b fakeBranches) Create(_ context.Context, branch, sha string) error {
+	b.r.branches[branch] = sha
+	return nil
+}
+
+type fakePullRequests struct {
+	gitprovider.PullRequestClient
+	r *fakeRepository
+}
+
+func (p fakePullRequests) Create(_ context.Context, title, branch, base, _ string) (gitprovider.PullRequest, error) {
+	p.r.prs = append(p.r.prs, branch+"->"+base)
+	return nil, nil
+}
+
+func Test_proposeChange(t *testing.T) {
+	repo := &fakeRepository{
+		branches: map[string]string{"main": "abc123"},
+		commits:  map[string][]gitprovider.CommitFile{},
+	}
+	_, err := proposeChange(context.Background(), repo, change{
+		Branch:  "update-config",
+		Path:    "config.yaml",
+		Content: "replicas: 2\n",
+		Title:   "Scale up",
+	})
+	if err != nil {
+		t.Fatalf("proposeChange() error = %v", err)
+	}
+
+	if repo.branches["update-config"] != "abc123" {
+		t.Errorf("branch created at %q, want the head of main", repo.branches["update-config"])
+	}
+	if files := repo.commits["update-config"]; len(files) != 1 || *files[0].Path != "config.yaml" {
+		t.Errorf("committed files = %+v, want config.yaml", files)
+	}
+	if len(repo.prs) != 1 || repo.prs[0] != "update-config->main" {
+		t.Errorf("pull requests = %v, want update-config->main", repo.prs)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// webhook-receiver verifies GitHub and GitLab webhook deliveries, and normalizes push and
+// pull/merge request events into a single, provider-independent webhooks.Event, using webhooks.Handler.
+//
+// Usage:
+//
+//	WEBHOOK_SECRET=<secret> go run ./examples/webhook-receiver -addr :8080
+//
+// Point GitHub webhooks at http://<host>:8080/github, and GitLab webhooks at http://<host>:8080/gitlab.
+//
+// Deliveries larger than -max-body-bytes are rejected, and so are deliveries with an already seen
+// X-GitHub-Delivery or X-Gitlab-Event-UUID within -replay-window, after their signature was verified.
+// These headers aren't signed, so only deliveries replayed unchanged are rejected.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/gitprovider/webhooks"
+)
+
+const (
+	// defaultMaxBodyBytes is the largest delivery accepted by default. GitHub caps payloads at 25 MB,
+	// but push and pull request events are far smaller in practice.
+	defaultMaxBodyBytes = 5 << 20
+	// defaultReplayWindow is how long delivery IDs are remembered by default.
+	defaultReplayWindow = 10 * time.Minute
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "the address to listen on")
+	maxBodyBytes := flag.Int64("max-body-bytes", defaultMaxBodyBytes, "the largest accepted delivery, in bytes")
+	replayWindow := flag.Duration("replay-window", defaultReplayWindow, "how long delivery IDs are remembered to reject replays")
+	flag.Parse()
+
+	secret := []byte(os.Getenv("WEBHOOK_SECRET"))
+	if len(secret) == 0 {
+		log.Fatal("WEBHOOK_SECRET must be set to the secret configured in the webhooks")
+	}
+	handle := func(e *webhooks.Event) {
+		b, _ := json.Marshal(e)
+		log.Println(string(b))
+	}
+
+	// The delivery IDs are recorded per provider, so the handlers can share the replay window
+	replay := webhooks.NewReplayWindow(*replayWindow, gitprovider.RealClock{})
+	mux := http.NewServeMux()
+	mux.Handle("/github", &webhooks.Handler{Provider: webhooks.ProviderGitHub, Secret: secret, Handle: handle, MaxBodyBytes: *maxBodyBytes, Replay: replay})
+	mux.Handle("/gitlab", &webhooks.Handler{Provider: webhooks.ProviderGitLab, Secret: secret, Handle: handle, MaxBodyBytes: *maxBodyBytes, Replay: replay})
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	log.Fatal(srv.ListenAndServe())
+}
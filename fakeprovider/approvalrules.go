@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// ApprovalRuleClient implements the gitprovider.ApprovalRuleClient interface.
+var _ gitprovider.ApprovalRuleClient = &ApprovalRuleClient{}
+
+// ApprovalRuleClient operates on the approval rules of a specific repository. The rules are
+// stored, and their required approvals reported by BranchClient.GetRules, but not enforced.
+type ApprovalRuleClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists the approval rules of the repository, sorted by name.
+func (c *ApprovalRuleClient) List(_ context.Context) ([]gitprovider.ApprovalRuleInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(repo.approvalRules))
+	for name := range repo.approvalRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rules := make([]gitprovider.ApprovalRuleInfo, 0, len(names))
+	for _, name := range names {
+		rules = append(rules, copyApprovalRuleInfo(repo.approvalRules[name]))
+	}
+	return rules, nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+//
+// The eligible approvers are the users of the rule, as the members of groups aren't known.
+func (c *ApprovalRuleClient) Reconcile(ctx context.Context, req gitprovider.ApprovalRuleInfo) (gitprovider.ApprovalRuleInfo, bool, error) {
+	// First thing, validate and default the request to ensure a valid and fully-populated object
+	// (to minimize any possible diffs between desired and actual state)
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return gitprovider.ApprovalRuleInfo{}, false, err
+	}
+	req.EligibleApprovers = append([]string(nil), req.Users...)
+
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return gitprovider.ApprovalRuleInfo{}, false, err
+	}
+	var change gitprovider.Change
+	actual, ok := repo.approvalRules[req.Name]
+	switch {
+	case !ok:
+		change = gitprovider.NewCreateChange(c.ref, req)
+	case req.Equals(actual):
+		// If the desired matches the actual state, just return the actual state
+		return copyApprovalRuleInfo(actual), false, nil
+	default:
+		change = gitprovider.NewUpdateChange(c.ref, actual, req)
+	}
+	// In dry-run mode, only report what would be created or updated
+	if c.dryRun {
+		return req, true, gitprovider.NewDryRunError(change)
+	}
+	repo.approvalRules[req.Name] = copyApprovalRuleInfo(req)
+	gitprovider.RecordChange(ctx, change, apiCallReconcileApprovalRule)
+	return req, true, nil
+}
+
+// Delete deletes the rule with the given name.
+//
+// ErrNotFound is returned if there is no rule with the name.
+func (c *ApprovalRuleClient) Delete(_ context.Context, name string) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return err
+	}
+	if _, ok := repo.approvalRules[name]; !ok {
+		return fmt.Errorf("approval rule %q: %w", name, gitprovider.ErrNotFound)
+	}
+	delete(repo.approvalRules, name)
+	return nil
+}
+
+// approvalRuleApplies returns whether the rule applies to the branch, i.e. it has no branches,
+// or the branch is one of them.
+func approvalRuleApplies(rule gitprovider.ApprovalRuleInfo, branch string) bool {
+	if len(rule.Branches) == 0 {
+		return true
+	}
+	for _, b := range rule.Branches {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// copyApprovalRuleInfo returns a copy of rule that doesn't share the slices.
+func copyApprovalRuleInfo(rule gitprovider.ApprovalRuleInfo) gitprovider.ApprovalRuleInfo {
+	rule.Users = append([]string(nil), rule.Users...)
+	rule.Groups = append([]string(nil), rule.Groups...)
+	rule.Branches = append([]string(nil), rule.Branches...)
+	rule.EligibleApprovers = append([]string(nil), rule.EligibleApprovers...)
+	rule.Default()
+	return rule
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// BadgeClient implements the gitprovider.BadgeClient interface.
+var _ gitprovider.BadgeClient = &BadgeClient{}
+
+// BadgeClient generates the URLs of the status badges of a specific repository, in the format
+// of GitLab badges. The fake server has no releases, so release badges are never found.
+type BadgeClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// URL returns the URL of the badge image described by req.
+//
+// ErrNotFound is returned if the branch doesn't exist, and for release badges.
+func (c *BadgeClient) URL(_ context.Context, req gitprovider.BadgeRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return "", err
+	}
+	if req.Kind == gitprovider.BadgeKindRelease {
+		return "", fmt.Errorf("no release in repository %s: %w", c.ref.String(), gitprovider.ErrNotFound)
+	}
+	branch := req.Branch
+	if len(branch) == 0 {
+		branch = *repo.info.DefaultBranch
+	}
+	if _, ok := repo.branches[branch]; !ok {
+		return "", fmt.Errorf("branch %q: %w", branch, gitprovider.ErrNotFound)
+	}
+	return fmt.Sprintf("%s/badges/%s/pipeline.svg", c.ref.String(), branch), nil
+}
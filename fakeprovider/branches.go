@@ -0,0 +1,210 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// BranchClient implements the gitprovider.BranchClient interface.
+var _ gitprovider.BranchClient = &BranchClient{}
+
+// BranchClient operates on the branches for a specific repository.
+type BranchClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Create creates a branch pointing to the given commit SHA, or branch.
+//
+// ErrAlreadyExists is returned if the branch exists, and ErrNotFound if the commit doesn't.
+func (c *BranchClient) Create(_ context.Context, branch, sha string) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return err
+	}
+	if _, ok := repo.branches[branch]; ok {
+		return fmt.Errorf("branch %q: %w", branch, gitprovider.ErrAlreadyExists)
+	}
+	cm, err := repo.resolve(sha)
+	if err != nil {
+		return err
+	}
+	repo.branches[branch] = cm.info.Sha
+	return nil
+}
+
+// Delete deletes the branch with the given name.
+//
+// ErrNotFound is returned if the branch doesn't exist, and ErrInvalidArgument for the default branch.
+func (c *BranchClient) Delete(_ context.Context, branch string) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return err
+	}
+	if _, ok := repo.branches[branch]; !ok {
+		return fmt.Errorf("branch %q: %w", branch, gitprovider.ErrNotFound)
+	}
+	if branch == *repo.info.DefaultBranch {
+		return fmt.Errorf("cannot delete the default branch %q: %w", branch, gitprovider.ErrInvalidArgument)
+	}
+	delete(repo.branches, branch)
+	return nil
+}
+
+// List lists all branches in the repository, sorted by name. Branches are never protected.
+func (c *BranchClient) List(_ context.Context) ([]gitprovider.Branch, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(repo.branches))
+	for name := range repo.branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	branches := make([]gitprovider.Branch, 0, len(names))
+	for _, name := range names {
+		branches = append(branches, &branchResource{info: gitprovider.BranchInfo{
+			Name:    name,
+			Sha:     repo.branches[name],
+			Default: name == *repo.info.DefaultBranch,
+		}})
+	}
+	return branches, nil
+}
+
+// SetDefault makes the branch with the given name the default branch of the repository.
+//
+// ErrNotFound is returned if the branch doesn't exist.
+func (c *BranchClient) SetDefault(_ context.Context, branch string) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return err
+	}
+	if _, ok := repo.branches[branch]; !ok {
+		return fmt.Errorf("branch %q: %w", branch, gitprovider.ErrNotFound)
+	}
+	repo.info.DefaultBranch = gitprovider.StringVar(branch)
+	repo.updatedAt = c.s.now()
+	return nil
+}
+
+// GetRules returns the required approvals of the approval rules which apply to the branch, as
+// branches are never protected otherwise.
+//
+// ErrNotFound is returned if the branch doesn't exist.
+func (c *BranchClient) GetRules(_ context.Context, branch string) (gitprovider.BranchRules, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return gitprovider.BranchRules{}, err
+	}
+	if _, ok := repo.branches[branch]; !ok {
+		return gitprovider.BranchRules{}, fmt.Errorf("branch %q: %w", branch, gitprovider.ErrNotFound)
+	}
+	rules := gitprovider.BranchRules{}
+	for _, rule := range repo.approvalRules {
+		if approvalRuleApplies(rule, branch) && rule.RequiredApprovals > rules.RequiredApprovals {
+			rules.RequiredApprovals = rule.RequiredApprovals
+		}
+	}
+	return rules, nil
+}
+
+// SyncForkWithUpstream fast-forwards the branch of this fork to the branch of its parent. The
+// branch is created if the fork doesn't have it, and the returned Operation is done.
+//
+// ErrInvalidArgument is returned if the repository isn't a fork, ErrNotFound if the parent doesn't
+// have the branch, and ErrForkDiverged if the branch of the fork can't be fast-forwarded.
+func (c *BranchClient) SyncForkWithUpstream(_ context.Context, branch string) (gitprovider.ForkSyncResult, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, err
+	}
+	if repo.info.Fork == nil {
+		return gitprovider.ForkSyncResult{}, fmt.Errorf("repository %s isn't a fork: %w", c.ref.String(), gitprovider.ErrInvalidArgument)
+	}
+	upstream, err := c.s.getRepository(repo.info.Fork.Parent)
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, err
+	}
+	upstreamSHA, ok := upstream.branches[branch]
+	if !ok {
+		return gitprovider.ForkSyncResult{}, fmt.Errorf("upstream branch %q: %w", branch, gitprovider.ErrNotFound)
+	}
+	result := gitprovider.ForkSyncResult{
+		Branch:    branch,
+		Strategy:  gitprovider.ForkSyncStrategyFastForward,
+		Operation: gitprovider.NewCompletedOperation(gitprovider.OperationStatus{State: gitprovider.OperationStateSucceeded}),
+	}
+
+	// Forks share their Git objects with the upstream repository
+	repo.copyCommits(upstream)
+	if forkSHA, ok := repo.branches[branch]; ok {
+		base := repo.mergeBase(repo.commits[forkSHA], repo.commits[upstreamSHA])
+		switch {
+		case base != nil && base.info.Sha == upstreamSHA:
+			// The branch has all the upstream commits already
+			result.Sha = forkSHA
+			return result, nil
+		case base == nil || base.info.Sha != forkSHA:
+			return gitprovider.ForkSyncResult{}, fmt.Errorf("branch %q: %w", branch, gitprovider.ErrForkDiverged)
+		}
+	}
+	repo.branches[branch] = upstreamSHA
+	result.Sha = upstreamSHA
+	result.Updated = true
+	return result, nil
+}
+
+var _ gitprovider.Branch = &branchResource{}
+
+type branchResource struct {
+	info gitprovider.BranchInfo
+}
+
+func (b *branchResource) Get() gitprovider.BranchInfo {
+	return b.info
+}
+
+// APIObject returns a *gitprovider.BranchInfo, as there is no provider-specific type.
+func (b *branchResource) APIObject() interface{} {
+	return &b.info
+}
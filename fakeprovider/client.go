@@ -0,0 +1,279 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakeprovider implements the gitprovider.Client API with in-memory state, for unit
+// testing code built on gitprovider without network access or mocks. Repositories, commits,
+// branches, files, pull requests, deploy keys, team access, milestones and merge queues are
+// created and read through the API like on a real provider; organizations and teams, which the
+// API can't create, are seeded with Server.AddOrganization and Server.AddTeam.
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+const (
+	// ProviderID is the provider ID of the fake provider.
+	ProviderID = gitprovider.ProviderID("fake")
+
+	// DefaultDomain is the domain of the Server created by NewClient, unless set with WithDomain.
+	DefaultDomain = "fake.example.com"
+)
+
+func init() {
+	gitprovider.RegisterClientFactory(ProviderID, func(baseURL *url.URL, _, _ string, optFns ...gitprovider.ClientOption) (gitprovider.Client, error) {
+		if baseURL != nil {
+			optFns = append(optFns, gitprovider.WithDomain(baseURL.Host))
+		}
+		c, err := NewClient(optFns...)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+}
+
+// NewClient creates a client for a new, empty Server, see Server.NewClient. The domain of the
+// server is set with WithDomain, DefaultDomain otherwise.
+func NewClient(optFns ...gitprovider.ClientOption) (*Client, error) {
+	opts, err := gitprovider.MakeClientOptions(optFns...)
+	if err != nil {
+		return nil, err
+	}
+	domain := DefaultDomain
+	if opts.Domain != nil {
+		domain = *opts.Domain
+	}
+	return NewServer(domain, opts.Clock).NewClient(optFns...)
+}
+
+// NewClient creates a client for the server. Like for the other providers, destructive calls
+// are disallowed unless enabled with WithDestructiveAPICalls, and Reconcile calls only report
+// the changes with WithDryRun. The options configuring the HTTP transport are ignored.
+//
+// ErrDomainUnsupported is returned if WithDomain is set to another domain than the server's.
+func (s *Server) NewClient(optFns ...gitprovider.ClientOption) (*Client, error) {
+	opts, err := gitprovider.MakeClientOptions(optFns...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Domain != nil && *opts.Domain != s.domain {
+		return nil, fmt.Errorf("domain %q not served by this server: %w", *opts.Domain, gitprovider.ErrDomainUnsupported)
+	}
+
+	ctx := &clientContext{
+		s:                     s,
+		domain:                s.domain,
+		destructiveActions:    opts.EnableDestructiveAPICalls != nil && *opts.EnableDestructiveAPICalls,
+		dryRun:                opts.DryRun != nil && *opts.DryRun,
+		optimisticConcurrency: opts.OptimisticConcurrency != nil && *opts.OptimisticConcurrency,
+		repoDefaults:          opts.OrgRepositoryDefaults,
+		generations:           gitprovider.NewGenerationTracker(),
+	}
+	return &Client{
+		clientContext: ctx,
+		orgs:          &OrganizationsClient{clientContext: ctx},
+		orgRepos:      &OrgRepositoriesClient{clientContext: ctx},
+		userRepos:     &UserRepositoriesClient{clientContext: ctx},
+		search:        &SearchClient{clientContext: ctx},
+		userKeys:      &UserKeysClient{clientContext: ctx},
+		invitations:   &InvitationsClient{clientContext: ctx},
+		events:        &EventsClient{clientContext: ctx},
+		tokens:        &TokensClient{clientContext: ctx},
+	}, nil
+}
+
+type clientContext struct {
+	s                     *Server
+	domain                string
+	destructiveActions    bool
+	dryRun                bool
+	optimisticConcurrency bool
+	repoDefaults          gitprovider.OrgRepositoryDefaults
+	generations           *gitprovider.GenerationTracker
+}
+
+// Client implements the gitprovider.Client interface.
+var _ gitprovider.Client = &Client{}
+
+// Client is an interface that allows talking to a Git provider.
+type Client struct {
+	*clientContext
+
+	orgs        *OrganizationsClient
+	orgRepos    *OrgRepositoriesClient
+	userRepos   *UserRepositoriesClient
+	search      *SearchClient
+	userKeys    *UserKeysClient
+	invitations *InvitationsClient
+	events      *EventsClient
+	tokens      *TokensClient
+}
+
+// SupportedDomain returns the domain of the server.
+func (c *Client) SupportedDomain() string {
+	return c.domain
+}
+
+// ProviderID returns the provider ID "fake".
+func (c *Client) ProviderID() gitprovider.ProviderID {
+	return ProviderID
+}
+
+// Raw returns the *Server the client operates on.
+func (c *Client) Raw() interface{} {
+	return c.s
+}
+
+// Server returns the Server the client operates on.
+func (c *Client) Server() *Server {
+	return c.s
+}
+
+// Organizations returns the OrganizationsClient handling sets of organizations.
+func (c *Client) Organizations() gitprovider.OrganizationsClient {
+	return c.orgs
+}
+
+// OrgRepositories returns the OrgRepositoriesClient handling sets of repositories in an organization.
+func (c *Client) OrgRepositories() gitprovider.OrgRepositoriesClient {
+	return c.orgRepos
+}
+
+// UserRepositories returns the UserRepositoriesClient handling sets of repositories for a user.
+func (c *Client) UserRepositories() gitprovider.UserRepositoriesClient {
+	return c.userRepos
+}
+
+// Search returns the SearchClient searching for repositories and code.
+func (c *Client) Search() gitprovider.SearchClient {
+	return c.search
+}
+
+// UserKeys returns the UserKeysClient handling the SSH and GPG keys of the authenticated user.
+func (c *Client) UserKeys() gitprovider.UserKeysClient {
+	return c.userKeys
+}
+
+// Invitations returns the InvitationsClient handling the repository invitations of the authenticated user.
+func (c *Client) Invitations() gitprovider.InvitationsClient {
+	return c.invitations
+}
+
+// Events returns the EventsClient listing the activity of repositories and organizations.
+func (c *Client) Events() gitprovider.EventsClient {
+	return c.events
+}
+
+// Tokens returns the TokensClient handling the access tokens of repositories and organizations.
+func (c *Client) Tokens() gitprovider.TokensClient {
+	return c.tokens
+}
+
+// HasTokenPermission returns true, the fake client has all permissions.
+func (c *Client) HasTokenPermission(_ context.Context, _ gitprovider.TokenPermission) (bool, error) {
+	return true, nil
+}
+
+// AuthenticatedUserID returns the identity of the authenticated user, see Server.SetUserLogin.
+// The login is used as the ID.
+func (c *Client) AuthenticatedUserID(_ context.Context) (gitprovider.Identity, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	return gitprovider.Identity{ID: c.s.userLogin, Login: c.s.userLogin}, nil
+}
+
+// TokenInfo returns the authenticated user, see Server.SetUserLogin, and the scopes and expiry set
+// with Server.SetTokenInfo.
+func (c *Client) TokenInfo(ctx context.Context) (gitprovider.TokenInfo, error) {
+	identity, err := c.AuthenticatedUserID(ctx)
+	if err != nil {
+		return gitprovider.TokenInfo{}, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	info := gitprovider.TokenInfo{Principal: identity, ExpiresAt: c.s.tokenExpiresAt}
+	if c.s.tokenScopes != nil {
+		info.Scopes = append([]string{}, c.s.tokenScopes...)
+		info.ScopesKnown = true
+	}
+	return info, nil
+}
+
+// ValidatePermissions checks that the repositories exist: the authenticated user may perform any
+// operation on them.
+func (c *Client) ValidatePermissions(ctx context.Context, needs ...gitprovider.Permission) error {
+	return gitprovider.CheckPermissions(ctx, needs, func(_ context.Context, ref gitprovider.RepositoryRef) (gitprovider.RepositoryAccess, error) {
+		if err := c.validateRef("ref", ref); err != nil {
+			return gitprovider.RepositoryAccess{}, err
+		}
+		c.s.mu.Lock()
+		defer c.s.mu.Unlock()
+		if _, err := c.s.getRepository(ref); err != nil {
+			return gitprovider.RepositoryAccess{}, err
+		}
+		return gitprovider.RepositoryAccess{Permission: gitprovider.RepositoryPermissionAdmin}, nil
+	})
+}
+
+// IsOwnAction returns true if the event was caused by the authenticated user.
+func (c *Client) IsOwnAction(ctx context.Context, event gitprovider.ActorEvent) (bool, error) {
+	identity, err := c.AuthenticatedUserID(ctx)
+	if err != nil {
+		return false, err
+	}
+	return identity.IsActor(event.EventActor()), nil
+}
+
+// SupportedFeatures returns all the features, as the fake provider implements the whole API.
+func (c *Client) SupportedFeatures() []gitprovider.Feature {
+	return gitprovider.KnownFeatures()
+}
+
+// Extension returns the extension registered under name for the fake provider, or nil.
+func (c *Client) Extension(name string) interface{} {
+	return gitprovider.LookupExtension(c, name)
+}
+
+// The API calls reported with the changes of Reconcile calls, see gitprovider.ChangeAction.
+const (
+	apiCallCreateRepository      = "create repository"
+	apiCallUpdateRepository      = "update repository"
+	apiCallCreateTeamAccess      = "create team access"
+	apiCallUpdateTeamAccess      = "update team access"
+	apiCallCreateDeployKey       = "create deploy key"
+	apiCallUpdateDeployKey       = "update deploy key"
+	apiCallRotateDeployKey       = "rotate deploy key"
+	apiCallReconcileEnvironment  = "reconcile environment"
+	apiCallReconcileApprovalRule = "reconcile approval rule"
+	apiCallCreateTagProtection   = "create tag protection"
+)
+
+// validateRef makes sure the reference is valid, and of the domain of the client.
+func (c *clientContext) validateRef(name string, ref gitprovider.IdentityRef) error {
+	if err := validation.ValidateTargets(name, ref); err != nil {
+		return err
+	}
+	if ref.GetDomain() != c.domain {
+		return fmt.Errorf("domain %q not supported by this client: %w", ref.GetDomain(), gitprovider.ErrDomainUnsupported)
+	}
+	return nil
+}
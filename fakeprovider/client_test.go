@@ -0,0 +1,1147 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/gitprovider/testutils"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+func newTestRepo(t *testing.T, c *Client) gitprovider.UserRepository {
+	t.Helper()
+	ref := gitprovider.UserRepositoryRef{
+		UserRef:        gitprovider.UserRef{Domain: DefaultDomain, UserLogin: DefaultUserLogin},
+		RepositoryName: "repo",
+	}
+	repo, err := c.UserRepositories().Create(context.Background(), ref, gitprovider.RepositoryInfo{}, &gitprovider.RepositoryCreateOptions{
+		AutoInit: gitprovider.BoolVar(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestRepositoryLifecycle(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	if _, err := c.UserRepositories().Create(ctx, repo.Repository().(gitprovider.UserRepositoryRef), gitprovider.RepositoryInfo{}); !errors.Is(err, gitprovider.ErrAlreadyExists) {
+		t.Fatalf("Create() error = %v, want ErrAlreadyExists", err)
+	}
+	if got := *repo.Get().DefaultBranch; got != "main" {
+		t.Fatalf("DefaultBranch = %q, want main", got)
+	}
+
+	if err := repo.Branches().Create(ctx, "feature", "main"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Commits().Create(ctx, "feature", "Add app", []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar("app/config.yaml"), Content: gitprovider.StringVar("replicas: 2\n")},
+		{Path: gitprovider.StringVar("README.md"), Content: nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	pr, err := repo.PullRequests().Create(ctx, "Add app", "feature", "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.PullRequests().Merge(ctx, pr.Get().Number, gitprovider.MergeMethodSquash, ""); err != nil {
+		t.Fatal(err)
+	}
+	if pr, _ := repo.PullRequests().Get(ctx, pr.Get().Number); !pr.Get().Merged {
+		t.Error("pull request not merged")
+	}
+
+	files, err := repo.Files().Get(ctx, "app", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || *files[0].Path != "app/config.yaml" || *files[0].Content != "replicas: 2\n" {
+		t.Errorf("Files().Get() = %v", files)
+	}
+	if _, _, err := repo.Files().Open(ctx, "README.md", "main"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Open() error = %v, want ErrNotFound", err)
+	}
+	_, info, err := repo.Files().Open(ctx, "app/config.yaml", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// git hash-object of "replicas: 2\n"
+	if want := "eb5c26739ea8767a00de3d6e1932c340ce323472"; info.SHA != want {
+		t.Errorf("SHA = %q, want %q", info.SHA, want)
+	}
+
+	commits, err := repo.Commits().ListPage(ctx, "main", 10, 1, &gitprovider.CommitListOptions{
+		Path:      gitprovider.StringVar("app"),
+		WithStats: gitprovider.BoolVar(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 || commits[0].Get().Message != "Add app (#1)" || commits[0].Get().Stats.Additions != 1 {
+		t.Errorf("ListPage() = %+v", commits)
+	}
+	if info := commits[0].Get(); len(info.Parents) != 1 || info.Committer != DefaultUserLogin {
+		t.Errorf("ListPage() parents = %v, committer = %q", info.Parents, info.Committer)
+	}
+
+	archive, err := c.UserRepositories().DownloadArchive(ctx, repo.Repository().(gitprovider.UserRepositoryRef), "", gitprovider.ArchiveFormatTarGz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	if got, want := tarNames(t, archive), []string{"app/config.yaml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("archive = %v, want %v", got, want)
+	}
+
+	if err := repo.Delete(ctx); !errors.Is(err, gitprovider.ErrDestructiveCallDisallowed) {
+		t.Errorf("Delete() error = %v, want ErrDestructiveCallDisallowed", err)
+	}
+}
+
+func TestPullRequestMergeConflict(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	if err := repo.Branches().Create(ctx, "feature", "main"); err != nil {
+		t.Fatal(err)
+	}
+	for _, branch := range []string{"main", "feature"} {
+		if _, err := repo.Commits().Create(ctx, branch, "Edit README", []gitprovider.CommitFile{
+			{Path: gitprovider.StringVar("README.md"), Content: gitprovider.StringVar(branch)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pr, err := repo.PullRequests().Create(ctx, "Edit README", "feature", "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.MergeQueue().Enqueue(ctx, pr.Get().Number); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.PullRequests().Merge(ctx, pr.Get().Number, gitprovider.MergeMethodMerge, ""); !errors.Is(err, gitprovider.ErrInvalidArgument) || !errors.Is(err, gitprovider.ErrMergeConflict) {
+		t.Fatalf("Merge() error = %v, want ErrInvalidArgument and ErrMergeConflict", err)
+	}
+	entries, err := repo.MergeQueue().List(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Position != 1 || entries[0].State != gitprovider.MergeQueueStateQueued {
+		t.Errorf("MergeQueue().List() = %+v", entries)
+	}
+}
+
+func TestPullRequestClose(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	if err := repo.Branches().Create(ctx, "feature", "main"); err != nil {
+		t.Fatal(err)
+	}
+	pr, err := repo.PullRequests().Create(ctx, "Feature", "feature", "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	number := pr.Get().Number
+	if err := repo.PullRequests().Close(ctx, number); err != nil {
+		t.Fatal(err)
+	}
+	pr, err = repo.PullRequests().Get(ctx, number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := pr.Get(); !info.Closed || info.Merged {
+		t.Errorf("Get() = %+v, want closed and not merged", info)
+	}
+	if err := repo.PullRequests().Close(ctx, number); !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Errorf("Close() error = %v, want ErrInvalidArgument", err)
+	}
+	if err := repo.PullRequests().Merge(ctx, number, gitprovider.MergeMethodMerge, ""); !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Errorf("Merge() error = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestDeployKeyReconcile(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(DefaultDomain, nil)
+	c, err := s.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	req := gitprovider.DeployKeyInfo{Name: "flux", Key: []byte("ssh-ed25519 AAAA")}
+
+	dryRunClient, err := s.NewClient(gitprovider.WithDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dryRunRepo, err := dryRunClient.UserRepositories().Get(ctx, repo.Repository().(gitprovider.UserRepositoryRef))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dryRunErr *gitprovider.DryRunError
+	if _, _, err := dryRunRepo.DeployKeys().Reconcile(ctx, req); !errors.As(err, &dryRunErr) {
+		t.Fatalf("Reconcile() error = %v, want DryRunError", err)
+	}
+
+	for i, wantAction := range []bool{true, false} {
+		_, actionTaken, err := repo.DeployKeys().Reconcile(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actionTaken != wantAction {
+			t.Errorf("Reconcile() #%d actionTaken = %v, want %v", i, actionTaken, wantAction)
+		}
+	}
+	req.ReadOnly = gitprovider.BoolVar(false)
+	if _, actionTaken, err := repo.DeployKeys().Reconcile(ctx, req); err != nil || !actionTaken {
+		t.Fatalf("Reconcile() = %v, %v, want update", actionTaken, err)
+	}
+	key, err := repo.DeployKeys().Get(ctx, "flux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *key.Get().ReadOnly {
+		t.Error("deploy key is still read-only")
+	}
+
+	// Rotating keeps the read-only flag, and rotating to the same key is a no-op
+	for i, wantAction := range []bool{true, false} {
+		key, actionTaken, err := repo.DeployKeys().Rotate(ctx, "flux", []byte("ssh-ed25519 BBBB"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actionTaken != wantAction {
+			t.Errorf("Rotate() #%d actionTaken = %v, want %v", i, actionTaken, wantAction)
+		}
+		if info := key.Get(); string(info.Key) != "ssh-ed25519 BBBB" || *info.ReadOnly {
+			t.Errorf("Rotate() #%d = %+v", i, info)
+		}
+	}
+	if _, _, err := repo.DeployKeys().Rotate(ctx, "missing", []byte("ssh-ed25519 BBBB")); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Rotate() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	c, err := gitprovider.Build(ProviderID, gitprovider.BuildOptions{Domain: "git.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.SupportedDomain(); got != "git.example.com" {
+		t.Errorf("SupportedDomain() = %q, want git.example.com", got)
+	}
+}
+
+func tarNames(t *testing.T, r io.Reader) []string {
+	t.Helper()
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+}
+
+func TestCreateFromTemplateRepository(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := newTestRepo(t, c)
+	ref := gitprovider.UserRepositoryRef{
+		UserRef:        gitprovider.UserRef{Domain: DefaultDomain, UserLogin: DefaultUserLogin},
+		RepositoryName: "generated",
+	}
+
+	// The template provides the initial contents
+	if _, err := c.UserRepositories().Create(ctx, ref, gitprovider.RepositoryInfo{}, &gitprovider.RepositoryCreateOptions{
+		AutoInit:           gitprovider.BoolVar(true),
+		TemplateRepository: tmpl.Repository(),
+	}); !errors.Is(err, validation.ErrFieldInvalid) {
+		t.Fatalf("Create() error = %v, want ErrFieldInvalid", err)
+	}
+
+	repo, err := c.UserRepositories().Create(ctx, ref, gitprovider.RepositoryInfo{
+		DefaultBranch: gitprovider.StringVar("trunk"),
+	}, &gitprovider.RepositoryCreateOptions{
+		TemplateRepository: tmpl.Repository(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := repo.Files().Open(ctx, "README.md", "trunk"); err != nil {
+		t.Errorf("Open(README.md) error = %v", err)
+	}
+
+	ref.RepositoryName = "scaffolded"
+	if repo, err = c.UserRepositories().CreateFromTemplate(ctx, ref, tmpl.Repository(), gitprovider.RepositoryInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := repo.Files().Open(ctx, "README.md", "main"); err != nil {
+		t.Errorf("Open(README.md) of the repository created with CreateFromTemplate error = %v", err)
+	}
+}
+
+func TestPullRequestMergeSettings(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	if err := repo.Set(gitprovider.RepositoryInfo{
+		MergeMethods:        []gitprovider.MergeMethod{gitprovider.MergeMethodRebase},
+		DeleteBranchOnMerge: gitprovider.BoolVar(true),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Branches().Create(ctx, "feature", "main"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Commits().Create(ctx, "feature", "Add app", []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar("app/config.yaml"), Content: gitprovider.StringVar("replicas: 2\n")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	pr, err := repo.PullRequests().Create(ctx, "Add app", "feature", "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.PullRequests().Merge(ctx, pr.Get().Number, gitprovider.MergeMethodMerge, ""); !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Fatalf("Merge() error = %v, want ErrInvalidArgument", err)
+	}
+	if err := repo.PullRequests().Merge(ctx, pr.Get().Number, gitprovider.MergeMethodRebase, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err := repo.Commits().ListPage(ctx, "main", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 || commits[0].Get().Message != "Add app" {
+		t.Errorf("ListPage() = %+v", commits)
+	}
+	branches, err := repo.Branches().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branches) != 1 {
+		t.Errorf("Branches().List() = %+v, want only main", branches)
+	}
+}
+
+func TestArchiveRepository(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	files := []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar("README.md"), Content: gitprovider.StringVar("deprecated")},
+	}
+
+	req := repo.Get()
+	req.Archived = gitprovider.BoolVar(true)
+	archived, actionTaken, err := c.UserRepositories().Reconcile(ctx, repo.Repository().(gitprovider.UserRepositoryRef), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !actionTaken || !req.Equals(archived.Get()) {
+		t.Errorf("Reconcile() = %+v, %v, want %+v, true", archived.Get(), actionTaken, req)
+	}
+	if _, err := repo.Commits().Create(ctx, "main", "Deprecate", files); !errors.Is(err, gitprovider.ErrInvalidArgument) || !errors.Is(err, gitprovider.ErrRepositoryArchived) {
+		t.Fatalf("Create() error = %v, want ErrInvalidArgument and ErrRepositoryArchived", err)
+	}
+
+	req.Archived = gitprovider.BoolVar(false)
+	if _, _, err := c.UserRepositories().Reconcile(ctx, repo.Repository().(gitprovider.UserRepositoryRef), req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Commits().Create(ctx, "main", "Deprecate", files); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReactions(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	if err := repo.Branches().Create(ctx, "feature", "main"); err != nil {
+		t.Fatal(err)
+	}
+	pr, err := repo.PullRequests().Create(ctx, "Feature", "feature", "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject := gitprovider.ReactionSubject{Number: pr.Get().Number, PullRequest: true}
+
+	first, err := repo.Reactions().Add(ctx, subject, gitprovider.ReactionContentThumbsUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reacting twice with the same content returns the existing reaction
+	second, err := repo.Reactions().Add(ctx, subject, gitprovider.ReactionContentThumbsUp)
+	if err != nil || second != first {
+		t.Errorf("Add() = %+v, %v, want %+v", second, err, first)
+	}
+	reactions, err := repo.Reactions().List(ctx, subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users := gitprovider.ReactedUsers(reactions, gitprovider.ReactionContentThumbsUp); !reflect.DeepEqual(users, []string{DefaultUserLogin}) {
+		t.Errorf("ReactedUsers() = %v", users)
+	}
+	if err := repo.Reactions().Remove(ctx, subject, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Reactions().Remove(ctx, subject, first.ID); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Remove() error = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Reactions().Add(ctx, gitprovider.ReactionSubject{Number: subject.Number, PullRequest: true, CommentID: 42}, gitprovider.ReactionContentHeart); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Add() on a missing comment error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestListIter(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgRef := gitprovider.OrganizationRef{Domain: DefaultDomain, Organization: "fluxcd"}
+	want := []string{"a", "b", "c", "d", "e"}
+	for _, name := range want {
+		ref := gitprovider.OrgRepositoryRef{OrganizationRef: orgRef, RepositoryName: name}
+		if _, err := c.OrgRepositories().Create(ctx, ref, gitprovider.RepositoryInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The repositories span three pages
+	var got []string
+	it := c.OrgRepositories().ListIter(ctx, orgRef)
+	for it.Next() {
+		got = append(got, it.Repository().Repository().GetRepository())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListIter() = %v, want %v", got, want)
+	}
+
+	it = c.OrgRepositories().ListIter(ctx, gitprovider.OrganizationRef{Domain: "other.example.com", Organization: "fluxcd"})
+	if it.Next() || it.Err() == nil {
+		t.Errorf("ListIter() with an invalid ref didn't fail")
+	}
+}
+
+func TestEnvironments(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	req := gitprovider.EnvironmentInfo{Name: "production", Reviewers: []string{"bob", "alice"}, WaitTimer: 30}
+
+	env, actionTaken, err := repo.Environments().Reconcile(ctx, req)
+	if err != nil || !actionTaken {
+		t.Fatalf("Reconcile() = %v, %v", actionTaken, err)
+	}
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(env.Reviewers, want) {
+		t.Errorf("Reconcile() reviewers = %v, want %v", env.Reviewers, want)
+	}
+	// Reconciling the same state again is a no-op
+	if _, actionTaken, err := repo.Environments().Reconcile(ctx, req); err != nil || actionTaken {
+		t.Errorf("Reconcile() = %v, %v, want no action", actionTaken, err)
+	}
+	req.WaitTimer = 0
+	if _, actionTaken, err := repo.Environments().Reconcile(ctx, req); err != nil || !actionTaken {
+		t.Errorf("Reconcile() = %v, %v, want an update", actionTaken, err)
+	}
+	envs, err := repo.Environments().List(ctx)
+	if err != nil || len(envs) != 1 || envs[0].WaitTimer != 0 {
+		t.Errorf("List() = %+v, %v", envs, err)
+	}
+	if _, _, err := repo.Environments().Reconcile(ctx, gitprovider.EnvironmentInfo{}); !errors.Is(err, validation.ErrFieldRequired) {
+		t.Errorf("Reconcile() without a name error = %v, want ErrFieldRequired", err)
+	}
+
+	if err := repo.Environments().Delete(ctx, "production"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Environments().Get(ctx, "production"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTagProtection(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	for _, pattern := range []string{"v*", "release-*", "v*"} {
+		if _, _, err := repo.TagProtection().Reconcile(ctx, gitprovider.TagProtectionInfo{Pattern: pattern}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rules, err := repo.TagProtection().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []gitprovider.TagProtectionInfo{{Pattern: "release-*"}, {Pattern: "v*"}}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("List() = %+v, want %+v", rules, want)
+	}
+	if _, _, err := repo.TagProtection().Reconcile(ctx, gitprovider.TagProtectionInfo{}); !errors.Is(err, validation.ErrFieldRequired) {
+		t.Errorf("Reconcile() without a pattern error = %v, want ErrFieldRequired", err)
+	}
+
+	if err := repo.TagProtection().Delete(ctx, "v*"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.TagProtection().Delete(ctx, "v*"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestApprovalRules(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	req := gitprovider.ApprovalRuleInfo{Name: "release", RequiredApprovals: 2, Users: []string{"bob", "alice"}, Branches: []string{"main"}}
+	if _, actionTaken, err := repo.ApprovalRules().Reconcile(ctx, req); err != nil || !actionTaken {
+		t.Fatalf("Reconcile() = %v, %v, want action", actionTaken, err)
+	}
+	resp, actionTaken, err := repo.ApprovalRules().Reconcile(ctx, req)
+	if err != nil || actionTaken {
+		t.Fatalf("Reconcile() = %v, %v, want no action", actionTaken, err)
+	}
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(resp.EligibleApprovers, want) {
+		t.Errorf("EligibleApprovers = %v, want %v", resp.EligibleApprovers, want)
+	}
+
+	rules, err := repo.Branches().GetRules(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.RequiredApprovals != 2 {
+		t.Errorf("GetRules().RequiredApprovals = %d, want 2", rules.RequiredApprovals)
+	}
+	if _, _, err := repo.ApprovalRules().Reconcile(ctx, gitprovider.ApprovalRuleInfo{Name: "invalid", RequiredApprovals: -1}); !errors.Is(err, validation.ErrFieldInvalid) {
+		t.Errorf("Reconcile() with negative approvals error = %v, want ErrFieldInvalid", err)
+	}
+
+	if err := repo.ApprovalRules().Delete(ctx, "release"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ApprovalRules().Delete(ctx, "release"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestListFiltered(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgRef := gitprovider.OrganizationRef{Domain: DefaultDomain, Organization: "fluxcd"}
+	for name, visibility := range map[string]gitprovider.RepositoryVisibility{
+		"flux-system": gitprovider.RepositoryVisibilityPrivate,
+		"flux-public": gitprovider.RepositoryVisibilityPublic,
+		"podinfo":     gitprovider.RepositoryVisibilityPrivate,
+	} {
+		ref := gitprovider.OrgRepositoryRef{OrganizationRef: orgRef, RepositoryName: name}
+		if _, err := c.OrgRepositories().Create(ctx, ref, gitprovider.RepositoryInfo{Visibility: gitprovider.RepositoryVisibilityVar(visibility)}, &gitprovider.RepositoryCreateOptions{AutoInit: gitprovider.BoolVar(true)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repos, err := c.OrgRepositories().List(ctx, orgRef, &gitprovider.RepositoryListOptions{
+		NamePrefix: gitprovider.StringVar("flux-"),
+		Visibility: gitprovider.RepositoryVisibilityVar(gitprovider.RepositoryVisibilityPrivate),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].Repository().GetRepository() != "flux-system" {
+		t.Errorf("List() = %v, want only flux-system", repos)
+	}
+
+	// No commits were made after the future
+	future := time.Now().Add(time.Hour)
+	repos, err = c.OrgRepositories().List(ctx, orgRef, &gitprovider.RepositoryListOptions{ActiveSince: &future})
+	if err != nil || len(repos) != 0 {
+		t.Errorf("List() = %v, %v, want no repositories", repos, err)
+	}
+
+	var names []string
+	it := c.OrgRepositories().ListIter(ctx, orgRef, &gitprovider.RepositoryListOptions{NamePrefix: gitprovider.StringVar("flux-")})
+	for it.Next() {
+		names = append(names, it.Repository().Repository().GetRepository())
+	}
+	if err := it.Err(); err != nil || !reflect.DeepEqual(names, []string{"flux-public", "flux-system"}) {
+		t.Errorf("ListIter() = %v, %v, want flux-public and flux-system", names, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgRef := gitprovider.OrganizationRef{Domain: DefaultDomain, Organization: "fluxcd"}
+	for name, topics := range map[string][]string{
+		"flux2":   {"flux", "gitops"},
+		"podinfo": {"demo"},
+	} {
+		ref := gitprovider.OrgRepositoryRef{OrganizationRef: orgRef, RepositoryName: name}
+		if _, err := c.OrgRepositories().Create(ctx, ref, gitprovider.RepositoryInfo{Topics: topics}, &gitprovider.RepositoryCreateOptions{AutoInit: gitprovider.BoolVar(true)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repos, err := c.Search().Repositories(ctx, gitprovider.SearchQuery{Owner: orgRef, Topics: []string{"gitops"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].Repository.GetRepository() != "flux2" {
+		t.Errorf("Repositories() = %+v, want only flux2", repos)
+	}
+	if repos, err := c.Search().Repositories(ctx, gitprovider.SearchQuery{Text: "POD"}); err != nil || len(repos) != 1 {
+		t.Errorf("Repositories() = %+v, %v, want only podinfo", repos, err)
+	}
+
+	// The initial commits have a README.md with the repository name
+	files, err := c.Search().Code(ctx, gitprovider.SearchQuery{Text: "podinfo", Filename: "README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != "README.md" || files[0].Repository.GetRepository() != "podinfo" {
+		t.Errorf("Code() = %+v, want the README.md of podinfo", files)
+	}
+	if _, err := c.Search().Code(ctx, gitprovider.SearchQuery{}); !errors.Is(err, validation.ErrFieldRequired) {
+		t.Errorf("Code() without text error = %v, want ErrFieldRequired", err)
+	}
+}
+
+func TestBadges(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+
+	badgeURL, err := repo.Badges().URL(ctx, gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindPipeline})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://fake.example.com/fake-user/repo/badges/main/pipeline.svg"; badgeURL != want {
+		t.Errorf("URL() = %q, want %q", badgeURL, want)
+	}
+	if _, err := repo.Badges().URL(ctx, gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindPipeline, Branch: "missing"}); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("URL() for a missing branch error = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Badges().URL(ctx, gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindRelease}); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("URL() for a release error = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Badges().URL(ctx, gitprovider.BadgeRequest{Kind: "coverage"}); !errors.Is(err, validation.ErrFieldEnumInvalid) {
+		t.Errorf("URL() for an unknown kind error = %v, want ErrFieldEnumInvalid", err)
+	}
+}
+
+func TestCommitComments(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	commits, err := repo.Commits().ListPage(ctx, "main", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha := commits[0].Get().Sha
+
+	comment, err := repo.Commits().CreateComment(ctx, sha[:7], "attested")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments, err := repo.Commits().ListComments(ctx, sha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []gitprovider.CommitCommentInfo{comment}; !reflect.DeepEqual(comments, want) {
+		t.Errorf("ListComments() = %+v, want %+v", comments, want)
+	}
+	if _, err := repo.Commits().ListComments(ctx, "0000000"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("ListComments() for a missing commit error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUserKeys(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshKey, err := c.UserKeys().Add(ctx, gitprovider.UserKeyInfo{Type: gitprovider.UserKeyTypeSSH, Title: "bot", Key: "ssh-ed25519 AAAA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.UserKeys().Add(ctx, gitprovider.UserKeyInfo{Type: gitprovider.UserKeyTypeGPG, Key: "gpg"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.UserKeys().Add(ctx, gitprovider.UserKeyInfo{Type: gitprovider.UserKeyTypeSSH, Title: "other", Key: "ssh-ed25519 AAAA"}); !errors.Is(err, gitprovider.ErrAlreadyExists) {
+		t.Errorf("Add() of a key in use error = %v, want ErrAlreadyExists", err)
+	}
+
+	keys, err := c.UserKeys().List(ctx, gitprovider.UserKeyTypeSSH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []gitprovider.UserKeyInfo{sshKey}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("List() = %+v, want %+v", keys, want)
+	}
+
+	if err := c.UserKeys().Delete(ctx, gitprovider.UserKeyTypeSSH, sshKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UserKeys().Delete(ctx, gitprovider.UserKeyTypeSSH, sshKey.ID); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Delete() of a missing key error = %v, want ErrNotFound", err)
+	}
+	keys, err = c.UserKeys().List(ctx, gitprovider.UserKeyTypeGPG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 {
+		t.Errorf("List() returned %d GPG keys, want 1", len(keys))
+	}
+}
+
+func TestInvitations(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	invitations, err := c.Invitations().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invitations) != 0 {
+		t.Errorf("List() = %+v, want no invitations", invitations)
+	}
+	if err := c.Invitations().Accept(ctx, 1); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Accept() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	if _, err := repo.Commits().Create(ctx, "main", "update", []gitprovider.CommitFile{{Path: gitprovider.StringVar("a"), Content: gitprovider.StringVar("a")}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Collaborators().Add(ctx, gitprovider.CollaboratorInfo{Login: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Collaborators().Remove(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := c.Events().ListRepository(ctx, repo.Repository(), gitprovider.EventListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, string(e.Type)+" "+e.Action+" "+e.Member+e.Branch)
+	}
+	if want := []string{"member removed alice", "member added alice", "push  main"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListRepository() = %q, want %q", got, want)
+	}
+
+	events, err = c.Events().ListRepository(ctx, repo.Repository(), gitprovider.EventListOptions{Types: []gitprovider.EventType{gitprovider.EventTypePush}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Actor != DefaultUserLogin || events[0].Repository != repo.Repository() {
+		t.Errorf("ListRepository() of pushes = %+v, want a push of %s", events, DefaultUserLogin)
+	}
+}
+
+func TestSyncForkWithUpstream(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := newTestRepo(t, c)
+	forkRef := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: DefaultDomain, Organization: "bots"},
+		RepositoryName:  "repo",
+	}
+	if err := c.Server().ForkRepository(upstream.Repository(), forkRef); err != nil {
+		t.Fatal(err)
+	}
+	fork, err := c.OrgRepositories().Get(ctx, forkRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := fork.Get(); !info.IsFork() || info.Fork.Parent != upstream.Repository() {
+		t.Errorf("Get().Fork = %+v, want the upstream repository", info.Fork)
+	}
+	if _, err := upstream.Branches().SyncForkWithUpstream(ctx, "main"); !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Errorf("SyncForkWithUpstream() of the upstream error = %v, want ErrInvalidArgument", err)
+	}
+
+	result, err := fork.Branches().SyncForkWithUpstream(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated {
+		t.Errorf("SyncForkWithUpstream() = %+v, want up to date", result)
+	}
+
+	commit, err := upstream.Commits().Create(ctx, "main", "update", []gitprovider.CommitFile{{Path: gitprovider.StringVar("a"), Content: gitprovider.StringVar("a")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = fork.Branches().SyncForkWithUpstream(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, err := result.Operation.Wait(ctx); err != nil || status.State != gitprovider.OperationStateSucceeded {
+		t.Errorf("Operation.Wait() = %+v, %v, want succeeded", status, err)
+	}
+	result.Operation = nil
+	want := gitprovider.ForkSyncResult{Branch: "main", Sha: commit.Get().Sha, Updated: true, Strategy: gitprovider.ForkSyncStrategyFastForward}
+	if result != want {
+		t.Errorf("SyncForkWithUpstream() = %+v, want %+v", result, want)
+	}
+
+	// Diverge the fork
+	if _, err := fork.Commits().Create(ctx, "main", "fork", []gitprovider.CommitFile{{Path: gitprovider.StringVar("b"), Content: gitprovider.StringVar("b")}}); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := fork.Branches().SyncForkWithUpstream(ctx, "main"); err != nil || result.Updated {
+		t.Errorf("SyncForkWithUpstream() of a fork ahead = %+v, %v, want up to date", result, err)
+	}
+	if _, err := upstream.Commits().Create(ctx, "main", "upstream", []gitprovider.CommitFile{{Path: gitprovider.StringVar("c"), Content: gitprovider.StringVar("c")}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fork.Branches().SyncForkWithUpstream(ctx, "main"); !errors.Is(err, gitprovider.ErrForkDiverged) {
+		t.Errorf("SyncForkWithUpstream() error = %v, want ErrForkDiverged", err)
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	created := repo.Timestamps()
+	if created.CreatedAt == nil || created.Generation != 1 {
+		t.Fatalf("Timestamps() = %+v, want a creation time at generation 1", created)
+	}
+
+	// Get returns the same generation until the repository changes
+	stale, err := c.UserRepositories().Get(ctx, repo.Repository().(gitprovider.UserRepositoryRef))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stale.Timestamps().Generation; got != 1 {
+		t.Errorf("Generation = %d, want 1", got)
+	}
+	info := repo.Get()
+	info.Description = gitprovider.StringVar("updated")
+	if err := repo.Set(info); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.Timestamps().Generation; got != 2 {
+		t.Errorf("Generation after Update() = %d, want 2", got)
+	}
+	if got := stale.Timestamps().Generation; got != 1 {
+		t.Errorf("Generation of the object returned before Update() = %d, want 1", got)
+	}
+
+	pr, err := repo.PullRequests().Create(ctx, "title", "main", "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := pr.Timestamps(); ts.CreatedAt == nil || ts.UpdatedAt == nil || ts.Generation != 1 {
+		t.Errorf("pull request Timestamps() = %+v, want creation and update times at generation 1", ts)
+	}
+}
+
+func TestFeatureSupport(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgRef := gitprovider.OrganizationRef{Domain: DefaultDomain, Organization: "org"}
+	c.Server().AddOrganization(orgRef, gitprovider.OrganizationInfo{})
+	org, err := c.Organizations().Get(ctx, orgRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := c.OrgRepositories().Create(ctx, gitprovider.OrgRepositoryRef{OrganizationRef: orgRef, RepositoryName: "repo"}, gitprovider.RepositoryInfo{}, &gitprovider.RepositoryCreateOptions{
+		AutoInit: gitprovider.BoolVar(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := testutils.FeatureTarget{Client: c, Organization: org, Repository: repo}
+	for _, err := range testutils.CheckFeatureSupport(ctx, target) {
+		t.Error(err)
+	}
+}
+
+func TestGetWithStats(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	if _, err := repo.Commits().Create(ctx, "main", "add code", []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar("main.go"), Content: gitprovider.StringVar("package main\n")},
+		{Path: gitprovider.StringVar("build.sh"), Content: gitprovider.StringVar("#!/bin/sh\n")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.UserRepositories().Get(ctx, repo.Repository().(gitprovider.UserRepositoryRef), &gitprovider.RepositoryGetOptions{WithStats: gitprovider.BoolVar(true)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &gitprovider.RepositoryStats{
+		Languages:        []gitprovider.RepositoryLanguage{{Name: "Go", Percentage: 56.5}, {Name: "Shell", Percentage: 43.5}},
+		CommitCount:      2,
+		ContributorCount: 1,
+	}
+	if stats := got.Get().Stats; !reflect.DeepEqual(stats, want) {
+		t.Errorf("Stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	token, err := c.Tokens().Create(ctx, repo.Repository(), gitprovider.AccessTokenInfo{Name: "flux", Scopes: []string{"read_repository"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token == "" || *token.AccessLevel != gitprovider.RepositoryPermissionMaintain {
+		t.Errorf("Create() = %+v, want a maintain token with its value", token)
+	}
+	if _, err := c.Tokens().Create(ctx, repo.Repository(), gitprovider.AccessTokenInfo{Name: "invalid"}); !errors.Is(err, validation.ErrFieldRequired) {
+		t.Errorf("Create() without scopes error = %v, want ErrFieldRequired", err)
+	}
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+	rotated, err := c.Tokens().Rotate(ctx, repo.Repository(), token.ID, expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.ID == token.ID || rotated.Token == token.Token || !rotated.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Rotate() = %+v, want a new token expiring at %v", rotated, expiresAt)
+	}
+	tokens, err := c.Tokens().List(ctx, repo.Repository())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].ID != rotated.ID || tokens[0].Token != "" {
+		t.Errorf("List() = %+v, want the rotated token without its value", tokens)
+	}
+
+	if err := c.Tokens().Revoke(ctx, repo.Repository(), rotated.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tokens().Revoke(ctx, repo.Repository(), rotated.ID); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Revoke() error = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Tokens().List(ctx, gitprovider.UserRef{Domain: DefaultDomain, UserLogin: DefaultUserLogin}); !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Errorf("List() of a user error = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestTokenInfo(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(DefaultDomain, nil)
+	c, err := s.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := c.TokenInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Principal.Login != DefaultUserLogin || info.ScopesKnown {
+		t.Errorf("TokenInfo() = %+v, want the default user with unknown scopes", info)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	s.SetTokenInfo([]string{"repo"}, &expiresAt)
+	info, err = c.TokenInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := info.MissingScopes("repo", "workflow"); !reflect.DeepEqual(missing, []string{"workflow"}) || !info.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("TokenInfo() = %+v, missing %v, want the repo scope expiring at %v", info, missing, expiresAt)
+	}
+}
+
+func TestValidatePermissions(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	if err := c.ValidatePermissions(ctx, gitprovider.Permission{Scope: gitprovider.PermissionScopeRepositoryAdmin, Repository: repo.Repository()}); err != nil {
+		t.Errorf("ValidatePermissions() = %v, want nil", err)
+	}
+	missing := gitprovider.UserRepositoryRef{UserRef: gitprovider.UserRef{Domain: DefaultDomain, UserLogin: DefaultUserLogin}, RepositoryName: "missing"}
+	if err := c.ValidatePermissions(ctx, gitprovider.Permission{Scope: gitprovider.PermissionScopeContentsWrite, Repository: missing}); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("ValidatePermissions() of a missing repository = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient(gitprovider.WithOptimisticConcurrency())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t, c)
+	other, err := c.UserRepositories().Get(ctx, repo.Repository().(gitprovider.UserRepositoryRef))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Set(gitprovider.RepositoryInfo{Description: gitprovider.StringVar("theirs")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Set(gitprovider.RepositoryInfo{Description: gitprovider.StringVar("ours")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(ctx); !errors.Is(err, gitprovider.ErrConflict) {
+		t.Fatalf("Update() of a repository changed since it was read = %v, want ErrConflict", err)
+	}
+	if got := *other.Get().Description; got != "theirs" {
+		t.Errorf("Description = %q, want the concurrent change kept", got)
+	}
+	// Reconcile makes the desired state the actual state regardless
+	if actionTaken, err := repo.Reconcile(ctx); err != nil || !actionTaken {
+		t.Errorf("Reconcile() = %v, %v, want the repository updated", actionTaken, err)
+	}
+	if err := other.Update(ctx); !errors.Is(err, gitprovider.ErrConflict) {
+		t.Errorf("Update() after the Reconcile = %v, want ErrConflict", err)
+	}
+
+	// The Update calls of deploy keys are checked too
+	key, err := repo.DeployKeys().Create(ctx, gitprovider.DeployKeyInfo{Name: "flux", Key: []byte("ssh-ed25519 AAAA")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := repo.DeployKeys().Get(ctx, "flux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := otherKey.Set(gitprovider.DeployKeyInfo{Name: "flux", Key: []byte("ssh-ed25519 BBBB")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := otherKey.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := key.Set(gitprovider.DeployKeyInfo{Name: "flux", Key: []byte("ssh-ed25519 AAAA"), ReadOnly: gitprovider.BoolVar(false)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := key.Update(ctx); !errors.Is(err, gitprovider.ErrConflict) {
+		t.Errorf("Update() of a deploy key changed since it was read = %v, want ErrConflict", err)
+	}
+	if err := otherKey.Update(ctx); err != nil {
+		t.Errorf("Update() of an unchanged deploy key = %v, want nil", err)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// CollaboratorClient implements the gitprovider.CollaboratorClient interface.
+var _ gitprovider.CollaboratorClient = &CollaboratorClient{}
+
+// CollaboratorClient operates on the collaborators of a specific repository. Users are added
+// directly, so there are never pending invitations.
+type CollaboratorClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get returns the access of the collaborator with the given login.
+//
+// ErrNotFound is returned if the user isn't a collaborator.
+func (c *CollaboratorClient) Get(_ context.Context, login string) (gitprovider.CollaboratorInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return gitprovider.CollaboratorInfo{}, err
+	}
+	permission, ok := repo.collaborators[login]
+	if !ok {
+		return gitprovider.CollaboratorInfo{}, fmt.Errorf("collaborator %q: %w", login, gitprovider.ErrNotFound)
+	}
+	return gitprovider.CollaboratorInfo{Login: login, Permission: gitprovider.RepositoryPermissionVar(permission)}, nil
+}
+
+// List lists the collaborators of the repository, sorted by login.
+func (c *CollaboratorClient) List(_ context.Context) ([]gitprovider.CollaboratorInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	logins := make([]string, 0, len(repo.collaborators))
+	for login := range repo.collaborators {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+
+	collaborators := make([]gitprovider.CollaboratorInfo, 0, len(logins))
+	for _, login := range logins {
+		collaborators = append(collaborators, gitprovider.CollaboratorInfo{
+			Login:      login,
+			Permission: gitprovider.RepositoryPermissionVar(repo.collaborators[login]),
+		})
+	}
+	return collaborators, nil
+}
+
+// Add makes req.Login a collaborator, or changes the permission of an existing one. The returned
+// invitation is always nil.
+func (c *CollaboratorClient) Add(_ context.Context, req gitprovider.CollaboratorInfo) (*gitprovider.CollaboratorInvitation, error) {
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, err
+	}
+
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := repo.collaborators[req.Login]; !ok {
+		c.s.recordEvent(repo, gitprovider.Event{Type: gitprovider.EventTypeMember, Action: "added", Member: req.Login})
+	}
+	repo.collaborators[req.Login] = *req.Permission
+	return nil, nil
+}
+
+// Remove removes the collaborator with the given login.
+//
+// ErrNotFound is returned if the user isn't a collaborator.
+func (c *CollaboratorClient) Remove(_ context.Context, login string) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return err
+	}
+	if _, ok := repo.collaborators[login]; !ok {
+		return fmt.Errorf("collaborator %q: %w", login, gitprovider.ErrNotFound)
+	}
+	delete(repo.collaborators, login)
+	c.s.recordEvent(repo, gitprovider.Event{Type: gitprovider.EventTypeMember, Action: "removed", Member: login})
+	return nil
+}
+
+// ListInvitations returns an empty list, as users are added without invitations.
+func (c *CollaboratorClient) ListInvitations(_ context.Context) ([]gitprovider.CollaboratorInvitation, error) {
+	return []gitprovider.CollaboratorInvitation{}, nil
+}
+
+// CancelInvitation returns ErrNotFound, as users are added without invitations.
+func (c *CollaboratorClient) CancelInvitation(_ context.Context, id int64) error {
+	return fmt.Errorf("invitation %d: %w", id, gitprovider.ErrNotFound)
+}
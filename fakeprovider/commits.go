@@ -0,0 +1,275 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+// defaultPerPage is the page size used by CommitClient.ListPage if perPage isn't positive.
+const defaultPerPage = 30
+
+// CommitClient implements the gitprovider.CommitClient interface.
+var _ gitprovider.CommitClient = &CommitClient{}
+
+// CommitClient operates on the commits for a specific repository.
+type CommitClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// ListPage lists the commits of the branch, newest first, following the first parent of merge
+// commits. All CommitListOptions filters are applied before paginating, Author matches the login
+// of the committing user. With WithStats, the stats count the lines of the changed files.
+//
+// ErrEmptyRepository is returned if the repository doesn't have any commits yet.
+func (c *CommitClient) ListPage(_ context.Context, branch string, perPage, page int, opts ...gitprovider.CommitListOption) ([]gitprovider.Commit, error) {
+	o, err := gitprovider.MakeCommitListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	if page < 1 {
+		page = 1
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.resolve(branch)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*commit
+	for cm := head; cm != nil; cm = repo.firstParent(cm) {
+		if matchesCommitListOptions(repo, cm, o) {
+			matched = append(matched, cm)
+		}
+	}
+
+	commits := []gitprovider.Commit{}
+	for i := (page - 1) * perPage; i < len(matched) && i < page*perPage; i++ {
+		info := matched[i].info
+		if o.WithStats != nil && *o.WithStats {
+			info.Stats = diffStats(repo.parentTree(matched[i]), matched[i].tree)
+		}
+		commits = append(commits, &commitResource{info: info})
+	}
+	return commits, nil
+}
+
+// Create creates a commit on the branch, with the given files added, updated, or deleted if
+// their Content is nil.
+//
+// If the repository is empty, the initial commit is created and the branch is created pointing to it.
+// ErrNotFound is returned if the branch doesn't exist otherwise, and ErrInvalidArgument if the
+// repository is archived.
+func (c *CommitClient) Create(_ context.Context, branch, message string, files []gitprovider.CommitFile) (gitprovider.Commit, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files added: %w", gitprovider.ErrInvalidArgument)
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	if repo.info.Archived != nil && *repo.info.Archived {
+		return nil, validation.NewMultiError(
+			fmt.Errorf("repository %s is archived: %w", c.ref.String(), gitprovider.ErrInvalidArgument),
+			gitprovider.ErrRepositoryArchived,
+		)
+	}
+
+	tree := map[string]string{}
+	var parents []string
+	if len(repo.commits) != 0 {
+		sha, ok := repo.branches[branch]
+		if !ok {
+			return nil, fmt.Errorf("branch %q: %w", branch, gitprovider.ErrNotFound)
+		}
+		tree = copyTree(repo.commits[sha].tree)
+		parents = []string{sha}
+	}
+	for _, file := range files {
+		if file.Path == nil {
+			return nil, fmt.Errorf("file path is required: %w", gitprovider.ErrInvalidArgument)
+		}
+		if file.Content == nil {
+			delete(tree, *file.Path)
+			continue
+		}
+		tree[*file.Path] = *file.Content
+	}
+
+	cm := c.s.commit(repo, branch, message, parents, tree)
+	c.s.recordEvent(repo, gitprovider.Event{Type: gitprovider.EventTypePush, Branch: branch})
+	return &commitResource{info: cm.info}, nil
+}
+
+// firstParent returns the first parent of the commit, or nil for a root commit.
+func (r *repository) firstParent(c *commit) *commit {
+	if len(c.parents) == 0 {
+		return nil
+	}
+	return r.commits[c.parents[0]]
+}
+
+// parentTree returns the tree of the first parent of the commit, which is empty for a root commit.
+func (r *repository) parentTree(c *commit) map[string]string {
+	if p := r.firstParent(c); p != nil {
+		return p.tree
+	}
+	return map[string]string{}
+}
+
+func matchesCommitListOptions(repo *repository, c *commit, o gitprovider.CommitListOptions) bool {
+	if o.Author != nil && c.info.Author != *o.Author {
+		return false
+	}
+	if o.Since != nil && c.info.CreatedAt.Before(*o.Since) {
+		return false
+	}
+	if o.Until != nil && c.info.CreatedAt.After(*o.Until) {
+		return false
+	}
+	if o.Path != nil {
+		return touchesPath(repo.parentTree(c), c.tree, *o.Path)
+	}
+	return true
+}
+
+// touchesPath returns true if a file at or under path differs between the trees.
+func touchesPath(before, after map[string]string, path string) bool {
+	path = strings.Trim(path, "/")
+	under := func(p string) bool { return path == "" || p == path || strings.HasPrefix(p, path+"/") }
+	for p, content := range after {
+		if old, ok := before[p]; under(p) && (!ok || old != content) {
+			return true
+		}
+	}
+	for p := range before {
+		if _, ok := after[p]; under(p) && !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// diffStats counts the lines of the added and changed files as additions, and the lines of the
+// deleted and changed files as deletions.
+func diffStats(before, after map[string]string) *gitprovider.CommitStats {
+	stats := &gitprovider.CommitStats{}
+	for p, content := range after {
+		if old, ok := before[p]; !ok || old != content {
+			stats.Additions += countLines(content)
+			stats.Deletions += countLines(old)
+		}
+	}
+	for p, old := range before {
+		if _, ok := after[p]; !ok {
+			stats.Deletions += countLines(old)
+		}
+	}
+	stats.Total = stats.Additions + stats.Deletions
+	return stats
+}
+
+func countLines(s string) int {
+	if s == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(s, "\n"), "\n") + 1
+}
+
+// ListComments lists the comments on the commit with the given SHA, oldest first.
+//
+// ErrNotFound is returned if the commit doesn't exist.
+func (c *CommitClient) ListComments(_ context.Context, sha string) ([]gitprovider.CommitCommentInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	cm, err := c.getCommit(sha)
+	if err != nil {
+		return nil, err
+	}
+	return append([]gitprovider.CommitCommentInfo{}, cm.comments...), nil
+}
+
+// CreateComment adds a comment with the given body to the commit with the given SHA.
+//
+// ErrNotFound is returned if the commit doesn't exist.
+func (c *CommitClient) CreateComment(_ context.Context, sha, body string) (gitprovider.CommitCommentInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	cm, err := c.getCommit(sha)
+	if err != nil {
+		return gitprovider.CommitCommentInfo{}, err
+	}
+	c.s.nextID++
+	comment := gitprovider.CommitCommentInfo{
+		ID:        int64(c.s.nextID),
+		Body:      body,
+		Author:    c.s.userLogin,
+		CreatedAt: c.s.now(),
+	}
+	cm.comments = append(cm.comments, comment)
+	return comment, nil
+}
+
+// getCommit returns the commit with the given (abbreviated) SHA, or ErrNotFound.
+func (c *CommitClient) getCommit(sha string) (*commit, error) {
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	if len(sha) == 0 {
+		return nil, fmt.Errorf("commit %q: %w", sha, gitprovider.ErrNotFound)
+	}
+	return repo.resolve(sha)
+}
+
+var _ gitprovider.Commit = &commitResource{}
+
+type commitResource struct {
+	info gitprovider.CommitInfo
+}
+
+func (c *commitResource) Get() gitprovider.CommitInfo {
+	info := c.info
+	// Don't share the parents with the server state
+	info.Parents = append([]string(nil), c.info.Parents...)
+	return info
+}
+
+// APIObject returns a *gitprovider.CommitInfo, as there is no provider-specific type.
+func (c *commitResource) APIObject() interface{} {
+	return &c.info
+}
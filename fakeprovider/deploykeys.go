@@ -0,0 +1,303 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// DeployKeyClient implements the gitprovider.DeployKeyClient interface.
+var _ gitprovider.DeployKeyClient = &DeployKeyClient{}
+
+// DeployKeyClient operates on the deploy keys for a specific repository.
+type DeployKeyClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get returns the deploy key with the given name.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *DeployKeyClient) Get(_ context.Context, name string) (gitprovider.DeployKey, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := repo.deployKeys[name]
+	if !ok {
+		return nil, fmt.Errorf("deploy key %q: %w", name, gitprovider.ErrNotFound)
+	}
+	return newDeployKey(c, info), nil
+}
+
+// List lists all deploy keys of the repository, sorted by name.
+func (c *DeployKeyClient) List(_ context.Context) ([]gitprovider.DeployKey, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(repo.deployKeys))
+	for name := range repo.deployKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	keys := make([]gitprovider.DeployKey, 0, len(names))
+	for _, name := range names {
+		keys = append(keys, newDeployKey(c, repo.deployKeys[name]))
+	}
+	return keys, nil
+}
+
+// Create creates a deploy key with the given specifications.
+//
+// ErrAlreadyExists will be returned if the resource already exists.
+func (c *DeployKeyClient) Create(_ context.Context, req gitprovider.DeployKeyInfo) (gitprovider.DeployKey, error) {
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := repo.deployKeys[req.Name]; ok {
+		return nil, fmt.Errorf("deploy key %q: %w", req.Name, gitprovider.ErrAlreadyExists)
+	}
+	repo.deployKeys[req.Name] = copyDeployKeyInfo(req)
+	return newDeployKey(c, req), nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (c *DeployKeyClient) Reconcile(ctx context.Context, req gitprovider.DeployKeyInfo) (gitprovider.DeployKey, bool, error) {
+	// First thing, validate and default the request to ensure a valid and fully-populated object
+	// (to minimize any possible diffs between desired and actual state)
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, false, err
+	}
+
+	actual, err := c.Get(ctx, req.Name)
+	if err != nil {
+		// Create if not found
+		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(c.ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
+			resp, err := c.Create(ctx, req)
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateDeployKey)
+			return resp, true, nil
+		}
+		return nil, false, err
+	}
+
+	// If the desired matches the actual state, just return the actual state
+	if req.Equals(actual.Get()) {
+		return actual, false, nil
+	}
+	change := gitprovider.NewUpdateChange(c.ref, actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return actual, true, gitprovider.NewDryRunError(change)
+	}
+	if err := actual.Set(req); err != nil {
+		return actual, false, err
+	}
+	if err := actual.Update(ctx); err != nil {
+		return actual, true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateDeployKey)
+	return actual, true, nil
+}
+
+// Rotate replaces the key material of the deploy key with the given name. The fake server
+// replaces the key in place.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *DeployKeyClient) Rotate(ctx context.Context, name string, newKey []byte) (gitprovider.DeployKey, bool, error) {
+	actual, err := c.Get(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	req := actual.Get()
+	if gitprovider.EqualPublicKeys(req.Key, newKey) {
+		return actual, false, nil
+	}
+	req.Key = newKey
+	if err := req.ValidateInfo(); err != nil {
+		return nil, false, err
+	}
+	change := gitprovider.NewUpdateChange(c.ref, actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return actual, true, gitprovider.NewDryRunError(change)
+	}
+	if err := actual.Set(req); err != nil {
+		return actual, false, err
+	}
+	if err := actual.Update(ctx); err != nil {
+		return actual, true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallRotateDeployKey)
+	return actual, true, nil
+}
+
+func newDeployKey(c *DeployKeyClient, info gitprovider.DeployKeyInfo) *deployKey {
+	return &deployKey{
+		info: copyDeployKeyInfo(info),
+		read: copyDeployKeyInfo(info),
+		c:    c,
+	}
+}
+
+var _ gitprovider.DeployKey = &deployKey{}
+
+type deployKey struct {
+	info gitprovider.DeployKeyInfo
+	// read is the state of the deploy key when it was last read, checked by Update with
+	// optimistic concurrency
+	read gitprovider.DeployKeyInfo
+	c    *DeployKeyClient
+}
+
+func (dk *deployKey) Get() gitprovider.DeployKeyInfo {
+	return copyDeployKeyInfo(dk.info)
+}
+
+func (dk *deployKey) Set(info gitprovider.DeployKeyInfo) error {
+	if err := info.ValidateInfo(); err != nil {
+		return err
+	}
+	dk.info = copyDeployKeyInfo(info)
+	return nil
+}
+
+// APIObject returns a *gitprovider.DeployKeyInfo, as there is no provider-specific type.
+func (dk *deployKey) APIObject() interface{} {
+	return &dk.info
+}
+
+func (dk *deployKey) Repository() gitprovider.RepositoryRef {
+	return dk.c.ref
+}
+
+// Timestamps returns the generation of the deploy key, the server doesn't record when deploy
+// keys were created.
+func (dk *deployKey) Timestamps() gitprovider.Timestamps {
+	key := fmt.Sprintf("%s/keys/%s", dk.c.ref.String(), dk.info.Name)
+	return gitprovider.Timestamps{
+		Generation: dk.c.generations.Observe(key, gitprovider.ObjectVersion(nil, &dk.info)),
+	}
+}
+
+// Update will apply the desired state in this object to the server.
+//
+// ErrNotFound is returned if the resource does not exist.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the deploy key was
+// changed since it was read.
+func (dk *deployKey) Update(_ context.Context) error {
+	dk.c.s.mu.Lock()
+	defer dk.c.s.mu.Unlock()
+
+	repo, err := dk.c.s.getRepository(dk.c.ref)
+	if err != nil {
+		return err
+	}
+	actual, ok := repo.deployKeys[dk.info.Name]
+	if !ok {
+		return fmt.Errorf("deploy key %q: %w", dk.info.Name, gitprovider.ErrNotFound)
+	}
+	if dk.c.optimisticConcurrency {
+		object := fmt.Sprintf("%s/keys/%s", dk.c.ref.String(), dk.info.Name)
+		if err := gitprovider.CheckUnchanged(object, dk.read, actual); err != nil {
+			return err
+		}
+	}
+	info := copyDeployKeyInfo(dk.info)
+	info.Default()
+	repo.deployKeys[dk.info.Name] = info
+	dk.info = copyDeployKeyInfo(info)
+	dk.read = copyDeployKeyInfo(info)
+	return nil
+}
+
+// Delete deletes a deploy key from the repository.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (dk *deployKey) Delete(_ context.Context) error {
+	dk.c.s.mu.Lock()
+	defer dk.c.s.mu.Unlock()
+
+	repo, err := dk.c.s.getRepository(dk.c.ref)
+	if err != nil {
+		return err
+	}
+	if _, ok := repo.deployKeys[dk.info.Name]; !ok {
+		return fmt.Errorf("deploy key %q: %w", dk.info.Name, gitprovider.ErrNotFound)
+	}
+	delete(repo.deployKeys, dk.info.Name)
+	return nil
+}
+
+// Reconcile makes sure the desired state in this object (called "req" here) becomes
+// the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (dk *deployKey) Reconcile(ctx context.Context) (bool, error) {
+	actual, actionTaken, err := dk.c.Reconcile(ctx, dk.Get())
+	if err != nil {
+		return actionTaken, err
+	}
+	dk.info = actual.Get()
+	dk.read = actual.Get()
+	return actionTaken, nil
+}
+
+// copyDeployKeyInfo returns a copy of info which doesn't share the key or pointer fields.
+func copyDeployKeyInfo(info gitprovider.DeployKeyInfo) gitprovider.DeployKeyInfo {
+	c := gitprovider.DeployKeyInfo{
+		Name: info.Name,
+		Key:  append([]byte{}, info.Key...),
+	}
+	if info.ReadOnly != nil {
+		c.ReadOnly = gitprovider.BoolVar(*info.ReadOnly)
+	}
+	return c
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// EnvironmentClient implements the gitprovider.EnvironmentClient interface.
+var _ gitprovider.EnvironmentClient = &EnvironmentClient{}
+
+// EnvironmentClient operates on the deployment environments of a specific repository.
+type EnvironmentClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get returns the protection rules of the environment with the given name.
+//
+// ErrNotFound is returned if the environment doesn't exist.
+func (c *EnvironmentClient) Get(_ context.Context, name string) (gitprovider.EnvironmentInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return gitprovider.EnvironmentInfo{}, err
+	}
+	env, ok := repo.environments[name]
+	if !ok {
+		return gitprovider.EnvironmentInfo{}, fmt.Errorf("environment %q: %w", name, gitprovider.ErrNotFound)
+	}
+	return copyEnvironmentInfo(env), nil
+}
+
+// List lists the environments of the repository, sorted by name.
+func (c *EnvironmentClient) List(_ context.Context) ([]gitprovider.EnvironmentInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(repo.environments))
+	for name := range repo.environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	envs := make([]gitprovider.EnvironmentInfo, 0, len(names))
+	for _, name := range names {
+		envs = append(envs, copyEnvironmentInfo(repo.environments[name]))
+	}
+	return envs, nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (c *EnvironmentClient) Reconcile(ctx context.Context, req gitprovider.EnvironmentInfo) (gitprovider.EnvironmentInfo, bool, error) {
+	// First thing, validate and default the request to ensure a valid and fully-populated object
+	// (to minimize any possible diffs between desired and actual state)
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return gitprovider.EnvironmentInfo{}, false, err
+	}
+
+	actual, err := c.Get(ctx, req.Name)
+	var change gitprovider.Change
+	switch {
+	case errors.Is(err, gitprovider.ErrNotFound):
+		change = gitprovider.NewCreateChange(c.ref, req)
+	case err != nil:
+		return gitprovider.EnvironmentInfo{}, false, err
+	case req.Equals(actual):
+		// If the desired matches the actual state, just return the actual state
+		return actual, false, nil
+	default:
+		change = gitprovider.NewUpdateChange(c.ref, actual, req)
+	}
+	// In dry-run mode, only report what would be created or updated
+	if c.dryRun {
+		return req, true, gitprovider.NewDryRunError(change)
+	}
+
+	c.s.mu.Lock()
+	repo, err := c.s.getRepository(c.ref)
+	if err == nil {
+		repo.environments[req.Name] = copyEnvironmentInfo(req)
+	}
+	c.s.mu.Unlock()
+	if err != nil {
+		return gitprovider.EnvironmentInfo{}, true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallReconcileEnvironment)
+	return req, true, nil
+}
+
+// Delete deletes the environment with the given name.
+//
+// ErrNotFound is returned if the environment doesn't exist.
+func (c *EnvironmentClient) Delete(_ context.Context, name string) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return err
+	}
+	if _, ok := repo.environments[name]; !ok {
+		return fmt.Errorf("environment %q: %w", name, gitprovider.ErrNotFound)
+	}
+	delete(repo.environments, name)
+	return nil
+}
+
+// copyEnvironmentInfo returns a copy of env that doesn't share the reviewer slices.
+func copyEnvironmentInfo(env gitprovider.EnvironmentInfo) gitprovider.EnvironmentInfo {
+	env.Reviewers = append([]string(nil), env.Reviewers...)
+	env.TeamReviewers = append([]string(nil), env.TeamReviewers...)
+	env.Default()
+	return env
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// EventsClient implements the gitprovider.EventsClient interface.
+var _ gitprovider.EventsClient = &EventsClient{}
+
+// EventsClient lists the activity events of repositories and organizations. The server records
+// pushes of commits, opened and merged pull requests, and added and removed collaborators.
+type EventsClient struct {
+	*clientContext
+}
+
+// ListRepository lists the events of the given repository matching opts, newest first.
+//
+// ErrNotFound is returned if the repository doesn't exist.
+func (c *EventsClient) ListRepository(_ context.Context, ref gitprovider.RepositoryRef, opts gitprovider.EventListOptions) ([]gitprovider.Event, error) {
+	opts.Default()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	if _, err := c.s.getRepository(ref); err != nil {
+		return nil, err
+	}
+	return c.filterEvents(opts, func(e gitprovider.Event) bool {
+		return repoKey(e.Repository) == repoKey(ref)
+	}), nil
+}
+
+// ListOrganization lists the events of the repositories of the given organization matching
+// opts, newest first, including the events of deleted repositories.
+//
+// ErrNotFound is returned if the organization doesn't exist.
+func (c *EventsClient) ListOrganization(_ context.Context, ref gitprovider.OrganizationRef, opts gitprovider.EventListOptions) ([]gitprovider.Event, error) {
+	opts.Default()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	if _, ok := c.s.orgs[ref.GetIdentity()]; !ok {
+		return nil, fmt.Errorf("organization %s: %w", ref.String(), gitprovider.ErrNotFound)
+	}
+	return c.filterEvents(opts, func(e gitprovider.Event) bool {
+		return e.Repository.GetType() != gitprovider.IdentityTypeUser && e.Repository.GetIdentity() == ref.GetIdentity()
+	}), nil
+}
+
+// filterEvents returns the events selected by fn and matching opts, newest first.
+func (c *EventsClient) filterEvents(opts gitprovider.EventListOptions, fn func(gitprovider.Event) bool) []gitprovider.Event {
+	events := []gitprovider.Event{}
+	for i := len(c.s.events) - 1; i >= 0 && len(events) < opts.Limit; i-- {
+		event := c.s.events[i]
+		if opts.Before(event) {
+			break
+		}
+		if fn(event) && opts.Matches(event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// FileClient implements the gitprovider.FileClient interface.
+var _ gitprovider.FileClient = &FileClient{}
+
+// FileClient operates on the files for a specific repository.
+type FileClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get returns the file at the given path, or the files directly in the directory at the given path,
+// at the given Git reference. The reference can be a branch or commit SHA.
+//
+// ErrNotFound is returned if there are no files at the path.
+func (c *FileClient) Get(_ context.Context, filePath, ref string) ([]*gitprovider.CommitFile, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := repo.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	filePath = strings.Trim(filePath, "/")
+	files := make([]*gitprovider.CommitFile, 0)
+	for _, p := range sortedPaths(cm.tree) {
+		if p == filePath || path.Dir(p) == filePath || (filePath == "" && !strings.Contains(p, "/")) {
+			files = append(files, &gitprovider.CommitFile{
+				Path:    gitprovider.StringVar(p),
+				Content: gitprovider.StringVar(cm.tree[p]),
+			})
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files found on this path[%s]: %w", filePath, gitprovider.ErrNotFound)
+	}
+	return files, nil
+}
+
+// Open returns a reader of the content of the file at the given path and Git reference, along with
+// the size and blob SHA of the file.
+//
+// ErrNotFound is returned if the file doesn't exist, and ErrInvalidArgument if the path is a directory.
+func (c *FileClient) Open(_ context.Context, filePath, ref string) (io.ReadCloser, *gitprovider.FileInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	cm, err := repo.resolve(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filePath = strings.Trim(filePath, "/")
+	content, ok := cm.tree[filePath]
+	if !ok {
+		for p := range cm.tree {
+			if strings.HasPrefix(p, filePath+"/") {
+				return nil, nil, fmt.Errorf("path %q is not a regular file: %w", filePath, gitprovider.ErrInvalidArgument)
+			}
+		}
+		return nil, nil, fmt.Errorf("file %q: %w", filePath, gitprovider.ErrNotFound)
+	}
+	return io.NopCloser(strings.NewReader(content)), &gitprovider.FileInfo{
+		Path: filePath,
+		Size: int64(len(content)),
+		SHA:  plumbing.ComputeHash(plumbing.BlobObject, []byte(content)).String(),
+	}, nil
+}
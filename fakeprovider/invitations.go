@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// InvitationsClient implements the gitprovider.InvitationsClient interface.
+var _ gitprovider.InvitationsClient = &InvitationsClient{}
+
+// InvitationsClient operates on the pending repository invitations of the authenticated user.
+// The fake provider adds collaborators directly, so there are never pending invitations.
+type InvitationsClient struct {
+	*clientContext
+}
+
+// List lists the pending invitations of the authenticated user, which is always empty.
+func (c *InvitationsClient) List(_ context.Context) ([]gitprovider.RepositoryInvitation, error) {
+	return []gitprovider.RepositoryInvitation{}, nil
+}
+
+// Accept accepts the invitation with the given ID.
+//
+// ErrNotFound is always returned, as there are no pending invitations.
+func (c *InvitationsClient) Accept(_ context.Context, _ int64) error {
+	return gitprovider.ErrNotFound
+}
+
+// Decline declines the invitation with the given ID.
+//
+// ErrNotFound is always returned, as there are no pending invitations.
+func (c *InvitationsClient) Decline(_ context.Context, _ int64) error {
+	return gitprovider.ErrNotFound
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// MergeQueueClient implements the gitprovider.MergeQueueClient interface.
+var _ gitprovider.MergeQueueClient = &MergeQueueClient{}
+
+// MergeQueueClient operates on the merge queue for a specific repository. The queue isn't processed
+// by the fake provider: entries stay queued until they are dequeued, or the pull request is merged.
+type MergeQueueClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Enqueue adds the pull request to the merge queue of its base branch.
+//
+// ErrNotFound is returned if the pull request doesn't exist, ErrAlreadyExists if it's already queued,
+// and ErrInvalidArgument if it's merged or closed.
+func (c *MergeQueueClient) Enqueue(_ context.Context, number int) (gitprovider.MergeQueueEntry, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return gitprovider.MergeQueueEntry{}, err
+	}
+	if number < 1 || number > len(repo.pullRequests) {
+		return gitprovider.MergeQueueEntry{}, fmt.Errorf("pull request #%d: %w", number, gitprovider.ErrNotFound)
+	}
+	pr := repo.pullRequests[number-1]
+	if pr.info.Merged {
+		return gitprovider.MergeQueueEntry{}, fmt.Errorf("pull request #%d is already merged: %w", number, gitprovider.ErrInvalidArgument)
+	}
+	if pr.info.Closed {
+		return gitprovider.MergeQueueEntry{}, fmt.Errorf("pull request #%d is closed: %w", number, gitprovider.ErrInvalidArgument)
+	}
+	if _, ok := repo.queueEntry(number); ok {
+		return gitprovider.MergeQueueEntry{}, fmt.Errorf("pull request #%d is already queued: %w", number, gitprovider.ErrAlreadyExists)
+	}
+	repo.mergeQueue = append(repo.mergeQueue, gitprovider.MergeQueueEntry{
+		PullRequestNumber: number,
+		BaseBranch:        pr.baseBranch,
+		State:             gitprovider.MergeQueueStateQueued,
+		EnqueuedAt:        c.s.now(),
+	})
+	entry, _ := repo.queueEntry(number)
+	return entry, nil
+}
+
+// Dequeue removes the pull request from the merge queue.
+//
+// ErrNotFound is returned if the pull request isn't queued.
+func (c *MergeQueueClient) Dequeue(_ context.Context, number int) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return err
+	}
+	if !repo.dequeue(number) {
+		return fmt.Errorf("pull request #%d is not queued: %w", number, gitprovider.ErrNotFound)
+	}
+	return nil
+}
+
+// Get returns the merge queue entry of the pull request.
+//
+// ErrNotFound is returned if the pull request isn't queued.
+func (c *MergeQueueClient) Get(_ context.Context, number int) (gitprovider.MergeQueueEntry, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return gitprovider.MergeQueueEntry{}, err
+	}
+	entry, ok := repo.queueEntry(number)
+	if !ok {
+		return gitprovider.MergeQueueEntry{}, fmt.Errorf("pull request #%d is not queued: %w", number, gitprovider.ErrNotFound)
+	}
+	return entry, nil
+}
+
+// List lists the entries of the merge queue of the base branch, in merge order.
+func (c *MergeQueueClient) List(_ context.Context, baseBranch string) ([]gitprovider.MergeQueueEntry, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	entries := []gitprovider.MergeQueueEntry{}
+	for _, entry := range repo.mergeQueue {
+		if entry.BaseBranch == baseBranch {
+			entry.Position = len(entries) + 1
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// queueEntry returns the merge queue entry of the pull request, with its position in the queue
+// of its base branch.
+func (r *repository) queueEntry(number int) (gitprovider.MergeQueueEntry, bool) {
+	positions := map[string]int{}
+	for _, entry := range r.mergeQueue {
+		positions[entry.BaseBranch]++
+		if entry.PullRequestNumber == number {
+			entry.Position = positions[entry.BaseBranch]
+			return entry, true
+		}
+	}
+	return gitprovider.MergeQueueEntry{}, false
+}
+
+// dequeue removes the pull request from the merge queue, and returns false if it wasn't queued.
+func (r *repository) dequeue(number int) bool {
+	for i, entry := range r.mergeQueue {
+		if entry.PullRequestNumber == number {
+			r.mergeQueue = append(r.mergeQueue[:i], r.mergeQueue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
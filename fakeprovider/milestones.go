@@ -0,0 +1,160 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// MilestoneClient implements the gitprovider.MilestoneClient interface.
+var _ gitprovider.MilestoneClient = &MilestoneClient{}
+
+// MilestoneClient operates on the milestones for a specific repository.
+type MilestoneClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists all milestones in the repository, both open and closed.
+func (c *MilestoneClient) List(_ context.Context) ([]gitprovider.Milestone, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	milestones := make([]gitprovider.Milestone, 0, len(repo.milestones))
+	for _, m := range repo.milestones {
+		milestones = append(milestones, &milestoneResource{info: m.info})
+	}
+	return milestones, nil
+}
+
+// Get retrieves an existing milestone by number.
+//
+// ErrNotFound is returned if the milestone doesn't exist.
+func (c *MilestoneClient) Get(_ context.Context, number int) (gitprovider.Milestone, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	m, err := c.getMilestone(number)
+	if err != nil {
+		return nil, err
+	}
+	return &milestoneResource{info: m.info}, nil
+}
+
+// Create creates an open milestone with the given specifications.
+func (c *MilestoneClient) Create(_ context.Context, req gitprovider.MilestoneInfo) (gitprovider.Milestone, error) {
+	if err := req.ValidateInfo(); err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	req.Number = len(repo.milestones) + 1
+	req.State = gitprovider.MilestoneStateOpen
+	req.WebURL = fmt.Sprintf("%s/milestone/%d", c.ref.String(), req.Number)
+	m := &milestone{info: req}
+	repo.milestones = append(repo.milestones, m)
+	return &milestoneResource{info: m.info}, nil
+}
+
+// AssignPullRequest assigns the pull request with the given number to the milestone.
+//
+// ErrNotFound is returned if the milestone or pull request doesn't exist.
+func (c *MilestoneClient) AssignPullRequest(_ context.Context, number, pullRequestNumber int) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	m, err := c.getMilestone(number)
+	if err != nil {
+		return err
+	}
+	repo := c.s.repos[repoKey(c.ref)]
+	if pullRequestNumber < 1 || pullRequestNumber > len(repo.pullRequests) {
+		return fmt.Errorf("pull request #%d: %w", pullRequestNumber, gitprovider.ErrNotFound)
+	}
+	m.pullRequests = append(m.pullRequests, pullRequestNumber)
+	return nil
+}
+
+// AssignIssue assigns the issue with the given number to the milestone. Issues aren't
+// stored by the fake provider, so any issue number is accepted.
+//
+// ErrNotFound is returned if the milestone doesn't exist.
+func (c *MilestoneClient) AssignIssue(_ context.Context, number, issueNumber int) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	m, err := c.getMilestone(number)
+	if err != nil {
+		return err
+	}
+	m.issues = append(m.issues, issueNumber)
+	return nil
+}
+
+// Close closes the milestone with the given number.
+//
+// ErrNotFound is returned if the milestone doesn't exist.
+func (c *MilestoneClient) Close(_ context.Context, number int) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	m, err := c.getMilestone(number)
+	if err != nil {
+		return err
+	}
+	m.info.State = gitprovider.MilestoneStateClosed
+	return nil
+}
+
+// getMilestone returns the milestone with the given number. The server lock must be held.
+func (c *MilestoneClient) getMilestone(number int) (*milestone, error) {
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	if number < 1 || number > len(repo.milestones) {
+		return nil, fmt.Errorf("milestone #%d: %w", number, gitprovider.ErrNotFound)
+	}
+	return repo.milestones[number-1], nil
+}
+
+var _ gitprovider.Milestone = &milestoneResource{}
+
+type milestoneResource struct {
+	info gitprovider.MilestoneInfo
+}
+
+func (m *milestoneResource) Get() gitprovider.MilestoneInfo {
+	return m.info
+}
+
+// APIObject returns a *gitprovider.MilestoneInfo, as there is no provider-specific type.
+func (m *milestoneResource) APIObject() interface{} {
+	return &m.info
+}
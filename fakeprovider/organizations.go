@@ -0,0 +1,219 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// OrganizationsClient implements the gitprovider.OrganizationsClient interface.
+var _ gitprovider.OrganizationsClient = &OrganizationsClient{}
+
+// OrganizationsClient operates on organizations the user has access to.
+type OrganizationsClient struct {
+	*clientContext
+}
+
+// Get a specific organization the user has access to.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *OrganizationsClient) Get(_ context.Context, ref gitprovider.OrganizationRef) (gitprovider.Organization, error) {
+	if err := c.validateRef("OrganizationRef", ref); err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	org, ok := c.s.orgs[ref.GetIdentity()]
+	if !ok {
+		return nil, fmt.Errorf("organization %s: %w", ref.String(), gitprovider.ErrNotFound)
+	}
+	return newOrganization(c.clientContext, org), nil
+}
+
+// List all organizations, including sub-organizations. If a depth is set in opts, only the
+// top-level organizations are listed, with their sub-organizations down to that depth.
+func (c *OrganizationsClient) List(_ context.Context, opts ...gitprovider.OrganizationListOption) ([]gitprovider.Organization, error) {
+	o, err := gitprovider.MakeOrganizationListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	depth := -1
+	if o.Depth != nil {
+		depth = *o.Depth
+	}
+	return c.descendants("", depth), nil
+}
+
+// Children returns the sub-organizations of the given organization, down to the depth set in opts.
+//
+// ErrNotFound is returned if the organization does not exist.
+func (c *OrganizationsClient) Children(_ context.Context, ref gitprovider.OrganizationRef, opts ...gitprovider.OrganizationListOption) ([]gitprovider.Organization, error) {
+	if err := c.validateRef("OrganizationRef", ref); err != nil {
+		return nil, err
+	}
+	o, err := gitprovider.MakeOrganizationListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	if _, ok := c.s.orgs[ref.GetIdentity()]; !ok {
+		return nil, fmt.Errorf("organization %s: %w", ref.String(), gitprovider.ErrNotFound)
+	}
+	return c.descendants(ref.GetIdentity(), o.GetDepth()), nil
+}
+
+// descendants returns the organizations under the given path (all organizations if empty), down
+// to the given depth, where a negative depth traverses the full tree.
+func (c *OrganizationsClient) descendants(parent string, depth int) []gitprovider.Organization {
+	prefix := ""
+	if parent != "" {
+		prefix = parent + "/"
+	}
+	keys := make([]string, 0, len(c.s.orgs))
+	for key := range c.s.orgs {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		level := strings.Count(strings.TrimPrefix(key, prefix), "/") + 1
+		if depth < 0 || level <= depth {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	orgs := make([]gitprovider.Organization, 0, len(keys))
+	for _, key := range keys {
+		orgs = append(orgs, newOrganization(c.clientContext, c.s.orgs[key]))
+	}
+	return orgs
+}
+
+func newOrganization(ctx *clientContext, org *organization) *organizationResource {
+	return &organizationResource{
+		info: org.info,
+		ref:  org.ref,
+		teams: &TeamsClient{
+			clientContext: ctx,
+			ref:           org.ref,
+		},
+	}
+}
+
+var _ gitprovider.Organization = &organizationResource{}
+
+type organizationResource struct {
+	info  gitprovider.OrganizationInfo
+	ref   gitprovider.OrganizationRef
+	teams *TeamsClient
+}
+
+func (o *organizationResource) Get() gitprovider.OrganizationInfo {
+	return o.info
+}
+
+// APIObject returns a *gitprovider.OrganizationInfo.
+func (o *organizationResource) APIObject() interface{} {
+	return &o.info
+}
+
+func (o *organizationResource) Organization() gitprovider.OrganizationRef {
+	return o.ref
+}
+
+func (o *organizationResource) Teams() gitprovider.TeamsClient {
+	return o.teams
+}
+
+// TeamsClient implements the gitprovider.TeamsClient interface.
+var _ gitprovider.TeamsClient = &TeamsClient{}
+
+// TeamsClient handles teams organization-wide.
+type TeamsClient struct {
+	*clientContext
+	ref gitprovider.OrganizationRef
+}
+
+// Get a team within the specific organization.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *TeamsClient) Get(_ context.Context, name string) (gitprovider.Team, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	org, ok := c.s.orgs[c.ref.GetIdentity()]
+	if !ok {
+		return nil, fmt.Errorf("organization %s: %w", c.ref.String(), gitprovider.ErrNotFound)
+	}
+	info, ok := org.teams[name]
+	if !ok {
+		return nil, fmt.Errorf("team %q: %w", name, gitprovider.ErrNotFound)
+	}
+	return &team{info: info, ref: c.ref}, nil
+}
+
+// List all teams within the specific organization, sorted by name.
+func (c *TeamsClient) List(_ context.Context) ([]gitprovider.Team, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	org, ok := c.s.orgs[c.ref.GetIdentity()]
+	if !ok {
+		return nil, fmt.Errorf("organization %s: %w", c.ref.String(), gitprovider.ErrNotFound)
+	}
+	names := make([]string, 0, len(org.teams))
+	for name := range org.teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	teams := make([]gitprovider.Team, 0, len(names))
+	for _, name := range names {
+		teams = append(teams, &team{info: org.teams[name], ref: c.ref})
+	}
+	return teams, nil
+}
+
+var _ gitprovider.Team = &team{}
+
+type team struct {
+	info gitprovider.TeamInfo
+	ref  gitprovider.OrganizationRef
+}
+
+func (t *team) Get() gitprovider.TeamInfo {
+	return t.info
+}
+
+// APIObject returns a *gitprovider.TeamInfo.
+func (t *team) APIObject() interface{} {
+	return &t.info
+}
+
+func (t *team) Organization() gitprovider.OrganizationRef {
+	return t.ref
+}
@@ -0,0 +1,375 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+// PullRequestClient implements the gitprovider.PullRequestClient interface.
+var _ gitprovider.PullRequestClient = &PullRequestClient{}
+
+// PullRequestClient operates on the pull requests for a specific repository.
+type PullRequestClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists all pull requests in the repository, whether open, merged or closed.
+func (c *PullRequestClient) List(_ context.Context) ([]gitprovider.PullRequest, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	prs := make([]gitprovider.PullRequest, 0, len(repo.pullRequests))
+	for _, pr := range repo.pullRequests {
+		prs = append(prs, newPullRequest(c.clientContext, pr))
+	}
+	return prs, nil
+}
+
+// Create opens a pull request to merge branch into baseBranch.
+//
+// ErrNotFound is returned if either branch doesn't exist.
+func (c *PullRequestClient) Create(_ context.Context, title, branch, baseBranch, description string) (gitprovider.PullRequest, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range []string{branch, baseBranch} {
+		if _, ok := repo.branches[b]; !ok {
+			return nil, fmt.Errorf("branch %q: %w", b, gitprovider.ErrNotFound)
+		}
+	}
+
+	number := len(repo.pullRequests) + 1
+	now := c.s.now()
+	pr := &pullRequest{
+		info: gitprovider.PullRequestInfo{
+			Number: number,
+			WebURL: fmt.Sprintf("%s/pull/%d", c.ref.String(), number),
+		},
+		title:       title,
+		description: description,
+		branch:      branch,
+		baseBranch:  baseBranch,
+		createdAt:   now,
+		updatedAt:   now,
+	}
+	repo.pullRequests = append(repo.pullRequests, pr)
+	c.s.recordEvent(repo, gitprovider.Event{Type: gitprovider.EventTypePullRequest, Action: "opened", PullRequestNumber: number})
+	return newPullRequest(c.clientContext, pr), nil
+}
+
+// Get retrieves an existing pull request by number.
+//
+// ErrNotFound is returned if the pull request doesn't exist.
+func (c *PullRequestClient) Get(_ context.Context, number int) (gitprovider.PullRequest, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pr, _, err := c.getPullRequest(number)
+	if err != nil {
+		return nil, err
+	}
+	return newPullRequest(c.clientContext, pr), nil
+}
+
+// Merge merges the pull request into its base branch, with a merge commit or a single squashed
+// commit. The message is used as the commit message if set. The pull request is removed from the
+// merge queue.
+//
+// ErrInvalidArgument is returned if the pull request was already merged or closed, it has conflicts with
+// the base branch, which matches ErrMergeConflict too, or the merge method isn't allowed by RepositoryInfo.MergeMethods. Rebasing
+// creates a single commit, with the message of the head commit. With
+// RepositoryInfo.DeleteBranchOnMerge, the head branch is deleted.
+func (c *PullRequestClient) Merge(_ context.Context, number int, mergeMethod gitprovider.MergeMethod, message string) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pr, repo, err := c.getPullRequest(number)
+	if err != nil {
+		return err
+	}
+	if pr.info.Merged {
+		return fmt.Errorf("pull request #%d is already merged: %w", number, gitprovider.ErrInvalidArgument)
+	}
+	if pr.info.Closed {
+		return fmt.Errorf("pull request #%d is closed: %w", number, gitprovider.ErrInvalidArgument)
+	}
+	if !mergeMethodAllowed(repo.info.MergeMethods, mergeMethod) {
+		return fmt.Errorf("merge method %q is not allowed: %w", mergeMethod, gitprovider.ErrInvalidArgument)
+	}
+	head, err := repo.resolve(pr.branch)
+	if err != nil {
+		return err
+	}
+	base, err := repo.resolve(pr.baseBranch)
+	if err != nil {
+		return err
+	}
+
+	// Apply the changes of the head branch since the merge base onto the base branch
+	ancestorTree := map[string]string{}
+	if mb := repo.mergeBase(head, base); mb != nil {
+		ancestorTree = mb.tree
+	}
+	tree := copyTree(base.tree)
+	for _, p := range sortedPaths(unionTree(ancestorTree, head.tree)) {
+		old, inAncestor := ancestorTree[p]
+		content, inHead := head.tree[p]
+		if inAncestor == inHead && old == content {
+			continue
+		}
+		current, inBase := base.tree[p]
+		if inBase != inAncestor || current != old {
+			if inBase == inHead && current == content {
+				continue
+			}
+			return validation.NewMultiError(
+				fmt.Errorf("pull request #%d conflicts with %q at %q: %w", number, pr.baseBranch, p, gitprovider.ErrInvalidArgument),
+				gitprovider.ErrMergeConflict,
+			)
+		}
+		if inHead {
+			tree[p] = content
+		} else {
+			delete(tree, p)
+		}
+	}
+
+	switch mergeMethod {
+	case gitprovider.MergeMethodMerge:
+		if message == "" {
+			message = fmt.Sprintf("Merge pull request #%d from %s", number, pr.branch)
+		}
+		c.s.commit(repo, pr.baseBranch, message, []string{base.info.Sha, head.info.Sha}, tree)
+	case gitprovider.MergeMethodSquash:
+		if message == "" {
+			message = fmt.Sprintf("%s (#%d)", pr.title, number)
+		}
+		c.s.commit(repo, pr.baseBranch, message, []string{base.info.Sha}, tree)
+	case gitprovider.MergeMethodRebase:
+		c.s.commit(repo, pr.baseBranch, head.info.Message, []string{base.info.Sha}, tree)
+	default:
+		return fmt.Errorf("unsupported merge method %q: %w", mergeMethod, gitprovider.ErrInvalidArgument)
+	}
+	pr.info.Merged = true
+	pr.updatedAt = c.s.now()
+	c.s.recordEvent(repo, gitprovider.Event{Type: gitprovider.EventTypePullRequest, Action: "merged", PullRequestNumber: number})
+	repo.dequeue(number)
+	if repo.info.DeleteBranchOnMerge != nil && *repo.info.DeleteBranchOnMerge && pr.branch != *repo.info.DefaultBranch {
+		delete(repo.branches, pr.branch)
+	}
+	return nil
+}
+
+// Close closes the pull request without merging it. The pull request is removed from the merge
+// queue.
+//
+// ErrInvalidArgument is returned if the pull request was already merged or closed.
+func (c *PullRequestClient) Close(_ context.Context, number int) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pr, repo, err := c.getPullRequest(number)
+	if err != nil {
+		return err
+	}
+	if pr.info.Merged || pr.info.Closed {
+		return fmt.Errorf("pull request #%d is not open: %w", number, gitprovider.ErrInvalidArgument)
+	}
+	pr.info.Closed = true
+	pr.updatedAt = c.s.now()
+	c.s.recordEvent(repo, gitprovider.Event{Type: gitprovider.EventTypePullRequest, Action: "closed", PullRequestNumber: number})
+	repo.dequeue(number)
+	return nil
+}
+
+// mergeMethodAllowed returns true if method is in allowed, or allowed is nil.
+func mergeMethodAllowed(allowed []gitprovider.MergeMethod, method gitprovider.MergeMethod) bool {
+	if allowed == nil {
+		return true
+	}
+	for _, m := range allowed {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// getPullRequest returns the pull request with the given number, and its repository.
+// The server lock must be held.
+func (c *PullRequestClient) getPullRequest(number int) (*pullRequest, *repository, error) {
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	if number < 1 || number > len(repo.pullRequests) {
+		return nil, nil, fmt.Errorf("pull request #%d: %w", number, gitprovider.ErrNotFound)
+	}
+	return repo.pullRequests[number-1], repo, nil
+}
+
+// unionTree returns a tree with the paths of both trees.
+func unionTree(a, b map[string]string) map[string]string {
+	u := copyTree(a)
+	for p, content := range b {
+		u[p] = content
+	}
+	return u
+}
+
+func newPullRequest(ctx *clientContext, pr *pullRequest) *pullRequestResource {
+	return &pullRequestResource{
+		clientContext: ctx,
+		info:          pr.info,
+		createdAt:     pr.createdAt,
+		updatedAt:     pr.updatedAt,
+	}
+}
+
+var _ gitprovider.PullRequest = &pullRequestResource{}
+
+type pullRequestResource struct {
+	*clientContext
+
+	info      gitprovider.PullRequestInfo
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func (pr *pullRequestResource) Get() gitprovider.PullRequestInfo {
+	return pr.info
+}
+
+// APIObject returns a *gitprovider.PullRequestInfo, as there is no provider-specific type.
+func (pr *pullRequestResource) APIObject() interface{} {
+	return &pr.info
+}
+
+func (pr *pullRequestResource) Timestamps() gitprovider.Timestamps {
+	createdAt, updatedAt := pr.createdAt, pr.updatedAt
+	return gitprovider.Timestamps{
+		CreatedAt:  &createdAt,
+		UpdatedAt:  &updatedAt,
+		Generation: pr.generations.Observe(pr.info.WebURL, gitprovider.ObjectVersion(nil, &pr.info)),
+	}
+}
+
+// ListComments lists the comments of the pull request, oldest first.
+func (c *PullRequestClient) ListComments(_ context.Context, number int) ([]gitprovider.PullRequestComment, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pr, _, err := c.getPullRequest(number)
+	if err != nil {
+		return nil, err
+	}
+	comments := make([]gitprovider.PullRequestComment, 0, len(pr.comments))
+	for _, info := range pr.comments {
+		comments = append(comments, &pullRequestComment{info: info})
+	}
+	return comments, nil
+}
+
+// CreateComment adds a comment with the given body to the pull request, authored by the user.
+func (c *PullRequestClient) CreateComment(_ context.Context, number int, body string) (gitprovider.PullRequestComment, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pr, _, err := c.getPullRequest(number)
+	if err != nil {
+		return nil, err
+	}
+	c.s.nextID++
+	info := gitprovider.PullRequestCommentInfo{
+		ID:        int64(c.s.nextID),
+		Body:      body,
+		Author:    c.s.userLogin,
+		CreatedAt: c.s.now(),
+	}
+	pr.comments = append(pr.comments, info)
+	return &pullRequestComment{info: info}, nil
+}
+
+// EditComment replaces the body of a comment of the pull request.
+//
+// ErrNotFound is returned if the comment doesn't exist.
+func (c *PullRequestClient) EditComment(_ context.Context, number int, id int64, body string) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pr, _, err := c.getPullRequest(number)
+	if err != nil {
+		return err
+	}
+	for i := range pr.comments {
+		if pr.comments[i].ID == id {
+			pr.comments[i].Body = body
+			return nil
+		}
+	}
+	return fmt.Errorf("comment %d: %w", id, gitprovider.ErrNotFound)
+}
+
+// DeleteComment deletes a comment of the pull request.
+//
+// ErrNotFound is returned if the comment doesn't exist.
+func (c *PullRequestClient) DeleteComment(_ context.Context, number int, id int64) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pr, _, err := c.getPullRequest(number)
+	if err != nil {
+		return err
+	}
+	for i := range pr.comments {
+		if pr.comments[i].ID == id {
+			pr.comments = append(pr.comments[:i], pr.comments[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("comment %d: %w", id, gitprovider.ErrNotFound)
+}
+
+var _ gitprovider.PullRequestComment = &pullRequestComment{}
+
+type pullRequestComment struct {
+	info gitprovider.PullRequestCommentInfo
+}
+
+func (c *pullRequestComment) Get() gitprovider.PullRequestCommentInfo {
+	return c.info
+}
+
+// APIObject returns a *gitprovider.PullRequestCommentInfo, as there is no provider-specific type.
+func (c *pullRequestComment) APIObject() interface{} {
+	return &c.info
+}
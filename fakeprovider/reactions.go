@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// ReactionClient implements the gitprovider.ReactionClient interface.
+var _ gitprovider.ReactionClient = &ReactionClient{}
+
+// ReactionClient operates on the emoji reactions for a specific repository. The fake server
+// doesn't have issues, so only pull requests and their comments can be reacted on.
+type ReactionClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists the reactions on the given subject, oldest first.
+func (c *ReactionClient) List(_ context.Context, subject gitprovider.ReactionSubject) ([]gitprovider.ReactionInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pr, err := c.getSubject(subject)
+	if err != nil {
+		return nil, err
+	}
+	return append([]gitprovider.ReactionInfo{}, pr.reactions[subject.CommentID]...), nil
+}
+
+// Add adds a reaction with the given content to the subject, as the user. If the user already
+// reacted with that content, the existing reaction is returned.
+func (c *ReactionClient) Add(_ context.Context, subject gitprovider.ReactionSubject, content gitprovider.ReactionContent) (gitprovider.ReactionInfo, error) {
+	if err := gitprovider.ValidateReactionContent(content); err != nil {
+		return gitprovider.ReactionInfo{}, fmt.Errorf("reaction %q: %w", content, gitprovider.ErrInvalidArgument)
+	}
+
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pr, err := c.getSubject(subject)
+	if err != nil {
+		return gitprovider.ReactionInfo{}, err
+	}
+	for _, r := range pr.reactions[subject.CommentID] {
+		if r.Content == content && r.User == c.s.userLogin {
+			return r, nil
+		}
+	}
+	c.s.nextID++
+	info := gitprovider.ReactionInfo{
+		ID:      int64(c.s.nextID),
+		Content: content,
+		User:    c.s.userLogin,
+	}
+	if pr.reactions == nil {
+		pr.reactions = map[int64][]gitprovider.ReactionInfo{}
+	}
+	pr.reactions[subject.CommentID] = append(pr.reactions[subject.CommentID], info)
+	return info, nil
+}
+
+// Remove removes the reaction with the given ID from the subject.
+//
+// ErrNotFound is returned if the reaction doesn't exist.
+func (c *ReactionClient) Remove(_ context.Context, subject gitprovider.ReactionSubject, id int64) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pr, err := c.getSubject(subject)
+	if err != nil {
+		return err
+	}
+	reactions := pr.reactions[subject.CommentID]
+	for i := range reactions {
+		if reactions[i].ID == id {
+			pr.reactions[subject.CommentID] = append(reactions[:i], reactions[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("reaction %d: %w", id, gitprovider.ErrNotFound)
+}
+
+// getSubject returns the pull request the subject is on, making sure the comment exists if the
+// subject is a comment. The server lock must be held.
+func (c *ReactionClient) getSubject(subject gitprovider.ReactionSubject) (*pullRequest, error) {
+	if !subject.PullRequest {
+		return nil, fmt.Errorf("issue #%d: %w", subject.Number, gitprovider.ErrNotFound)
+	}
+	prs := &PullRequestClient{clientContext: c.clientContext, ref: c.ref}
+	pr, _, err := prs.getPullRequest(subject.Number)
+	if err != nil {
+		return nil, err
+	}
+	if subject.CommentID == 0 {
+		return pr, nil
+	}
+	for _, comment := range pr.comments {
+		if comment.ID == subject.CommentID {
+			return pr, nil
+		}
+	}
+	return nil, fmt.Errorf("comment %d: %w", subject.CommentID, gitprovider.ErrNotFound)
+}
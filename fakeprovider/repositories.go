@@ -0,0 +1,567 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// OrgRepositoriesClient implements the gitprovider.OrgRepositoriesClient interface.
+var _ gitprovider.OrgRepositoriesClient = &OrgRepositoriesClient{}
+
+// OrgRepositoriesClient operates on repositories the user has access to.
+type OrgRepositoriesClient struct {
+	*clientContext
+}
+
+// Get returns the repository at the given path. The statistics requested with
+// RepositoryGetOptions.WithStats are those of the default branch.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *OrgRepositoriesClient) Get(_ context.Context, ref gitprovider.OrgRepositoryRef, opts ...gitprovider.RepositoryGetOption) (gitprovider.OrgRepository, error) {
+	if err := c.validateRef("OrgRepositoryRef", ref); err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(ref)
+	if err != nil {
+		return nil, err
+	}
+	resource := newOrgRepository(c.clientContext, repo)
+	if o := gitprovider.MakeRepositoryGetOptions(opts...); o.WithStats != nil && *o.WithStats {
+		resource.info.Stats = repo.stats()
+	}
+	return resource, nil
+}
+
+// List all repositories in the given organization, sorted by name.
+func (c *OrgRepositoriesClient) List(_ context.Context, ref gitprovider.OrganizationRef, opts ...gitprovider.RepositoryListOption) ([]gitprovider.OrgRepository, error) {
+	if err := c.validateRef("OrganizationRef", ref); err != nil {
+		return nil, err
+	}
+	o, err := gitprovider.MakeRepositoryListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repos := []gitprovider.OrgRepository{}
+	for _, repo := range c.s.listRepositories(ref.GetIdentity()) {
+		if !o.Matches(repo.ref.GetRepository(), repo.info, repo.lastActivity()) {
+			continue
+		}
+		repos = append(repos, newOrgRepository(c.clientContext, repo))
+	}
+	return repos, nil
+}
+
+// ListIter returns an iterator over the repositories in the given organization, which fetches
+// iterPageSize repositories at a time, before filtering them like List.
+func (c *OrgRepositoriesClient) ListIter(ctx context.Context, ref gitprovider.OrganizationRef, opts ...gitprovider.RepositoryListOption) *gitprovider.OrgRepositoryIterator {
+	o, optsErr := gitprovider.MakeRepositoryListOptions(opts...)
+	return gitprovider.NewOrgRepositoryIterator(ctx, func(_ context.Context, offset int) ([]gitprovider.OrgRepository, int, error) {
+		if err := c.validateRef("OrganizationRef", ref); err != nil {
+			return nil, 0, err
+		}
+		if optsErr != nil {
+			return nil, 0, optsErr
+		}
+		c.s.mu.Lock()
+		defer c.s.mu.Unlock()
+
+		page, next := c.s.listRepositoriesPage(ref.GetIdentity(), offset)
+		repos := make([]gitprovider.OrgRepository, 0, len(page))
+		for _, repo := range page {
+			if !o.Matches(repo.ref.GetRepository(), repo.info, repo.lastActivity()) {
+				continue
+			}
+			repos = append(repos, newOrgRepository(c.clientContext, repo))
+		}
+		return repos, next, nil
+	})
+}
+
+// Create creates a repository for the given organization, with the data and options.
+// The organization doesn't need to be added to the server.
+//
+// ErrAlreadyExists will be returned if the resource already exists.
+func (c *OrgRepositoriesClient) Create(_ context.Context, ref gitprovider.OrgRepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.OrgRepository, error) {
+	if err := c.validateRef("OrgRepositoryRef", ref); err != nil {
+		return nil, err
+	}
+	// Use the organization defaults for the unset fields, and the default create options
+	defaults := c.repoDefaults.For(ref.OrganizationRef)
+	defaults.ApplyToRepositoryInfo(&req)
+
+	repo, err := c.createRepository(ref, req, defaults.MergeCreateOptions(opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return newOrgRepository(c.clientContext, repo), nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (c *OrgRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.OrgRepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryReconcileOption) (gitprovider.OrgRepository, bool, error) {
+	// Use the organization defaults for the unset fields, instead of the built-in defaults
+	c.repoDefaults.For(ref.OrganizationRef).ApplyToRepositoryInfo(&req)
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, false, err
+	}
+
+	actual, err := c.Get(ctx, ref)
+	if errors.Is(err, gitprovider.ErrNotFound) {
+		change := gitprovider.NewCreateChange(ref, req)
+		if c.dryRun {
+			return nil, true, gitprovider.NewDryRunError(change)
+		}
+		resp, err := c.Create(ctx, ref, req, toCreateOpts(opts...)...)
+		if err != nil {
+			return nil, true, err
+		}
+		gitprovider.RecordChange(ctx, change, apiCallCreateRepository)
+		return resp, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	actionTaken, err := reconcileRepository(ctx, actual, req, c.dryRun)
+	return actual, actionTaken, err
+}
+
+// CreateFromTemplate creates a repository for the given organization from the template repository,
+// like Create with RepositoryCreateOptions.TemplateRepository.
+func (c *OrgRepositoriesClient) CreateFromTemplate(ctx context.Context, ref gitprovider.OrgRepositoryRef, template gitprovider.RepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.OrgRepository, error) {
+	return c.Create(ctx, ref, req, append(opts, &gitprovider.RepositoryCreateOptions{TemplateRepository: template})...)
+}
+
+// DownloadArchive returns an archive of the repository contents at the given Git reference,
+// which can be a branch or commit SHA. If gitRef is empty, the default branch is used.
+// The files are at the root of the archive.
+//
+// ErrNotFound is returned if the repository or the reference does not exist.
+func (c *OrgRepositoriesClient) DownloadArchive(_ context.Context, ref gitprovider.OrgRepositoryRef, gitRef string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	if err := c.validateRef("OrgRepositoryRef", ref); err != nil {
+		return nil, err
+	}
+	return c.downloadArchive(ref, gitRef, format)
+}
+
+// UserRepositoriesClient implements the gitprovider.UserRepositoriesClient interface.
+var _ gitprovider.UserRepositoriesClient = &UserRepositoriesClient{}
+
+// UserRepositoriesClient operates on repositories the user has access to.
+type UserRepositoriesClient struct {
+	*clientContext
+}
+
+// Get returns the repository at the given path. The statistics requested with
+// RepositoryGetOptions.WithStats are those of the default branch.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *UserRepositoriesClient) Get(_ context.Context, ref gitprovider.UserRepositoryRef, opts ...gitprovider.RepositoryGetOption) (gitprovider.UserRepository, error) {
+	if err := c.validateRef("UserRepositoryRef", ref); err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(ref)
+	if err != nil {
+		return nil, err
+	}
+	resource := newUserRepository(c.clientContext, repo)
+	if o := gitprovider.MakeRepositoryGetOptions(opts...); o.WithStats != nil && *o.WithStats {
+		resource.info.Stats = repo.stats()
+	}
+	return resource, nil
+}
+
+// List all repositories of the given user, sorted by name.
+func (c *UserRepositoriesClient) List(_ context.Context, ref gitprovider.UserRef, opts ...gitprovider.RepositoryListOption) ([]gitprovider.UserRepository, error) {
+	if err := c.validateRef("UserRef", ref); err != nil {
+		return nil, err
+	}
+	o, err := gitprovider.MakeRepositoryListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repos := []gitprovider.UserRepository{}
+	for _, repo := range c.s.listRepositories(ref.GetIdentity()) {
+		if !o.Matches(repo.ref.GetRepository(), repo.info, repo.lastActivity()) {
+			continue
+		}
+		repos = append(repos, newUserRepository(c.clientContext, repo))
+	}
+	return repos, nil
+}
+
+// ListIter returns an iterator over the repositories of the given user, which fetches
+// iterPageSize repositories at a time, before filtering them like List.
+func (c *UserRepositoriesClient) ListIter(ctx context.Context, ref gitprovider.UserRef, opts ...gitprovider.RepositoryListOption) *gitprovider.UserRepositoryIterator {
+	o, optsErr := gitprovider.MakeRepositoryListOptions(opts...)
+	return gitprovider.NewUserRepositoryIterator(ctx, func(_ context.Context, offset int) ([]gitprovider.UserRepository, int, error) {
+		if err := c.validateRef("UserRef", ref); err != nil {
+			return nil, 0, err
+		}
+		if optsErr != nil {
+			return nil, 0, optsErr
+		}
+		c.s.mu.Lock()
+		defer c.s.mu.Unlock()
+
+		page, next := c.s.listRepositoriesPage(ref.GetIdentity(), offset)
+		repos := make([]gitprovider.UserRepository, 0, len(page))
+		for _, repo := range page {
+			if !o.Matches(repo.ref.GetRepository(), repo.info, repo.lastActivity()) {
+				continue
+			}
+			repos = append(repos, newUserRepository(c.clientContext, repo))
+		}
+		return repos, next, nil
+	})
+}
+
+// Create creates a repository for the given user, with the data and options.
+//
+// ErrAlreadyExists will be returned if the resource already exists.
+func (c *UserRepositoriesClient) Create(_ context.Context, ref gitprovider.UserRepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.UserRepository, error) {
+	if err := c.validateRef("UserRepositoryRef", ref); err != nil {
+		return nil, err
+	}
+	repo, err := c.createRepository(ref, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newUserRepository(c.clientContext, repo), nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (c *UserRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.UserRepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryReconcileOption) (gitprovider.UserRepository, bool, error) {
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, false, err
+	}
+
+	actual, err := c.Get(ctx, ref)
+	if errors.Is(err, gitprovider.ErrNotFound) {
+		change := gitprovider.NewCreateChange(ref, req)
+		if c.dryRun {
+			return nil, true, gitprovider.NewDryRunError(change)
+		}
+		resp, err := c.Create(ctx, ref, req, toCreateOpts(opts...)...)
+		if err != nil {
+			return nil, true, err
+		}
+		gitprovider.RecordChange(ctx, change, apiCallCreateRepository)
+		return resp, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	actionTaken, err := reconcileRepository(ctx, actual, req, c.dryRun)
+	return actual, actionTaken, err
+}
+
+// CreateFromTemplate creates a repository for the given user from the template repository,
+// like Create with RepositoryCreateOptions.TemplateRepository.
+func (c *UserRepositoriesClient) CreateFromTemplate(ctx context.Context, ref gitprovider.UserRepositoryRef, template gitprovider.RepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.UserRepository, error) {
+	return c.Create(ctx, ref, req, append(opts, &gitprovider.RepositoryCreateOptions{TemplateRepository: template})...)
+}
+
+// DownloadArchive returns an archive of the repository contents at the given Git reference,
+// which can be a branch or commit SHA. If gitRef is empty, the default branch is used.
+// The files are at the root of the archive.
+//
+// ErrNotFound is returned if the repository or the reference does not exist.
+func (c *UserRepositoriesClient) DownloadArchive(_ context.Context, ref gitprovider.UserRepositoryRef, gitRef string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	if err := c.validateRef("UserRepositoryRef", ref); err != nil {
+		return nil, err
+	}
+	return c.downloadArchive(ref, gitRef, format)
+}
+
+// GetUserLogin returns the authenticated user, see Server.SetUserLogin.
+func (c *UserRepositoriesClient) GetUserLogin(_ context.Context) (gitprovider.UserRef, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	return gitprovider.UserRef{Domain: c.domain, UserLogin: c.s.userLogin}, nil
+}
+
+// listRepositories returns the repositories owned by the given identity, sorted by name.
+func (s *Server) listRepositories(identity string) []*repository {
+	var repos []*repository
+	for _, repo := range s.repos {
+		if repo.ref.GetIdentity() == identity {
+			repos = append(repos, repo)
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].ref.GetRepository() < repos[j].ref.GetRepository() })
+	return repos
+}
+
+// lastActivity returns the time of the newest commit in the repository, or the zero time if
+// it has no commits.
+func (r *repository) lastActivity() time.Time {
+	var lastActivity time.Time
+	for _, c := range r.commits {
+		if c.info.CreatedAt.After(lastActivity) {
+			lastActivity = c.info.CreatedAt
+		}
+	}
+	return lastActivity
+}
+
+// languageExtensions maps the file extensions to the languages reported in the statistics.
+//
+//nolint:gochecknoglobals
+var languageExtensions = map[string]string{
+	".go":   "Go",
+	".js":   "JavaScript",
+	".py":   "Python",
+	".sh":   "Shell",
+	".ts":   "TypeScript",
+	".yaml": "YAML",
+	".yml":  "YAML",
+}
+
+// stats returns the statistics of the default branch of the repository. The languages are
+// the shares of the bytes of the files with known extensions.
+func (r *repository) stats() *gitprovider.RepositoryStats {
+	stats := &gitprovider.RepositoryStats{}
+	head, err := r.resolve("")
+	if err != nil {
+		return stats
+	}
+	var size int
+	shares := map[string]float64{}
+	for p, content := range head.tree {
+		size += len(content)
+		if language, ok := languageExtensions[path.Ext(p)]; ok {
+			shares[language] += float64(len(content))
+		}
+	}
+	stats.SizeKB = int64(size / 1024)
+	stats.Languages = gitprovider.NewRepositoryLanguages(shares)
+
+	authors := map[string]bool{}
+	seen := map[string]bool{}
+	queue := []*commit{head}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if seen[c.info.Sha] {
+			continue
+		}
+		seen[c.info.Sha] = true
+		authors[c.info.Author] = true
+		for _, p := range c.parents {
+			queue = append(queue, r.commits[p])
+		}
+	}
+	stats.CommitCount = len(seen)
+	stats.ContributorCount = len(authors)
+	return stats
+}
+
+// iterPageSize is the number of repositories per page fetched by the ListIter iterators. It's
+// small, so that iterating over several pages doesn't take many repositories.
+const iterPageSize = 2
+
+// listRepositoriesPage returns the repositories of the owner with the identity, starting at the
+// given offset, and the offset of the next page, or 0 if it's the last page.
+func (s *Server) listRepositoriesPage(identity string, offset int) ([]*repository, int) {
+	repos := s.listRepositories(identity)
+	if offset >= len(repos) {
+		return nil, 0
+	}
+	end := offset + iterPageSize
+	if end >= len(repos) {
+		return repos[offset:], 0
+	}
+	return repos[offset:end], end
+}
+
+// createRepository validates, defaults and adds the repository. With the AutoInit option, an
+// initial commit with a README.md (and a LICENSE with LicenseTemplate, and a .gitignore with
+// GitignoreTemplate) is made to the default branch. With TemplateRepository, the initial commit
+// has the files of the default branch of the template.
+func (c *clientContext) createRepository(ref gitprovider.RepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (*repository, error) {
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, err
+	}
+	o, err := gitprovider.MakeRepositoryCreateOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	if _, ok := c.s.repos[repoKey(ref)]; ok {
+		return nil, fmt.Errorf("repository %s: %w", ref.String(), gitprovider.ErrAlreadyExists)
+	}
+	var tmplTree map[string]string
+	if o.TemplateRepository != nil {
+		tmpl, err := c.s.getRepository(o.TemplateRepository)
+		if err != nil {
+			return nil, err
+		}
+		if head, ok := tmpl.branches[*tmpl.info.DefaultBranch]; ok {
+			tmplTree = copyTree(tmpl.commits[head].tree)
+		}
+	}
+	// Fork and Mirror are readonly
+	req.Fork, req.Mirror = nil, nil
+	now := c.s.now()
+	repo := &repository{
+		ref:           ref,
+		info:          copyRepositoryInfo(req),
+		createdAt:     now,
+		updatedAt:     now,
+		commits:       map[string]*commit{},
+		branches:      map[string]string{},
+		deployKeys:    map[string]gitprovider.DeployKeyInfo{},
+		teamAccess:    map[string]gitprovider.TeamAccessInfo{},
+		collaborators: map[string]gitprovider.RepositoryPermission{},
+		environments:  map[string]gitprovider.EnvironmentInfo{},
+		approvalRules: map[string]gitprovider.ApprovalRuleInfo{},
+	}
+	if o.AutoInit != nil && *o.AutoInit {
+		tree := map[string]string{"README.md": fmt.Sprintf("# %s\n", ref.GetRepository())}
+		if o.LicenseTemplate != nil {
+			tree["LICENSE"] = string(*o.LicenseTemplate)
+		}
+		if o.GitignoreTemplate != nil {
+			tree[".gitignore"] = fmt.Sprintf("# %s\n", *o.GitignoreTemplate)
+		}
+		c.s.commit(repo, *req.DefaultBranch, "Initial commit", nil, tree)
+	}
+	if tmplTree != nil {
+		c.s.commit(repo, *req.DefaultBranch, "Initial commit", nil, tmplTree)
+	}
+	c.s.repos[repoKey(ref)] = repo
+	return repo, nil
+}
+
+// downloadArchive returns a tar.gz or zip archive of the tree at gitRef.
+func (c *clientContext) downloadArchive(ref gitprovider.RepositoryRef, gitRef string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	if err := gitprovider.ValidateArchiveFormat(format); err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	repo, err := c.s.getRepository(ref)
+	if err != nil {
+		c.s.mu.Unlock()
+		return nil, err
+	}
+	commit, err := repo.resolve(gitRef)
+	c.s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if format == gitprovider.ArchiveFormatZip {
+		zw := zip.NewWriter(&buf)
+		for _, p := range sortedPaths(commit.tree) {
+			w, err := zw.Create(p)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := io.WriteString(w, commit.tree[p]); err != nil {
+				return nil, err
+			}
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(&buf), nil
+	}
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, p := range sortedPaths(commit.tree) {
+		content := commit.tree[p]
+		hdr := &tar.Header{Name: p, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content)), ModTime: commit.info.CreatedAt}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(tw, content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&buf), nil
+}
+
+func reconcileRepository(ctx context.Context, actual gitprovider.UserRepository, req gitprovider.RepositoryInfo, dryRun bool) (bool, error) {
+	// If the desired matches the actual state, just return the actual state
+	if req.Equals(actual.Get()) {
+		return false, nil
+	}
+	change := gitprovider.NewUpdateChange(actual.Repository(), actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
+	if err := actual.Set(req); err != nil {
+		return false, err
+	}
+	if err := actual.Update(ctx); err != nil {
+		return true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateRepository)
+	return true, nil
+}
+
+func toCreateOpts(opts ...gitprovider.RepositoryReconcileOption) []gitprovider.RepositoryCreateOption {
+	// Convert RepositoryReconcileOption => RepositoryCreateOption
+	createOpts := make([]gitprovider.RepositoryCreateOption, 0, len(opts))
+	for _, opt := range opts {
+		createOpts = append(createOpts, opt)
+	}
+	return createOpts
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func newUserRepository(ctx *clientContext, repo *repository) *userRepository {
+	return &userRepository{
+		clientContext: ctx,
+		info:          copyRepositoryInfo(repo.info),
+		read:          copyRepositoryInfo(repo.info),
+		ref:           repo.ref,
+		createdAt:     repo.createdAt,
+		updatedAt:     repo.updatedAt,
+		deployKeys:    &DeployKeyClient{clientContext: ctx, ref: repo.ref},
+		commits:       &CommitClient{clientContext: ctx, ref: repo.ref},
+		branches:      &BranchClient{clientContext: ctx, ref: repo.ref},
+		pullRequests:  &PullRequestClient{clientContext: ctx, ref: repo.ref},
+		files:         &FileClient{clientContext: ctx, ref: repo.ref},
+		milestones:    &MilestoneClient{clientContext: ctx, ref: repo.ref},
+		mergeQueue:    &MergeQueueClient{clientContext: ctx, ref: repo.ref},
+		collaborators: &CollaboratorClient{clientContext: ctx, ref: repo.ref},
+		reactions:     &ReactionClient{clientContext: ctx, ref: repo.ref},
+		environments:  &EnvironmentClient{clientContext: ctx, ref: repo.ref},
+		badges:        &BadgeClient{clientContext: ctx, ref: repo.ref},
+		tagProtection: &TagProtectionClient{clientContext: ctx, ref: repo.ref},
+		approvalRules: &ApprovalRuleClient{clientContext: ctx, ref: repo.ref},
+	}
+}
+
+var _ gitprovider.UserRepository = &userRepository{}
+
+type userRepository struct {
+	*clientContext
+
+	info gitprovider.RepositoryInfo
+	// read is the state of the repository when it was last read, checked by Update with
+	// optimistic concurrency
+	read      gitprovider.RepositoryInfo
+	ref       gitprovider.RepositoryRef
+	createdAt time.Time
+	updatedAt time.Time
+
+	deployKeys    *DeployKeyClient
+	commits       *CommitClient
+	branches      *BranchClient
+	pullRequests  *PullRequestClient
+	files         *FileClient
+	milestones    *MilestoneClient
+	mergeQueue    *MergeQueueClient
+	collaborators *CollaboratorClient
+	reactions     *ReactionClient
+	environments  *EnvironmentClient
+	badges        *BadgeClient
+	tagProtection *TagProtectionClient
+	approvalRules *ApprovalRuleClient
+}
+
+func (r *userRepository) Get() gitprovider.RepositoryInfo {
+	return copyRepositoryInfo(r.info)
+}
+
+func (r *userRepository) Set(info gitprovider.RepositoryInfo) error {
+	if err := info.ValidateInfo(); err != nil {
+		return err
+	}
+	r.info = copyRepositoryInfo(info)
+	return nil
+}
+
+// APIObject returns a *gitprovider.RepositoryInfo, as there is no provider-specific type.
+func (r *userRepository) APIObject() interface{} {
+	return &r.info
+}
+
+func (r *userRepository) Repository() gitprovider.RepositoryRef {
+	return r.ref
+}
+
+func (r *userRepository) Timestamps() gitprovider.Timestamps {
+	createdAt, updatedAt := r.createdAt, r.updatedAt
+	return gitprovider.Timestamps{
+		CreatedAt:  &createdAt,
+		UpdatedAt:  &updatedAt,
+		Generation: r.generations.Observe(r.ref.String(), gitprovider.ObjectVersion(nil, &r.info)),
+	}
+}
+
+func (r *userRepository) DeployKeys() gitprovider.DeployKeyClient {
+	return r.deployKeys
+}
+
+func (r *userRepository) Commits() gitprovider.CommitClient {
+	return r.commits
+}
+
+func (r *userRepository) Branches() gitprovider.BranchClient {
+	return r.branches
+}
+
+func (r *userRepository) PullRequests() gitprovider.PullRequestClient {
+	return r.pullRequests
+}
+
+func (r *userRepository) Files() gitprovider.FileClient {
+	return r.files
+}
+
+func (r *userRepository) Milestones() gitprovider.MilestoneClient {
+	return r.milestones
+}
+
+func (r *userRepository) MergeQueue() gitprovider.MergeQueueClient {
+	return r.mergeQueue
+}
+
+func (r *userRepository) Collaborators() gitprovider.CollaboratorClient {
+	return r.collaborators
+}
+
+func (r *userRepository) Reactions() gitprovider.ReactionClient {
+	return r.reactions
+}
+
+func (r *userRepository) Environments() gitprovider.EnvironmentClient {
+	return r.environments
+}
+
+func (r *userRepository) Badges() gitprovider.BadgeClient {
+	return r.badges
+}
+
+func (r *userRepository) TagProtection() gitprovider.TagProtectionClient {
+	return r.tagProtection
+}
+
+func (r *userRepository) ApprovalRules() gitprovider.ApprovalRuleClient {
+	return r.approvalRules
+}
+
+// Update will apply the desired state in this object to the server.
+// Only set fields will be respected (i.e. PATCH behaviour).
+//
+// ErrNotFound is returned if the resource does not exist.
+//
+// The internal object will be overridden with the server data.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the repository was
+// changed since it was read.
+func (r *userRepository) Update(_ context.Context) error {
+	return r.update(r.optimisticConcurrency)
+}
+
+// update applies the desired state to the server, after checking for concurrent changes if
+// checkUnchanged is set.
+func (r *userRepository) update(checkUnchanged bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	repo, err := r.s.getRepository(r.ref)
+	if err != nil {
+		return err
+	}
+	if checkUnchanged {
+		if err := gitprovider.CheckUnchanged(r.ref.String(), r.read, repo.info); err != nil {
+			return err
+		}
+	}
+	if r.info.Description != nil {
+		repo.info.Description = gitprovider.StringVar(*r.info.Description)
+	}
+	if r.info.DefaultBranch != nil {
+		repo.info.DefaultBranch = gitprovider.StringVar(*r.info.DefaultBranch)
+	}
+	if r.info.Visibility != nil {
+		repo.info.Visibility = gitprovider.RepositoryVisibilityVar(*r.info.Visibility)
+	}
+	if r.info.Topics != nil {
+		repo.info.Topics = append([]string{}, r.info.Topics...)
+	}
+	if r.info.MergeMethods != nil {
+		repo.info.MergeMethods = append([]gitprovider.MergeMethod{}, r.info.MergeMethods...)
+	}
+	if r.info.DeleteBranchOnMerge != nil {
+		repo.info.DeleteBranchOnMerge = gitprovider.BoolVar(*r.info.DeleteBranchOnMerge)
+	}
+	if r.info.Archived != nil {
+		repo.info.Archived = gitprovider.BoolVar(*r.info.Archived)
+	}
+	repo.updatedAt = r.s.now()
+	r.info = copyRepositoryInfo(repo.info)
+	r.read = copyRepositoryInfo(repo.info)
+	r.updatedAt = repo.updatedAt
+	return nil
+}
+
+// Reconcile makes sure the desired state in this object (called "req" here) becomes
+// the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+//
+// The internal object will be overridden with the server data if actionTaken == true.
+func (r *userRepository) Reconcile(ctx context.Context) (bool, error) {
+	r.s.mu.Lock()
+	repo, err := r.s.getRepository(r.ref)
+	var actual gitprovider.RepositoryInfo
+	if err == nil {
+		actual = copyRepositoryInfo(repo.info)
+	}
+	r.s.mu.Unlock()
+
+	if errors.Is(err, gitprovider.ErrNotFound) {
+		change := gitprovider.NewCreateChange(r.ref, r.Get())
+		if r.dryRun {
+			return true, gitprovider.NewDryRunError(change)
+		}
+		repo, err := r.createRepository(r.ref, r.Get())
+		if err != nil {
+			return true, err
+		}
+		r.info = copyRepositoryInfo(repo.info)
+		r.read = copyRepositoryInfo(repo.info)
+		r.createdAt, r.updatedAt = repo.createdAt, repo.updatedAt
+		gitprovider.RecordChange(ctx, change, apiCallCreateRepository)
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	desired := r.Get()
+	if err := gitprovider.ValidateAndDefaultInfo(&desired); err != nil {
+		return false, err
+	}
+	// If desired state already is the actual state, do nothing
+	if desired.Equals(actual) {
+		return false, nil
+	}
+	change := gitprovider.NewUpdateChange(r.ref, actual, desired)
+	// In dry-run mode, only report what would be updated
+	if r.dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
+	// Otherwise, make the desired state the actual state. Reconcile overwrites the actual state
+	// by design, so there's no need to check for concurrent changes
+	if err := r.update(false); err != nil {
+		return true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateRepository)
+	return true, nil
+}
+
+// Delete deletes the current resource irreversibly.
+//
+// ErrNotFound is returned if the resource doesn't exist anymore.
+// ErrDestructiveCallDisallowed is returned unless destructive calls are enabled for the client.
+func (r *userRepository) Delete(_ context.Context) error {
+	if !r.destructiveActions {
+		return fmt.Errorf("cannot delete repository: %w", gitprovider.ErrDestructiveCallDisallowed)
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, err := r.s.getRepository(r.ref); err != nil {
+		return err
+	}
+	delete(r.s.repos, repoKey(r.ref))
+	return nil
+}
+
+func newOrgRepository(ctx *clientContext, repo *repository) *orgRepository {
+	return &orgRepository{
+		userRepository: *newUserRepository(ctx, repo),
+		teamAccess:     &TeamAccessClient{clientContext: ctx, ref: repo.ref},
+	}
+}
+
+var _ gitprovider.OrgRepository = &orgRepository{}
+
+type orgRepository struct {
+	userRepository
+
+	teamAccess *TeamAccessClient
+}
+
+func (r *orgRepository) TeamAccess() gitprovider.TeamAccessClient {
+	return r.teamAccess
+}
+
+// copyRepositoryInfo returns a copy of info which doesn't share the pointer fields.
+func copyRepositoryInfo(info gitprovider.RepositoryInfo) gitprovider.RepositoryInfo {
+	c := gitprovider.RepositoryInfo{}
+	if info.Description != nil {
+		c.Description = gitprovider.StringVar(*info.Description)
+	}
+	if info.DefaultBranch != nil {
+		c.DefaultBranch = gitprovider.StringVar(*info.DefaultBranch)
+	}
+	if info.Visibility != nil {
+		c.Visibility = gitprovider.RepositoryVisibilityVar(*info.Visibility)
+	}
+	if info.Topics != nil {
+		c.Topics = append([]string{}, info.Topics...)
+	}
+	if info.MergeMethods != nil {
+		c.MergeMethods = append([]gitprovider.MergeMethod{}, info.MergeMethods...)
+	}
+	if info.DeleteBranchOnMerge != nil {
+		c.DeleteBranchOnMerge = gitprovider.BoolVar(*info.DeleteBranchOnMerge)
+	}
+	if info.Archived != nil {
+		c.Archived = gitprovider.BoolVar(*info.Archived)
+	}
+	if info.Fork != nil {
+		fork := *info.Fork
+		c.Fork = &fork
+	}
+	if info.Mirror != nil {
+		mirror := *info.Mirror
+		c.Mirror = &mirror
+	}
+	if info.Stats != nil {
+		stats := *info.Stats
+		stats.Languages = append([]gitprovider.RepositoryLanguage{}, info.Stats.Languages...)
+		c.Stats = &stats
+	}
+	return c
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// SearchClient implements the gitprovider.SearchClient interface.
+var _ gitprovider.SearchClient = &SearchClient{}
+
+// SearchClient searches the repositories of the server, and the files of their default branch.
+// Text matches case-insensitively.
+type SearchClient struct {
+	*clientContext
+}
+
+// Repositories returns the repositories with the text in their name or description, and all
+// the topics of the query, sorted by owner and name.
+func (c *SearchClient) Repositories(_ context.Context, query gitprovider.SearchQuery) ([]gitprovider.RepositorySearchResult, error) {
+	query.Default()
+	if err := query.ValidateRepositoryQuery(); err != nil {
+		return nil, err
+	}
+	if query.Owner != nil {
+		if err := c.validateRef("Owner", query.Owner); err != nil {
+			return nil, err
+		}
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	text := strings.ToLower(query.Text)
+	results := []gitprovider.RepositorySearchResult{}
+	for _, repo := range c.s.searchRepositories(query.Owner) {
+		var description string
+		if repo.info.Description != nil {
+			description = *repo.info.Description
+		}
+		name := strings.ToLower(repo.ref.GetRepository())
+		if !strings.Contains(name, text) && !strings.Contains(strings.ToLower(description), text) {
+			continue
+		}
+		if !hasTopics(repo.info.Topics, query.Topics) {
+			continue
+		}
+		results = append(results, gitprovider.RepositorySearchResult{
+			Repository:  repo.ref,
+			Description: description,
+			Topics:      append([]string{}, repo.info.Topics...),
+		})
+		if len(results) == query.Limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Code returns the files of the default branches with the text in their content, sorted by
+// repository and path.
+func (c *SearchClient) Code(_ context.Context, query gitprovider.SearchQuery) ([]gitprovider.CodeSearchResult, error) {
+	query.Default()
+	if err := query.ValidateCodeQuery(); err != nil {
+		return nil, err
+	}
+	if query.Owner != nil {
+		if err := c.validateRef("Owner", query.Owner); err != nil {
+			return nil, err
+		}
+	}
+	if query.Repository != nil {
+		if err := c.validateRef("Repository", query.Repository); err != nil {
+			return nil, err
+		}
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	text := strings.ToLower(query.Text)
+	results := []gitprovider.CodeSearchResult{}
+	for _, repo := range c.s.searchRepositories(query.Owner) {
+		if query.Repository != nil && repoKey(repo.ref) != repoKey(query.Repository) {
+			continue
+		}
+		head, err := repo.resolve("")
+		if err != nil {
+			// Empty repositories have no files
+			continue
+		}
+		for _, p := range sortedPaths(head.tree) {
+			if query.Filename != "" && path.Base(p) != query.Filename {
+				continue
+			}
+			if !strings.Contains(strings.ToLower(head.tree[p]), text) {
+				continue
+			}
+			results = append(results, gitprovider.CodeSearchResult{
+				Repository: repo.ref,
+				Path:       p,
+			})
+			if len(results) == query.Limit {
+				return results, nil
+			}
+		}
+	}
+	return results, nil
+}
+
+// searchRepositories returns the repositories of the owner and its sub-organizations, or all
+// the repositories if owner is nil, sorted by owner and name.
+func (s *Server) searchRepositories(owner gitprovider.IdentityRef) []*repository {
+	var repos []*repository
+	for _, repo := range s.repos {
+		if owner != nil {
+			identity := repo.ref.GetIdentity()
+			if identity != owner.GetIdentity() && !strings.HasPrefix(identity, owner.GetIdentity()+"/") {
+				continue
+			}
+		}
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repoKey(repos[i].ref) < repoKey(repos[j].ref) })
+	return repos
+}
+
+// hasTopics returns true if all the wanted topics are in topics.
+func hasTopics(topics, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, topic := range topics {
+			if topic == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,398 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"crypto/sha1" // #nosec G505
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// DefaultUserLogin is the login of the authenticated user, unless set with Server.SetUserLogin.
+const DefaultUserLogin = "fake-user"
+
+// Server is the in-memory state of a fake Git provider: its organizations, teams and repositories.
+// A Server can be shared by several clients, e.g. to test concurrent reconcilers, and seeded with
+// the organizations and teams the API can't create. It is safe for concurrent use.
+type Server struct {
+	mu        sync.Mutex
+	domain    string
+	userLogin string
+	// tokenScopes and tokenExpiresAt are reported by Client.TokenInfo, see SetTokenInfo
+	tokenScopes    []string
+	tokenExpiresAt *time.Time
+	clock          gitprovider.Clock
+	nextID         int
+
+	orgs  map[string]*organization
+	repos map[string]*repository
+	// userKeys are the SSH and GPG keys of the authenticated user
+	userKeys []gitprovider.UserKeyInfo
+	// events are the activity events of all repositories, oldest first. They are kept when a
+	// repository is deleted, as an audit log.
+	events []gitprovider.Event
+	// tokens are the access tokens of the repositories and organizations, by the String() of
+	// their owner
+	tokens map[string][]gitprovider.AccessTokenInfo
+}
+
+// organization is an organization, with its teams by name.
+type organization struct {
+	ref   gitprovider.OrganizationRef
+	info  gitprovider.OrganizationInfo
+	teams map[string]gitprovider.TeamInfo
+}
+
+// repository is a repository, with its Git history and the resources bound to it.
+type repository struct {
+	ref  gitprovider.RepositoryRef
+	info gitprovider.RepositoryInfo
+	// createdAt and updatedAt are the times the repository was created and its info last updated
+	createdAt time.Time
+	updatedAt time.Time
+
+	commits  map[string]*commit
+	branches map[string]string
+
+	deployKeys    map[string]gitprovider.DeployKeyInfo
+	teamAccess    map[string]gitprovider.TeamAccessInfo
+	collaborators map[string]gitprovider.RepositoryPermission
+	environments  map[string]gitprovider.EnvironmentInfo
+	// protectedTags are the patterns of the tag protection rules, sorted
+	protectedTags []string
+	approvalRules map[string]gitprovider.ApprovalRuleInfo
+	pullRequests  []*pullRequest
+	milestones    []*milestone
+	mergeQueue    []gitprovider.MergeQueueEntry
+}
+
+// commit is a commit, with the full content of the repository at that commit.
+type commit struct {
+	info     gitprovider.CommitInfo
+	parents  []string
+	tree     map[string]string
+	comments []gitprovider.CommitCommentInfo
+}
+
+// pullRequest is a pull request, with its head and base branches.
+type pullRequest struct {
+	info        gitprovider.PullRequestInfo
+	createdAt   time.Time
+	updatedAt   time.Time
+	title       string
+	description string
+	branch      string
+	baseBranch  string
+	comments    []gitprovider.PullRequestCommentInfo
+	// reactions are the reactions on the pull request (key 0) and its comments, by comment ID
+	reactions map[int64][]gitprovider.ReactionInfo
+}
+
+// milestone is a milestone, with the pull requests and issues assigned to it.
+type milestone struct {
+	info         gitprovider.MilestoneInfo
+	pullRequests []int
+	issues       []int
+}
+
+// NewServer returns an empty Server for the given domain. If clock is nil, gitprovider.RealClock is used.
+func NewServer(domain string, clock gitprovider.Clock) *Server {
+	return &Server{
+		domain:    domain,
+		userLogin: DefaultUserLogin,
+		clock:     gitprovider.ClockOrDefault(clock),
+		orgs:      map[string]*organization{},
+		repos:     map[string]*repository{},
+		tokens:    map[string][]gitprovider.AccessTokenInfo{},
+	}
+}
+
+// Domain returns the domain of the server.
+func (s *Server) Domain() string {
+	return s.domain
+}
+
+// SetUserLogin sets the login of the authenticated user, which owns the user repositories
+// created by the clients, and authors their commits.
+func (s *Server) SetUserLogin(login string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userLogin = login
+}
+
+// SetTokenInfo sets the scopes and expiry of the token reported by Client.TokenInfo. If scopes
+// is nil, the scopes are reported as unknown.
+func (s *Server) SetTokenInfo(scopes []string, expiresAt *time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenScopes = scopes
+	s.tokenExpiresAt = expiresAt
+}
+
+// AddOrganization adds an organization, or updates its information if it exists. The parent
+// organizations of a sub-organization are added too.
+func (s *Server) AddOrganization(ref gitprovider.OrganizationRef, info gitprovider.OrganizationInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addOrganization(ref).info = info
+}
+
+// AddTeam adds a team with the given members to an organization, replacing the team if it
+// exists. The organization is added if it doesn't exist.
+func (s *Server) AddTeam(ref gitprovider.OrganizationRef, name string, members ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addOrganization(ref).teams[name] = gitprovider.TeamInfo{Name: name, Members: append([]string{}, members...)}
+}
+
+// ForkRepository adds a fork of the parent repository, with its branches and commits, as the
+// clients can't create forks. The fork reports the parent in RepositoryInfo.Fork.
+//
+// ErrNotFound is returned if the parent doesn't exist, and ErrAlreadyExists if the fork does.
+func (s *Server) ForkRepository(parent, fork gitprovider.RepositoryRef) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parentRepo, err := s.getRepository(parent)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.repos[repoKey(fork)]; ok {
+		return fmt.Errorf("repository %s: %w", fork.String(), gitprovider.ErrAlreadyExists)
+	}
+	info := copyRepositoryInfo(parentRepo.info)
+	info.Fork = &gitprovider.RepositoryFork{Parent: parentRepo.ref, Source: parentRepo.ref}
+	if parentRepo.info.Fork != nil {
+		info.Fork.Source = parentRepo.info.Fork.Source
+	}
+	info.Mirror = nil
+	now := s.now()
+	repo := &repository{
+		ref:           fork,
+		info:          info,
+		createdAt:     now,
+		updatedAt:     now,
+		commits:       map[string]*commit{},
+		branches:      map[string]string{},
+		deployKeys:    map[string]gitprovider.DeployKeyInfo{},
+		teamAccess:    map[string]gitprovider.TeamAccessInfo{},
+		collaborators: map[string]gitprovider.RepositoryPermission{},
+		environments:  map[string]gitprovider.EnvironmentInfo{},
+	}
+	repo.copyCommits(parentRepo)
+	for name, sha := range parentRepo.branches {
+		repo.branches[name] = sha
+	}
+	s.repos[repoKey(fork)] = repo
+	return nil
+}
+
+func (s *Server) addOrganization(ref gitprovider.OrganizationRef) *organization {
+	path := []string{ref.Organization}
+	for _, sub := range ref.SubOrganizations {
+		path = append(path, sub)
+		// Make sure the parents exist
+		if _, ok := s.orgs[strings.Join(path[:len(path)-1], "/")]; !ok {
+			s.addOrganization(orgRef(s.domain, path[:len(path)-1]))
+		}
+	}
+	key := strings.Join(path, "/")
+	if org, ok := s.orgs[key]; ok {
+		return org
+	}
+	org := &organization{
+		ref:   orgRef(s.domain, path),
+		info:  gitprovider.OrganizationInfo{Name: gitprovider.StringVar(path[len(path)-1])},
+		teams: map[string]gitprovider.TeamInfo{},
+	}
+	s.orgs[key] = org
+	return org
+}
+
+// orgRef returns the reference of the organization with the given path.
+func orgRef(domain string, path []string) gitprovider.OrganizationRef {
+	ref := gitprovider.OrganizationRef{Domain: domain, Organization: path[0]}
+	if len(path) > 1 {
+		ref.SubOrganizations = append([]string{}, path[1:]...)
+	}
+	return ref
+}
+
+// repoKey returns the key of a repository in Server.repos.
+func repoKey(ref gitprovider.RepositoryRef) string {
+	return ref.GetIdentity() + "/" + ref.GetRepository()
+}
+
+// getRepository returns the repository with the given reference, or ErrNotFound.
+func (s *Server) getRepository(ref gitprovider.RepositoryRef) (*repository, error) {
+	repo, ok := s.repos[repoKey(ref)]
+	if !ok {
+		return nil, fmt.Errorf("repository %s: %w", ref.String(), gitprovider.ErrNotFound)
+	}
+	return repo, nil
+}
+
+// hash returns a unique, SHA-1 formatted ID.
+func (s *Server) hash(parts ...string) string {
+	s.nextID++
+	h := sha1.New() // #nosec G401
+	fmt.Fprintf(h, "%d\x00%s", s.nextID, strings.Join(parts, "\x00"))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// now returns the current time of the server clock.
+func (s *Server) now() time.Time {
+	return s.clock.Now()
+}
+
+// recordEvent adds an activity event of the authenticated user to the repository.
+func (s *Server) recordEvent(r *repository, event gitprovider.Event) {
+	s.nextID++
+	event.ID = strconv.Itoa(s.nextID)
+	event.Actor = s.userLogin
+	event.Repository = r.ref
+	event.CreatedAt = s.now()
+	s.events = append(s.events, event)
+}
+
+// resolve returns the commit of a branch name or (abbreviated) commit SHA. If ref is empty,
+// the default branch is used. ErrEmptyRepository is returned for repositories without commits.
+func (r *repository) resolve(ref string) (*commit, error) {
+	if len(r.commits) == 0 {
+		return nil, gitprovider.ErrEmptyRepository
+	}
+	if ref == "" {
+		ref = *r.info.DefaultBranch
+	}
+	if sha, ok := r.branches[ref]; ok {
+		return r.commits[sha], nil
+	}
+	if c, ok := r.commits[ref]; ok {
+		return c, nil
+	}
+	if len(ref) >= 7 {
+		for sha, c := range r.commits {
+			if strings.HasPrefix(sha, ref) {
+				return c, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("reference %q: %w", ref, gitprovider.ErrNotFound)
+}
+
+// copyCommits adds the commits of another repository of the fork network, without their comments.
+func (r *repository) copyCommits(from *repository) {
+	for sha, c := range from.commits {
+		if _, ok := r.commits[sha]; ok {
+			continue
+		}
+		cc := *c
+		cc.comments = nil
+		r.commits[sha] = &cc
+	}
+}
+
+// commit adds a commit with the given parents and content, and moves the branch to it.
+func (s *Server) commit(r *repository, branch, message string, parents []string, tree map[string]string) *commit {
+	sha := s.hash(append(append([]string{message}, parents...), treeEntries(tree)...)...)
+	c := &commit{
+		info: gitprovider.CommitInfo{
+			Sha:         sha,
+			TreeSha:     s.hash(treeEntries(tree)...),
+			Author:      s.userLogin,
+			Committer:   s.userLogin,
+			Message:     message,
+			Parents:     append([]string(nil), parents...),
+			CreatedAt:   s.now(),
+			CommittedAt: s.now(),
+			URL:         fmt.Sprintf("%s/commit/%s", r.ref.String(), sha),
+		},
+		parents: parents,
+		tree:    tree,
+	}
+	r.commits[sha] = c
+	r.branches[branch] = sha
+	return c
+}
+
+// mergeBase returns the newest common ancestor of the two commits, or nil if there is none.
+func (r *repository) mergeBase(a, b *commit) *commit {
+	ancestors := map[string]bool{}
+	queue := []*commit{a}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if ancestors[c.info.Sha] {
+			continue
+		}
+		ancestors[c.info.Sha] = true
+		for _, p := range c.parents {
+			queue = append(queue, r.commits[p])
+		}
+	}
+	queue = []*commit{b}
+	seen := map[string]bool{}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if ancestors[c.info.Sha] {
+			return c
+		}
+		if seen[c.info.Sha] {
+			continue
+		}
+		seen[c.info.Sha] = true
+		for _, p := range c.parents {
+			queue = append(queue, r.commits[p])
+		}
+	}
+	return nil
+}
+
+// treeEntries returns the sorted "path\x00content" entries of a tree.
+func treeEntries(tree map[string]string) []string {
+	entries := make([]string, 0, len(tree))
+	for _, p := range sortedPaths(tree) {
+		entries = append(entries, p+"\x00"+tree[p])
+	}
+	return entries
+}
+
+func sortedPaths(tree map[string]string) []string {
+	paths := make([]string, 0, len(tree))
+	for p := range tree {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// copyTree returns a copy of the tree, which can be modified.
+func copyTree(tree map[string]string) map[string]string {
+	c := make(map[string]string, len(tree))
+	for p, content := range tree {
+		c[p] = content
+	}
+	return c
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// TagProtectionClient implements the gitprovider.TagProtectionClient interface.
+var _ gitprovider.TagProtectionClient = &TagProtectionClient{}
+
+// TagProtectionClient operates on the tag protection rules of a specific repository. The rules
+// are stored, but not enforced.
+type TagProtectionClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists the tag protection rules of the repository, sorted by pattern.
+func (c *TagProtectionClient) List(_ context.Context) ([]gitprovider.TagProtectionInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]gitprovider.TagProtectionInfo, 0, len(repo.protectedTags))
+	for _, pattern := range repo.protectedTags {
+		rules = append(rules, gitprovider.TagProtectionInfo{Pattern: pattern})
+	}
+	return rules, nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (c *TagProtectionClient) Reconcile(ctx context.Context, req gitprovider.TagProtectionInfo) (gitprovider.TagProtectionInfo, bool, error) {
+	if err := req.ValidateInfo(); err != nil {
+		return gitprovider.TagProtectionInfo{}, false, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return gitprovider.TagProtectionInfo{}, false, err
+	}
+	i := sort.SearchStrings(repo.protectedTags, req.Pattern)
+	if i < len(repo.protectedTags) && repo.protectedTags[i] == req.Pattern {
+		return req, false, nil
+	}
+	change := gitprovider.NewCreateChange(c.ref, req)
+	// In dry-run mode, only report what would be created
+	if c.dryRun {
+		return req, true, gitprovider.NewDryRunError(change)
+	}
+	repo.protectedTags = append(repo.protectedTags, "")
+	copy(repo.protectedTags[i+1:], repo.protectedTags[i:])
+	repo.protectedTags[i] = req.Pattern
+	gitprovider.RecordChange(ctx, change, apiCallCreateTagProtection)
+	return req, true, nil
+}
+
+// Delete deletes the rule with the given pattern.
+//
+// ErrNotFound is returned if there is no rule with the pattern.
+func (c *TagProtectionClient) Delete(_ context.Context, pattern string) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return err
+	}
+	i := sort.SearchStrings(repo.protectedTags, pattern)
+	if i == len(repo.protectedTags) || repo.protectedTags[i] != pattern {
+		return fmt.Errorf("tag protection %q: %w", pattern, gitprovider.ErrNotFound)
+	}
+	repo.protectedTags = append(repo.protectedTags[:i], repo.protectedTags[i+1:]...)
+	return nil
+}
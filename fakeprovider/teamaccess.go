@@ -0,0 +1,279 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// TeamAccessClient implements the gitprovider.TeamAccessClient interface.
+var _ gitprovider.TeamAccessClient = &TeamAccessClient{}
+
+// TeamAccessClient operates on the teams list for a specific repository.
+type TeamAccessClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get a team's permission level of this given repository.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *TeamAccessClient) Get(_ context.Context, name string) (gitprovider.TeamAccess, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := repo.teamAccess[name]
+	if !ok {
+		return nil, fmt.Errorf("team access %q: %w", name, gitprovider.ErrNotFound)
+	}
+	return newTeamAccess(c, info), nil
+}
+
+// List the team access control list for this repository, sorted by team name.
+func (c *TeamAccessClient) List(_ context.Context) ([]gitprovider.TeamAccess, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(repo.teamAccess))
+	for name := range repo.teamAccess {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	teamAccess := make([]gitprovider.TeamAccess, 0, len(names))
+	for _, name := range names {
+		teamAccess = append(teamAccess, newTeamAccess(c, repo.teamAccess[name]))
+	}
+	return teamAccess, nil
+}
+
+// Create adds a given team to the repository's team access control list. The team must have
+// been added to the organization of the repository with Server.AddTeam.
+//
+// ErrAlreadyExists will be returned if the resource already exists, and ErrNotFound if the
+// team doesn't exist.
+func (c *TeamAccessClient) Create(_ context.Context, req gitprovider.TeamAccessInfo) (gitprovider.TeamAccess, error) {
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	repo, err := c.s.getRepository(c.ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := repo.teamAccess[req.Name]; ok {
+		return nil, fmt.Errorf("team access %q: %w", req.Name, gitprovider.ErrAlreadyExists)
+	}
+	if !c.teamExists(req.Name) {
+		return nil, fmt.Errorf("team %q: %w", req.Name, gitprovider.ErrNotFound)
+	}
+	repo.teamAccess[req.Name] = copyTeamAccessInfo(req)
+	return newTeamAccess(c, req), nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (c *TeamAccessClient) Reconcile(ctx context.Context, req gitprovider.TeamAccessInfo) (gitprovider.TeamAccess, bool, error) {
+	// First thing, validate and default the request to ensure a valid and fully-populated object
+	// (to minimize any possible diffs between desired and actual state)
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, false, err
+	}
+
+	actual, err := c.Get(ctx, req.Name)
+	if err != nil {
+		// Create if not found
+		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(c.ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
+			resp, err := c.Create(ctx, req)
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateTeamAccess)
+			return resp, true, nil
+		}
+		return nil, false, err
+	}
+
+	// If the desired matches the actual state, just return the actual state
+	if req.Equals(actual.Get()) {
+		return actual, false, nil
+	}
+	change := gitprovider.NewUpdateChange(c.ref, actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return actual, true, gitprovider.NewDryRunError(change)
+	}
+	if err := actual.Set(req); err != nil {
+		return actual, false, err
+	}
+	if err := actual.Update(ctx); err != nil {
+		return actual, true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateTeamAccess)
+	return actual, true, nil
+}
+
+// teamExists returns true if the team exists in the organization owning the repository, or
+// any of its parents. The server lock must be held.
+func (c *TeamAccessClient) teamExists(name string) bool {
+	path := strings.Split(c.ref.GetIdentity(), "/")
+	for i := len(path); i > 0; i-- {
+		if org, ok := c.s.orgs[strings.Join(path[:i], "/")]; ok {
+			if _, ok := org.teams[name]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newTeamAccess(c *TeamAccessClient, info gitprovider.TeamAccessInfo) *teamAccess {
+	return &teamAccess{
+		info: copyTeamAccessInfo(info),
+		read: copyTeamAccessInfo(info),
+		c:    c,
+	}
+}
+
+var _ gitprovider.TeamAccess = &teamAccess{}
+
+type teamAccess struct {
+	info gitprovider.TeamAccessInfo
+	// read is the state of the team access when it was last read, checked by Update with
+	// optimistic concurrency
+	read gitprovider.TeamAccessInfo
+	c    *TeamAccessClient
+}
+
+func (ta *teamAccess) Get() gitprovider.TeamAccessInfo {
+	return copyTeamAccessInfo(ta.info)
+}
+
+func (ta *teamAccess) Set(info gitprovider.TeamAccessInfo) error {
+	if err := info.ValidateInfo(); err != nil {
+		return err
+	}
+	ta.info = copyTeamAccessInfo(info)
+	return nil
+}
+
+// APIObject returns a *gitprovider.TeamAccessInfo, as there is no provider-specific type.
+func (ta *teamAccess) APIObject() interface{} {
+	return &ta.info
+}
+
+func (ta *teamAccess) Repository() gitprovider.RepositoryRef {
+	return ta.c.ref
+}
+
+// Update will apply the desired state in this object to the server.
+//
+// ErrNotFound is returned if the resource does not exist.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the team access was
+// changed since it was read.
+func (ta *teamAccess) Update(_ context.Context) error {
+	ta.c.s.mu.Lock()
+	defer ta.c.s.mu.Unlock()
+
+	repo, err := ta.c.s.getRepository(ta.c.ref)
+	if err != nil {
+		return err
+	}
+	actual, ok := repo.teamAccess[ta.info.Name]
+	if !ok {
+		return fmt.Errorf("team access %q: %w", ta.info.Name, gitprovider.ErrNotFound)
+	}
+	if ta.c.optimisticConcurrency {
+		object := fmt.Sprintf("%s/teams/%s", ta.c.ref.String(), ta.info.Name)
+		if err := gitprovider.CheckUnchanged(object, ta.read, actual); err != nil {
+			return err
+		}
+	}
+	info := copyTeamAccessInfo(ta.info)
+	info.Default()
+	repo.teamAccess[ta.info.Name] = info
+	ta.info = copyTeamAccessInfo(info)
+	ta.read = copyTeamAccessInfo(info)
+	return nil
+}
+
+// Delete removes the given team from the repository's team access control list.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (ta *teamAccess) Delete(_ context.Context) error {
+	ta.c.s.mu.Lock()
+	defer ta.c.s.mu.Unlock()
+
+	repo, err := ta.c.s.getRepository(ta.c.ref)
+	if err != nil {
+		return err
+	}
+	if _, ok := repo.teamAccess[ta.info.Name]; !ok {
+		return fmt.Errorf("team access %q: %w", ta.info.Name, gitprovider.ErrNotFound)
+	}
+	delete(repo.teamAccess, ta.info.Name)
+	return nil
+}
+
+// Reconcile makes sure the desired state in this object (called "req" here) becomes
+// the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (ta *teamAccess) Reconcile(ctx context.Context) (bool, error) {
+	actual, actionTaken, err := ta.c.Reconcile(ctx, ta.Get())
+	if err != nil {
+		return actionTaken, err
+	}
+	ta.info = actual.Get()
+	ta.read = actual.Get()
+	return actionTaken, nil
+}
+
+// copyTeamAccessInfo returns a copy of info which doesn't share the pointer fields.
+func copyTeamAccessInfo(info gitprovider.TeamAccessInfo) gitprovider.TeamAccessInfo {
+	c := gitprovider.TeamAccessInfo{Name: info.Name}
+	if info.Permission != nil {
+		c.Permission = gitprovider.RepositoryPermissionVar(*info.Permission)
+	}
+	return c
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// TokensClient implements the gitprovider.TokensClient interface.
+var _ gitprovider.TokensClient = &TokensClient{}
+
+// TokensClient operates on the access tokens of repositories and organizations. The token values
+// are generated from the token IDs, and only returned on creation and rotation.
+type TokensClient struct {
+	*clientContext
+}
+
+// List lists the active access tokens of the repository or organization.
+//
+// ErrNotFound is returned if the owner doesn't exist.
+func (c *TokensClient) List(_ context.Context, owner gitprovider.IdentityRef) ([]gitprovider.AccessTokenInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	if err := c.validateTokenOwner(owner); err != nil {
+		return nil, err
+	}
+	tokens := []gitprovider.AccessTokenInfo{}
+	for _, token := range c.s.tokens[owner.String()] {
+		token.Token = ""
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// Create creates an access token for the repository or organization. The access level defaults
+// to maintain.
+//
+// ErrNotFound is returned if the owner doesn't exist.
+func (c *TokensClient) Create(_ context.Context, owner gitprovider.IdentityRef, req gitprovider.AccessTokenInfo) (gitprovider.AccessTokenInfo, error) {
+	if err := req.Validate(); err != nil {
+		return gitprovider.AccessTokenInfo{}, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	if err := c.validateTokenOwner(owner); err != nil {
+		return gitprovider.AccessTokenInfo{}, err
+	}
+	token := c.newToken(req.Name, req.Scopes, req.AccessLevel, req.ExpiresAt)
+	c.s.tokens[owner.String()] = append(c.s.tokens[owner.String()], token)
+	return token, nil
+}
+
+// Rotate revokes the token with the given ID, and creates a new one with the same name, scopes
+// and access level, which expires at expiresAt.
+//
+// ErrNotFound is returned if the owner or token doesn't exist.
+func (c *TokensClient) Rotate(_ context.Context, owner gitprovider.IdentityRef, id int64, expiresAt time.Time) (gitprovider.AccessTokenInfo, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	i, err := c.findToken(owner, id)
+	if err != nil {
+		return gitprovider.AccessTokenInfo{}, err
+	}
+	tokens := c.s.tokens[owner.String()]
+	old := tokens[i]
+	token := c.newToken(old.Name, old.Scopes, old.AccessLevel, &expiresAt)
+	tokens[i] = token
+	return token, nil
+}
+
+// Revoke revokes the token with the given ID.
+//
+// ErrNotFound is returned if the owner or token doesn't exist.
+func (c *TokensClient) Revoke(_ context.Context, owner gitprovider.IdentityRef, id int64) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	i, err := c.findToken(owner, id)
+	if err != nil {
+		return err
+	}
+	tokens := c.s.tokens[owner.String()]
+	c.s.tokens[owner.String()] = append(tokens[:i:i], tokens[i+1:]...)
+	return nil
+}
+
+// validateTokenOwner makes sure owner is an existing repository or organization.
+func (c *TokensClient) validateTokenOwner(owner gitprovider.IdentityRef) error {
+	if err := c.validateRef("owner", owner); err != nil {
+		return err
+	}
+	switch ref := owner.(type) {
+	case gitprovider.RepositoryRef:
+		_, err := c.s.getRepository(ref)
+		return err
+	case gitprovider.OrganizationRef:
+		if _, ok := c.s.orgs[ref.GetIdentity()]; !ok {
+			return fmt.Errorf("organization %s: %w", ref.String(), gitprovider.ErrNotFound)
+		}
+		return nil
+	default:
+		return fmt.Errorf("access tokens are owned by a repository or organization, not %s: %w", owner, gitprovider.ErrInvalidArgument)
+	}
+}
+
+// findToken returns the index of the token with the given ID of owner.
+func (c *TokensClient) findToken(owner gitprovider.IdentityRef, id int64) (int, error) {
+	if err := c.validateTokenOwner(owner); err != nil {
+		return 0, err
+	}
+	for i, token := range c.s.tokens[owner.String()] {
+		if token.ID == id {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("access token %d of %s: %w", id, owner, gitprovider.ErrNotFound)
+}
+
+// newToken returns a new token with the next ID, created now.
+func (c *TokensClient) newToken(name string, scopes []string, accessLevel *gitprovider.RepositoryPermission, expiresAt *time.Time) gitprovider.AccessTokenInfo {
+	c.s.nextID++
+	permission := gitprovider.RepositoryPermissionMaintain
+	if accessLevel != nil {
+		permission = *accessLevel
+	}
+	createdAt := c.s.clock.Now()
+	return gitprovider.AccessTokenInfo{
+		ID:          int64(c.s.nextID),
+		Name:        name,
+		Scopes:      append([]string(nil), scopes...),
+		AccessLevel: &permission,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   &createdAt,
+		Token:       fmt.Sprintf("fake-token-%d", c.s.nextID),
+	}
+}
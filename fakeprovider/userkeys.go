@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// UserKeysClient implements the gitprovider.UserKeysClient interface.
+var _ gitprovider.UserKeysClient = &UserKeysClient{}
+
+// UserKeysClient operates on the SSH and GPG keys of the authenticated user. A key can only be
+// added once, regardless of its title.
+type UserKeysClient struct {
+	*clientContext
+}
+
+// List lists the keys of the given type, in the order they were added.
+func (c *UserKeysClient) List(_ context.Context, keyType gitprovider.UserKeyType) ([]gitprovider.UserKeyInfo, error) {
+	if err := gitprovider.ValidateUserKeyType(keyType); err != nil {
+		return nil, fmt.Errorf("key type %q: %w", keyType, err)
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	keys := []gitprovider.UserKeyInfo{}
+	for _, key := range c.s.userKeys {
+		if key.Type == keyType {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Add adds the given key, returning it with its ID and creation time.
+//
+// ErrAlreadyExists is returned if the key was already added.
+func (c *UserKeysClient) Add(_ context.Context, req gitprovider.UserKeyInfo) (gitprovider.UserKeyInfo, error) {
+	if err := req.Validate(); err != nil {
+		return gitprovider.UserKeyInfo{}, err
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	for _, key := range c.s.userKeys {
+		if key.Type == req.Type && key.Key == req.Key {
+			return gitprovider.UserKeyInfo{}, gitprovider.ErrAlreadyExists
+		}
+	}
+	c.s.nextID++
+	now := c.s.clock.Now()
+	key := gitprovider.UserKeyInfo{
+		ID:        int64(c.s.nextID),
+		Type:      req.Type,
+		Title:     req.Title,
+		Key:       req.Key,
+		CreatedAt: &now,
+	}
+	c.s.userKeys = append(c.s.userKeys, key)
+	return key, nil
+}
+
+// Delete removes the key of the given type and ID.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *UserKeysClient) Delete(_ context.Context, keyType gitprovider.UserKeyType, id int64) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	for i, key := range c.s.userKeys {
+		if key.Type == keyType && key.ID == id {
+			c.s.userKeys = append(c.s.userKeys[:i], c.s.userKeys[i+1:]...)
+			return nil
+		}
+	}
+	return gitprovider.ErrNotFound
+}
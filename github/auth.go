@@ -18,6 +18,7 @@ package github
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/google/go-github/v41/github"
 
@@ -32,6 +33,18 @@ const (
 	TokenVariable = "GITHUB_TOKEN" // #nosec G101
 )
 
+func init() {
+	gitprovider.RegisterClientFactory(ProviderID, func(baseURL *url.URL, _, token string, optFns ...gitprovider.ClientOption) (gitprovider.Client, error) {
+		if baseURL != nil {
+			optFns = append(optFns, gitprovider.WithDomain(baseURL.Host))
+		}
+		if token != "" {
+			optFns = append(optFns, gitprovider.WithOAuth2Token(token))
+		}
+		return NewClient(optFns...)
+	})
+}
+
 // NewClient creates a new gitprovider.Client instance for GitHub API endpoints.
 //
 // Using WithOAuth2Token you can specify authentication
@@ -86,5 +99,17 @@ func NewClient(optFns ...gitprovider.ClientOption) (gitprovider.Client, error) {
 		destructiveActions = *opts.EnableDestructiveAPICalls
 	}
 
-	return newClient(gh, domain, destructiveActions), nil
+	c := newClient(gh, domain, destructiveActions)
+	// In dry-run mode, Reconcile calls only compute the changes they would apply
+	c.dryRun = opts.DryRun != nil && *opts.DryRun
+	c.optimisticConcurrency = opts.OptimisticConcurrency != nil && *opts.OptimisticConcurrency
+	c.repoDefaults = opts.OrgRepositoryDefaults
+	if opts.Concurrency != nil {
+		c.setConcurrency(*opts.Concurrency)
+	}
+	if opts.WaitAfterCreate != nil {
+		c.waitAfterCreate = *opts.WaitAfterCreate
+	}
+	c.clock = opts.Clock
+	return c, nil
 }
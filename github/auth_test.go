@@ -61,6 +61,35 @@ func Test_DomainVariations(t *testing.T) {
 	}
 }
 
+func Test_NewClientFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://github.com/fluxcd/go-git-providers", want: "github.com"},
+		{url: "https://github.example.com/org/repo", want: "github.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			c, err := gitprovider.NewClientFromURL(tt.url, "token")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, ProviderID, c.ProviderID())
+			assertEqual(t, tt.want, c.SupportedDomain())
+		})
+	}
+}
+
+func Test_BuildPreset(t *testing.T) {
+	c, err := gitprovider.BuildPreset("github", gitprovider.BuildOptions{Token: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, ProviderID, c.ProviderID())
+	assertEqual(t, DefaultDomain, c.SupportedDomain())
+}
+
 func assertEqual(t *testing.T, a interface{}, b interface{}) {
 	if a != b {
 		t.Fatalf("%s != %s", a, b)
@@ -18,7 +18,9 @@ package github
 
 import (
 	"context"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v41/github"
 
@@ -26,11 +28,11 @@ import (
 )
 
 // ProviderID is the provider ID for GitHub.
-const ProviderID = gitprovider.ProviderID("github")
+const ProviderID = gitprovider.ProviderGitHub
 
 func newClient(c *github.Client, domain string, destructiveActions bool) *Client {
-	ghClient := &githubClientImpl{c, destructiveActions}
-	ctx := &clientContext{ghClient, domain, destructiveActions}
+	ghClient := &githubClientImpl{c: c, destructiveActions: destructiveActions, concurrency: 1}
+	ctx := &clientContext{c: ghClient, domain: domain, destructiveActions: destructiveActions, concurrency: 1, generations: gitprovider.NewGenerationTracker()}
 	return &Client{
 		clientContext: ctx,
 		orgs: &OrganizationsClient{
@@ -42,6 +44,21 @@ func newClient(c *github.Client, domain string, destructiveActions bool) *Client
 		userRepos: &UserRepositoriesClient{
 			clientContext: ctx,
 		},
+		search: &SearchClient{
+			clientContext: ctx,
+		},
+		userKeys: &UserKeysClient{
+			clientContext: ctx,
+		},
+		invitations: &InvitationsClient{
+			clientContext: ctx,
+		},
+		events: &EventsClient{
+			clientContext: ctx,
+		},
+		tokens: &TokensClient{
+			clientContext: ctx,
+		},
 	}
 }
 
@@ -49,6 +66,20 @@ type clientContext struct {
 	c                  githubClient
 	domain             string
 	destructiveActions bool
+	// dryRun makes Reconcile calls return the changes they would apply, without writing
+	dryRun bool
+	// optimisticConcurrency makes repository Update calls fail if the repository changed since it was read
+	optimisticConcurrency bool
+	// repoDefaults are the repository defaults per organization, applied when creating repositories
+	repoDefaults gitprovider.OrgRepositoryDefaults
+	// concurrency is the number of requests aggregate List calls run at once
+	concurrency int
+	// waitAfterCreate is how long repository Create calls wait for the repository to be visible
+	waitAfterCreate time.Duration
+	// clock is used to wait for created repositories
+	clock gitprovider.Clock
+	// generations tracks the generations of the returned objects
+	generations *gitprovider.GenerationTracker
 }
 
 // Client implements the gitprovider.Client interface.
@@ -58,9 +89,25 @@ var _ gitprovider.Client = &Client{}
 type Client struct {
 	*clientContext
 
-	orgs      *OrganizationsClient
-	orgRepos  *OrgRepositoriesClient
-	userRepos *UserRepositoriesClient
+	orgs        *OrganizationsClient
+	orgRepos    *OrgRepositoriesClient
+	userRepos   *UserRepositoriesClient
+	search      *SearchClient
+	userKeys    *UserKeysClient
+	invitations *InvitationsClient
+	events      *EventsClient
+	tokens      *TokensClient
+
+	identity gitprovider.IdentityCache
+}
+
+// setConcurrency sets the number of requests aggregate List calls run at once, both for
+// paginated requests and fan-outs over the listed objects.
+func (c *Client) setConcurrency(n int) {
+	c.concurrency = n
+	if impl, ok := c.c.(*githubClientImpl); ok {
+		impl.concurrency = n
+	}
 }
 
 // SupportedDomain returns the domain endpoint for this client, e.g. "github.com", "enterprise.github.com" or
@@ -98,6 +145,31 @@ func (c *Client) UserRepositories() gitprovider.UserRepositoriesClient {
 	return c.userRepos
 }
 
+// Search returns the SearchClient searching for repositories and code.
+func (c *Client) Search() gitprovider.SearchClient {
+	return c.search
+}
+
+// UserKeys returns the UserKeysClient handling the SSH and GPG keys of the authenticated user.
+func (c *Client) UserKeys() gitprovider.UserKeysClient {
+	return c.userKeys
+}
+
+// Invitations returns the InvitationsClient handling the repository invitations of the authenticated user.
+func (c *Client) Invitations() gitprovider.InvitationsClient {
+	return c.invitations
+}
+
+// Events returns the EventsClient listing the activity of repositories and organizations.
+func (c *Client) Events() gitprovider.EventsClient {
+	return c.events
+}
+
+// Tokens returns the TokensClient handling the access tokens of repositories and organizations.
+func (c *Client) Tokens() gitprovider.TokensClient {
+	return c.tokens
+}
+
 //nolint:gochecknoglobals
 var permissionScopes = map[gitprovider.TokenPermission]string{
 	gitprovider.TokenPermissionRWRepository: "repo",
@@ -130,3 +202,129 @@ func (c *Client) HasTokenPermission(ctx context.Context, permission gitprovider.
 
 	return false, nil
 }
+
+// AuthenticatedUserID returns the identity of the authenticated user, which is a bot for GitHub
+// App tokens. It's fetched on the first call, and cached by the client.
+func (c *Client) AuthenticatedUserID(ctx context.Context) (gitprovider.Identity, error) {
+	return c.identity.Get(ctx, c.fetchIdentity)
+}
+
+// IsOwnAction returns true if the event was caused by the authenticated user.
+func (c *Client) IsOwnAction(ctx context.Context, event gitprovider.ActorEvent) (bool, error) {
+	return c.identity.IsOwnAction(ctx, event, c.fetchIdentity)
+}
+
+// tokenExpirationLayouts are the layouts of the GitHub-Authentication-Token-Expiration header.
+//
+//nolint:gochecknoglobals
+var tokenExpirationLayouts = []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"}
+
+// TokenInfo returns the authenticated user, and the expiry of personal access tokens which expire.
+// The scopes are reported by the X-OAuth-Scopes header for classic personal access tokens and OAuth
+// tokens only: GitHub doesn't disclose the permissions of fine-grained personal access tokens and
+// App tokens, for which ScopesKnown is false.
+func (c *Client) TokenInfo(ctx context.Context) (gitprovider.TokenInfo, error) {
+	// GET /user
+	user, res, err := c.c.Client().Users.Get(ctx, "")
+	if err != nil {
+		return gitprovider.TokenInfo{}, handleHTTPError(err)
+	}
+	info := gitprovider.TokenInfo{
+		Principal: gitprovider.Identity{
+			ID:    strconv.FormatInt(user.GetID(), 10),
+			Login: user.GetLogin(),
+			Bot:   user.GetType() == "Bot",
+		},
+	}
+	if values := res.Header.Values("X-OAuth-Scopes"); len(values) != 0 {
+		info.ScopesKnown = true
+		for _, s := range strings.Split(strings.Join(values, ","), ",") {
+			if scope := strings.TrimSpace(s); scope != "" {
+				info.Scopes = append(info.Scopes, scope)
+			}
+		}
+	}
+	if expiration := res.Header.Get("GitHub-Authentication-Token-Expiration"); expiration != "" {
+		for _, layout := range tokenExpirationLayouts {
+			if expiresAt, err := time.Parse(layout, expiration); err == nil {
+				info.ExpiresAt = &expiresAt
+				break
+			}
+		}
+	}
+	return info, nil
+}
+
+// repositoryPermissionsOrder lists the permissions of a repository returned by GitHub, from the
+// highest to the lowest.
+//
+//nolint:gochecknoglobals
+var repositoryPermissionsOrder = []gitprovider.RepositoryPermission{
+	gitprovider.RepositoryPermissionAdmin,
+	gitprovider.RepositoryPermissionMaintain,
+	gitprovider.RepositoryPermissionPush,
+	gitprovider.RepositoryPermissionTriage,
+	gitprovider.RepositoryPermissionPull,
+}
+
+// ValidatePermissions probes the permissions of the authenticated user on every repository, and
+// the scopes of classic tokens, which need the repo scope (or public_repo for public repositories)
+// to write. The permissions of fine-grained tokens can't be probed: they are checked with the
+// permissions of their user only.
+func (c *Client) ValidatePermissions(ctx context.Context, needs ...gitprovider.Permission) error {
+	return gitprovider.CheckPermissions(ctx, needs, c.probeRepositoryAccess)
+}
+
+func (c *Client) probeRepositoryAccess(ctx context.Context, ref gitprovider.RepositoryRef) (gitprovider.RepositoryAccess, error) {
+	if err := validateIdentityFields(ref, c.domain); err != nil {
+		return gitprovider.RepositoryAccess{}, err
+	}
+	// GET /repos/{owner}/{repo}
+	apiObj, res, err := c.c.Client().Repositories.Get(ctx, ref.GetIdentity(), ref.GetRepository())
+	if err != nil {
+		return gitprovider.RepositoryAccess{}, handleHTTPError(err)
+	}
+
+	access := gitprovider.RepositoryAccess{}
+	for _, permission := range repositoryPermissionsOrder {
+		if apiObj.GetPermissions()[string(permission)] {
+			access.Permission = permission
+			break
+		}
+	}
+	if scopes := res.Header.Values("X-OAuth-Scopes"); len(scopes) != 0 {
+		access.ReadOnly = true
+		access.ReadOnlyReason = "the token lacks the repo scope"
+		for _, s := range strings.Split(strings.Join(scopes, ","), ",") {
+			scope := strings.TrimSpace(s)
+			if scope == "repo" || (scope == "public_repo" && !apiObj.GetPrivate()) {
+				access.ReadOnly = false
+				access.ReadOnlyReason = ""
+			}
+		}
+	}
+	return access, nil
+}
+
+func (c *Client) fetchIdentity(ctx context.Context) (gitprovider.Identity, error) {
+	// GET /user
+	user, err := c.c.GetUser(ctx)
+	if err != nil {
+		return gitprovider.Identity{}, err
+	}
+	return gitprovider.Identity{
+		ID:    strconv.FormatInt(user.GetID(), 10),
+		Login: user.GetLogin(),
+		Bot:   user.GetType() == "Bot",
+	}, nil
+}
+
+// SupportedFeatures returns the features of the high-level API this provider supports.
+func (c *Client) SupportedFeatures() []gitprovider.Feature {
+	return gitprovider.SupportedFeatures(ProviderID, "")
+}
+
+// Extension returns the provider-specific extension registered under name, or nil.
+func (c *Client) Extension(name string) interface{} {
+	return gitprovider.LookupExtension(c, name)
+}
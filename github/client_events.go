@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// EventsClient implements the gitprovider.EventsClient interface.
+var _ gitprovider.EventsClient = &EventsClient{}
+
+// EventsClient lists the activity events of repositories and organizations, using the Events API.
+// GitHub only keeps the events of the last 90 days, up to 300 events.
+type EventsClient struct {
+	*clientContext
+}
+
+// ListRepository lists the events of the given repository matching opts.
+//
+// ErrNotFound is returned if the repository doesn't exist.
+func (c *EventsClient) ListRepository(ctx context.Context, ref gitprovider.RepositoryRef, opts gitprovider.EventListOptions) ([]gitprovider.Event, error) {
+	if err := validateIdentityFields(ref, c.domain); err != nil {
+		return nil, err
+	}
+	return c.list(opts, func(listOpts *github.ListOptions) ([]*github.Event, *github.Response, error) {
+		// GET /repos/{owner}/{repo}/events
+		return c.c.Client().Activity.ListRepositoryEvents(ctx, ref.GetIdentity(), ref.GetRepository(), listOpts)
+	}, func(string) gitprovider.RepositoryRef {
+		return ref
+	})
+}
+
+// ListOrganization lists the events of the repositories and members of the given organization
+// matching opts.
+func (c *EventsClient) ListOrganization(ctx context.Context, ref gitprovider.OrganizationRef, opts gitprovider.EventListOptions) ([]gitprovider.Event, error) {
+	if err := validateOrganizationRef(ref, c.domain); err != nil {
+		return nil, err
+	}
+	return c.list(opts, func(listOpts *github.ListOptions) ([]*github.Event, *github.Response, error) {
+		// GET /orgs/{org}/events
+		return c.c.Client().Activity.ListEventsForOrganization(ctx, ref.Organization, listOpts)
+	}, func(name string) gitprovider.RepositoryRef {
+		// The repository is named "owner/name"
+		return gitprovider.OrgRepositoryRef{
+			OrganizationRef: ref,
+			RepositoryName:  name[strings.LastIndex(name, "/")+1:],
+		}
+	})
+}
+
+// list pages through the events returned by fn until the limit or the Since time of opts is
+// reached, using repoRef to get the reference of the repository of each event from its name.
+func (c *EventsClient) list(opts gitprovider.EventListOptions, fn func(*github.ListOptions) ([]*github.Event, *github.Response, error), repoRef func(string) gitprovider.RepositoryRef) ([]gitprovider.Event, error) {
+	opts.Default()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	events := make([]gitprovider.Event, 0)
+	listOpts := &github.ListOptions{PerPage: 100}
+	for {
+		pageObjs, resp, err := fn(listOpts)
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		for _, apiObj := range pageObjs {
+			event := eventFromAPI(apiObj)
+			if opts.Before(event) {
+				return events, nil
+			}
+			if !opts.Matches(event) {
+				continue
+			}
+			if apiObj.GetRepo() != nil {
+				event.Repository = repoRef(apiObj.GetRepo().GetName())
+			}
+			events = append(events, event)
+			if len(events) == opts.Limit {
+				return events, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return events, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// eventFromAPI returns the normalized event, without its repository.
+func eventFromAPI(apiObj *github.Event) gitprovider.Event {
+	event := gitprovider.Event{
+		ID:        apiObj.GetID(),
+		Type:      gitprovider.EventTypeOther,
+		Actor:     apiObj.GetActor().GetLogin(),
+		CreatedAt: apiObj.GetCreatedAt(),
+	}
+	// Events with an unknown or malformed payload are reported as other events
+	payload, err := apiObj.ParsePayload()
+	if err != nil {
+		return event
+	}
+	switch p := payload.(type) {
+	case *github.PushEvent:
+		event.Type = gitprovider.EventTypePush
+		event.Branch = strings.TrimPrefix(p.GetRef(), "refs/heads/")
+	case *github.PullRequestEvent:
+		event.Type = gitprovider.EventTypePullRequest
+		event.Action = p.GetAction()
+		if event.Action == "closed" && p.GetPullRequest().GetMerged() {
+			event.Action = "merged"
+		}
+		event.PullRequestNumber = p.GetNumber()
+	case *github.MemberEvent:
+		event.Type = gitprovider.EventTypeMember
+		event.Action = p.GetAction()
+		event.Member = p.GetMember().GetLogin()
+	}
+	return event
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestEventsClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/orgs/acme/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id": "4", "type": "PushEvent", "actor": {"login": "alice"}, "repo": {"name": "acme/app"},
+				"payload": {"ref": "refs/heads/main"}, "created_at": "2021-06-01T13:00:00Z"},
+			{"id": "3", "type": "PullRequestEvent", "actor": {"login": "bob"}, "repo": {"name": "acme/app"},
+				"payload": {"action": "closed", "number": 7, "pull_request": {"merged": true}}, "created_at": "2021-06-01T12:00:00Z"},
+			{"id": "2", "type": "MemberEvent", "actor": {"login": "alice"}, "repo": {"name": "acme/lib"},
+				"payload": {"action": "added", "member": {"login": "carol"}}, "created_at": "2021-06-01T11:00:00Z"},
+			{"id": "1", "type": "PushEvent", "actor": {"login": "alice"}, "repo": {"name": "acme/lib"},
+				"payload": {"ref": "refs/heads/main"}, "created_at": "2021-06-01T10:00:00Z"}
+		]`))
+	})
+	client := newTestClient(t, mux)
+	c := client.Events()
+	org := gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "acme"}
+	since := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	events, err := c.ListOrganization(context.Background(), org, gitprovider.EventListOptions{
+		Types: []gitprovider.EventType{gitprovider.EventTypePullRequest, gitprovider.EventTypeMember},
+		Since: &since,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gitprovider.Event{
+		{
+			ID:                "3",
+			Type:              gitprovider.EventTypePullRequest,
+			Action:            "merged",
+			Actor:             "bob",
+			Repository:        gitprovider.OrgRepositoryRef{OrganizationRef: org, RepositoryName: "app"},
+			PullRequestNumber: 7,
+			CreatedAt:         time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:         "2",
+			Type:       gitprovider.EventTypeMember,
+			Action:     "added",
+			Actor:      "alice",
+			Repository: gitprovider.OrgRepositoryRef{OrganizationRef: org, RepositoryName: "lib"},
+			Member:     "carol",
+			CreatedAt:  time.Date(2021, 6, 1, 11, 0, 0, 0, time.UTC),
+		},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("ListOrganization() = %+v, want %+v", events, want)
+	}
+
+	events, err = c.ListOrganization(context.Background(), org, gitprovider.EventListOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != gitprovider.EventTypePush || events[0].Branch != "main" {
+		t.Errorf("ListOrganization() with a limit = %+v, want the push to main", events)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// InvitationsClient implements the gitprovider.InvitationsClient interface.
+var _ gitprovider.InvitationsClient = &InvitationsClient{}
+
+// InvitationsClient operates on the pending repository invitations of the authenticated user.
+type InvitationsClient struct {
+	*clientContext
+}
+
+// List lists the pending invitations of the authenticated user.
+//
+// List returns all available invitations, using multiple paginated requests if needed.
+func (c *InvitationsClient) List(ctx context.Context) ([]gitprovider.RepositoryInvitation, error) {
+	invitations := make([]gitprovider.RepositoryInvitation, 0)
+	opts := &github.ListOptions{}
+	err := allPages(opts, func() (*github.Response, error) {
+		// GET /user/repository_invitations
+		pageObjs, resp, err := c.c.Client().Users.ListInvitations(ctx, opts)
+		for _, apiObj := range pageObjs {
+			invitations = append(invitations, gitprovider.RepositoryInvitation{
+				ID:         apiObj.GetID(),
+				Repository: repositoryRefFromAPI(c.domain, apiObj.GetRepo()),
+				Inviter:    apiObj.GetInviter().GetLogin(),
+				Permission: invitationPermission(apiObj.GetPermissions()),
+				CreatedAt:  apiObj.GetCreatedAt().Time,
+			})
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// Accept accepts the invitation with the given ID.
+//
+// ErrNotFound is returned if there is no such pending invitation.
+func (c *InvitationsClient) Accept(ctx context.Context, id int64) error {
+	// PATCH /user/repository_invitations/{id}
+	_, err := c.c.Client().Users.AcceptInvitation(ctx, id)
+	return handleHTTPError(err)
+}
+
+// Decline declines the invitation with the given ID.
+//
+// ErrNotFound is returned if there is no such pending invitation.
+func (c *InvitationsClient) Decline(ctx context.Context, id int64) error {
+	// DELETE /user/repository_invitations/{id}
+	_, err := c.c.Client().Users.DeclineInvitation(ctx, id)
+	return handleHTTPError(err)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestInvitationsClient(t *testing.T) {
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/user/repository_invitations", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 1, "permissions": "write", "created_at": "2021-06-01T10:00:00Z",
+			"inviter": {"login": "alice"},
+			"repository": {"name": "app", "owner": {"login": "acme", "type": "Organization"}}}]`))
+	})
+	mux.HandleFunc("/api/v3/user/repository_invitations/1", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/api/v3/user/repository_invitations/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	})
+	client := newTestClient(t, mux)
+	c := client.Invitations()
+	ctx := context.Background()
+
+	invitations, err := c.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gitprovider.RepositoryInvitation{{
+		ID: 1,
+		Repository: gitprovider.OrgRepositoryRef{
+			OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "acme"},
+			RepositoryName:  "app",
+		},
+		Inviter:    "alice",
+		Permission: gitprovider.RepositoryPermissionPush,
+		CreatedAt:  time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+	}}
+	if !reflect.DeepEqual(invitations, want) {
+		t.Errorf("List() = %+v, want %+v", invitations, want)
+	}
+
+	if err := c.Accept(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Decline(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{http.MethodPatch, http.MethodDelete}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	if err := c.Accept(ctx, 2); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Accept() of a missing invitation error = %v, want ErrNotFound", err)
+	}
+}
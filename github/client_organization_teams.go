@@ -74,16 +74,16 @@ func (c *TeamsClient) List(ctx context.Context) ([]gitprovider.Team, error) {
 	}
 
 	// Use .Get() to get detailed information about each member
-	teams := make([]gitprovider.Team, 0, len(apiObjs))
-	for _, apiObj := range apiObjs {
+	teams := make([]gitprovider.Team, len(apiObjs))
+	err = gitprovider.RunConcurrently(c.concurrency, len(apiObjs), func(i int) error {
 		// Get detailed information about individual teams (including members).
 		// Slug is validated to be non-nil in ListOrgTeams.
-		team, err := c.Get(ctx, *apiObj.Slug)
-		if err != nil {
-			return nil, err
-		}
-
-		teams = append(teams, team)
+		team, err := c.Get(ctx, *apiObjs[i].Slug)
+		teams[i] = team
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return teams, nil
@@ -50,9 +50,10 @@ func (c *OrganizationsClient) Get(ctx context.Context, ref gitprovider.Organizat
 }
 
 // List all top-level organizations the specific user has access to.
+// GitHub organizations don't have sub-organizations, so OrganizationListOptions.Depth is ignored.
 //
 // List returns all available organizations, using multiple paginated requests if needed.
-func (c *OrganizationsClient) List(ctx context.Context) ([]gitprovider.Organization, error) {
+func (c *OrganizationsClient) List(ctx context.Context, _ ...gitprovider.OrganizationListOption) ([]gitprovider.Organization, error) {
 	// GET /user/orgs
 	apiObjs, err := c.c.ListOrgs(ctx)
 	if err != nil {
@@ -77,6 +78,6 @@ func (c *OrganizationsClient) List(ctx context.Context) ([]gitprovider.Organizat
 // This is not supported in GitHub.
 //
 // Children returns all available organizations, using multiple paginated requests if needed.
-func (c *OrganizationsClient) Children(_ context.Context, _ gitprovider.OrganizationRef) ([]gitprovider.Organization, error) {
+func (c *OrganizationsClient) Children(_ context.Context, _ gitprovider.OrganizationRef, _ ...gitprovider.OrganizationListOption) ([]gitprovider.Organization, error) {
 	return nil, gitprovider.ErrNoProviderSupport
 }
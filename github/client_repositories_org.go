@@ -19,6 +19,8 @@ package github
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 
 	"github.com/google/go-github/v41/github"
 
@@ -33,10 +35,11 @@ type OrgRepositoriesClient struct {
 	*clientContext
 }
 
-// Get returns the repository at the given path.
+// Get returns the repository at the given path. The statistics requested with
+// RepositoryGetOptions.WithStats take three more requests.
 //
 // ErrNotFound is returned if the resource does not exist.
-func (c *OrgRepositoriesClient) Get(ctx context.Context, ref gitprovider.OrgRepositoryRef) (gitprovider.OrgRepository, error) {
+func (c *OrgRepositoriesClient) Get(ctx context.Context, ref gitprovider.OrgRepositoryRef, opts ...gitprovider.RepositoryGetOption) (gitprovider.OrgRepository, error) {
 	// Make sure the OrgRepositoryRef is valid
 	if err := validateOrgRepositoryRef(ref, c.domain); err != nil {
 		return nil, err
@@ -46,20 +49,41 @@ func (c *OrgRepositoriesClient) Get(ctx context.Context, ref gitprovider.OrgRepo
 	if err != nil {
 		return nil, err
 	}
-	return newOrgRepository(c.clientContext, apiObj, ref), nil
+	repo := newOrgRepository(c.clientContext, apiObj, ref)
+	if o := gitprovider.MakeRepositoryGetOptions(opts...); o.WithStats != nil && *o.WithStats {
+		if repo.stats, err = getRepositoryStats(ctx, c.clientContext, apiObj, ref); err != nil {
+			return nil, err
+		}
+	}
+	return repo, nil
 }
 
 // List all repositories in the given organization.
+// The visibility filter is passed to GitHub as the type of the repositories. With an activity filter,
+// the repositories are sorted by their last push, and the listing stops at the first one pushed
+// before ActiveSince. The name and archival filters are applied to the received list.
 //
 // List returns all available repositories, using multiple paginated requests if needed.
-func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.OrganizationRef) ([]gitprovider.OrgRepository, error) {
+func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.OrganizationRef, opts ...gitprovider.RepositoryListOption) ([]gitprovider.OrgRepository, error) {
 	// Make sure the OrganizationRef is valid
 	if err := validateOrganizationRef(ref, c.domain); err != nil {
 		return nil, err
 	}
+	o, err := gitprovider.MakeRepositoryListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	listOpts := orgRepoListOptions(o)
 
 	// GET /orgs/{org}/repos
-	apiObjs, err := c.c.ListOrgRepos(ctx, ref.Organization)
+	var apiObjs []*github.Repository
+	if o.ActiveSince != nil {
+		apiObjs, err = listUntilInactive(*o.ActiveSince, func(page int) ([]*github.Repository, int, error) {
+			return c.c.ListOrgReposPage(ctx, ref.Organization, listOpts, page)
+		})
+	} else {
+		apiObjs, err = c.c.ListOrgRepos(ctx, ref.Organization, listOpts)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -68,6 +92,9 @@ func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.Organi
 	repos := make([]gitprovider.OrgRepository, 0, len(apiObjs))
 	for _, apiObj := range apiObjs {
 		// apiObj is already validated at ListOrgRepos
+		if !o.Matches(*apiObj.Name, repositoryFromAPI(apiObj), lastActivityFromAPI(apiObj)) {
+			continue
+		}
 		repos = append(repos, newOrgRepository(c.clientContext, apiObj, gitprovider.OrgRepositoryRef{
 			OrganizationRef: ref,
 			RepositoryName:  *apiObj.Name,
@@ -76,6 +103,58 @@ func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.Organi
 	return repos, nil
 }
 
+// ListIter returns an iterator over the repositories in the given organization, which fetches
+// one page at a time. The filters are handled like in List.
+func (c *OrgRepositoriesClient) ListIter(ctx context.Context, ref gitprovider.OrganizationRef, opts ...gitprovider.RepositoryListOption) *gitprovider.OrgRepositoryIterator {
+	o, optsErr := gitprovider.MakeRepositoryListOptions(opts...)
+	listOpts := orgRepoListOptions(o)
+	return gitprovider.NewOrgRepositoryIterator(ctx, func(ctx context.Context, page int) ([]gitprovider.OrgRepository, int, error) {
+		if optsErr != nil {
+			return nil, 0, optsErr
+		}
+		// Make sure the OrganizationRef is valid
+		if err := validateOrganizationRef(ref, c.domain); err != nil {
+			return nil, 0, err
+		}
+
+		// GET /orgs/{org}/repos
+		apiObjs, next, err := c.c.ListOrgReposPage(ctx, ref.Organization, listOpts, page)
+		if err != nil {
+			return nil, 0, err
+		}
+		apiObjs, next = untilInactive(apiObjs, next, o.ActiveSince)
+
+		repos := make([]gitprovider.OrgRepository, 0, len(apiObjs))
+		for _, apiObj := range apiObjs {
+			// apiObj is already validated at ListOrgReposPage
+			if !o.Matches(*apiObj.Name, repositoryFromAPI(apiObj), lastActivityFromAPI(apiObj)) {
+				continue
+			}
+			repos = append(repos, newOrgRepository(c.clientContext, apiObj, gitprovider.OrgRepositoryRef{
+				OrganizationRef: ref,
+				RepositoryName:  *apiObj.Name,
+			}))
+		}
+		return repos, next, nil
+	})
+}
+
+// orgRepoListOptions returns the parameters of "GET /orgs/{org}/repos" for the filters GitHub
+// supports: the visibility, as the type of the repositories, and the activity, by sorting the
+// repositories by their last push.
+func orgRepoListOptions(o gitprovider.RepositoryListOptions) github.RepositoryListByOrgOptions {
+	listOpts := github.RepositoryListByOrgOptions{}
+	if o.Visibility != nil {
+		// The type of the repositories can be their visibility
+		listOpts.Type = string(*o.Visibility)
+	}
+	if o.ActiveSince != nil {
+		listOpts.Sort = "pushed"
+		listOpts.Direction = "desc"
+	}
+	return listOpts
+}
+
 // Create creates a repository for the given organization, with the data and options.
 //
 // ErrAlreadyExists will be returned if the resource already exists.
@@ -85,10 +164,17 @@ func (c *OrgRepositoriesClient) Create(ctx context.Context, ref gitprovider.OrgR
 		return nil, err
 	}
 
-	apiObj, err := createRepository(ctx, c.c, ref, ref.Organization, req, opts...)
+	// Use the organization defaults for the unset fields, and the default create options
+	defaults := c.repoDefaults.For(ref.OrganizationRef)
+	defaults.ApplyToRepositoryInfo(&req)
+
+	apiObj, err := createRepository(ctx, c.c, ref, ref.Organization, req, defaults.MergeCreateOptions(opts...)...)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.waitForRepository(ctx, apiObj); err != nil {
+		return nil, err
+	}
 	return newOrgRepository(c.clientContext, apiObj, ref), nil
 }
 
@@ -98,6 +184,9 @@ func (c *OrgRepositoriesClient) Create(ctx context.Context, ref gitprovider.OrgR
 // If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
 // If req is already the actual state, this is a no-op (actionTaken == false).
 func (c *OrgRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.OrgRepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryReconcileOption) (gitprovider.OrgRepository, bool, error) {
+	// Use the organization defaults for the unset fields, instead of the built-in defaults
+	c.repoDefaults.For(ref.OrganizationRef).ApplyToRepositoryInfo(&req)
+
 	// First thing, validate and default the request to ensure a valid and fully-populated object
 	// (to minimize any possible diffs between desired and actual state)
 	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
@@ -108,18 +197,45 @@ func (c *OrgRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.O
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := c.Create(ctx, ref, req, toCreateOpts(opts...)...)
-			return resp, true, err
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateOrgRepository)
+			return resp, true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
 		return nil, false, err
 	}
 	// Run generic reconciliation
-	actionTaken, err := reconcileRepository(ctx, actual, req)
+	actionTaken, err := reconcileRepository(ctx, actual, req, c.dryRun)
 	return actual, actionTaken, err
 }
 
+// CreateFromTemplate creates a repository for the given organization from the template repository,
+// like Create with RepositoryCreateOptions.TemplateRepository.
+func (c *OrgRepositoriesClient) CreateFromTemplate(ctx context.Context, ref gitprovider.OrgRepositoryRef, template gitprovider.RepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.OrgRepository, error) {
+	return c.Create(ctx, ref, req, append(opts, &gitprovider.RepositoryCreateOptions{TemplateRepository: template})...)
+}
+
+// DownloadArchive returns an archive of the repository contents at the given Git reference,
+// which can be a branch, tag or commit SHA. If gitRef is empty, the default branch is used.
+//
+// The caller is responsible for closing the returned reader.
+// ErrNotFound is returned if the repository or the reference does not exist.
+func (c *OrgRepositoriesClient) DownloadArchive(ctx context.Context, ref gitprovider.OrgRepositoryRef, gitRef string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	// Make sure the RepositoryRef is valid
+	if err := validateOrgRepositoryRef(ref, c.domain); err != nil {
+		return nil, err
+	}
+	return c.c.DownloadArchive(ctx, ref.GetIdentity(), ref.GetRepository(), gitRef, format)
+}
+
 func createRepository(ctx context.Context, c githubClient, ref gitprovider.RepositoryRef, orgName string, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (*github.Repository, error) {
 	// First thing, validate and default the request to ensure a valid and fully-populated object
 	// (to minimize any possible diffs between desired and actual state)
@@ -137,20 +253,54 @@ func createRepository(ctx context.Context, c githubClient, ref gitprovider.Repos
 	data := repositoryToAPI(&req, ref)
 	applyRepoCreateOptions(&data, o)
 
-	return c.CreateRepo(ctx, orgName, &data)
+	var apiObj *github.Repository
+	if tmpl := o.TemplateRepository; tmpl != nil {
+		if tmpl.GetDomain() != ref.GetDomain() {
+			return nil, fmt.Errorf("template repository %s must be on %s: %w", tmpl.String(), ref.GetDomain(), gitprovider.ErrInvalidArgument)
+		}
+		apiObj, err = c.CreateRepoFromTemplate(ctx, tmpl.GetIdentity(), tmpl.GetRepository(), orgName, &data)
+	} else {
+		apiObj, err = c.CreateRepo(ctx, orgName, &data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// GitHub uses the default branch name of the owner, or of the template, for the first commit
+	hasCommits := o.TemplateRepository != nil || (o.AutoInit != nil && *o.AutoInit)
+	if hasCommits && apiObj.GetDefaultBranch() != *req.DefaultBranch {
+		if err := c.RenameBranch(ctx, apiObj.GetOwner().GetLogin(), apiObj.GetName(), apiObj.GetDefaultBranch(), *req.DefaultBranch); err != nil {
+			return nil, err
+		}
+		apiObj.DefaultBranch = req.DefaultBranch
+	}
+	// Repositories can't be created archived, hence archive them last
+	if req.Archived != nil && *req.Archived {
+		return c.UpdateRepo(ctx, apiObj.GetOwner().GetLogin(), apiObj.GetName(), &github.Repository{Archived: req.Archived})
+	}
+	return apiObj, nil
 }
 
-func reconcileRepository(ctx context.Context, actual gitprovider.UserRepository, req gitprovider.RepositoryInfo) (bool, error) {
+func reconcileRepository(ctx context.Context, actual gitprovider.UserRepository, req gitprovider.RepositoryInfo, dryRun bool) (bool, error) {
 	// If the desired matches the actual state, just return the actual state
 	if req.Equals(actual.Get()) {
 		return false, nil
 	}
+	change := gitprovider.NewUpdateChange(actual.Repository(), actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
 	// Populate the desired state to the current-actual object
 	if err := actual.Set(req); err != nil {
 		return false, err
 	}
 	// Apply the desired state by running Update
-	return true, actual.Update(ctx)
+	if err := actual.Update(ctx); err != nil {
+		return true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateRepository)
+	return true, nil
 }
 
 func toCreateOpts(opts ...gitprovider.RepositoryReconcileOption) []gitprovider.RepositoryCreateOption {
@@ -161,3 +311,15 @@ func toCreateOpts(opts ...gitprovider.RepositoryReconcileOption) []gitprovider.R
 	}
 	return createOpts
 }
+
+// waitForRepository waits for a created repository to become visible, if WithWaitAfterCreate is set.
+func (c *clientContext) waitForRepository(ctx context.Context, apiObj *github.Repository) error {
+	if c.waitAfterCreate == 0 {
+		return nil
+	}
+	waiter := gitprovider.NewVisibilityWaiter(gitprovider.ProviderGitHub, c.clock)
+	return waiter.WaitUntilVisible(ctx, func(ctx context.Context) error {
+		_, err := c.c.GetRepo(ctx, apiObj.GetOwner().GetLogin(), apiObj.GetName())
+		return err
+	}, c.waitAfterCreate)
+}
@@ -19,6 +19,9 @@ package github
 import (
 	"context"
 	"errors"
+	"io"
+
+	"github.com/google/go-github/v41/github"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 )
@@ -31,10 +34,11 @@ type UserRepositoriesClient struct {
 	*clientContext
 }
 
-// Get returns the repository at the given path.
+// Get returns the repository at the given path. The statistics requested with
+// RepositoryGetOptions.WithStats take three more requests.
 //
 // ErrNotFound is returned if the resource does not exist.
-func (c *UserRepositoriesClient) Get(ctx context.Context, ref gitprovider.UserRepositoryRef) (gitprovider.UserRepository, error) {
+func (c *UserRepositoriesClient) Get(ctx context.Context, ref gitprovider.UserRepositoryRef, opts ...gitprovider.RepositoryGetOption) (gitprovider.UserRepository, error) {
 	// Make sure the UserRepositoryRef is valid
 	if err := validateUserRepositoryRef(ref, c.domain); err != nil {
 		return nil, err
@@ -44,20 +48,43 @@ func (c *UserRepositoriesClient) Get(ctx context.Context, ref gitprovider.UserRe
 	if err != nil {
 		return nil, err
 	}
-	return newUserRepository(c.clientContext, apiObj, ref), nil
+	repo := newUserRepository(c.clientContext, apiObj, ref)
+	if o := gitprovider.MakeRepositoryGetOptions(opts...); o.WithStats != nil && *o.WithStats {
+		if repo.stats, err = getRepositoryStats(ctx, c.clientContext, apiObj, ref); err != nil {
+			return nil, err
+		}
+	}
+	return repo, nil
 }
 
 // List all repositories in the given organization.
 //
+// GitHub can't filter the repositories of a user, so the filters are applied to the received list.
+// With an activity filter, the repositories are sorted by their last push, and the listing stops
+// at the first one pushed before ActiveSince.
+//
 // List returns all available repositories, using multiple paginated requests if needed.
-func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserRef) ([]gitprovider.UserRepository, error) {
+func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserRef, opts ...gitprovider.RepositoryListOption) ([]gitprovider.UserRepository, error) {
 	// Make sure the UserRef is valid
 	if err := validateUserRef(ref, c.domain); err != nil {
 		return nil, err
 	}
+	o, err := gitprovider.MakeRepositoryListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := userRepoListOptions(o)
 
 	// GET /users/{username}/repos
-	apiObjs, err := c.c.ListUserRepos(ctx, ref.UserLogin)
+	var apiObjs []*github.Repository
+	if o.ActiveSince != nil {
+		apiObjs, err = listUntilInactive(*o.ActiveSince, func(page int) ([]*github.Repository, int, error) {
+			return c.c.ListUserReposPage(ctx, ref.UserLogin, listOpts, page)
+		})
+	} else {
+		apiObjs, err = c.c.ListUserRepos(ctx, ref.UserLogin, listOpts)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +93,9 @@ func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserR
 	repos := make([]gitprovider.UserRepository, 0, len(apiObjs))
 	for _, apiObj := range apiObjs {
 		// apiObj is already validated at ListUserRepos
+		if !o.Matches(*apiObj.Name, repositoryFromAPI(apiObj), lastActivityFromAPI(apiObj)) {
+			continue
+		}
 		repos = append(repos, newUserRepository(c.clientContext, apiObj, gitprovider.UserRepositoryRef{
 			UserRef:        ref,
 			RepositoryName: *apiObj.Name,
@@ -74,6 +104,53 @@ func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserR
 	return repos, nil
 }
 
+// ListIter returns an iterator over the repositories of the given user, which fetches one page
+// at a time. The filters are handled like in List.
+func (c *UserRepositoriesClient) ListIter(ctx context.Context, ref gitprovider.UserRef, opts ...gitprovider.RepositoryListOption) *gitprovider.UserRepositoryIterator {
+	o, optsErr := gitprovider.MakeRepositoryListOptions(opts...)
+	listOpts := userRepoListOptions(o)
+	return gitprovider.NewUserRepositoryIterator(ctx, func(ctx context.Context, page int) ([]gitprovider.UserRepository, int, error) {
+		if optsErr != nil {
+			return nil, 0, optsErr
+		}
+		// Make sure the UserRef is valid
+		if err := validateUserRef(ref, c.domain); err != nil {
+			return nil, 0, err
+		}
+
+		// GET /users/{username}/repos
+		apiObjs, next, err := c.c.ListUserReposPage(ctx, ref.UserLogin, listOpts, page)
+		if err != nil {
+			return nil, 0, err
+		}
+		apiObjs, next = untilInactive(apiObjs, next, o.ActiveSince)
+
+		repos := make([]gitprovider.UserRepository, 0, len(apiObjs))
+		for _, apiObj := range apiObjs {
+			// apiObj is already validated at ListUserReposPage
+			if !o.Matches(*apiObj.Name, repositoryFromAPI(apiObj), lastActivityFromAPI(apiObj)) {
+				continue
+			}
+			repos = append(repos, newUserRepository(c.clientContext, apiObj, gitprovider.UserRepositoryRef{
+				UserRef:        ref,
+				RepositoryName: *apiObj.Name,
+			}))
+		}
+		return repos, next, nil
+	})
+}
+
+// userRepoListOptions returns the parameters of "GET /users/{username}/repos" for the filters GitHub
+// supports: only the activity, by sorting the repositories by their last push.
+func userRepoListOptions(o gitprovider.RepositoryListOptions) github.RepositoryListOptions {
+	listOpts := github.RepositoryListOptions{}
+	if o.ActiveSince != nil {
+		listOpts.Sort = "pushed"
+		listOpts.Direction = "desc"
+	}
+	return listOpts
+}
+
 // Create creates a repository for the given organization, with the data and options
 //
 // ErrAlreadyExists will be returned if the resource already exists.
@@ -91,6 +168,9 @@ func (c *UserRepositoriesClient) Create(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+	if err := c.waitForRepository(ctx, apiObj); err != nil {
+		return nil, err
+	}
 	return newUserRepository(c.clientContext, apiObj, ref), nil
 }
 
@@ -110,8 +190,16 @@ func (c *UserRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := c.Create(ctx, ref, req, toCreateOpts(opts...)...)
-			return resp, true, err
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateUserRepository)
+			return resp, true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
@@ -119,6 +207,38 @@ func (c *UserRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.
 	}
 
 	// Run generic reconciliation
-	actionTaken, err := reconcileRepository(ctx, actual, req)
+	actionTaken, err := reconcileRepository(ctx, actual, req, c.dryRun)
 	return actual, actionTaken, err
 }
+
+// CreateFromTemplate creates a repository for the given user from the template repository,
+// like Create with RepositoryCreateOptions.TemplateRepository.
+func (c *UserRepositoriesClient) CreateFromTemplate(ctx context.Context, ref gitprovider.UserRepositoryRef, template gitprovider.RepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.UserRepository, error) {
+	return c.Create(ctx, ref, req, append(opts, &gitprovider.RepositoryCreateOptions{TemplateRepository: template})...)
+}
+
+// DownloadArchive returns an archive of the repository contents at the given Git reference,
+// which can be a branch, tag or commit SHA. If gitRef is empty, the default branch is used.
+//
+// The caller is responsible for closing the returned reader.
+// ErrNotFound is returned if the repository or the reference does not exist.
+func (c *UserRepositoriesClient) DownloadArchive(ctx context.Context, ref gitprovider.UserRepositoryRef, gitRef string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	// Make sure the RepositoryRef is valid
+	if err := validateUserRepositoryRef(ref, c.domain); err != nil {
+		return nil, err
+	}
+	return c.c.DownloadArchive(ctx, ref.GetIdentity(), ref.GetRepository(), gitRef, format)
+}
+
+// GetUserLogin returns the UserRef of the authenticated user.
+func (c *UserRepositoriesClient) GetUserLogin(ctx context.Context) (gitprovider.UserRef, error) {
+	// GET /user
+	user, err := c.c.GetUser(ctx)
+	if err != nil {
+		return gitprovider.UserRef{}, err
+	}
+	return gitprovider.UserRef{
+		Domain:    c.domain,
+		UserLogin: user.GetLogin(),
+	}, nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// ApprovalRuleClient implements the gitprovider.ApprovalRuleClient interface.
+var _ gitprovider.ApprovalRuleClient = &ApprovalRuleClient{}
+
+// ApprovalRuleClient operates on the approval rules for a specific repository.
+// GitHub has no named approval rules: the required approvals and code owner reviews are pull
+// request rules of the rulesets, see Extras.RepositoryRulesets, so every method returns
+// gitprovider.ErrNoProviderSupport.
+type ApprovalRuleClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists the approval rules of the repository.
+func (c *ApprovalRuleClient) List(_ context.Context) ([]gitprovider.ApprovalRuleInfo, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+func (c *ApprovalRuleClient) Reconcile(_ context.Context, _ gitprovider.ApprovalRuleInfo) (gitprovider.ApprovalRuleInfo, bool, error) {
+	return gitprovider.ApprovalRuleInfo{}, false, gitprovider.ErrNoProviderSupport
+}
+
+// Delete deletes the rule with the given name.
+func (c *ApprovalRuleClient) Delete(_ context.Context, _ string) error {
+	return gitprovider.ErrNoProviderSupport
+}
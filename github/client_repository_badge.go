@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+// releaseBadgeURL is the shields.io badge of the latest release of a repository on github.com,
+// which has no release badge of its own.
+const releaseBadgeURL = "https://img.shields.io/github/v/release/%s/%s"
+
+// BadgeClient implements the gitprovider.BadgeClient interface.
+var _ gitprovider.BadgeClient = &BadgeClient{}
+
+// BadgeClient generates the URLs of the status badges for a specific repository.
+type BadgeClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// URL returns the canonical URL of the badge image described by req. Pipeline badges are the
+// status badges of a GitHub Actions workflow, which req.Workflow is required for. Release
+// badges are shields.io badges, only available on github.com.
+//
+// ErrNotFound is returned if the workflow, branch or latest release doesn't exist.
+func (c *BadgeClient) URL(ctx context.Context, req gitprovider.BadgeRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	switch req.Kind {
+	case gitprovider.BadgeKindPipeline:
+		if len(req.Workflow) == 0 {
+			validator := validation.New("Badge")
+			validator.Required("Workflow")
+			return "", validator.Error()
+		}
+		// GET /repos/{owner}/{repo}/actions/workflows/{workflow_id}
+		if _, _, err := c.c.Client().Actions.GetWorkflowByFileName(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), req.Workflow); err != nil {
+			return "", handleHTTPError(err)
+		}
+		badgeURL := fmt.Sprintf("%s/actions/workflows/%s/badge.svg", c.ref.String(), url.PathEscape(req.Workflow))
+		if len(req.Branch) == 0 {
+			// Without branch, the badge is the status of the default branch
+			return badgeURL, nil
+		}
+		// GET /repos/{owner}/{repo}/git/ref/heads/{branch}
+		if _, _, err := c.c.Client().Git.GetRef(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), "heads/"+req.Branch); err != nil {
+			return "", handleHTTPError(err)
+		}
+		return fmt.Sprintf("%s?branch=%s", badgeURL, url.QueryEscape(req.Branch)), nil
+	default:
+		if c.domain != DefaultDomain {
+			return "", fmt.Errorf("release badges are only available on %s: %w", DefaultDomain, gitprovider.ErrNoProviderSupport)
+		}
+		// GET /repos/{owner}/{repo}/releases/latest
+		if _, _, err := c.c.Client().Repositories.GetLatestRelease(ctx, c.ref.GetIdentity(), c.ref.GetRepository()); err != nil {
+			return "", handleHTTPError(err)
+		}
+		return fmt.Sprintf(releaseBadgeURL, c.ref.GetIdentity(), c.ref.GetRepository()), nil
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+func TestBadgeClient_URL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/actions/workflows/ci.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 1, "path": ".github/workflows/ci.yaml"}`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/git/ref/heads/main", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ref": "refs/heads/main", "object": {"sha": "abc"}}`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "v1.0.0"}`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &BadgeClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		req     gitprovider.BadgeRequest
+		want    string
+		wantErr error
+	}{
+		{
+			name: "workflow of the default branch",
+			req:  gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindPipeline, Workflow: "ci.yaml"},
+			want: "https://github.example.com/org/repo/actions/workflows/ci.yaml/badge.svg",
+		},
+		{
+			name: "workflow of a branch",
+			req:  gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindPipeline, Workflow: "ci.yaml", Branch: "main"},
+			want: "https://github.example.com/org/repo/actions/workflows/ci.yaml/badge.svg?branch=main",
+		},
+		{
+			name:    "missing workflow",
+			req:     gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindPipeline, Workflow: "release.yaml"},
+			wantErr: gitprovider.ErrNotFound,
+		},
+		{
+			name:    "missing branch",
+			req:     gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindPipeline, Workflow: "ci.yaml", Branch: "dev"},
+			wantErr: gitprovider.ErrNotFound,
+		},
+		{
+			name:    "workflow required",
+			req:     gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindPipeline},
+			wantErr: validation.ErrFieldRequired,
+		},
+		{
+			name:    "release badges are only on github.com",
+			req:     gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindRelease},
+			wantErr: gitprovider.ErrNoProviderSupport,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.URL(ctx, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("URL() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("URL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// On github.com, release badges are shields.io badges
+	c.clientContext = newClient(client.c.Client(), DefaultDomain, false).clientContext
+	got, err := c.URL(ctx, gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindRelease})
+	if want := "https://img.shields.io/github/v/release/org/repo"; err != nil || got != want {
+		t.Errorf("URL() = %q, %v, want %q", got, err, want)
+	}
+}
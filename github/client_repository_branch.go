@@ -18,6 +18,9 @@ package github
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/google/go-github/v41/github"
@@ -50,3 +53,172 @@ func (c *BranchClient) Create(ctx context.Context, branch, sha string) error {
 
 	return nil
 }
+
+// Delete deletes the branch with the given name.
+func (c *BranchClient) Delete(ctx context.Context, branch string) error {
+	// DELETE /repos/{owner}/{repo}/git/refs/{ref}
+	_, err := c.c.Client().Git.DeleteRef(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), "heads/"+branch)
+	return handleHTTPError(err)
+}
+
+// List lists all branches in the repository, including their protection status.
+func (c *BranchClient) List(ctx context.Context) ([]gitprovider.Branch, error) {
+	// GET /repos/{owner}/{repo}
+	repo, err := c.c.GetRepo(ctx, c.ref.GetIdentity(), c.ref.GetRepository())
+	if err != nil {
+		return nil, err
+	}
+
+	var apiObjs []*github.Branch
+	opts := &github.BranchListOptions{}
+	err = allPages(&opts.ListOptions, func() (*github.Response, error) {
+		// GET /repos/{owner}/{repo}/branches
+		pageObjs, resp, listErr := c.c.Client().Repositories.ListBranches(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), opts)
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make([]gitprovider.Branch, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		branches = append(branches, newBranch(c.clientContext, apiObj, repo.GetDefaultBranch()))
+	}
+	return branches, nil
+}
+
+// SetDefault makes the branch with the given name the default branch of the repository.
+func (c *BranchClient) SetDefault(ctx context.Context, branch string) error {
+	// PATCH /repos/{owner}/{repo}
+	_, err := c.c.UpdateRepo(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), &github.Repository{
+		DefaultBranch: &branch,
+	})
+	return err
+}
+
+// GetRules returns the rules in effect on the branch, from the rulesets which apply to it.
+// The rules of classic branch protection aren't included.
+func (c *BranchClient) GetRules(ctx context.Context, branch string) (gitprovider.BranchRules, error) {
+	rules, err := branchRules(ctx, c.clientContext, c.ref, branch)
+	if err != nil {
+		return gitprovider.BranchRules{}, err
+	}
+	return branchRulesFromAPI(rules)
+}
+
+// mergeUpstreamResponse is the response of the merge-upstream endpoint, which go-github doesn't have.
+type mergeUpstreamResponse struct {
+	// MergeType is "fast-forward", "merge" or "none"
+	MergeType string `json:"merge_type"`
+}
+
+// SyncForkWithUpstream brings the branch of this fork up to date with the branch of its parent,
+// with the merge-upstream endpoint. On GitHub Enterprise versions without that endpoint, the
+// branch is fast-forwarded to the head of the upstream branch instead. Both are synchronous, so
+// the returned Operation is done.
+func (c *BranchClient) SyncForkWithUpstream(ctx context.Context, branch string) (gitprovider.ForkSyncResult, error) {
+	result, err := c.syncFork(ctx, branch)
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, err
+	}
+	result.Operation = gitprovider.NewCompletedOperation(gitprovider.OperationStatus{State: gitprovider.OperationStateSucceeded})
+	return result, nil
+}
+
+// syncFork synchronizes the branch with the merge-upstream endpoint, or the fallback.
+func (c *BranchClient) syncFork(ctx context.Context, branch string) (gitprovider.ForkSyncResult, error) {
+	body := map[string]string{"branch": branch}
+	path := fmt.Sprintf("repos/%s/%s/merge-upstream", c.ref.GetIdentity(), c.ref.GetRepository())
+	req, err := c.c.Client().NewRequest(http.MethodPost, path, body)
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, err
+	}
+	apiObj := &mergeUpstreamResponse{}
+	// POST /repos/{owner}/{repo}/merge-upstream
+	_, err = c.c.Client().Do(ctx, req, apiObj)
+	if err != nil {
+		return c.syncForkFallback(ctx, branch, err)
+	}
+	sha, err := c.branchSHA(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), branch)
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, err
+	}
+	return gitprovider.ForkSyncResult{
+		Branch:   branch,
+		Sha:      sha,
+		Updated:  apiObj.MergeType != "none",
+		Strategy: gitprovider.ForkSyncStrategyMergeUpstream,
+	}, nil
+}
+
+// syncForkFallback handles the error of the merge-upstream endpoint: merge conflicts are returned
+// as ErrForkDiverged, and if the endpoint doesn't exist, the branch is fast-forwarded.
+func (c *BranchClient) syncForkFallback(ctx context.Context, branch string, mergeErr error) (gitprovider.ForkSyncResult, error) {
+	ghErrorResponse := &github.ErrorResponse{}
+	if !errors.As(mergeErr, &ghErrorResponse) {
+		return gitprovider.ForkSyncResult{}, handleHTTPError(mergeErr)
+	}
+	switch ghErrorResponse.Response.StatusCode {
+	case http.StatusConflict:
+		return gitprovider.ForkSyncResult{}, fmt.Errorf("branch %q: %s: %w", branch, ghErrorResponse.Message, gitprovider.ErrForkDiverged)
+	case http.StatusNotFound:
+		// The endpoint, the repository or the branch doesn't exist, which the fallback tells apart
+	default:
+		return gitprovider.ForkSyncResult{}, handleHTTPError(mergeErr)
+	}
+
+	// GET /repos/{owner}/{repo}
+	repo, err := c.c.GetRepo(ctx, c.ref.GetIdentity(), c.ref.GetRepository())
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, err
+	}
+	if !repo.GetFork() || repo.GetParent() == nil {
+		return gitprovider.ForkSyncResult{}, fmt.Errorf("repository %s isn't a fork: %w", c.ref.String(), gitprovider.ErrInvalidArgument)
+	}
+	parent := repo.GetParent()
+	upstreamSHA, err := c.branchSHA(ctx, parent.GetOwner().GetLogin(), parent.GetName(), branch)
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, err
+	}
+	result := gitprovider.ForkSyncResult{Branch: branch, Strategy: gitprovider.ForkSyncStrategyFastForward}
+
+	// GET /repos/{owner}/{repo}/compare/{basehead}
+	comparison, _, err := c.c.Client().Repositories.CompareCommits(ctx, c.ref.GetIdentity(), c.ref.GetRepository(),
+		branch, parent.GetOwner().GetLogin()+":"+branch, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, handleHTTPError(err)
+	}
+	switch comparison.GetStatus() {
+	case "identical", "behind":
+		// The branch has all the upstream commits already
+		result.Sha = comparison.GetBaseCommit().GetSHA()
+		return result, nil
+	case "ahead":
+	default:
+		return gitprovider.ForkSyncResult{}, fmt.Errorf("branch %q is %s: %w", branch, comparison.GetStatus(), gitprovider.ErrForkDiverged)
+	}
+
+	// go-github doesn't escape the ref in the URL path of UpdateRef
+	ref := "refs/heads/" + gitprovider.EscapePath(branch)
+	// PATCH /repos/{owner}/{repo}/git/refs/{ref}
+	if _, _, err := c.c.Client().Git.UpdateRef(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), &github.Reference{
+		Ref:    &ref,
+		Object: &github.GitObject{SHA: &upstreamSHA},
+	}, false); err != nil {
+		return gitprovider.ForkSyncResult{}, handleHTTPError(err)
+	}
+	result.Sha = upstreamSHA
+	result.Updated = true
+	return result, nil
+}
+
+// branchSHA returns the SHA of the commit the branch of the given repository points to.
+func (c *BranchClient) branchSHA(ctx context.Context, owner, repo, branch string) (string, error) {
+	// GET /repos/{owner}/{repo}/branches/{branch}
+	apiObj, _, err := c.c.Client().Repositories.GetBranch(ctx, owner, repo, gitprovider.EscapePath(branch), true)
+	if err != nil {
+		return "", handleHTTPError(err)
+	}
+	return apiObj.GetCommit().GetSHA(), nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestBranchClient_SyncForkWithUpstream(t *testing.T) {
+	var updatedRef string
+	mux := http.NewServeMux()
+	// bots/app has the merge-upstream endpoint, bots/legacy doesn't, like older GitHub Enterprise versions
+	mux.HandleFunc("/api/v3/repos/bots/app/merge-upstream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message": "Successfully fetched and fast-forwarded from upstream acme:main.", "merge_type": "fast-forward", "base_branch": "acme:main"}`))
+	})
+	mux.HandleFunc("/api/v3/repos/bots/conflict/merge-upstream", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"message": "There are merge conflicts"}`))
+	})
+	mux.HandleFunc("/api/v3/repos/bots/app/branches/main", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "main", "commit": {"sha": "222"}}`))
+	})
+	mux.HandleFunc("/api/v3/repos/bots/legacy", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "legacy", "fork": true, "owner": {"login": "bots"}, "parent": {"name": "legacy", "owner": {"login": "acme"}}}`))
+	})
+	mux.HandleFunc("/api/v3/repos/acme/legacy/branches/main", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "main", "commit": {"sha": "333"}}`))
+	})
+	mux.HandleFunc("/api/v3/repos/bots/legacy/compare/main...acme:main", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "ahead", "base_commit": {"sha": "111"}}`))
+	})
+	mux.HandleFunc("/api/v3/repos/bots/legacy/git/refs/heads/main", func(w http.ResponseWriter, r *http.Request) {
+		updatedRef = r.Method + " " + r.URL.Path
+		w.Write([]byte(`{"ref": "refs/heads/main", "object": {"sha": "333"}}`))
+	})
+	client := newTestClient(t, mux)
+	clientContext := client.clientContext
+	branches := func(name string) *BranchClient {
+		ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "bots"}, RepositoryName: name}
+		return &BranchClient{clientContext: clientContext, ref: ref}
+	}
+	ctx := context.Background()
+
+	result, err := branches("app").SyncForkWithUpstream(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gitprovider.ForkSyncResult{Branch: "main", Sha: "222", Updated: true, Strategy: gitprovider.ForkSyncStrategyMergeUpstream}
+	result.Operation = nil
+	if result != want {
+		t.Errorf("SyncForkWithUpstream() = %+v, want %+v", result, want)
+	}
+
+	result, err = branches("legacy").SyncForkWithUpstream(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = gitprovider.ForkSyncResult{Branch: "main", Sha: "333", Updated: true, Strategy: gitprovider.ForkSyncStrategyFastForward}
+	result.Operation = nil
+	if result != want {
+		t.Errorf("SyncForkWithUpstream() = %+v, want %+v", result, want)
+	}
+	if updatedRef != "PATCH /api/v3/repos/bots/legacy/git/refs/heads/main" {
+		t.Errorf("SyncForkWithUpstream() updated %q, want the main branch", updatedRef)
+	}
+
+	if _, err := branches("conflict").SyncForkWithUpstream(ctx, "main"); !errors.Is(err, gitprovider.ErrForkDiverged) {
+		t.Errorf("SyncForkWithUpstream() error = %v, want ErrForkDiverged", err)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// CollaboratorClient implements the gitprovider.CollaboratorClient interface.
+var _ gitprovider.CollaboratorClient = &CollaboratorClient{}
+
+// CollaboratorClient operates on the individual users with access to a specific repository.
+// Adding a collaborator on GitHub sends an invitation, which the user has to accept.
+type CollaboratorClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get returns the access of the collaborator with the given login.
+//
+// ErrNotFound is returned if the user isn't a collaborator, e.g. while an invitation is pending.
+func (c *CollaboratorClient) Get(ctx context.Context, login string) (gitprovider.CollaboratorInfo, error) {
+	collaborators, err := c.List(ctx)
+	if err != nil {
+		return gitprovider.CollaboratorInfo{}, err
+	}
+	for _, collaborator := range collaborators {
+		if strings.EqualFold(collaborator.Login, login) {
+			return collaborator, nil
+		}
+	}
+	return gitprovider.CollaboratorInfo{}, fmt.Errorf("collaborator %q: %w", login, gitprovider.ErrNotFound)
+}
+
+// List lists the users with direct access to the repository.
+//
+// List returns all available collaborators, using multiple paginated requests if needed.
+func (c *CollaboratorClient) List(ctx context.Context) ([]gitprovider.CollaboratorInfo, error) {
+	var apiObjs []*github.User
+	opts := &github.ListCollaboratorsOptions{Affiliation: "direct"}
+	err := allPages(&opts.ListOptions, func() (*github.Response, error) {
+		// GET /repos/{owner}/{repo}/collaborators
+		pageObjs, resp, listErr := c.c.Client().Repositories.ListCollaborators(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), opts)
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+
+	collaborators := make([]gitprovider.CollaboratorInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		if apiObj.Login == nil {
+			return nil, fmt.Errorf("didn't expect login to be nil for user: %+v: %w", apiObj, gitprovider.ErrInvalidServerData)
+		}
+		collaborators = append(collaborators, gitprovider.CollaboratorInfo{
+			Login:      apiObj.GetLogin(),
+			Permission: getPermissionFromMap(apiObj.Permissions),
+		})
+	}
+	return collaborators, nil
+}
+
+// Add invites the user req.Login to the repository, or changes the permission of an existing
+// collaborator. The invitation is returned if one was created.
+func (c *CollaboratorClient) Add(ctx context.Context, req gitprovider.CollaboratorInfo) (*gitprovider.CollaboratorInvitation, error) {
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, err
+	}
+	// PUT /repos/{owner}/{repo}/collaborators/{username}
+	apiObj, resp, err := c.c.Client().Repositories.AddCollaborator(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), req.Login, &github.RepositoryAddCollaboratorOptions{
+		Permission: string(*req.Permission),
+	})
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	// 204 No Content is returned if the user already is a collaborator
+	if resp.StatusCode != http.StatusCreated || apiObj.ID == nil {
+		return nil, nil
+	}
+	invitation := invitationFromAPI(apiObj.GetID(), apiObj.GetInvitee(), apiObj.GetPermissions(), apiObj.GetCreatedAt())
+	return &invitation, nil
+}
+
+// Remove revokes the direct access of the collaborator with the given login.
+//
+// ErrNotFound is returned if the user isn't a collaborator.
+func (c *CollaboratorClient) Remove(ctx context.Context, login string) error {
+	// GET /repos/{owner}/{repo}/collaborators/{username}
+	isCollaborator, _, err := c.c.Client().Repositories.IsCollaborator(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), login)
+	if err != nil {
+		return handleHTTPError(err)
+	}
+	if !isCollaborator {
+		return fmt.Errorf("collaborator %q: %w", login, gitprovider.ErrNotFound)
+	}
+	// DELETE /repos/{owner}/{repo}/collaborators/{username}
+	_, err = c.c.Client().Repositories.RemoveCollaborator(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), login)
+	return handleHTTPError(err)
+}
+
+// ListInvitations lists the pending invitations to the repository.
+func (c *CollaboratorClient) ListInvitations(ctx context.Context) ([]gitprovider.CollaboratorInvitation, error) {
+	var apiObjs []*github.RepositoryInvitation
+	opts := &github.ListOptions{}
+	err := allPages(opts, func() (*github.Response, error) {
+		// GET /repos/{owner}/{repo}/invitations
+		pageObjs, resp, listErr := c.c.Client().Repositories.ListInvitations(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), opts)
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+
+	invitations := make([]gitprovider.CollaboratorInvitation, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		invitations = append(invitations, invitationFromAPI(apiObj.GetID(), apiObj.GetInvitee(), apiObj.GetPermissions(), apiObj.GetCreatedAt()))
+	}
+	return invitations, nil
+}
+
+// CancelInvitation withdraws the pending invitation with the given ID.
+//
+// ErrNotFound is returned if there is no such pending invitation.
+func (c *CollaboratorClient) CancelInvitation(ctx context.Context, id int64) error {
+	// DELETE /repos/{owner}/{repo}/invitations/{invitation_id}
+	_, err := c.c.Client().Repositories.DeleteInvitation(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), id)
+	return handleHTTPError(err)
+}
+
+// invitationPermissions maps the permission names used by invitations to RepositoryPermissions.
+//
+//nolint:gochecknoglobals
+var invitationPermissions = map[string]gitprovider.RepositoryPermission{
+	"read":  gitprovider.RepositoryPermissionPull,
+	"write": gitprovider.RepositoryPermissionPush,
+}
+
+func invitationFromAPI(id int64, invitee *github.User, permissions string, createdAt github.Timestamp) gitprovider.CollaboratorInvitation {
+	return gitprovider.CollaboratorInvitation{
+		ID:         id,
+		Login:      invitee.GetLogin(),
+		Permission: invitationPermission(permissions),
+		CreatedAt:  createdAt.Time,
+	}
+}
+
+// invitationPermission returns the RepositoryPermission of the permission name of an invitation.
+func invitationPermission(permissions string) gitprovider.RepositoryPermission {
+	permission, ok := invitationPermissions[permissions]
+	if !ok {
+		// triage, maintain and admin are named the same
+		permission = gitprovider.RepositoryPermission(permissions)
+	}
+	return permission
+}
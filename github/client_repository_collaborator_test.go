@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestCollaboratorClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/collaborators", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("affiliation"); got != "direct" {
+			t.Errorf("affiliation = %q, want direct", got)
+		}
+		w.Write([]byte(`[{"login": "alice", "permissions": {"pull": true, "triage": true, "push": true}}]`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/collaborators/alice", func(w http.ResponseWriter, r *http.Request) {
+		// Changing the permission of an existing collaborator doesn't create an invitation
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/collaborators/bob", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 42, "invitee": {"login": "bob"}, "permissions": "write", "created_at": "2021-11-01T12:00:00Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/invitations", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 42, "invitee": {"login": "bob"}, "permissions": "write", "created_at": "2021-11-01T12:00:00Z"}]`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &CollaboratorClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	alice, err := c.Get(ctx, "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (gitprovider.CollaboratorInfo{Login: "alice", Permission: gitprovider.RepositoryPermissionVar(gitprovider.RepositoryPermissionPush)}); !reflect.DeepEqual(alice, want) {
+		t.Errorf("Get() = %+v, want %+v", alice, want)
+	}
+	if _, err := c.Get(ctx, "bob"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Get() of an invited user error = %v, want ErrNotFound", err)
+	}
+
+	invitation, err := c.Add(ctx, gitprovider.CollaboratorInfo{Login: "alice"})
+	if err != nil || invitation != nil {
+		t.Errorf("Add() of a collaborator = %+v, %v, want no invitation", invitation, err)
+	}
+	want := gitprovider.CollaboratorInvitation{
+		ID:         42,
+		Login:      "bob",
+		Permission: gitprovider.RepositoryPermissionPush,
+		CreatedAt:  time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC),
+	}
+	invitation, err = c.Add(ctx, gitprovider.CollaboratorInfo{Login: "bob", Permission: gitprovider.RepositoryPermissionVar(gitprovider.RepositoryPermissionPush)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invitation == nil || !reflect.DeepEqual(*invitation, want) {
+		t.Errorf("Add() = %+v, want %+v", invitation, want)
+	}
+
+	invitations, err := c.ListInvitations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(invitations, []gitprovider.CollaboratorInvitation{want}) {
+		t.Errorf("ListInvitations() = %+v, want %+v", invitations, want)
+	}
+
+	if err := c.Remove(ctx, "bob"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Remove() of an invited user error = %v, want ErrNotFound", err)
+	}
+}
@@ -18,6 +18,7 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -39,8 +40,15 @@ type CommitClient struct {
 // ListPage lists all repository commits of the given page and page size.
 // ListPage returns all available repository commits
 // using multiple paginated requests if needed.
-func (c *CommitClient) ListPage(ctx context.Context, branch string, perPage, page int) ([]gitprovider.Commit, error) {
-	dks, err := c.listPage(ctx, branch, perPage, page)
+// All CommitListOptions filters are applied server-side, where Author matches a login or email address.
+// GitHub doesn't list the stats of commits, so CommitListOptions.WithStats takes one more request per
+// listed commit, running up to gitprovider.WithConcurrency requests at once; use a small perPage with it.
+func (c *CommitClient) ListPage(ctx context.Context, branch string, perPage, page int, opts ...gitprovider.CommitListOption) ([]gitprovider.Commit, error) {
+	o, err := gitprovider.MakeCommitListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	dks, err := c.listPage(ctx, branch, perPage, page, o)
 	if err != nil {
 		return nil, err
 	}
@@ -52,9 +60,9 @@ func (c *CommitClient) ListPage(ctx context.Context, branch string, perPage, pag
 	return commits, nil
 }
 
-func (c *CommitClient) listPage(ctx context.Context, branch string, perPage, page int) ([]*commitType, error) {
+func (c *CommitClient) listPage(ctx context.Context, branch string, perPage, page int, opts gitprovider.CommitListOptions) ([]*commitType, error) {
 	// GET /repos/{owner}/{repo}/commits
-	apiObjs, err := c.c.ListCommitsPage(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), branch, perPage, page)
+	apiObjs, err := c.c.ListCommitsPage(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), branch, perPage, page, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -86,32 +94,46 @@ func (c *CommitClient) Create(ctx context.Context, branch string, message string
 	}
 
 	commits, err := c.ListPage(ctx, branch, 1, 0)
+	if errors.Is(err, gitprovider.ErrEmptyRepository) {
+		// The Git Data API refuses to operate on empty repositories, so bootstrap the repository
+		// through the contents API first, then replace the bootstrap commit with a root commit
+		// holding all the files.
+		if err := c.bootstrapEmptyRepository(ctx, branch, message, files[0]); err != nil {
+			return nil, err
+		}
+		return c.createCommit(ctx, branch, message, "", nil, treeEntries)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	latestCommitTreeSHA := commits[0].Get().TreeSha
+	latestCommitSHA := commits[0].Get().Sha
+	parents := []*github.Commit{
+		{
+			SHA: &latestCommitSHA,
+		},
+	}
+	return c.createCommit(ctx, branch, message, commits[0].Get().TreeSha, parents, treeEntries)
+}
 
-	tree, _, err := c.c.Client().Git.CreateTree(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), latestCommitTreeSHA, treeEntries)
+// createCommit creates a commit of the given tree entries on top of baseTree, and points the branch to it.
+func (c *CommitClient) createCommit(ctx context.Context, branch, message, baseTree string, parents []*github.Commit, treeEntries []*github.TreeEntry) (gitprovider.Commit, error) {
+	tree, _, err := c.c.Client().Git.CreateTree(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), baseTree, treeEntries)
 	if err != nil {
 		return nil, err
 	}
 
-	latestCommitSHA := commits[0].Get().Sha
 	nCommit, _, err := c.c.Client().Git.CreateCommit(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), &github.Commit{
 		Message: &message,
 		Tree:    tree,
-		Parents: []*github.Commit{
-			{
-				SHA: &latestCommitSHA,
-			},
-		},
+		Parents: parents,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	ref := "refs/heads/" + branch
+	// go-github doesn't escape the ref in the URL path of UpdateRef
+	ref := "refs/heads/" + gitprovider.EscapePath(branch)
 	ghRef := &github.Reference{
 		Ref: &ref,
 		Object: &github.GitObject{
@@ -125,3 +147,61 @@ func (c *CommitClient) Create(ctx context.Context, branch string, message string
 
 	return newCommit(c, nCommit), nil
 }
+
+// bootstrapEmptyRepository creates the given branch in an empty repository, by committing
+// the given file through the contents API.
+func (c *CommitClient) bootstrapEmptyRepository(ctx context.Context, branch, message string, file gitprovider.CommitFile) error {
+	if file.Path == nil || file.Content == nil {
+		return fmt.Errorf("the initial commit of an empty repository can't delete files: %w", gitprovider.ErrInvalidArgument)
+	}
+	// PUT /repos/{owner}/{repo}/contents/{path}
+	_, _, err := c.c.Client().Repositories.CreateFile(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), gitprovider.EscapePath(*file.Path), &github.RepositoryContentFileOptions{
+		Message: &message,
+		Content: []byte(*file.Content),
+		Branch:  &branch,
+	})
+	return handleHTTPError(err)
+}
+
+// ListComments lists the comments on the commit with the given SHA, oldest first.
+func (c *CommitClient) ListComments(ctx context.Context, sha string) ([]gitprovider.CommitCommentInfo, error) {
+	var apiObjs []*github.RepositoryComment
+	opts := &github.ListOptions{}
+	err := allPages(opts, func() (*github.Response, error) {
+		// GET /repos/{owner}/{repo}/commits/{commit_sha}/comments
+		pageObjs, resp, listErr := c.c.Client().Repositories.ListCommitComments(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), sha, opts)
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]gitprovider.CommitCommentInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		comments = append(comments, commitCommentFromAPI(apiObj))
+	}
+	return comments, nil
+}
+
+// CreateComment adds a comment with the given body to the commit with the given SHA.
+func (c *CommitClient) CreateComment(ctx context.Context, sha, body string) (gitprovider.CommitCommentInfo, error) {
+	// POST /repos/{owner}/{repo}/commits/{commit_sha}/comments
+	apiObj, _, err := c.c.Client().Repositories.CreateComment(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), sha, &github.RepositoryComment{
+		Body: &body,
+	})
+	if err != nil {
+		return gitprovider.CommitCommentInfo{}, handleHTTPError(err)
+	}
+	return commitCommentFromAPI(apiObj), nil
+}
+
+func commitCommentFromAPI(apiObj *github.RepositoryComment) gitprovider.CommitCommentInfo {
+	return gitprovider.CommitCommentInfo{
+		ID:        apiObj.GetID(),
+		Body:      apiObj.GetBody(),
+		Author:    apiObj.GetUser().GetLogin(),
+		CreatedAt: apiObj.GetCreatedAt(),
+		Path:      apiObj.GetPath(),
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestCommitClient_ListPage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{
+			"sha": "c2",
+			"html_url": "https://github.example.com/org/repo/commit/c2",
+			"parents": [{"sha": "c1"}, {"sha": "b1"}],
+			"commit": {
+				"message": "Merge branch 'feature'",
+				"tree": {"sha": "t2"},
+				"author": {"name": "Alice", "email": "alice@example.com", "date": "2021-01-01T10:00:00Z"},
+				"committer": {"name": "GitHub", "email": "noreply@github.com", "date": "2021-01-02T10:00:00Z"},
+				"verification": {"verified": true, "reason": "valid"}
+			}
+		}]`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &CommitClient{clientContext: client.clientContext, ref: ref}
+
+	commits, err := c.ListPage(context.Background(), "main", 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 {
+		t.Fatalf("ListPage() = %+v, want one commit", commits)
+	}
+	want := gitprovider.CommitInfo{
+		Sha:            "c2",
+		TreeSha:        "t2",
+		Author:         "Alice",
+		AuthorEmail:    "alice@example.com",
+		Committer:      "GitHub",
+		CommitterEmail: "noreply@github.com",
+		Message:        "Merge branch 'feature'",
+		Parents:        []string{"c1", "b1"},
+		CreatedAt:      time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC),
+		CommittedAt:    time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC),
+		URL:            "https://github.example.com/org/repo/commit/c2",
+		Verification:   &gitprovider.CommitVerification{Verified: true, Reason: "valid"},
+	}
+	if got := commits[0].Get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ListPage() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCommitClient_ListPageWithStats(t *testing.T) {
+	var mu sync.Mutex
+	gets, inFlight, maxInFlight := 0, 0, 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/commits", func(w http.ResponseWriter, r *http.Request) {
+		var commits []string
+		for i := 1; i <= 4; i++ {
+			commits = append(commits, fmt.Sprintf(`{"sha": "c%d", "commit": {"message": "m", "tree": {"sha": "t"}, "author": {"name": "Alice", "date": "2021-01-01T10:00:00Z"}}}`, i))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(commits, ","))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/commits/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		gets++
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		additions := strings.TrimPrefix(r.URL.Path, "/api/v3/repos/org/repo/commits/c")
+		fmt.Fprintf(w, `{"sha": "c%s", "stats": {"additions": %s, "deletions": 1, "total": %s1}}`, additions, additions, additions)
+	})
+	client := newTestClient(t, mux)
+	client.setConcurrency(2)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &CommitClient{clientContext: client.clientContext, ref: ref}
+
+	commits, err := c.ListPage(context.Background(), "main", 4, 1, &gitprovider.CommitListOptions{WithStats: gitprovider.BoolVar(true)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every listed commit is fetched for its stats, up to the client concurrency at once
+	if gets != len(commits) || maxInFlight > 2 {
+		t.Errorf("ListPage() fetched %d commits with up to %d at once, want %d with up to 2", gets, maxInFlight, len(commits))
+	}
+	for i, commit := range commits {
+		if stats := commit.Get().Stats; stats == nil || stats.Additions != i+1 {
+			t.Errorf("commit %d stats = %+v, want %d additions", i, stats, i+1)
+		}
+	}
+}
+
+func TestCommitClient_Comments(t *testing.T) {
+	var got github.RepositoryComment
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/commits/c2/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(`{"id": 2, "body": "attested", "user": {"login": "bot"}, "created_at": "2021-01-02T10:00:00Z"}`))
+			return
+		}
+		w.Write([]byte(`[{"id": 1, "body": "nit", "path": "main.go", "user": {"login": "alice"}, "created_at": "2021-01-01T10:00:00Z"}]`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &CommitClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	comments, err := c.ListComments(ctx, "c2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gitprovider.CommitCommentInfo{{ID: 1, Body: "nit", Author: "alice", CreatedAt: time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC), Path: "main.go"}}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("ListComments() = %+v, want %+v", comments, want)
+	}
+
+	comment, err := c.CreateComment(ctx, "c2", "attested")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.ID != 2 || comment.Author != "bot" || got.GetBody() != "attested" {
+		t.Errorf("CreateComment() = %+v, request = %+v", comment, got)
+	}
+}
@@ -19,6 +19,7 @@ package github
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/google/go-github/v41/github"
 
@@ -117,8 +118,16 @@ func (c *DeployKeyClient) Reconcile(ctx context.Context, req gitprovider.DeployK
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(c.ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := c.Create(ctx, req)
-			return resp, true, err
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateDeployKey)
+			return resp, true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
@@ -129,13 +138,22 @@ func (c *DeployKeyClient) Reconcile(ctx context.Context, req gitprovider.DeployK
 	if req.Equals(actual.Get()) {
 		return actual, false, nil
 	}
+	change := gitprovider.NewUpdateChange(c.ref, actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return actual, true, gitprovider.NewDryRunError(change)
+	}
 
 	// Populate the desired state to the current-actual object
 	if err := actual.Set(req); err != nil {
 		return actual, false, err
 	}
 	// Apply the desired state by running Update
-	return actual, true, actual.Update(ctx)
+	if err := actual.Update(ctx); err != nil {
+		return actual, true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallDeleteDeployKey, apiCallCreateDeployKey)
+	return actual, true, nil
 }
 
 func createDeployKey(ctx context.Context, c githubClient, ref gitprovider.RepositoryRef, req gitprovider.DeployKeyInfo) (*github.Key, error) {
@@ -147,3 +165,37 @@ func createDeployKey(ctx context.Context, c githubClient, ref gitprovider.Reposi
 	// POST /repos/{owner}/{repo}/keys
 	return c.CreateKey(ctx, ref.GetIdentity(), ref.GetRepository(), deployKeyToAPI(&req))
 }
+
+// Rotate replaces the key material of the deploy key with the given name. GitHub allows several
+// keys with the same title, hence the new key is created before the old one is deleted.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *DeployKeyClient) Rotate(ctx context.Context, name string, newKey []byte) (gitprovider.DeployKey, bool, error) {
+	actual, err := c.get(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	req := actual.Get()
+	if gitprovider.EqualPublicKeys(req.Key, newKey) {
+		return actual, false, nil
+	}
+	req.Key = newKey
+	if err := req.ValidateInfo(); err != nil {
+		return nil, false, err
+	}
+	change := gitprovider.NewUpdateChange(c.ref, actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return actual, true, gitprovider.NewDryRunError(change)
+	}
+
+	resp, err := c.Create(ctx, req)
+	if err != nil {
+		return actual, false, err
+	}
+	if err := actual.Delete(ctx); err != nil {
+		return resp, true, fmt.Errorf("created the new deploy key %q, but failed to delete the old one: %w", name, err)
+	}
+	gitprovider.RecordChange(ctx, change, apiCallCreateDeployKey, apiCallDeleteDeployKey)
+	return resp, true, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestDeployKeyClient_ReconcileReadOnlyDrift(t *testing.T) {
+	var deleted, created bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/keys", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			created = true
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 2, "title": "flux", "key": "ssh-ed25519 AAAAC3Nza", "read_only": false}`))
+		default:
+			// The provider returns the key without its comment
+			w.Write([]byte(`[{"id": 1, "title": "flux", "key": "ssh-ed25519 AAAAC3Nza", "read_only": true}]`))
+		}
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/keys/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = true
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &DeployKeyClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	// Only the comment differs, which is not a drift
+	_, actionTaken, err := c.Reconcile(ctx, gitprovider.DeployKeyInfo{
+		Name:     "flux",
+		Key:      []byte("ssh-ed25519 AAAAC3Nza flux@cluster"),
+		ReadOnly: gitprovider.BoolVar(true),
+	})
+	if err != nil || actionTaken || deleted || created {
+		t.Fatalf("Reconcile() actionTaken = %v, err = %v, want a no-op", actionTaken, err)
+	}
+
+	// Granting write access rotates the key
+	_, actionTaken, err = c.Reconcile(ctx, gitprovider.DeployKeyInfo{
+		Name:     "flux",
+		Key:      []byte("ssh-ed25519 AAAAC3Nza"),
+		ReadOnly: gitprovider.BoolVar(false),
+	})
+	if err != nil || !actionTaken {
+		t.Fatalf("Reconcile() actionTaken = %v, err = %v, want an update", actionTaken, err)
+	}
+	if !deleted || !created {
+		t.Errorf("Reconcile() deleted = %v, created = %v, want the key to be rotated", deleted, created)
+	}
+}
+
+func TestDeployKeyClient_Rotate(t *testing.T) {
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/keys", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" keys")
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 2, "title": "flux", "key": "ssh-ed25519 BBBB", "read_only": true}`))
+		default:
+			w.Write([]byte(`[{"id": 1, "title": "flux", "key": "ssh-ed25519 AAAA", "read_only": true}]`))
+		}
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/keys/1", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" keys/1")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &DeployKeyClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	if _, actionTaken, err := c.Rotate(ctx, "flux", []byte("ssh-ed25519 AAAA flux@cluster")); err != nil || actionTaken {
+		t.Fatalf("Rotate() to the same key actionTaken = %v, err = %v, want a no-op", actionTaken, err)
+	}
+
+	requests = nil
+	resp, actionTaken, err := c.Rotate(ctx, "flux", []byte("ssh-ed25519 BBBB"))
+	if err != nil || !actionTaken {
+		t.Fatalf("Rotate() actionTaken = %v, err = %v", actionTaken, err)
+	}
+	if info := resp.Get(); string(info.Key) != "ssh-ed25519 BBBB" || info.ReadOnly == nil || !*info.ReadOnly {
+		t.Errorf("Rotate() = %+v, want the new read-only key", info)
+	}
+	// The new key is created before the old one is deleted
+	if want := []string{"GET keys", "POST keys", "DELETE keys/1"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+const (
+	// environmentRuleWaitTimer and environmentRuleRequiredReviewers are the types of the
+	// protection rules of an environment.
+	environmentRuleWaitTimer         = "wait_timer"
+	environmentRuleRequiredReviewers = "required_reviewers"
+
+	// environmentReviewerUser and environmentReviewerTeam are the types of environment reviewers.
+	environmentReviewerUser = "User"
+	environmentReviewerTeam = "Team"
+)
+
+// EnvironmentClient implements the gitprovider.EnvironmentClient interface.
+var _ gitprovider.EnvironmentClient = &EnvironmentClient{}
+
+// EnvironmentClient operates on the deployment environments for a specific repository.
+type EnvironmentClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get returns the protection rules of the environment with the given name.
+//
+// ErrNotFound is returned if the environment doesn't exist.
+func (c *EnvironmentClient) Get(ctx context.Context, name string) (gitprovider.EnvironmentInfo, error) {
+	apiObj, err := c.get(ctx, name)
+	if err != nil {
+		return gitprovider.EnvironmentInfo{}, err
+	}
+	return environmentFromAPI(apiObj), nil
+}
+
+func (c *EnvironmentClient) get(ctx context.Context, name string) (*github.Environment, error) {
+	// GET /repos/{owner}/{repo}/environments/{environment_name}
+	apiObj, _, err := c.c.Client().Repositories.GetEnvironment(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), url.PathEscape(name))
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	if err := validateEnvironmentAPI(apiObj); err != nil {
+		return nil, err
+	}
+	return apiObj, nil
+}
+
+// List lists the environments of the repository.
+func (c *EnvironmentClient) List(ctx context.Context) ([]gitprovider.EnvironmentInfo, error) {
+	// GET /repos/{owner}/{repo}/environments
+	list, _, err := c.c.Client().Repositories.ListEnvironments(ctx, c.ref.GetIdentity(), c.ref.GetRepository())
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+
+	envs := make([]gitprovider.EnvironmentInfo, 0, len(list.Environments))
+	for _, apiObj := range list.Environments {
+		if err := validateEnvironmentAPI(apiObj); err != nil {
+			return nil, err
+		}
+		envs = append(envs, environmentFromAPI(apiObj))
+	}
+	return envs, nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (c *EnvironmentClient) Reconcile(ctx context.Context, req gitprovider.EnvironmentInfo) (gitprovider.EnvironmentInfo, bool, error) {
+	// First thing, validate and default the request to ensure a valid and fully-populated object
+	// (to minimize any possible diffs between desired and actual state)
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return gitprovider.EnvironmentInfo{}, false, err
+	}
+	if len(req.TeamReviewers) != 0 && c.ref.GetType() != gitprovider.IdentityTypeOrganization {
+		return gitprovider.EnvironmentInfo{}, false, fmt.Errorf("team reviewers of environment %q need a repository owned by an organization: %w", req.Name, gitprovider.ErrInvalidArgument)
+	}
+
+	var change gitprovider.Change
+	var branchPolicy *github.BranchPolicy
+	apiObj, err := c.get(ctx, req.Name)
+	switch {
+	case errors.Is(err, gitprovider.ErrNotFound):
+		change = gitprovider.NewCreateChange(c.ref, req)
+	case err != nil:
+		// Unexpected path, Get should succeed or return NotFound
+		return gitprovider.EnvironmentInfo{}, false, err
+	default:
+		actual := environmentFromAPI(apiObj)
+		// If the desired matches the actual state, just return the actual state
+		if req.Equals(actual) {
+			return actual, false, nil
+		}
+		change = gitprovider.NewUpdateChange(c.ref, actual, req)
+		// Keep the deployment branch policy, which isn't managed
+		branchPolicy = apiObj.DeploymentBranchPolicy
+	}
+	// In dry-run mode, only report what would be created or updated
+	if c.dryRun {
+		return req, true, gitprovider.NewDryRunError(change)
+	}
+
+	reviewers, err := c.reviewersToAPI(ctx, req)
+	if err != nil {
+		return gitprovider.EnvironmentInfo{}, false, err
+	}
+	// PUT /repos/{owner}/{repo}/environments/{environment_name}
+	apiObj, _, err = c.c.Client().Repositories.CreateUpdateEnvironment(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), url.PathEscape(req.Name), &github.CreateUpdateEnvironment{
+		WaitTimer:              &req.WaitTimer,
+		Reviewers:              reviewers,
+		DeploymentBranchPolicy: branchPolicy,
+	})
+	if err != nil {
+		return gitprovider.EnvironmentInfo{}, true, handleHTTPError(err)
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateEnvironment)
+	return environmentFromAPI(apiObj), true, nil
+}
+
+// reviewersToAPI looks up the IDs of the reviewers of the environment.
+func (c *EnvironmentClient) reviewersToAPI(ctx context.Context, req gitprovider.EnvironmentInfo) ([]*github.EnvReviewers, error) {
+	reviewers := make([]*github.EnvReviewers, 0, len(req.Reviewers)+len(req.TeamReviewers))
+	for _, login := range req.Reviewers {
+		// GET /users/{username}
+		user, _, err := c.c.Client().Users.Get(ctx, login)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reviewer %q: %w", login, handleHTTPError(err))
+		}
+		reviewers = append(reviewers, &github.EnvReviewers{Type: github.String(environmentReviewerUser), ID: user.ID})
+	}
+	for _, slug := range req.TeamReviewers {
+		// GET /orgs/{org}/teams/{team_slug}
+		team, _, err := c.c.Client().Teams.GetTeamBySlug(ctx, c.ref.GetIdentity(), slug)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get team reviewer %q: %w", slug, handleHTTPError(err))
+		}
+		reviewers = append(reviewers, &github.EnvReviewers{Type: github.String(environmentReviewerTeam), ID: team.ID})
+	}
+	return reviewers, nil
+}
+
+// Delete deletes the environment with the given name.
+//
+// ErrNotFound is returned if the environment doesn't exist.
+func (c *EnvironmentClient) Delete(ctx context.Context, name string) error {
+	// DELETE /repos/{owner}/{repo}/environments/{environment_name}
+	_, err := c.c.Client().Repositories.DeleteEnvironment(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), url.PathEscape(name))
+	return handleHTTPError(err)
+}
+
+// environmentFromAPI returns the protection rules of the environment, defaulted for comparison.
+func environmentFromAPI(apiObj *github.Environment) gitprovider.EnvironmentInfo {
+	info := gitprovider.EnvironmentInfo{Name: apiObj.GetName()}
+	for _, rule := range apiObj.ProtectionRules {
+		switch rule.GetType() {
+		case environmentRuleWaitTimer:
+			info.WaitTimer = rule.GetWaitTimer()
+		case environmentRuleRequiredReviewers:
+			for _, reviewer := range rule.Reviewers {
+				switch r := reviewer.Reviewer.(type) {
+				case *github.User:
+					info.Reviewers = append(info.Reviewers, r.GetLogin())
+				case *github.Team:
+					info.TeamReviewers = append(info.TeamReviewers, r.GetSlug())
+				}
+			}
+		}
+	}
+	info.Default()
+	return info
+}
+
+// validateEnvironmentAPI validates the apiObj received from the server, to make sure that it is
+// valid for our use.
+func validateEnvironmentAPI(apiObj *github.Environment) error {
+	return validateAPIObject("GitHub.Environment", func(validator validation.Validator) {
+		if apiObj.Name == nil {
+			validator.Required("Name")
+		}
+	})
+}
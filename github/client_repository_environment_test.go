@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestEnvironmentClient_Reconcile(t *testing.T) {
+	var got github.CreateUpdateEnvironment
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/environments/production", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(`{"name": "production", "protection_rules": [
+				{"type": "required_reviewers", "reviewers": [{"type": "User", "reviewer": {"login": "carol"}}, {"type": "Team", "reviewer": {"slug": "ops"}}]}
+			]}`))
+			return
+		}
+		w.Write([]byte(`{"name": "production", "deployment_branch_policy": {"protected_branches": true, "custom_branch_policies": false}, "protection_rules": [
+			{"type": "wait_timer", "wait_timer": 30},
+			{"type": "required_reviewers", "reviewers": [{"type": "User", "reviewer": {"login": "bob"}}, {"type": "Team", "reviewer": {"slug": "ops"}}]}
+		]}`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/environments/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v3/users/carol", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"login": "carol", "id": 3}`))
+	})
+	mux.HandleFunc("/api/v3/orgs/org/teams/ops", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"slug": "ops", "id": 7}`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &EnvironmentClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	env, err := c.Get(ctx, "production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gitprovider.EnvironmentInfo{Name: "production", Reviewers: []string{"bob"}, TeamReviewers: []string{"ops"}, WaitTimer: 30}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("Get() = %+v, want %+v", env, want)
+	}
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+
+	// Reconciling the actual state is a no-op
+	if _, actionTaken, err := c.Reconcile(ctx, want); err != nil || actionTaken {
+		t.Errorf("Reconcile() = %v, %v, want no action", actionTaken, err)
+	}
+
+	env, actionTaken, err := c.Reconcile(ctx, gitprovider.EnvironmentInfo{Name: "production", Reviewers: []string{"carol"}, TeamReviewers: []string{"ops"}})
+	if err != nil || !actionTaken {
+		t.Fatalf("Reconcile() = %v, %v", actionTaken, err)
+	}
+	if want := []string{"carol"}; !reflect.DeepEqual(env.Reviewers, want) {
+		t.Errorf("Reconcile() reviewers = %v, want %v", env.Reviewers, want)
+	}
+	if got.GetWaitTimer() != 0 || len(got.Reviewers) != 2 || got.Reviewers[0].GetID() != 3 || got.Reviewers[1].GetType() != "Team" || got.Reviewers[1].GetID() != 7 {
+		t.Errorf("request = %+v", got)
+	}
+	// The deployment branch policy isn't managed, and is kept as-is
+	if got.DeploymentBranchPolicy == nil || !got.DeploymentBranchPolicy.GetProtectedBranches() {
+		t.Errorf("request branch policy = %+v, want the actual one", got.DeploymentBranchPolicy)
+	}
+}
+
+func TestEnvironmentClient_ReconcileUserRepository(t *testing.T) {
+	ref := gitprovider.UserRepositoryRef{UserRef: gitprovider.UserRef{Domain: "github.example.com", UserLogin: "user"}, RepositoryName: "repo"}
+	c := &EnvironmentClient{clientContext: newClient(github.NewClient(nil), "github.example.com", false).clientContext, ref: ref}
+
+	_, _, err := c.Reconcile(context.Background(), gitprovider.EnvironmentInfo{Name: "production", TeamReviewers: []string{"ops"}})
+	if !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Errorf("Reconcile() error = %v, want ErrInvalidArgument", err)
+	}
+}
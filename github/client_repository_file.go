@@ -19,7 +19,9 @@ package github
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/google/go-github/v41/github"
@@ -34,16 +36,17 @@ type FileClient struct {
 	ref gitprovider.RepositoryRef
 }
 
-// Get fetches and returns the contents of a file from a given branch and path
-func (c *FileClient) Get(ctx context.Context, path, branch string) ([]*gitprovider.CommitFile, error) {
+// Get fetches and returns the contents of the files from a given Git reference and path.
+// The reference can be a branch, tag or commit SHA.
+func (c *FileClient) Get(ctx context.Context, path, ref string) ([]*gitprovider.CommitFile, error) {
 
 	opts := &github.RepositoryContentGetOptions{
-		Ref: branch,
+		Ref: ref,
 	}
 
 	_, directoryContent, _, err := c.c.Client().Repositories.GetContents(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), path, opts)
 	if err != nil {
-		return nil, err
+		return nil, handleHTTPError(err)
 	}
 
 	if len(directoryContent) == 0 {
@@ -75,3 +78,38 @@ func (c *FileClient) Get(ctx context.Context, path, branch string) ([]*gitprovid
 
 	return files, nil
 }
+
+// Open returns a reader streaming the raw content of the file at the given path and Git reference,
+// along with the size and blob SHA of the file. The caller is responsible for closing the returned reader.
+//
+// ErrNotFound is returned if the file doesn't exist.
+func (c *FileClient) Open(ctx context.Context, path, ref string) (io.ReadCloser, *gitprovider.FileInfo, error) {
+	opts := &github.RepositoryContentGetOptions{
+		Ref: ref,
+	}
+
+	// GET /repos/{owner}/{repo}/contents/{path}
+	fileContent, _, _, err := c.c.Client().Repositories.GetContents(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), path, opts)
+	if err != nil {
+		return nil, nil, handleHTTPError(err)
+	}
+	if fileContent == nil || fileContent.GetDownloadURL() == "" {
+		return nil, nil, fmt.Errorf("path %q is not a regular file: %w", path, gitprovider.ErrInvalidArgument)
+	}
+
+	// Download the raw content, the body is left unread by BareDo and streamed to the caller
+	req, err := c.c.Client().NewRequest(http.MethodGet, fileContent.GetDownloadURL(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.c.Client().BareDo(ctx, req)
+	if err != nil {
+		return nil, nil, handleHTTPError(err)
+	}
+
+	return resp.Body, &gitprovider.FileInfo{
+		Path: fileContent.GetPath(),
+		Size: int64(fileContent.GetSize()),
+		SHA:  fileContent.GetSHA(),
+	}, nil
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// MergeQueueClient implements the gitprovider.MergeQueueClient interface.
+var _ gitprovider.MergeQueueClient = &MergeQueueClient{}
+
+// MergeQueueClient operates on the merge queue for a specific repository.
+// The merge queue is only available through the GraphQL API.
+type MergeQueueClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// mergeQueueEntryFields are the fields of a MergeQueueEntry, see mergeQueueEntry.
+const mergeQueueEntryFields = `fragment entry on MergeQueueEntry {
+  position
+  state
+  enqueuedAt
+  pullRequest { number baseRefName }
+}`
+
+// mergeQueueEntry is the GraphQL MergeQueueEntry object.
+type mergeQueueEntry struct {
+	Position    int       `json:"position"`
+	State       string    `json:"state"`
+	EnqueuedAt  time.Time `json:"enqueuedAt"`
+	PullRequest struct {
+		Number      int    `json:"number"`
+		BaseRefName string `json:"baseRefName"`
+	} `json:"pullRequest"`
+}
+
+// Enqueue adds the pull request with the given number to the merge queue of its base branch.
+func (c *MergeQueueClient) Enqueue(ctx context.Context, number int) (gitprovider.MergeQueueEntry, error) {
+	// GET /repos/{owner}/{repo}/pulls/{pull_number}
+	pr, _, err := c.c.Client().PullRequests.Get(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), number)
+	if err != nil {
+		return gitprovider.MergeQueueEntry{}, handleHTTPError(err)
+	}
+
+	var data struct {
+		EnqueuePullRequest struct {
+			MergeQueueEntry *mergeQueueEntry `json:"mergeQueueEntry"`
+		} `json:"enqueuePullRequest"`
+	}
+	query := `mutation($id: ID!) {
+  enqueuePullRequest(input: {pullRequestId: $id}) { mergeQueueEntry { ...entry } }
+}
+` + mergeQueueEntryFields
+	if err := graphQL(ctx, c.c.Client(), query, map[string]interface{}{"id": pr.GetNodeID()}, &data); err != nil {
+		return gitprovider.MergeQueueEntry{}, err
+	}
+	if data.EnqueuePullRequest.MergeQueueEntry == nil {
+		return gitprovider.MergeQueueEntry{}, fmt.Errorf("pull request %d wasn't enqueued", number)
+	}
+	return mergeQueueEntryFromAPI(data.EnqueuePullRequest.MergeQueueEntry), nil
+}
+
+// Dequeue removes the pull request with the given number from the merge queue.
+func (c *MergeQueueClient) Dequeue(ctx context.Context, number int) error {
+	// GET /repos/{owner}/{repo}/pulls/{pull_number}
+	pr, _, err := c.c.Client().PullRequests.Get(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), number)
+	if err != nil {
+		return handleHTTPError(err)
+	}
+
+	query := `mutation($id: ID!) {
+  dequeuePullRequest(input: {id: $id}) { clientMutationId }
+}`
+	return graphQL(ctx, c.c.Client(), query, map[string]interface{}{"id": pr.GetNodeID()}, nil)
+}
+
+// Get returns the merge queue entry of the pull request with the given number.
+//
+// ErrNotFound is returned if the pull request isn't queued.
+func (c *MergeQueueClient) Get(ctx context.Context, number int) (gitprovider.MergeQueueEntry, error) {
+	var data struct {
+		Repository struct {
+			PullRequest struct {
+				MergeQueueEntry *mergeQueueEntry `json:"mergeQueueEntry"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	query := `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) { pullRequest(number: $number) { mergeQueueEntry { ...entry } } }
+}
+` + mergeQueueEntryFields
+	vars := map[string]interface{}{
+		"owner":  c.ref.GetIdentity(),
+		"name":   c.ref.GetRepository(),
+		"number": number,
+	}
+	if err := graphQL(ctx, c.c.Client(), query, vars, &data); err != nil {
+		return gitprovider.MergeQueueEntry{}, err
+	}
+	if data.Repository.PullRequest.MergeQueueEntry == nil {
+		return gitprovider.MergeQueueEntry{}, fmt.Errorf("pull request %d isn't queued: %w", number, gitprovider.ErrNotFound)
+	}
+	return mergeQueueEntryFromAPI(data.Repository.PullRequest.MergeQueueEntry), nil
+}
+
+// List lists the entries of the merge queue of the given base branch, in merge order.
+// The list is empty if the branch has no merge queue.
+func (c *MergeQueueClient) List(ctx context.Context, baseBranch string) ([]gitprovider.MergeQueueEntry, error) {
+	query := `query($owner: String!, $name: String!, $branch: String!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    mergeQueue(branch: $branch) {
+      entries(first: 100, after: $after) {
+        nodes { ...entry }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}
+` + mergeQueueEntryFields
+	vars := map[string]interface{}{
+		"owner":  c.ref.GetIdentity(),
+		"name":   c.ref.GetRepository(),
+		"branch": baseBranch,
+	}
+
+	entries := []gitprovider.MergeQueueEntry{}
+	for {
+		var data struct {
+			Repository struct {
+				MergeQueue *struct {
+					Entries struct {
+						Nodes    []*mergeQueueEntry `json:"nodes"`
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+					} `json:"entries"`
+				} `json:"mergeQueue"`
+			} `json:"repository"`
+		}
+		if err := graphQL(ctx, c.c.Client(), query, vars, &data); err != nil {
+			return nil, err
+		}
+		if data.Repository.MergeQueue == nil {
+			return entries, nil
+		}
+		for _, node := range data.Repository.MergeQueue.Entries.Nodes {
+			entries = append(entries, mergeQueueEntryFromAPI(node))
+		}
+		pageInfo := data.Repository.MergeQueue.Entries.PageInfo
+		if !pageInfo.HasNextPage {
+			return entries, nil
+		}
+		vars["after"] = pageInfo.EndCursor
+	}
+}
+
+func mergeQueueEntryFromAPI(apiObj *mergeQueueEntry) gitprovider.MergeQueueEntry {
+	return gitprovider.MergeQueueEntry{
+		PullRequestNumber: apiObj.PullRequest.Number,
+		BaseBranch:        apiObj.PullRequest.BaseRefName,
+		Position:          apiObj.Position,
+		State:             mergeQueueStateFromAPI(apiObj.State),
+		EnqueuedAt:        apiObj.EnqueuedAt,
+	}
+}
+
+func mergeQueueStateFromAPI(state string) gitprovider.MergeQueueState {
+	switch strings.ToUpper(state) {
+	case "AWAITING_CHECKS", "LOCKED":
+		return gitprovider.MergeQueueStateChecking
+	case "MERGEABLE":
+		return gitprovider.MergeQueueStateMergeable
+	case "UNMERGEABLE":
+		return gitprovider.MergeQueueStateFailed
+	default:
+		return gitprovider.MergeQueueStateQueued
+	}
+}
+
+// graphQLError is an error returned in the errors field of a GraphQL response.
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// graphQL runs the query with the given variables against the GraphQL API, and decodes the
+// data field of the response into data, if set. The GraphQL API is located next to the REST
+// API, i.e. at /graphql on github.com, and at /api/graphql on GitHub Enterprise.
+func graphQL(ctx context.Context, gh *github.Client, query string, variables map[string]interface{}, data interface{}) error {
+	body := map[string]interface{}{"query": query, "variables": variables}
+	req, err := gh.NewRequest(http.MethodPost, "../graphql", body)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data   interface{}    `json:"data"`
+		Errors []graphQLError `json:"errors"`
+	}
+	resp.Data = data
+	// POST /graphql
+	if _, err := gh.Do(ctx, req, &resp); err != nil {
+		return handleHTTPError(err)
+	}
+	if len(resp.Errors) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(resp.Errors))
+	for _, e := range resp.Errors {
+		msgs = append(msgs, e.Message)
+	}
+	if resp.Errors[0].Type == "NOT_FOUND" {
+		return fmt.Errorf("graphql: %s: %w", strings.Join(msgs, "; "), gitprovider.ErrNotFound)
+	}
+	return fmt.Errorf("graphql: %s", strings.Join(msgs, "; "))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestMergeQueueClient(t *testing.T) {
+	enqueuedAt := time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	// The GraphQL API of GitHub Enterprise is at /api/graphql
+	mux.HandleFunc("/api/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Variables["number"] == float64(2) {
+			w.Write([]byte(`{"data": {"repository": {"pullRequest": {"mergeQueueEntry": null}}}}`))
+			return
+		}
+		if req.Variables["branch"] == "main" {
+			w.Write([]byte(`{"data": {"repository": {"mergeQueue": {"entries": {
+				"nodes": [{"position": 1, "state": "AWAITING_CHECKS", "enqueuedAt": "2021-11-01T12:00:00Z", "pullRequest": {"number": 1, "baseRefName": "main"}}],
+				"pageInfo": {"hasNextPage": false}
+			}}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"repository": null}, "errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a Repository"}]}`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &MergeQueueClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	entries, err := c.List(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gitprovider.MergeQueueEntry{{
+		PullRequestNumber: 1,
+		BaseBranch:        "main",
+		Position:          1,
+		State:             gitprovider.MergeQueueStateChecking,
+		EnqueuedAt:        enqueuedAt,
+	}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("List() = %+v, want %+v", entries, want)
+	}
+
+	if _, err := c.Get(ctx, 2); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Get() of an unqueued pull request error = %v, want ErrNotFound", err)
+	}
+	if _, err := c.List(ctx, "other"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("List() of a missing repository error = %v, want ErrNotFound", err)
+	}
+}
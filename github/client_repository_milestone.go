@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// MilestoneClient implements the gitprovider.MilestoneClient interface.
+var _ gitprovider.MilestoneClient = &MilestoneClient{}
+
+// MilestoneClient operates on the milestones for a specific repository.
+type MilestoneClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists all milestones in the repository, both open and closed.
+func (c *MilestoneClient) List(ctx context.Context) ([]gitprovider.Milestone, error) {
+	var apiObjs []*github.Milestone
+	opts := &github.MilestoneListOptions{State: "all"}
+	err := allPages(&opts.ListOptions, func() (*github.Response, error) {
+		// GET /repos/{owner}/{repo}/milestones
+		pageObjs, resp, listErr := c.c.Client().Issues.ListMilestones(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), opts)
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	milestones := make([]gitprovider.Milestone, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		milestones = append(milestones, newMilestone(c.clientContext, apiObj))
+	}
+	return milestones, nil
+}
+
+// Get retrieves an existing milestone by number.
+func (c *MilestoneClient) Get(ctx context.Context, number int) (gitprovider.Milestone, error) {
+	// GET /repos/{owner}/{repo}/milestones/{milestone_number}
+	apiObj, _, err := c.c.Client().Issues.GetMilestone(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), number)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return newMilestone(c.clientContext, apiObj), nil
+}
+
+// Create creates a milestone with the given specifications.
+func (c *MilestoneClient) Create(ctx context.Context, req gitprovider.MilestoneInfo) (gitprovider.Milestone, error) {
+	if err := req.ValidateInfo(); err != nil {
+		return nil, err
+	}
+	// POST /repos/{owner}/{repo}/milestones
+	apiObj, _, err := c.c.Client().Issues.CreateMilestone(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), milestoneToAPI(&req))
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return newMilestone(c.clientContext, apiObj), nil
+}
+
+// AssignPullRequest assigns the pull request with the given number to the milestone.
+// GitHub treats pull requests as issues, hence this is equivalent to AssignIssue.
+func (c *MilestoneClient) AssignPullRequest(ctx context.Context, number, pullRequestNumber int) error {
+	return c.AssignIssue(ctx, number, pullRequestNumber)
+}
+
+// AssignIssue assigns the issue with the given number to the milestone.
+func (c *MilestoneClient) AssignIssue(ctx context.Context, number, issueNumber int) error {
+	// PATCH /repos/{owner}/{repo}/issues/{issue_number}
+	_, _, err := c.c.Client().Issues.Edit(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), issueNumber, &github.IssueRequest{
+		Milestone: &number,
+	})
+	return handleHTTPError(err)
+}
+
+// Close closes the milestone with the given number.
+func (c *MilestoneClient) Close(ctx context.Context, number int) error {
+	// PATCH /repos/{owner}/{repo}/milestones/{milestone_number}
+	_, _, err := c.c.Client().Issues.EditMilestone(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), number, &github.Milestone{
+		State: github.String(string(gitprovider.MilestoneStateClosed)),
+	})
+	return handleHTTPError(err)
+}
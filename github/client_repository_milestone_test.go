@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+func TestMilestoneClient(t *testing.T) {
+	var created, editedMilestone, editedIssue map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/milestones", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			if got := r.URL.Query().Get("state"); got != "all" {
+				t.Errorf("List() state = %q, want all", got)
+			}
+			w.Write([]byte(`[{"number": 1, "title": "v1.0", "state": "closed"}, {"number": 2, "title": "v1.1", "state": "open"}]`))
+		case http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"number": 3, "title": "v2.0", "description": "next", "state": "open", "html_url": "https://github.example.com/org/repo/milestone/3"}`))
+		}
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/milestones/3", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			if err := json.NewDecoder(r.Body).Decode(&editedMilestone); err != nil {
+				t.Error(err)
+			}
+		}
+		w.Write([]byte(`{"number": 3, "title": "v2.0", "state": "open"}`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/milestones/4", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/issues/7", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&editedIssue); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"number": 7}`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &MilestoneClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	milestones, err := c.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var states []gitprovider.MilestoneState
+	for _, m := range milestones {
+		states = append(states, m.Get().State)
+	}
+	if want := []gitprovider.MilestoneState{gitprovider.MilestoneStateClosed, gitprovider.MilestoneStateOpen}; !reflect.DeepEqual(states, want) {
+		t.Errorf("List() states = %v, want %v", states, want)
+	}
+
+	m, err := c.Create(ctx, gitprovider.MilestoneInfo{Title: "v2.0", Description: gitprovider.StringVar("next")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created["title"] != "v2.0" || created["description"] != "next" {
+		t.Errorf("Create() request = %v, want the title and description", created)
+	}
+	if got := m.Get(); got.Number != 3 || got.WebURL != "https://github.example.com/org/repo/milestone/3" {
+		t.Errorf("Create() = %+v, want milestone 3", got)
+	}
+	if _, err := c.Create(ctx, gitprovider.MilestoneInfo{}); !errors.Is(err, validation.ErrFieldRequired) {
+		t.Errorf("Create() without a title = %v, want ErrFieldRequired", err)
+	}
+
+	if m, err := c.Get(ctx, 3); err != nil || m.Get().Title != "v2.0" {
+		t.Errorf("Get() = %v, %v, want milestone v2.0", m, err)
+	}
+	if _, err := c.Get(ctx, 4); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Get() of a missing milestone = %v, want ErrNotFound", err)
+	}
+
+	// Pull requests are assigned like issues
+	if err := c.AssignPullRequest(ctx, 3, 7); err != nil {
+		t.Fatal(err)
+	}
+	if editedIssue["milestone"] != float64(3) {
+		t.Errorf("AssignPullRequest() request = %v, want milestone 3", editedIssue)
+	}
+
+	if err := c.Close(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if editedMilestone["state"] != "closed" {
+		t.Errorf("Close() request = %v, want state closed", editedMilestone)
+	}
+}
@@ -93,3 +93,68 @@ func (c *PullRequestClient) Merge(ctx context.Context, number int, mergeMethod g
 
 	return nil
 }
+
+// Close closes the pull request without merging it.
+func (c *PullRequestClient) Close(ctx context.Context, number int) error {
+	// PATCH /repos/{owner}/{repo}/pulls/{pull_number}
+	_, _, err := c.c.Client().PullRequests.Edit(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), number, &github.PullRequest{
+		State: github.String("closed"),
+	})
+	return handleHTTPError(err)
+}
+
+// ListComments lists the general comments of the pull request, oldest first.
+// GitHub treats pull requests as issues, hence these are the issue comments.
+func (c *PullRequestClient) ListComments(ctx context.Context, number int) ([]gitprovider.PullRequestComment, error) {
+	var apiObjs []*github.IssueComment
+	opts := &github.IssueListCommentsOptions{}
+	err := allPages(&opts.ListOptions, func() (*github.Response, error) {
+		// GET /repos/{owner}/{repo}/issues/{issue_number}/comments
+		pageObjs, resp, listErr := c.c.Client().Issues.ListComments(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), number, opts)
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]gitprovider.PullRequestComment, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		comments = append(comments, newPullRequestComment(apiObj))
+	}
+	return comments, nil
+}
+
+// CreateComment adds a comment with the given body to the pull request.
+func (c *PullRequestClient) CreateComment(ctx context.Context, number int, body string) (gitprovider.PullRequestComment, error) {
+	// POST /repos/{owner}/{repo}/issues/{issue_number}/comments
+	apiObj, _, err := c.c.Client().Issues.CreateComment(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), number, &github.IssueComment{
+		Body: &body,
+	})
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return newPullRequestComment(apiObj), nil
+}
+
+// EditComment replaces the body of a comment of the pull request. Comment IDs are unique
+// within the repository on GitHub, so the pull request number isn't used.
+//
+// ErrNotFound is returned if the comment doesn't exist.
+func (c *PullRequestClient) EditComment(ctx context.Context, _ int, id int64, body string) error {
+	// PATCH /repos/{owner}/{repo}/issues/comments/{comment_id}
+	_, _, err := c.c.Client().Issues.EditComment(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), id, &github.IssueComment{
+		Body: &body,
+	})
+	return handleHTTPError(err)
+}
+
+// DeleteComment deletes a comment of the pull request. Comment IDs are unique within the
+// repository on GitHub, so the pull request number isn't used.
+//
+// ErrNotFound is returned if the comment doesn't exist.
+func (c *PullRequestClient) DeleteComment(ctx context.Context, _ int, id int64) error {
+	// DELETE /repos/{owner}/{repo}/issues/comments/{comment_id}
+	_, err := c.c.Client().Issues.DeleteComment(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), id)
+	return handleHTTPError(err)
+}
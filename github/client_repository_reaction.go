@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+// ReactionClient implements the gitprovider.ReactionClient interface.
+var _ gitprovider.ReactionClient = &ReactionClient{}
+
+// ReactionClient operates on the emoji reactions for a specific repository.
+// GitHub treats pull requests as issues, hence both are handled the same way.
+type ReactionClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists the reactions on the given subject, oldest first.
+func (c *ReactionClient) List(ctx context.Context, subject gitprovider.ReactionSubject) ([]gitprovider.ReactionInfo, error) {
+	if err := validateReactionSubject(subject); err != nil {
+		return nil, err
+	}
+	var apiObjs []*github.Reaction
+	opts := &github.ListOptions{}
+	err := allPages(opts, func() (*github.Response, error) {
+		var pageObjs []*github.Reaction
+		var resp *github.Response
+		var listErr error
+		if subject.CommentID != 0 {
+			// GET /repos/{owner}/{repo}/issues/comments/{comment_id}/reactions
+			pageObjs, resp, listErr = c.c.Client().Reactions.ListIssueCommentReactions(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), subject.CommentID, opts)
+		} else {
+			// GET /repos/{owner}/{repo}/issues/{issue_number}/reactions
+			pageObjs, resp, listErr = c.c.Client().Reactions.ListIssueReactions(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), subject.Number, opts)
+		}
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reactions := make([]gitprovider.ReactionInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		reactions = append(reactions, reactionFromAPI(apiObj))
+	}
+	return reactions, nil
+}
+
+// Add adds a reaction with the given content to the subject, as the authenticated user.
+// GitHub returns the existing reaction if the user already reacted with that content.
+func (c *ReactionClient) Add(ctx context.Context, subject gitprovider.ReactionSubject, content gitprovider.ReactionContent) (gitprovider.ReactionInfo, error) {
+	if err := validateReactionSubject(subject); err != nil {
+		return gitprovider.ReactionInfo{}, err
+	}
+	if err := gitprovider.ValidateReactionContent(content); err != nil {
+		return gitprovider.ReactionInfo{}, err
+	}
+
+	var apiObj *github.Reaction
+	var err error
+	if subject.CommentID != 0 {
+		// POST /repos/{owner}/{repo}/issues/comments/{comment_id}/reactions
+		apiObj, _, err = c.c.Client().Reactions.CreateIssueCommentReaction(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), subject.CommentID, string(content))
+	} else {
+		// POST /repos/{owner}/{repo}/issues/{issue_number}/reactions
+		apiObj, _, err = c.c.Client().Reactions.CreateIssueReaction(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), subject.Number, string(content))
+	}
+	if err != nil {
+		return gitprovider.ReactionInfo{}, handleHTTPError(err)
+	}
+	return reactionFromAPI(apiObj), nil
+}
+
+// Remove removes the reaction with the given ID from the subject.
+func (c *ReactionClient) Remove(ctx context.Context, subject gitprovider.ReactionSubject, id int64) error {
+	if err := validateReactionSubject(subject); err != nil {
+		return err
+	}
+	var err error
+	if subject.CommentID != 0 {
+		// DELETE /repos/{owner}/{repo}/issues/comments/{comment_id}/reactions/{reaction_id}
+		_, err = c.c.Client().Reactions.DeleteIssueCommentReaction(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), subject.CommentID, id)
+	} else {
+		// DELETE /repos/{owner}/{repo}/issues/{issue_number}/reactions/{reaction_id}
+		_, err = c.c.Client().Reactions.DeleteIssueReaction(ctx, c.ref.GetIdentity(), c.ref.GetRepository(), subject.Number, id)
+	}
+	return handleHTTPError(err)
+}
+
+func validateReactionSubject(subject gitprovider.ReactionSubject) error {
+	validator := validation.New("ReactionSubject")
+	subject.ValidateFields(validator)
+	return validator.Error()
+}
+
+func reactionFromAPI(apiObj *github.Reaction) gitprovider.ReactionInfo {
+	return gitprovider.ReactionInfo{
+		ID:      apiObj.GetID(),
+		Content: gitprovider.ReactionContent(apiObj.GetContent()),
+		User:    apiObj.GetUser().GetLogin(),
+	}
+}
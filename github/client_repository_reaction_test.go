@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestReactionClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/issues/3/reactions", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 10, "content": "+1", "user": {"login": "alice"}}, {"id": 11, "content": "eyes", "user": {"login": "bob"}}]`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/issues/comments/5/reactions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 12, "content": "rocket", "user": {"login": "bot"}}`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/issues/3/reactions/99", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &ReactionClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	reactions, err := c.List(ctx, gitprovider.ReactionSubject{Number: 3, PullRequest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gitprovider.ReactionInfo{
+		{ID: 10, Content: gitprovider.ReactionContentThumbsUp, User: "alice"},
+		{ID: 11, Content: gitprovider.ReactionContentEyes, User: "bob"},
+	}
+	if !reflect.DeepEqual(reactions, want) {
+		t.Errorf("List() = %+v, want %+v", reactions, want)
+	}
+
+	reaction, err := c.Add(ctx, gitprovider.ReactionSubject{Number: 3, CommentID: 5}, gitprovider.ReactionContentRocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (gitprovider.ReactionInfo{ID: 12, Content: gitprovider.ReactionContentRocket, User: "bot"}); reaction != want {
+		t.Errorf("Add() = %+v, want %+v", reaction, want)
+	}
+	if _, err := c.Add(ctx, gitprovider.ReactionSubject{Number: 3}, gitprovider.ReactionContent("tada")); err == nil {
+		t.Error("Add() with an unknown content succeeded, want an error")
+	}
+
+	if err := c.Remove(ctx, gitprovider.ReactionSubject{Number: 3}, 99); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Remove() error = %v, want ErrNotFound", err)
+	}
+}
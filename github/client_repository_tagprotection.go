@@ -0,0 +1,139 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// TagProtectionClient implements the gitprovider.TagProtectionClient interface.
+var _ gitprovider.TagProtectionClient = &TagProtectionClient{}
+
+// TagProtectionClient operates on the tag protection rules for a specific repository.
+// go-github doesn't have the tag protection endpoints, hence the requests are made directly.
+type TagProtectionClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// tagProtection is a tag protection rule of the API.
+type tagProtection struct {
+	ID      int64  `json:"id,omitempty"`
+	Pattern string `json:"pattern"`
+}
+
+// List lists the tag protection rules of the repository.
+func (c *TagProtectionClient) List(ctx context.Context) ([]gitprovider.TagProtectionInfo, error) {
+	apiObjs, err := c.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]gitprovider.TagProtectionInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		rules = append(rules, gitprovider.TagProtectionInfo{Pattern: apiObj.Pattern})
+	}
+	return rules, nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (c *TagProtectionClient) Reconcile(ctx context.Context, req gitprovider.TagProtectionInfo) (gitprovider.TagProtectionInfo, bool, error) {
+	if err := req.ValidateInfo(); err != nil {
+		return gitprovider.TagProtectionInfo{}, false, err
+	}
+	apiObj, err := c.get(ctx, req.Pattern)
+	if err != nil {
+		return gitprovider.TagProtectionInfo{}, false, err
+	}
+	if apiObj != nil {
+		return req, false, nil
+	}
+	change := gitprovider.NewCreateChange(c.ref, req)
+	// In dry-run mode, only report what would be created
+	if c.dryRun {
+		return req, true, gitprovider.NewDryRunError(change)
+	}
+
+	path := fmt.Sprintf("repos/%s/%s/tags/protection", c.ref.GetIdentity(), c.ref.GetRepository())
+	httpReq, err := c.c.Client().NewRequest(http.MethodPost, path, &tagProtection{Pattern: req.Pattern})
+	if err != nil {
+		return gitprovider.TagProtectionInfo{}, false, err
+	}
+	// POST /repos/{owner}/{repo}/tags/protection
+	if _, err := c.c.Client().Do(ctx, httpReq, &tagProtection{}); err != nil {
+		return gitprovider.TagProtectionInfo{}, true, handleHTTPError(err)
+	}
+	gitprovider.RecordChange(ctx, change, apiCallCreateTagProtection)
+	return req, true, nil
+}
+
+// Delete deletes the rule with the given pattern.
+//
+// ErrNotFound is returned if there is no rule with the pattern.
+func (c *TagProtectionClient) Delete(ctx context.Context, pattern string) error {
+	apiObj, err := c.get(ctx, pattern)
+	if err != nil {
+		return err
+	}
+	if apiObj == nil {
+		return fmt.Errorf("tag protection %q: %w", pattern, gitprovider.ErrNotFound)
+	}
+	path := fmt.Sprintf("repos/%s/%s/tags/protection/%d", c.ref.GetIdentity(), c.ref.GetRepository(), apiObj.ID)
+	httpReq, err := c.c.Client().NewRequest(http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+	// DELETE /repos/{owner}/{repo}/tags/protection/{tag_protection_id}
+	_, err = c.c.Client().Do(ctx, httpReq, nil)
+	return handleHTTPError(err)
+}
+
+// get returns the rule with the given pattern, or nil if there is none. The API can only delete
+// rules by ID, and has no endpoint for a single rule.
+func (c *TagProtectionClient) get(ctx context.Context, pattern string) (*tagProtection, error) {
+	apiObjs, err := c.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, apiObj := range apiObjs {
+		if apiObj.Pattern == pattern {
+			return apiObj, nil
+		}
+	}
+	return nil, nil
+}
+
+// list returns the rules of the repository, which the API doesn't paginate.
+func (c *TagProtectionClient) list(ctx context.Context) ([]*tagProtection, error) {
+	path := fmt.Sprintf("repos/%s/%s/tags/protection", c.ref.GetIdentity(), c.ref.GetRepository())
+	httpReq, err := c.c.Client().NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var apiObjs []*tagProtection
+	// GET /repos/{owner}/{repo}/tags/protection
+	if _, err := c.c.Client().Do(ctx, httpReq, &apiObjs); err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return apiObjs, nil
+}
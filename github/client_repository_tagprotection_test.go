@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestTagProtectionClient(t *testing.T) {
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/tags/protection", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			calls = append(calls, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 3, "pattern": "release-*"}`))
+			return
+		}
+		w.Write([]byte(`[{"id": 2, "pattern": "v*"}]`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/tags/protection/2", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &TagProtectionClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	rules, err := c.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []gitprovider.TagProtectionInfo{{Pattern: "v*"}}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("List() = %+v, want %+v", rules, want)
+	}
+	if _, actionTaken, err := c.Reconcile(ctx, gitprovider.TagProtectionInfo{Pattern: "v*"}); err != nil || actionTaken {
+		t.Errorf("Reconcile() = %v, %v, want no action", actionTaken, err)
+	}
+	if _, actionTaken, err := c.Reconcile(ctx, gitprovider.TagProtectionInfo{Pattern: "release-*"}); err != nil || !actionTaken {
+		t.Errorf("Reconcile() = %v, %v, want a creation", actionTaken, err)
+	}
+	if err := c.Delete(ctx, "v*"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(ctx, "unknown"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Delete() error = %v, want ErrNotFound", err)
+	}
+	want := []string{"POST /api/v3/repos/org/repo/tags/protection", "DELETE /api/v3/repos/org/repo/tags/protection/2"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
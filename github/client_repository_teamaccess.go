@@ -112,8 +112,16 @@ func (c *TeamAccessClient) Reconcile(ctx context.Context,
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(c.ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := c.Create(ctx, req)
-			return resp, true, err
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallAddTeam)
+			return resp, true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
@@ -124,10 +132,19 @@ func (c *TeamAccessClient) Reconcile(ctx context.Context,
 	if req.Equals(actual.Get()) {
 		return actual, false, nil
 	}
+	change := gitprovider.NewUpdateChange(c.ref, actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return actual, true, gitprovider.NewDryRunError(change)
+	}
 
 	// Populate the desired state to the current-actual object
 	if err := actual.Set(req); err != nil {
 		return actual, false, err
 	}
-	return actual, true, actual.Update(ctx)
+	if err := actual.Update(ctx); err != nil {
+		return actual, true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallAddTeam)
+	return actual, true, nil
 }
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// searchPageSize is the largest number of results per page of a search.
+const searchPageSize = 100
+
+// SearchClient implements the gitprovider.SearchClient interface.
+var _ gitprovider.SearchClient = &SearchClient{}
+
+// SearchClient searches for repositories and code using the GitHub search API.
+type SearchClient struct {
+	*clientContext
+}
+
+// Repositories returns the repositories matching the text or topics of the query, up to
+// query.Limit results.
+func (c *SearchClient) Repositories(ctx context.Context, query gitprovider.SearchQuery) ([]gitprovider.RepositorySearchResult, error) {
+	query.Default()
+	if err := query.ValidateRepositoryQuery(); err != nil {
+		return nil, err
+	}
+	q, err := c.searchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	for _, topic := range query.Topics {
+		q = append(q, "topic:"+quoteSearchTerm(topic))
+	}
+
+	results := make([]gitprovider.RepositorySearchResult, 0)
+	opts := &github.SearchOptions{ListOptions: searchListOptions(query.Limit)}
+	for {
+		// GET /search/repositories
+		pageObjs, resp, err := c.c.Client().Search.Repositories(ctx, strings.Join(q, " "), opts)
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		for _, apiObj := range pageObjs.Repositories {
+			results = append(results, gitprovider.RepositorySearchResult{
+				Repository:  repositoryRefFromAPI(c.domain, apiObj),
+				Description: apiObj.GetDescription(),
+				Topics:      apiObj.Topics,
+			})
+		}
+		// Stop paginating once there are enough results
+		if len(results) >= query.Limit || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+// Code returns the files with content matching the text of the query, up to query.Limit results.
+func (c *SearchClient) Code(ctx context.Context, query gitprovider.SearchQuery) ([]gitprovider.CodeSearchResult, error) {
+	query.Default()
+	if err := query.ValidateCodeQuery(); err != nil {
+		return nil, err
+	}
+	q, err := c.searchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if query.Repository != nil {
+		if err := validateIdentityFields(query.Repository, c.domain); err != nil {
+			return nil, err
+		}
+		q = append(q, fmt.Sprintf("repo:%s/%s", query.Repository.GetIdentity(), query.Repository.GetRepository()))
+	}
+	if query.Filename != "" {
+		q = append(q, "filename:"+quoteSearchTerm(query.Filename))
+	}
+
+	results := make([]gitprovider.CodeSearchResult, 0)
+	opts := &github.SearchOptions{ListOptions: searchListOptions(query.Limit)}
+	for {
+		// GET /search/code
+		pageObjs, resp, err := c.c.Client().Search.Code(ctx, strings.Join(q, " "), opts)
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		for _, apiObj := range pageObjs.CodeResults {
+			results = append(results, gitprovider.CodeSearchResult{
+				Repository: repositoryRefFromAPI(c.domain, apiObj.GetRepository()),
+				Path:       apiObj.GetPath(),
+				SHA:        apiObj.GetSHA(),
+			})
+		}
+		// Stop paginating once there are enough results
+		if len(results) >= query.Limit || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+// searchQuery returns the terms of the GitHub search query for the text and owner of the query.
+func (c *SearchClient) searchQuery(query gitprovider.SearchQuery) ([]string, error) {
+	var q []string
+	if query.Text != "" {
+		q = append(q, query.Text)
+	}
+	if query.Owner != nil {
+		if err := validateIdentityFields(query.Owner, c.domain); err != nil {
+			return nil, err
+		}
+		qualifier := "user:"
+		if query.Owner.GetType() == gitprovider.IdentityTypeOrganization {
+			qualifier = "org:"
+		}
+		q = append(q, qualifier+query.Owner.GetIdentity())
+	}
+	return q, nil
+}
+
+// searchListOptions returns the list options to fetch limit results with as few pages as possible.
+func searchListOptions(limit int) github.ListOptions {
+	if limit > searchPageSize {
+		limit = searchPageSize
+	}
+	return github.ListOptions{PerPage: limit}
+}
+
+// quoteSearchTerm quotes the term if it contains whitespace, so that it's a single search term.
+func quoteSearchTerm(term string) string {
+	if strings.ContainsAny(term, " \t") {
+		return fmt.Sprintf("%q", term)
+	}
+	return term
+}
+
+// repositoryRefFromAPI returns the reference to the repository, an OrgRepositoryRef if it's
+// owned by an organization, or a UserRepositoryRef otherwise.
+func repositoryRefFromAPI(domain string, apiObj *github.Repository) gitprovider.RepositoryRef {
+	owner := apiObj.GetOwner()
+	if owner.GetType() == "Organization" {
+		return gitprovider.OrgRepositoryRef{
+			OrganizationRef: gitprovider.OrganizationRef{Domain: domain, Organization: owner.GetLogin()},
+			RepositoryName:  apiObj.GetName(),
+		}
+	}
+	return gitprovider.UserRepositoryRef{
+		UserRef:        gitprovider.UserRef{Domain: domain, UserLogin: owner.GetLogin()},
+		RepositoryName: apiObj.GetName(),
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestSearchClient(t *testing.T) {
+	var queries []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("q"))
+		// Two pages of one result each
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v3/search/repositories?page=2>; rel="next"`, "http://"+r.Host))
+			w.Write([]byte(`{"total_count": 2, "items": [{"name": "flux2", "description": "GitOps", "topics": ["flux", "gitops"], "owner": {"login": "fluxcd", "type": "Organization"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"total_count": 2, "items": [{"name": "fleet", "topics": ["flux"], "owner": {"login": "alice", "type": "User"}}]}`))
+	})
+	mux.HandleFunc("/api/v3/search/code", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("q"))
+		w.Write([]byte(`{"total_count": 1, "items": [{"path": "clusters/prod/kustomization.yaml", "sha": "abc", "repository": {"name": "fleet", "owner": {"login": "fluxcd", "type": "Organization"}}}]}`))
+	})
+	client := newTestClient(t, mux)
+	c := client.Search()
+	ctx := context.Background()
+
+	repos, err := c.Repositories(ctx, gitprovider.SearchQuery{Text: "gitops", Topics: []string{"flux"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRepos := []gitprovider.RepositorySearchResult{
+		{
+			Repository:  gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "fluxcd"}, RepositoryName: "flux2"},
+			Description: "GitOps",
+			Topics:      []string{"flux", "gitops"},
+		},
+		{
+			Repository: gitprovider.UserRepositoryRef{UserRef: gitprovider.UserRef{Domain: "github.example.com", UserLogin: "alice"}, RepositoryName: "fleet"},
+			Topics:     []string{"flux"},
+		},
+	}
+	if !reflect.DeepEqual(repos, wantRepos) {
+		t.Errorf("Repositories() = %+v, want %+v", repos, wantRepos)
+	}
+	// The limit stops the pagination
+	repos, err = c.Repositories(ctx, gitprovider.SearchQuery{Topics: []string{"flux"}, Limit: 1})
+	if err != nil || len(repos) != 1 {
+		t.Errorf("Repositories() = %+v, %v, want one result", repos, err)
+	}
+
+	files, err := c.Code(ctx, gitprovider.SearchQuery{
+		Text:     "kind: Kustomization",
+		Owner:    gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "fluxcd"},
+		Filename: "kustomization.yaml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFiles := []gitprovider.CodeSearchResult{{
+		Repository: gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "fluxcd"}, RepositoryName: "fleet"},
+		Path:       "clusters/prod/kustomization.yaml",
+		SHA:        "abc",
+	}}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("Code() = %+v, want %+v", files, wantFiles)
+	}
+
+	wantQueries := []string{
+		"gitops topic:flux",
+		"gitops topic:flux",
+		"topic:flux",
+		"kind: Kustomization org:fluxcd filename:kustomization.yaml",
+	}
+	if !reflect.DeepEqual(queries, wantQueries) {
+		t.Errorf("queries = %q, want %q", queries, wantQueries)
+	}
+}
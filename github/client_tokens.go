@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// TokensClient implements the gitprovider.TokensClient interface.
+var _ gitprovider.TokensClient = &TokensClient{}
+
+// TokensClient operates on the access tokens of repositories and organizations.
+// GitHub personal access tokens, fine-grained or classic, can only be created by their user in
+// the web UI and aren't owned by a repository or organization, so every method returns
+// gitprovider.ErrNoProviderSupport.
+type TokensClient struct {
+	*clientContext
+}
+
+// List lists the active access tokens of the repository or organization.
+func (c *TokensClient) List(_ context.Context, _ gitprovider.IdentityRef) ([]gitprovider.AccessTokenInfo, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Create creates an access token for the repository or organization.
+func (c *TokensClient) Create(_ context.Context, _ gitprovider.IdentityRef, _ gitprovider.AccessTokenInfo) (gitprovider.AccessTokenInfo, error) {
+	return gitprovider.AccessTokenInfo{}, gitprovider.ErrNoProviderSupport
+}
+
+// Rotate replaces the token with the given ID by a new one expiring at the given time.
+func (c *TokensClient) Rotate(_ context.Context, _ gitprovider.IdentityRef, _ int64, _ time.Time) (gitprovider.AccessTokenInfo, error) {
+	return gitprovider.AccessTokenInfo{}, gitprovider.ErrNoProviderSupport
+}
+
+// Revoke revokes the token with the given ID.
+func (c *TokensClient) Revoke(_ context.Context, _ gitprovider.IdentityRef, _ int64) error {
+	return gitprovider.ErrNoProviderSupport
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// UserKeysClient implements the gitprovider.UserKeysClient interface.
+var _ gitprovider.UserKeysClient = &UserKeysClient{}
+
+// UserKeysClient operates on the SSH and GPG keys of the authenticated user.
+type UserKeysClient struct {
+	*clientContext
+}
+
+// List lists the keys of the given type.
+//
+// List returns all available keys, using multiple paginated requests if needed.
+func (c *UserKeysClient) List(ctx context.Context, keyType gitprovider.UserKeyType) ([]gitprovider.UserKeyInfo, error) {
+	if err := gitprovider.ValidateUserKeyType(keyType); err != nil {
+		return nil, fmt.Errorf("key type %q: %w", keyType, err)
+	}
+
+	keys := make([]gitprovider.UserKeyInfo, 0)
+	opts := &github.ListOptions{}
+	err := allPages(opts, func() (*github.Response, error) {
+		if keyType == gitprovider.UserKeyTypeGPG {
+			// GET /user/gpg_keys
+			pageObjs, resp, err := c.c.Client().Users.ListGPGKeys(ctx, "", opts)
+			for _, apiObj := range pageObjs {
+				keys = append(keys, gpgKeyFromAPI(apiObj))
+			}
+			return resp, err
+		}
+		// GET /user/keys
+		pageObjs, resp, err := c.c.Client().Users.ListKeys(ctx, "", opts)
+		for _, apiObj := range pageObjs {
+			keys = append(keys, sshKeyFromAPI(apiObj))
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Add adds the given key, returning it as stored by the provider.
+//
+// ErrAlreadyExists is returned if the key is already in use.
+func (c *UserKeysClient) Add(ctx context.Context, req gitprovider.UserKeyInfo) (gitprovider.UserKeyInfo, error) {
+	if err := req.Validate(); err != nil {
+		return gitprovider.UserKeyInfo{}, err
+	}
+
+	if req.Type == gitprovider.UserKeyTypeGPG {
+		// POST /user/gpg_keys
+		apiObj, _, err := c.c.Client().Users.CreateGPGKey(ctx, req.Key)
+		if err != nil {
+			return gitprovider.UserKeyInfo{}, handleHTTPError(err)
+		}
+		key := gpgKeyFromAPI(apiObj)
+		if key.Key == "" {
+			key.Key = req.Key
+		}
+		return key, nil
+	}
+	// POST /user/keys
+	apiObj, _, err := c.c.Client().Users.CreateKey(ctx, &github.Key{
+		Title: &req.Title,
+		Key:   &req.Key,
+	})
+	if err != nil {
+		return gitprovider.UserKeyInfo{}, handleHTTPError(err)
+	}
+	return sshKeyFromAPI(apiObj), nil
+}
+
+// Delete removes the key of the given type and ID.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *UserKeysClient) Delete(ctx context.Context, keyType gitprovider.UserKeyType, id int64) error {
+	if err := gitprovider.ValidateUserKeyType(keyType); err != nil {
+		return fmt.Errorf("key type %q: %w", keyType, err)
+	}
+
+	var err error
+	if keyType == gitprovider.UserKeyTypeGPG {
+		// DELETE /user/gpg_keys/{gpg_key_id}
+		_, err = c.c.Client().Users.DeleteGPGKey(ctx, id)
+	} else {
+		// DELETE /user/keys/{key_id}
+		_, err = c.c.Client().Users.DeleteKey(ctx, id)
+	}
+	return handleHTTPError(err)
+}
+
+func sshKeyFromAPI(apiObj *github.Key) gitprovider.UserKeyInfo {
+	return gitprovider.UserKeyInfo{
+		ID:        apiObj.GetID(),
+		Type:      gitprovider.UserKeyTypeSSH,
+		Title:     apiObj.GetTitle(),
+		Key:       apiObj.GetKey(),
+		CreatedAt: timestampTime(apiObj.CreatedAt),
+	}
+}
+
+func gpgKeyFromAPI(apiObj *github.GPGKey) gitprovider.UserKeyInfo {
+	return gitprovider.UserKeyInfo{
+		ID:        apiObj.GetID(),
+		Type:      gitprovider.UserKeyTypeGPG,
+		Key:       apiObj.GetRawKey(),
+		KeyID:     apiObj.GetKeyID(),
+		CreatedAt: apiObj.CreatedAt,
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestUserKeysClient(t *testing.T) {
+	var deleted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/user/keys", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message": "Validation Failed", "errors": [{"resource": "PublicKey", "code": "custom", "field": "key", "message": "key is already in use"}]}`))
+			return
+		}
+		w.Write([]byte(`[{"id": 1, "title": "bot", "key": "ssh-ed25519 AAAA"}]`))
+	})
+	mux.HandleFunc("/api/v3/user/gpg_keys", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 2, "key_id": "3AA5C34371567BD2"}`))
+	})
+	mux.HandleFunc("/api/v3/user/gpg_keys/2", func(w http.ResponseWriter, r *http.Request) {
+		deleted = append(deleted, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+	c := client.UserKeys()
+	ctx := context.Background()
+
+	keys, err := c.List(ctx, gitprovider.UserKeyTypeSSH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gitprovider.UserKeyInfo{{ID: 1, Type: gitprovider.UserKeyTypeSSH, Title: "bot", Key: "ssh-ed25519 AAAA"}}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("List() = %+v, want %+v", keys, want)
+	}
+
+	_, err = c.Add(ctx, gitprovider.UserKeyInfo{Type: gitprovider.UserKeyTypeSSH, Key: "ssh-ed25519 AAAA"})
+	if !errors.Is(err, gitprovider.ErrAlreadyExists) {
+		t.Errorf("Add() of a key in use error = %v, want ErrAlreadyExists", err)
+	}
+
+	// The GPG key is returned with the added key material
+	key, err := c.Add(ctx, gitprovider.UserKeyInfo{Type: gitprovider.UserKeyTypeGPG, Key: "-----BEGIN PGP PUBLIC KEY BLOCK-----"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantKey := gitprovider.UserKeyInfo{ID: 2, Type: gitprovider.UserKeyTypeGPG, Key: "-----BEGIN PGP PUBLIC KEY BLOCK-----", KeyID: "3AA5C34371567BD2"}
+	if !reflect.DeepEqual(key, wantKey) {
+		t.Errorf("Add() = %+v, want %+v", key, wantKey)
+	}
+
+	if err := c.Delete(ctx, gitprovider.UserKeyTypeGPG, 2); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"DELETE /api/v3/user/gpg_keys/2"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("requests = %v, want %v", deleted, want)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/google/go-github/v41/github"
+)
+
+// ExtrasName is the name the GitHub Extras are registered under, for use with Client.Extension.
+const ExtrasName = "github"
+
+// Extras contains GitHub-specific functionality that doesn't fit the generic interfaces.
+// It can be accessed using c.Extension(github.ExtrasName).(github.Extras), or ExtrasFor(c).
+type Extras interface {
+	// RateLimits returns the current rate limits of the authenticated user or client.
+	RateLimits(ctx context.Context) (*github.RateLimits, error)
+	// RepositoryRulesets gives access to the rulesets of the given repository.
+	RepositoryRulesets(ref gitprovider.RepositoryRef) *RulesetClient
+	// OrganizationRulesets gives access to the rulesets of the given organization, e.g. to
+	// require workflows in all its repositories.
+	OrganizationRulesets(ref gitprovider.OrganizationRef) *RulesetClient
+}
+
+func init() {
+	gitprovider.RegisterExtension(ProviderID, ExtrasName, func(c gitprovider.Client) (interface{}, bool) {
+		cl, ok := c.(*Client)
+		if !ok {
+			return nil, false
+		}
+		return &extras{cl}, true
+	})
+}
+
+// The API calls reported with the changes of Reconcile calls, see gitprovider.ChangeAction.
+const (
+	apiCallCreateOrgRepository  = "POST /orgs/{org}/repos"
+	apiCallCreateUserRepository = "POST /user/repos"
+	apiCallUpdateRepository     = "PATCH /repos/{owner}/{repo}"
+	apiCallUpdateEnvironment    = "PUT /repos/{owner}/{repo}/environments/{environment_name}"
+	apiCallAddTeam              = "PUT /orgs/{org}/teams/{team_slug}/repos/{owner}/{repo}"
+	apiCallCreateDeployKey      = "POST /repos/{owner}/{repo}/keys"
+	apiCallDeleteDeployKey      = "DELETE /repos/{owner}/{repo}/keys/{key_id}"
+	apiCallCreateTagProtection  = "POST /repos/{owner}/{repo}/tags/protection"
+)
+
+// ExtrasFor returns the GitHub Extras for the given client.
+// ErrNoProviderSupport is returned if c isn't a GitHub client.
+func ExtrasFor(c gitprovider.Client) (Extras, error) {
+	e, ok := c.Extension(ExtrasName).(Extras)
+	if !ok {
+		return nil, fmt.Errorf("%s client has no GitHub extras: %w", c.ProviderID(), gitprovider.ErrNoProviderSupport)
+	}
+	return e, nil
+}
+
+// extras implements Extras.
+type extras struct {
+	c *Client
+}
+
+func (e *extras) RateLimits(ctx context.Context) (*github.RateLimits, error) {
+	return e.c.c.GetRateLimits(ctx)
+}
+
+func (e *extras) RepositoryRulesets(ref gitprovider.RepositoryRef) *RulesetClient {
+	return &RulesetClient{clientContext: e.c.clientContext, path: fmt.Sprintf("repos/%s/%s", ref.GetIdentity(), ref.GetRepository())}
+}
+
+func (e *extras) OrganizationRulesets(ref gitprovider.OrganizationRef) *RulesetClient {
+	return &RulesetClient{clientContext: e.c.clientContext, path: "orgs/" + ref.Organization}
+}
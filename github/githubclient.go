@@ -19,6 +19,8 @@ package github
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/google/go-github/v41/github"
@@ -35,6 +37,12 @@ type githubClient interface {
 	// GetOrg is a wrapper for "GET /orgs/{org}".
 	// This function HTTP error wrapping, and validates the server result.
 	GetOrg(ctx context.Context, orgName string) (*github.Organization, error)
+	// GetUser is a wrapper for "GET /user".
+	// This function handles HTTP error wrapping.
+	GetUser(ctx context.Context) (*github.User, error)
+	// GetRateLimits is a wrapper for "GET /rate_limit".
+	// This function handles HTTP error wrapping.
+	GetRateLimits(ctx context.Context) (*github.RateLimits, error)
 	// ListOrgs is a wrapper for "GET /user/orgs".
 	// This function handles pagination, HTTP error wrapping, and validates the server result.
 	ListOrgs(ctx context.Context) ([]*github.Organization, error)
@@ -49,30 +57,56 @@ type githubClient interface {
 	// GetRepo is a wrapper for "GET /repos/{owner}/{repo}".
 	// This function handles HTTP error wrapping, and validates the server result.
 	GetRepo(ctx context.Context, owner, repo string) (*github.Repository, error)
-	// ListOrgRepos is a wrapper for "GET /orgs/{org}/repos".
+	// ListOrgRepos is a wrapper for "GET /orgs/{org}/repos", filtered by the type in opts.
 	// This function handles pagination, HTTP error wrapping, and validates the server result.
-	ListOrgRepos(ctx context.Context, org string) ([]*github.Repository, error)
-	// ListUserRepos is a wrapper for "GET /users/{username}/repos".
+	ListOrgRepos(ctx context.Context, org string, opts github.RepositoryListByOrgOptions) ([]*github.Repository, error)
+	// ListOrgReposPage is a wrapper for "GET /orgs/{org}/repos", filtered and sorted as set in opts, returning the
+	// given page and the number of the next one, or 0 if it's the last page.
+	// This function handles HTTP error wrapping, and validates the server result.
+	ListOrgReposPage(ctx context.Context, org string, opts github.RepositoryListByOrgOptions, page int) ([]*github.Repository, int, error)
+	// ListUserRepos is a wrapper for "GET /users/{username}/repos", sorted as set in opts.
 	// This function handles pagination, HTTP error wrapping, and validates the server result.
-	ListUserRepos(ctx context.Context, username string) ([]*github.Repository, error)
+	ListUserRepos(ctx context.Context, username string, opts github.RepositoryListOptions) ([]*github.Repository, error)
+	// ListUserReposPage is a wrapper for "GET /users/{username}/repos", sorted as set in opts, returning the given
+	// page and the number of the next one, or 0 if it's the last page.
+	// This function handles HTTP error wrapping, and validates the server result.
+	ListUserReposPage(ctx context.Context, username string, opts github.RepositoryListOptions, page int) ([]*github.Repository, int, error)
 	// CreateRepo is a wrapper for "POST /user/repos" (if orgName == "")
-	// or "POST /orgs/{org}/repos" (if orgName != "").
+	// or "POST /orgs/{org}/repos" (if orgName != ""), followed by "PUT /repos/{owner}/{repo}/topics"
+	// if req.Topics is set.
 	// This function handles HTTP error wrapping, and validates the server result.
 	CreateRepo(ctx context.Context, orgName string, req *github.Repository) (*github.Repository, error)
-	// UpdateRepo is a wrapper for "PATCH /repos/{owner}/{repo}".
+	// CreateRepoFromTemplate is a wrapper for "POST /repos/{template_owner}/{template_repo}/generate",
+	// creating the repository for the authenticated user (if orgName == "") or the organization,
+	// followed by "PATCH /repos/{owner}/{repo}" for internal repositories and merge settings, and
+	// "PUT /repos/{owner}/{repo}/topics" if req.Topics is set.
+	// This function handles HTTP error wrapping, and validates the server result.
+	CreateRepoFromTemplate(ctx context.Context, templateOwner, templateRepo, orgName string, req *github.Repository) (*github.Repository, error)
+	// RenameBranch is a wrapper for "POST /repos/{owner}/{repo}/branches/{branch}/rename".
+	// This function handles HTTP error wrapping.
+	RenameBranch(ctx context.Context, owner, repo, branch, newName string) error
+	// UpdateRepo is a wrapper for "PATCH /repos/{owner}/{repo}", followed by
+	// "PUT /repos/{owner}/{repo}/topics" if req.Topics is set and differs from the actual topics.
+	// If req.Archived is true, the repository is archived last, as archived repositories are read-only.
 	// This function handles HTTP error wrapping, and validates the server result.
 	UpdateRepo(ctx context.Context, owner, repo string, req *github.Repository) (*github.Repository, error)
 	// DeleteRepo is a wrapper for "DELETE /repos/{owner}/{repo}".
 	// This function handles HTTP error wrapping.
 	// DANGEROUS COMMAND: In order to use this, you must set destructiveActions to true.
 	DeleteRepo(ctx context.Context, owner, repo string) error
+	// DownloadArchive is a wrapper for "GET /repos/{owner}/{repo}/tarball/{ref}"
+	// or "GET /repos/{owner}/{repo}/zipball/{ref}", following the redirect to the archive.
+	// This function handles HTTP error wrapping. The caller must close the returned body.
+	DownloadArchive(ctx context.Context, owner, repo, ref string, format gitprovider.ArchiveFormat) (io.ReadCloser, error)
 
 	// ListKeys is a wrapper for "GET /repos/{owner}/{repo}/keys".
 	// This function handles pagination, HTTP error wrapping, and validates the server result.
 	ListKeys(ctx context.Context, owner, repo string) ([]*github.Key, error)
 	// ListCommitsPage is a wrapper for "GET /repos/{owner}/{repo}/commits".
+	// If opts.WithStats is set, "GET /repos/{owner}/{repo}/commits/{ref}" is called for each commit,
+	// running up to the client concurrency requests at once.
 	// This function handles pagination, HTTP error wrapping.
-	ListCommitsPage(ctx context.Context, owner, repo, branch string, perPage int, page int) ([]*github.Commit, error)
+	ListCommitsPage(ctx context.Context, owner, repo, branch string, perPage int, page int, opts gitprovider.CommitListOptions) ([]*github.Commit, error)
 	// CreateKey is a wrapper for "POST /repos/{owner}/{repo}/keys".
 	// This function handles HTTP error wrapping, and validates the server result.
 	CreateKey(ctx context.Context, owner, repo string, req *github.Key) (*github.Key, error)
@@ -101,6 +135,8 @@ type githubClient interface {
 type githubClientImpl struct {
 	c                  *github.Client
 	destructiveActions bool
+	// concurrency is the number of pages the aggregate List* methods fetch at once
+	concurrency int
 }
 
 // githubClientImpl implements githubClient.
@@ -110,6 +146,24 @@ func (c *githubClientImpl) Client() *github.Client {
 	return c.c
 }
 
+func (c *githubClientImpl) GetUser(ctx context.Context) (*github.User, error) {
+	// GET /user
+	apiObj, _, err := c.c.Users.Get(ctx, "")
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return apiObj, nil
+}
+
+func (c *githubClientImpl) GetRateLimits(ctx context.Context) (*github.RateLimits, error) {
+	// GET /rate_limit
+	apiObj, _, err := c.c.RateLimits(ctx)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return apiObj, nil
+}
+
 func (c *githubClientImpl) GetOrg(ctx context.Context, orgName string) (*github.Organization, error) {
 	// GET /orgs/{org}
 	apiObj, _, err := c.c.Organizations.Get(ctx, orgName)
@@ -147,16 +201,17 @@ func (c *githubClientImpl) ListOrgs(ctx context.Context) ([]*github.Organization
 
 func (c *githubClientImpl) ListOrgTeamMembers(ctx context.Context, orgName, teamName string) ([]*github.User, error) {
 	apiObjs := []*github.User{}
-	opts := &github.TeamListTeamMembersOptions{}
-	err := allPages(&opts.ListOptions, func() (*github.Response, error) {
+	pages, err := allPagesConcurrently(c.concurrency, func(page int) (interface{}, *github.Response, error) {
 		// GET /orgs/{org}/teams/{team_slug}/members
-		pageObjs, resp, listErr := c.c.Teams.ListTeamMembersBySlug(ctx, orgName, teamName, opts)
-		apiObjs = append(apiObjs, pageObjs...)
-		return resp, listErr
+		opts := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{Page: page}}
+		return c.c.Teams.ListTeamMembersBySlug(ctx, orgName, teamName, opts)
 	})
 	if err != nil {
 		return nil, err
 	}
+	for _, pageObjs := range pages {
+		apiObjs = append(apiObjs, pageObjs.([]*github.User)...)
+	}
 
 	// Make sure the Login field is set.
 	for _, apiObj := range apiObjs {
@@ -171,16 +226,16 @@ func (c *githubClientImpl) ListOrgTeamMembers(ctx context.Context, orgName, team
 func (c *githubClientImpl) ListOrgTeams(ctx context.Context, orgName string) ([]*github.Team, error) {
 	// List all teams, using pagination. This does not contain information about the members
 	apiObjs := []*github.Team{}
-	opts := &github.ListOptions{}
-	err := allPages(opts, func() (*github.Response, error) {
+	pages, err := allPagesConcurrently(c.concurrency, func(page int) (interface{}, *github.Response, error) {
 		// GET /orgs/{org}/teams
-		pageObjs, resp, listErr := c.c.Teams.ListTeams(ctx, orgName, opts)
-		apiObjs = append(apiObjs, pageObjs...)
-		return resp, listErr
+		return c.c.Teams.ListTeams(ctx, orgName, &github.ListOptions{Page: page})
 	})
 	if err != nil {
 		return nil, err
 	}
+	for _, pageObjs := range pages {
+		apiObjs = append(apiObjs, pageObjs.([]*github.Team)...)
+	}
 
 	// Make sure the Slug field is set.
 	for _, apiObj := range apiObjs {
@@ -209,21 +264,37 @@ func validateRepositoryAPIResp(apiObj *github.Repository, err error) (*github.Re
 	return apiObj, nil
 }
 
-func (c *githubClientImpl) ListOrgRepos(ctx context.Context, org string) ([]*github.Repository, error) {
+func (c *githubClientImpl) ListOrgRepos(ctx context.Context, org string, opts github.RepositoryListByOrgOptions) ([]*github.Repository, error) {
 	var apiObjs []*github.Repository
-	opts := &github.RepositoryListByOrgOptions{}
-	err := allPages(&opts.ListOptions, func() (*github.Response, error) {
+	pages, err := allPagesConcurrently(c.concurrency, func(page int) (interface{}, *github.Response, error) {
 		// GET /orgs/{org}/repos
-		pageObjs, resp, listErr := c.c.Repositories.ListByOrg(ctx, org, opts)
-		apiObjs = append(apiObjs, pageObjs...)
-		return resp, listErr
+		pageOpts := opts
+		pageOpts.Page = page
+		return c.c.Repositories.ListByOrg(ctx, org, &pageOpts)
 	})
 	if err != nil {
 		return nil, err
 	}
+	for _, pageObjs := range pages {
+		apiObjs = append(apiObjs, pageObjs.([]*github.Repository)...)
+	}
 	return validateRepositoryObjects(apiObjs)
 }
 
+func (c *githubClientImpl) ListOrgReposPage(ctx context.Context, org string, opts github.RepositoryListByOrgOptions, page int) ([]*github.Repository, int, error) {
+	// GET /orgs/{org}/repos
+	opts.Page = page
+	apiObjs, resp, err := c.c.Repositories.ListByOrg(ctx, org, &opts)
+	if err != nil {
+		return nil, 0, handleHTTPError(err)
+	}
+	apiObjs, err = validateRepositoryObjects(apiObjs)
+	if err != nil {
+		return nil, 0, err
+	}
+	return apiObjs, resp.NextPage, nil
+}
+
 func validateRepositoryObjects(apiObjs []*github.Repository) ([]*github.Repository, error) {
 	for _, apiObj := range apiObjs {
 		// Make sure apiObj is valid
@@ -234,12 +305,11 @@ func validateRepositoryObjects(apiObjs []*github.Repository) ([]*github.Reposito
 	return apiObjs, nil
 }
 
-func (c *githubClientImpl) ListUserRepos(ctx context.Context, username string) ([]*github.Repository, error) {
+func (c *githubClientImpl) ListUserRepos(ctx context.Context, username string, opts github.RepositoryListOptions) ([]*github.Repository, error) {
 	var apiObjs []*github.Repository
-	opts := &github.RepositoryListOptions{}
 	err := allPages(&opts.ListOptions, func() (*github.Response, error) {
 		// GET /users/{username}/repos
-		pageObjs, resp, listErr := c.c.Repositories.List(ctx, username, opts)
+		pageObjs, resp, listErr := c.c.Repositories.List(ctx, username, &opts)
 		apiObjs = append(apiObjs, pageObjs...)
 		return resp, listErr
 	})
@@ -249,6 +319,20 @@ func (c *githubClientImpl) ListUserRepos(ctx context.Context, username string) (
 	return validateRepositoryObjects(apiObjs)
 }
 
+func (c *githubClientImpl) ListUserReposPage(ctx context.Context, username string, opts github.RepositoryListOptions, page int) ([]*github.Repository, int, error) {
+	// GET /users/{username}/repos
+	opts.Page = page
+	apiObjs, resp, err := c.c.Repositories.List(ctx, username, &opts)
+	if err != nil {
+		return nil, 0, handleHTTPError(err)
+	}
+	apiObjs, err = validateRepositoryObjects(apiObjs)
+	if err != nil {
+		return nil, 0, err
+	}
+	return apiObjs, resp.NextPage, nil
+}
+
 func (c *githubClientImpl) CreateRepo(ctx context.Context, orgName string, req *github.Repository) (*github.Repository, error) {
 	// POST /user/repos (if orgName == "")
 	// POST /orgs/{org}/repos (if orgName != "")
@@ -256,16 +340,94 @@ func (c *githubClientImpl) CreateRepo(ctx context.Context, orgName string, req *
 	if *req.Visibility == "private" {
 		req.Private = &setPrivate
 	}
-	apiObj, _, err := c.c.Repositories.Create(ctx, orgName, req)
-	return validateRepositoryAPIResp(apiObj, err)
+	// Topics can't be set at POST-time, and repositories can't be created archived
+	data := *req
+	data.Topics = nil
+	data.Archived = nil
+	apiObj, _, err := c.c.Repositories.Create(ctx, orgName, &data)
+	apiObj, err = validateRepositoryAPIResp(apiObj, err)
+	if err != nil {
+		return nil, err
+	}
+	return c.replaceTopics(ctx, apiObj.GetOwner().GetLogin(), apiObj, req.Topics)
 }
 
 func (c *githubClientImpl) UpdateRepo(ctx context.Context, owner, repo string, req *github.Repository) (*github.Repository, error) {
 	// PATCH /repos/{owner}/{repo}
-	apiObj, _, err := c.c.Repositories.Edit(ctx, owner, repo, req)
+	data := *req
+	data.Topics = nil
+	if req.GetArchived() {
+		data.Archived = nil
+	}
+	apiObj, _, err := c.c.Repositories.Edit(ctx, owner, repo, &data)
+	apiObj, err = validateRepositoryAPIResp(apiObj, err)
+	if err != nil {
+		return nil, err
+	}
+	apiObj, err = c.replaceTopics(ctx, owner, apiObj, req.Topics)
+	if err != nil || !req.GetArchived() {
+		return apiObj, err
+	}
+	// PATCH /repos/{owner}/{repo}
+	apiObj, _, err = c.c.Repositories.Edit(ctx, owner, apiObj.GetName(), &github.Repository{Archived: req.Archived})
 	return validateRepositoryAPIResp(apiObj, err)
 }
 
+func (c *githubClientImpl) CreateRepoFromTemplate(ctx context.Context, templateOwner, templateRepo, orgName string, req *github.Repository) (*github.Repository, error) {
+	tmplReq := &github.TemplateRepoRequest{
+		Name:        req.Name,
+		Description: req.Description,
+		Private:     github.Bool(req.GetVisibility() != "public"),
+	}
+	if orgName != "" {
+		tmplReq.Owner = &orgName
+	}
+	// POST /repos/{template_owner}/{template_repo}/generate
+	apiObj, _, err := c.c.Repositories.CreateFromTemplate(ctx, templateOwner, templateRepo, tmplReq)
+	apiObj, err = validateRepositoryAPIResp(apiObj, err)
+	if err != nil {
+		return nil, err
+	}
+	owner := apiObj.GetOwner().GetLogin()
+	// Only the private or public visibility can be set when generating, and no merge settings
+	if req.GetVisibility() == "internal" || req.AllowMergeCommit != nil || req.DeleteBranchOnMerge != nil {
+		// PATCH /repos/{owner}/{repo}
+		apiObj, _, err = c.c.Repositories.Edit(ctx, owner, apiObj.GetName(), &github.Repository{
+			Visibility:          req.Visibility,
+			AllowMergeCommit:    req.AllowMergeCommit,
+			AllowSquashMerge:    req.AllowSquashMerge,
+			AllowRebaseMerge:    req.AllowRebaseMerge,
+			DeleteBranchOnMerge: req.DeleteBranchOnMerge,
+		})
+		apiObj, err = validateRepositoryAPIResp(apiObj, err)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.replaceTopics(ctx, owner, apiObj, req.Topics)
+}
+
+func (c *githubClientImpl) RenameBranch(ctx context.Context, owner, repo, branch, newName string) error {
+	// POST /repos/{owner}/{repo}/branches/{branch}/rename
+	_, _, err := c.c.Repositories.RenameBranch(ctx, owner, repo, gitprovider.EscapePath(branch), newName)
+	return handleHTTPError(err)
+}
+
+// replaceTopics sets the topics of apiObj, owned by owner, if topics is not nil and differs from
+// the actual topics.
+func (c *githubClientImpl) replaceTopics(ctx context.Context, owner string, apiObj *github.Repository, topics []string) (*github.Repository, error) {
+	if topics == nil || gitprovider.EqualTopics(topics, apiObj.Topics) {
+		return apiObj, nil
+	}
+	// PUT /repos/{owner}/{repo}/topics
+	names, _, err := c.c.Repositories.ReplaceAllTopics(ctx, owner, apiObj.GetName(), topics)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	apiObj.Topics = names
+	return apiObj, nil
+}
+
 func (c *githubClientImpl) DeleteRepo(ctx context.Context, owner, repo string) error {
 	// Don't allow deleting repositories if the user didn't explicitly allow dangerous API calls.
 	if !c.destructiveActions {
@@ -276,6 +438,37 @@ func (c *githubClientImpl) DeleteRepo(ctx context.Context, owner, repo string) e
 	return handleHTTPError(err)
 }
 
+func (c *githubClientImpl) DownloadArchive(ctx context.Context, owner, repo, ref string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	archiveFormat, err := archiveFormatToAPI(format)
+	if err != nil {
+		return nil, err
+	}
+	// GET /repos/{owner}/{repo}/{archive_format}/{ref}
+	link, resp, err := c.c.Repositories.GetArchiveLink(ctx, owner, repo, archiveFormat, &github.RepositoryContentGetOptions{Ref: gitprovider.EscapePath(ref)}, true)
+	if err != nil {
+		// GetArchiveLink doesn't return a *github.ErrorResponse for unexpected status codes
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, gitprovider.ErrNotFound
+		}
+		return nil, handleHTTPError(err)
+	}
+	return gitprovider.NewResumableReader(ctx, func(ctx context.Context, header http.Header) (*http.Response, error) {
+		req, err := c.c.NewRequest(http.MethodGet, link.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		// The body is left unread by BareDo, and is streamed to the caller
+		resp, err := c.c.BareDo(ctx, req)
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		return resp.Response, nil
+	}, gitprovider.DefaultMaxDownloadResumes)
+}
+
 func (c *githubClientImpl) ListKeys(ctx context.Context, owner, repo string) ([]*github.Key, error) {
 	apiObjs := []*github.Key{}
 	opts := &github.ListOptions{}
@@ -297,7 +490,7 @@ func (c *githubClientImpl) ListKeys(ctx context.Context, owner, repo string) ([]
 	return apiObjs, nil
 }
 
-func (c *githubClientImpl) ListCommitsPage(ctx context.Context, owner, repo, branch string, perPage int, page int) ([]*github.Commit, error) {
+func (c *githubClientImpl) ListCommitsPage(ctx context.Context, owner, repo, branch string, perPage int, page int, opts gitprovider.CommitListOptions) ([]*github.Commit, error) {
 	apiObjs := make([]*github.Commit, 0)
 	lcOpts := &github.CommitsListOptions{
 		ListOptions: github.ListOptions{
@@ -306,24 +499,55 @@ func (c *githubClientImpl) ListCommitsPage(ctx context.Context, owner, repo, bra
 		},
 		SHA: branch,
 	}
+	if opts.Path != nil {
+		lcOpts.Path = *opts.Path
+	}
+	if opts.Author != nil {
+		lcOpts.Author = *opts.Author
+	}
+	if opts.Since != nil {
+		lcOpts.Since = *opts.Since
+	}
+	if opts.Until != nil {
+		lcOpts.Until = *opts.Until
+	}
 
 	// GET /repos/{owner}/{repo}/commits
 	pageObjs, _, listErr := c.c.Repositories.ListCommits(ctx, owner, repo, lcOpts)
-	for _, c := range pageObjs {
+	if listErr != nil {
+		return nil, handleHTTPError(listErr)
+	}
+	// The listed commits lack the stats, so each commit is fetched, up to c.concurrency at once
+	stats := make([]*github.CommitStats, len(pageObjs))
+	if opts.WithStats != nil && *opts.WithStats {
+		err := gitprovider.RunConcurrently(c.concurrency, len(pageObjs), func(i int) error {
+			// GET /repos/{owner}/{repo}/commits/{ref}
+			apiObj, _, err := c.c.Repositories.GetCommit(ctx, owner, repo, pageObjs[i].GetSHA(), nil)
+			if err != nil {
+				return handleHTTPError(err)
+			}
+			stats[i] = apiObj.Stats
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, pageObj := range pageObjs {
 		apiObjs = append(apiObjs, &github.Commit{
-			SHA: c.SHA,
+			SHA: pageObj.SHA,
 			Tree: &github.Tree{
-				SHA: c.Commit.Tree.SHA,
+				SHA: pageObj.Commit.Tree.SHA,
 			},
-			Author:  c.Commit.Author,
-			Message: c.Commit.Message,
-			URL:     c.HTMLURL,
+			Author:       pageObj.Commit.Author,
+			Committer:    pageObj.Commit.Committer,
+			Message:      pageObj.Commit.Message,
+			Parents:      pageObj.Parents,
+			HTMLURL:      pageObj.HTMLURL,
+			Verification: pageObj.Commit.Verification,
+			Stats:        stats[i],
 		})
 	}
-
-	if listErr != nil {
-		return nil, listErr
-	}
 	return apiObjs, nil
 }
 
@@ -23,7 +23,6 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
-	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -35,6 +34,7 @@ import (
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/fluxcd/go-git-providers/gitprovider/testutils"
+	"github.com/fluxcd/go-git-providers/gittestutils"
 )
 
 const (
@@ -166,37 +166,13 @@ var _ = Describe("GitHub Provider", func() {
 	})
 
 	cleanupOrgRepos := func(prefix string) {
-		fmt.Fprintf(os.Stderr, "Deleting repos starting with %s in org: %s\n", prefix, testOrgName)
-		repos, err := c.OrgRepositories().List(ctx, newOrgRef(testOrgName))
-		Expect(err).ToNot(HaveOccurred())
-		for _, repo := range repos {
-			// Delete the test org repo used
-			name := repo.Repository().GetRepository()
-			if !strings.HasPrefix(name, prefix) {
-				continue
-			}
-			fmt.Fprintf(os.Stderr, "Deleting the org repo: %s\n", name)
-			repo.Delete(ctx)
-			Expect(err).ToNot(HaveOccurred())
-		}
+		fixture := gittestutils.NewFixture(c, newOrgRef(testOrgName), newUserRef(testUser), "")
+		Expect(fixture.CleanupOrgRepositories(ctx, prefix)).To(Succeed())
 	}
 
 	cleanupUserRepos := func(prefix string) {
-		fmt.Fprintf(os.Stderr, "Deleting repos starting with %s for user: %s\n", prefix, testUser)
-		repos, err := c.UserRepositories().List(ctx, newUserRef(testUser))
-		Expect(err).ToNot(HaveOccurred())
-		fmt.Fprintf(os.Stderr, "repos, len: %d\n", len(repos))
-		for _, repo := range repos {
-			fmt.Fprintf(os.Stderr, "repo: %s\n", repo.Repository().GetRepository())
-			name := repo.Repository().GetRepository()
-			if !strings.HasPrefix(name, prefix) {
-				fmt.Fprintf(os.Stderr, "Skipping the org repo: %s\n", name)
-				continue
-			}
-			fmt.Fprintf(os.Stderr, "Deleting the org repo: %s\n", name)
-			repo.Delete(ctx)
-			Expect(err).ToNot(HaveOccurred())
-		}
+		fixture := gittestutils.NewFixture(c, newOrgRef(testOrgName), newUserRef(testUser), "")
+		Expect(fixture.CleanupUserRepositories(ctx, prefix)).To(Succeed())
 	}
 
 	It("should list the available organizations the user has access to", func() {
@@ -261,7 +237,7 @@ var _ = Describe("GitHub Provider", func() {
 
 		// Generate a repository name which doesn't exist already
 		testOrgRepoName = fmt.Sprintf("test-repo-%03d", rand.Intn(1000))
-		for findOrgRepo(repos, testOrgRepoName) != nil {
+		for gittestutils.FindOrgRepository(repos, testOrgRepoName) != nil {
 			testOrgRepoName = fmt.Sprintf("test-repo-%03d", rand.Intn(1000))
 		}
 
@@ -302,7 +278,7 @@ var _ = Describe("GitHub Provider", func() {
 
 		// Generate a repository name which doesn't exist already
 		testUserRepoName = fmt.Sprintf("test-repo-%03d", rand.Intn(1000))
-		for findUserRepo(repos, testUserRepoName) != nil {
+		for gittestutils.FindUserRepository(repos, testUserRepoName) != nil {
 			testUserRepoName = fmt.Sprintf("test-repo-%03d", rand.Intn(1000))
 		}
 
@@ -620,30 +596,6 @@ func newUserRepoRef(userLogin, repoName string) gitprovider.UserRepositoryRef {
 	}
 }
 
-func findUserRepo(repos []gitprovider.UserRepository, name string) gitprovider.UserRepository {
-	if name == "" {
-		return nil
-	}
-	for _, repo := range repos {
-		if repo.Repository().GetRepository() == name {
-			return repo
-		}
-	}
-	return nil
-}
-
-func findOrgRepo(repos []gitprovider.OrgRepository, name string) gitprovider.OrgRepository {
-	if name == "" {
-		return nil
-	}
-	for _, repo := range repos {
-		if repo.Repository().GetRepository() == name {
-			return repo
-		}
-	}
-	return nil
-}
-
 func validateRepo(repo gitprovider.OrgRepository, expectedRepoRef gitprovider.RepositoryRef) {
 	info := repo.Get()
 	// Expect certain fields to be set
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// getRepositoryStats returns the statistics of the repository. The size is part of apiObj, and
+// the languages, commits and contributors take a request each. The counts are the number of
+// pages when listing one item per page, as GitHub doesn't report them directly.
+func getRepositoryStats(ctx context.Context, c *clientContext, apiObj *github.Repository, ref gitprovider.RepositoryRef) (*gitprovider.RepositoryStats, error) {
+	owner, repo := ref.GetIdentity(), ref.GetRepository()
+	stats := &gitprovider.RepositoryStats{SizeKB: int64(apiObj.GetSize())}
+
+	// GET /repos/{owner}/{repo}/languages
+	languages, _, err := c.c.Client().Repositories.ListLanguages(ctx, owner, repo)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	shares := make(map[string]float64, len(languages))
+	for name, bytes := range languages {
+		shares[name] = float64(bytes)
+	}
+	stats.Languages = gitprovider.NewRepositoryLanguages(shares)
+
+	// GET /repos/{owner}/{repo}/commits
+	commits, resp, err := c.c.Client().Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err = handleHTTPError(err); err != nil && !errors.Is(err, gitprovider.ErrEmptyRepository) {
+		return nil, err
+	}
+	stats.CommitCount = countFromPages(len(commits), resp)
+
+	// GET /repos/{owner}/{repo}/contributors
+	contributors, resp, err := c.c.Client().Repositories.ListContributors(ctx, owner, repo, &github.ListContributorsOptions{
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	stats.ContributorCount = countFromPages(len(contributors), resp)
+	return stats, nil
+}
+
+// countFromPages returns the number of items of a list requested with one item per page.
+func countFromPages(items int, resp *github.Response) int {
+	if resp != nil && resp.LastPage != 0 {
+		return resp.LastPage
+	}
+	return items
+}
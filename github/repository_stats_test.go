@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestOrgRepositoriesClient_GetWithStats(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/acme/app", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "app", "size": 2048, "owner": {"login": "acme"}}`))
+	})
+	mux.HandleFunc("/api/v3/repos/acme/app/languages", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Go": 7500, "Shell": 2500}`))
+	})
+	mux.HandleFunc("/api/v3/repos/acme/app/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `<`+r.URL.Path+`?page=2&per_page=1>; rel="next", <`+r.URL.Path+`?page=42&per_page=1>; rel="last"`)
+		w.Write([]byte(`[{"sha": "abc"}]`))
+	})
+	mux.HandleFunc("/api/v3/repos/acme/app/contributors", func(w http.ResponseWriter, r *http.Request) {
+		// A single page has no Link header
+		w.Write([]byte(`[{"login": "octocat"}]`))
+	})
+	c := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "acme"}, RepositoryName: "app"}
+
+	repo, err := c.OrgRepositories().Get(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Get().Stats != nil {
+		t.Errorf("Get() without WithStats returned stats %+v", repo.Get().Stats)
+	}
+
+	repo, err = c.OrgRepositories().Get(context.Background(), ref, &gitprovider.RepositoryGetOptions{WithStats: gitprovider.BoolVar(true)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &gitprovider.RepositoryStats{
+		SizeKB:           2048,
+		Languages:        []gitprovider.RepositoryLanguage{{Name: "Go", Percentage: 75}, {Name: "Shell", Percentage: 25}},
+		CommitCount:      42,
+		ContributorCount: 1,
+	}
+	if got := repo.Get().Stats; !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+}
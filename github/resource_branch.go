@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func newBranch(ctx *clientContext, apiObj *github.Branch, defaultBranch string) *branch {
+	return &branch{
+		clientContext: ctx,
+		b:             *apiObj,
+		isDefault:     apiObj.GetName() == defaultBranch,
+	}
+}
+
+var _ gitprovider.Branch = &branch{}
+
+type branch struct {
+	*clientContext
+
+	b         github.Branch
+	isDefault bool
+}
+
+func (b *branch) Get() gitprovider.BranchInfo {
+	return gitprovider.BranchInfo{
+		Name:      b.b.GetName(),
+		Sha:       b.b.GetCommit().GetSHA(),
+		Protected: b.b.GetProtected(),
+		Default:   b.isDefault,
+	}
+}
+
+func (b *branch) APIObject() interface{} {
+	return &b.b
+}
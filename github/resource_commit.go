@@ -45,12 +45,37 @@ func (c *commitType) APIObject() interface{} {
 }
 
 func commitFromAPI(apiObj *github.Commit) gitprovider.CommitInfo {
-	return gitprovider.CommitInfo{
-		Sha:       *apiObj.SHA,
-		TreeSha:   *apiObj.Tree.SHA,
-		Author:    *apiObj.Author.Name,
-		Message:   *apiObj.Message,
-		CreatedAt: *apiObj.Author.Date,
-		URL:       *apiObj.URL,
+	info := gitprovider.CommitInfo{
+		Sha:            *apiObj.SHA,
+		TreeSha:        *apiObj.Tree.SHA,
+		Author:         *apiObj.Author.Name,
+		AuthorEmail:    apiObj.Author.GetEmail(),
+		Committer:      apiObj.GetCommitter().GetName(),
+		CommitterEmail: apiObj.GetCommitter().GetEmail(),
+		Message:        *apiObj.Message,
+		CreatedAt:      *apiObj.Author.Date,
+		CommittedAt:    apiObj.GetCommitter().GetDate(),
+		URL:            apiObj.GetHTMLURL(),
 	}
+	// Commits created with the Git Data API only have their API URL
+	if info.URL == "" {
+		info.URL = apiObj.GetURL()
+	}
+	for _, parent := range apiObj.Parents {
+		info.Parents = append(info.Parents, parent.GetSHA())
+	}
+	if apiObj.Verification != nil {
+		info.Verification = &gitprovider.CommitVerification{
+			Verified: apiObj.Verification.GetVerified(),
+			Reason:   apiObj.Verification.GetReason(),
+		}
+	}
+	if apiObj.Stats != nil {
+		info.Stats = &gitprovider.CommitStats{
+			Additions: apiObj.Stats.GetAdditions(),
+			Deletions: apiObj.Stats.GetDeletions(),
+			Total:     apiObj.Stats.GetTotal(),
+		}
+	}
+	return info
 }
@@ -20,7 +20,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"reflect"
 
 	"github.com/google/go-github/v41/github"
 
@@ -30,8 +29,9 @@ import (
 
 func newDeployKey(c *DeployKeyClient, key *github.Key) *deployKey {
 	return &deployKey{
-		k: *key,
-		c: c,
+		k:    *key,
+		read: deployKeyFromAPI(key),
+		c:    c,
 	}
 }
 
@@ -39,7 +39,10 @@ var _ gitprovider.DeployKey = &deployKey{}
 
 type deployKey struct {
 	k github.Key
-	c *DeployKeyClient
+	// read is the state of the deploy key when it was last read from the server, checked by
+	// Update with optimistic concurrency
+	read gitprovider.DeployKeyInfo
+	c    *DeployKeyClient
 }
 
 func (dk *deployKey) Get() gitprovider.DeployKeyInfo {
@@ -62,6 +65,14 @@ func (dk *deployKey) Repository() gitprovider.RepositoryRef {
 	return dk.c.ref
 }
 
+func (dk *deployKey) Timestamps() gitprovider.Timestamps {
+	key := fmt.Sprintf("%s/keys/%d", dk.c.ref.String(), dk.k.GetID())
+	return gitprovider.Timestamps{
+		CreatedAt:  timestampTime(dk.k.CreatedAt),
+		Generation: dk.c.generations.Observe(key, gitprovider.ObjectVersion(nil, &dk.k)),
+	}
+}
+
 // Update will apply the desired state in this object to the server.
 // Only set fields will be respected (i.e. PATCH behaviour).
 // In order to apply changes to this object, use the .Set({Resource}Info) error
@@ -71,7 +82,27 @@ func (dk *deployKey) Repository() gitprovider.RepositoryRef {
 // ErrNotFound is returned if the resource does not exist.
 //
 // The internal API object will be overridden with the received server data.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the deploy key was
+// changed since it was read.
 func (dk *deployKey) Update(ctx context.Context) error {
+	if dk.c.optimisticConcurrency {
+		// GET /repos/{owner}/{repo}/keys
+		actual, err := dk.c.get(ctx, dk.read.Name)
+		if err != nil {
+			return err
+		}
+		object := fmt.Sprintf("%s/keys/%s", dk.c.ref.String(), dk.read.Name)
+		if err := gitprovider.CheckUnchanged(object, dk.read, actual.Get()); err != nil {
+			return err
+		}
+	}
+	return dk.update(ctx)
+}
+
+// update applies the desired state in this object to the server, without checking for
+// concurrent changes.
+func (dk *deployKey) update(ctx context.Context) error {
 	// Delete the old key and recreate
 	if err := dk.Delete(ctx); err != nil {
 		return err
@@ -101,27 +132,48 @@ func (dk *deployKey) Delete(ctx context.Context) error {
 //
 // The internal API object will be overridden with the received server data if actionTaken == true.
 func (dk *deployKey) Reconcile(ctx context.Context) (bool, error) {
-	actual, err := dk.c.get(ctx, *dk.k.Key)
+	actual, err := dk.c.get(ctx, *dk.k.Title)
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
-			return true, dk.createIntoSelf(ctx)
+			change := gitprovider.NewCreateChange(dk.c.ref, dk.Get())
+			if dk.c.dryRun {
+				return true, gitprovider.NewDryRunError(change)
+			}
+			if err := dk.createIntoSelf(ctx); err != nil {
+				return true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateDeployKey)
+			return true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
 		return false, err
 	}
 
-	// Use wrappers here to extract the "spec" part of the object for comparison
-	desiredSpec := newGithubKeySpec(&dk.k)
-	actualSpec := newGithubKeySpec(&actual.k)
+	// Default the desired state, so that an unset read-only flag is compared as it's created
+	req := dk.Get()
+	req.Default()
 
-	// If the desired matches the actual state, do nothing
-	if desiredSpec.Equals(actualSpec) {
+	// If the desired matches the actual state, do nothing. This compares the name, the read-only
+	// flag and the key material; a difference in any of them rotates the key.
+	if req.Equals(actual.Get()) {
 		return false, nil
 	}
-	// If desired and actual state mis-match, update
-	return true, dk.Update(ctx)
+	change := gitprovider.NewUpdateChange(dk.c.ref, actual.Get(), dk.Get())
+	// In dry-run mode, only report what would be updated
+	if dk.c.dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
+	// If desired and actual state mis-match, replace the actual key
+	dk.k.ID = actual.k.ID
+	// Reconcile overwrites the actual state by design, so there's no need to check for
+	// concurrent changes
+	if err := dk.update(ctx); err != nil {
+		return true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallDeleteDeployKey, apiCallCreateDeployKey)
+	return true, nil
 }
 
 func (dk *deployKey) createIntoSelf(ctx context.Context) error {
@@ -131,6 +183,7 @@ func (dk *deployKey) createIntoSelf(ctx context.Context) error {
 		return err
 	}
 	dk.k = *apiObj
+	dk.read = deployKeyFromAPI(apiObj)
 	return nil
 }
 
@@ -177,25 +230,3 @@ func deployKeyInfoToAPIObj(info *gitprovider.DeployKeyInfo, apiObj *github.Key)
 		apiObj.ReadOnly = info.ReadOnly
 	}
 }
-
-// This function copies over the fields that are part of create request of a deploy
-// i.e. the desired spec of the deploy key. This allows us to separate "spec" from "status" fields.
-func newGithubKeySpec(key *github.Key) *githubKeySpec {
-	return &githubKeySpec{
-		&github.Key{
-			// Create-specific parameters
-			// See: https://docs.github.com/en/rest/reference/repos#create-a-deploy-key
-			Title:    key.Title,
-			Key:      key.Key,
-			ReadOnly: key.ReadOnly,
-		},
-	}
-}
-
-type githubKeySpec struct {
-	*github.Key
-}
-
-func (s *githubKeySpec) Equals(other *githubKeySpec) bool {
-	return reflect.DeepEqual(s, other)
-}
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func newMilestone(ctx *clientContext, apiObj *github.Milestone) *milestone {
+	return &milestone{
+		clientContext: ctx,
+		m:             *apiObj,
+	}
+}
+
+var _ gitprovider.Milestone = &milestone{}
+
+type milestone struct {
+	*clientContext
+
+	m github.Milestone
+}
+
+func (m *milestone) Get() gitprovider.MilestoneInfo {
+	return milestoneFromAPI(&m.m)
+}
+
+func (m *milestone) APIObject() interface{} {
+	return &m.m
+}
+
+func milestoneFromAPI(apiObj *github.Milestone) gitprovider.MilestoneInfo {
+	return gitprovider.MilestoneInfo{
+		Title:       apiObj.GetTitle(),
+		Description: apiObj.Description,
+		DueDate:     apiObj.DueOn,
+		Number:      apiObj.GetNumber(),
+		State:       gitprovider.MilestoneState(apiObj.GetState()),
+		WebURL:      apiObj.GetHTMLURL(),
+	}
+}
+
+func milestoneToAPI(info *gitprovider.MilestoneInfo) *github.Milestone {
+	return &github.Milestone{
+		Title:       &info.Title,
+		Description: info.Description,
+		DueOn:       info.DueDate,
+	}
+}
@@ -44,10 +44,44 @@ func (pr *pullrequest) APIObject() interface{} {
 	return &pr.pr
 }
 
+func (pr *pullrequest) Timestamps() gitprovider.Timestamps {
+	return gitprovider.Timestamps{
+		CreatedAt:  pr.pr.CreatedAt,
+		UpdatedAt:  pr.pr.UpdatedAt,
+		Generation: pr.generations.Observe(pr.pr.GetHTMLURL(), gitprovider.ObjectVersion(pr.pr.UpdatedAt, &pr.pr)),
+	}
+}
+
 func pullrequestFromAPI(apiObj *github.PullRequest) gitprovider.PullRequestInfo {
 	return gitprovider.PullRequestInfo{
 		Merged: apiObj.GetMerged(),
+		Closed: apiObj.GetState() == "closed" && !apiObj.GetMerged(),
 		Number: apiObj.GetNumber(),
 		WebURL: apiObj.GetHTMLURL(),
 	}
 }
+
+func newPullRequestComment(apiObj *github.IssueComment) *pullRequestComment {
+	return &pullRequestComment{
+		c: *apiObj,
+	}
+}
+
+var _ gitprovider.PullRequestComment = &pullRequestComment{}
+
+type pullRequestComment struct {
+	c github.IssueComment
+}
+
+func (c *pullRequestComment) Get() gitprovider.PullRequestCommentInfo {
+	return gitprovider.PullRequestCommentInfo{
+		ID:        c.c.GetID(),
+		Body:      c.c.GetBody(),
+		Author:    c.c.GetUser().GetLogin(),
+		CreatedAt: c.c.GetCreatedAt(),
+	}
+}
+
+func (c *pullRequestComment) APIObject() interface{} {
+	return &c.c
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"time"
 
 	"github.com/google/go-github/v41/github"
 
@@ -31,6 +32,7 @@ func newUserRepository(ctx *clientContext, apiObj *github.Repository, ref gitpro
 	return &userRepository{
 		clientContext: ctx,
 		r:             *apiObj,
+		read:          repositoryFromAPI(apiObj),
 		ref:           ref,
 		deployKeys: &DeployKeyClient{
 			clientContext: ctx,
@@ -52,6 +54,38 @@ func newUserRepository(ctx *clientContext, apiObj *github.Repository, ref gitpro
 			clientContext: ctx,
 			ref:           ref,
 		},
+		milestones: &MilestoneClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		mergeQueue: &MergeQueueClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		collaborators: &CollaboratorClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		reactions: &ReactionClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		environments: &EnvironmentClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		badges: &BadgeClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		tagProtection: &TagProtectionClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		approvalRules: &ApprovalRuleClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
 	}
 }
 
@@ -62,16 +96,32 @@ type userRepository struct {
 
 	r   github.Repository // go-github
 	ref gitprovider.RepositoryRef
-
-	deployKeys   *DeployKeyClient
-	commits      *CommitClient
-	branches     *BranchClient
-	pullRequests *PullRequestClient
-	files        *FileClient
+	// read is the state of the repository when it was last read from the server, checked by
+	// Update with optimistic concurrency
+	read gitprovider.RepositoryInfo
+	// stats is set if requested when getting the repository
+	stats *gitprovider.RepositoryStats
+
+	deployKeys    *DeployKeyClient
+	commits       *CommitClient
+	branches      *BranchClient
+	pullRequests  *PullRequestClient
+	files         *FileClient
+	milestones    *MilestoneClient
+	mergeQueue    *MergeQueueClient
+	collaborators *CollaboratorClient
+	reactions     *ReactionClient
+	environments  *EnvironmentClient
+	badges        *BadgeClient
+	tagProtection *TagProtectionClient
+	approvalRules *ApprovalRuleClient
 }
 
 func (r *userRepository) Get() gitprovider.RepositoryInfo {
-	return repositoryFromAPI(&r.r)
+	info := repositoryFromAPI(&r.r)
+	info.Fork, info.Mirror = relationsFromAPI(r.domain, &r.r)
+	info.Stats = r.stats
+	return info
 }
 
 func (r *userRepository) Set(info gitprovider.RepositoryInfo) error {
@@ -90,6 +140,15 @@ func (r *userRepository) Repository() gitprovider.RepositoryRef {
 	return r.ref
 }
 
+func (r *userRepository) Timestamps() gitprovider.Timestamps {
+	updatedAt := timestampTime(r.r.UpdatedAt)
+	return gitprovider.Timestamps{
+		CreatedAt:  timestampTime(r.r.CreatedAt),
+		UpdatedAt:  updatedAt,
+		Generation: r.generations.Observe(r.ref.String(), gitprovider.ObjectVersion(updatedAt, &r.r)),
+	}
+}
+
 func (r *userRepository) DeployKeys() gitprovider.DeployKeyClient {
 	return r.deployKeys
 }
@@ -110,6 +169,38 @@ func (r *userRepository) Files() gitprovider.FileClient {
 	return r.files
 }
 
+func (r *userRepository) Milestones() gitprovider.MilestoneClient {
+	return r.milestones
+}
+
+func (r *userRepository) MergeQueue() gitprovider.MergeQueueClient {
+	return r.mergeQueue
+}
+
+func (r *userRepository) Collaborators() gitprovider.CollaboratorClient {
+	return r.collaborators
+}
+
+func (r *userRepository) Reactions() gitprovider.ReactionClient {
+	return r.reactions
+}
+
+func (r *userRepository) Environments() gitprovider.EnvironmentClient {
+	return r.environments
+}
+
+func (r *userRepository) Badges() gitprovider.BadgeClient {
+	return r.badges
+}
+
+func (r *userRepository) TagProtection() gitprovider.TagProtectionClient {
+	return r.tagProtection
+}
+
+func (r *userRepository) ApprovalRules() gitprovider.ApprovalRuleClient {
+	return r.approvalRules
+}
+
 // Update will apply the desired state in this object to the server.
 // Only set fields will be respected (i.e. PATCH behaviour).
 // In order to apply changes to this object, use the .Set({Resource}Info) error
@@ -119,13 +210,33 @@ func (r *userRepository) Files() gitprovider.FileClient {
 // ErrNotFound is returned if the resource does not exist.
 //
 // The internal API object will be overridden with the received server data.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the repository was
+// changed since it was read.
 func (r *userRepository) Update(ctx context.Context) error {
+	if r.optimisticConcurrency {
+		// GET /repos/{owner}/{repo}
+		actual, err := r.c.GetRepo(ctx, r.ref.GetIdentity(), r.ref.GetRepository())
+		if err != nil {
+			return err
+		}
+		if err := gitprovider.CheckUnchanged(r.ref.String(), r.read, repositoryFromAPI(actual)); err != nil {
+			return err
+		}
+	}
+	return r.update(ctx)
+}
+
+// update applies the desired state in this object to the server, without checking for
+// concurrent changes.
+func (r *userRepository) update(ctx context.Context) error {
 	// PATCH /repos/{owner}/{repo}
 	apiObj, err := r.c.UpdateRepo(ctx, r.ref.GetIdentity(), r.ref.GetRepository(), &r.r)
 	if err != nil {
 		return err
 	}
 	r.r = *apiObj
+	r.read = repositoryFromAPI(apiObj)
 	return nil
 }
 
@@ -142,6 +253,10 @@ func (r *userRepository) Reconcile(ctx context.Context) (bool, error) {
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(r.ref, r.Get())
+			if r.dryRun {
+				return true, gitprovider.NewDryRunError(change)
+			}
 			orgName := ""
 			if orgRef, ok := r.ref.(gitprovider.OrgRepositoryRef); ok {
 				orgName = orgRef.Organization
@@ -151,6 +266,12 @@ func (r *userRepository) Reconcile(ctx context.Context) (bool, error) {
 				return true, err
 			}
 			r.r = *repo
+			r.read = repositoryFromAPI(repo)
+			if orgName == "" {
+				gitprovider.RecordChange(ctx, change, apiCallCreateUserRepository)
+			} else {
+				gitprovider.RecordChange(ctx, change, apiCallCreateOrgRepository)
+			}
 			return true, nil
 		}
 
@@ -161,12 +282,23 @@ func (r *userRepository) Reconcile(ctx context.Context) (bool, error) {
 	desiredSpec := newGithubRepositorySpec(&r.r)
 	actualSpec := newGithubRepositorySpec(apiObj)
 
-	// If desired state already is the actual state, do nothing
-	if desiredSpec.Equals(actualSpec) {
+	// If desired state already is the actual state, do nothing. The topics aren't part of the
+	// spec, they're only managed if set
+	if desiredSpec.Equals(actualSpec) && (r.r.Topics == nil || gitprovider.EqualTopics(r.r.Topics, apiObj.Topics)) {
 		return false, nil
 	}
-	// Otherwise, make the desired state the actual state
-	return true, r.Update(ctx)
+	change := gitprovider.NewUpdateChange(r.ref, repositoryFromAPI(apiObj), r.Get())
+	// In dry-run mode, only report what would be updated
+	if r.dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
+	// Otherwise, make the desired state the actual state. Reconcile overwrites the actual state
+	// by design, so there's no need to check for concurrent changes
+	if err := r.update(ctx); err != nil {
+		return true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateRepository)
+	return true, nil
 }
 
 // Delete deletes the current resource irreversibly.
@@ -214,17 +346,90 @@ func validateRepositoryAPI(apiObj *github.Repository) error {
 	})
 }
 
+// lastActivityFromAPI returns the time of the last push to the repository. It's the only activity
+// GitHub can sort the listed repositories by.
+func lastActivityFromAPI(apiObj *github.Repository) time.Time {
+	return apiObj.GetPushedAt().Time
+}
+
+// untilInactive returns the repositories of a page sorted by their last push, up to the first one
+// without activity since the given time, and the next page, or 0 if the listing can stop there.
+// The page is returned as is if since is nil.
+func untilInactive(apiObjs []*github.Repository, next int, since *time.Time) ([]*github.Repository, int) {
+	if since == nil {
+		return apiObjs, next
+	}
+	for i, apiObj := range apiObjs {
+		if lastActivityFromAPI(apiObj).Before(*since) {
+			return apiObjs[:i], 0
+		}
+	}
+	return apiObjs, next
+}
+
+// listUntilInactive fetches the pages of repositories sorted by their last push, until the first
+// one without activity since the given time.
+func listUntilInactive(since time.Time, listPage func(page int) ([]*github.Repository, int, error)) ([]*github.Repository, error) {
+	var apiObjs []*github.Repository
+	for page := 1; page != 0; {
+		pageObjs, next, err := listPage(page)
+		if err != nil {
+			return nil, err
+		}
+		pageObjs, page = untilInactive(pageObjs, next, &since)
+		apiObjs = append(apiObjs, pageObjs...)
+	}
+	return apiObjs, nil
+}
+
 func repositoryFromAPI(apiObj *github.Repository) gitprovider.RepositoryInfo {
 	repo := gitprovider.RepositoryInfo{
 		Description:   apiObj.Description,
 		DefaultBranch: apiObj.DefaultBranch,
+		Topics:        apiObj.Topics,
+		Archived:      apiObj.Archived,
+		// Only returned to users with push access
+		DeleteBranchOnMerge: apiObj.DeleteBranchOnMerge,
 	}
 	if apiObj.Visibility != nil {
 		repo.Visibility = gitprovider.RepositoryVisibilityVar(gitprovider.RepositoryVisibility(*apiObj.Visibility))
 	}
+	if apiObj.AllowMergeCommit != nil && apiObj.AllowSquashMerge != nil && apiObj.AllowRebaseMerge != nil {
+		repo.MergeMethods = []gitprovider.MergeMethod{}
+		if *apiObj.AllowMergeCommit {
+			repo.MergeMethods = append(repo.MergeMethods, gitprovider.MergeMethodMerge)
+		}
+		if *apiObj.AllowSquashMerge {
+			repo.MergeMethods = append(repo.MergeMethods, gitprovider.MergeMethodSquash)
+		}
+		if *apiObj.AllowRebaseMerge {
+			repo.MergeMethods = append(repo.MergeMethods, gitprovider.MergeMethodRebase)
+		}
+	}
 	return repo
 }
 
+// relationsFromAPI returns the fork and mirror relationships of the repository, which need the
+// domain of the client for the repository references.
+func relationsFromAPI(domain string, apiObj *github.Repository) (*gitprovider.RepositoryFork, *gitprovider.RepositoryMirror) {
+	var fork *gitprovider.RepositoryFork
+	if apiObj.GetFork() {
+		fork = &gitprovider.RepositoryFork{}
+		// The parent and source are only returned when getting a single repository
+		if apiObj.Parent != nil {
+			fork.Parent = repositoryRefFromAPI(domain, apiObj.Parent)
+		}
+		if apiObj.Source != nil {
+			fork.Source = repositoryRefFromAPI(domain, apiObj.Source)
+		}
+	}
+	var mirror *gitprovider.RepositoryMirror
+	if apiObj.GetMirrorURL() != "" {
+		mirror = gitprovider.NewRepositoryMirror(apiObj.GetMirrorURL())
+	}
+	return fork, mirror
+}
+
 func repositoryToAPI(repo *gitprovider.RepositoryInfo, ref gitprovider.RepositoryRef) github.Repository {
 	apiObj := github.Repository{
 		Name: gitprovider.StringVar(ref.GetRepository()),
@@ -243,6 +448,24 @@ func repositoryInfoToAPIObj(repo *gitprovider.RepositoryInfo, apiObj *github.Rep
 	if repo.Visibility != nil {
 		apiObj.Visibility = gitprovider.StringVar(string(*repo.Visibility))
 	}
+	if repo.Topics != nil {
+		apiObj.Topics = repo.Topics
+	}
+	if repo.MergeMethods != nil {
+		allowed := map[gitprovider.MergeMethod]bool{}
+		for _, m := range repo.MergeMethods {
+			allowed[m] = true
+		}
+		apiObj.AllowMergeCommit = gitprovider.BoolVar(allowed[gitprovider.MergeMethodMerge])
+		apiObj.AllowSquashMerge = gitprovider.BoolVar(allowed[gitprovider.MergeMethodSquash])
+		apiObj.AllowRebaseMerge = gitprovider.BoolVar(allowed[gitprovider.MergeMethodRebase])
+	}
+	if repo.DeleteBranchOnMerge != nil {
+		apiObj.DeleteBranchOnMerge = repo.DeleteBranchOnMerge
+	}
+	if repo.Archived != nil {
+		apiObj.Archived = repo.Archived
+	}
 }
 
 func applyRepoCreateOptions(apiObj *github.Repository, opts gitprovider.RepositoryCreateOptions) {
@@ -250,6 +473,7 @@ func applyRepoCreateOptions(apiObj *github.Repository, opts gitprovider.Reposito
 	if opts.LicenseTemplate != nil {
 		apiObj.LicenseTemplate = gitprovider.StringVar(string(*opts.LicenseTemplate))
 	}
+	apiObj.GitignoreTemplate = opts.GitignoreTemplate
 }
 
 // This function copies over the fields that are part of create/update requests of a repository
@@ -285,6 +509,7 @@ func newGithubRepositorySpec(repo *github.Repository) *githubRepositorySpec {
 			AllowMergeCommit:    repo.AllowMergeCommit,
 			AllowRebaseMerge:    repo.AllowRebaseMerge,
 			DeleteBranchOnMerge: repo.DeleteBranchOnMerge,
+			Archived:            repo.Archived,
 		},
 	}
 }
@@ -0,0 +1,353 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/google/go-github/v41/github"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// The types of the rules of a ruleset.
+const (
+	RuleTypeCreation                 = "creation"
+	RuleTypeUpdate                   = "update"
+	RuleTypeDeletion                 = "deletion"
+	RuleTypeNonFastForward           = "non_fast_forward"
+	RuleTypeRequiredLinearHistory    = "required_linear_history"
+	RuleTypeRequiredSignatures       = "required_signatures"
+	RuleTypePullRequest              = "pull_request"
+	RuleTypeRequiredStatusChecks     = "required_status_checks"
+	RuleTypeWorkflows                = "workflows"
+	RuleTypeCommitMessagePattern     = "commit_message_pattern"
+	RuleTypeCommitAuthorEmailPattern = "commit_author_email_pattern"
+	RuleTypeCommitterEmailPattern    = "committer_email_pattern"
+	RuleTypeBranchNamePattern        = "branch_name_pattern"
+	RuleTypeTagNamePattern           = "tag_name_pattern"
+	RuleTypeFilePathRestriction      = "file_path_restriction"
+	RuleTypeMaxFilePathLength        = "max_file_path_length"
+	RuleTypeFileExtensionRestriction = "file_extension_restriction"
+	RuleTypeMaxFileSize              = "max_file_size"
+)
+
+// Ruleset is a GitHub repository or organization ruleset. Rulesets target branches, tags, or
+// pushes, and are the successor of branch protection. go-github doesn't have rulesets yet.
+type Ruleset struct {
+	// ID is set by GitHub.
+	ID int64 `json:"id,omitempty"`
+	// Name is the name of the ruleset.
+	Name string `json:"name"`
+	// Target is "branch", "tag" or "push". GitHub defaults it to "branch".
+	Target string `json:"target,omitempty"`
+	// SourceType is "Repository" or "Organization", the kind of owner of the ruleset. It's set by GitHub.
+	SourceType string `json:"source_type,omitempty"`
+	// Source is the name of the owner of the ruleset. It's set by GitHub.
+	Source string `json:"source,omitempty"`
+	// Enforcement is "active", "evaluate" or "disabled".
+	Enforcement string `json:"enforcement"`
+	// BypassActors are the actors which can bypass the rules.
+	BypassActors []*RulesetBypassActor `json:"bypass_actors,omitempty"`
+	// Conditions select the refs, and for organization rulesets the repositories, the rules apply to.
+	Conditions *RulesetConditions `json:"conditions,omitempty"`
+	// Rules are the rules of the ruleset.
+	Rules []*RulesetRule `json:"rules,omitempty"`
+}
+
+// RulesetBypassActor is an actor which can bypass the rules of a ruleset.
+type RulesetBypassActor struct {
+	// ActorID is the ID of the team, integration or role.
+	ActorID int64 `json:"actor_id"`
+	// ActorType is "Team", "Integration", "RepositoryRole" or "OrganizationAdmin".
+	ActorType string `json:"actor_type"`
+	// BypassMode is "always" or "pull_request".
+	BypassMode string `json:"bypass_mode,omitempty"`
+}
+
+// RulesetConditions select the refs and repositories a ruleset applies to.
+type RulesetConditions struct {
+	RefName        *RulesetRefNameCondition        `json:"ref_name,omitempty"`
+	RepositoryName *RulesetRepositoryNameCondition `json:"repository_name,omitempty"`
+}
+
+// RulesetRefNameCondition selects refs by name, with fnmatch patterns, "~DEFAULT_BRANCH" or "~ALL".
+type RulesetRefNameCondition struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+// RulesetRepositoryNameCondition selects the repositories of an organization ruleset by name.
+type RulesetRepositoryNameCondition struct {
+	Include   []string `json:"include"`
+	Exclude   []string `json:"exclude"`
+	Protected bool     `json:"protected,omitempty"`
+}
+
+// RulesetRule is a rule of a ruleset. The parameters depend on the type, e.g. the
+// PullRequestRuleParameters of RuleTypePullRequest rules.
+type RulesetRule struct {
+	// Type is one of the RuleType constants.
+	Type string `json:"type"`
+	// Parameters are the JSON parameters of the rule, if the type has any.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	// RulesetID is the ID of the ruleset of the rule, set for the rules in effect on a branch.
+	RulesetID int64 `json:"ruleset_id,omitempty"`
+}
+
+// NewRulesetRule returns a rule of the given type, with the given parameters, which can be nil.
+func NewRulesetRule(ruleType string, parameters interface{}) (*RulesetRule, error) {
+	rule := &RulesetRule{Type: ruleType}
+	if parameters == nil {
+		return rule, nil
+	}
+	data, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the parameters of the %s rule: %w", ruleType, err)
+	}
+	rule.Parameters = data
+	return rule, nil
+}
+
+// DecodeParameters decodes the parameters of the rule into v, e.g. *PullRequestRuleParameters.
+func (r *RulesetRule) DecodeParameters(v interface{}) error {
+	if len(r.Parameters) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.Parameters, v); err != nil {
+		return fmt.Errorf("failed to decode the parameters of the %s rule: %w", r.Type, err)
+	}
+	return nil
+}
+
+// PullRequestRuleParameters are the parameters of RuleTypePullRequest rules.
+type PullRequestRuleParameters struct {
+	DismissStaleReviewsOnPush      bool `json:"dismiss_stale_reviews_on_push"`
+	RequireCodeOwnerReview         bool `json:"require_code_owner_review"`
+	RequireLastPushApproval        bool `json:"require_last_push_approval"`
+	RequiredApprovingReviewCount   int  `json:"required_approving_review_count"`
+	RequiredReviewThreadResolution bool `json:"required_review_thread_resolution"`
+}
+
+// RequiredStatusChecksRuleParameters are the parameters of RuleTypeRequiredStatusChecks rules.
+type RequiredStatusChecksRuleParameters struct {
+	RequiredStatusChecks             []RulesetStatusCheck `json:"required_status_checks"`
+	StrictRequiredStatusChecksPolicy bool                 `json:"strict_required_status_checks_policy"`
+}
+
+// RulesetStatusCheck is a status check required by a ruleset.
+type RulesetStatusCheck struct {
+	// Context is the name of the status check.
+	Context string `json:"context"`
+	// IntegrationID is the ID of the GitHub App which must report the status check, if set.
+	IntegrationID int64 `json:"integration_id,omitempty"`
+}
+
+// WorkflowsRuleParameters are the parameters of RuleTypeWorkflows rules, the required workflows.
+type WorkflowsRuleParameters struct {
+	Workflows []RulesetWorkflow `json:"workflows"`
+}
+
+// RulesetWorkflow is a workflow which must pass, from a repository of the organization.
+type RulesetWorkflow struct {
+	// Path is the path of the workflow file, e.g. ".github/workflows/ci.yaml".
+	Path string `json:"path"`
+	// RepositoryID is the ID of the repository of the workflow.
+	RepositoryID int64 `json:"repository_id"`
+	// Ref is the branch or tag of the workflow, if SHA isn't set.
+	Ref string `json:"ref,omitempty"`
+	// SHA is the commit of the workflow.
+	SHA string `json:"sha,omitempty"`
+}
+
+// PatternRuleParameters are the parameters of the commit metadata rules, i.e. the
+// RuleTypeCommitMessagePattern, RuleTypeCommitAuthorEmailPattern, RuleTypeCommitterEmailPattern,
+// RuleTypeBranchNamePattern and RuleTypeTagNamePattern rules.
+type PatternRuleParameters struct {
+	// Name describes the rule.
+	Name string `json:"name,omitempty"`
+	// Negate makes the rule match the values which don't match the pattern.
+	Negate bool `json:"negate,omitempty"`
+	// Operator is "starts_with", "ends_with", "contains" or "regex".
+	Operator string `json:"operator"`
+	// Pattern is the pattern to match.
+	Pattern string `json:"pattern"`
+}
+
+// FilePathRestrictionRuleParameters are the parameters of RuleTypeFilePathRestriction rules,
+// a push rule rejecting changes to the given files.
+type FilePathRestrictionRuleParameters struct {
+	RestrictedFilePaths []string `json:"restricted_file_paths"`
+}
+
+// RulesetClient operates on the rulesets of a repository or organization.
+// It can be accessed through Extras.RepositoryRulesets or Extras.OrganizationRulesets.
+type RulesetClient struct {
+	*clientContext
+	// path is the path of the owner of the rulesets, e.g. "repos/{owner}/{repo}"
+	path string
+}
+
+// List lists the rulesets. The rules of the rulesets aren't included, use Get for them.
+func (c *RulesetClient) List(ctx context.Context) ([]*Ruleset, error) {
+	var rulesets []*Ruleset
+	for page := 1; page != 0; {
+		var pageObjs []*Ruleset
+		// GET /repos/{owner}/{repo}/rulesets or /orgs/{org}/rulesets
+		resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/rulesets?per_page=100&page=%d", c.path, page), nil, &pageObjs)
+		if err != nil {
+			return nil, err
+		}
+		rulesets = append(rulesets, pageObjs...)
+		page = resp.NextPage
+	}
+	return rulesets, nil
+}
+
+// Get returns the ruleset with the given ID.
+//
+// gitprovider.ErrNotFound is returned if the ruleset doesn't exist.
+func (c *RulesetClient) Get(ctx context.Context, id int64) (*Ruleset, error) {
+	ruleset := &Ruleset{}
+	// GET /repos/{owner}/{repo}/rulesets/{ruleset_id} or /orgs/{org}/rulesets/{ruleset_id}
+	if _, err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/rulesets/%d", c.path, id), nil, ruleset); err != nil {
+		return nil, err
+	}
+	return ruleset, nil
+}
+
+// Create creates the given ruleset, and returns it with its ID.
+func (c *RulesetClient) Create(ctx context.Context, ruleset *Ruleset) (*Ruleset, error) {
+	created := &Ruleset{}
+	// POST /repos/{owner}/{repo}/rulesets or /orgs/{org}/rulesets
+	if _, err := c.do(ctx, http.MethodPost, c.path+"/rulesets", ruleset, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Update replaces the ruleset with the ID of the given ruleset.
+//
+// gitprovider.ErrNotFound is returned if the ruleset doesn't exist.
+func (c *RulesetClient) Update(ctx context.Context, ruleset *Ruleset) (*Ruleset, error) {
+	updated := &Ruleset{}
+	// PUT /repos/{owner}/{repo}/rulesets/{ruleset_id} or /orgs/{org}/rulesets/{ruleset_id}
+	if _, err := c.do(ctx, http.MethodPut, fmt.Sprintf("%s/rulesets/%d", c.path, ruleset.ID), ruleset, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Delete deletes the ruleset with the given ID.
+//
+// gitprovider.ErrNotFound is returned if the ruleset doesn't exist.
+func (c *RulesetClient) Delete(ctx context.Context, id int64) error {
+	// DELETE /repos/{owner}/{repo}/rulesets/{ruleset_id} or /orgs/{org}/rulesets/{ruleset_id}
+	_, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("%s/rulesets/%d", c.path, id), nil, nil)
+	return err
+}
+
+// do makes a request to the REST API, as go-github doesn't have the ruleset endpoints.
+func (c *RulesetClient) do(ctx context.Context, method, path string, body, v interface{}) (*github.Response, error) {
+	req, err := c.c.Client().NewRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.c.Client().Do(ctx, req, v)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return resp, nil
+}
+
+// branchRules returns the rules in effect on the branch of the repository, from all the
+// rulesets which apply to it.
+func branchRules(ctx context.Context, c *clientContext, ref gitprovider.RepositoryRef, branch string) ([]*RulesetRule, error) {
+	rc := &RulesetClient{clientContext: c, path: fmt.Sprintf("repos/%s/%s", ref.GetIdentity(), ref.GetRepository())}
+	var rules []*RulesetRule
+	for page := 1; page != 0; {
+		var pageObjs []*RulesetRule
+		// GET /repos/{owner}/{repo}/rules/branches/{branch}
+		resp, err := rc.do(ctx, http.MethodGet, fmt.Sprintf("%s/rules/branches/%s?per_page=100&page=%d", rc.path, url.PathEscape(branch), page), nil, &pageObjs)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, pageObjs...)
+		page = resp.NextPage
+	}
+	return rules, nil
+}
+
+// branchRulesFromAPI normalizes the rules in effect on a branch. If several rulesets have
+// pull request rules, the highest number of approvals is required.
+func branchRulesFromAPI(rules []*RulesetRule) (gitprovider.BranchRules, error) {
+	result := gitprovider.BranchRules{}
+	for _, rule := range rules {
+		switch rule.Type {
+		case RuleTypeDeletion:
+			result.BlockDeletion = true
+		case RuleTypeNonFastForward:
+			result.BlockForcePushes = true
+		case RuleTypeRequiredLinearHistory:
+			result.RequireLinearHistory = true
+		case RuleTypeRequiredSignatures:
+			result.RequireSignedCommits = true
+		case RuleTypePullRequest:
+			params := &PullRequestRuleParameters{}
+			if err := rule.DecodeParameters(params); err != nil {
+				return gitprovider.BranchRules{}, err
+			}
+			result.RequirePullRequest = true
+			if params.RequiredApprovingReviewCount > result.RequiredApprovals {
+				result.RequiredApprovals = params.RequiredApprovingReviewCount
+			}
+			if params.RequireCodeOwnerReview {
+				result.RequireCodeOwnerReview = true
+			}
+		case RuleTypeRequiredStatusChecks:
+			params := &RequiredStatusChecksRuleParameters{}
+			if err := rule.DecodeParameters(params); err != nil {
+				return gitprovider.BranchRules{}, err
+			}
+			for _, check := range params.RequiredStatusChecks {
+				result.RequiredStatusChecks = append(result.RequiredStatusChecks, check.Context)
+			}
+		}
+	}
+	result.RequiredStatusChecks = uniqueSorted(result.RequiredStatusChecks)
+	return result, nil
+}
+
+// uniqueSorted returns the sorted, unique strings of s, or nil if s is empty.
+func uniqueSorted(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	sort.Strings(s)
+	unique := s[:1]
+	for _, v := range s[1:] {
+		if v != unique[len(unique)-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestRulesetClient(t *testing.T) {
+	var created Ruleset
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/orgs/org/rulesets", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Fatal(err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 7, "name": "ci", "enforcement": "active", "source_type": "Organization", "source": "org"}`))
+			return
+		}
+		w.Write([]byte(`[{"id": 7, "name": "ci", "enforcement": "active"}]`))
+	})
+	mux.HandleFunc("/api/v3/orgs/org/rulesets/8", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	})
+	client := newTestClient(t, mux)
+	e := &extras{client}
+	c := e.OrganizationRulesets(gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"})
+	ctx := context.Background()
+
+	workflows, err := NewRulesetRule(RuleTypeWorkflows, &WorkflowsRuleParameters{
+		Workflows: []RulesetWorkflow{{Path: ".github/workflows/ci.yaml", RepositoryID: 42, Ref: "main"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ruleset, err := c.Create(ctx, &Ruleset{
+		Name:        "ci",
+		Enforcement: "active",
+		Conditions: &RulesetConditions{
+			RefName:        &RulesetRefNameCondition{Include: []string{"~DEFAULT_BRANCH"}, Exclude: []string{}},
+			RepositoryName: &RulesetRepositoryNameCondition{Include: []string{"~ALL"}, Exclude: []string{}},
+		},
+		Rules: []*RulesetRule{workflows},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ruleset.ID != 7 || ruleset.SourceType != "Organization" {
+		t.Errorf("Create() = %+v", ruleset)
+	}
+	params := &WorkflowsRuleParameters{}
+	if err := created.Rules[0].DecodeParameters(params); err != nil {
+		t.Fatal(err)
+	}
+	if params.Workflows[0].Path != ".github/workflows/ci.yaml" {
+		t.Errorf("Create() sent workflows %+v", params.Workflows)
+	}
+
+	rulesets, err := c.List(ctx)
+	if err != nil || len(rulesets) != 1 || rulesets[0].Name != "ci" {
+		t.Errorf("List() = %+v, %v", rulesets, err)
+	}
+	if _, err := c.Get(ctx, 8); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+	if err := c.Delete(ctx, 8); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestBranchClient_GetRules(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/org/repo/rules/branches/main", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"type": "deletion", "ruleset_id": 1},
+			{"type": "non_fast_forward", "ruleset_id": 1},
+			{"type": "pull_request", "ruleset_id": 1, "parameters": {"required_approving_review_count": 1}},
+			{"type": "pull_request", "ruleset_id": 2, "parameters": {"required_approving_review_count": 2}},
+			{"type": "required_status_checks", "ruleset_id": 2, "parameters": {"required_status_checks": [{"context": "test"}, {"context": "build"}]}},
+			{"type": "commit_message_pattern", "ruleset_id": 2, "parameters": {"operator": "starts_with", "pattern": "JIRA-"}}
+		]`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "github.example.com", Organization: "org"}, RepositoryName: "repo"}
+	c := &BranchClient{clientContext: client.clientContext, ref: ref}
+
+	rules, err := c.GetRules(context.Background(), "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gitprovider.BranchRules{
+		BlockDeletion:        true,
+		BlockForcePushes:     true,
+		RequirePullRequest:   true,
+		RequiredApprovals:    2,
+		RequiredStatusChecks: []string{"build", "test"},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("GetRules() = %+v, want %+v", rules, want)
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v41/github"
 
@@ -28,8 +30,10 @@ import (
 )
 
 const (
-	alreadyExistsMagicString = "name already exists on this account"
-	rateLimitDocURL          = "https://developer.github.com/v3/#rate-limiting"
+	alreadyExistsMagicString   = "name already exists on this account"
+	keyInUseMagicString        = "key is already in use"
+	emptyRepositoryMagicString = "repository is empty"
+	rateLimitDocURL            = "https://developer.github.com/v3/#rate-limiting"
 )
 
 // TODO: Guard better against nil pointer dereference panics in this package, also
@@ -94,57 +98,114 @@ func validateIdentityFields(ref gitprovider.IdentityRef, expectedDomain string)
 // handleHTTPError checks the type of err, and returns typed variants of it
 // However, it _always_ keeps the original error too, and just wraps it in a MultiError
 // The consumer must use errors.Is and errors.As to check for equality and get data out of it.
+// Secrets in the error message, e.g. echoed request parameters, are redacted.
 func handleHTTPError(err error) error {
+	return gitprovider.RedactError(typedHTTPError(err))
+}
+
+// classifyHTTPError returns the error of the gitprovider taxonomy matching a failed response,
+// from its status code and endpoint, e.g. a 409 Conflict from the merges endpoint is a merge
+// conflict, or else gitprovider.ClassifyHTTPError of the status code and message.
+func classifyHTTPError(resp *http.Response, message string) error {
+	if req := resp.Request; req != nil && req.URL != nil {
+		if resp.StatusCode == http.StatusConflict && req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/merges") {
+			return gitprovider.ErrMergeConflict
+		}
+	}
+	return gitprovider.ClassifyHTTPError(resp.StatusCode, message)
+}
+
+// typedHTTPError returns the typed variants of err for handleHTTPError.
+func typedHTTPError(err error) error {
 	// Short-circuit quickly if possible, allow always piping through this function
 	if err == nil {
 		return nil
 	}
 	ghRateLimitError := &github.RateLimitError{}
+	ghAbuseRateLimitError := &github.AbuseRateLimitError{}
 	ghErrorResponse := &github.ErrorResponse{}
 	if errors.As(err, &ghRateLimitError) {
 		// Convert go-github's RateLimitError to our similar error type
 		return validation.NewMultiError(err, &gitprovider.RateLimitError{
-			HTTPError: gitprovider.HTTPError{
-				Response:         ghRateLimitError.Response,
-				ErrorMessage:     ghRateLimitError.Error(),
-				Message:          ghRateLimitError.Message,
-				DocumentationURL: rateLimitDocURL,
-			},
+			HTTPError: newHTTPError(ghRateLimitError.Response, ghRateLimitError.Error(), ghRateLimitError.Message, rateLimitDocURL),
 			Limit:     ghRateLimitError.Rate.Limit,
 			Remaining: ghRateLimitError.Rate.Remaining,
 			Reset:     ghRateLimitError.Rate.Reset.Time,
 		})
-	} else if errors.As(err, &ghErrorResponse) {
-		httpErr := gitprovider.HTTPError{
-			Response:         ghErrorResponse.Response,
-			ErrorMessage:     ghErrorResponse.Error(),
-			Message:          ghErrorResponse.Message,
-			DocumentationURL: ghErrorResponse.DocumentationURL,
+	} else if errors.As(err, &ghAbuseRateLimitError) {
+		// Convert go-github's secondary rate limit error, which only tells when to retry
+		rateLimitErr := &gitprovider.RateLimitError{
+			HTTPError: newHTTPError(ghAbuseRateLimitError.Response, ghAbuseRateLimitError.Error(), ghAbuseRateLimitError.Message, rateLimitDocURL),
 		}
+		if ghAbuseRateLimitError.RetryAfter != nil {
+			rateLimitErr.Reset = time.Now().Add(*ghAbuseRateLimitError.RetryAfter)
+		}
+		return validation.NewMultiError(err, rateLimitErr)
+	} else if errors.As(err, &ghErrorResponse) {
+		httpErr := newHTTPError(ghErrorResponse.Response, ghErrorResponse.Error(), ghErrorResponse.Message, ghErrorResponse.DocumentationURL)
+		// The archived repository, protected branch, merge conflict, etc. errors, if any
+		classified := classifyHTTPError(ghErrorResponse.Response, ghErrorResponse.Message)
 		// Check for invalid credentials, and return a typed error in that case
 		if ghErrorResponse.Response.StatusCode == http.StatusForbidden ||
 			ghErrorResponse.Response.StatusCode == http.StatusUnauthorized {
-			return validation.NewMultiError(err,
+			return newTypedError(err, classified,
 				&gitprovider.InvalidCredentialsError{HTTPError: httpErr},
 			)
 		}
+		// Check for operations on an empty repository, GitHub either responds with
+		// 409 "Git Repository is empty." or 404 "This repository is empty."
+		if strings.Contains(strings.ToLower(ghErrorResponse.Message), emptyRepositoryMagicString) {
+			return validation.NewMultiError(err, &httpErr, gitprovider.ErrEmptyRepository)
+		}
 		// Check for 404 Not Found
 		if ghErrorResponse.Response.StatusCode == http.StatusNotFound {
-			return validation.NewMultiError(err, gitprovider.ErrNotFound)
+			return validation.NewMultiError(err, &httpErr, gitprovider.ErrNotFound)
 		}
 		// Check for already exists errors
 		for _, validationErr := range ghErrorResponse.Errors {
-			if validationErr.Message == alreadyExistsMagicString {
-				return validation.NewMultiError(err, gitprovider.ErrAlreadyExists)
+			if validationErr.Message == alreadyExistsMagicString || validationErr.Message == keyInUseMagicString {
+				return validation.NewMultiError(err, &httpErr, gitprovider.ErrAlreadyExists)
+			}
+		}
+		// Check for 422 Unprocessable Entity, with the invalid fields
+		if ghErrorResponse.Response.StatusCode == http.StatusUnprocessableEntity {
+			validationErr := &gitprovider.ValidationError{HTTPError: httpErr}
+			for _, item := range ghErrorResponse.Errors {
+				validationErr.Errors = append(validationErr.Errors, gitprovider.ValidationErrorItem{
+					Resource: item.Resource,
+					Field:    item.Field,
+					Code:     item.Code,
+					Message:  item.Message,
+				})
 			}
+			return newTypedError(err, classified, validationErr)
 		}
 		// Otherwise, return a generic *HTTPError
-		return validation.NewMultiError(err, &httpErr)
+		return newTypedError(err, classified, &httpErr)
 	}
 	// Do nothing, just pipe through the unknown err
 	return err
 }
 
+// newHTTPError returns the HTTPError of resp, with its status code, request ID and body, and the
+// given messages.
+func newHTTPError(resp *http.Response, errorMessage, message, documentationURL string) gitprovider.HTTPError {
+	httpErr := gitprovider.NewHTTPError(resp, nil)
+	httpErr.ErrorMessage = errorMessage
+	httpErr.Message = message
+	httpErr.DocumentationURL = documentationURL
+	return httpErr
+}
+
+// newTypedError returns a MultiError of err, its typed variant, and the classified error of the
+// taxonomy if it isn't nil.
+func newTypedError(err, classified, typedErr error) error {
+	if classified == nil {
+		return validation.NewMultiError(err, typedErr)
+	}
+	return validation.NewMultiError(err, typedErr, classified)
+}
+
 // allPages runs fn for each page, expecting a HTTP request to be made and returned during that call.
 // allPages expects that the data is saved in fn to an outer variable.
 // allPages calls fn as many times as needed to get all pages, and modifies opts for each call.
@@ -163,6 +224,42 @@ func allPages(opts *github.ListOptions, fn func() (*github.Response, error)) err
 	}
 }
 
+// allPagesConcurrently is like allPages, but fn is called with the page number to get, and returns
+// the objects of that page. Once the first page reveals the number of pages, the remaining ones are
+// fetched with up to concurrency requests at once. The objects of each page are returned in order.
+// There is no need to wrap the resulting error in handleHTTPError(err), as that's already done.
+func allPagesConcurrently(concurrency int, fn func(page int) (interface{}, *github.Response, error)) ([]interface{}, error) {
+	// The first request doesn't set the page, as allPages does
+	pageObjs, resp, err := fn(0)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	pages := []interface{}{pageObjs}
+	// Fetch the pages one by one if there is no more than one request at a time, or if the number
+	// of pages isn't known
+	if concurrency <= 1 || resp.LastPage == 0 {
+		for resp.NextPage != 0 {
+			pageObjs, resp, err = fn(resp.NextPage)
+			if err != nil {
+				return nil, handleHTTPError(err)
+			}
+			pages = append(pages, pageObjs)
+		}
+		return pages, nil
+	}
+
+	rest := make([]interface{}, resp.LastPage-resp.NextPage+1)
+	err = gitprovider.RunConcurrently(concurrency, len(rest), func(i int) error {
+		pageObjs, _, err := fn(resp.NextPage + i)
+		rest[i] = pageObjs
+		return err
+	})
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return append(pages, rest...), nil
+}
+
 // validateAPIObject creates a Validatior with the specified name, gives it to fn, and
 // depending on if any error was registered with it; either returns nil, or a MultiError
 // with both the validation error and ErrInvalidServerData, to mark that the server data
@@ -176,3 +273,23 @@ func validateAPIObject(name string, fn func(validation.Validator)) error {
 	}
 	return nil
 }
+
+// archiveFormatToAPI converts a gitprovider.ArchiveFormat to the archive format used by the GitHub API.
+func archiveFormatToAPI(format gitprovider.ArchiveFormat) (github.ArchiveFormat, error) {
+	switch format {
+	case gitprovider.ArchiveFormatTarGz:
+		return github.Tarball, nil
+	case gitprovider.ArchiveFormatZip:
+		return github.Zipball, nil
+	default:
+		return "", fmt.Errorf("unsupported archive format %q: %w", format, gitprovider.ErrInvalidArgument)
+	}
+}
+
+// timestampTime returns the time of a go-github timestamp, or nil if ts is nil.
+func timestampTime(ts *github.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	return &ts.Time
+}
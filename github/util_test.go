@@ -19,6 +19,7 @@ package github
 import (
 	"net/http"
 	"net/url"
+	"reflect"
 	"testing"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -133,3 +134,151 @@ func Test_allPages(t *testing.T) {
 		})
 	}
 }
+
+func Test_allPagesConcurrently(t *testing.T) {
+	tests := []struct {
+		name          string
+		concurrency   int
+		lastPage      int
+		failAt        int
+		expectedErrs  []error
+		expectedPages []interface{}
+	}{
+		{
+			name:          "one page only",
+			concurrency:   3,
+			expectedPages: []interface{}{0},
+		},
+		{
+			name:          "four pages, sequential",
+			concurrency:   1,
+			lastPage:      4,
+			expectedPages: []interface{}{0, 2, 3, 4},
+		},
+		{
+			name:          "four pages, concurrent",
+			concurrency:   3,
+			lastPage:      4,
+			expectedPages: []interface{}{0, 2, 3, 4},
+		},
+		{
+			name:         "four pages, concurrent, error at third",
+			concurrency:  3,
+			lastPage:     4,
+			failAt:       3,
+			expectedErrs: []error{&validation.MultiError{}, gitprovider.ErrNotFound, newGHError()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages, err := allPagesConcurrently(tt.concurrency, func(page int) (interface{}, *github.Response, error) {
+				if page != 0 && page == tt.failAt {
+					return nil, nil, newGHError()
+				}
+				// page 0 is the first page, where the page number is omitted
+				resp := &github.Response{}
+				if page < tt.lastPage {
+					resp.NextPage = page + 1
+					if page == 0 {
+						resp.NextPage = 2
+					}
+				}
+				if page == 0 {
+					resp.LastPage = tt.lastPage
+				}
+				return page, resp, nil
+			})
+			validation.TestExpectErrors(t, "allPagesConcurrently", err, tt.expectedErrs...)
+			if tt.expectedErrs == nil && !reflect.DeepEqual(pages, tt.expectedPages) {
+				t.Errorf("allPagesConcurrently() = %v, want %v", pages, tt.expectedPages)
+			}
+		})
+	}
+}
+
+func Test_handleHTTPError(t *testing.T) {
+	newResponseError := func(statusCode int, message string) *github.ErrorResponse {
+		err := newGHError()
+		err.Response.StatusCode = statusCode
+		err.Message = message
+		return err
+	}
+	newEndpointError := func(statusCode int, message, method, path string) *github.ErrorResponse {
+		err := newResponseError(statusCode, message)
+		err.Response.Request = &http.Request{Method: method, URL: &url.URL{Path: path}}
+		return err
+	}
+	tests := []struct {
+		name         string
+		err          error
+		expectedErrs []error
+	}{
+		{
+			name: "nil error",
+		},
+		{
+			name:         "not found",
+			err:          newResponseError(http.StatusNotFound, "Not Found"),
+			expectedErrs: []error{&validation.MultiError{}, gitprovider.ErrNotFound},
+		},
+		{
+			name:         "empty repository, git data API",
+			err:          newResponseError(http.StatusConflict, "Git Repository is empty."),
+			expectedErrs: []error{&validation.MultiError{}, gitprovider.ErrEmptyRepository},
+		},
+		{
+			name:         "empty repository, contents API",
+			err:          newResponseError(http.StatusNotFound, "This repository is empty."),
+			expectedErrs: []error{&validation.MultiError{}, gitprovider.ErrEmptyRepository},
+		},
+		{
+			name:         "forbidden",
+			err:          newResponseError(http.StatusForbidden, "Resource not accessible by personal access token"),
+			expectedErrs: []error{&gitprovider.InvalidCredentialsError{}, gitprovider.ErrPermissionDenied},
+		},
+		{
+			name:         "archived repository",
+			err:          newResponseError(http.StatusForbidden, "Repository was archived so is read-only."),
+			expectedErrs: []error{&gitprovider.InvalidCredentialsError{}, gitprovider.ErrRepositoryArchived},
+		},
+		{
+			name:         "protected branch",
+			err:          newResponseError(http.StatusUnprocessableEntity, "Cannot delete this protected branch"),
+			expectedErrs: []error{&gitprovider.ValidationError{}, gitprovider.ErrBranchProtected},
+		},
+		{
+			name:         "merge conflict",
+			err:          newResponseError(http.StatusMethodNotAllowed, "Pull Request is not mergeable"),
+			expectedErrs: []error{&gitprovider.HTTPError{}, gitprovider.ErrMergeConflict},
+		},
+		{
+			name:         "merge conflict, merges endpoint",
+			err:          newEndpointError(http.StatusConflict, "Konflikt", http.MethodPost, "/repos/org/repo/merges"),
+			expectedErrs: []error{&gitprovider.HTTPError{}, gitprovider.ErrMergeConflict},
+		},
+		{
+			name:         "name in the message",
+			err:          newResponseError(http.StatusForbidden, "Must have admin rights to Repository archived-charts."),
+			expectedErrs: []error{&gitprovider.InvalidCredentialsError{}, gitprovider.ErrPermissionDenied},
+		},
+		{
+			name:         "validation failed",
+			err:          newResponseError(http.StatusUnprocessableEntity, "Validation Failed"),
+			expectedErrs: []error{&gitprovider.ValidationError{}, gitprovider.ErrValidationFailed},
+		},
+		{
+			name: "secondary rate limit",
+			err: &github.AbuseRateLimitError{
+				Response: newResponseError(http.StatusForbidden, "").Response,
+				Message:  "You have exceeded a secondary rate limit.",
+			},
+			expectedErrs: []error{&gitprovider.RateLimitError{}, gitprovider.ErrRateLimited},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := handleHTTPError(tt.err)
+			validation.TestExpectErrors(t, "handleHTTPError", err, tt.expectedErrs...)
+		})
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package gitlab
 
 import (
+	"net/url"
+
 	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/go-logr/logr"
 	gogitlab "github.com/xanzy/go-gitlab"
 )
 
@@ -26,6 +29,20 @@ const (
 	DefaultDomain = "gitlab.com"
 )
 
+func init() {
+	gitprovider.RegisterClientFactory(ProviderID, func(baseURL *url.URL, _, token string, optFns ...gitprovider.ClientOption) (gitprovider.Client, error) {
+		if baseURL != nil && baseURL.Host != DefaultDomain {
+			optFns = append(optFns, gitprovider.WithDomain(baseURL.String()))
+		}
+		// Tokens from a token source are OAuth2 tokens
+		tokenType := ""
+		if opts, err := gitprovider.MakeClientOptions(optFns...); err == nil && opts.TokenSource != nil {
+			tokenType = "oauth2"
+		}
+		return NewClient(token, tokenType, optFns...)
+	})
+}
+
 // NewClient creates a new gitlab.Client instance for GitLab API endpoints.
 func NewClient(token string, tokenType string, optFns ...gitprovider.ClientOption) (gitprovider.Client, error) {
 	var gl *gogitlab.Client
@@ -43,17 +60,22 @@ func NewClient(token string, tokenType string, optFns ...gitprovider.ClientOptio
 		return nil, err
 	}
 
+	glOpts := []gogitlab.ClientOptionFunc{gogitlab.WithHTTPClient(httpClient)}
+	if opts.Logger != nil {
+		glOpts = append(glOpts, gogitlab.WithCustomLeveledLogger(&retryLogger{log: *opts.Logger}))
+	}
+
 	if tokenType == "oauth2" {
 		if opts.Domain == nil || *opts.Domain == DefaultDomain {
 			// No domain set or the default gitlab.com used
 			domain = DefaultDomain
-			gl, err = gogitlab.NewOAuthClient(token, gogitlab.WithHTTPClient(httpClient))
+			gl, err = gogitlab.NewOAuthClient(token, glOpts...)
 			if err != nil {
 				return nil, err
 			}
 		} else {
 			domain = *opts.Domain
-			gl, err = gogitlab.NewOAuthClient(token, gogitlab.WithHTTPClient(httpClient), gogitlab.WithBaseURL(domain))
+			gl, err = gogitlab.NewOAuthClient(token, append(glOpts, gogitlab.WithBaseURL(domain))...)
 			if err != nil {
 				return nil, err
 			}
@@ -62,13 +84,13 @@ func NewClient(token string, tokenType string, optFns ...gitprovider.ClientOptio
 		if opts.Domain == nil || *opts.Domain == DefaultDomain {
 			// No domain set or the default gitlab.com used
 			domain = DefaultDomain
-			gl, err = gogitlab.NewClient(token, gogitlab.WithHTTPClient(httpClient))
+			gl, err = gogitlab.NewClient(token, glOpts...)
 			if err != nil {
 				return nil, err
 			}
 		} else {
 			domain = *opts.Domain
-			gl, err = gogitlab.NewClient(token, gogitlab.WithHTTPClient(httpClient), gogitlab.WithBaseURL(domain))
+			gl, err = gogitlab.NewClient(token, append(glOpts, gogitlab.WithBaseURL(domain))...)
 			if err != nil {
 				return nil, err
 			}
@@ -81,5 +103,38 @@ func NewClient(token string, tokenType string, optFns ...gitprovider.ClientOptio
 		destructiveActions = *opts.EnableDestructiveAPICalls
 	}
 
-	return newClient(gl, domain, sshDomain, destructiveActions), nil
+	c := newClient(gl, domain, sshDomain, destructiveActions, gitprovider.ClockOrDefault(opts.Clock))
+	// In dry-run mode, Reconcile calls only compute the changes they would apply
+	c.dryRun = opts.DryRun != nil && *opts.DryRun
+	c.optimisticConcurrency = opts.OptimisticConcurrency != nil && *opts.OptimisticConcurrency
+	c.repoDefaults = opts.OrgRepositoryDefaults
+	if opts.Concurrency != nil {
+		c.setConcurrency(*opts.Concurrency)
+	}
+	if opts.WaitAfterCreate != nil {
+		c.waitAfterCreate = *opts.WaitAfterCreate
+	}
+	return c, nil
+}
+
+// retryLogger implements retryablehttp.LeveledLogger, logging the request attempts and retries
+// of the go-gitlab client at debug level (V(1)).
+type retryLogger struct {
+	log logr.Logger
+}
+
+func (l *retryLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.log.V(1).Info(msg, keysAndValues...)
+}
+
+func (l *retryLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.log.V(1).Info(msg, keysAndValues...)
+}
+
+func (l *retryLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log.V(1).Info(msg, keysAndValues...)
+}
+
+func (l *retryLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.log.V(1).Info(msg, keysAndValues...)
 }
@@ -18,19 +18,24 @@ package gitlab
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/xanzy/go-gitlab"
 )
 
 // ProviderID is the provider ID for GitLab.
-const ProviderID = gitprovider.ProviderID("gitlab")
+const ProviderID = gitprovider.ProviderGitLab
 
-func newClient(c *gitlab.Client, domain string, sshDomain string, destructiveActions bool) *Client {
-	glClient := &gitlabClientImpl{c, destructiveActions}
-	ctx := &clientContext{glClient, domain, sshDomain, destructiveActions}
+func newClient(c *gitlab.Client, domain string, sshDomain string, destructiveActions bool, clock gitprovider.Clock) *Client {
+	glClient := &gitlabClientImpl{c: c, destructiveActions: destructiveActions, concurrency: 1}
+	ctx := &clientContext{c: glClient, domain: domain, sshDomain: sshDomain, destructiveActions: destructiveActions, clock: clock, concurrency: 1, generations: gitprovider.NewGenerationTracker()}
 	return &Client{
 		clientContext: ctx,
 		orgs: &OrganizationsClient{
@@ -42,6 +47,21 @@ func newClient(c *gitlab.Client, domain string, sshDomain string, destructiveAct
 		userRepos: &UserRepositoriesClient{
 			clientContext: ctx,
 		},
+		search: &SearchClient{
+			clientContext: ctx,
+		},
+		userKeys: &UserKeysClient{
+			clientContext: ctx,
+		},
+		invitations: &InvitationsClient{
+			clientContext: ctx,
+		},
+		events: &EventsClient{
+			clientContext: ctx,
+		},
+		tokens: &TokensClient{
+			clientContext: ctx,
+		},
 	}
 }
 
@@ -50,6 +70,19 @@ type clientContext struct {
 	domain             string
 	sshDomain          string
 	destructiveActions bool
+	clock              gitprovider.Clock
+	// dryRun makes Reconcile calls return the changes they would apply, without writing
+	dryRun bool
+	// optimisticConcurrency makes repository Update calls fail if the project changed since it was read
+	optimisticConcurrency bool
+	// repoDefaults are the repository defaults per organization, applied when creating repositories
+	repoDefaults gitprovider.OrgRepositoryDefaults
+	// concurrency is the number of requests aggregate List calls run at once
+	concurrency int
+	// waitAfterCreate is how long repository Create calls wait for the project to be visible
+	waitAfterCreate time.Duration
+	// generations tracks the generations of the returned objects
+	generations *gitprovider.GenerationTracker
 }
 
 // Client implements the gitprovider.Client interface.
@@ -59,9 +92,25 @@ var _ gitprovider.Client = &Client{}
 type Client struct {
 	*clientContext
 
-	orgs      *OrganizationsClient
-	orgRepos  *OrgRepositoriesClient
-	userRepos *UserRepositoriesClient
+	orgs        *OrganizationsClient
+	orgRepos    *OrgRepositoriesClient
+	userRepos   *UserRepositoriesClient
+	search      *SearchClient
+	userKeys    *UserKeysClient
+	invitations *InvitationsClient
+	events      *EventsClient
+	tokens      *TokensClient
+
+	identity gitprovider.IdentityCache
+}
+
+// setConcurrency sets the number of requests aggregate List calls run at once, both for
+// paginated requests and fan-outs over the listed objects.
+func (c *Client) setConcurrency(n int) {
+	c.concurrency = n
+	if impl, ok := c.c.(*gitlabClientImpl); ok {
+		impl.concurrency = n
+	}
 }
 
 // SupportedDomain returns the domain endpoint for this client, e.g. "gitlab.com" or
@@ -111,7 +160,147 @@ func (c *Client) UserRepositories() gitprovider.UserRepositoriesClient {
 	return c.userRepos
 }
 
+// Search returns the SearchClient searching for repositories and code.
+func (c *Client) Search() gitprovider.SearchClient {
+	return c.search
+}
+
+// UserKeys returns the UserKeysClient handling the SSH and GPG keys of the authenticated user.
+func (c *Client) UserKeys() gitprovider.UserKeysClient {
+	return c.userKeys
+}
+
+// Invitations returns the InvitationsClient handling the repository invitations of the authenticated user.
+func (c *Client) Invitations() gitprovider.InvitationsClient {
+	return c.invitations
+}
+
+// Events returns the EventsClient listing the activity of repositories and organizations.
+func (c *Client) Events() gitprovider.EventsClient {
+	return c.events
+}
+
+// Tokens returns the TokensClient handling the access tokens of repositories and organizations.
+func (c *Client) Tokens() gitprovider.TokensClient {
+	return c.tokens
+}
+
 // HasTokenPermission returns true if the given token has the given permissions.
 func (c *Client) HasTokenPermission(_ context.Context, _ gitprovider.TokenPermission) (bool, error) {
 	return false, gitprovider.ErrNoProviderSupport
 }
+
+// AuthenticatedUserID returns the identity of the authenticated user, which is a bot for project
+// and group access tokens. It's fetched on the first call, and cached by the client.
+func (c *Client) AuthenticatedUserID(ctx context.Context) (gitprovider.Identity, error) {
+	return c.identity.Get(ctx, c.fetchIdentity)
+}
+
+// IsOwnAction returns true if the event was caused by the authenticated user.
+func (c *Client) IsOwnAction(ctx context.Context, event gitprovider.ActorEvent) (bool, error) {
+	return c.identity.IsOwnAction(ctx, event, c.fetchIdentity)
+}
+
+// TokenInfo returns the authenticated user, and the scopes and expiry of the token. GitLab 15.5
+// or later reports them for personal, project and group access tokens; ScopesKnown is false if the
+// server doesn't find the token, e.g. for OAuth tokens and older servers.
+func (c *Client) TokenInfo(ctx context.Context) (gitprovider.TokenInfo, error) {
+	// GET /user
+	identity, err := c.fetchIdentity(ctx)
+	if err != nil {
+		return gitprovider.TokenInfo{}, err
+	}
+	info := gitprovider.TokenInfo{Principal: identity}
+
+	req, err := c.c.Client().NewRequest(http.MethodGet, "personal_access_tokens/self", nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return gitprovider.TokenInfo{}, err
+	}
+	token := &accessToken{}
+	// GET /personal_access_tokens/self
+	if _, err := c.c.Client().Do(req, token); err != nil {
+		if err = handleHTTPError(err); errors.Is(err, gitprovider.ErrNotFound) {
+			return info, nil
+		}
+		return gitprovider.TokenInfo{}, err
+	}
+	info.Scopes = token.Scopes
+	info.ScopesKnown = true
+	if token.ExpiresAt != nil {
+		expiresAt := time.Time(*token.ExpiresAt)
+		info.ExpiresAt = &expiresAt
+	}
+	return info, nil
+}
+
+// ValidatePermissions probes the access level of the authenticated user on every project, the
+// highest of its project and group membership, and the scopes of the token, which needs the api
+// scope to write with the API.
+func (c *Client) ValidatePermissions(ctx context.Context, needs ...gitprovider.Permission) error {
+	var info *gitprovider.TokenInfo
+	return gitprovider.CheckPermissions(ctx, needs, func(ctx context.Context, ref gitprovider.RepositoryRef) (gitprovider.RepositoryAccess, error) {
+		if err := validateIdentityFields(ref, c.domain); err != nil {
+			return gitprovider.RepositoryAccess{}, err
+		}
+		// GET /projects/{id}
+		apiObj, _, err := c.c.Client().Projects.GetProject(getRepoPath(ref), nil, gitlab.WithContext(ctx))
+		if err != nil {
+			return gitprovider.RepositoryAccess{}, handleHTTPError(err)
+		}
+		access := gitprovider.RepositoryAccess{}
+		if apiObj.Permissions != nil {
+			accessLevel := 0
+			if apiObj.Permissions.ProjectAccess != nil {
+				accessLevel = int(apiObj.Permissions.ProjectAccess.AccessLevel)
+			}
+			if apiObj.Permissions.GroupAccess != nil && int(apiObj.Permissions.GroupAccess.AccessLevel) > accessLevel {
+				accessLevel = int(apiObj.Permissions.GroupAccess.AccessLevel)
+			}
+			if permission, err := getGitProviderPermission(accessLevel); err == nil {
+				access.Permission = *permission
+			}
+		}
+
+		if info == nil {
+			tokenInfo, err := c.TokenInfo(ctx)
+			if err != nil {
+				return gitprovider.RepositoryAccess{}, err
+			}
+			info = &tokenInfo
+		}
+		if len(info.MissingScopes("api")) != 0 {
+			access.ReadOnly = true
+			access.ReadOnlyReason = "the token lacks the api scope"
+		}
+		return access, nil
+	})
+}
+
+// accessTokenBotUsername matches the usernames of the bot users of project and group access tokens,
+// e.g. "project_42_bot" or "group_7_bot_0123456789abcdef0123456789abcdef".
+//
+//nolint:gochecknoglobals
+var accessTokenBotUsername = regexp.MustCompile(`^(project|group)_\d+_bot(_[0-9a-f]+)?$`)
+
+func (c *Client) fetchIdentity(ctx context.Context) (gitprovider.Identity, error) {
+	// GET /user
+	user, err := c.c.GetCurrentUser(ctx)
+	if err != nil {
+		return gitprovider.Identity{}, err
+	}
+	return gitprovider.Identity{
+		ID:    strconv.Itoa(user.ID),
+		Login: user.Username,
+		Bot:   accessTokenBotUsername.MatchString(user.Username),
+	}, nil
+}
+
+// SupportedFeatures returns the features of the high-level API this provider supports.
+func (c *Client) SupportedFeatures() []gitprovider.Feature {
+	return gitprovider.SupportedFeatures(ProviderID, "")
+}
+
+// Extension returns the provider-specific extension registered under name, or nil.
+func (c *Client) Extension(name string) interface{} {
+	return gitprovider.LookupExtension(c, name)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// EventsClient implements the gitprovider.EventsClient interface.
+var _ gitprovider.EventsClient = &EventsClient{}
+
+// EventsClient lists the activity events of projects. GitLab has no activity API for groups.
+type EventsClient struct {
+	*clientContext
+}
+
+// ListRepository lists the events of the given project matching opts.
+//
+// ErrNotFound is returned if the project doesn't exist.
+func (c *EventsClient) ListRepository(ctx context.Context, ref gitprovider.RepositoryRef, opts gitprovider.EventListOptions) ([]gitprovider.Event, error) {
+	if err := validateIdentityFields(ref, c.domain); err != nil {
+		return nil, err
+	}
+	opts.Default()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	events := make([]gitprovider.Event, 0)
+	listOpts := &gitlab.ListContributionEventsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		// GET /projects/{id}/events
+		apiObjs, resp, err := c.c.Client().Events.ListProjectVisibleEvents(getRepoPath(ref), listOpts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		for _, apiObj := range apiObjs {
+			event := eventFromAPI(apiObj)
+			if opts.Before(event) {
+				return events, nil
+			}
+			if !opts.Matches(event) {
+				continue
+			}
+			event.Repository = ref
+			events = append(events, event)
+			if len(events) == opts.Limit {
+				return events, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return events, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// ListOrganization returns gitprovider.ErrNoProviderSupport, as GitLab has no activity API for groups.
+func (c *EventsClient) ListOrganization(_ context.Context, _ gitprovider.OrganizationRef, _ gitprovider.EventListOptions) ([]gitprovider.Event, error) {
+	return nil, fmt.Errorf("gitlab has no group events: %w", gitprovider.ErrNoProviderSupport)
+}
+
+// eventFromAPI returns the normalized event, without its project.
+func eventFromAPI(apiObj *gitlab.ContributionEvent) gitprovider.Event {
+	event := gitprovider.Event{
+		ID:     strconv.Itoa(apiObj.ID),
+		Type:   gitprovider.EventTypeOther,
+		Action: apiObj.ActionName,
+		Actor:  apiObj.AuthorUsername,
+	}
+	if apiObj.CreatedAt != nil {
+		event.CreatedAt = *apiObj.CreatedAt
+	}
+	switch {
+	case strings.HasPrefix(apiObj.ActionName, "pushed") && apiObj.PushData.RefType == "branch":
+		event.Type = gitprovider.EventTypePush
+		event.Action = ""
+		event.Branch = apiObj.PushData.Ref
+	case apiObj.TargetType == "MergeRequest":
+		event.Type = gitprovider.EventTypePullRequest
+		event.PullRequestNumber = apiObj.TargetIID
+		// Merged merge requests are "accepted"
+		if apiObj.ActionName == "accepted" {
+			event.Action = "merged"
+		}
+	case apiObj.ActionName == "joined" || apiObj.ActionName == "left":
+		event.Type = gitprovider.EventTypeMember
+		event.Member = apiObj.AuthorUsername
+		event.Action = "added"
+		if apiObj.ActionName == "left" {
+			event.Action = "removed"
+		}
+	}
+	return event
+}
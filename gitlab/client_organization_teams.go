@@ -39,7 +39,7 @@ type TeamsClient struct {
 //
 // ErrNotFound is returned if the resource does not exist.
 func (c *TeamsClient) Get(ctx context.Context, teamName string) (gitprovider.Team, error) {
-	apiObjs, err := c.c.ListGroupMembers(ctx, c.ref.Organization)
+	apiObjs, err := c.c.ListGroupMembers(ctx, c.ref.GetIdentity())
 	if err != nil {
 		return nil, err
 	}
@@ -65,19 +65,19 @@ func (c *TeamsClient) Get(ctx context.Context, teamName string) (gitprovider.Tea
 //
 // List returns all available organizations, using multiple paginated requests if needed.
 func (c *TeamsClient) List(ctx context.Context) ([]gitprovider.Team, error) {
-	subgroups, err := c.c.ListSubgroups(ctx, c.ref.Organization)
+	subgroups, err := c.c.ListSubgroups(ctx, c.ref.GetIdentity())
 	if err != nil {
 		return nil, err
 	}
 
-	teams := make([]gitprovider.Team, 0, len(subgroups))
-	for _, subgroup := range subgroups {
-		team, err := c.Get(ctx, subgroup.Name)
-		if err != nil {
-			return nil, err
-		}
-
-		teams = append(teams, team)
+	teams := make([]gitprovider.Team, len(subgroups))
+	err = gitprovider.RunConcurrently(c.concurrency, len(subgroups), func(i int) error {
+		team, err := c.Get(ctx, subgroups[i].Name)
+		teams[i] = team
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return teams, nil
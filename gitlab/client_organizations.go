@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/xanzy/go-gitlab"
 )
 
 // OrganizationsClient implements the gitprovider.OrganizationsClient interface.
@@ -36,7 +37,7 @@ type OrganizationsClient struct {
 // ErrNotFound is returned if the resource does not exist.
 func (c *OrganizationsClient) Get(ctx context.Context, ref gitprovider.OrganizationRef) (gitprovider.Organization, error) {
 	// GET /groups/{group}
-	apiObj, err := c.c.GetGroup(ctx, ref.Organization)
+	apiObj, err := c.c.GetGroup(ctx, ref.GetIdentity())
 	if err != nil {
 		return nil, err
 	}
@@ -44,46 +45,84 @@ func (c *OrganizationsClient) Get(ctx context.Context, ref gitprovider.Organizat
 	return newOrganization(c.clientContext, apiObj, ref), nil
 }
 
-// List all groups the specific user has access to.
+// List all groups the specific user has access to, including subgroups.
+// If OrganizationListOptions.Depth is set, only the top-level groups are listed, with their
+// subgroups included down to the given depth.
 //
 // List returns all available groups, using multiple paginated requests if needed.
-func (c *OrganizationsClient) List(ctx context.Context) ([]gitprovider.Organization, error) {
+func (c *OrganizationsClient) List(ctx context.Context, opts ...gitprovider.OrganizationListOption) ([]gitprovider.Organization, error) {
+	o, err := gitprovider.MakeOrganizationListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	// GET /groups
 	apiObjs, err := c.c.ListGroups(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	groups := make([]gitprovider.Organization, 0, len(apiObjs))
+	if o.Depth == nil {
+		return c.withSubgroups(ctx, apiObjs, 1)
+	}
+
+	// Older GitLab versions don't support listing only top-level groups, so filter the result
+	topLevel := make([]*gitlab.Group, 0, len(apiObjs))
 	for _, apiObj := range apiObjs {
-		ref := gitprovider.OrganizationRef{
-			Domain:       apiObj.WebURL,
-			Organization: apiObj.FullName,
+		if apiObj.ParentID == 0 {
+			topLevel = append(topLevel, apiObj)
 		}
-		groups = append(groups, newOrganization(c.clientContext, apiObj, ref))
 	}
 
-	return groups, nil
+	return c.withSubgroups(ctx, topLevel, o.GetDepth())
 }
 
-// Children returns the immediate child-organizations for the specific OrganizationRef o.
-// The OrganizationRef may point to any existing sub-organization.
+// Children returns the immediate subgroups for the specific OrganizationRef o.
+// The OrganizationRef may point to any existing subgroup.
+// Deeper subgroups are included down to the given OrganizationListOptions.Depth.
 //
-// Children returns all available organizations, using multiple paginated requests if needed.
-func (c *OrganizationsClient) Children(ctx context.Context, ref gitprovider.OrganizationRef) ([]gitprovider.Organization, error) {
-	apiObjs, err := c.c.ListSubgroups(ctx, ref.Organization)
+// Children returns all available groups, using multiple paginated requests if needed.
+func (c *OrganizationsClient) Children(ctx context.Context, ref gitprovider.OrganizationRef, opts ...gitprovider.OrganizationListOption) ([]gitprovider.Organization, error) {
+	o, err := gitprovider.MakeOrganizationListOptions(opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	subgroups := make([]gitprovider.Organization, 0, len(apiObjs))
-	for _, apiObj := range apiObjs {
-		ref := gitprovider.OrganizationRef{
-			Domain:       apiObj.WebURL,
-			Organization: apiObj.FullName,
+	// GET /groups/{group}/subgroups
+	apiObjs, err := c.c.ListSubgroups(ctx, ref.GetIdentity())
+	if err != nil {
+		return nil, err
+	}
+
+	return c.withSubgroups(ctx, apiObjs, o.GetDepth())
+}
+
+// withSubgroups traverses the subgroups of the given groups breadth-first, down to the given depth,
+// where a depth of 1 only returns the given groups, and a negative depth traverses the full tree.
+func (c *OrganizationsClient) withSubgroups(ctx context.Context, apiObjs []*gitlab.Group, depth int) ([]gitprovider.Organization, error) {
+	all := apiObjs
+	level := apiObjs
+	for d := 1; depth < 0 || d < depth; d++ {
+		var next []*gitlab.Group
+		for _, apiObj := range level {
+			// GET /groups/{group}/subgroups
+			subgroups, err := c.c.ListSubgroups(ctx, apiObj.FullPath)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, subgroups...)
 		}
-		subgroups = append(subgroups, newOrganization(c.clientContext, apiObj, ref))
+		if len(next) == 0 {
+			break
+		}
+		all = append(all, next...)
+		level = next
+	}
+
+	groups := make([]gitprovider.Organization, 0, len(all))
+	for _, apiObj := range all {
+		groups = append(groups, newOrganization(c.clientContext, apiObj, groupRef(c.domain, apiObj)))
 	}
 
-	return subgroups, nil
+	return groups, nil
 }
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestOrganizationsClient_List(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/groups", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 1, "path": "group", "full_path": "group"},
+			{"id": 2, "path": "sub", "full_path": "group/sub", "parent_id": 1},
+			{"id": 3, "path": "deep", "full_path": "group/sub/deep", "parent_id": 2}]`))
+	})
+	mux.HandleFunc("/api/v4/groups/group/subgroups", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 2, "path": "sub", "full_path": "group/sub", "parent_id": 1}]`))
+	})
+	mux.HandleFunc("/api/v4/groups/group/sub/subgroups", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 3, "path": "deep", "full_path": "group/sub/deep", "parent_id": 2}]`))
+	})
+	mux.HandleFunc("/api/v4/groups/group/sub/deep/subgroups", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	client := newTestClient(t, mux)
+	c := &OrganizationsClient{clientContext: client.clientContext}
+
+	tests := []struct {
+		name string
+		opts []gitprovider.OrganizationListOption
+		want []string
+	}{
+		{
+			name: "subgroups are returned by default",
+			want: []string{"group", "group/sub", "group/sub/deep"},
+		},
+		{
+			name: "depth 1 only returns top-level groups",
+			opts: []gitprovider.OrganizationListOption{&gitprovider.OrganizationListOptions{Depth: gitprovider.IntVar(1)}},
+			want: []string{"group"},
+		},
+		{
+			name: "depth 2",
+			opts: []gitprovider.OrganizationListOption{&gitprovider.OrganizationListOptions{Depth: gitprovider.IntVar(2)}},
+			want: []string{"group", "group/sub"},
+		},
+		{
+			name: "negative depth traverses the full tree",
+			opts: []gitprovider.OrganizationListOption{&gitprovider.OrganizationListOptions{Depth: gitprovider.IntVar(-1)}},
+			want: []string{"group", "group/sub", "group/sub/deep"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs, err := c.List(context.Background(), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(orgs))
+			for _, org := range orgs {
+				got = append(got, org.Organization().GetIdentity())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
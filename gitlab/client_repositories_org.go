@@ -19,6 +19,8 @@ package gitlab
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/xanzy/go-gitlab"
@@ -32,10 +34,11 @@ type OrgRepositoriesClient struct {
 	*clientContext
 }
 
-// Get returns the repository at the given path.
+// Get returns the repository at the given path. The statistics requested with
+// RepositoryGetOptions.WithStats take three more requests.
 //
 // ErrNotFound is returned if the resource does not exist.
-func (c *OrgRepositoriesClient) Get(ctx context.Context, ref gitprovider.OrgRepositoryRef) (gitprovider.OrgRepository, error) {
+func (c *OrgRepositoriesClient) Get(ctx context.Context, ref gitprovider.OrgRepositoryRef, opts ...gitprovider.RepositoryGetOption) (gitprovider.OrgRepository, error) {
 	// Make sure the OrgRepositoryRef is valid
 	if err := validateOrgRepositoryRef(ref, c.domain); err != nil {
 		return nil, err
@@ -45,20 +48,32 @@ func (c *OrgRepositoriesClient) Get(ctx context.Context, ref gitprovider.OrgRepo
 	if err != nil {
 		return nil, err
 	}
-	return newGroupProject(c.clientContext, apiObj, ref), nil
+	repo := newGroupProject(c.clientContext, apiObj, ref)
+	if o := gitprovider.MakeRepositoryGetOptions(opts...); o.WithStats != nil && *o.WithStats {
+		if repo.stats, err = getRepositoryStats(ctx, c.clientContext, apiObj.ID); err != nil {
+			return nil, err
+		}
+	}
+	return repo, nil
 }
 
 // List all repositories in the given organization.
+// The name, visibility and archival filters are passed to GitLab, the activity filter is
+// applied to the received list.
 //
 // List returns all available repositories, using multiple paginated requests if needed.
-func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.OrganizationRef) ([]gitprovider.OrgRepository, error) {
+func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.OrganizationRef, opts ...gitprovider.RepositoryListOption) ([]gitprovider.OrgRepository, error) {
 	// Make sure the OrganizationRef is valid
 	if err := validateOrganizationRef(ref, c.domain); err != nil {
 		return nil, err
 	}
+	o, err := gitprovider.MakeRepositoryListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
 
 	// GET /orgs/{org}/repos
-	apiObjs, err := c.c.ListGroupProjects(ctx, ref.Organization)
+	apiObjs, err := c.c.ListGroupProjects(ctx, ref.Organization, groupProjectsListOptions(o))
 	if err != nil {
 		return nil, err
 	}
@@ -67,6 +82,9 @@ func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.Organi
 	repos := make([]gitprovider.OrgRepository, 0, len(apiObjs))
 	for _, apiObj := range apiObjs {
 		// apiObj is already validated at ListOrgRepos
+		if !o.Matches(apiObj.Name, repositoryFromAPI(apiObj), lastActivityFromAPI(apiObj)) {
+			continue
+		}
 		repos = append(repos, newGroupProject(c.clientContext, apiObj, gitprovider.OrgRepositoryRef{
 			OrganizationRef: ref,
 			RepositoryName:  apiObj.Name,
@@ -75,6 +93,51 @@ func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.Organi
 	return repos, nil
 }
 
+// ListIter returns an iterator over the repositories in the given organization, which fetches
+// one page at a time. The filters are handled like in List.
+func (c *OrgRepositoriesClient) ListIter(ctx context.Context, ref gitprovider.OrganizationRef, opts ...gitprovider.RepositoryListOption) *gitprovider.OrgRepositoryIterator {
+	o, optsErr := gitprovider.MakeRepositoryListOptions(opts...)
+	listOpts := groupProjectsListOptions(o)
+	return gitprovider.NewOrgRepositoryIterator(ctx, func(ctx context.Context, page int) ([]gitprovider.OrgRepository, int, error) {
+		if optsErr != nil {
+			return nil, 0, optsErr
+		}
+		// Make sure the OrganizationRef is valid
+		if err := validateOrganizationRef(ref, c.domain); err != nil {
+			return nil, 0, err
+		}
+
+		// GET /groups/{group}/projects
+		apiObjs, next, err := c.c.ListGroupProjectsPage(ctx, ref.Organization, listOpts, page)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		repos := make([]gitprovider.OrgRepository, 0, len(apiObjs))
+		for _, apiObj := range apiObjs {
+			// apiObj is already validated at ListGroupProjectsPage
+			if !o.Matches(apiObj.Name, repositoryFromAPI(apiObj), lastActivityFromAPI(apiObj)) {
+				continue
+			}
+			repos = append(repos, newGroupProject(c.clientContext, apiObj, gitprovider.OrgRepositoryRef{
+				OrganizationRef: ref,
+				RepositoryName:  apiObj.Name,
+			}))
+		}
+		return repos, next, nil
+	})
+}
+
+// groupProjectsListOptions returns the parameters of "GET /groups/{group}/projects" for the filters
+// GitLab supports: the name, visibility and archival.
+func groupProjectsListOptions(o gitprovider.RepositoryListOptions) gitlab.ListGroupProjectsOptions {
+	return gitlab.ListGroupProjectsOptions{
+		Search:     o.NamePrefix,
+		Visibility: visibilityToAPI(o.Visibility),
+		Archived:   o.Archived,
+	}
+}
+
 // Create creates a repository for the given organization, with the data and options.
 //
 // ErrAlreadyExists will be returned if the resource already exists.
@@ -84,10 +147,17 @@ func (c *OrgRepositoriesClient) Create(ctx context.Context, ref gitprovider.OrgR
 		return nil, err
 	}
 
-	apiObj, err := createProject(ctx, c.c, ref, ref.Organization, req, opts...)
+	// Use the organization defaults for the unset fields, and the default create options
+	defaults := c.repoDefaults.For(ref.OrganizationRef)
+	defaults.ApplyToRepositoryInfo(&req)
+
+	apiObj, err := createProject(ctx, c.c, ref, ref.Organization, req, defaults.MergeCreateOptions(opts...)...)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.waitForProject(ctx, apiObj); err != nil {
+		return nil, err
+	}
 	return newGroupProject(c.clientContext, apiObj, ref), nil
 }
 
@@ -97,6 +167,9 @@ func (c *OrgRepositoriesClient) Create(ctx context.Context, ref gitprovider.OrgR
 // If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
 // If req is already the actual state, this is a no-op (actionTaken == false).
 func (c *OrgRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.OrgRepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryReconcileOption) (gitprovider.OrgRepository, bool, error) {
+	// Use the organization defaults for the unset fields, instead of the built-in defaults
+	c.repoDefaults.For(ref.OrganizationRef).ApplyToRepositoryInfo(&req)
+
 	// First thing, validate and default the request to ensure a valid and fully-populated object
 	// (to minimize any possible diffs between desired and actual state)
 	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
@@ -107,17 +180,44 @@ func (c *OrgRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.O
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := c.Create(ctx, ref, req, toCreateOpts(opts...)...)
-			return resp, true, err
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateProject)
+			return resp, true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
 		return nil, false, err
 	}
-	actionTaken, err := reconcileRepository(ctx, actual, req)
+	actionTaken, err := reconcileRepository(ctx, actual, req, c.dryRun)
 	return actual, actionTaken, err
 }
 
+// CreateFromTemplate creates a repository for the given organization from the template repository,
+// like Create with RepositoryCreateOptions.TemplateRepository.
+func (c *OrgRepositoriesClient) CreateFromTemplate(ctx context.Context, ref gitprovider.OrgRepositoryRef, template gitprovider.RepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.OrgRepository, error) {
+	return c.Create(ctx, ref, req, append(opts, &gitprovider.RepositoryCreateOptions{TemplateRepository: template})...)
+}
+
+// DownloadArchive returns an archive of the repository contents at the given Git reference,
+// which can be a branch, tag or commit SHA. If gitRef is empty, the default branch is used.
+//
+// The caller is responsible for closing the returned reader.
+// ErrNotFound is returned if the repository or the reference does not exist.
+func (c *OrgRepositoriesClient) DownloadArchive(ctx context.Context, ref gitprovider.OrgRepositoryRef, gitRef string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	// Make sure the RepositoryRef is valid
+	if err := validateOrgRepositoryRef(ref, c.domain); err != nil {
+		return nil, err
+	}
+	return c.c.DownloadArchive(ctx, getRepoPath(ref), gitRef, format)
+}
+
 //nolint
 func createProject(ctx context.Context, c gitlabClient, ref gitprovider.RepositoryRef, groupName string, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (*gitlab.Project, error) {
 	// First thing, validate and default the request to ensure a valid and fully-populated object
@@ -140,23 +240,104 @@ func createProject(ctx context.Context, c gitlabClient, ref gitprovider.Reposito
 		return nil, err
 	}
 	apiOpts := gitlab.CreateProjectOptions{
-		InitializeWithReadme: o.AutoInit,
+		InitializeWithReadme:         o.AutoInit,
+		RemoveSourceBranchAfterMerge: req.DeleteBranchOnMerge,
+	}
+	if req.MergeMethods != nil {
+		apiOpts.MergeMethod = &data.MergeMethod
+		apiOpts.SquashOption = &data.SquashOption
+	}
+	if tmpl := o.TemplateRepository; tmpl != nil {
+		if tmpl.GetDomain() != ref.GetDomain() {
+			return nil, fmt.Errorf("template repository %s must be on %s: %w", tmpl.String(), ref.GetDomain(), gitprovider.ErrInvalidArgument)
+		}
+		tmplProject, err := c.GetUserProject(ctx, getRepoPath(tmpl))
+		if err != nil {
+			return nil, err
+		}
+		apiOpts.UseCustomTemplate = gitlab.Bool(true)
+		apiOpts.TemplateProjectID = &tmplProject.ID
+		if tmplProject.Namespace != nil && tmplProject.Namespace.Kind == "group" {
+			apiOpts.GroupWithProjectTemplatesID = &tmplProject.Namespace.ID
+		}
 	}
 
-	return c.CreateProject(ctx, &data, &apiOpts)
+	project, err := c.CreateProject(ctx, &data, &apiOpts)
+	if err != nil {
+		return nil, err
+	}
+	if o.AutoInit != nil && *o.AutoInit && (o.LicenseTemplate != nil || o.GitignoreTemplate != nil) {
+		if err := commitTemplateFiles(ctx, c, project, o); err != nil {
+			return nil, err
+		}
+	}
+	// Projects can't be created archived, hence archive them last
+	if req.Archived != nil && *req.Archived {
+		return c.SetProjectArchived(ctx, project.ID, true)
+	}
+	return project, nil
 }
 
-func reconcileRepository(ctx context.Context, actual gitprovider.UserRepository, req gitprovider.RepositoryInfo) (bool, error) {
+// commitTemplateFiles adds the LICENSE and .gitignore files of the templates in o to the default
+// branch of the project, as GitLab can't add them at creation.
+func commitTemplateFiles(ctx context.Context, c gitlabClient, project *gitlab.Project, o gitprovider.RepositoryCreateOptions) error {
+	var actions []*gitlab.CommitActionOptions
+	if o.LicenseTemplate != nil {
+		// GET /templates/licenses/{key}
+		license, _, err := c.Client().LicenseTemplates.GetLicenseTemplate(string(*o.LicenseTemplate), &gitlab.GetLicenseTemplateOptions{
+			Project: &project.Name,
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return handleHTTPError(err)
+		}
+		actions = append(actions, &gitlab.CommitActionOptions{
+			Action:   gitlab.FileAction(gitlab.FileCreate),
+			FilePath: gitlab.String("LICENSE"),
+			Content:  &license.Content,
+		})
+	}
+	if o.GitignoreTemplate != nil {
+		// GET /templates/gitignores/{key}
+		gitignore, _, err := c.Client().GitIgnoreTemplates.GetTemplate(*o.GitignoreTemplate, gitlab.WithContext(ctx))
+		if err != nil {
+			return handleHTTPError(err)
+		}
+		actions = append(actions, &gitlab.CommitActionOptions{
+			Action:   gitlab.FileAction(gitlab.FileCreate),
+			FilePath: gitlab.String(".gitignore"),
+			Content:  &gitignore.Content,
+		})
+	}
+
+	// POST /projects/{project}/repository/commits
+	_, _, err := c.Client().Commits.CreateCommit(project.ID, &gitlab.CreateCommitOptions{
+		Branch:        &project.DefaultBranch,
+		CommitMessage: gitlab.String("Add license and .gitignore"),
+		Actions:       actions,
+	}, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+func reconcileRepository(ctx context.Context, actual gitprovider.UserRepository, req gitprovider.RepositoryInfo, dryRun bool) (bool, error) {
 	// If the desired matches the actual state, just return the actual state
 	if req.Equals(actual.Get()) {
 		return false, nil
 	}
+	change := gitprovider.NewUpdateChange(actual.Repository(), actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
 	// Populate the desired state to the current-actual object
 	if err := actual.Set(req); err != nil {
 		return false, err
 	}
 	// Apply the desired state by running Update
-	return true, actual.Update(ctx)
+	if err := actual.Update(ctx); err != nil {
+		return true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateProject)
+	return true, nil
 }
 
 func toCreateOpts(opts ...gitprovider.RepositoryReconcileOption) []gitprovider.RepositoryCreateOption {
@@ -167,3 +348,15 @@ func toCreateOpts(opts ...gitprovider.RepositoryReconcileOption) []gitprovider.R
 	}
 	return createOpts
 }
+
+// waitForProject waits for a created project to become visible, if WithWaitAfterCreate is set.
+func (c *clientContext) waitForProject(ctx context.Context, apiObj *gitlab.Project) error {
+	if c.waitAfterCreate == 0 {
+		return nil
+	}
+	waiter := gitprovider.NewVisibilityWaiter(gitprovider.ProviderGitLab, c.clock)
+	return waiter.WaitUntilVisible(ctx, func(ctx context.Context) error {
+		_, err := c.c.GetUserProject(ctx, apiObj.PathWithNamespace)
+		return err
+	}, c.waitAfterCreate)
+}
@@ -19,6 +19,9 @@ package gitlab
 import (
 	"context"
 	"errors"
+	"io"
+
+	"github.com/xanzy/go-gitlab"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 )
@@ -31,10 +34,11 @@ type UserRepositoriesClient struct {
 	*clientContext
 }
 
-// Get returns the repository at the given path.
+// Get returns the repository at the given path. The statistics requested with
+// RepositoryGetOptions.WithStats take three more requests.
 //
 // ErrNotFound is returned if the resource does not exist.
-func (c *UserRepositoriesClient) Get(ctx context.Context, ref gitprovider.UserRepositoryRef) (gitprovider.UserRepository, error) {
+func (c *UserRepositoriesClient) Get(ctx context.Context, ref gitprovider.UserRepositoryRef, opts ...gitprovider.RepositoryGetOption) (gitprovider.UserRepository, error) {
 	// Make sure the UserRepositoryRef is valid
 	if err := validateUserRepositoryRef(ref, c.domain); err != nil {
 		return nil, err
@@ -44,20 +48,32 @@ func (c *UserRepositoriesClient) Get(ctx context.Context, ref gitprovider.UserRe
 	if err != nil {
 		return nil, err
 	}
-	return newUserProject(c.clientContext, apiObj, ref), nil
+	repo := newUserProject(c.clientContext, apiObj, ref)
+	if o := gitprovider.MakeRepositoryGetOptions(opts...); o.WithStats != nil && *o.WithStats {
+		if repo.stats, err = getRepositoryStats(ctx, c.clientContext, apiObj.ID); err != nil {
+			return nil, err
+		}
+	}
+	return repo, nil
 }
 
 // List all repositories in the given organization.
 //
+// The filters are passed to GitLab, and checked again on the received list.
+//
 // List returns all available repositories, using multiple paginated requests if needed.
-func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserRef) ([]gitprovider.UserRepository, error) {
+func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserRef, opts ...gitprovider.RepositoryListOption) ([]gitprovider.UserRepository, error) {
 	// Make sure the UserRef is valid
 	if err := validateUserRef(ref, c.domain); err != nil {
 		return nil, err
 	}
+	o, err := gitprovider.MakeRepositoryListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
 
 	// GET /users/{username}/repos
-	apiObjs, err := c.c.ListUserProjects(ctx, ref.UserLogin)
+	apiObjs, err := c.c.ListUserProjects(ctx, ref.UserLogin, userProjectsListOptions(o))
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +82,9 @@ func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserR
 	repos := make([]gitprovider.UserRepository, 0, len(apiObjs))
 	for _, apiObj := range apiObjs {
 		// apiObj is already validated at ListUserRepos
+		if !o.Matches(apiObj.Name, repositoryFromAPI(apiObj), lastActivityFromAPI(apiObj)) {
+			continue
+		}
 		repos = append(repos, newUserProject(c.clientContext, apiObj, gitprovider.UserRepositoryRef{
 			UserRef:        ref,
 			RepositoryName: apiObj.Name,
@@ -74,6 +93,52 @@ func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserR
 	return repos, nil
 }
 
+// ListIter returns an iterator over the repositories of the given user, which fetches one page
+// at a time. The filters are handled like in List.
+func (c *UserRepositoriesClient) ListIter(ctx context.Context, ref gitprovider.UserRef, opts ...gitprovider.RepositoryListOption) *gitprovider.UserRepositoryIterator {
+	o, optsErr := gitprovider.MakeRepositoryListOptions(opts...)
+	listOpts := userProjectsListOptions(o)
+	return gitprovider.NewUserRepositoryIterator(ctx, func(ctx context.Context, page int) ([]gitprovider.UserRepository, int, error) {
+		if optsErr != nil {
+			return nil, 0, optsErr
+		}
+		// Make sure the UserRef is valid
+		if err := validateUserRef(ref, c.domain); err != nil {
+			return nil, 0, err
+		}
+
+		// GET /users/{username}/projects
+		apiObjs, next, err := c.c.ListUserProjectsPage(ctx, ref.UserLogin, listOpts, page)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		repos := make([]gitprovider.UserRepository, 0, len(apiObjs))
+		for _, apiObj := range apiObjs {
+			// apiObj is already validated at ListUserProjectsPage
+			if !o.Matches(apiObj.Name, repositoryFromAPI(apiObj), lastActivityFromAPI(apiObj)) {
+				continue
+			}
+			repos = append(repos, newUserProject(c.clientContext, apiObj, gitprovider.UserRepositoryRef{
+				UserRef:        ref,
+				RepositoryName: apiObj.Name,
+			}))
+		}
+		return repos, next, nil
+	})
+}
+
+// userProjectsListOptions returns the parameters of "GET /users/{username}/projects" for the
+// filters, which GitLab all supports.
+func userProjectsListOptions(o gitprovider.RepositoryListOptions) gitlab.ListProjectsOptions {
+	return gitlab.ListProjectsOptions{
+		Search:            o.NamePrefix,
+		Visibility:        visibilityToAPI(o.Visibility),
+		Archived:          o.Archived,
+		LastActivityAfter: o.ActiveSince,
+	}
+}
+
 // Create creates a repository for the given organization, with the data and options
 //
 // ErrAlreadyExists will be returned if the resource already exists.
@@ -91,6 +156,9 @@ func (c *UserRepositoriesClient) Create(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+	if err := c.waitForProject(ctx, apiObj); err != nil {
+		return nil, err
+	}
 	return newUserProject(c.clientContext, apiObj, ref), nil
 }
 
@@ -110,14 +178,54 @@ func (c *UserRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := c.Create(ctx, ref, req, toCreateOpts(opts...)...)
-			return resp, true, err
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateProject)
+			return resp, true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
 		return nil, false, err
 	}
 
-	actionTaken, err := reconcileRepository(ctx, actual, req)
+	actionTaken, err := reconcileRepository(ctx, actual, req, c.dryRun)
 	return actual, actionTaken, err
 }
+
+// CreateFromTemplate creates a repository for the given user from the template repository,
+// like Create with RepositoryCreateOptions.TemplateRepository.
+func (c *UserRepositoriesClient) CreateFromTemplate(ctx context.Context, ref gitprovider.UserRepositoryRef, template gitprovider.RepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.UserRepository, error) {
+	return c.Create(ctx, ref, req, append(opts, &gitprovider.RepositoryCreateOptions{TemplateRepository: template})...)
+}
+
+// DownloadArchive returns an archive of the repository contents at the given Git reference,
+// which can be a branch, tag or commit SHA. If gitRef is empty, the default branch is used.
+//
+// The caller is responsible for closing the returned reader.
+// ErrNotFound is returned if the repository or the reference does not exist.
+func (c *UserRepositoriesClient) DownloadArchive(ctx context.Context, ref gitprovider.UserRepositoryRef, gitRef string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	// Make sure the RepositoryRef is valid
+	if err := validateUserRepositoryRef(ref, c.domain); err != nil {
+		return nil, err
+	}
+	return c.c.DownloadArchive(ctx, getRepoPath(ref), gitRef, format)
+}
+
+// GetUserLogin returns the UserRef of the authenticated user.
+func (c *UserRepositoriesClient) GetUserLogin(ctx context.Context) (gitprovider.UserRef, error) {
+	// GET /user
+	user, err := c.c.GetCurrentUser(ctx)
+	if err != nil {
+		return gitprovider.UserRef{}, err
+	}
+	return gitprovider.UserRef{
+		Domain:    c.domain,
+		UserLogin: user.Username,
+	}, nil
+}
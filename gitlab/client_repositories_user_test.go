@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestUserRepositoriesClient_ListFiltered(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/users/user/projects", func(w http.ResponseWriter, r *http.Request) {
+		// The filters are passed to the server
+		query := r.URL.Query()
+		if query.Get("search") != "flux-" || query.Get("visibility") != "private" || query.Get("archived") != "false" || query.Get("last_activity_after") == "" {
+			t.Errorf("query = %v", query)
+		}
+		// The name is only matched as a prefix by the client
+		w.Write([]byte(`[
+			{"name": "flux-system", "visibility": "private", "last_activity_at": "2021-03-01T00:00:00Z"},
+			{"name": "old-flux-system", "visibility": "private", "last_activity_at": "2021-03-01T00:00:00Z"}
+		]`))
+	})
+	c := newTestClient(t, mux)
+	activeSince := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	repos, err := c.UserRepositories().List(context.Background(), gitprovider.UserRef{Domain: c.domain, UserLogin: "user"}, &gitprovider.RepositoryListOptions{
+		NamePrefix:  gitprovider.StringVar("flux-"),
+		Visibility:  gitprovider.RepositoryVisibilityVar(gitprovider.RepositoryVisibilityPrivate),
+		Archived:    gitprovider.BoolVar(false),
+		ActiveSince: &activeSince,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].Repository().GetRepository() != "flux-system" {
+		t.Errorf("List() = %v, want only flux-system", repos)
+	}
+}
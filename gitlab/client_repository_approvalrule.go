@@ -0,0 +1,231 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// ApprovalRuleClient implements the gitprovider.ApprovalRuleClient interface.
+var _ gitprovider.ApprovalRuleClient = &ApprovalRuleClient{}
+
+// ApprovalRuleClient operates on the project-level merge request approval rules of a specific
+// project. Approval rules are a GitLab Premium feature.
+type ApprovalRuleClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists the approval rules of the project.
+func (c *ApprovalRuleClient) List(ctx context.Context) ([]gitprovider.ApprovalRuleInfo, error) {
+	apiObjs, err := listApprovalRules(ctx, c.clientContext, c.ref)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]gitprovider.ApprovalRuleInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		rules = append(rules, approvalRuleFromAPI(apiObj))
+	}
+	return rules, nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+//
+// ErrNotFound is returned if a user or group doesn't exist, and ErrInvalidArgument if a branch
+// isn't protected.
+func (c *ApprovalRuleClient) Reconcile(ctx context.Context, req gitprovider.ApprovalRuleInfo) (gitprovider.ApprovalRuleInfo, bool, error) {
+	// First thing, validate and default the request to ensure a valid and fully-populated object
+	// (to minimize any possible diffs between desired and actual state)
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return gitprovider.ApprovalRuleInfo{}, false, err
+	}
+
+	existing, err := c.getByName(ctx, req.Name)
+	if err != nil {
+		return gitprovider.ApprovalRuleInfo{}, false, err
+	}
+	var change gitprovider.Change
+	if existing == nil {
+		change = gitprovider.NewCreateChange(c.ref, req)
+	} else {
+		actual := approvalRuleFromAPI(existing)
+		// If the desired matches the actual state, just return the actual state
+		if req.Equals(actual) {
+			return actual, false, nil
+		}
+		change = gitprovider.NewUpdateChange(c.ref, actual, req)
+	}
+	// In dry-run mode, only report what would be created or updated
+	if c.dryRun {
+		return req, true, gitprovider.NewDryRunError(change)
+	}
+
+	userIDs, groupIDs, branchIDs, err := c.approvalRuleToAPI(ctx, req)
+	if err != nil {
+		return gitprovider.ApprovalRuleInfo{}, false, err
+	}
+	var apiObj *gitlab.ProjectApprovalRule
+	if existing == nil {
+		// POST /projects/{id}/approval_rules
+		apiObj, _, err = c.c.Client().Projects.CreateProjectApprovalRule(getRepoPath(c.ref), &gitlab.CreateProjectLevelRuleOptions{
+			Name:               gitlab.String(req.Name),
+			ApprovalsRequired:  gitlab.Int(req.RequiredApprovals),
+			UserIDs:            &userIDs,
+			GroupIDs:           &groupIDs,
+			ProtectedBranchIDs: &branchIDs,
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return gitprovider.ApprovalRuleInfo{}, true, handleHTTPError(err)
+		}
+		gitprovider.RecordChange(ctx, change, apiCallCreateApprovalRule)
+	} else {
+		// PUT /projects/{id}/approval_rules/{approval_rule_id}
+		apiObj, _, err = c.c.Client().Projects.UpdateProjectApprovalRule(getRepoPath(c.ref), existing.ID, &gitlab.UpdateProjectLevelRuleOptions{
+			Name:               gitlab.String(req.Name),
+			ApprovalsRequired:  gitlab.Int(req.RequiredApprovals),
+			UserIDs:            &userIDs,
+			GroupIDs:           &groupIDs,
+			ProtectedBranchIDs: &branchIDs,
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return gitprovider.ApprovalRuleInfo{}, true, handleHTTPError(err)
+		}
+		gitprovider.RecordChange(ctx, change, apiCallUpdateApprovalRule)
+	}
+	return approvalRuleFromAPI(apiObj), true, nil
+}
+
+// Delete deletes the rule with the given name.
+//
+// ErrNotFound is returned if there is no rule with the name.
+func (c *ApprovalRuleClient) Delete(ctx context.Context, name string) error {
+	existing, err := c.getByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("approval rule %q: %w", name, gitprovider.ErrNotFound)
+	}
+	// DELETE /projects/{id}/approval_rules/{approval_rule_id}
+	_, err = c.c.Client().Projects.DeleteProjectApprovalRule(getRepoPath(c.ref), existing.ID, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+// getByName returns the approval rule with the given name, or nil if there is none.
+func (c *ApprovalRuleClient) getByName(ctx context.Context, name string) (*gitlab.ProjectApprovalRule, error) {
+	apiObjs, err := listApprovalRules(ctx, c.clientContext, c.ref)
+	if err != nil {
+		return nil, err
+	}
+	for _, apiObj := range apiObjs {
+		if apiObj.Name == name {
+			return apiObj, nil
+		}
+	}
+	return nil, nil
+}
+
+// approvalRuleToAPI resolves the IDs of the users, groups and protected branches of the rule.
+func (c *ApprovalRuleClient) approvalRuleToAPI(ctx context.Context, req gitprovider.ApprovalRuleInfo) ([]int, []int, []int, error) {
+	userIDs := make([]int, 0, len(req.Users))
+	for _, login := range req.Users {
+		userID, err := c.getUserID(ctx, login)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	groupIDs := make([]int, 0, len(req.Groups))
+	for _, path := range req.Groups {
+		group, err := c.c.GetGroup(ctx, path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		groupIDs = append(groupIDs, group.ID)
+	}
+	branchIDs := make([]int, 0, len(req.Branches))
+	for _, branch := range req.Branches {
+		// GET /projects/{id}/protected_branches/{name}
+		apiObj, _, err := c.c.Client().ProtectedBranches.GetProtectedBranch(getRepoPath(c.ref), branch, gitlab.WithContext(ctx))
+		if err := handleHTTPError(err); errors.Is(err, gitprovider.ErrNotFound) {
+			return nil, nil, nil, fmt.Errorf("branch %q isn't protected: %w", branch, gitprovider.ErrInvalidArgument)
+		} else if err != nil {
+			return nil, nil, nil, err
+		}
+		branchIDs = append(branchIDs, apiObj.ID)
+	}
+	return userIDs, groupIDs, branchIDs, nil
+}
+
+// listApprovalRules lists the project-level approval rules of the project.
+func listApprovalRules(ctx context.Context, c *clientContext, ref gitprovider.RepositoryRef) ([]*gitlab.ProjectApprovalRule, error) {
+	// GET /projects/{id}/approval_rules
+	apiObjs, _, err := c.c.Client().Projects.GetProjectApprovalRules(getRepoPath(ref), gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return apiObjs, nil
+}
+
+// approvalRuleFromAPI returns the approval rule, defaulted for comparison.
+func approvalRuleFromAPI(apiObj *gitlab.ProjectApprovalRule) gitprovider.ApprovalRuleInfo {
+	info := gitprovider.ApprovalRuleInfo{
+		Name:              apiObj.Name,
+		RequiredApprovals: apiObj.ApprovalsRequired,
+	}
+	for _, user := range apiObj.Users {
+		info.Users = append(info.Users, user.Username)
+	}
+	for _, group := range apiObj.Groups {
+		info.Groups = append(info.Groups, group.FullPath)
+	}
+	for _, branch := range apiObj.ProtectedBranches {
+		info.Branches = append(info.Branches, branch.Name)
+	}
+	for _, user := range apiObj.EligibleApprovers {
+		info.EligibleApprovers = append(info.EligibleApprovers, user.Username)
+	}
+	sort.Strings(info.EligibleApprovers)
+	info.Default()
+	return info
+}
+
+// approvalRuleApplies returns whether the approval rule applies to the branch, i.e. it has no
+// protected branches, or one of them matches the branch.
+func approvalRuleApplies(apiObj *gitlab.ProjectApprovalRule, branch string) bool {
+	if len(apiObj.ProtectedBranches) == 0 {
+		return true
+	}
+	for _, protected := range apiObj.ProtectedBranches {
+		if protectedBranchMatches(protected.Name, branch) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestApprovalRuleClient_Reconcile(t *testing.T) {
+	var created map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/group/repo/approval_rules", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`[{"id": 1, "name": "security", "approvals_required": 1, "users": [{"username": "alice"}], "eligible_approvers": [{"username": "alice"}]}]`))
+		case http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 2, "name": "release", "approvals_required": 2,
+				"groups": [{"id": 7, "full_path": "group/maintainers"}], "protected_branches": [{"id": 5, "name": "main"}],
+				"eligible_approvers": [{"username": "carol"}, {"username": "bob"}]}`))
+		}
+	})
+	mux.HandleFunc("/api/v4/groups/group/maintainers", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 7, "name": "maintainers", "path": "maintainers", "full_path": "group/maintainers"}`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/protected_branches/main", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 5, "name": "main"}`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: client.domain, Organization: "group"}, RepositoryName: "repo"}
+	c := &ApprovalRuleClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	// The existing rule is up to date
+	resp, actionTaken, err := c.Reconcile(ctx, gitprovider.ApprovalRuleInfo{Name: "security", RequiredApprovals: 1, Users: []string{"alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actionTaken || !reflect.DeepEqual(resp.EligibleApprovers, []string{"alice"}) {
+		t.Errorf("Reconcile() = %+v, %v, want no action", resp, actionTaken)
+	}
+
+	resp, actionTaken, err = c.Reconcile(ctx, gitprovider.ApprovalRuleInfo{Name: "release", RequiredApprovals: 2, Groups: []string{"group/maintainers"}, Branches: []string{"main"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gitprovider.ApprovalRuleInfo{
+		Name:              "release",
+		RequiredApprovals: 2,
+		Groups:            []string{"group/maintainers"},
+		Branches:          []string{"main"},
+		EligibleApprovers: []string{"bob", "carol"},
+	}
+	if !actionTaken || !reflect.DeepEqual(resp, want) {
+		t.Errorf("Reconcile() = %+v, %v, want %+v, true", resp, actionTaken, want)
+	}
+	if created["name"] != "release" || !reflect.DeepEqual(created["group_ids"], []interface{}{float64(7)}) || !reflect.DeepEqual(created["protected_branch_ids"], []interface{}{float64(5)}) {
+		t.Errorf("created rule %v", created)
+	}
+}
+
+func TestBranchClient_GetRulesApprovals(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/group/repo/protected_branches", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name": "main", "allow_force_push": true, "push_access_levels": [{"access_level": 40}], "code_owner_approval_required": true}]`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/approval_rules", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id": 1, "name": "all", "approvals_required": 1},
+			{"id": 2, "name": "main", "approvals_required": 2, "protected_branches": [{"id": 5, "name": "main"}]}
+		]`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: client.domain, Organization: "group"}, RepositoryName: "repo"}
+	c := &BranchClient{clientContext: client.clientContext, ref: ref}
+
+	tests := []struct {
+		branch string
+		want   gitprovider.BranchRules
+	}{
+		{branch: "main", want: gitprovider.BranchRules{BlockDeletion: true, RequiredApprovals: 2, RequireCodeOwnerReview: true}},
+		{branch: "feature", want: gitprovider.BranchRules{RequiredApprovals: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			got, err := c.GetRules(context.Background(), tt.branch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetRules() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
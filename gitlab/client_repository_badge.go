@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// BadgeClient implements the gitprovider.BadgeClient interface.
+var _ gitprovider.BadgeClient = &BadgeClient{}
+
+// BadgeClient generates the URLs of the status badges for a specific project.
+type BadgeClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// URL returns the canonical URL of the badge image described by req: the pipeline status
+// badge of a branch, or the latest release badge. The workflow of req is ignored.
+//
+// ErrNotFound is returned if the branch doesn't exist, or the project has no releases.
+func (c *BadgeClient) URL(ctx context.Context, req gitprovider.BadgeRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	switch req.Kind {
+	case gitprovider.BadgeKindPipeline:
+		branch := req.Branch
+		if len(branch) == 0 {
+			// GET /projects/{id}
+			apiObj, _, err := c.c.Client().Projects.GetProject(getRepoPath(c.ref), nil, gitlab.WithContext(ctx))
+			if err != nil {
+				return "", handleHTTPError(err)
+			}
+			branch = apiObj.DefaultBranch
+		} else {
+			// GET /projects/{id}/repository/branches/{branch}
+			if _, _, err := c.c.Client().Branches.GetBranch(getRepoPath(c.ref), branch, gitlab.WithContext(ctx)); err != nil {
+				return "", handleHTTPError(err)
+			}
+		}
+		return fmt.Sprintf("%s/badges/%s/pipeline.svg", c.ref.String(), branch), nil
+	default:
+		// GET /projects/{id}/releases
+		apiObjs, _, err := c.c.Client().Releases.ListReleases(getRepoPath(c.ref), &gitlab.ListReleasesOptions{PerPage: 1}, gitlab.WithContext(ctx))
+		if err != nil {
+			return "", handleHTTPError(err)
+		}
+		if len(apiObjs) == 0 {
+			return "", fmt.Errorf("no release in repository %s: %w", c.ref.String(), gitprovider.ErrNotFound)
+		}
+		return fmt.Sprintf("%s/-/badges/release.svg", c.ref.String()), nil
+	}
+}
@@ -18,6 +18,11 @@ package gitlab
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/xanzy/go-gitlab"
@@ -46,3 +51,224 @@ func (c *BranchClient) Create(_ context.Context, branch, sha string) error {
 
 	return nil
 }
+
+// Delete deletes the branch with the given name.
+func (c *BranchClient) Delete(ctx context.Context, branch string) error {
+	// DELETE /projects/{id}/repository/branches/{branch}
+	_, err := c.c.Client().Branches.DeleteBranch(getRepoPath(c.ref), branch, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+// List lists all branches in the repository, including their protection status.
+func (c *BranchClient) List(ctx context.Context) ([]gitprovider.Branch, error) {
+	var apiObjs []*gitlab.Branch
+	opts := &gitlab.ListBranchesOptions{}
+	err := allBranchPages(opts, func() (*gitlab.Response, error) {
+		// GET /projects/{id}/repository/branches
+		pageObjs, resp, listErr := c.c.Client().Branches.ListBranches(getRepoPath(c.ref), opts, gitlab.WithContext(ctx))
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make([]gitprovider.Branch, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		branches = append(branches, newBranch(c.clientContext, apiObj))
+	}
+	return branches, nil
+}
+
+// SetDefault makes the branch with the given name the default branch of the repository.
+func (c *BranchClient) SetDefault(ctx context.Context, branch string) error {
+	// PUT /projects/{id}
+	_, _, err := c.c.Client().Projects.EditProject(getRepoPath(c.ref), &gitlab.EditProjectOptions{
+		DefaultBranch: &branch,
+	}, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+// GetRules returns the rules of the protected branches which match the branch, by name or
+// wildcard. Protected branches can't be deleted, and without push access changes need merge
+// requests. The required approvals are the highest of the approval rules which apply to the
+// branch, which GitLab Free doesn't have.
+func (c *BranchClient) GetRules(ctx context.Context, branch string) (gitprovider.BranchRules, error) {
+	opts := &gitlab.ListProtectedBranchesOptions{}
+	var apiObjs []*gitlab.ProtectedBranch
+	for {
+		// GET /projects/{id}/protected_branches
+		pageObjs, resp, err := c.c.Client().ProtectedBranches.ListProtectedBranches(getRepoPath(c.ref), opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return gitprovider.BranchRules{}, handleHTTPError(err)
+		}
+		apiObjs = append(apiObjs, pageObjs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	rules := gitprovider.BranchRules{}
+	for _, apiObj := range apiObjs {
+		if !protectedBranchMatches(apiObj.Name, branch) {
+			continue
+		}
+		rules.BlockDeletion = true
+		if !apiObj.AllowForcePush {
+			rules.BlockForcePushes = true
+		}
+		if !canPush(apiObj.PushAccessLevels) {
+			rules.RequirePullRequest = true
+		}
+		if apiObj.CodeOwnerApprovalRequired {
+			rules.RequireCodeOwnerReview = true
+		}
+	}
+
+	approvalRules, err := listApprovalRules(ctx, c.clientContext, c.ref)
+	switch {
+	case errors.Is(err, gitprovider.ErrNotFound):
+		// Without approval rules, only the protected branches apply
+		return rules, nil
+	case err != nil:
+		return gitprovider.BranchRules{}, err
+	}
+	for _, apiObj := range approvalRules {
+		if approvalRuleApplies(apiObj, branch) && apiObj.ApprovalsRequired > rules.RequiredApprovals {
+			rules.RequiredApprovals = apiObj.ApprovalsRequired
+		}
+	}
+	return rules, nil
+}
+
+// protectedBranchMatches returns whether the name or wildcard pattern of a protected branch
+// matches the branch. The wildcard "*" matches any characters.
+func protectedBranchMatches(pattern, branch string) bool {
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	matched, _ := regexp.MatchString("^"+expr+"$", branch)
+	return matched
+}
+
+// canPush returns whether any role, user or group is allowed to push to the protected branch.
+func canPush(levels []*gitlab.BranchAccessDescription) bool {
+	for _, level := range levels {
+		if level.AccessLevel != gitlab.NoPermissions || level.UserID != 0 || level.GroupID != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncForkWithUpstream brings the branch of this fork up to date with the branch of its parent.
+// If the fork has a pull mirror, a pull is started, which is asynchronous: the returned Operation
+// follows the import status of the project. Otherwise, a merge request from the upstream branch
+// into the branch of the fork is created and merged.
+func (c *BranchClient) SyncForkWithUpstream(ctx context.Context, branch string) (gitprovider.ForkSyncResult, error) {
+	// GET /projects/{id}
+	project, _, err := c.c.Client().Projects.GetProject(getRepoPath(c.ref), &gitlab.GetProjectOptions{}, gitlab.WithContext(ctx))
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, handleHTTPError(err)
+	}
+	if project.ForkedFromProject == nil {
+		return gitprovider.ForkSyncResult{}, fmt.Errorf("repository %s isn't a fork: %w", c.ref.String(), gitprovider.ErrInvalidArgument)
+	}
+	forkSHA, err := c.branchSHA(ctx, project.ID, branch)
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, err
+	}
+	upstreamSHA, err := c.branchSHA(ctx, project.ForkedFromProject.ID, branch)
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, err
+	}
+	result := gitprovider.ForkSyncResult{Branch: branch, Sha: forkSHA}
+
+	if project.Mirror {
+		// POST /projects/{id}/mirror/pull
+		if _, err := c.c.Client().Projects.StartMirroringProject(project.ID, gitlab.WithContext(ctx)); err != nil {
+			return gitprovider.ForkSyncResult{}, handleHTTPError(err)
+		}
+		result.Strategy = gitprovider.ForkSyncStrategyMirrorPull
+		result.Sha = upstreamSHA
+		result.Updated = forkSHA != upstreamSHA
+		result.Operation = c.mirrorPullOperation(project.ID)
+		return result, nil
+	}
+
+	result.Strategy = gitprovider.ForkSyncStrategyMergeRequest
+	result.Operation = gitprovider.NewCompletedOperation(gitprovider.OperationStatus{State: gitprovider.OperationStateSucceeded})
+	if forkSHA == upstreamSHA {
+		return result, nil
+	}
+	title := fmt.Sprintf("Synchronize %s with upstream", branch)
+	// POST /projects/{id}/merge_requests
+	mr, _, err := c.c.Client().MergeRequests.CreateMergeRequest(project.ForkedFromProject.ID, &gitlab.CreateMergeRequestOptions{
+		Title:           &title,
+		SourceBranch:    &branch,
+		TargetBranch:    &branch,
+		TargetProjectID: &project.ID,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return gitprovider.ForkSyncResult{}, handleHTTPError(err)
+	}
+	// PUT /projects/{id}/merge_requests/{merge_request_iid}/merge
+	merged, _, err := c.c.Client().MergeRequests.AcceptMergeRequest(project.ID, mr.IID, &gitlab.AcceptMergeRequestOptions{}, gitlab.WithContext(ctx))
+	if err != nil {
+		// Don't leave the merge request open
+		closeEvent := "close"
+		// PUT /projects/{id}/merge_requests/{merge_request_iid}
+		_, _, _ = c.c.Client().MergeRequests.UpdateMergeRequest(project.ID, mr.IID, &gitlab.UpdateMergeRequestOptions{
+			StateEvent: &closeEvent,
+		}, gitlab.WithContext(ctx))
+
+		glErrorResponse := &gitlab.ErrorResponse{}
+		if errors.As(err, &glErrorResponse) && (glErrorResponse.Response.StatusCode == http.StatusMethodNotAllowed ||
+			glErrorResponse.Response.StatusCode == http.StatusNotAcceptable) {
+			return gitprovider.ForkSyncResult{}, fmt.Errorf("branch %q: %s: %w", branch, glErrorResponse.Message, gitprovider.ErrForkDiverged)
+		}
+		return gitprovider.ForkSyncResult{}, handleHTTPError(err)
+	}
+	result.Sha = merged.MergeCommitSHA
+	if result.Sha == "" {
+		// The merge request was fast-forwarded
+		result.Sha = upstreamSHA
+	}
+	result.Updated = true
+	return result, nil
+}
+
+// mirrorPullOperation returns the Operation of the pull of the mirror of the project, which
+// follows the import status of the project.
+func (c *BranchClient) mirrorPullOperation(pid int) gitprovider.Operation {
+	return gitprovider.NewOperation(c.clock, 0, func(ctx context.Context) (gitprovider.OperationStatus, error) {
+		// GET /projects/{id}
+		project, _, err := c.c.Client().Projects.GetProject(pid, &gitlab.GetProjectOptions{}, gitlab.WithContext(ctx))
+		if err != nil {
+			return gitprovider.OperationStatus{}, handleHTTPError(err)
+		}
+		switch project.ImportStatus {
+		case "scheduled":
+			return gitprovider.OperationStatus{State: gitprovider.OperationStatePending}, nil
+		case "started":
+			return gitprovider.OperationStatus{State: gitprovider.OperationStateRunning}, nil
+		case "failed":
+			return gitprovider.OperationStatus{State: gitprovider.OperationStateFailed, Message: project.ImportError}, nil
+		default:
+			// "finished", or "none" if the pull didn't need to import anything
+			return gitprovider.OperationStatus{State: gitprovider.OperationStateSucceeded}, nil
+		}
+	})
+}
+
+// branchSHA returns the SHA of the commit the branch of the given project points to.
+func (c *BranchClient) branchSHA(ctx context.Context, pid int, branch string) (string, error) {
+	// GET /projects/{id}/repository/branches/{branch}
+	apiObj, _, err := c.c.Client().Branches.GetBranch(pid, branch, gitlab.WithContext(ctx))
+	if err != nil {
+		return "", handleHTTPError(err)
+	}
+	if apiObj.Commit == nil {
+		return "", fmt.Errorf("branch %q has no commit: %w", branch, gitprovider.ErrInvalidServerData)
+	}
+	return apiObj.Commit.ID, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestBranchClient_GetRules(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/group/repo/protected_branches", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"name": "main", "allow_force_push": false, "push_access_levels": [{"access_level": 0}]},
+			{"name": "release-*", "allow_force_push": true, "push_access_levels": [{"access_level": 40}]}
+		]`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: client.domain, Organization: "group"}, RepositoryName: "repo"}
+	c := &BranchClient{clientContext: client.clientContext, ref: ref}
+
+	tests := []struct {
+		branch string
+		want   gitprovider.BranchRules
+	}{
+		{branch: "main", want: gitprovider.BranchRules{BlockDeletion: true, BlockForcePushes: true, RequirePullRequest: true}},
+		{branch: "release-1.0", want: gitprovider.BranchRules{BlockDeletion: true}},
+		{branch: "feature", want: gitprovider.BranchRules{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			got, err := c.GetRules(context.Background(), tt.branch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetRules() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
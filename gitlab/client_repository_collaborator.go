@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// CollaboratorClient implements the gitprovider.CollaboratorClient interface.
+var _ gitprovider.CollaboratorClient = &CollaboratorClient{}
+
+// CollaboratorClient operates on the direct members of a specific project.
+// Members are added directly; invitations are only pending for invited email addresses.
+type CollaboratorClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get returns the access of the project member with the given username.
+//
+// ErrNotFound is returned if the user isn't a direct member of the project.
+func (c *CollaboratorClient) Get(ctx context.Context, login string) (gitprovider.CollaboratorInfo, error) {
+	userID, err := c.getUserID(ctx, login)
+	if err != nil {
+		return gitprovider.CollaboratorInfo{}, err
+	}
+	// GET /projects/{id}/members/{user_id}
+	apiObj, _, err := c.c.Client().ProjectMembers.GetProjectMember(getRepoPath(c.ref), userID, gitlab.WithContext(ctx))
+	if err != nil {
+		return gitprovider.CollaboratorInfo{}, handleHTTPError(err)
+	}
+	return collaboratorFromAPI(apiObj)
+}
+
+// List lists the direct members of the project. Members inherited from groups are not included.
+//
+// List returns all available members, using multiple paginated requests if needed.
+func (c *CollaboratorClient) List(ctx context.Context) ([]gitprovider.CollaboratorInfo, error) {
+	var apiObjs []*gitlab.ProjectMember
+	opts := &gitlab.ListProjectMembersOptions{}
+	err := allProjectMemberPages(opts, func() (*gitlab.Response, error) {
+		// GET /projects/{id}/members
+		pageObjs, resp, listErr := c.c.Client().ProjectMembers.ListProjectMembers(getRepoPath(c.ref), opts, gitlab.WithContext(ctx))
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+
+	collaborators := make([]gitprovider.CollaboratorInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		collaborator, err := collaboratorFromAPI(apiObj)
+		if err != nil {
+			return nil, err
+		}
+		collaborators = append(collaborators, collaborator)
+	}
+	return collaborators, nil
+}
+
+// Add makes the user req.Login a member of the project, or changes the access level of an
+// existing member. GitLab doesn't invite existing users, so the returned invitation is always nil.
+func (c *CollaboratorClient) Add(ctx context.Context, req gitprovider.CollaboratorInfo) (*gitprovider.CollaboratorInvitation, error) {
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, err
+	}
+	permission, err := getGitlabPermission(*req.Permission)
+	if err != nil {
+		return nil, err
+	}
+	accessLevel := gitlab.AccessLevelValue(permission)
+	userID, err := c.getUserID(ctx, req.Login)
+	if err != nil {
+		return nil, err
+	}
+
+	// GET /projects/{id}/members/{user_id}
+	_, _, err = c.c.Client().ProjectMembers.GetProjectMember(getRepoPath(c.ref), userID, gitlab.WithContext(ctx))
+	if err := handleHTTPError(err); err != nil {
+		if !errors.Is(err, gitprovider.ErrNotFound) {
+			return nil, err
+		}
+		// POST /projects/{id}/members
+		_, _, err = c.c.Client().ProjectMembers.AddProjectMember(getRepoPath(c.ref), &gitlab.AddProjectMemberOptions{
+			UserID:      userID,
+			AccessLevel: &accessLevel,
+		}, gitlab.WithContext(ctx))
+		return nil, handleHTTPError(err)
+	}
+	// PUT /projects/{id}/members/{user_id}
+	_, _, err = c.c.Client().ProjectMembers.EditProjectMember(getRepoPath(c.ref), userID, &gitlab.EditProjectMemberOptions{
+		AccessLevel: &accessLevel,
+	}, gitlab.WithContext(ctx))
+	return nil, handleHTTPError(err)
+}
+
+// Remove removes the direct member with the given username from the project.
+//
+// ErrNotFound is returned if the user isn't a direct member of the project.
+func (c *CollaboratorClient) Remove(ctx context.Context, login string) error {
+	userID, err := c.getUserID(ctx, login)
+	if err != nil {
+		return err
+	}
+	// DELETE /projects/{id}/members/{user_id}
+	_, err = c.c.Client().ProjectMembers.DeleteProjectMember(getRepoPath(c.ref), userID, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+// ListInvitations lists the pending invitations of the project. The invitations are sent to
+// email addresses, which are returned as the login.
+func (c *CollaboratorClient) ListInvitations(ctx context.Context) ([]gitprovider.CollaboratorInvitation, error) {
+	var apiObjs []*gitlab.PendingInvite
+	opts := &gitlab.ListPendingInvitationsOptions{}
+	err := allPendingInvitationPages(opts, func() (*gitlab.Response, error) {
+		// GET /projects/{id}/invitations
+		pageObjs, resp, listErr := c.c.Client().Invites.ListPendingProjectInvitations(getRepoPath(c.ref), opts, gitlab.WithContext(ctx))
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+
+	invitations := make([]gitprovider.CollaboratorInvitation, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		permission, err := getGitProviderPermission(int(apiObj.AccessLevel))
+		if err != nil {
+			return nil, err
+		}
+		invitation := gitprovider.CollaboratorInvitation{
+			ID:         int64(apiObj.ID),
+			Login:      apiObj.InviteEmail,
+			Permission: *permission,
+		}
+		if apiObj.CreatedAt != nil {
+			invitation.CreatedAt = *apiObj.CreatedAt
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, nil
+}
+
+// CancelInvitation withdraws the pending invitation with the given ID. GitLab identifies
+// invitations by email address, so the invitation is looked up first.
+//
+// ErrNotFound is returned if there is no such pending invitation.
+func (c *CollaboratorClient) CancelInvitation(ctx context.Context, id int64) error {
+	invitations, err := c.ListInvitations(ctx)
+	if err != nil {
+		return err
+	}
+	for _, invitation := range invitations {
+		if invitation.ID != id {
+			continue
+		}
+		path := fmt.Sprintf("projects/%s/invitations/%s", gitlab.PathEscape(getRepoPath(c.ref)), gitlab.PathEscape(invitation.Login))
+		req, err := c.c.Client().NewRequest(http.MethodDelete, path, nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+		if err != nil {
+			return err
+		}
+		// DELETE /projects/{id}/invitations/{email}
+		_, err = c.c.Client().Do(req, nil)
+		return handleHTTPError(err)
+	}
+	return fmt.Errorf("invitation %d: %w", id, gitprovider.ErrNotFound)
+}
+
+// getUserID returns the ID of the user with the given username.
+func (c *clientContext) getUserID(ctx context.Context, login string) (int, error) {
+	// GET /users?username={username}
+	apiObjs, _, err := c.c.Client().Users.ListUsers(&gitlab.ListUsersOptions{Username: gitlab.String(login)}, gitlab.WithContext(ctx))
+	if err != nil {
+		return 0, handleHTTPError(err)
+	}
+	for _, apiObj := range apiObjs {
+		if strings.EqualFold(apiObj.Username, login) {
+			return apiObj.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("user %q: %w", login, gitprovider.ErrNotFound)
+}
+
+func collaboratorFromAPI(apiObj *gitlab.ProjectMember) (gitprovider.CollaboratorInfo, error) {
+	permission, err := getGitProviderPermission(int(apiObj.AccessLevel))
+	if err != nil {
+		return gitprovider.CollaboratorInfo{}, err
+	}
+	return gitprovider.CollaboratorInfo{
+		Login:      apiObj.Username,
+		Permission: permission,
+	}, nil
+}
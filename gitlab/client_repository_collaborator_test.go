@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestCollaboratorClient(t *testing.T) {
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/users", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("username") {
+		case "alice":
+			w.Write([]byte(`[{"id": 1, "username": "alice"}]`))
+		case "bob":
+			w.Write([]byte(`[{"id": 2, "username": "bob"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/members/1", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" members/1")
+		w.Write([]byte(`{"id": 1, "username": "alice", "access_level": 30}`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/members/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "404 Not found"}`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/members", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" members")
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"id": 2, "username": "bob", "access_level": 10}`))
+			return
+		}
+		w.Write([]byte(`[{"id": 1, "username": "alice", "access_level": 30}]`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/invitations", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 7, "invite_email": "carol@example.com", "access_level": 10, "created_at": "2021-11-01T12:00:00Z"}]`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/invitations/carol@example.com", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" invitations/carol@example.com")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: client.domain, Organization: "group"}, RepositoryName: "repo"}
+	c := &CollaboratorClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	alice := gitprovider.CollaboratorInfo{Login: "alice", Permission: gitprovider.RepositoryPermissionVar(gitprovider.RepositoryPermissionPush)}
+	list, err := c.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(list, []gitprovider.CollaboratorInfo{alice}) {
+		t.Errorf("List() = %+v, want %+v", list, alice)
+	}
+	if _, err := c.Get(ctx, "bob"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Get() of a non-member error = %v, want ErrNotFound", err)
+	}
+
+	// Adding an existing member changes its access level
+	requests = nil
+	invitation, err := c.Add(ctx, gitprovider.CollaboratorInfo{Login: "alice", Permission: gitprovider.RepositoryPermissionVar(gitprovider.RepositoryPermissionMaintain)})
+	if err != nil || invitation != nil {
+		t.Errorf("Add() = %+v, %v, want no invitation", invitation, err)
+	}
+	if want := []string{"GET members/1", "PUT members/1"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("Add() requests = %v, want %v", requests, want)
+	}
+	requests = nil
+	if _, err := c.Add(ctx, gitprovider.CollaboratorInfo{Login: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"POST members"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("Add() requests = %v, want %v", requests, want)
+	}
+
+	invitations, err := c.ListInvitations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantInvitation := gitprovider.CollaboratorInvitation{
+		ID:         7,
+		Login:      "carol@example.com",
+		Permission: gitprovider.RepositoryPermissionPull,
+		CreatedAt:  time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(invitations, []gitprovider.CollaboratorInvitation{wantInvitation}) {
+		t.Errorf("ListInvitations() = %+v, want %+v", invitations, wantInvitation)
+	}
+	requests = nil
+	if err := c.CancelInvitation(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"DELETE invitations/carol@example.com"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("CancelInvitation() requests = %v, want %v", requests, want)
+	}
+	if err := c.CancelInvitation(ctx, 8); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("CancelInvitation() of a missing invitation error = %v, want ErrNotFound", err)
+	}
+}
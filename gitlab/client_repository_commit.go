@@ -19,6 +19,7 @@ package gitlab
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/xanzy/go-gitlab"
@@ -34,8 +35,13 @@ type CommitClient struct {
 }
 
 // ListPage lists repository commits of the given page and page size.
-func (c *CommitClient) ListPage(_ context.Context, branch string, perPage, page int) ([]gitprovider.Commit, error) {
-	dks, err := c.listPage(branch, perPage, page)
+// The CommitListOptions.Author filter is applied to each page, as the GitLab API can't filter by author.
+func (c *CommitClient) ListPage(ctx context.Context, branch string, perPage, page int, opts ...gitprovider.CommitListOption) ([]gitprovider.Commit, error) {
+	o, err := gitprovider.MakeCommitListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	dks, err := c.listPage(ctx, branch, perPage, page, o)
 	if err != nil {
 		return nil, err
 	}
@@ -47,16 +53,23 @@ func (c *CommitClient) ListPage(_ context.Context, branch string, perPage, page
 	return commits, nil
 }
 
-func (c *CommitClient) listPage(branch string, perPage, page int) ([]*commitType, error) {
+func (c *CommitClient) listPage(ctx context.Context, branch string, perPage, page int, opts gitprovider.CommitListOptions) ([]*commitType, error) {
 	// GET /repos/{owner}/{repo}/commits
-	apiObjs, err := c.c.ListCommitsPage(getRepoPath(c.ref), branch, perPage, page)
+	apiObjs, err := c.c.ListCommitsPage(getRepoPath(c.ref), branch, perPage, page, opts)
+	if (err != nil || len(apiObjs) == 0) && isEmptyProject(ctx, c.clientContext, c.ref) {
+		return nil, gitprovider.ErrEmptyRepository
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Map the api object to our CommitType type
+	// Map the api object to our CommitType type, filtering by author client-side
+	authorFilter := gitprovider.CommitListOptions{Author: opts.Author}
 	keys := make([]*commitType, 0, len(apiObjs))
 	for _, apiObj := range apiObjs {
+		if !authorFilter.Matches(apiObj.AuthorName, apiObj.AuthorEmail, time.Time{}) {
+			continue
+		}
 		keys = append(keys, newCommit(c, apiObj))
 	}
 
@@ -64,6 +77,7 @@ func (c *CommitClient) listPage(branch string, perPage, page int) ([]*commitType
 }
 
 // Create creates a commit with the given specifications.
+// GitLab creates the branch along with the initial commit when the project is empty.
 func (c *CommitClient) Create(_ context.Context, branch string, message string, files []gitprovider.CommitFile) (gitprovider.Commit, error) {
 
 	if len(files) == 0 {
@@ -97,3 +111,49 @@ func (c *CommitClient) Create(_ context.Context, branch string, message string,
 
 	return newCommit(c, commit), nil
 }
+
+// ListComments lists the comments on the commit with the given SHA, oldest first. GitLab
+// doesn't return the ID and creation time of commit comments.
+func (c *CommitClient) ListComments(ctx context.Context, sha string) ([]gitprovider.CommitCommentInfo, error) {
+	var apiObjs []*gitlab.CommitComment
+	opts := &gitlab.GetCommitCommentsOptions{}
+	for {
+		// GET /projects/{id}/repository/commits/{sha}/comments
+		pageObjs, resp, err := c.c.Client().Commits.GetCommitComments(getRepoPath(c.ref), sha, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		apiObjs = append(apiObjs, pageObjs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	comments := make([]gitprovider.CommitCommentInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		comments = append(comments, commitCommentFromAPI(apiObj))
+	}
+	return comments, nil
+}
+
+// CreateComment adds a comment with the given body to the commit with the given SHA.
+func (c *CommitClient) CreateComment(ctx context.Context, sha, body string) (gitprovider.CommitCommentInfo, error) {
+	// POST /projects/{id}/repository/commits/{sha}/comments
+	apiObj, _, err := c.c.Client().Commits.PostCommitComment(getRepoPath(c.ref), sha, &gitlab.PostCommitCommentOptions{
+		Note: &body,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return gitprovider.CommitCommentInfo{}, handleHTTPError(err)
+	}
+	return commitCommentFromAPI(apiObj), nil
+}
+
+func commitCommentFromAPI(apiObj *gitlab.CommitComment) gitprovider.CommitCommentInfo {
+	return gitprovider.CommitCommentInfo{
+		Body:   apiObj.Note,
+		Author: apiObj.Author.Username,
+		Path:   apiObj.Path,
+		Line:   apiObj.Line,
+	}
+}
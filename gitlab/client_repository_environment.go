@@ -0,0 +1,201 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// EnvironmentClient implements the gitprovider.EnvironmentClient interface.
+var _ gitprovider.EnvironmentClient = &EnvironmentClient{}
+
+// EnvironmentClient operates on the protected environments of a specific project.
+// The reviewers are the users, and the team reviewers the (sub)groups, allowed to deploy to
+// the environment. GitLab doesn't have wait timers.
+type EnvironmentClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get returns the protection rules of the environment with the given name.
+//
+// ErrNotFound is returned if the environment isn't protected.
+func (c *EnvironmentClient) Get(ctx context.Context, name string) (gitprovider.EnvironmentInfo, error) {
+	// GET /projects/{id}/protected_environments/{name}
+	apiObj, _, err := c.c.Client().ProtectedEnvironments.GetProtectedEnvironment(getRepoPath(c.ref), name, gitlab.WithContext(ctx))
+	if err != nil {
+		return gitprovider.EnvironmentInfo{}, handleHTTPError(err)
+	}
+	return c.environmentFromAPI(ctx, apiObj)
+}
+
+// List lists the protected environments of the project.
+//
+// List returns all available environments, using multiple paginated requests if needed.
+func (c *EnvironmentClient) List(ctx context.Context) ([]gitprovider.EnvironmentInfo, error) {
+	opts := &gitlab.ListProtectedEnvironmentsOptions{}
+	var apiObjs []*gitlab.ProtectedEnvironment
+	for {
+		// GET /projects/{id}/protected_environments
+		pageObjs, resp, err := c.c.Client().ProtectedEnvironments.ListProtectedEnvironments(getRepoPath(c.ref), opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		apiObjs = append(apiObjs, pageObjs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	envs := make([]gitprovider.EnvironmentInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		env, err := c.environmentFromAPI(ctx, apiObj)
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+//
+// GitLab can't change a protected environment, so an update unprotects and protects it again.
+// ErrNoProviderSupport is returned if req.WaitTimer is set.
+func (c *EnvironmentClient) Reconcile(ctx context.Context, req gitprovider.EnvironmentInfo) (gitprovider.EnvironmentInfo, bool, error) {
+	// First thing, validate and default the request to ensure a valid and fully-populated object
+	// (to minimize any possible diffs between desired and actual state)
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return gitprovider.EnvironmentInfo{}, false, err
+	}
+	if req.WaitTimer != 0 {
+		return gitprovider.EnvironmentInfo{}, false, fmt.Errorf("gitlab doesn't support environment wait timers: %w", gitprovider.ErrNoProviderSupport)
+	}
+
+	actual, err := c.Get(ctx, req.Name)
+	exists := true
+	var change gitprovider.Change
+	switch {
+	case errors.Is(err, gitprovider.ErrNotFound):
+		exists = false
+		change = gitprovider.NewCreateChange(c.ref, req)
+	case err != nil:
+		// Unexpected path, Get should succeed or return NotFound
+		return gitprovider.EnvironmentInfo{}, false, err
+	case req.Equals(actual):
+		// If the desired matches the actual state, just return the actual state
+		return actual, false, nil
+	default:
+		change = gitprovider.NewUpdateChange(c.ref, actual, req)
+	}
+	// In dry-run mode, only report what would be created or updated
+	if c.dryRun {
+		return req, true, gitprovider.NewDryRunError(change)
+	}
+
+	accessLevels, err := c.accessLevelsToAPI(ctx, req)
+	if err != nil {
+		return gitprovider.EnvironmentInfo{}, false, err
+	}
+	if exists {
+		// DELETE /projects/{id}/protected_environments/{name}
+		_, err := c.c.Client().ProtectedEnvironments.UnprotectEnvironment(getRepoPath(c.ref), req.Name, gitlab.WithContext(ctx))
+		if err != nil {
+			return gitprovider.EnvironmentInfo{}, true, handleHTTPError(err)
+		}
+	}
+	// POST /projects/{id}/protected_environments
+	apiObj, _, err := c.c.Client().ProtectedEnvironments.ProtectRepositoryEnvironments(getRepoPath(c.ref), &gitlab.ProtectRepositoryEnvironmentsOptions{
+		Name:               gitlab.String(req.Name),
+		DeployAccessLevels: &accessLevels,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return gitprovider.EnvironmentInfo{}, true, handleHTTPError(err)
+	}
+	gitprovider.RecordChange(ctx, change, apiCallProtectEnvironment)
+	resp, err := c.environmentFromAPI(ctx, apiObj)
+	return resp, true, err
+}
+
+// Delete unprotects the environment with the given name.
+//
+// ErrNotFound is returned if the environment isn't protected.
+func (c *EnvironmentClient) Delete(ctx context.Context, name string) error {
+	// DELETE /projects/{id}/protected_environments/{name}
+	_, err := c.c.Client().ProtectedEnvironments.UnprotectEnvironment(getRepoPath(c.ref), name, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+// accessLevelsToAPI looks up the IDs of the reviewers of the environment. Without reviewers,
+// deployments are left to the maintainers of the project.
+func (c *EnvironmentClient) accessLevelsToAPI(ctx context.Context, req gitprovider.EnvironmentInfo) ([]*gitlab.EnvironmentAccessOptions, error) {
+	if len(req.Reviewers) == 0 && len(req.TeamReviewers) == 0 {
+		return []*gitlab.EnvironmentAccessOptions{{AccessLevel: gitlab.AccessLevel(gitlab.MaintainerPermissions)}}, nil
+	}
+	accessLevels := make([]*gitlab.EnvironmentAccessOptions, 0, len(req.Reviewers)+len(req.TeamReviewers))
+	for _, login := range req.Reviewers {
+		userID, err := c.getUserID(ctx, login)
+		if err != nil {
+			return nil, err
+		}
+		accessLevels = append(accessLevels, &gitlab.EnvironmentAccessOptions{UserID: gitlab.Int(userID)})
+	}
+	for _, teamName := range req.TeamReviewers {
+		group, err := c.c.GetGroup(ctx, teamName)
+		if err != nil {
+			return nil, err
+		}
+		accessLevels = append(accessLevels, &gitlab.EnvironmentAccessOptions{GroupID: gitlab.Int(group.ID)})
+	}
+	return accessLevels, nil
+}
+
+// environmentFromAPI returns the reviewers of the protected environment, defaulted for
+// comparison. Access levels that aren't for a user or group are skipped.
+func (c *EnvironmentClient) environmentFromAPI(ctx context.Context, apiObj *gitlab.ProtectedEnvironment) (gitprovider.EnvironmentInfo, error) {
+	info := gitprovider.EnvironmentInfo{Name: apiObj.Name}
+	for _, accessLevel := range apiObj.DeployAccessLevels {
+		switch {
+		case accessLevel.UserID != 0:
+			// GET /users/{id}
+			user, _, err := c.c.Client().Users.GetUser(accessLevel.UserID, gitlab.GetUsersOptions{}, gitlab.WithContext(ctx))
+			if err != nil {
+				return gitprovider.EnvironmentInfo{}, handleHTTPError(err)
+			}
+			info.Reviewers = append(info.Reviewers, user.Username)
+		case accessLevel.GroupID != 0:
+			group, err := c.c.GetGroup(ctx, accessLevel.GroupID)
+			if err != nil {
+				return gitprovider.EnvironmentInfo{}, err
+			}
+			info.TeamReviewers = append(info.TeamReviewers, group.FullPath)
+		}
+	}
+	info.Default()
+	return info, nil
+}
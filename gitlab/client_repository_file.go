@@ -19,7 +19,10 @@ package gitlab
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"strings"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -35,21 +38,25 @@ type FileClient struct {
 	ref gitprovider.RepositoryRef
 }
 
-// Get fetches and returns the contents of a file from a given branch and path
-func (c *FileClient) Get(_ context.Context, path, branch string) ([]*gitprovider.CommitFile, error) {
+// Get fetches and returns the contents of the files from a given Git reference and path.
+// The reference can be a branch, tag or commit SHA.
+func (c *FileClient) Get(ctx context.Context, path, ref string) ([]*gitprovider.CommitFile, error) {
 
 	opts := &gitlab.ListTreeOptions{
 		Path: &path,
-		Ref:  &branch,
+		Ref:  &ref,
 	}
 
 	listFiles, _, err := c.c.Client().Repositories.ListTree(getRepoPath(c.ref), opts)
 	if err != nil {
+		if isEmptyProject(ctx, c.clientContext, c.ref) {
+			return nil, gitprovider.ErrEmptyRepository
+		}
 		return nil, err
 	}
 
 	fileOpts := &gitlab.GetFileOptions{
-		Ref: &branch,
+		Ref: &ref,
 	}
 
 	files := make([]*gitprovider.CommitFile, 0)
@@ -73,3 +80,43 @@ func (c *FileClient) Get(_ context.Context, path, branch string) ([]*gitprovider
 
 	return files, nil
 }
+
+// Open returns a reader streaming the raw content of the file at the given path and Git reference,
+// along with the size and blob SHA of the file. The caller is responsible for closing the returned reader.
+//
+// ErrNotFound is returned if the file doesn't exist.
+func (c *FileClient) Open(ctx context.Context, path, ref string) (io.ReadCloser, *gitprovider.FileInfo, error) {
+	// The ref is required by the GitLab API, default to the HEAD of the default branch
+	if ref == "" {
+		ref = "HEAD"
+	}
+	gitRef := &ref
+
+	// HEAD /projects/{project}/repository/files/{file_path}
+	meta, _, err := c.c.Client().RepositoryFiles.GetFileMetaData(getRepoPath(c.ref), path, &gitlab.GetFileMetaDataOptions{
+		Ref: gitRef,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, nil, handleHTTPError(err)
+	}
+
+	// GET /projects/{project}/repository/files/{file_path}/raw
+	u := fmt.Sprintf("projects/%s/repository/files/%s/raw", gitlab.PathEscape(getRepoPath(c.ref)), gitlab.PathEscape(path))
+	req, err := c.c.Client().NewRequest(http.MethodGet, u, &gitlab.GetRawFileOptions{Ref: gitRef}, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := streamResponse(func(w io.Writer) error {
+		_, err := c.c.Client().Do(req, w)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return body, &gitprovider.FileInfo{
+		Path: meta.FilePath,
+		Size: int64(meta.Size),
+		SHA:  meta.BlobID,
+	}, nil
+}
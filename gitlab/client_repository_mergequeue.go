@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// MergeQueueClient implements the gitprovider.MergeQueueClient interface.
+var _ gitprovider.MergeQueueClient = &MergeQueueClient{}
+
+// MergeQueueClient operates on the merge trains for a specific repository.
+// The merge train API requires GitLab 15.11 or later, and merge trains enabled in the project.
+type MergeQueueClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// mergeTrainCar is a merge request in a merge train, as returned by the merge train API.
+type mergeTrainCar struct {
+	MergeRequest struct {
+		IID int `json:"iid"`
+	} `json:"merge_request"`
+	TargetBranch string     `json:"target_branch"`
+	Status       string     `json:"status"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+// listMergeTrainOptions are the options for listing the cars of a merge train.
+type listMergeTrainOptions struct {
+	gitlab.ListOptions
+	Scope *string `url:"scope,omitempty" json:"scope,omitempty"`
+	Sort  *string `url:"sort,omitempty" json:"sort,omitempty"`
+}
+
+// Enqueue adds the merge request with the given number to the merge train of its target branch.
+func (c *MergeQueueClient) Enqueue(ctx context.Context, number int) (gitprovider.MergeQueueEntry, error) {
+	path := fmt.Sprintf("projects/%s/merge_trains/merge_requests/%d", gitlab.PathEscape(getRepoPath(c.ref)), number)
+	req, err := c.c.Client().NewRequest(http.MethodPost, path, nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return gitprovider.MergeQueueEntry{}, err
+	}
+	// POST /projects/{id}/merge_trains/merge_requests/{merge_request_iid}
+	if _, err := c.c.Client().Do(req, nil); err != nil {
+		return gitprovider.MergeQueueEntry{}, handleHTTPError(err)
+	}
+	return c.Get(ctx, number)
+}
+
+// Dequeue removes the merge request with the given number from the merge train, by canceling
+// its merge.
+func (c *MergeQueueClient) Dequeue(ctx context.Context, number int) error {
+	// POST /projects/{id}/merge_requests/{merge_request_iid}/cancel_merge_when_pipeline_succeeds
+	_, _, err := c.c.Client().MergeRequests.CancelMergeWhenPipelineSucceeds(getRepoPath(c.ref), number, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+// Get returns the merge train entry of the merge request with the given number.
+//
+// ErrNotFound is returned if the merge request isn't in a merge train.
+func (c *MergeQueueClient) Get(ctx context.Context, number int) (gitprovider.MergeQueueEntry, error) {
+	path := fmt.Sprintf("projects/%s/merge_trains/merge_requests/%d", gitlab.PathEscape(getRepoPath(c.ref)), number)
+	req, err := c.c.Client().NewRequest(http.MethodGet, path, nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return gitprovider.MergeQueueEntry{}, err
+	}
+	car := &mergeTrainCar{}
+	// GET /projects/{id}/merge_trains/merge_requests/{merge_request_iid}
+	if _, err := c.c.Client().Do(req, car); err != nil {
+		return gitprovider.MergeQueueEntry{}, handleHTTPError(err)
+	}
+	entry := mergeQueueEntryFromAPI(car, 0)
+	if entry.State == gitprovider.MergeQueueStateMerged {
+		return entry, nil
+	}
+
+	// The position is the index of the car in the active train
+	entries, err := c.List(ctx, car.TargetBranch)
+	if err != nil {
+		return gitprovider.MergeQueueEntry{}, err
+	}
+	for _, e := range entries {
+		if e.PullRequestNumber == number {
+			return e, nil
+		}
+	}
+	return entry, nil
+}
+
+// List lists the entries of the active merge train of the given target branch, in merge order.
+func (c *MergeQueueClient) List(ctx context.Context, baseBranch string) ([]gitprovider.MergeQueueEntry, error) {
+	path := fmt.Sprintf("projects/%s/merge_trains/%s", gitlab.PathEscape(getRepoPath(c.ref)), gitlab.PathEscape(baseBranch))
+	opts := &listMergeTrainOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+		Scope:       gitlab.String("active"),
+		Sort:        gitlab.String("asc"),
+	}
+
+	var cars []*mergeTrainCar
+	for {
+		req, err := c.c.Client().NewRequest(http.MethodGet, path, opts, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+		if err != nil {
+			return nil, err
+		}
+		var page []*mergeTrainCar
+		// GET /projects/{id}/merge_trains/{target_branch}
+		resp, err := c.c.Client().Do(req, &page)
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		cars = append(cars, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	entries := make([]gitprovider.MergeQueueEntry, 0, len(cars))
+	for i, car := range cars {
+		entries = append(entries, mergeQueueEntryFromAPI(car, i+1))
+	}
+	return entries, nil
+}
+
+func mergeQueueEntryFromAPI(apiObj *mergeTrainCar, position int) gitprovider.MergeQueueEntry {
+	entry := gitprovider.MergeQueueEntry{
+		PullRequestNumber: apiObj.MergeRequest.IID,
+		BaseBranch:        apiObj.TargetBranch,
+		Position:          position,
+		State:             mergeQueueStateFromAPI(apiObj.Status),
+	}
+	if apiObj.CreatedAt != nil {
+		entry.EnqueuedAt = *apiObj.CreatedAt
+	}
+	return entry
+}
+
+func mergeQueueStateFromAPI(status string) gitprovider.MergeQueueState {
+	switch status {
+	case "fresh", "stale":
+		return gitprovider.MergeQueueStateChecking
+	case "merging":
+		return gitprovider.MergeQueueStateMergeable
+	case "merged", "skip_merged":
+		return gitprovider.MergeQueueStateMerged
+	default:
+		return gitprovider.MergeQueueStateQueued
+	}
+}
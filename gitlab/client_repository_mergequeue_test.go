@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestMergeQueueClient(t *testing.T) {
+	createdAt := time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/group/repo/merge_trains/main", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("scope"); got != "active" {
+			t.Errorf("scope = %q, want active", got)
+		}
+		w.Write([]byte(`[
+			{"merge_request": {"iid": 3}, "target_branch": "main", "status": "fresh", "created_at": "2021-11-01T12:00:00Z"},
+			{"merge_request": {"iid": 5}, "target_branch": "main", "status": "idle", "created_at": "2021-11-01T12:00:00Z"}
+		]`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/merge_trains/merge_requests/5", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"merge_request": {"iid": 5}, "target_branch": "main", "status": "idle", "created_at": "2021-11-01T12:00:00Z"}`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/merge_trains/merge_requests/7", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "404 Not found"}`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: client.domain, Organization: "group"}, RepositoryName: "repo"}
+	c := &MergeQueueClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	entry, err := c.Get(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gitprovider.MergeQueueEntry{
+		PullRequestNumber: 5,
+		BaseBranch:        "main",
+		Position:          2,
+		State:             gitprovider.MergeQueueStateQueued,
+		EnqueuedAt:        createdAt,
+	}
+	if !reflect.DeepEqual(entry, want) {
+		t.Errorf("Get() = %+v, want %+v", entry, want)
+	}
+
+	if _, err := c.Get(ctx, 7); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Get() of a merge request outside of the train error = %v, want ErrNotFound", err)
+	}
+}
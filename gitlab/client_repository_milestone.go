@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// milestoneCloseEvent is the state event used to close a gitlab milestone
+const milestoneCloseEvent = "close"
+
+// MilestoneClient implements the gitprovider.MilestoneClient interface.
+var _ gitprovider.MilestoneClient = &MilestoneClient{}
+
+// MilestoneClient operates on the milestones for a specific repository.
+// The milestone number maps to the project-scoped IID of the GitLab milestone.
+type MilestoneClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists all milestones in the repository, both open and closed.
+func (c *MilestoneClient) List(ctx context.Context) ([]gitprovider.Milestone, error) {
+	apiObjs, err := c.list(ctx, &gitlab.ListMilestonesOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	milestones := make([]gitprovider.Milestone, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		milestones = append(milestones, newMilestone(c.clientContext, apiObj))
+	}
+	return milestones, nil
+}
+
+// Get retrieves an existing milestone by number.
+func (c *MilestoneClient) Get(ctx context.Context, number int) (gitprovider.Milestone, error) {
+	apiObj, err := c.get(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return newMilestone(c.clientContext, apiObj), nil
+}
+
+// Create creates a milestone with the given specifications.
+func (c *MilestoneClient) Create(ctx context.Context, req gitprovider.MilestoneInfo) (gitprovider.Milestone, error) {
+	if err := req.ValidateInfo(); err != nil {
+		return nil, err
+	}
+	// POST /projects/{id}/milestones
+	apiObj, _, err := c.c.Client().Milestones.CreateMilestone(getRepoPath(c.ref), milestoneToAPI(&req), gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return newMilestone(c.clientContext, apiObj), nil
+}
+
+// AssignPullRequest assigns the merge request with the given number to the milestone.
+func (c *MilestoneClient) AssignPullRequest(ctx context.Context, number, pullRequestNumber int) error {
+	apiObj, err := c.get(ctx, number)
+	if err != nil {
+		return err
+	}
+	// PUT /projects/{id}/merge_requests/{merge_request_iid}
+	_, _, err = c.c.Client().MergeRequests.UpdateMergeRequest(getRepoPath(c.ref), pullRequestNumber, &gitlab.UpdateMergeRequestOptions{
+		MilestoneID: &apiObj.ID,
+	}, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+// AssignIssue assigns the issue with the given number to the milestone.
+func (c *MilestoneClient) AssignIssue(ctx context.Context, number, issueNumber int) error {
+	apiObj, err := c.get(ctx, number)
+	if err != nil {
+		return err
+	}
+	// PUT /projects/{id}/issues/{issue_iid}
+	_, _, err = c.c.Client().Issues.UpdateIssue(getRepoPath(c.ref), issueNumber, &gitlab.UpdateIssueOptions{
+		MilestoneID: &apiObj.ID,
+	}, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+// Close closes the milestone with the given number.
+func (c *MilestoneClient) Close(ctx context.Context, number int) error {
+	apiObj, err := c.get(ctx, number)
+	if err != nil {
+		return err
+	}
+	// PUT /projects/{id}/milestones/{milestone_id}
+	_, _, err = c.c.Client().Milestones.UpdateMilestone(getRepoPath(c.ref), apiObj.ID, &gitlab.UpdateMilestoneOptions{
+		StateEvent: gitlab.String(milestoneCloseEvent),
+	}, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+// get looks up a milestone by its project-scoped IID, as the GitLab API addresses milestones by their global ID.
+func (c *MilestoneClient) get(ctx context.Context, number int) (*gitlab.Milestone, error) {
+	apiObjs, err := c.list(ctx, &gitlab.ListMilestonesOptions{IIDs: &[]int{number}})
+	if err != nil {
+		return nil, err
+	}
+	if len(apiObjs) == 0 {
+		return nil, fmt.Errorf("milestone %d: %w", number, gitprovider.ErrNotFound)
+	}
+	return apiObjs[0], nil
+}
+
+func (c *MilestoneClient) list(ctx context.Context, opts *gitlab.ListMilestonesOptions) ([]*gitlab.Milestone, error) {
+	var apiObjs []*gitlab.Milestone
+	err := allMilestonePages(opts, func() (*gitlab.Response, error) {
+		// GET /projects/{id}/milestones
+		pageObjs, resp, listErr := c.c.Client().Milestones.ListMilestones(getRepoPath(c.ref), opts, gitlab.WithContext(ctx))
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return apiObjs, nil
+}
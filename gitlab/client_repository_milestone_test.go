@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestMilestoneClient(t *testing.T) {
+	var created, closed, assigned map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/group/repo/milestones", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			// Milestones are looked up by their project-scoped IID
+			switch iids := r.URL.Query()["iids[]"]; {
+			case len(iids) == 0:
+				w.Write([]byte(`[{"id": 10, "iid": 1, "title": "v1.0", "state": "closed"}, {"id": 30, "iid": 3, "title": "v2.0", "state": "active", "due_date": "2026-12-01"}]`))
+			case iids[0] == "3":
+				w.Write([]byte(`[{"id": 30, "iid": 3, "title": "v2.0", "state": "active", "due_date": "2026-12-01"}]`))
+			default:
+				w.Write([]byte(`[]`))
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 30, "iid": 3, "title": "v2.0", "state": "active", "due_date": "2026-12-01", "web_url": "https://gitlab.example.com/group/repo/-/milestones/3"}`))
+		}
+	})
+	// The milestones are updated by their global ID
+	mux.HandleFunc("/api/v4/projects/group/repo/milestones/30", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&closed); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"id": 30, "iid": 3, "title": "v2.0", "state": "closed"}`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/merge_requests/7", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&assigned); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"id": 70, "iid": 7}`))
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: client.domain, Organization: "group"}, RepositoryName: "repo"}
+	c := &MilestoneClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+
+	milestones, err := c.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var states []gitprovider.MilestoneState
+	for _, m := range milestones {
+		states = append(states, m.Get().State)
+	}
+	if want := []gitprovider.MilestoneState{gitprovider.MilestoneStateClosed, gitprovider.MilestoneStateOpen}; !reflect.DeepEqual(states, want) {
+		t.Errorf("List() states = %v, want %v", states, want)
+	}
+
+	dueDate := time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)
+	m, err := c.Create(ctx, gitprovider.MilestoneInfo{Title: "v2.0", DueDate: &dueDate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created["title"] != "v2.0" || created["due_date"] != "2026-12-01" {
+		t.Errorf("Create() request = %v, want the title and due date", created)
+	}
+	if got := m.Get(); got.Number != 3 || got.State != gitprovider.MilestoneStateOpen || !got.DueDate.Equal(dueDate) {
+		t.Errorf("Create() = %+v, want open milestone 3 due on %v", got, dueDate)
+	}
+
+	if m, err := c.Get(ctx, 3); err != nil || m.Get().Title != "v2.0" {
+		t.Errorf("Get() = %v, %v, want milestone v2.0", m, err)
+	}
+	if _, err := c.Get(ctx, 4); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Get() of a missing milestone = %v, want ErrNotFound", err)
+	}
+
+	if err := c.AssignPullRequest(ctx, 3, 7); err != nil {
+		t.Fatal(err)
+	}
+	if assigned["milestone_id"] != float64(30) {
+		t.Errorf("AssignPullRequest() request = %v, want milestone ID 30", assigned)
+	}
+	if err := c.AssignPullRequest(ctx, 4, 7); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("AssignPullRequest() to a missing milestone = %v, want ErrNotFound", err)
+	}
+
+	if err := c.Close(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if closed["state_event"] != "close" {
+		t.Errorf("Close() request = %v, want the close state event", closed)
+	}
+}
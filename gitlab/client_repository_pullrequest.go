@@ -18,6 +18,7 @@ package gitlab
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -83,8 +84,8 @@ func (c *PullRequestClient) Get(_ context.Context, number int) (gitprovider.Pull
 }
 
 // Merge merges a pull request with the given specifications.
-func (c *PullRequestClient) Merge(_ context.Context, number int, mergeMethod gitprovider.MergeMethod, message string) error {
-	if err := c.waitForMergeRequestToBeMergeable(number); err != nil {
+func (c *PullRequestClient) Merge(ctx context.Context, number int, mergeMethod gitprovider.MergeMethod, message string) error {
+	if err := c.waitForMergeRequestToBeMergeable(ctx, number); err != nil {
 		return err
 	}
 
@@ -99,6 +100,9 @@ func (c *PullRequestClient) Merge(_ context.Context, number int, mergeMethod git
 		squash = true
 	case gitprovider.MergeMethodMerge:
 		mergeCommitMessage = &message
+	case gitprovider.MergeMethodRebase:
+		// The project merge method decides whether the source branch is fast-forwarded,
+		// hence there is no commit message to set
 	default:
 		return fmt.Errorf("unknown merge method: %s", mergeMethod)
 	}
@@ -112,7 +116,7 @@ func (c *PullRequestClient) Merge(_ context.Context, number int, mergeMethod git
 		SHA:                       nil,
 	}
 
-	_, _, err := c.c.Client().MergeRequests.AcceptMergeRequest(getRepoPath(c.ref), number, amrOpts)
+	_, _, err := c.c.Client().MergeRequests.AcceptMergeRequest(getRepoPath(c.ref), number, amrOpts, gitlab.WithContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -120,17 +124,93 @@ func (c *PullRequestClient) Merge(_ context.Context, number int, mergeMethod git
 	return nil
 }
 
-func (c *PullRequestClient) waitForMergeRequestToBeMergeable(number int) error {
+// Close closes the merge request without merging it.
+func (c *PullRequestClient) Close(ctx context.Context, number int) error {
+	// PUT /projects/{id}/merge_requests/{merge_request_iid}
+	_, _, err := c.c.Client().MergeRequests.UpdateMergeRequest(getRepoPath(c.ref), number, &gitlab.UpdateMergeRequestOptions{
+		StateEvent: gitlab.String("close"),
+	}, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+func (c *PullRequestClient) waitForMergeRequestToBeMergeable(ctx context.Context, number int) error {
 	// gitlab says to poll for merge status
-	for retries := 0; retries < 10; retries++ {
-		mr, _, err := c.c.Client().MergeRequests.GetMergeRequest(getRepoPath(c.ref), number, &gitlab.GetMergeRequestsOptions{})
+	retries := 0
+	op := gitprovider.NewOperation(c.clock, time.Second*2, func(ctx context.Context) (gitprovider.OperationStatus, error) {
+		if retries++; retries > 10 {
+			return gitprovider.OperationStatus{State: gitprovider.OperationStateFailed}, nil
+		}
+		mr, _, err := c.c.Client().MergeRequests.GetMergeRequest(getRepoPath(c.ref), number, &gitlab.GetMergeRequestsOptions{}, gitlab.WithContext(ctx))
 		if err != nil || mr.MergeStatus == mergeStatusChecking {
-			time.Sleep(time.Second * 2)
+			return gitprovider.OperationStatus{State: gitprovider.OperationStateRunning}, nil
+		}
+		return gitprovider.OperationStatus{State: gitprovider.OperationStateSucceeded}, nil
+	})
+	if _, err := op.Wait(ctx); err != nil {
+		if errors.Is(err, gitprovider.ErrOperationFailed) {
+			return fmt.Errorf("merge status unavailable for pull request number: %d", number)
+		}
+		return err
+	}
+	return nil
+}
+
+// ListComments lists the notes of the merge request, oldest first. System notes and notes on
+// lines of the diff are not included.
+func (c *PullRequestClient) ListComments(ctx context.Context, number int) ([]gitprovider.PullRequestComment, error) {
+	var apiObjs []*gitlab.Note
+	opts := &gitlab.ListMergeRequestNotesOptions{
+		OrderBy: gitlab.String("created_at"),
+		Sort:    gitlab.String("asc"),
+	}
+	err := allMergeRequestNotePages(opts, func() (*gitlab.Response, error) {
+		// GET /projects/{id}/merge_requests/{merge_request_iid}/notes
+		pageObjs, resp, listErr := c.c.Client().Notes.ListMergeRequestNotes(getRepoPath(c.ref), number, opts, gitlab.WithContext(ctx))
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]gitprovider.PullRequestComment, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		if apiObj.System || apiObj.Position != nil {
 			continue
 		}
+		comments = append(comments, newPullRequestComment(apiObj))
+	}
+	return comments, nil
+}
 
-		return nil
+// CreateComment adds a note with the given body to the merge request.
+func (c *PullRequestClient) CreateComment(ctx context.Context, number int, body string) (gitprovider.PullRequestComment, error) {
+	// POST /projects/{id}/merge_requests/{merge_request_iid}/notes
+	apiObj, _, err := c.c.Client().Notes.CreateMergeRequestNote(getRepoPath(c.ref), number, &gitlab.CreateMergeRequestNoteOptions{
+		Body: &body,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, handleHTTPError(err)
 	}
+	return newPullRequestComment(apiObj), nil
+}
+
+// EditComment replaces the body of a note of the merge request.
+//
+// ErrNotFound is returned if the note doesn't exist.
+func (c *PullRequestClient) EditComment(ctx context.Context, number int, id int64, body string) error {
+	// PUT /projects/{id}/merge_requests/{merge_request_iid}/notes/{note_id}
+	_, _, err := c.c.Client().Notes.UpdateMergeRequestNote(getRepoPath(c.ref), number, int(id), &gitlab.UpdateMergeRequestNoteOptions{
+		Body: &body,
+	}, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
 
-	return fmt.Errorf("merge status unavailable for pull request number: %d", number)
+// DeleteComment deletes a note of the merge request.
+//
+// ErrNotFound is returned if the note doesn't exist.
+func (c *PullRequestClient) DeleteComment(ctx context.Context, number int, id int64) error {
+	// DELETE /projects/{id}/merge_requests/{merge_request_iid}/notes/{note_id}
+	_, err := c.c.Client().Notes.DeleteMergeRequestNote(getRepoPath(c.ref), number, int(id), gitlab.WithContext(ctx))
+	return handleHTTPError(err)
 }
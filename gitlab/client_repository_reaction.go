@@ -0,0 +1,193 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+// ReactionClient implements the gitprovider.ReactionClient interface.
+var _ gitprovider.ReactionClient = &ReactionClient{}
+
+// ReactionClient operates on the award emoji for a specific repository.
+type ReactionClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// awardEmojiNames maps the reaction contents to the GitLab award emoji names.
+//
+//nolint:gochecknoglobals
+var awardEmojiNames = map[gitprovider.ReactionContent]string{
+	gitprovider.ReactionContentThumbsUp:   "thumbsup",
+	gitprovider.ReactionContentThumbsDown: "thumbsdown",
+	gitprovider.ReactionContentLaugh:      "laughing",
+	gitprovider.ReactionContentConfused:   "confused",
+	gitprovider.ReactionContentHeart:      "heart",
+	gitprovider.ReactionContentHooray:     "tada",
+	gitprovider.ReactionContentRocket:     "rocket",
+	gitprovider.ReactionContentEyes:       "eyes",
+}
+
+// List lists the award emoji on the given subject, oldest first.
+func (c *ReactionClient) List(ctx context.Context, subject gitprovider.ReactionSubject) ([]gitprovider.ReactionInfo, error) {
+	if err := validateReactionSubject(subject); err != nil {
+		return nil, err
+	}
+	apiObjs, err := c.list(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	reactions := make([]gitprovider.ReactionInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		reactions = append(reactions, reactionFromAPI(apiObj))
+	}
+	return reactions, nil
+}
+
+// Add awards the emoji with the given content to the subject, as the authenticated user.
+// GitLab refuses to award the same emoji twice, hence the existing award is looked up first.
+func (c *ReactionClient) Add(ctx context.Context, subject gitprovider.ReactionSubject, content gitprovider.ReactionContent) (gitprovider.ReactionInfo, error) {
+	if err := validateReactionSubject(subject); err != nil {
+		return gitprovider.ReactionInfo{}, err
+	}
+	if err := gitprovider.ValidateReactionContent(content); err != nil {
+		return gitprovider.ReactionInfo{}, err
+	}
+	name := awardEmojiNames[content]
+
+	user, err := c.c.GetCurrentUser(ctx)
+	if err != nil {
+		return gitprovider.ReactionInfo{}, err
+	}
+	apiObjs, err := c.list(ctx, subject)
+	if err != nil {
+		return gitprovider.ReactionInfo{}, err
+	}
+	for _, apiObj := range apiObjs {
+		if apiObj.Name == name && apiObj.User.ID == user.ID {
+			return reactionFromAPI(apiObj), nil
+		}
+	}
+
+	opts := &gitlab.CreateAwardEmojiOptions{Name: name}
+	pid, emoji := getRepoPath(c.ref), c.c.Client().AwardEmoji
+	var apiObj *gitlab.AwardEmoji
+	switch {
+	case subject.CommentID != 0 && subject.PullRequest:
+		// POST /projects/{id}/merge_requests/{merge_request_iid}/notes/{note_id}/award_emoji
+		apiObj, _, err = emoji.CreateMergeRequestAwardEmojiOnNote(pid, subject.Number, int(subject.CommentID), opts, gitlab.WithContext(ctx))
+	case subject.CommentID != 0:
+		// POST /projects/{id}/issues/{issue_iid}/notes/{note_id}/award_emoji
+		apiObj, _, err = emoji.CreateIssuesAwardEmojiOnNote(pid, subject.Number, int(subject.CommentID), opts, gitlab.WithContext(ctx))
+	case subject.PullRequest:
+		// POST /projects/{id}/merge_requests/{merge_request_iid}/award_emoji
+		apiObj, _, err = emoji.CreateMergeRequestAwardEmoji(pid, subject.Number, opts, gitlab.WithContext(ctx))
+	default:
+		// POST /projects/{id}/issues/{issue_iid}/award_emoji
+		apiObj, _, err = emoji.CreateIssueAwardEmoji(pid, subject.Number, opts, gitlab.WithContext(ctx))
+	}
+	if err != nil {
+		return gitprovider.ReactionInfo{}, handleHTTPError(err)
+	}
+	return reactionFromAPI(apiObj), nil
+}
+
+// Remove removes the award emoji with the given ID from the subject.
+func (c *ReactionClient) Remove(ctx context.Context, subject gitprovider.ReactionSubject, id int64) error {
+	if err := validateReactionSubject(subject); err != nil {
+		return err
+	}
+	pid, emoji := getRepoPath(c.ref), c.c.Client().AwardEmoji
+	var err error
+	switch {
+	case subject.CommentID != 0 && subject.PullRequest:
+		// DELETE /projects/{id}/merge_requests/{merge_request_iid}/notes/{note_id}/award_emoji/{award_id}
+		_, err = emoji.DeleteMergeRequestAwardEmojiOnNote(pid, subject.Number, int(subject.CommentID), int(id), gitlab.WithContext(ctx))
+	case subject.CommentID != 0:
+		// DELETE /projects/{id}/issues/{issue_iid}/notes/{note_id}/award_emoji/{award_id}
+		_, err = emoji.DeleteIssuesAwardEmojiOnNote(pid, subject.Number, int(subject.CommentID), int(id), gitlab.WithContext(ctx))
+	case subject.PullRequest:
+		// DELETE /projects/{id}/merge_requests/{merge_request_iid}/award_emoji/{award_id}
+		_, err = emoji.DeleteMergeRequestAwardEmoji(pid, subject.Number, int(id), gitlab.WithContext(ctx))
+	default:
+		// DELETE /projects/{id}/issues/{issue_iid}/award_emoji/{award_id}
+		_, err = emoji.DeleteIssueAwardEmoji(pid, subject.Number, int(id), gitlab.WithContext(ctx))
+	}
+	return handleHTTPError(err)
+}
+
+// list returns all award emoji on the subject.
+func (c *ReactionClient) list(ctx context.Context, subject gitprovider.ReactionSubject) ([]*gitlab.AwardEmoji, error) {
+	var apiObjs []*gitlab.AwardEmoji
+	opts := &gitlab.ListAwardEmojiOptions{}
+	pid, emoji := getRepoPath(c.ref), c.c.Client().AwardEmoji
+	err := allAwardEmojiPages(opts, func() (*gitlab.Response, error) {
+		var pageObjs []*gitlab.AwardEmoji
+		var resp *gitlab.Response
+		var listErr error
+		switch {
+		case subject.CommentID != 0 && subject.PullRequest:
+			// GET /projects/{id}/merge_requests/{merge_request_iid}/notes/{note_id}/award_emoji
+			pageObjs, resp, listErr = emoji.ListMergeRequestAwardEmojiOnNote(pid, subject.Number, int(subject.CommentID), opts, gitlab.WithContext(ctx))
+		case subject.CommentID != 0:
+			// GET /projects/{id}/issues/{issue_iid}/notes/{note_id}/award_emoji
+			pageObjs, resp, listErr = emoji.ListIssuesAwardEmojiOnNote(pid, subject.Number, int(subject.CommentID), opts, gitlab.WithContext(ctx))
+		case subject.PullRequest:
+			// GET /projects/{id}/merge_requests/{merge_request_iid}/award_emoji
+			pageObjs, resp, listErr = emoji.ListMergeRequestAwardEmoji(pid, subject.Number, opts, gitlab.WithContext(ctx))
+		default:
+			// GET /projects/{id}/issues/{issue_iid}/award_emoji
+			pageObjs, resp, listErr = emoji.ListIssueAwardEmoji(pid, subject.Number, opts, gitlab.WithContext(ctx))
+		}
+		apiObjs = append(apiObjs, pageObjs...)
+		return resp, listErr
+	})
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return apiObjs, nil
+}
+
+func validateReactionSubject(subject gitprovider.ReactionSubject) error {
+	validator := validation.New("ReactionSubject")
+	subject.ValidateFields(validator)
+	return validator.Error()
+}
+
+// reactionFromAPI maps an award emoji to a reaction. Emoji without a gitprovider.ReactionContent
+// equivalent keep their GitLab name.
+func reactionFromAPI(apiObj *gitlab.AwardEmoji) gitprovider.ReactionInfo {
+	content := gitprovider.ReactionContent(apiObj.Name)
+	for c, name := range awardEmojiNames {
+		if name == apiObj.Name {
+			content = c
+			break
+		}
+	}
+	return gitprovider.ReactionInfo{
+		ID:      int64(apiObj.ID),
+		Content: content,
+		User:    apiObj.User.Username,
+	}
+}
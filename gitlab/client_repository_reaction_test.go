@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestReactionClient(t *testing.T) {
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 1, "username": "bot"}`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/merge_requests/3/award_emoji", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" merge_requests/3/award_emoji")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 12, "name": "rocket", "user": {"id": 1, "username": "bot"}}`))
+			return
+		}
+		w.Write([]byte(`[{"id": 10, "name": "thumbsup", "user": {"id": 2, "username": "alice"}},
+			{"id": 11, "name": "thumbsup", "user": {"id": 1, "username": "bot"}},
+			{"id": 13, "name": "100", "user": {"id": 2, "username": "alice"}}]`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo/issues/4/notes/5/award_emoji/6", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" issues/4/notes/5/award_emoji/6")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: client.domain, Organization: "group"}, RepositoryName: "repo"}
+	c := &ReactionClient{clientContext: client.clientContext, ref: ref}
+	ctx := context.Background()
+	mr := gitprovider.ReactionSubject{Number: 3, PullRequest: true}
+
+	reactions, err := c.List(ctx, mr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gitprovider.ReactionInfo{
+		{ID: 10, Content: gitprovider.ReactionContentThumbsUp, User: "alice"},
+		{ID: 11, Content: gitprovider.ReactionContentThumbsUp, User: "bot"},
+		{ID: 13, Content: gitprovider.ReactionContent("100"), User: "alice"},
+	}
+	if !reflect.DeepEqual(reactions, want) {
+		t.Errorf("List() = %+v, want %+v", reactions, want)
+	}
+
+	// The emoji was already awarded by the user, so no new award is created
+	requests = nil
+	reaction, err := c.Add(ctx, mr, gitprovider.ReactionContentThumbsUp)
+	if err != nil || reaction.ID != 11 {
+		t.Errorf("Add() = %+v, %v, want the existing award 11", reaction, err)
+	}
+	reaction, err = c.Add(ctx, mr, gitprovider.ReactionContentRocket)
+	if err != nil || reaction.ID != 12 || reaction.Content != gitprovider.ReactionContentRocket {
+		t.Errorf("Add() = %+v, %v, want the new award 12", reaction, err)
+	}
+
+	if err := c.Remove(ctx, gitprovider.ReactionSubject{Number: 4, CommentID: 5}, 6); err != nil {
+		t.Fatal(err)
+	}
+	wantRequests := []string{
+		"GET merge_requests/3/award_emoji",
+		"GET merge_requests/3/award_emoji",
+		"POST merge_requests/3/award_emoji",
+		"DELETE issues/4/notes/5/award_emoji/6",
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", requests, wantRequests)
+	}
+
+	if _, err := c.List(ctx, gitprovider.ReactionSubject{}); err == nil {
+		t.Error("List() without a number succeeded, want an error")
+	}
+}
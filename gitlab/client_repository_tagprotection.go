@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"errors"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// TagProtectionClient implements the gitprovider.TagProtectionClient interface.
+var _ gitprovider.TagProtectionClient = &TagProtectionClient{}
+
+// TagProtectionClient operates on the protected tags of a specific project. Only maintainers
+// are allowed to create the protected tags.
+type TagProtectionClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists the protected tags of the project.
+//
+// List returns all available protected tags, using multiple paginated requests if needed.
+func (c *TagProtectionClient) List(ctx context.Context) ([]gitprovider.TagProtectionInfo, error) {
+	opts := &gitlab.ListProtectedTagsOptions{}
+	var apiObjs []*gitlab.ProtectedTag
+	for {
+		// GET /projects/{id}/protected_tags
+		pageObjs, resp, err := c.c.Client().ProtectedTags.ListProtectedTags(getRepoPath(c.ref), opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		apiObjs = append(apiObjs, pageObjs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	rules := make([]gitprovider.TagProtectionInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		rules = append(rules, gitprovider.TagProtectionInfo{Pattern: apiObj.Name})
+	}
+	return rules, nil
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (c *TagProtectionClient) Reconcile(ctx context.Context, req gitprovider.TagProtectionInfo) (gitprovider.TagProtectionInfo, bool, error) {
+	if err := req.ValidateInfo(); err != nil {
+		return gitprovider.TagProtectionInfo{}, false, err
+	}
+	// GET /projects/{id}/protected_tags/{name}
+	_, _, err := c.c.Client().ProtectedTags.GetProtectedTag(getRepoPath(c.ref), req.Pattern, gitlab.WithContext(ctx))
+	err = handleHTTPError(err)
+	switch {
+	case err == nil:
+		return req, false, nil
+	case !errors.Is(err, gitprovider.ErrNotFound):
+		return gitprovider.TagProtectionInfo{}, false, err
+	}
+	change := gitprovider.NewCreateChange(c.ref, req)
+	// In dry-run mode, only report what would be created
+	if c.dryRun {
+		return req, true, gitprovider.NewDryRunError(change)
+	}
+
+	// POST /projects/{id}/protected_tags
+	_, _, err = c.c.Client().ProtectedTags.ProtectRepositoryTags(getRepoPath(c.ref), &gitlab.ProtectRepositoryTagsOptions{
+		Name:              gitlab.String(req.Pattern),
+		CreateAccessLevel: gitlab.AccessLevel(gitlab.MaintainerPermissions),
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return gitprovider.TagProtectionInfo{}, true, handleHTTPError(err)
+	}
+	gitprovider.RecordChange(ctx, change, apiCallProtectTag)
+	return req, true, nil
+}
+
+// Delete unprotects the tags with the given name or pattern.
+//
+// ErrNotFound is returned if they aren't protected.
+func (c *TagProtectionClient) Delete(ctx context.Context, pattern string) error {
+	// DELETE /projects/{id}/protected_tags/{name}
+	_, err := c.c.Client().ProtectedTags.UnprotectRepositoryTags(getRepoPath(c.ref), pattern, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// searchPageSize is the largest number of results per page of a search.
+const searchPageSize = 100
+
+// SearchClient implements the gitprovider.SearchClient interface.
+var _ gitprovider.SearchClient = &SearchClient{}
+
+// SearchClient searches for projects using the project listings, and for code using the
+// GitLab search API. Code search across groups or the whole instance needs advanced search.
+type SearchClient struct {
+	*clientContext
+}
+
+// Repositories returns the projects matching the text or topics of the query, up to
+// query.Limit results. GitLab filters by one topic at most, the other topics are checked on
+// the received projects.
+func (c *SearchClient) Repositories(ctx context.Context, query gitprovider.SearchQuery) ([]gitprovider.RepositorySearchResult, error) {
+	query.Default()
+	if err := query.ValidateRepositoryQuery(); err != nil {
+		return nil, err
+	}
+	if err := c.validateOwner(query.Owner); err != nil {
+		return nil, err
+	}
+	var search, topic *string
+	if query.Text != "" {
+		search = gitlab.String(query.Text)
+	}
+	if len(query.Topics) != 0 {
+		topic = gitlab.String(query.Topics[0])
+	}
+
+	results := make([]gitprovider.RepositorySearchResult, 0)
+	listOpts := searchListOptions(query.Limit)
+	for {
+		var apiObjs []*gitlab.Project
+		var resp *gitlab.Response
+		var err error
+		switch {
+		case query.Owner == nil:
+			// GET /projects
+			apiObjs, resp, err = c.c.Client().Projects.ListProjects(&gitlab.ListProjectsOptions{
+				ListOptions: listOpts,
+				Search:      search,
+				Topic:       topic,
+			}, gitlab.WithContext(ctx))
+		case query.Owner.GetType() == gitprovider.IdentityTypeUser:
+			// GET /users/{username}/projects
+			apiObjs, resp, err = c.c.Client().Projects.ListUserProjects(query.Owner.GetIdentity(), &gitlab.ListProjectsOptions{
+				ListOptions: listOpts,
+				Search:      search,
+				Topic:       topic,
+			}, gitlab.WithContext(ctx))
+		default:
+			// GET /groups/{group}/projects
+			apiObjs, resp, err = c.c.Client().Groups.ListGroupProjects(query.Owner.GetIdentity(), &gitlab.ListGroupProjectsOptions{
+				ListOptions:      listOpts,
+				Search:           search,
+				IncludeSubgroups: gitlab.Bool(true),
+			}, gitlab.WithContext(ctx))
+		}
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		for _, apiObj := range apiObjs {
+			topics := projectTopics(apiObj)
+			if !hasTopics(topics, query.Topics) {
+				continue
+			}
+			results = append(results, gitprovider.RepositorySearchResult{
+				Repository:  projectRef(c.domain, apiObj),
+				Description: apiObj.Description,
+				Topics:      topics,
+			})
+		}
+		// Stop paginating once there are enough results
+		if len(results) >= query.Limit || resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+// Code returns the files with content matching the text of the query, up to query.Limit results.
+func (c *SearchClient) Code(ctx context.Context, query gitprovider.SearchQuery) ([]gitprovider.CodeSearchResult, error) {
+	query.Default()
+	if err := query.ValidateCodeQuery(); err != nil {
+		return nil, err
+	}
+	if err := c.validateOwner(query.Owner); err != nil {
+		return nil, err
+	}
+	if query.Repository != nil {
+		if err := c.validateOwner(query.Repository); err != nil {
+			return nil, err
+		}
+	}
+	text := query.Text
+	if query.Filename != "" {
+		text += " filename:" + query.Filename
+	}
+
+	// The projects of the blobs, by ID
+	projects := map[int]gitprovider.RepositoryRef{}
+	results := make([]gitprovider.CodeSearchResult, 0)
+	opts := &gitlab.SearchOptions{ListOptions: searchListOptions(query.Limit)}
+	for {
+		var apiObjs []*gitlab.Blob
+		var resp *gitlab.Response
+		var err error
+		switch {
+		case query.Repository != nil:
+			// GET /projects/{id}/search?scope=blobs
+			apiObjs, resp, err = c.c.Client().Search.BlobsByProject(getRepoPath(query.Repository), text, opts, gitlab.WithContext(ctx))
+		case query.Owner != nil && query.Owner.GetType() != gitprovider.IdentityTypeUser:
+			// GET /groups/{id}/search?scope=blobs
+			apiObjs, resp, err = c.c.Client().Search.BlobsByGroup(query.Owner.GetIdentity(), text, opts, gitlab.WithContext(ctx))
+		case query.Owner != nil:
+			return nil, fmt.Errorf("gitlab can't search the code of a user: %w", gitprovider.ErrNoProviderSupport)
+		default:
+			// GET /search?scope=blobs
+			apiObjs, resp, err = c.c.Client().Search.Blobs(text, opts, gitlab.WithContext(ctx))
+		}
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		for _, apiObj := range apiObjs {
+			ref, ok := projects[apiObj.ProjectID]
+			switch {
+			case ok:
+			case query.Repository != nil:
+				ref = query.Repository
+			default:
+				// GET /projects/{id}
+				project, _, err := c.c.Client().Projects.GetProject(apiObj.ProjectID, nil, gitlab.WithContext(ctx))
+				if err != nil {
+					return nil, handleHTTPError(err)
+				}
+				ref = projectRef(c.domain, project)
+			}
+			projects[apiObj.ProjectID] = ref
+			results = append(results, gitprovider.CodeSearchResult{
+				Repository: ref,
+				Path:       apiObj.Filename,
+			})
+		}
+		// Stop paginating once there are enough results
+		if len(results) >= query.Limit || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+// validateOwner makes sure the owner, if set, is on the domain of the client. Unlike other
+// calls, searches can be restricted to subgroups.
+func (c *SearchClient) validateOwner(owner gitprovider.IdentityRef) error {
+	if owner != nil && owner.GetDomain() != c.domain {
+		return fmt.Errorf("domain %q not supported by this client: %w", owner.GetDomain(), gitprovider.ErrDomainUnsupported)
+	}
+	return nil
+}
+
+// searchListOptions returns the list options to fetch limit results with as few pages as possible.
+func searchListOptions(limit int) gitlab.ListOptions {
+	if limit > searchPageSize {
+		limit = searchPageSize
+	}
+	return gitlab.ListOptions{PerPage: limit}
+}
+
+// hasTopics returns true if all the wanted topics are in topics.
+func hasTopics(topics, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, topic := range topics {
+			if strings.EqualFold(topic, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// projectRef returns the reference to the project, an OrgRepositoryRef if it's in a group,
+// or a UserRepositoryRef if it's in the namespace of a user. The repository name is the path
+// of the project, which addresses it.
+func projectRef(domain string, apiObj *gitlab.Project) gitprovider.RepositoryRef {
+	if apiObj.Namespace != nil && apiObj.Namespace.Kind == "user" {
+		return gitprovider.UserRepositoryRef{
+			UserRef:        gitprovider.UserRef{Domain: domain, UserLogin: apiObj.Namespace.Path},
+			RepositoryName: apiObj.Path,
+		}
+	}
+	fullPath := apiObj.PathWithNamespace
+	if apiObj.Namespace != nil {
+		fullPath = apiObj.Namespace.FullPath
+	} else if i := strings.LastIndex(fullPath, "/"); i >= 0 {
+		fullPath = fullPath[:i]
+	}
+	parts := strings.Split(fullPath, "/")
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: domain, Organization: parts[0]},
+		RepositoryName:  apiObj.Path,
+	}
+	if len(parts) > 1 {
+		ref.SubOrganizations = parts[1:]
+	}
+	return ref
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// TokensClient implements the gitprovider.TokensClient interface.
+var _ gitprovider.TokensClient = &TokensClient{}
+
+// TokensClient operates on the project and group access tokens. Group access tokens need
+// GitLab 14.7 or later, and rotating tokens GitLab 16.0 or later.
+type TokensClient struct {
+	*clientContext
+}
+
+// accessToken is a project or group access token. go-gitlab has neither the group access tokens
+// nor the rotate endpoint, so the requests are made directly for both.
+type accessToken struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Scopes      []string        `json:"scopes"`
+	AccessLevel int             `json:"access_level"`
+	ExpiresAt   *gitlab.ISOTime `json:"expires_at"`
+	CreatedAt   *time.Time      `json:"created_at"`
+	Active      bool            `json:"active"`
+	Revoked     bool            `json:"revoked"`
+	Token       string          `json:"token"`
+}
+
+// createAccessTokenOptions are the options to create an access token.
+type createAccessTokenOptions struct {
+	Name        *string         `json:"name,omitempty"`
+	Scopes      *[]string       `json:"scopes,omitempty"`
+	AccessLevel *int            `json:"access_level,omitempty"`
+	ExpiresAt   *gitlab.ISOTime `json:"expires_at,omitempty"`
+}
+
+// rotateAccessTokenOptions are the options to rotate an access token.
+type rotateAccessTokenOptions struct {
+	ExpiresAt *gitlab.ISOTime `json:"expires_at,omitempty"`
+}
+
+// List lists the active access tokens of the project or group.
+//
+// List returns all available tokens, using multiple paginated requests if needed.
+func (c *TokensClient) List(ctx context.Context, owner gitprovider.IdentityRef) ([]gitprovider.AccessTokenInfo, error) {
+	path, err := c.tokensPath(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &gitlab.ListOptions{PerPage: 100}
+	var apiObjs []*accessToken
+	for {
+		req, err := c.c.Client().NewRequest(http.MethodGet, path, opts, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+		if err != nil {
+			return nil, err
+		}
+		var page []*accessToken
+		// GET /projects/{id}/access_tokens or /groups/{id}/access_tokens
+		resp, err := c.c.Client().Do(req, &page)
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		apiObjs = append(apiObjs, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	tokens := make([]gitprovider.AccessTokenInfo, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		if !apiObj.Active || apiObj.Revoked {
+			continue
+		}
+		tokens = append(tokens, accessTokenFromAPI(apiObj))
+	}
+	return tokens, nil
+}
+
+// Create creates an access token for the project or group. The access level defaults to
+// maintainer.
+func (c *TokensClient) Create(ctx context.Context, owner gitprovider.IdentityRef, req gitprovider.AccessTokenInfo) (gitprovider.AccessTokenInfo, error) {
+	if err := req.Validate(); err != nil {
+		return gitprovider.AccessTokenInfo{}, err
+	}
+	path, err := c.tokensPath(owner)
+	if err != nil {
+		return gitprovider.AccessTokenInfo{}, err
+	}
+
+	permission := gitprovider.RepositoryPermissionMaintain
+	if req.AccessLevel != nil {
+		permission = *req.AccessLevel
+	}
+	accessLevel, err := getGitlabPermission(permission)
+	if err != nil {
+		return gitprovider.AccessTokenInfo{}, err
+	}
+	opts := &createAccessTokenOptions{
+		Name:        gitlab.String(req.Name),
+		Scopes:      &req.Scopes,
+		AccessLevel: gitlab.Int(accessLevel),
+	}
+	if req.ExpiresAt != nil {
+		expiresAt := gitlab.ISOTime(*req.ExpiresAt)
+		opts.ExpiresAt = &expiresAt
+	}
+
+	apiObj := &accessToken{}
+	// POST /projects/{id}/access_tokens or /groups/{id}/access_tokens
+	if err := c.do(ctx, http.MethodPost, path, opts, apiObj); err != nil {
+		return gitprovider.AccessTokenInfo{}, err
+	}
+	return accessTokenFromAPI(apiObj), nil
+}
+
+// Rotate revokes the token with the given ID, and creates a new one with the same name, scopes
+// and access level, which expires at expiresAt.
+//
+// ErrNotFound is returned if the token doesn't exist or is already revoked.
+func (c *TokensClient) Rotate(ctx context.Context, owner gitprovider.IdentityRef, id int64, expiresAt time.Time) (gitprovider.AccessTokenInfo, error) {
+	path, err := c.tokensPath(owner)
+	if err != nil {
+		return gitprovider.AccessTokenInfo{}, err
+	}
+
+	isoTime := gitlab.ISOTime(expiresAt)
+	apiObj := &accessToken{}
+	// POST /projects/{id}/access_tokens/{token_id}/rotate or /groups/{id}/access_tokens/{token_id}/rotate
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("%s/%d/rotate", path, id), &rotateAccessTokenOptions{ExpiresAt: &isoTime}, apiObj); err != nil {
+		return gitprovider.AccessTokenInfo{}, err
+	}
+	return accessTokenFromAPI(apiObj), nil
+}
+
+// Revoke revokes the token with the given ID.
+//
+// ErrNotFound is returned if the token doesn't exist or is already revoked.
+func (c *TokensClient) Revoke(ctx context.Context, owner gitprovider.IdentityRef, id int64) error {
+	path, err := c.tokensPath(owner)
+	if err != nil {
+		return err
+	}
+	// DELETE /projects/{id}/access_tokens/{token_id} or /groups/{id}/access_tokens/{token_id}
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("%s/%d", path, id), nil, nil)
+}
+
+// tokensPath returns the path of the access tokens of the project or group.
+func (c *TokensClient) tokensPath(owner gitprovider.IdentityRef) (string, error) {
+	if err := validateIdentityFields(owner, c.domain); err != nil {
+		return "", err
+	}
+	switch ref := owner.(type) {
+	case gitprovider.RepositoryRef:
+		return fmt.Sprintf("projects/%s/access_tokens", gitlab.PathEscape(getRepoPath(ref))), nil
+	case gitprovider.OrganizationRef:
+		return fmt.Sprintf("groups/%s/access_tokens", gitlab.PathEscape(ref.GetIdentity())), nil
+	default:
+		return "", fmt.Errorf("access tokens are owned by a project or group, not %s: %w", owner, gitprovider.ErrInvalidArgument)
+	}
+}
+
+// do makes a request to the access tokens API, decoding the response into v if it isn't nil.
+func (c *TokensClient) do(ctx context.Context, method, path string, opts, v interface{}) error {
+	req, err := c.c.Client().NewRequest(method, path, opts, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return err
+	}
+	_, err = c.c.Client().Do(req, v)
+	return handleHTTPError(err)
+}
+
+func accessTokenFromAPI(apiObj *accessToken) gitprovider.AccessTokenInfo {
+	info := gitprovider.AccessTokenInfo{
+		ID:        int64(apiObj.ID),
+		Name:      apiObj.Name,
+		Scopes:    apiObj.Scopes,
+		CreatedAt: apiObj.CreatedAt,
+		Token:     apiObj.Token,
+	}
+	if permission, err := getGitProviderPermission(apiObj.AccessLevel); err == nil {
+		info.AccessLevel = permission
+	}
+	if apiObj.ExpiresAt != nil {
+		expiresAt := time.Time(*apiObj.ExpiresAt)
+		info.ExpiresAt = &expiresAt
+	}
+	return info
+}
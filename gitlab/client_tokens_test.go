@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestTokensClient(t *testing.T) {
+	var created, rotated map[string]interface{}
+	revoked := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/group/repo/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`[{"id": 1, "name": "ci", "scopes": ["api"], "access_level": 30, "active": true},
+				{"id": 2, "name": "old", "scopes": ["api"], "access_level": 30, "active": false, "revoked": true}]`))
+		case http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 3, "name": "flux", "scopes": ["read_repository"], "access_level": 40,
+				"expires_at": "2026-12-31", "active": true, "token": "glpat-secret"}`))
+		}
+	})
+	mux.HandleFunc("/api/v4/groups/group/access_tokens/3/rotate", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&rotated); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"id": 4, "name": "flux", "scopes": ["read_repository"], "access_level": 40,
+			"expires_at": "2027-06-30", "active": true, "token": "glpat-rotated"}`))
+	})
+	mux.HandleFunc("/api/v4/groups/group/access_tokens/4", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			revoked = true
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	client := newTestClient(t, mux)
+	c := &TokensClient{clientContext: client.clientContext}
+	ctx := context.Background()
+	org := gitprovider.OrganizationRef{Domain: client.domain, Organization: "group"}
+	repo := gitprovider.OrgRepositoryRef{OrganizationRef: org, RepositoryName: "repo"}
+
+	tokens, err := c.List(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].Name != "ci" || *tokens[0].AccessLevel != gitprovider.RepositoryPermissionPush {
+		t.Errorf("List() = %+v, want the active ci token", tokens)
+	}
+
+	expiresAt := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	token, err := c.Create(ctx, repo, gitprovider.AccessTokenInfo{Name: "flux", Scopes: []string{"read_repository"}, ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created["access_level"] != float64(40) || created["expires_at"] != "2026-12-31" {
+		t.Errorf("Create() sent %v, want maintainer access expiring 2026-12-31", created)
+	}
+	if token.ID != 3 || token.Token != "glpat-secret" || !token.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Create() = %+v", token)
+	}
+
+	token, err = c.Rotate(ctx, org, token.ID, time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated["expires_at"] != "2027-06-30" || token.ID != 4 || token.Token != "glpat-rotated" {
+		t.Errorf("Rotate() = %+v, sent %v", token, rotated)
+	}
+	if err := c.Revoke(ctx, org, token.ID); err != nil || !revoked {
+		t.Errorf("Revoke() = %v, revoked %v", err, revoked)
+	}
+
+	user := gitprovider.UserRef{Domain: client.domain, UserLogin: "alice"}
+	if _, err := c.List(ctx, user); !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Errorf("List() of a user error = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestClient_TokenInfo(t *testing.T) {
+	selfFound := true
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 42, "username": "project_7_bot"}`))
+	})
+	mux.HandleFunc("/api/v4/personal_access_tokens/self", func(w http.ResponseWriter, r *http.Request) {
+		if !selfFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "404 Not Found"}`))
+			return
+		}
+		w.Write([]byte(`{"id": 3, "name": "flux", "scopes": ["api", "write_repository"], "expires_at": "2026-12-31", "active": true}`))
+	})
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+
+	info, err := c.TokenInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (gitprovider.Identity{ID: "42", Login: "project_7_bot", Bot: true}); info.Principal != want {
+		t.Errorf("TokenInfo().Principal = %+v, want %+v", info.Principal, want)
+	}
+	if !info.ScopesKnown || len(info.MissingScopes("api")) != 0 {
+		t.Errorf("TokenInfo() = %+v, want the api scope", info)
+	}
+	if info.ExpiresAt == nil || !info.Expired(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("TokenInfo().ExpiresAt = %v, want 2026-12-31", info.ExpiresAt)
+	}
+
+	selfFound = false
+	info, err = c.TokenInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ScopesKnown || info.Principal.Login != "project_7_bot" {
+		t.Errorf("TokenInfo() without token details = %+v, want only the principal", info)
+	}
+}
+
+func TestClient_ValidatePermissions(t *testing.T) {
+	scopes := `["api"]`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 42, "username": "flux"}`))
+	})
+	mux.HandleFunc("/api/v4/personal_access_tokens/self", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 3, "name": "flux", "scopes": ` + scopes + `, "active": true}`))
+	})
+	mux.HandleFunc("/api/v4/projects/group/repo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 7, "path": "repo", "permissions": {"project_access": {"access_level": 30}, "group_access": {"access_level": 40}}}`))
+	})
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: c.domain, Organization: "group"}, RepositoryName: "repo"}
+
+	contents := gitprovider.Permission{Scope: gitprovider.PermissionScopeContentsWrite, Repository: ref}
+	if err := c.ValidatePermissions(ctx, contents); err != nil {
+		t.Errorf("ValidatePermissions() of contents = %v, want nil", err)
+	}
+	admin := gitprovider.Permission{Scope: gitprovider.PermissionScopeRepositoryAdmin, Repository: ref}
+	if err := c.ValidatePermissions(ctx, admin); !errors.Is(err, gitprovider.ErrPermissionDenied) {
+		t.Errorf("ValidatePermissions() of admin = %v, want ErrPermissionDenied", err)
+	}
+
+	scopes = `["read_api", "write_repository"]`
+	if err := c.ValidatePermissions(ctx, contents); !errors.Is(err, gitprovider.ErrPermissionDenied) {
+		t.Errorf("ValidatePermissions() without the api scope = %v, want ErrPermissionDenied", err)
+	}
+}
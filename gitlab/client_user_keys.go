@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+const (
+	// keyTakenMagicString is part of the validation message of a key that is already in use.
+	keyTakenMagicString = "has already been taken"
+	// userKeysPageSize is the number of keys per page when listing the keys of the user.
+	userKeysPageSize = 100
+)
+
+// UserKeysClient implements the gitprovider.UserKeysClient interface.
+var _ gitprovider.UserKeysClient = &UserKeysClient{}
+
+// UserKeysClient operates on the SSH and GPG keys of the authenticated user.
+type UserKeysClient struct {
+	*clientContext
+}
+
+// List lists the keys of the given type.
+//
+// List returns all available keys, using multiple paginated requests if needed.
+func (c *UserKeysClient) List(ctx context.Context, keyType gitprovider.UserKeyType) ([]gitprovider.UserKeyInfo, error) {
+	if err := gitprovider.ValidateUserKeyType(keyType); err != nil {
+		return nil, fmt.Errorf("key type %q: %w", keyType, err)
+	}
+
+	keys := make([]gitprovider.UserKeyInfo, 0)
+	page := 1
+	for page != 0 {
+		var resp *gitlab.Response
+		var err error
+		if keyType == gitprovider.UserKeyTypeGPG {
+			var apiObjs []*gitlab.GPGKey
+			// GET /user/gpg_keys
+			apiObjs, resp, err = c.c.Client().Users.ListGPGKeys(withPage(page), gitlab.WithContext(ctx))
+			for _, apiObj := range apiObjs {
+				keys = append(keys, gpgKeyFromAPI(apiObj))
+			}
+		} else {
+			var apiObjs []*gitlab.SSHKey
+			// GET /user/keys
+			apiObjs, resp, err = c.c.Client().Users.ListSSHKeys(withPage(page), gitlab.WithContext(ctx))
+			for _, apiObj := range apiObjs {
+				keys = append(keys, sshKeyFromAPI(apiObj))
+			}
+		}
+		if err != nil {
+			return nil, handleHTTPError(err)
+		}
+		page = resp.NextPage
+	}
+	return keys, nil
+}
+
+// Add adds the given key, returning it as stored by the provider.
+//
+// ErrAlreadyExists is returned if the key is already in use.
+func (c *UserKeysClient) Add(ctx context.Context, req gitprovider.UserKeyInfo) (gitprovider.UserKeyInfo, error) {
+	if err := req.Validate(); err != nil {
+		return gitprovider.UserKeyInfo{}, err
+	}
+
+	if req.Type == gitprovider.UserKeyTypeGPG {
+		// POST /user/gpg_keys
+		apiObj, _, err := c.c.Client().Users.AddGPGKey(&gitlab.AddGPGKeyOptions{
+			Key: &req.Key,
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return gitprovider.UserKeyInfo{}, handleKeyError(err)
+		}
+		return gpgKeyFromAPI(apiObj), nil
+	}
+	// POST /user/keys
+	apiObj, _, err := c.c.Client().Users.AddSSHKey(&gitlab.AddSSHKeyOptions{
+		Title: &req.Title,
+		Key:   &req.Key,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return gitprovider.UserKeyInfo{}, handleKeyError(err)
+	}
+	return sshKeyFromAPI(apiObj), nil
+}
+
+// Delete removes the key of the given type and ID.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *UserKeysClient) Delete(ctx context.Context, keyType gitprovider.UserKeyType, id int64) error {
+	if err := gitprovider.ValidateUserKeyType(keyType); err != nil {
+		return fmt.Errorf("key type %q: %w", keyType, err)
+	}
+
+	var err error
+	if keyType == gitprovider.UserKeyTypeGPG {
+		// DELETE /user/gpg_keys/{key_id}
+		_, err = c.c.Client().Users.DeleteGPGKey(int(id), gitlab.WithContext(ctx))
+	} else {
+		// DELETE /user/keys/{key_id}
+		_, err = c.c.Client().Users.DeleteSSHKey(int(id), gitlab.WithContext(ctx))
+	}
+	return handleHTTPError(err)
+}
+
+// withPage requests the given page of a list, for the list calls of go-gitlab without options.
+func withPage(page int) gitlab.RequestOptionFunc {
+	return func(req *retryablehttp.Request) error {
+		q := req.URL.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(userKeysPageSize))
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}
+}
+
+// handleKeyError is handleHTTPError, also returning ErrAlreadyExists for keys already in use,
+// which GitLab reports as a validation error of the key or its fingerprint.
+func handleKeyError(err error) error {
+	glErrorResponse := &gitlab.ErrorResponse{}
+	if errors.As(err, &glErrorResponse) && strings.Contains(glErrorResponse.Message, keyTakenMagicString) {
+		return gitprovider.RedactError(validation.NewMultiError(err, gitprovider.ErrAlreadyExists))
+	}
+	return handleHTTPError(err)
+}
+
+func sshKeyFromAPI(apiObj *gitlab.SSHKey) gitprovider.UserKeyInfo {
+	return gitprovider.UserKeyInfo{
+		ID:        int64(apiObj.ID),
+		Type:      gitprovider.UserKeyTypeSSH,
+		Title:     apiObj.Title,
+		Key:       apiObj.Key,
+		CreatedAt: apiObj.CreatedAt,
+	}
+}
+
+func gpgKeyFromAPI(apiObj *gitlab.GPGKey) gitprovider.UserKeyInfo {
+	return gitprovider.UserKeyInfo{
+		ID:        int64(apiObj.ID),
+		Type:      gitprovider.UserKeyTypeGPG,
+		Key:       apiObj.Key,
+		CreatedAt: apiObj.CreatedAt,
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// ExtrasName is the name the GitLab Extras are registered under, for use with Client.Extension.
+const ExtrasName = "gitlab"
+
+// Extras contains GitLab-specific functionality that doesn't fit the generic interfaces.
+// It can be accessed using c.Extension(gitlab.ExtrasName).(gitlab.Extras), or ExtrasFor(c).
+type Extras interface {
+	// ServerVersion returns the version of the GitLab server, e.g. "14.4.2-ee". It can be
+	// passed to gitprovider.SupportedFeatures.
+	ServerVersion(ctx context.Context) (string, error)
+	// SetCodeOwnerApprovalRequired sets whether merge requests into the protected branch with the
+	// given name need the approval of the code owners of the changed files, which is reported by
+	// BranchRules.RequireCodeOwnerReview. It's a GitLab Premium feature.
+	//
+	// ErrNotFound is returned if the branch isn't protected.
+	SetCodeOwnerApprovalRequired(ctx context.Context, ref gitprovider.RepositoryRef, branch string, required bool) error
+}
+
+func init() {
+	gitprovider.RegisterExtension(ProviderID, ExtrasName, func(c gitprovider.Client) (interface{}, bool) {
+		cl, ok := c.(*Client)
+		if !ok {
+			return nil, false
+		}
+		return &extras{cl}, true
+	})
+}
+
+// The API calls reported with the changes of Reconcile calls, see gitprovider.ChangeAction.
+const (
+	apiCallCreateProject      = "POST /projects"
+	apiCallUpdateProject      = "PUT /projects/{project}"
+	apiCallProtectEnvironment = "POST /projects/{id}/protected_environments"
+	apiCallShareProject       = "POST /projects/{project}/share"
+	apiCallUnshareProject     = "DELETE /projects/{project}/share/{group_id}"
+	apiCallCreateApprovalRule = "POST /projects/{id}/approval_rules"
+	apiCallUpdateApprovalRule = "PUT /projects/{id}/approval_rules/{approval_rule_id}"
+	apiCallCreateDeployKey    = "POST /projects/{project}/deploy_keys"
+	apiCallDeleteDeployKey    = "DELETE /projects/{project}/deploy_keys/{key_id}"
+	apiCallProtectTag         = "POST /projects/{id}/protected_tags"
+)
+
+// ExtrasFor returns the GitLab Extras for the given client.
+// ErrNoProviderSupport is returned if c isn't a GitLab client.
+func ExtrasFor(c gitprovider.Client) (Extras, error) {
+	e, ok := c.Extension(ExtrasName).(Extras)
+	if !ok {
+		return nil, fmt.Errorf("%s client has no GitLab extras: %w", c.ProviderID(), gitprovider.ErrNoProviderSupport)
+	}
+	return e, nil
+}
+
+// extras implements Extras.
+type extras struct {
+	c *Client
+}
+
+func (e *extras) SetCodeOwnerApprovalRequired(ctx context.Context, ref gitprovider.RepositoryRef, branch string, required bool) error {
+	// PATCH /projects/{id}/protected_branches/{name}
+	_, err := e.c.c.Client().ProtectedBranches.RequireCodeOwnerApprovals(getRepoPath(ref), branch, &gitlab.RequireCodeOwnerApprovalsOptions{
+		CodeOwnerApprovalRequired: gitlab.Bool(required),
+	}, gitlab.WithContext(ctx))
+	return handleHTTPError(err)
+}
+
+func (e *extras) ServerVersion(ctx context.Context) (string, error) {
+	apiObj, err := e.c.c.GetVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	return apiObj.Version, nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/gitprovider/testutils"
+)
+
+func TestFeatureSupport(t *testing.T) {
+	// The probes must fail with ErrNoProviderSupport before any request for the unsupported
+	// features, and with other errors for the supported ones
+	c := newTestClient(t, http.NotFoundHandler())
+	orgRef := gitprovider.OrganizationRef{Domain: c.domain, Organization: "group"}
+	repoRef := gitprovider.OrgRepositoryRef{OrganizationRef: orgRef, RepositoryName: "repo"}
+	target := testutils.FeatureTarget{
+		Client:       c,
+		Organization: newOrganization(c.clientContext, &gitlab.Group{Path: "group", FullPath: "group"}, orgRef),
+		Repository:   newGroupProject(c.clientContext, &gitlab.Project{Path: "repo", PathWithNamespace: "group/repo"}, repoRef),
+	}
+	for _, err := range testutils.CheckFeatureSupport(context.Background(), target) {
+		t.Error(err)
+	}
+}
@@ -19,6 +19,8 @@ package gitlab
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -38,7 +40,7 @@ type gitlabClient interface {
 	// GetGroup is a wrapper for "GET /groups/{group}".
 	// This function HTTP error wrapping, and validates the server result.
 	GetGroup(ctx context.Context, groupID interface{}) (*gitlab.Group, error)
-	// ListGroups is a wrapper for "GET /groups".
+	// ListGroups is a wrapper for "GET /groups?top_level_only=true".
 	// This function handles pagination, HTTP error wrapping, and validates the server result.
 	ListGroups(ctx context.Context) ([]*gitlab.Group, error)
 	// ListSubgroups is a wrapper for "GET /groups/{group}/subgroups".
@@ -53,28 +55,50 @@ type gitlabClient interface {
 	// GetProject is a wrapper for "GET /projects/{project}".
 	// This function handles HTTP error wrapping, and validates the server result.
 	GetGroupProject(ctx context.Context, groupName string, projectName string) (*gitlab.Project, error)
-	// ListGroupProjects is a wrapper for "GET /groups/{group}/projects".
+	// ListGroupProjects is a wrapper for "GET /groups/{group}/projects", filtered by opts.
 	// This function handles pagination, HTTP error wrapping, and validates the server result.
-	ListGroupProjects(ctx context.Context, groupName string) ([]*gitlab.Project, error)
+	ListGroupProjects(ctx context.Context, groupName string, opts gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, error)
+	// ListGroupProjectsPage is a wrapper for "GET /groups/{group}/projects", filtered by opts, returning the given
+	// page and the number of the next one, or 0 if it's the last page.
+	// This function handles HTTP error wrapping, and validates the server result.
+	ListGroupProjectsPage(ctx context.Context, groupName string, opts gitlab.ListGroupProjectsOptions, page int) ([]*gitlab.Project, int, error)
 	// GetProject is a wrapper for "GET /projects/{project}".
 	// This function handles HTTP error wrapping, and validates the server result.
 	GetUserProject(ctx context.Context, projectName string) (*gitlab.Project, error)
-	// ListUserProjects is a wrapper for "GET /users/{username}/projects".
+	// GetCurrentUser is a wrapper for "GET /user".
+	// This function handles HTTP error wrapping.
+	GetCurrentUser(ctx context.Context) (*gitlab.User, error)
+	// GetVersion is a wrapper for "GET /version".
+	// This function handles HTTP error wrapping.
+	GetVersion(ctx context.Context) (*gitlab.Version, error)
+	// ListUserProjects is a wrapper for "GET /users/{username}/projects", filtered by opts.
 	// This function handles pagination, HTTP error wrapping, and validates the server result.
-	ListUserProjects(ctx context.Context, username string) ([]*gitlab.Project, error)
+	ListUserProjects(ctx context.Context, username string, opts gitlab.ListProjectsOptions) ([]*gitlab.Project, error)
+	// ListUserProjectsPage is a wrapper for "GET /users/{username}/projects", filtered by opts, returning the given
+	// page and the number of the next one, or 0 if it's the last page.
+	// This function handles HTTP error wrapping, and validates the server result.
+	ListUserProjectsPage(ctx context.Context, username string, opts gitlab.ListProjectsOptions, page int) ([]*gitlab.Project, int, error)
 	// ListProjectUsers is a wrapper for "GET /projects/{project}/users".
 	// This function handles pagination, HTTP error wrapping, and validates the server result.
 	ListProjectUsers(ctx context.Context, projectName string) ([]*gitlab.ProjectUser, error)
 	// CreateProject is a wrapper for "POST /projects"
 	// This function handles HTTP error wrapping, and validates the server result.
 	CreateProject(ctx context.Context, req *gitlab.Project, opts *gitlab.CreateProjectOptions) (*gitlab.Project, error)
-	// UpdateProject is a wrapper for "PUT /projects/{project}".
+	// UpdateProject is a wrapper for "PUT /projects/{project}", followed by SetProjectArchived
+	// if the archived state of the project differs from req.Archived.
 	// This function handles HTTP error wrapping, and validates the server result.
 	UpdateProject(ctx context.Context, req *gitlab.Project) (*gitlab.Project, error)
+	// SetProjectArchived is a wrapper for "POST /projects/{project}/archive" if archived is true,
+	// or "POST /projects/{project}/unarchive" otherwise.
+	// This function handles HTTP error wrapping, and validates the server result.
+	SetProjectArchived(ctx context.Context, projectID int, archived bool) (*gitlab.Project, error)
 	// DeleteProject is a wrapper for "DELETE /projects/{project}".
 	// This function handles HTTP error wrapping.
 	// DANGEROUS COMMAND: In order to use this, you must set destructiveActions to true.
 	DeleteProject(ctx context.Context, projectName string) error
+	// DownloadArchive is a wrapper for "GET /projects/{project}/repository/archive[.format]".
+	// This function handles HTTP error wrapping, the archive is streamed through the returned reader.
+	DownloadArchive(ctx context.Context, projectName, sha string, format gitprovider.ArchiveFormat) (io.ReadCloser, error)
 
 	// Deploy key methods
 
@@ -100,8 +124,9 @@ type gitlabClient interface {
 	// Commits
 
 	// ListCommitsPage is a wrapper for "GET /projects/{project}/repository/commits".
+	// The Path, Since, Until and WithStats options are passed on, Author is not supported by the API.
 	// This function handles pagination, HTTP error wrapping.
-	ListCommitsPage(projectName, branch string, perPage int, page int) ([]*gitlab.Commit, error)
+	ListCommitsPage(projectName, branch string, perPage int, page int, opts gitprovider.CommitListOptions) ([]*gitlab.Commit, error)
 }
 
 // gitlabClientImpl is a wrapper around *gitlab.Client, which implements higher-level methods,
@@ -111,6 +136,8 @@ type gitlabClient interface {
 type gitlabClientImpl struct {
 	c                  *gitlab.Client
 	destructiveActions bool
+	// concurrency is the number of pages the aggregate List* methods fetch at once
+	concurrency int
 }
 
 // gitlabClientImpl implements gitlabClient.
@@ -134,7 +161,9 @@ func (c *gitlabClientImpl) GetGroup(ctx context.Context, groupID interface{}) (*
 
 func (c *gitlabClientImpl) ListGroups(ctx context.Context) ([]*gitlab.Group, error) {
 	apiObjs := []*gitlab.Group{}
-	opts := &gitlab.ListGroupsOptions{}
+	opts := &gitlab.ListGroupsOptions{
+		TopLevelOnly: gitlab.Bool(true),
+	}
 	err := allGroupPages(opts, func() (*gitlab.Response, error) {
 		// GET /groups
 		pageObjs, resp, listErr := c.c.Groups.ListGroups(opts, gitlab.WithContext(ctx))
@@ -155,16 +184,17 @@ func (c *gitlabClientImpl) ListGroups(ctx context.Context) ([]*gitlab.Group, err
 
 func (c *gitlabClientImpl) ListSubgroups(ctx context.Context, groupName string) ([]*gitlab.Group, error) {
 	var apiObjs []*gitlab.Group
-	opts := &gitlab.ListSubgroupsOptions{}
-	err := allSubgroupPages(opts, func() (*gitlab.Response, error) {
-		// GET /groups
-		pageObjs, resp, listErr := c.c.Groups.ListSubgroups(groupName, opts, gitlab.WithContext(ctx))
-		apiObjs = append(apiObjs, pageObjs...)
-		return resp, listErr
+	pages, err := allPagesConcurrently(c.concurrency, func(page int) (interface{}, *gitlab.Response, error) {
+		// GET /groups/{group}/subgroups
+		opts := &gitlab.ListSubgroupsOptions{ListOptions: gitlab.ListOptions{Page: page}}
+		return c.c.Groups.ListSubgroups(groupName, opts, gitlab.WithContext(ctx))
 	})
 	if err != nil {
 		return nil, err
 	}
+	for _, pageObjs := range pages {
+		apiObjs = append(apiObjs, pageObjs.([]*gitlab.Group)...)
+	}
 	// Validate the API objects
 	for _, apiObj := range apiObjs {
 		if err := validateGroupAPI(apiObj); err != nil {
@@ -180,20 +210,37 @@ func (c *gitlabClientImpl) GetGroupProject(ctx context.Context, groupName string
 	return validateProjectAPIResp(apiObj, err)
 }
 
-func (c *gitlabClientImpl) ListGroupProjects(ctx context.Context, groupName string) ([]*gitlab.Project, error) {
+func (c *gitlabClientImpl) ListGroupProjects(ctx context.Context, groupName string, opts gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, error) {
 	var apiObjs []*gitlab.Project
-	opts := &gitlab.ListGroupProjectsOptions{}
-	err := allGroupProjectPages(opts, func() (*gitlab.Response, error) {
-		pageObjs, resp, listErr := c.c.Groups.ListGroupProjects(groupName, opts, gitlab.WithContext(ctx))
-		apiObjs = append(apiObjs, pageObjs...)
-		return resp, listErr
+	pages, err := allPagesConcurrently(c.concurrency, func(page int) (interface{}, *gitlab.Response, error) {
+		// GET /groups/{group}/projects
+		pageOpts := opts
+		pageOpts.Page = page
+		return c.c.Groups.ListGroupProjects(groupName, &pageOpts, gitlab.WithContext(ctx))
 	})
 	if err != nil {
 		return nil, err
 	}
+	for _, pageObjs := range pages {
+		apiObjs = append(apiObjs, pageObjs.([]*gitlab.Project)...)
+	}
 	return validateProjectObjects(apiObjs)
 }
 
+func (c *gitlabClientImpl) ListGroupProjectsPage(ctx context.Context, groupName string, opts gitlab.ListGroupProjectsOptions, page int) ([]*gitlab.Project, int, error) {
+	// GET /groups/{group}/projects
+	opts.Page = page
+	apiObjs, resp, err := c.c.Groups.ListGroupProjects(groupName, &opts, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, 0, handleHTTPError(err)
+	}
+	apiObjs, err = validateProjectObjects(apiObjs)
+	if err != nil {
+		return nil, 0, err
+	}
+	return apiObjs, resp.NextPage, nil
+}
+
 func validateProjectObjects(apiObjs []*gitlab.Project) ([]*gitlab.Project, error) {
 	for _, apiObj := range apiObjs {
 		// Make sure apiObj is valid
@@ -206,19 +253,43 @@ func validateProjectObjects(apiObjs []*gitlab.Project) ([]*gitlab.Project, error
 
 func (c *gitlabClientImpl) ListGroupMembers(ctx context.Context, groupName string) ([]*gitlab.GroupMember, error) {
 	var apiObjs []*gitlab.GroupMember
-	opts := &gitlab.ListGroupMembersOptions{}
-	err := allGroupMemberPages(opts, func() (*gitlab.Response, error) {
+	pages, err := allPagesConcurrently(c.concurrency, func(page int) (interface{}, *gitlab.Response, error) {
 		// GET /groups/{group}/members
-		pageObjs, resp, listErr := c.c.Groups.ListGroupMembers(groupName, opts, gitlab.WithContext(ctx))
-		apiObjs = append(apiObjs, pageObjs...)
-		return resp, listErr
+		opts := &gitlab.ListGroupMembersOptions{ListOptions: gitlab.ListOptions{Page: page}}
+		return c.c.Groups.ListGroupMembers(groupName, opts, gitlab.WithContext(ctx))
 	})
 	if err != nil {
 		return nil, err
 	}
+	for _, pageObjs := range pages {
+		apiObjs = append(apiObjs, pageObjs.([]*gitlab.GroupMember)...)
+	}
 	return apiObjs, nil
 }
 
+func (c *gitlabClientImpl) GetCurrentUser(ctx context.Context) (*gitlab.User, error) {
+	// GET /user
+	apiObj, _, err := c.c.Users.CurrentUser(gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return apiObj, nil
+}
+
+func (c *gitlabClientImpl) GetVersion(ctx context.Context) (*gitlab.Version, error) {
+	// GET /version
+	// Version.GetVersion doesn't take request options, so build the request to pass ctx
+	req, err := c.c.NewRequest(http.MethodGet, "version", nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, err
+	}
+	apiObj := &gitlab.Version{}
+	if _, err := c.c.Do(req, apiObj); err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return apiObj, nil
+}
+
 func (c *gitlabClientImpl) GetUserProject(ctx context.Context, projectName string) (*gitlab.Project, error) {
 	opts := &gitlab.GetProjectOptions{}
 	apiObj, _, err := c.c.Projects.GetProject(projectName, opts, gitlab.WithContext(ctx))
@@ -267,12 +338,11 @@ func (c *gitlabClientImpl) ListProjectUsers(ctx context.Context, projectName str
 	return apiObjs, nil
 }
 
-func (c *gitlabClientImpl) ListUserProjects(ctx context.Context, username string) ([]*gitlab.Project, error) {
+func (c *gitlabClientImpl) ListUserProjects(ctx context.Context, username string, opts gitlab.ListProjectsOptions) ([]*gitlab.Project, error) {
 	var apiObjs []*gitlab.Project
-	opts := &gitlab.ListProjectsOptions{}
-	err := allProjectPages(opts, func() (*gitlab.Response, error) {
+	err := allProjectPages(&opts, func() (*gitlab.Response, error) {
 		// GET /projects/{project}/users
-		pageObjs, resp, listErr := c.c.Projects.ListUserProjects(username, opts, gitlab.WithContext(ctx))
+		pageObjs, resp, listErr := c.c.Projects.ListUserProjects(username, &opts, gitlab.WithContext(ctx))
 		apiObjs = append(apiObjs, pageObjs...)
 		return resp, listErr
 	})
@@ -282,6 +352,20 @@ func (c *gitlabClientImpl) ListUserProjects(ctx context.Context, username string
 	return apiObjs, nil
 }
 
+func (c *gitlabClientImpl) ListUserProjectsPage(ctx context.Context, username string, opts gitlab.ListProjectsOptions, page int) ([]*gitlab.Project, int, error) {
+	// GET /users/{username}/projects
+	opts.Page = page
+	apiObjs, resp, err := c.c.Projects.ListUserProjects(username, &opts, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, 0, handleHTTPError(err)
+	}
+	apiObjs, err = validateProjectObjects(apiObjs)
+	if err != nil {
+		return nil, 0, err
+	}
+	return apiObjs, resp.NextPage, nil
+}
+
 func (c *gitlabClientImpl) CreateProject(ctx context.Context, req *gitlab.Project, extraOpts *gitlab.CreateProjectOptions) (*gitlab.Project, error) {
 	var namespaceID int
 	// If the project doesn't belong to a user set its namespace ID
@@ -301,6 +385,9 @@ func (c *gitlabClientImpl) CreateProject(ctx context.Context, req *gitlab.Projec
 	opts.DefaultBranch = &req.DefaultBranch
 	opts.Description = &req.Description
 	opts.Visibility = &req.Visibility
+	if req.Topics != nil {
+		opts.Topics = &req.Topics
+	}
 	if namespaceID != 0 {
 		opts.NamespaceID = &namespaceID
 	}
@@ -315,7 +402,33 @@ func (c *gitlabClientImpl) UpdateProject(ctx context.Context, req *gitlab.Projec
 		Description: &req.Description,
 		Visibility:  &req.Visibility,
 	}
+	if req.Topics != nil {
+		opts.Topics = &req.Topics
+	}
+	// The merge settings are only known if the project was fetched from the server
+	if req.MergeMethod != "" {
+		opts.MergeMethod = &req.MergeMethod
+		opts.SquashOption = &req.SquashOption
+		opts.RemoveSourceBranchAfterMerge = &req.RemoveSourceBranchAfterMerge
+	}
 	apiObj, _, err := c.c.Projects.EditProject(req.ID, opts, gitlab.WithContext(ctx))
+	apiObj, err = validateProjectAPIResp(apiObj, err)
+	if err != nil || apiObj.Archived == req.Archived {
+		return apiObj, err
+	}
+	return c.SetProjectArchived(ctx, apiObj.ID, req.Archived)
+}
+
+func (c *gitlabClientImpl) SetProjectArchived(ctx context.Context, projectID int, archived bool) (*gitlab.Project, error) {
+	var apiObj *gitlab.Project
+	var err error
+	if archived {
+		// POST /projects/{project}/archive
+		apiObj, _, err = c.c.Projects.ArchiveProject(projectID, gitlab.WithContext(ctx))
+	} else {
+		// POST /projects/{project}/unarchive
+		apiObj, _, err = c.c.Projects.UnarchiveProject(projectID, gitlab.WithContext(ctx))
+	}
 	return validateProjectAPIResp(apiObj, err)
 }
 
@@ -329,6 +442,32 @@ func (c *gitlabClientImpl) DeleteProject(ctx context.Context, projectName string
 	return err
 }
 
+func (c *gitlabClientImpl) DownloadArchive(ctx context.Context, projectName, sha string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	if err := gitprovider.ValidateArchiveFormat(format); err != nil {
+		return nil, fmt.Errorf("unsupported archive format %q: %w", format, gitprovider.ErrInvalidArgument)
+	}
+	opts := &gitlab.ArchiveOptions{
+		Format: gitlab.String(string(format)),
+	}
+	if sha != "" {
+		opts.SHA = &sha
+	}
+
+	// StreamArchive doesn't expose the response headers before streaming the body, so the
+	// range headers are ignored, and a resumed download skips the content already read
+	return gitprovider.NewResumableReader(ctx, func(ctx context.Context, _ http.Header) (*http.Response, error) {
+		body, err := streamResponse(func(w io.Writer) error {
+			// GET /projects/{project}/repository/archive[.format]
+			_, err := c.c.Repositories.StreamArchive(projectName, w, opts, gitlab.WithContext(ctx))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: body, ContentLength: -1}, nil
+	}, gitprovider.DefaultMaxDownloadResumes)
+}
+
 func (c *gitlabClientImpl) ListKeys(projectName string) ([]*gitlab.DeployKey, error) {
 	apiObjs := []*gitlab.DeployKey{}
 	opts := &gitlab.ListProjectDeployKeysOptions{}
@@ -390,7 +529,7 @@ func (c *gitlabClientImpl) UnshareProject(projectName string, groupID int) error
 	return handleHTTPError(err)
 }
 
-func (c *gitlabClientImpl) ListCommitsPage(projectName string, branch string, perPage int, page int) ([]*gitlab.Commit, error) {
+func (c *gitlabClientImpl) ListCommitsPage(projectName string, branch string, perPage int, page int, listOpts gitprovider.CommitListOptions) ([]*gitlab.Commit, error) {
 	apiObjs := make([]*gitlab.Commit, 0)
 
 	opts := gitlab.ListCommitsOptions{
@@ -398,18 +537,24 @@ func (c *gitlabClientImpl) ListCommitsPage(projectName string, branch string, pe
 			PerPage: perPage,
 			Page:    page,
 		},
-		RefName: &branch,
+		RefName:   &branch,
+		Path:      listOpts.Path,
+		Since:     listOpts.Since,
+		Until:     listOpts.Until,
+		WithStats: listOpts.WithStats,
 	}
 
 	// GET /projects/{id}/repository/commits
 	pageObjs, _, listErr := c.c.Commits.ListCommits(projectName, &opts)
 	for _, c := range pageObjs {
 		apiObjs = append(apiObjs, &gitlab.Commit{
-			ID:         c.ID,
-			AuthorName: c.AuthorName,
-			Message:    c.Message,
-			CreatedAt:  c.CreatedAt,
-			WebURL:     c.WebURL,
+			ID:          c.ID,
+			AuthorName:  c.AuthorName,
+			AuthorEmail: c.AuthorEmail,
+			Message:     c.Message,
+			CreatedAt:   c.CreatedAt,
+			WebURL:      c.WebURL,
+			Stats:       c.Stats,
 		})
 	}
 
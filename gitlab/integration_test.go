@@ -38,6 +38,7 @@ import (
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	testutils "github.com/fluxcd/go-git-providers/gitprovider/testutils"
+	"github.com/fluxcd/go-git-providers/gittestutils"
 )
 
 const (
@@ -257,35 +258,13 @@ var _ = Describe("GitLab Provider", func() {
 	}
 
 	cleanupOrgRepos := func(prefix string) {
-		fmt.Fprintf(os.Stderr, "Deleting repos starting with %s in org: %s\n", prefix, testOrgName)
-		repos, err := c.OrgRepositories().List(ctx, newOrgRef(testOrgName))
-		Expect(err).ToNot(HaveOccurred())
-		for _, repo := range repos {
-			// Delete the test org repo used
-			name := repo.Repository().GetRepository()
-			if !strings.HasPrefix(name, prefix) {
-				continue
-			}
-			fmt.Fprintf(os.Stderr, "Deleting the org repo: %s\n", name)
-			repo.Delete(ctx)
-			Expect(err).ToNot(HaveOccurred())
-		}
+		fixture := gittestutils.NewFixture(c, newOrgRef(testOrgName), newUserRef(testUserName), "")
+		Expect(fixture.CleanupOrgRepositories(ctx, prefix)).To(Succeed())
 	}
 
 	cleanupUserRepos := func(prefix string) {
-		fmt.Fprintf(os.Stderr, "Deleting repos starting with %s for user: %s\n", prefix, testUserName)
-		repos, err := c.UserRepositories().List(ctx, newUserRef(testUserName))
-		Expect(err).ToNot(HaveOccurred())
-		for _, repo := range repos {
-			// Delete the test org repo used
-			name := repo.Repository().GetRepository()
-			if !strings.HasPrefix(name, prefix) {
-				continue
-			}
-			fmt.Fprintf(os.Stderr, "Deleting the org repo: %s\n", name)
-			repo.Delete(ctx)
-			Expect(err).ToNot(HaveOccurred())
-		}
+		fixture := gittestutils.NewFixture(c, newOrgRef(testOrgName), newUserRef(testUserName), "")
+		Expect(fixture.CleanupUserRepositories(ctx, prefix)).To(Succeed())
 	}
 
 	It("should list the available organizations the user has access to", func() {
@@ -352,7 +331,7 @@ var _ = Describe("GitLab Provider", func() {
 
 		// Generate a repository name which doesn't exist already
 		testOrgRepoName = fmt.Sprintf("test-org-repo-%03d", rand.Intn(1000))
-		for findOrgRepo(repos, testOrgRepoName) != nil {
+		for gittestutils.FindOrgRepository(repos, testOrgRepoName) != nil {
 			testOrgRepoName = fmt.Sprintf("test-org-repo-%03d", rand.Intn(1000))
 		}
 
@@ -458,7 +437,7 @@ var _ = Describe("GitLab Provider", func() {
 
 		// Generate an org repo name which doesn't exist already
 		testSharedOrgRepoName = fmt.Sprintf("test-shared-org-repo-%03d", rand.Intn(1000))
-		for findOrgRepo(repos, testSharedOrgRepoName) != nil {
+		for gittestutils.FindOrgRepository(repos, testSharedOrgRepoName) != nil {
 			testSharedOrgRepoName = fmt.Sprintf("test-shared-org-repo-%03d", rand.Intn(1000))
 		}
 
@@ -632,7 +611,7 @@ var _ = Describe("GitLab Provider", func() {
 
 		// Generate a repository name which doesn't exist already
 		testRepoName = fmt.Sprintf("test-repo-%03d", rand.Intn(1000))
-		for findUserRepo(repos, testRepoName) != nil {
+		for gittestutils.FindUserRepository(repos, testRepoName) != nil {
 			testRepoName = fmt.Sprintf("test-repo-%03d", rand.Intn(1000))
 		}
 
@@ -968,27 +947,3 @@ func newUserRepoRef(userLogin, repoName string) gitprovider.UserRepositoryRef {
 		RepositoryName: repoName,
 	}
 }
-
-func findOrgRepo(repos []gitprovider.OrgRepository, name string) gitprovider.OrgRepository {
-	if name == "" {
-		return nil
-	}
-	for _, repo := range repos {
-		if repo.Repository().GetRepository() == name {
-			return repo
-		}
-	}
-	return nil
-}
-
-func findUserRepo(repos []gitprovider.UserRepository, name string) gitprovider.UserRepository {
-	if name == "" {
-		return nil
-	}
-	for _, repo := range repos {
-		if repo.Repository().GetRepository() == name {
-			return repo
-		}
-	}
-	return nil
-}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// getRepositoryStats returns the statistics of the project, which take three requests: the
+// project with its statistics, its languages, and the first page of its contributors, whose
+// total count GitLab reports in a header.
+func getRepositoryStats(ctx context.Context, c *clientContext, projectID int) (*gitprovider.RepositoryStats, error) {
+	// GET /projects/{id}?statistics=true
+	project, _, err := c.c.Client().Projects.GetProject(projectID, &gitlab.GetProjectOptions{Statistics: gitlab.Bool(true)}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	stats := &gitprovider.RepositoryStats{}
+	if project.Statistics != nil {
+		stats.SizeKB = project.Statistics.RepositorySize / 1024
+		stats.CommitCount = project.Statistics.CommitCount
+	}
+
+	// GET /projects/{id}/languages
+	languages, _, err := c.c.Client().Projects.GetProjectLanguages(projectID, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	if languages != nil {
+		shares := make(map[string]float64, len(*languages))
+		for name, percentage := range *languages {
+			shares[name] = float64(percentage)
+		}
+		stats.Languages = gitprovider.NewRepositoryLanguages(shares)
+	}
+
+	// GET /projects/{id}/repository/contributors
+	contributors, resp, err := c.c.Client().Repositories.Contributors(projectID, &gitlab.ListContributorsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 1},
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	stats.ContributorCount = len(contributors)
+	if resp != nil && resp.TotalItems != 0 {
+		stats.ContributorCount = resp.TotalItems
+	}
+	return stats, nil
+}
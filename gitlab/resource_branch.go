@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func newBranch(ctx *clientContext, apiObj *gitlab.Branch) *branch {
+	return &branch{
+		clientContext: ctx,
+		b:             *apiObj,
+	}
+}
+
+var _ gitprovider.Branch = &branch{}
+
+type branch struct {
+	*clientContext
+
+	b gitlab.Branch
+}
+
+func (b *branch) Get() gitprovider.BranchInfo {
+	info := gitprovider.BranchInfo{
+		Name:      b.b.Name,
+		Protected: b.b.Protected,
+		Default:   b.b.Default,
+	}
+	if b.b.Commit != nil {
+		info.Sha = b.b.Commit.ID
+	}
+	return info
+}
+
+func (b *branch) APIObject() interface{} {
+	return &b.b
+}
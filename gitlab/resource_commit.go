@@ -45,11 +45,26 @@ func (c *commitType) APIObject() interface{} {
 }
 
 func commitFromAPI(apiObj *gitlab.Commit) gitprovider.CommitInfo {
-	return gitprovider.CommitInfo{
-		Sha:       apiObj.ID,
-		Author:    apiObj.AuthorName,
-		Message:   apiObj.Message,
-		CreatedAt: *apiObj.CreatedAt,
-		URL:       apiObj.WebURL,
+	info := gitprovider.CommitInfo{
+		Sha:            apiObj.ID,
+		Author:         apiObj.AuthorName,
+		AuthorEmail:    apiObj.AuthorEmail,
+		Committer:      apiObj.CommitterName,
+		CommitterEmail: apiObj.CommitterEmail,
+		Message:        apiObj.Message,
+		Parents:        apiObj.ParentIDs,
+		CreatedAt:      *apiObj.CreatedAt,
+		URL:            apiObj.WebURL,
 	}
+	if apiObj.CommittedDate != nil {
+		info.CommittedAt = *apiObj.CommittedDate
+	}
+	if apiObj.Stats != nil {
+		info.Stats = &gitprovider.CommitStats{
+			Additions: apiObj.Stats.Additions,
+			Deletions: apiObj.Stats.Deletions,
+			Total:     apiObj.Stats.Total,
+		}
+	}
+	return info
 }
@@ -20,7 +20,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"reflect"
 
 	"github.com/xanzy/go-gitlab"
 
@@ -31,6 +30,7 @@ import (
 func newDeployKey(c *DeployKeyClient, key *gitlab.DeployKey) *deployKey {
 	return &deployKey{
 		k:       *key,
+		read:    deployKeyFromAPI(key),
 		c:       c,
 		canpush: key.CanPush,
 	}
@@ -39,7 +39,10 @@ func newDeployKey(c *DeployKeyClient, key *gitlab.DeployKey) *deployKey {
 var _ gitprovider.DeployKey = &deployKey{}
 
 type deployKey struct {
-	k       gitlab.DeployKey
+	k gitlab.DeployKey
+	// read is the state of the deploy key when it was last read from the server, checked by
+	// Update with optimistic concurrency
+	read    gitprovider.DeployKeyInfo
 	c       *DeployKeyClient
 	canpush *bool
 }
@@ -64,6 +67,14 @@ func (dk *deployKey) Repository() gitprovider.RepositoryRef {
 	return dk.c.ref
 }
 
+func (dk *deployKey) Timestamps() gitprovider.Timestamps {
+	key := fmt.Sprintf("%s/keys/%d", dk.c.ref.String(), dk.k.ID)
+	return gitprovider.Timestamps{
+		CreatedAt:  dk.k.CreatedAt,
+		Generation: dk.c.generations.Observe(key, gitprovider.ObjectVersion(nil, &dk.k)),
+	}
+}
+
 // Update will apply the desired state in this object to the server.
 // Only set fields will be respected (i.e. PATCH behaviour).
 // In order to apply changes to this object, use the .Set({Resource}Info) error
@@ -73,7 +84,27 @@ func (dk *deployKey) Repository() gitprovider.RepositoryRef {
 // ErrNotFound is returned if the resource does not exist.
 //
 // The internal API object will be overridden with the received server data.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the deploy key was
+// changed since it was read.
 func (dk *deployKey) Update(ctx context.Context) error {
+	if dk.c.optimisticConcurrency {
+		// GET /projects/{project}/deploy_keys
+		actual, err := dk.c.get(dk.read.Name)
+		if err != nil {
+			return err
+		}
+		object := fmt.Sprintf("%s/keys/%s", dk.c.ref.String(), dk.read.Name)
+		if err := gitprovider.CheckUnchanged(object, dk.read, actual.Get()); err != nil {
+			return err
+		}
+	}
+	return dk.update(ctx)
+}
+
+// update applies the desired state in this object to the server, without checking for
+// concurrent changes.
+func (dk *deployKey) update(ctx context.Context) error {
 	// Delete the old key and recreate
 	if err := dk.Delete(ctx); err != nil {
 		return err
@@ -107,23 +138,44 @@ func (dk *deployKey) Reconcile(ctx context.Context) (bool, error) {
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
-			return true, dk.createIntoSelf()
+			change := gitprovider.NewCreateChange(dk.c.ref, dk.Get())
+			if dk.c.dryRun {
+				return true, gitprovider.NewDryRunError(change)
+			}
+			if err := dk.createIntoSelf(); err != nil {
+				return true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateDeployKey)
+			return true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
 		return false, err
 	}
 
-	// Use wrappers here to extract the "spec" part of the object for comparison
-	desiredSpec := newGitlabKeySpec(&dk.k)
-	actualSpec := newGitlabKeySpec(&actual.k)
+	// Default the desired state, so that an unset read-only flag is compared as it's created
+	req := dk.Get()
+	req.Default()
 
-	// If the desired matches the actual state, do nothing
-	if desiredSpec.Equals(actualSpec) {
+	// If the desired matches the actual state, do nothing. This compares the name, the read-only
+	// flag and the key material; a difference in any of them rotates the key.
+	if req.Equals(actual.Get()) {
 		return false, nil
 	}
-	// If desired and actual state mis-match, update
-	return true, dk.Update(ctx)
+	change := gitprovider.NewUpdateChange(dk.c.ref, actual.Get(), dk.Get())
+	// In dry-run mode, only report what would be updated
+	if dk.c.dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
+	// If desired and actual state mis-match, replace the actual key
+	dk.k.ID = actual.k.ID
+	// Reconcile overwrites the actual state by design, so there's no need to check for
+	// concurrent changes
+	if err := dk.update(ctx); err != nil {
+		return true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallDeleteDeployKey, apiCallCreateDeployKey)
+	return true, nil
 }
 
 func (dk *deployKey) createIntoSelf() error {
@@ -133,6 +185,7 @@ func (dk *deployKey) createIntoSelf() error {
 		return err
 	}
 	dk.k = *apiObj
+	dk.read = deployKeyFromAPI(apiObj)
 	return nil
 }
 
@@ -148,10 +201,15 @@ func validateDeployKeyAPI(apiObj *gitlab.DeployKey) error {
 }
 
 func deployKeyFromAPI(apiObj *gitlab.DeployKey) gitprovider.DeployKeyInfo {
-	return gitprovider.DeployKeyInfo{
+	info := gitprovider.DeployKeyInfo{
 		Name: apiObj.Title,
 		Key:  []byte(apiObj.Key),
 	}
+	// GitLab keys are read-only unless they can push
+	if apiObj.CanPush != nil {
+		info.ReadOnly = gitprovider.BoolVar(!*apiObj.CanPush)
+	}
+	return info
 }
 
 func deployKeyToAPI(info *gitprovider.DeployKeyInfo) *gitlab.DeployKey {
@@ -175,24 +233,3 @@ func deployKeyInfoToAPIObj(info *gitprovider.DeployKeyInfo, apiObj *gitlab.Deplo
 		}
 	}
 }
-
-// This function copies over the fields that are part of create request of a deploy
-// i.e. the desired spec of the deploy key. This allows us to separate "spec" from "status" fields.
-func newGitlabKeySpec(key *gitlab.DeployKey) *gitlabKeySpec {
-	return &gitlabKeySpec{
-		&gitlab.DeployKey{
-			// Create-specific parameters
-			Title:   key.Title,
-			Key:     key.Key,
-			CanPush: key.CanPush,
-		},
-	}
-}
-
-type gitlabKeySpec struct {
-	*gitlab.DeployKey
-}
-
-func (s *gitlabKeySpec) Equals(other *gitlabKeySpec) bool {
-	return reflect.DeepEqual(s, other)
-}
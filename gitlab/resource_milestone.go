@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// The value of the "State" field of a gitlab milestone that is still open
+const milestoneActiveState = "active"
+
+func newMilestone(ctx *clientContext, apiObj *gitlab.Milestone) *milestone {
+	return &milestone{
+		clientContext: ctx,
+		m:             *apiObj,
+	}
+}
+
+var _ gitprovider.Milestone = &milestone{}
+
+type milestone struct {
+	*clientContext
+
+	m gitlab.Milestone
+}
+
+func (m *milestone) Get() gitprovider.MilestoneInfo {
+	return milestoneFromAPI(&m.m)
+}
+
+func (m *milestone) APIObject() interface{} {
+	return &m.m
+}
+
+func milestoneFromAPI(apiObj *gitlab.Milestone) gitprovider.MilestoneInfo {
+	info := gitprovider.MilestoneInfo{
+		Title:       apiObj.Title,
+		Description: &apiObj.Description,
+		Number:      apiObj.IID,
+		State:       gitprovider.MilestoneStateClosed,
+		WebURL:      apiObj.WebURL,
+	}
+	// GitLab calls open milestones "active"
+	if apiObj.State == milestoneActiveState {
+		info.State = gitprovider.MilestoneStateOpen
+	}
+	if apiObj.DueDate != nil {
+		dueDate := time.Time(*apiObj.DueDate)
+		info.DueDate = &dueDate
+	}
+	return info
+}
+
+func milestoneToAPI(info *gitprovider.MilestoneInfo) *gitlab.CreateMilestoneOptions {
+	opts := &gitlab.CreateMilestoneOptions{
+		Title:       &info.Title,
+		Description: info.Description,
+	}
+	if info.DueDate != nil {
+		dueDate := gitlab.ISOTime(*info.DueDate)
+		opts.DueDate = &dueDate
+	}
+	return opts
+}
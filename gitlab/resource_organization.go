@@ -17,6 +17,8 @@ limitations under the License.
 package gitlab
 
 import (
+	"strings"
+
 	"github.com/xanzy/go-gitlab"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -62,6 +64,24 @@ func (o *organization) Teams() gitprovider.TeamsClient {
 	return o.teams
 }
 
+// groupRef returns the hierarchical OrganizationRef of the given group, e.g. the group
+// "fluxcd/engineering/frontend" yields the sub-organizations ["engineering", "frontend"].
+func groupRef(domain string, apiObj *gitlab.Group) gitprovider.OrganizationRef {
+	fullPath := apiObj.FullPath
+	if fullPath == "" {
+		fullPath = apiObj.Path
+	}
+	parts := strings.Split(fullPath, "/")
+	ref := gitprovider.OrganizationRef{
+		Domain:       domain,
+		Organization: parts[0],
+	}
+	if len(parts) > 1 {
+		ref.SubOrganizations = parts[1:]
+	}
+	return ref
+}
+
 func organizationFromAPI(apiObj *gitlab.Group) gitprovider.OrganizationInfo {
 	return gitprovider.OrganizationInfo{
 		Name:        &apiObj.Name,
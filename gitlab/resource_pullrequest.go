@@ -21,8 +21,11 @@ import (
 	"github.com/xanzy/go-gitlab"
 )
 
-// The value of the "State" field of a gitlab merge request after it has been merged"
-const mergedState = "merged"
+// The values of the "State" field of a gitlab merge request after it has been merged or closed
+const (
+	mergedState = "merged"
+	closedState = "closed"
+)
 
 func newPullRequest(ctx *clientContext, apiObj *gitlab.MergeRequest) *pullrequest {
 	return &pullrequest{
@@ -47,10 +50,47 @@ func (pr *pullrequest) APIObject() interface{} {
 	return &pr.pr
 }
 
+func (pr *pullrequest) Timestamps() gitprovider.Timestamps {
+	return gitprovider.Timestamps{
+		CreatedAt:  pr.pr.CreatedAt,
+		UpdatedAt:  pr.pr.UpdatedAt,
+		Generation: pr.generations.Observe(pr.pr.WebURL, gitprovider.ObjectVersion(pr.pr.UpdatedAt, &pr.pr)),
+	}
+}
+
 func pullrequestFromAPI(apiObj *gitlab.MergeRequest) gitprovider.PullRequestInfo {
 	return gitprovider.PullRequestInfo{
 		Merged: apiObj.State == mergedState,
+		Closed: apiObj.State == closedState,
 		Number: apiObj.IID,
 		WebURL: apiObj.WebURL,
 	}
 }
+
+func newPullRequestComment(apiObj *gitlab.Note) *pullRequestComment {
+	return &pullRequestComment{
+		n: *apiObj,
+	}
+}
+
+var _ gitprovider.PullRequestComment = &pullRequestComment{}
+
+type pullRequestComment struct {
+	n gitlab.Note
+}
+
+func (c *pullRequestComment) Get() gitprovider.PullRequestCommentInfo {
+	info := gitprovider.PullRequestCommentInfo{
+		ID:     int64(c.n.ID),
+		Body:   c.n.Body,
+		Author: c.n.Author.Username,
+	}
+	if c.n.CreatedAt != nil {
+		info.CreatedAt = *c.n.CreatedAt
+	}
+	return info
+}
+
+func (c *pullRequestComment) APIObject() interface{} {
+	return &c.n
+}
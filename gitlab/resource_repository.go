@@ -19,6 +19,8 @@ package gitlab
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	gogitlab "github.com/xanzy/go-gitlab"
@@ -30,6 +32,7 @@ func newUserProject(ctx *clientContext, apiObj *gogitlab.Project, ref gitprovide
 	return &userProject{
 		clientContext: ctx,
 		p:             *apiObj,
+		read:          repositoryFromAPI(apiObj),
 		ref:           ref,
 		deployKeys: &DeployKeyClient{
 			clientContext: ctx,
@@ -51,6 +54,38 @@ func newUserProject(ctx *clientContext, apiObj *gogitlab.Project, ref gitprovide
 			clientContext: ctx,
 			ref:           ref,
 		},
+		milestones: &MilestoneClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		mergeQueue: &MergeQueueClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		collaborators: &CollaboratorClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		reactions: &ReactionClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		environments: &EnvironmentClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		badges: &BadgeClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		tagProtection: &TagProtectionClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		approvalRules: &ApprovalRuleClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
 	}
 }
 
@@ -61,16 +96,32 @@ type userProject struct {
 
 	p   gogitlab.Project
 	ref gitprovider.RepositoryRef
-
-	deployKeys   *DeployKeyClient
-	commits      *CommitClient
-	branches     *BranchClient
-	pullRequests *PullRequestClient
-	files        *FileClient
+	// read is the state of the project when it was last read from the server, checked by Update
+	// with optimistic concurrency
+	read gitprovider.RepositoryInfo
+	// stats is set if requested when getting the repository
+	stats *gitprovider.RepositoryStats
+
+	deployKeys    *DeployKeyClient
+	commits       *CommitClient
+	branches      *BranchClient
+	pullRequests  *PullRequestClient
+	files         *FileClient
+	milestones    *MilestoneClient
+	mergeQueue    *MergeQueueClient
+	collaborators *CollaboratorClient
+	reactions     *ReactionClient
+	environments  *EnvironmentClient
+	badges        *BadgeClient
+	tagProtection *TagProtectionClient
+	approvalRules *ApprovalRuleClient
 }
 
 func (p *userProject) Get() gitprovider.RepositoryInfo {
-	return repositoryFromAPI(&p.p)
+	info := repositoryFromAPI(&p.p)
+	info.Fork, info.Mirror = relationsFromAPI(p.domain, &p.p)
+	info.Stats = p.stats
+	return info
 }
 
 func (p *userProject) Set(info gitprovider.RepositoryInfo) error {
@@ -89,6 +140,16 @@ func (p *userProject) Repository() gitprovider.RepositoryRef {
 	return p.ref
 }
 
+// Timestamps returns the last activity time of the project as its update time, as GitLab
+// doesn't report when the project settings were updated.
+func (p *userProject) Timestamps() gitprovider.Timestamps {
+	return gitprovider.Timestamps{
+		CreatedAt:  p.p.CreatedAt,
+		UpdatedAt:  p.p.LastActivityAt,
+		Generation: p.generations.Observe(p.ref.String(), gitprovider.ObjectVersion(p.p.LastActivityAt, &p.p)),
+	}
+}
+
 func (p *userProject) DeployKeys() gitprovider.DeployKeyClient {
 	return p.deployKeys
 }
@@ -109,14 +170,66 @@ func (p *userProject) Files() gitprovider.FileClient {
 	return p.files
 }
 
+func (p *userProject) Milestones() gitprovider.MilestoneClient {
+	return p.milestones
+}
+
+func (p *userProject) MergeQueue() gitprovider.MergeQueueClient {
+	return p.mergeQueue
+}
+
+func (p *userProject) Collaborators() gitprovider.CollaboratorClient {
+	return p.collaborators
+}
+
+func (p *userProject) Reactions() gitprovider.ReactionClient {
+	return p.reactions
+}
+
+func (p *userProject) Environments() gitprovider.EnvironmentClient {
+	return p.environments
+}
+
+func (p *userProject) Badges() gitprovider.BadgeClient {
+	return p.badges
+}
+
+func (p *userProject) TagProtection() gitprovider.TagProtectionClient {
+	return p.tagProtection
+}
+
+func (p *userProject) ApprovalRules() gitprovider.ApprovalRuleClient {
+	return p.approvalRules
+}
+
 // The internal API object will be overridden with the received server data.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the project was
+// changed since it was read.
 func (p *userProject) Update(ctx context.Context) error {
+	if p.optimisticConcurrency {
+		// GET /projects/{project}
+		actual, err := p.c.GetUserProject(ctx, getRepoPath(p.ref))
+		if err != nil {
+			return err
+		}
+		if err := gitprovider.CheckUnchanged(p.ref.String(), p.read, repositoryFromAPI(actual)); err != nil {
+			return err
+		}
+	}
+	return p.update(ctx)
+}
+
+// update applies the desired state in this object to the server, without checking for
+// concurrent changes.
+func (p *userProject) update(ctx context.Context) error {
 	// PATCH /repos/{owner}/{repo}
 	apiObj, err := p.c.UpdateProject(ctx, &p.p)
 	if err != nil {
 		return err
 	}
 	p.p = *apiObj
+	p.read = repositoryFromAPI(apiObj)
 	return nil
 }
 
@@ -133,6 +246,10 @@ func (p *userProject) Reconcile(ctx context.Context) (bool, error) {
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(p.ref, p.Get())
+			if p.dryRun {
+				return true, gitprovider.NewDryRunError(change)
+			}
 			// orgName := ""
 			// if orgRef, ok := p.ref.(gitprovider.OrgRepositoryRef); ok {
 			// 	orgName = orgRef.Organization
@@ -142,6 +259,8 @@ func (p *userProject) Reconcile(ctx context.Context) (bool, error) {
 				return true, err
 			}
 			p.p = *project
+			p.read = repositoryFromAPI(project)
+			gitprovider.RecordChange(ctx, change, apiCallCreateProject)
 			return true, nil
 		}
 
@@ -152,12 +271,23 @@ func (p *userProject) Reconcile(ctx context.Context) (bool, error) {
 	desiredSpec := newGitlabProjectSpec(&p.p)
 	actualSpec := newGitlabProjectSpec(apiObj)
 
-	// If desired state already is the actual state, do nothing
-	if desiredSpec.Equals(actualSpec) {
+	// If desired state already is the actual state, do nothing. The topics aren't part of the
+	// spec, they're only managed if set
+	if desiredSpec.Equals(actualSpec) && (p.p.Topics == nil || gitprovider.EqualTopics(p.p.Topics, projectTopics(apiObj))) {
 		return false, nil
 	}
-	// Otherwise, make the desired state the actual state
-	return true, p.Update(ctx)
+	change := gitprovider.NewUpdateChange(p.ref, repositoryFromAPI(apiObj), p.Get())
+	// In dry-run mode, only report what would be updated
+	if p.dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
+	// Otherwise, make the desired state the actual state. Reconcile overwrites the actual state
+	// by design, so there's no need to check for concurrent changes
+	if err := p.update(ctx); err != nil {
+		return true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateProject)
+	return true, nil
 }
 
 // Delete deletes the current resource irreversibly.
@@ -210,11 +340,16 @@ func (r *orgRepository) Reconcile(ctx context.Context) (bool, error) {
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(r.ref, r.Get())
+			if r.dryRun {
+				return true, gitprovider.NewDryRunError(change)
+			}
 			project, err := r.c.CreateProject(ctx, &r.p, nil)
 			if err != nil {
 				return true, err
 			}
 			r.p = *project
+			gitprovider.RecordChange(ctx, change, apiCallCreateProject)
 			return true, nil
 		}
 
@@ -225,23 +360,70 @@ func (r *orgRepository) Reconcile(ctx context.Context) (bool, error) {
 	desiredSpec := newGitlabProjectSpec(&r.p)
 	actualSpec := newGitlabProjectSpec(apiObj)
 
-	// If desired state already is the actual state, do nothing
-	if desiredSpec.Equals(actualSpec) {
+	// If desired state already is the actual state, do nothing. The topics aren't part of the
+	// spec, they're only managed if set
+	if desiredSpec.Equals(actualSpec) && (r.p.Topics == nil || gitprovider.EqualTopics(r.p.Topics, projectTopics(apiObj))) {
 		return false, nil
 	}
+	change := gitprovider.NewUpdateChange(r.ref, repositoryFromAPI(apiObj), r.Get())
+	// In dry-run mode, only report what would be updated
+	if r.dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
 	// Otherwise, make the desired state the actual state
-	return true, r.Update(ctx)
+	if err := r.Update(ctx); err != nil {
+		return true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateProject)
+	return true, nil
 }
 
 func repositoryFromAPI(apiObj *gogitlab.Project) gitprovider.RepositoryInfo {
 	repo := gitprovider.RepositoryInfo{
 		Description:   &apiObj.Description,
 		DefaultBranch: &apiObj.DefaultBranch,
+		Topics:        projectTopics(apiObj),
+		Archived:      &apiObj.Archived,
+	}
+	if apiObj.MergeMethod != "" {
+		repo.MergeMethods = mergeMethodsFromAPI(apiObj.MergeMethod, apiObj.SquashOption)
+		repo.DeleteBranchOnMerge = &apiObj.RemoveSourceBranchAfterMerge
 	}
 	repo.Visibility = gitprovider.RepositoryVisibilityVar(gitprovider.RepositoryVisibility(apiObj.Visibility))
 	return repo
 }
 
+// relationsFromAPI returns the fork and mirror relationships of the project, which need the
+// domain of the client for the repository references. GitLab doesn't report the upstream URL of
+// pull mirrors, nor the namespace kind of the fork parent, which is addressed as a group.
+func relationsFromAPI(domain string, apiObj *gogitlab.Project) (*gitprovider.RepositoryFork, *gitprovider.RepositoryMirror) {
+	var fork *gitprovider.RepositoryFork
+	if parent := apiObj.ForkedFromProject; parent != nil {
+		parts := strings.Split(parent.PathWithNamespace, "/")
+		ref := gitprovider.OrgRepositoryRef{
+			OrganizationRef: gitprovider.OrganizationRef{Domain: domain, Organization: parts[0]},
+			RepositoryName:  parent.Path,
+		}
+		if len(parts) > 2 {
+			ref.SubOrganizations = parts[1 : len(parts)-1]
+		}
+		fork = &gitprovider.RepositoryFork{Parent: ref}
+	}
+	var mirror *gitprovider.RepositoryMirror
+	if apiObj.Mirror {
+		mirror = &gitprovider.RepositoryMirror{}
+	}
+	return fork, mirror
+}
+
+// projectTopics returns the topics of the project, which are called tag list before GitLab 14.0.
+func projectTopics(apiObj *gogitlab.Project) []string {
+	if apiObj.Topics == nil {
+		return apiObj.TagList
+	}
+	return apiObj.Topics
+}
+
 func repositoryToAPI(repo *gitprovider.RepositoryInfo, ref gitprovider.RepositoryRef) gogitlab.Project {
 	apiObj := gogitlab.Project{
 		Name: *gitprovider.StringVar(ref.GetRepository()),
@@ -260,6 +442,70 @@ func repositoryInfoToAPIObj(repo *gitprovider.RepositoryInfo, apiObj *gogitlab.P
 	if repo.Visibility != nil {
 		apiObj.Visibility = gitlabVisibilityMap[*repo.Visibility]
 	}
+	if repo.Topics != nil {
+		apiObj.Topics = repo.Topics
+	}
+	if repo.MergeMethods != nil {
+		apiObj.MergeMethod, apiObj.SquashOption = mergeMethodsToAPI(repo.MergeMethods)
+	}
+	if repo.DeleteBranchOnMerge != nil {
+		apiObj.RemoveSourceBranchAfterMerge = *repo.DeleteBranchOnMerge
+	}
+	if repo.Archived != nil {
+		apiObj.Archived = *repo.Archived
+	}
+}
+
+// mergeMethodsFromAPI returns the merge methods allowed by the merge method and squash option
+// of a project. Squashing is always done on top of the merge method in GitLab, hence a project
+// that always squashes only allows the squash merge method.
+func mergeMethodsFromAPI(method gogitlab.MergeMethodValue, squash gogitlab.SquashOptionValue) []gitprovider.MergeMethod {
+	if squash == gogitlab.SquashOptionAlways {
+		return []gitprovider.MergeMethod{gitprovider.MergeMethodSquash}
+	}
+	var methods []gitprovider.MergeMethod
+	switch method {
+	case gogitlab.NoFastForwardMerge:
+		methods = append(methods, gitprovider.MergeMethodMerge)
+	case gogitlab.RebaseMerge:
+		methods = append(methods, gitprovider.MergeMethodMerge, gitprovider.MergeMethodRebase)
+	case gogitlab.FastForwardMerge:
+		methods = append(methods, gitprovider.MergeMethodRebase)
+	}
+	// Before GitLab 14.0 there is no squash option, and squashing is chosen per merge request
+	if squash != gogitlab.SquashOptionNever {
+		methods = append(methods, gitprovider.MergeMethodSquash)
+	}
+	return methods
+}
+
+// mergeMethodsToAPI is the inverse of mergeMethodsFromAPI.
+func mergeMethodsToAPI(methods []gitprovider.MergeMethod) (gogitlab.MergeMethodValue, gogitlab.SquashOptionValue) {
+	var merge, rebase, squash bool
+	for _, m := range methods {
+		switch m {
+		case gitprovider.MergeMethodMerge:
+			merge = true
+		case gitprovider.MergeMethodRebase:
+			rebase = true
+		case gitprovider.MergeMethodSquash:
+			squash = true
+		}
+	}
+
+	method := gogitlab.NoFastForwardMerge
+	if merge && rebase {
+		method = gogitlab.RebaseMerge
+	} else if rebase {
+		method = gogitlab.FastForwardMerge
+	}
+	squashOption := gogitlab.SquashOptionNever
+	if squash && !merge && !rebase {
+		squashOption = gogitlab.SquashOptionAlways
+	} else if squash {
+		squashOption = gogitlab.SquashOptionDefaultOff
+	}
+	return method, squashOption
 }
 
 // This function copies over the fields that are part of create/update requests of a project
@@ -275,6 +521,12 @@ func newGitlabProjectSpec(project *gogitlab.Project) *gitlabProjectSpec {
 
 			// Update-specific parameters
 			DefaultBranch: project.DefaultBranch,
+			Archived:      project.Archived,
+
+			// Merge settings
+			MergeMethod:                  project.MergeMethod,
+			SquashOption:                 project.SquashOption,
+			RemoveSourceBranchAfterMerge: project.RemoveSourceBranchAfterMerge,
 		},
 	}
 }
@@ -293,3 +545,19 @@ var gitlabVisibilityMap = map[gitprovider.RepositoryVisibility]gogitlab.Visibili
 	gitprovider.RepositoryVisibilityPrivate:  gogitlab.PrivateVisibility,
 	gitprovider.RepositoryVisibilityPublic:   gogitlab.PublicVisibility,
 }
+
+// visibilityToAPI returns the GitLab visibility for the visibility filter, or nil if unset.
+func visibilityToAPI(visibility *gitprovider.RepositoryVisibility) *gogitlab.VisibilityValue {
+	if visibility == nil {
+		return nil
+	}
+	return gogitlab.Visibility(gitlabVisibilityMap[*visibility])
+}
+
+// lastActivityFromAPI returns the time of the last activity in the project, e.g. a push.
+func lastActivityFromAPI(apiObj *gogitlab.Project) time.Time {
+	if apiObj.LastActivityAt == nil {
+		return time.Time{}
+	}
+	return *apiObj.LastActivityAt
+}
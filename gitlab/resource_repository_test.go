@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func Test_mergeMethods(t *testing.T) {
+	tests := []struct {
+		name         string
+		methods      []gitprovider.MergeMethod
+		mergeMethod  gitlab.MergeMethodValue
+		squashOption gitlab.SquashOptionValue
+	}{
+		{
+			name:         "merge",
+			methods:      []gitprovider.MergeMethod{gitprovider.MergeMethodMerge},
+			mergeMethod:  gitlab.NoFastForwardMerge,
+			squashOption: gitlab.SquashOptionNever,
+		},
+		{
+			name:         "squash",
+			methods:      []gitprovider.MergeMethod{gitprovider.MergeMethodSquash},
+			mergeMethod:  gitlab.NoFastForwardMerge,
+			squashOption: gitlab.SquashOptionAlways,
+		},
+		{
+			name:         "merge and squash",
+			methods:      []gitprovider.MergeMethod{gitprovider.MergeMethodSquash, gitprovider.MergeMethodMerge},
+			mergeMethod:  gitlab.NoFastForwardMerge,
+			squashOption: gitlab.SquashOptionDefaultOff,
+		},
+		{
+			name:         "rebase",
+			methods:      []gitprovider.MergeMethod{gitprovider.MergeMethodRebase},
+			mergeMethod:  gitlab.FastForwardMerge,
+			squashOption: gitlab.SquashOptionNever,
+		},
+		{
+			name:         "merge, rebase and squash",
+			methods:      []gitprovider.MergeMethod{gitprovider.MergeMethodMerge, gitprovider.MergeMethodRebase, gitprovider.MergeMethodSquash},
+			mergeMethod:  gitlab.RebaseMerge,
+			squashOption: gitlab.SquashOptionDefaultOff,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mergeMethod, squashOption := mergeMethodsToAPI(tt.methods)
+			if mergeMethod != tt.mergeMethod || squashOption != tt.squashOption {
+				t.Errorf("mergeMethodsToAPI() = %q, %q, want %q, %q", mergeMethod, squashOption, tt.mergeMethod, tt.squashOption)
+			}
+			if got := mergeMethodsFromAPI(mergeMethod, squashOption); !gitprovider.EqualMergeMethods(got, tt.methods) {
+				t.Errorf("mergeMethodsFromAPI() = %v, want %v", got, tt.methods)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package gitlab
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -26,8 +27,9 @@ import (
 
 func newTeamAccess(c *TeamAccessClient, ta gitprovider.TeamAccessInfo) *teamAccess {
 	return &teamAccess{
-		ta: ta,
-		c:  c,
+		ta:   ta,
+		read: ta,
+		c:    c,
 	}
 }
 
@@ -35,7 +37,10 @@ var _ gitprovider.TeamAccess = &teamAccess{}
 
 type teamAccess struct {
 	ta gitprovider.TeamAccessInfo
-	c  *TeamAccessClient
+	// read is the state of the team access when it was last read from the server, checked by
+	// Update with optimistic concurrency
+	read gitprovider.TeamAccessInfo
+	c    *TeamAccessClient
 }
 
 func (ta *teamAccess) Get() gitprovider.TeamAccessInfo {
@@ -66,7 +71,28 @@ func (ta *teamAccess) Delete(ctx context.Context) error {
 	return ta.c.c.UnshareProject(getRepoPath(ta.c.ref), group.ID)
 }
 
+// Update applies the permission in this object to the server, sharing the project with the group
+// again if it's already shared.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the team access was
+// changed since it was read.
 func (ta *teamAccess) Update(ctx context.Context) error {
+	if ta.c.optimisticConcurrency {
+		actual, err := ta.c.Get(ctx, ta.read.Name)
+		if err != nil {
+			return err
+		}
+		object := fmt.Sprintf("%s/teams/%s", ta.c.ref.String(), ta.read.Name)
+		if err := gitprovider.CheckUnchanged(object, ta.read, actual.Get()); err != nil {
+			return err
+		}
+	}
+	return ta.update(ctx)
+}
+
+// update applies the permission in this object to the server, without checking for concurrent
+// changes.
+func (ta *teamAccess) update(ctx context.Context) error {
 	resp, err := ta.c.Create(ctx, ta.Get())
 	if err != nil {
 		if strings.Contains(err.Error(), alreadySharedWithGroup) {
@@ -78,7 +104,7 @@ func (ta *teamAccess) Update(ctx context.Context) error {
 			if err != nil {
 				return err
 			}
-			err = ta.Update(ctx)
+			err = ta.update(ctx)
 			if err != nil {
 				return err
 			}
@@ -86,6 +112,7 @@ func (ta *teamAccess) Update(ctx context.Context) error {
 		}
 		return err
 	}
+	ta.read = resp.Get()
 	return ta.Set(resp.Get())
 }
 
@@ -96,14 +123,27 @@ func (ta *teamAccess) Update(ctx context.Context) error {
 // If req is already the actual state, this is a no-op (actionTaken == false).
 func (ta *teamAccess) Reconcile(ctx context.Context) (bool, error) {
 	req := ta.Get()
+	// Default the permission, so that an unset one is compared as the provider stores it
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return false, err
+	}
+	if err := ta.Set(req); err != nil {
+		return false, err
+	}
 	actual, err := ta.c.Get(ctx, req.Name)
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(ta.c.ref, req)
+			if ta.c.dryRun {
+				return true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := ta.c.Create(ctx, req)
 			if err != nil {
 				return true, err
 			}
+			gitprovider.RecordChange(ctx, change, apiCallShareProject)
+			ta.read = resp.Get()
 			return true, ta.Set(resp.Get())
 		}
 
@@ -115,8 +155,19 @@ func (ta *teamAccess) Reconcile(ctx context.Context) (bool, error) {
 	if req.Equals(actual.Get()) {
 		return false, nil
 	}
+	change := gitprovider.NewUpdateChange(ta.c.ref, actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if ta.c.dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
 
-	return true, ta.Update(ctx)
+	// Reconcile overwrites the actual state by design, so there's no need to check for
+	// concurrent changes
+	if err := ta.update(ctx); err != nil {
+		return true, err
+	}
+	gitprovider.RecordChange(ctx, change, apiCallUnshareProject, apiCallShareProject)
+	return true, nil
 }
 
 //nolint
@@ -1,10 +1,15 @@
 package gitlab
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/fluxcd/go-git-providers/validation"
@@ -38,7 +43,7 @@ func allGroupPages(opts *gitlab.ListGroupsOptions, fn func() (*gitlab.Response,
 	}
 }
 
-func allSubgroupPages(opts *gitlab.ListSubgroupsOptions, fn func() (*gitlab.Response, error)) error {
+func allProjectPages(opts *gitlab.ListProjectsOptions, fn func() (*gitlab.Response, error)) error {
 	for {
 		resp, err := fn()
 		if err != nil {
@@ -51,7 +56,7 @@ func allSubgroupPages(opts *gitlab.ListSubgroupsOptions, fn func() (*gitlab.Resp
 	}
 }
 
-func allGroupProjectPages(opts *gitlab.ListGroupProjectsOptions, fn func() (*gitlab.Response, error)) error {
+func allProjectUserPages(opts *gitlab.ListProjectUserOptions, fn func() (*gitlab.Response, error)) error {
 	for {
 		resp, err := fn()
 		if err != nil {
@@ -64,7 +69,7 @@ func allGroupProjectPages(opts *gitlab.ListGroupProjectsOptions, fn func() (*git
 	}
 }
 
-func allGroupMemberPages(opts *gitlab.ListGroupMembersOptions, fn func() (*gitlab.Response, error)) error {
+func allDeployKeyPages(opts *gitlab.ListProjectDeployKeysOptions, fn func() (*gitlab.Response, error)) error {
 	for {
 		resp, err := fn()
 		if err != nil {
@@ -77,7 +82,46 @@ func allGroupMemberPages(opts *gitlab.ListGroupMembersOptions, fn func() (*gitla
 	}
 }
 
-func allProjectPages(opts *gitlab.ListProjectsOptions, fn func() (*gitlab.Response, error)) error {
+func allBranchPages(opts *gitlab.ListBranchesOptions, fn func() (*gitlab.Response, error)) error {
+	for {
+		resp, err := fn()
+		if err != nil {
+			return handleHTTPError(err)
+		}
+		if resp.NextPage == 0 {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func allMilestonePages(opts *gitlab.ListMilestonesOptions, fn func() (*gitlab.Response, error)) error {
+	for {
+		resp, err := fn()
+		if err != nil {
+			return handleHTTPError(err)
+		}
+		if resp.NextPage == 0 {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func allMergeRequestNotePages(opts *gitlab.ListMergeRequestNotesOptions, fn func() (*gitlab.Response, error)) error {
+	for {
+		resp, err := fn()
+		if err != nil {
+			return handleHTTPError(err)
+		}
+		if resp.NextPage == 0 {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func allProjectMemberPages(opts *gitlab.ListProjectMembersOptions, fn func() (*gitlab.Response, error)) error {
 	for {
 		resp, err := fn()
 		if err != nil {
@@ -90,7 +134,7 @@ func allProjectPages(opts *gitlab.ListProjectsOptions, fn func() (*gitlab.Respon
 	}
 }
 
-func allProjectUserPages(opts *gitlab.ListProjectUserOptions, fn func() (*gitlab.Response, error)) error {
+func allPendingInvitationPages(opts *gitlab.ListPendingInvitationsOptions, fn func() (*gitlab.Response, error)) error {
 	for {
 		resp, err := fn()
 		if err != nil {
@@ -103,7 +147,7 @@ func allProjectUserPages(opts *gitlab.ListProjectUserOptions, fn func() (*gitlab
 	}
 }
 
-func allDeployKeyPages(opts *gitlab.ListProjectDeployKeysOptions, fn func() (*gitlab.Response, error)) error {
+func allAwardEmojiPages(opts *gitlab.ListAwardEmojiOptions, fn func() (*gitlab.Response, error)) error {
 	for {
 		resp, err := fn()
 		if err != nil {
@@ -116,6 +160,51 @@ func allDeployKeyPages(opts *gitlab.ListProjectDeployKeysOptions, fn func() (*gi
 	}
 }
 
+// allPagesConcurrently calls fn with the page number to get, and fn returns the objects of that page.
+// Once the first page reveals the number of pages, the remaining ones are fetched with up to
+// concurrency requests at once. GitLab omits the number of pages for large collections, which are
+// fetched one by one. The objects of each page are returned in order.
+// There is no need to wrap the resulting error in handleHTTPError(err), as that's already done.
+func allPagesConcurrently(concurrency int, fn func(page int) (interface{}, *gitlab.Response, error)) ([]interface{}, error) {
+	// The first request doesn't set the page, as allGroupPages does
+	pageObjs, resp, err := fn(0)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	pages := []interface{}{pageObjs}
+	if concurrency <= 1 || resp.TotalPages == 0 {
+		for resp.NextPage != 0 {
+			pageObjs, resp, err = fn(resp.NextPage)
+			if err != nil {
+				return nil, handleHTTPError(err)
+			}
+			pages = append(pages, pageObjs)
+		}
+		return pages, nil
+	}
+	if resp.NextPage == 0 {
+		return pages, nil
+	}
+
+	rest := make([]interface{}, resp.TotalPages-resp.NextPage+1)
+	err = gitprovider.RunConcurrently(concurrency, len(rest), func(i int) error {
+		pageObjs, _, err := fn(resp.NextPage + i)
+		rest[i] = pageObjs
+		return err
+	})
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return append(pages, rest...), nil
+}
+
+// isEmptyProject returns true if the project referenced by ref doesn't have any commits yet.
+// GitLab doesn't respond consistently to operations on empty projects, hence the project is inspected.
+func isEmptyProject(ctx context.Context, c *clientContext, ref gitprovider.RepositoryRef) bool {
+	project, _, err := c.c.Client().Projects.GetProject(getRepoPath(ref), &gitlab.GetProjectOptions{}, gitlab.WithContext(ctx))
+	return err == nil && project.EmptyRepo
+}
+
 // validateUserRepositoryRef makes sure the UserRepositoryRef is valid for GitHub's usage.
 func validateUserRepositoryRef(ref gitprovider.UserRepositoryRef, expectedDomain string) error {
 	// Make sure the RepositoryRef fields are valid
@@ -208,36 +297,123 @@ func validateIdentityFields(ref gitprovider.IdentityRef, expectedDomain string)
 // handleHTTPError checks the type of err, and returns typed variants of it
 // However, it _always_ keeps the original error too, and just wraps it in a MultiError
 // The consumer must use errors.Is and errors.As to check for equality and get data out of it.
+// Secrets in the error message, e.g. echoed request parameters, are redacted.
 func handleHTTPError(err error) error {
+	return gitprovider.RedactError(typedHTTPError(err))
+}
+
+// classifyHTTPError returns the error of the gitprovider taxonomy matching a failed response,
+// from its status code and endpoint, e.g. a 406 Not Acceptable from the merge endpoint of a merge
+// request is a merge conflict, or else gitprovider.ClassifyHTTPError of the status code and
+// message.
+func classifyHTTPError(resp *http.Response, message string) error {
+	if req := resp.Request; req != nil && req.URL != nil {
+		if resp.StatusCode == http.StatusNotAcceptable && req.Method == http.MethodPut &&
+			strings.Contains(req.URL.Path, "/merge_requests/") && strings.HasSuffix(req.URL.Path, "/merge") {
+			return gitprovider.ErrMergeConflict
+		}
+	}
+	return gitprovider.ClassifyHTTPError(resp.StatusCode, message)
+}
+
+// typedHTTPError returns the typed variants of err for handleHTTPError.
+func typedHTTPError(err error) error {
 	// Short-circuit quickly if possible, allow always piping through this function
 	if err == nil {
 		return nil
 	}
 	glErrorResponse := &gitlab.ErrorResponse{}
 	if errors.As(err, &glErrorResponse) {
-		httpErr := gitprovider.HTTPError{
-			Response:     glErrorResponse.Response,
-			ErrorMessage: glErrorResponse.Error(),
-			Message:      glErrorResponse.Message,
+		httpErr := gitprovider.NewHTTPError(glErrorResponse.Response, glErrorResponse.Body)
+		httpErr.ErrorMessage = glErrorResponse.Error()
+		httpErr.Message = glErrorResponse.Message
+		// The archived repository, protected branch, merge conflict, etc. errors, if any
+		classified := classifyHTTPError(glErrorResponse.Response, glErrorResponse.Message)
+		// Check for rate limits, the retries of go-gitlab being exhausted
+		if glErrorResponse.Response.StatusCode == http.StatusTooManyRequests {
+			return validation.NewMultiError(err, newRateLimitError(httpErr))
 		}
 		// Check for invalid credentials, and return a typed error in that case
 		if glErrorResponse.Response.StatusCode == http.StatusForbidden ||
 			glErrorResponse.Response.StatusCode == http.StatusUnauthorized {
-			return validation.NewMultiError(err,
+			return newTypedError(err, classified,
 				&gitprovider.InvalidCredentialsError{HTTPError: httpErr},
 			)
 		}
 		// Check for 404 Not Found
 		if glErrorResponse.Response.StatusCode == http.StatusNotFound {
-			return validation.NewMultiError(err, gitprovider.ErrNotFound)
+			return validation.NewMultiError(err, &httpErr, gitprovider.ErrNotFound)
 		}
 		// Check for already exists errors
 		if strings.Contains(glErrorResponse.Message, alreadyExistsMagicString) {
-			return validation.NewMultiError(err, gitprovider.ErrAlreadyExists)
+			return validation.NewMultiError(err, &httpErr, gitprovider.ErrAlreadyExists)
+		}
+		// Check for invalid fields, which GitLab reports with 400 Bad Request, in the message
+		if glErrorResponse.Response.StatusCode == http.StatusBadRequest ||
+			glErrorResponse.Response.StatusCode == http.StatusUnprocessableEntity {
+			return newTypedError(err, classified, &gitprovider.ValidationError{HTTPError: httpErr})
 		}
 		// Otherwise, return a generic *HTTPError
-		return validation.NewMultiError(err, &httpErr)
+		return newTypedError(err, classified, &httpErr)
 	}
 	// Do nothing, just pipe through the unknown err
 	return err
 }
+
+// newTypedError returns a MultiError of err, its typed variant, and the classified error of the
+// taxonomy if it isn't nil.
+func newTypedError(err, classified, typedErr error) error {
+	if classified == nil {
+		return validation.NewMultiError(err, typedErr)
+	}
+	return validation.NewMultiError(err, typedErr, classified)
+}
+
+// newRateLimitError returns the RateLimitError of a 429 Too Many Requests response, with the
+// limit from the RateLimit-* headers, if any.
+func newRateLimitError(httpErr gitprovider.HTTPError) *gitprovider.RateLimitError {
+	rateLimitErr := &gitprovider.RateLimitError{HTTPError: httpErr}
+	header := httpErr.Response.Header
+	rateLimitErr.Limit, _ = strconv.Atoi(header.Get("RateLimit-Limit"))
+	rateLimitErr.Remaining, _ = strconv.Atoi(header.Get("RateLimit-Remaining"))
+	if reset, err := strconv.ParseInt(header.Get("RateLimit-Reset"), 10, 64); err == nil {
+		rateLimitErr.Reset = time.Unix(reset, 0)
+	}
+	return rateLimitErr
+}
+
+// streamResponse runs fn in a goroutine, streaming everything it writes to w through the returned reader.
+// fn is expected to write a response body to w only once its HTTP status has been checked, so an error
+// returned by fn before the first write is returned directly, wrapped with handleHTTPError.
+func streamResponse(fn func(w io.Writer) error) (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	w := &startedWriter{w: pw, started: make(chan struct{})}
+	errCh := make(chan error, 1)
+	go func() {
+		err := handleHTTPError(fn(w))
+		errCh <- err
+		pw.CloseWithError(err)
+	}()
+
+	select {
+	case <-w.started:
+		return pr, nil
+	case err := <-errCh:
+		if err != nil {
+			return nil, err
+		}
+		return pr, nil
+	}
+}
+
+// startedWriter closes the started channel on the first call to Write.
+type startedWriter struct {
+	w       io.Writer
+	started chan struct{}
+	once    sync.Once
+}
+
+func (w *startedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	return w.w.Write(p)
+}
@@ -17,8 +17,13 @@ limitations under the License.
 package gitlab
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/url"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -133,3 +138,261 @@ func Test_allGroupPages(t *testing.T) {
 		})
 	}
 }
+
+func Test_allPagesConcurrently(t *testing.T) {
+	tests := []struct {
+		name          string
+		concurrency   int
+		totalPages    int
+		failAt        int
+		expectedErrs  []error
+		expectedPages []interface{}
+	}{
+		{
+			name:          "one page only",
+			concurrency:   3,
+			totalPages:    1,
+			expectedPages: []interface{}{0},
+		},
+		{
+			name:          "four pages, sequential",
+			concurrency:   1,
+			totalPages:    4,
+			expectedPages: []interface{}{0, 2, 3, 4},
+		},
+		{
+			name:          "four pages, concurrent",
+			concurrency:   3,
+			totalPages:    4,
+			expectedPages: []interface{}{0, 2, 3, 4},
+		},
+		{
+			name:          "four pages, total not reported",
+			concurrency:   3,
+			expectedPages: []interface{}{0, 2, 3, 4},
+		},
+		{
+			name:         "four pages, concurrent, error at third",
+			concurrency:  3,
+			totalPages:   4,
+			failAt:       3,
+			expectedErrs: []error{&validation.MultiError{}, gitprovider.ErrNotFound, newGLError()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages, err := allPagesConcurrently(tt.concurrency, func(page int) (interface{}, *gitlab.Response, error) {
+				if page != 0 && page == tt.failAt {
+					return nil, nil, newGLError()
+				}
+				// page 0 is the first page, where the page number is omitted
+				resp := &gitlab.Response{TotalPages: tt.totalPages}
+				switch {
+				case page == 0 && len(tt.expectedPages) > 1, page == 0 && tt.failAt != 0:
+					resp.NextPage = 2
+				case page != 0 && page < 4:
+					resp.NextPage = page + 1
+				}
+				return page, resp, nil
+			})
+			validation.TestExpectErrors(t, "allPagesConcurrently", err, tt.expectedErrs...)
+			if tt.expectedErrs == nil && !reflect.DeepEqual(pages, tt.expectedPages) {
+				t.Errorf("allPagesConcurrently() = %v, want %v", pages, tt.expectedPages)
+			}
+		})
+	}
+}
+
+func Test_handleHTTPError(t *testing.T) {
+	newResponseError := func(statusCode int, message string, header http.Header) *gitlab.ErrorResponse {
+		return &gitlab.ErrorResponse{
+			Response: &http.Response{
+				Request:    &http.Request{Method: "GET", URL: &url.URL{}},
+				StatusCode: statusCode,
+				Header:     header,
+			},
+			Message: message,
+		}
+	}
+	tests := []struct {
+		name         string
+		err          error
+		expectedErrs []error
+	}{
+		{
+			name:         "not found",
+			err:          newResponseError(http.StatusNotFound, "404 Project Not Found", nil),
+			expectedErrs: []error{gitprovider.ErrNotFound},
+		},
+		{
+			name:         "forbidden",
+			err:          newResponseError(http.StatusForbidden, "403 Forbidden", nil),
+			expectedErrs: []error{&gitprovider.InvalidCredentialsError{}, gitprovider.ErrPermissionDenied},
+		},
+		{
+			name:         "protected branch",
+			err:          newResponseError(http.StatusForbidden, "Protected branch cannot be deleted", nil),
+			expectedErrs: []error{&gitprovider.InvalidCredentialsError{}, gitprovider.ErrBranchProtected},
+		},
+		{
+			name:         "merge conflict",
+			err:          newResponseError(http.StatusNotAcceptable, "Branch cannot be merged", nil),
+			expectedErrs: []error{&gitprovider.HTTPError{}, gitprovider.ErrMergeConflict},
+		},
+		{
+			name: "merge conflict, merge endpoint",
+			err: &gitlab.ErrorResponse{
+				Response: &http.Response{
+					Request:    &http.Request{Method: http.MethodPut, URL: &url.URL{Path: "/api/v4/projects/1/merge_requests/2/merge"}},
+					StatusCode: http.StatusNotAcceptable,
+				},
+				Message: "406 Not Acceptable",
+			},
+			expectedErrs: []error{&gitprovider.HTTPError{}, gitprovider.ErrMergeConflict},
+		},
+		{
+			name:         "validation failed",
+			err:          newResponseError(http.StatusBadRequest, "{path: [is invalid]}", nil),
+			expectedErrs: []error{&gitprovider.ValidationError{}, gitprovider.ErrValidationFailed},
+		},
+		{
+			name:         "rate limited",
+			err:          newResponseError(http.StatusTooManyRequests, "Retry later", http.Header{"Ratelimit-Limit": []string{"600"}, "Ratelimit-Reset": []string{"1700000000"}}),
+			expectedErrs: []error{&gitprovider.RateLimitError{}, gitprovider.ErrRateLimited},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := handleHTTPError(tt.err)
+			validation.TestExpectErrors(t, "handleHTTPError", err, tt.expectedErrs...)
+		})
+	}
+
+	rateLimitErr := &gitprovider.RateLimitError{}
+	if err := handleHTTPError(tests[len(tests)-1].err); !errors.As(err, &rateLimitErr) || rateLimitErr.Limit != 600 || rateLimitErr.Reset.Unix() != 1700000000 {
+		t.Errorf("handleHTTPError() = %+v, want the limit and reset of the headers", rateLimitErr)
+	}
+}
+
+func Test_streamResponse(t *testing.T) {
+	errFailed := errors.New("request failed")
+	tests := []struct {
+		name        string
+		fn          func(w io.Writer) error
+		wantContent string
+		wantErr     error
+		wantReadErr error
+	}{
+		{
+			name: "content is streamed",
+			fn: func(w io.Writer) error {
+				_, err := io.WriteString(w, "foo")
+				return err
+			},
+			wantContent: "foo",
+		},
+		{
+			name:        "empty content",
+			fn:          func(w io.Writer) error { return nil },
+			wantContent: "",
+		},
+		{
+			name: "error before the first write is returned",
+			fn: func(w io.Writer) error {
+				return &gitlab.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound, Request: &http.Request{Method: http.MethodGet, URL: &url.URL{}}}}
+			},
+			wantErr: gitprovider.ErrNotFound,
+		},
+		{
+			name: "error after the first write is returned by the reader",
+			fn: func(w io.Writer) error {
+				if _, err := io.WriteString(w, "foo"); err != nil {
+					return err
+				}
+				return errFailed
+			},
+			wantContent: "foo",
+			wantReadErr: errFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := streamResponse(tt.fn)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("streamResponse() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer body.Close()
+			content, err := io.ReadAll(body)
+			if !errors.Is(err, tt.wantReadErr) {
+				t.Errorf("ReadAll() error = %v, want %v", err, tt.wantReadErr)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("ReadAll() = %q, want %q", content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func Test_groupRef(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiObj *gitlab.Group
+		want   gitprovider.OrganizationRef
+	}{
+		{
+			name:   "top-level group",
+			apiObj: &gitlab.Group{Path: "fluxcd", FullPath: "fluxcd"},
+			want:   gitprovider.OrganizationRef{Domain: "gitlab.com", Organization: "fluxcd"},
+		},
+		{
+			name:   "nested subgroup",
+			apiObj: &gitlab.Group{Path: "frontend", FullPath: "fluxcd/engineering/frontend"},
+			want: gitprovider.OrganizationRef{
+				Domain:           "gitlab.com",
+				Organization:     "fluxcd",
+				SubOrganizations: []string{"engineering", "frontend"},
+			},
+		},
+		{
+			name:   "full path missing",
+			apiObj: &gitlab.Group{Path: "fluxcd"},
+			want:   gitprovider.OrganizationRef{Domain: "gitlab.com", Organization: "fluxcd"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groupRef("gitlab.com", tt.apiObj)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupRef() = %#v, want %#v", got, tt.want)
+			}
+			if got.GetIdentity() != tt.apiObj.FullPath && tt.apiObj.FullPath != "" {
+				t.Errorf("groupRef().GetIdentity() = %q, want %q", got.GetIdentity(), tt.apiObj.FullPath)
+			}
+		})
+	}
+}
+
+func Test_handleHTTPError_details(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "01HABCDEF")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": {"username": ["is invalid"]}}`))
+	})
+	c := newTestClient(t, mux)
+
+	_, err := c.AuthenticatedUserID(context.Background())
+	var httpErr *gitprovider.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("AuthenticatedUserID() error = %v, want an HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusBadRequest || httpErr.RequestID != "01HABCDEF" || !strings.Contains(httpErr.Body, "is invalid") {
+		t.Errorf("HTTPError = %+v, want the status code, request ID and body", httpErr)
+	}
+	if !errors.Is(err, gitprovider.ErrValidationFailed) {
+		t.Errorf("AuthenticatedUserID() error = %v, want ErrValidationFailed", err)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TargetError is the error of a single target of a multi-target operation.
+type TargetError struct {
+	// Target identifies the target that failed, e.g. the String() of a RepositoryRef.
+	Target string
+	// Err is the error that occurred for this target.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *TargetError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Target, e.Err)
+}
+
+// Unwrap returns the underlying error, for use with errors.Is and errors.As.
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+// AggregateError is returned by bulk helpers and fan-out operations that continue past the
+// failure of a single target, and holds the errors of all the targets that failed. Targets
+// that aren't listed succeeded.
+//
+// errors.Is and errors.As match the errors of any of the targets, e.g.
+// errors.Is(err, ErrNotFound) is true if any target wasn't found. In order to get the
+// per-target errors, use:
+//
+//	aggErr := &AggregateError{}
+//	if errors.As(err, &aggErr) { for _, targetErr := range aggErr.Errors { ... } }
+//
+// Add is safe for concurrent use.
+type AggregateError struct {
+	// Errors contains the errors of the failed targets, in the order they were added.
+	Errors []*TargetError
+
+	mu sync.Mutex
+}
+
+// Add records err for the given target. A nil err is ignored.
+func (e *AggregateError) Add(target string, err error) {
+	if err == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Errors = append(e.Errors, &TargetError{Target: target, Err: err})
+}
+
+// ErrorOrNil returns e if any target failed, and nil otherwise.
+func (e *AggregateError) ErrorOrNil() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error implements the error interface.
+func (e *AggregateError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d target(s) failed:", len(e.Errors))
+	for _, err := range e.Errors {
+		fmt.Fprintf(&sb, "\n- %s", err.Error())
+	}
+	return sb.String()
+}
+
+// Is implements the interface used by errors.Is, and checks all contained errors.
+func (e *AggregateError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As implements the interface used by errors.As, and checks all contained errors.
+func (e *AggregateError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAggregateError(t *testing.T) {
+	errs := &AggregateError{}
+	if err := errs.ErrorOrNil(); err != nil {
+		t.Fatalf("ErrorOrNil() = %v, want nil", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range []string{"org/ok", "org/gone"} {
+		target := target
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if target == "org/gone" {
+				errs.Add(target, ErrNotFound)
+				return
+			}
+			errs.Add(target, nil)
+		}()
+	}
+	wg.Wait()
+
+	err := errs.ErrorOrNil()
+	if err == nil {
+		t.Fatal("ErrorOrNil() = nil, want an error")
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("errors.Is(%v, ErrNotFound) = false, want true", err)
+	}
+	if errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("errors.Is(%v, ErrAlreadyExists) = true, want false", err)
+	}
+
+	aggErr := &AggregateError{}
+	if !errors.As(err, &aggErr) {
+		t.Fatalf("errors.As(%v, *AggregateError) = false, want true", err)
+	}
+	if len(aggErr.Errors) != 1 || aggErr.Errors[0].Target != "org/gone" {
+		t.Errorf("AggregateError.Errors = %v, want only org/gone", aggErr.Errors)
+	}
+	if !strings.Contains(err.Error(), "org/gone: "+ErrNotFound.Error()) {
+		t.Errorf("Error() = %q, want it to contain the failed target", err.Error())
+	}
+}
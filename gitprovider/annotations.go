@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"net/http"
+	"sort"
+)
+
+// requestAnnotationsKey is the context key for the request annotations.
+type requestAnnotationsKey struct{}
+
+// WithRequestAnnotations returns a copy of ctx carrying the given annotations, e.g. a tenant
+// or reconcile ID. The annotations are merged with any annotations already present in ctx,
+// with the new values taking precedence.
+//
+// The provider clients pass ctx on to every HTTP request they make, so transports in the
+// chain (see WithPreChainTransportHook) can read the annotations using RequestAnnotations
+// and emit them into logs, traces or headers. AnnotationHeadersTransport does the latter.
+func WithRequestAnnotations(ctx context.Context, annotations map[string]string) context.Context {
+	merged := RequestAnnotations(ctx)
+	if merged == nil {
+		merged = make(map[string]string, len(annotations))
+	}
+	for k, v := range annotations {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, requestAnnotationsKey{}, merged)
+}
+
+// RequestAnnotations returns a copy of the annotations attached to ctx by WithRequestAnnotations,
+// or nil if there are none.
+func RequestAnnotations(ctx context.Context) map[string]string {
+	annotations, ok := ctx.Value(requestAnnotationsKey{}).(map[string]string)
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		out[k] = v
+	}
+	return out
+}
+
+// annotationHeadersTransport is a http.RoundTripper that sets a header for every request annotation.
+type annotationHeadersTransport struct {
+	prefix string
+	next   http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *annotationHeadersTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	annotations := RequestAnnotations(req.Context())
+	if len(annotations) == 0 {
+		return t.next.RoundTrip(req)
+	}
+	// Don't modify the caller's request, as required by the http.RoundTripper contract
+	req = req.Clone(req.Context())
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		req.Header.Set(t.prefix+k, annotations[k])
+	}
+	return t.next.RoundTrip(req)
+}
+
+// AnnotationHeadersTransport returns a ChainableRoundTripperFunc that forwards the request
+// annotations in the request context as HTTP headers named headerPrefix + key, e.g.
+// "X-Reconcile-Id" for the "Reconcile-Id" annotation and the "X-" prefix. Register it using
+// WithPreChainTransportHook to make the annotations visible to proxies and the Git provider.
+func AnnotationHeadersTransport(headerPrefix string) ChainableRoundTripperFunc {
+	return func(in http.RoundTripper) http.RoundTripper {
+		if in == nil {
+			in = http.DefaultTransport
+		}
+		return &annotationHeadersTransport{prefix: headerPrefix, next: in}
+	}
+}
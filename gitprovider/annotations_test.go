@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRequestAnnotations(t *testing.T) {
+	ctx := context.Background()
+	if got := RequestAnnotations(ctx); got != nil {
+		t.Errorf("RequestAnnotations() = %v, want nil", got)
+	}
+
+	ctx = WithRequestAnnotations(ctx, map[string]string{"Tenant": "a", "Reconcile-Id": "1"})
+	child := WithRequestAnnotations(ctx, map[string]string{"Reconcile-Id": "2"})
+
+	want := map[string]string{"Tenant": "a", "Reconcile-Id": "2"}
+	if got := RequestAnnotations(child); !reflect.DeepEqual(got, want) {
+		t.Errorf("RequestAnnotations(child) = %v, want %v", got, want)
+	}
+	// The parent context must not be affected
+	want = map[string]string{"Tenant": "a", "Reconcile-Id": "1"}
+	if got := RequestAnnotations(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("RequestAnnotations(parent) = %v, want %v", got, want)
+	}
+}
+
+func TestAnnotationHeadersTransport(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer server.Close()
+
+	client, err := BuildClientFromTransportChain([]ChainableRoundTripperFunc{AnnotationHeadersTransport("X-")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRequestAnnotations(context.Background(), map[string]string{"Reconcile-Id": "abc"})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if v := got.Get("X-Reconcile-Id"); v != "abc" {
+		t.Errorf("X-Reconcile-Id header = %q, want %q", v, "abc")
+	}
+	if v := req.Header.Get("X-Reconcile-Id"); v != "" {
+		t.Errorf("original request was modified, got header %q", v)
+	}
+}
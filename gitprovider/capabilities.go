@@ -0,0 +1,228 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:generate go run ./internal/gencapabilities -in capabilities.json -out zz_generated.capabilities.go
+
+// Feature is an enum specifying a feature of the high-level API that not all providers support.
+type Feature string
+
+const (
+	// FeatureOrganizations is listing and getting organizations.
+	FeatureOrganizations = Feature("organizations")
+	// FeatureSubOrganizations is nested organizations, e.g. GitLab subgroups.
+	FeatureSubOrganizations = Feature("sub-organizations")
+	// FeatureTeams is listing and getting the teams of an organization.
+	FeatureTeams = Feature("teams")
+	// FeatureOrgRepositories is managing repositories owned by an organization.
+	FeatureOrgRepositories = Feature("org-repositories")
+	// FeatureUserRepositories is managing repositories owned by a user.
+	FeatureUserRepositories = Feature("user-repositories")
+	// FeatureDeployKeys is managing the deploy keys of a repository.
+	FeatureDeployKeys = Feature("deploy-keys")
+	// FeatureTeamAccess is managing the teams that have access to a repository.
+	FeatureTeamAccess = Feature("team-access")
+	// FeatureCollaborators is managing the individual users that have access to a repository.
+	FeatureCollaborators = Feature("collaborators")
+	// FeatureCommits is listing and creating commits.
+	FeatureCommits = Feature("commits")
+	// FeatureCommitStats is returning line statistics when listing commits.
+	FeatureCommitStats = Feature("commit-stats")
+	// FeatureBranches is creating and deleting branches.
+	FeatureBranches = Feature("branches")
+	// FeaturePullRequests is managing pull (or merge) requests.
+	FeaturePullRequests = Feature("pull-requests")
+	// FeatureFiles is reading files of a repository.
+	FeatureFiles = Feature("files")
+	// FeatureMilestones is managing milestones of a repository.
+	FeatureMilestones = Feature("milestones")
+	// FeatureArchiveDownload is downloading a tarball or zip archive of a repository.
+	FeatureArchiveDownload = Feature("archive-download")
+	// FeatureTokenPermissions is checking the permissions of the token used by the client.
+	FeatureTokenPermissions = Feature("token-permissions")
+	// FeatureMergeQueue is adding pull requests to a merge queue, e.g. GitLab merge trains.
+	FeatureMergeQueue = Feature("merge-queue")
+	// FeatureRepositoryTopics is managing the topics of a repository.
+	FeatureRepositoryTopics = Feature("repository-topics")
+	// FeatureReactions is managing the emoji reactions on issues, pull requests and their comments.
+	FeatureReactions = Feature("reactions")
+	// FeatureEnvironmentProtection is managing the protection rules of deployment environments.
+	FeatureEnvironmentProtection = Feature("environment-protection")
+	// FeatureRepositorySearch is searching for repositories by text or topics.
+	FeatureRepositorySearch = Feature("repository-search")
+	// FeatureCodeSearch is searching for files by their content.
+	FeatureCodeSearch = Feature("code-search")
+	// FeatureBadges is generating the URLs of the status badges of a repository.
+	FeatureBadges = Feature("badges")
+	// FeatureCommitComments is listing and creating comments on commits.
+	FeatureCommitComments = Feature("commit-comments")
+	// FeatureUserKeys is managing the SSH and GPG keys of the authenticated user.
+	FeatureUserKeys = Feature("user-keys")
+	// FeatureInvitationAcceptance is listing, accepting and declining the repository invitations
+	// of the authenticated user.
+	FeatureInvitationAcceptance = Feature("invitation-acceptance")
+	// FeatureEvents is listing the activity events of repositories and organizations.
+	FeatureEvents = Feature("events")
+	// FeatureForkSync is synchronizing the branches of forks with their upstream repositories.
+	FeatureForkSync = Feature("fork-sync")
+	// FeatureTagProtection is managing the rules protecting the tags of repositories.
+	FeatureTagProtection = Feature("tag-protection")
+	// FeatureBranchRules is reading the normalized rules in effect on branches.
+	FeatureBranchRules = Feature("branch-rules")
+	// FeatureRepositoryStats is getting the size, languages and commit and contributor counts of
+	// repositories.
+	FeatureRepositoryStats = Feature("repository-stats")
+	// FeatureApprovalRules is managing the pull request approval rules of repositories.
+	FeatureApprovalRules = Feature("approval-rules")
+	// FeatureAccessTokens is managing the access tokens of repositories and organizations.
+	FeatureAccessTokens = Feature("access-tokens")
+	// FeatureTokenInfo is reporting the scopes and expiry of the token used by the client.
+	FeatureTokenInfo = Feature("token-info")
+	// FeaturePermissionValidation is probing whether the token can perform operations on repositories.
+	FeaturePermissionValidation = Feature("permission-validation")
+)
+
+// knownFeatureValues is a map of known Feature values, used for validation.
+//
+//nolint:gochecknoglobals
+var knownFeatureValues = map[Feature]struct{}{
+	FeatureOrganizations:         {},
+	FeatureSubOrganizations:      {},
+	FeatureTeams:                 {},
+	FeatureOrgRepositories:       {},
+	FeatureUserRepositories:      {},
+	FeatureDeployKeys:            {},
+	FeatureTeamAccess:            {},
+	FeatureCollaborators:         {},
+	FeatureCommits:               {},
+	FeatureCommitStats:           {},
+	FeatureBranches:              {},
+	FeaturePullRequests:          {},
+	FeatureFiles:                 {},
+	FeatureMilestones:            {},
+	FeatureArchiveDownload:       {},
+	FeatureTokenPermissions:      {},
+	FeatureMergeQueue:            {},
+	FeatureRepositoryTopics:      {},
+	FeatureReactions:             {},
+	FeatureEnvironmentProtection: {},
+	FeatureRepositorySearch:      {},
+	FeatureCodeSearch:            {},
+	FeatureBadges:                {},
+	FeatureCommitComments:        {},
+	FeatureUserKeys:              {},
+	FeatureInvitationAcceptance:  {},
+	FeatureEvents:                {},
+	FeatureForkSync:              {},
+	FeatureTagProtection:         {},
+	FeatureBranchRules:           {},
+	FeatureRepositoryStats:       {},
+	FeatureApprovalRules:         {},
+	FeatureAccessTokens:          {},
+	FeatureTokenInfo:             {},
+	FeaturePermissionValidation:  {},
+}
+
+// Capability describes that a provider supports a feature.
+type Capability struct {
+	// Provider is the ID of the provider, e.g. "github".
+	// +required
+	Provider ProviderID `json:"provider"`
+
+	// Feature is the supported feature.
+	// +required
+	Feature Feature `json:"feature"`
+
+	// MinServerVersion is the oldest self-hosted server version supporting the feature, in the
+	// "<major>.<minor>" form. It is empty if all supported server versions have the feature.
+	// +optional
+	MinServerVersion string `json:"minServerVersion,omitempty"`
+}
+
+// CapabilitiesJSON returns the capabilities matrix, Capabilities, encoded as JSON. The format
+// is stable, and can be used by e.g. UIs to disable actions a provider doesn't support.
+func CapabilitiesJSON() ([]byte, error) {
+	return json.MarshalIndent(Capabilities, "", "  ")
+}
+
+// SupportedFeatures returns the features the given provider supports, in the order of the
+// capabilities matrix. If serverVersion is set (e.g. "7.21" for a self-hosted instance),
+// features requiring a newer server are left out.
+func SupportedFeatures(provider ProviderID, serverVersion string) []Feature {
+	features := []Feature{}
+	for _, c := range Capabilities {
+		if c.Provider != provider {
+			continue
+		}
+		if serverVersion != "" && c.MinServerVersion != "" && compareVersions(serverVersion, c.MinServerVersion) < 0 {
+			continue
+		}
+		features = append(features, c.Feature)
+	}
+	return features
+}
+
+// KnownFeatures returns all the features of the high-level API, sorted by name. The methods
+// needing a feature that SupportedFeatures leaves out return ErrNoProviderSupport.
+func KnownFeatures() []Feature {
+	features := make([]Feature, 0, len(knownFeatureValues))
+	for f := range knownFeatureValues {
+		features = append(features, f)
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i] < features[j] })
+	return features
+}
+
+// IsFeatureSupported returns whether the given provider supports the feature, on the latest
+// server version.
+func IsFeatureSupported(provider ProviderID, feature Feature) bool {
+	for _, f := range SupportedFeatures(provider, "") {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// compareVersions compares two dot-separated, numeric versions, returning -1, 0 or 1 if
+// a is older, equal or newer than b. Non-numeric parts are treated as 0.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCapabilities_UpToDate(t *testing.T) {
+	data, err := os.ReadFile("capabilities.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []Capability
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(Capabilities, want) {
+		t.Errorf("Capabilities is out of date with capabilities.json, run go generate ./gitprovider")
+	}
+
+	for _, c := range Capabilities {
+		if _, ok := knownFeatureValues[c.Feature]; !ok {
+			t.Errorf("unknown feature %q for provider %q", c.Feature, c.Provider)
+		}
+	}
+}
+
+func TestSupportedFeatures(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      ProviderID
+		serverVersion string
+		feature       Feature
+		want          bool
+	}{
+		{
+			name:     "supported",
+			provider: "gitlab",
+			feature:  FeatureSubOrganizations,
+			want:     true,
+		},
+		{
+			name:     "unsupported",
+			provider: "github",
+			feature:  FeatureSubOrganizations,
+		},
+		{
+			name:          "server too old",
+			provider:      "stash",
+			serverVersion: "5.0.2",
+			feature:       FeatureArchiveDownload,
+		},
+		{
+			name:          "server new enough",
+			provider:      "stash",
+			serverVersion: "5.10",
+			feature:       FeatureArchiveDownload,
+			want:          true,
+		},
+		{
+			name:     "unknown provider",
+			provider: "foo",
+			feature:  FeatureOrganizations,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := false
+			for _, f := range SupportedFeatures(tt.provider, tt.serverVersion) {
+				if f == tt.feature {
+					got = true
+				}
+			}
+			if got != tt.want {
+				t.Errorf("SupportedFeatures(%q, %q) contains %q = %v, want %v", tt.provider, tt.serverVersion, tt.feature, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnownFeatures(t *testing.T) {
+	features := KnownFeatures()
+	if len(features) != len(knownFeatureValues) {
+		t.Fatalf("KnownFeatures() returned %d features, want %d", len(features), len(knownFeatureValues))
+	}
+	for i := 1; i < len(features); i++ {
+		if features[i-1] >= features[i] {
+			t.Errorf("KnownFeatures() isn't sorted: %q before %q", features[i-1], features[i])
+		}
+	}
+}
@@ -0,0 +1,315 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// CassetteModeEnvVar is the environment variable overriding the mode of the cassettes set with
+// WithCassette. Its value can be CassetteModeRecord or CassetteModeReplay.
+const CassetteModeEnvVar = "GITPROVIDER_CASSETTE_MODE"
+
+// CassetteMode describes whether a cassette records or replays HTTP interactions.
+type CassetteMode string
+
+const (
+	// CassetteModeRecord sends the requests to the Git provider, and (re-)records the cassette.
+	CassetteModeRecord = CassetteMode("record")
+	// CassetteModeReplay replays the recorded responses, without network access.
+	CassetteModeReplay = CassetteMode("replay")
+)
+
+// ErrCassetteInteractionNotFound is returned in replay mode for requests without a (remaining)
+// recorded interaction in the cassette.
+var ErrCassetteInteractionNotFound = errors.New("no recorded interaction found in cassette")
+
+// cassetteRedactedQueryParams are the query parameters that are replaced with a placeholder before
+// matching or recording a request URL, as they might contain credentials.
+//
+//nolint:gochecknoglobals
+var cassetteRedactedQueryParams = []string{"access_token", "private_token", "token"}
+
+// cassetteRedactedHeaders are the response headers that aren't recorded, as they carry
+// credentials. The values of the other headers pass through Redact.
+//
+//nolint:gochecknoglobals
+var cassetteRedactedHeaders = []string{"Set-Cookie", "Cookie", "Authorization", "Proxy-Authorization", "Private-Token"}
+
+// cassetteKeyMaterialPattern matches SSH public keys, e.g. of deploy keys, which are replaced
+// in the recorded bodies in addition to the secrets removed by Redact.
+//
+//nolint:gochecknoglobals
+var cassetteKeyMaterialPattern = regexp.MustCompile(`(?:ssh-(?:rsa|dss|ed25519)|ecdsa-sha2-nistp\d+|sk-(?:ssh-ed25519|ecdsa-sha2-nistp256)@openssh\.com) AAAA[A-Za-z0-9+/]+=*`)
+
+// cassetteRecord is the serialized form of a cassette file.
+type cassetteRecord struct {
+	Interactions []cassetteInteraction `json:"interactions"`
+}
+
+// cassetteInteraction is a recorded request and its response.
+type cassetteInteraction struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+// cassetteRequest is the recorded part of a request, used for matching. Request headers aren't
+// recorded, so that credentials never end up in the cassette, and the body is redacted, see
+// redactCassetteBody.
+type cassetteRequest struct {
+	Method string       `json:"method"`
+	URL    string       `json:"url"`
+	Body   cassetteBody `json:"body,omitempty"`
+}
+
+// cassetteResponse is a recorded response.
+type cassetteResponse struct {
+	StatusCode int          `json:"statusCode"`
+	Header     http.Header  `json:"header,omitempty"`
+	Body       cassetteBody `json:"body,omitempty"`
+}
+
+// cassetteBody is a request or response body. It is serialized as a string if it is valid UTF-8,
+// to keep the cassettes reviewable, and as base64 otherwise, e.g. for repository archives.
+type cassetteBody []byte
+
+// MarshalJSON implements json.Marshaler.
+func (b cassetteBody) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *cassetteBody) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = []byte(s)
+		return nil
+	}
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// cassette records HTTP interactions to, or replays them from, a file.
+type cassette struct {
+	path string
+	mode CassetteMode
+
+	mu           sync.Mutex
+	interactions []cassetteInteraction
+	// used tracks the interactions already replayed, so that repeated requests, e.g. polling,
+	// get their responses in the recorded order.
+	used []bool
+}
+
+// newCassette opens the cassette at path. If mode is empty, it is taken from CassetteModeEnvVar,
+// and defaults to replaying if the file exists, and recording otherwise.
+func newCassette(path string, mode CassetteMode) (*cassette, error) {
+	if mode == "" {
+		mode = CassetteMode(os.Getenv(CassetteModeEnvVar))
+	}
+	switch mode {
+	case CassetteModeRecord:
+		return &cassette{path: path, mode: mode}, nil
+	case CassetteModeReplay, "":
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q: %w", mode, ErrInvalidClientOptions)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && mode == "" {
+		return &cassette{path: path, mode: CassetteModeRecord}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var rec cassetteRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	return &cassette{
+		path:         path,
+		mode:         CassetteModeReplay,
+		interactions: rec.Interactions,
+		used:         make([]bool, len(rec.Interactions)),
+	}, nil
+}
+
+// replay returns the response of the first unused interaction matching req.
+func (c *cassette) replay(req cassetteRequest, httpReq *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, in := range c.interactions {
+		if c.used[i] || in.Request.Method != req.Method || in.Request.URL != req.URL || !bytes.Equal(in.Request.Body, req.Body) {
+			continue
+		}
+		c.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       httpReq,
+		}, nil
+	}
+	return nil, fmt.Errorf("%s %s in %s: %w", req.Method, req.URL, c.path, ErrCassetteInteractionNotFound)
+}
+
+// record appends the interaction to the cassette, and writes the cassette file.
+func (c *cassette) record(in cassetteInteraction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, in)
+	data, err := json.MarshalIndent(cassetteRecord{Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, append(data, '\n'), 0o600)
+}
+
+// cassetteTransport is a http.RoundTripper that records the interactions with the Git provider to
+// a cassette, or replays them from it.
+type cassetteTransport struct {
+	cassette *cassette
+	next     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	creq := cassetteRequest{Method: req.Method, URL: redactCassetteURL(req.URL)}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// Match the redacted body, as recorded
+		creq.Body = redactCassetteBody(body)
+		// RoundTrippers must not modify the given request
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+
+	if t.cassette.mode == CassetteModeReplay {
+		return t.cassette.replay(creq, req)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	for _, k := range cassetteRedactedHeaders {
+		header.Del(k)
+	}
+	for k, values := range header {
+		for i, v := range values {
+			header[k][i] = Redact(v)
+		}
+	}
+	recordedBody := redactCassetteBody(body)
+	// The body is stored decoded and redacted, so the length might differ from the original
+	header.Set("Content-Length", strconv.Itoa(len(recordedBody)))
+	in := cassetteInteraction{
+		Request:  creq,
+		Response: cassetteResponse{StatusCode: resp.StatusCode, Header: header, Body: recordedBody},
+	}
+	if err := t.cassette.record(in); err != nil {
+		return nil, fmt.Errorf("failed to record cassette %s: %w", t.cassette.path, err)
+	}
+	return resp, nil
+}
+
+// redactCassetteURL returns u as a string, with the values of cassetteRedactedQueryParams replaced.
+func redactCassetteURL(u *url.URL) string {
+	q := u.Query()
+	redacted := false
+	for _, k := range cassetteRedactedQueryParams {
+		for key := range q {
+			if strings.EqualFold(key, k) {
+				q.Set(key, "REDACTED")
+				redacted = true
+			}
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// redactCassetteBody returns body with the secrets removed by Redact, e.g. tokens and webhook
+// secrets in JSON documents, and the SSH key material replaced. Bodies which aren't valid UTF-8,
+// e.g. repository archives, are returned as-is.
+func redactCassetteBody(body []byte) []byte {
+	if len(body) == 0 || !utf8.Valid(body) {
+		return body
+	}
+	s := Redact(string(body))
+	s = cassetteKeyMaterialPattern.ReplaceAllString(s, RedactedPlaceholder)
+	return []byte(s)
+}
+
+// cassetteTransportFunc returns a ChainableRoundTripperFunc recording to, or replaying from, c.
+func cassetteTransportFunc(c *cassette) ChainableRoundTripperFunc {
+	return func(in http.RoundTripper) http.RoundTripper {
+		if in == nil {
+			in = http.DefaultTransport
+		}
+		return &cassetteTransport{cassette: c, next: in}
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ChangeType is an enum specifying what kind of write a Reconcile() call would have done.
+type ChangeType string
+
+const (
+	// ChangeTypeCreate means that the resource doesn't exist, and would be created.
+	ChangeTypeCreate = ChangeType("create")
+
+	// ChangeTypeUpdate means that the resource exists, but would be updated.
+	ChangeTypeUpdate = ChangeType("update")
+)
+
+// FieldChange describes the difference between the actual and desired value of one field.
+type FieldChange struct {
+	// Field is the JSON name of the field in the {Resource}Info struct, e.g. "defaultBranch".
+	Field string `json:"field"`
+	// Old is the actual value of the field, or nil if unset or the resource doesn't exist.
+	Old interface{} `json:"old"`
+	// New is the desired value of the field.
+	New interface{} `json:"new"`
+}
+
+// Change describes a write that a Reconcile() call would have done.
+type Change struct {
+	// Type specifies whether the resource would be created or updated.
+	Type ChangeType `json:"type"`
+	// Resource is the kind of the resource, e.g. "Repository", "DeployKey" or "TeamAccess".
+	Resource string `json:"resource"`
+	// Repository is the repository the resource is (or would be) part of.
+	Repository RepositoryRef `json:"repository"`
+	// Name is the name of the deploy key or team. It is empty for repositories.
+	Name string `json:"name,omitempty"`
+	// Fields lists the fields whose desired values differ from the actual ones.
+	Fields []FieldChange `json:"fields"`
+}
+
+// String returns a short, human-readable description of the change.
+func (c Change) String() string {
+	target := ""
+	if c.Repository != nil {
+		target = c.Repository.String()
+	}
+	if c.Name != "" {
+		target = fmt.Sprintf("%s %q", target, c.Name)
+	}
+	fields := make([]string, 0, len(c.Fields))
+	for _, f := range c.Fields {
+		fields = append(fields, f.Field)
+	}
+	return fmt.Sprintf("%s %s %s (%s)", c.Type, c.Resource, target, strings.Join(fields, ", "))
+}
+
+// NewCreateChange returns the Change for creating a resource with the desired state. The desired
+// state should already be defaulted, e.g. using ValidateAndDefaultInfo.
+func NewCreateChange(ref RepositoryRef, desired InfoRequest) Change {
+	return newChange(ChangeTypeCreate, ref, nil, desired)
+}
+
+// NewUpdateChange returns the Change for updating a resource from the actual to the desired state.
+// actual and desired must be of the same {Resource}Info type.
+func NewUpdateChange(ref RepositoryRef, actual, desired InfoRequest) Change {
+	return newChange(ChangeTypeUpdate, ref, actual, desired)
+}
+
+func newChange(t ChangeType, ref RepositoryRef, actual, desired InfoRequest) Change {
+	dv := reflect.Indirect(reflect.ValueOf(desired))
+	c := Change{
+		Type:       t,
+		Resource:   strings.TrimSuffix(dv.Type().Name(), "Info"),
+		Repository: ref,
+		Fields:     DiffInfo(actual, desired),
+	}
+	if name := dv.FieldByName("Name"); name.IsValid() && name.Kind() == reflect.String {
+		c.Name = name.String()
+	}
+	return c
+}
+
+// DiffInfo returns the fields of the {Resource}Info struct desired whose values differ from
+// the ones in actual. actual may be nil, in which case all set fields of desired are returned.
+// Pointer fields are dereferenced, so Old and New hold the plain values. The fields tagged
+// `gitprovider:"readonly"`, which are only reported by the provider, are skipped.
+func DiffInfo(actual, desired InfoRequest) []FieldChange {
+	dv := reflect.Indirect(reflect.ValueOf(desired))
+	var av reflect.Value
+	if actual != nil {
+		av = reflect.Indirect(reflect.ValueOf(actual))
+	}
+
+	changes := []FieldChange{}
+	for i := 0; i < dv.NumField(); i++ {
+		field := dv.Type().Field(i)
+		if field.PkgPath != "" || field.Tag.Get("gitprovider") == "readonly" {
+			// Skip unexported and readonly fields
+			continue
+		}
+		newValue := fieldValue(dv.Field(i))
+		var oldValue interface{}
+		if av.IsValid() && av.Type() == dv.Type() {
+			oldValue = fieldValue(av.Field(i))
+		}
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, FieldChange{
+			Field: jsonFieldName(field),
+			Old:   oldValue,
+			New:   newValue,
+		})
+	}
+	return changes
+}
+
+// fieldValue returns the dereferenced value of v, or nil if v is a nil pointer or
+// an empty, non-pointer value.
+func fieldValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	} else if v.IsZero() {
+		return nil
+	}
+	// Keep []byte values human-readable, e.g. for deploy keys
+	if b, ok := v.Interface().([]byte); ok {
+		return string(b)
+	}
+	return v.Interface()
+}
+
+// jsonFieldName returns the name of the field in its JSON representation.
+func jsonFieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+// ChangeAction describes a write performed by a Reconcile() call: the fields that were
+// changed, and the API calls that were made to change them.
+type ChangeAction struct {
+	// ChangeAction extends Change.
+	Change `json:",inline"`
+
+	// APICalls lists the API calls performed, e.g. "PATCH /repos/{owner}/{repo}".
+	APICalls []string `json:"apiCalls"`
+}
+
+// ChangeRecorder collects the ChangeActions of the Reconcile() calls made with a context
+// returned by WithChangeRecorder. It is safe for concurrent use.
+type ChangeRecorder struct {
+	mu      sync.Mutex
+	actions []ChangeAction
+}
+
+// Actions returns the recorded ChangeActions, in the order they were performed.
+func (r *ChangeRecorder) Actions() []ChangeAction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ChangeAction(nil), r.actions...)
+}
+
+func (r *ChangeRecorder) record(action ChangeAction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions = append(r.actions, action)
+}
+
+// changeRecorderKey is the context key for the ChangeRecorder.
+type changeRecorderKey struct{}
+
+// WithChangeRecorder returns a copy of ctx that makes Reconcile() calls report the changes
+// they perform to r. Reconcile() calls that don't change anything don't record any action.
+// For example:
+//
+//	rec := &gitprovider.ChangeRecorder{}
+//	_, _, err := c.OrgRepositories().Reconcile(gitprovider.WithChangeRecorder(ctx, rec), ref, req)
+//	for _, action := range rec.Actions() { ... }
+func WithChangeRecorder(ctx context.Context, r *ChangeRecorder) context.Context {
+	return context.WithValue(ctx, changeRecorderKey{}, r)
+}
+
+// RecordChange records that change was performed using the given API calls, if ctx
+// has a ChangeRecorder. It is used by the provider implementations of Reconcile().
+func RecordChange(ctx context.Context, change Change, apiCalls ...string) {
+	if r, ok := ctx.Value(changeRecorderKey{}).(*ChangeRecorder); ok && r != nil {
+		r.record(ChangeAction{Change: change, APICalls: apiCalls})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDiffInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		actual  InfoRequest
+		desired InfoRequest
+		want    []FieldChange
+	}{
+		{
+			name:    "no changes",
+			actual:  TeamAccessInfo{Name: "team", Permission: RepositoryPermissionVar(RepositoryPermissionPush)},
+			desired: TeamAccessInfo{Name: "team", Permission: RepositoryPermissionVar(RepositoryPermissionPush)},
+			want:    []FieldChange{},
+		},
+		{
+			name:    "pointer field changed",
+			actual:  TeamAccessInfo{Name: "team", Permission: RepositoryPermissionVar(RepositoryPermissionPull)},
+			desired: TeamAccessInfo{Name: "team", Permission: RepositoryPermissionVar(RepositoryPermissionPush)},
+			want: []FieldChange{
+				{Field: "permission", Old: RepositoryPermissionPull, New: RepositoryPermissionPush},
+			},
+		},
+		{
+			name:    "byte slice and unset fields",
+			actual:  DeployKeyInfo{Name: "key", Key: []byte("ssh-rsa old")},
+			desired: DeployKeyInfo{Name: "key", Key: []byte("ssh-rsa new"), ReadOnly: BoolVar(false)},
+			want: []FieldChange{
+				{Field: "key", Old: "ssh-rsa old", New: "ssh-rsa new"},
+				{Field: "readOnly", Old: nil, New: false},
+			},
+		},
+		{
+			name:    "readonly fields",
+			actual:  RepositoryInfo{Description: StringVar("desc"), Mirror: NewRepositoryMirror("https://example.com/repo.git")},
+			desired: RepositoryInfo{Description: StringVar("desc")},
+			want:    []FieldChange{},
+		},
+		{
+			name:    "no actual state",
+			desired: DeployKeyInfo{Name: "key", Key: []byte("ssh-rsa new")},
+			want: []FieldChange{
+				{Field: "name", New: "key"},
+				{Field: "key", New: "ssh-rsa new"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffInfo(tt.actual, tt.desired); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeRecorder(t *testing.T) {
+	ref := UserRepositoryRef{
+		UserRef:        UserRef{Domain: "github.com", UserLogin: "foo-user"},
+		RepositoryName: "foo-repo",
+	}
+	change := NewCreateChange(ref, RepositoryInfo{Description: StringVar("foo")})
+
+	// Without a recorder, RecordChange is a no-op
+	RecordChange(context.Background(), change, "POST /user/repos")
+
+	rec := &ChangeRecorder{}
+	ctx := WithChangeRecorder(context.Background(), rec)
+	RecordChange(ctx, change, "POST /user/repos")
+
+	want := []ChangeAction{{Change: change, APICalls: []string{"POST /user/repos"}}}
+	if got := rec.Actions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Actions() = %+v, want %+v", got, want)
+	}
+}
@@ -16,7 +16,11 @@ limitations under the License.
 
 package gitprovider
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 // Client is an interface that allows talking to a Git provider.
 type Client interface {
@@ -37,6 +41,35 @@ type Client interface {
 	// permission. Permissions should be coarse-grained and applicable to *all* providers.
 	HasTokenPermission(ctx context.Context, permission TokenPermission) (bool, error)
 
+	// AuthenticatedUserID returns the identity of the account the token authenticates as, including
+	// whether it's a bot account. It's fetched on the first call, and cached by the client.
+	AuthenticatedUserID(ctx context.Context) (Identity, error)
+
+	// IsOwnAction returns true if the event was caused by the account the token authenticates as,
+	// so that e.g. webhook consumers can ignore the events caused by their own changes.
+	IsOwnAction(ctx context.Context, event ActorEvent) (bool, error)
+
+	// TokenInfo returns the account the token authenticates as, and the scopes and expiry of the
+	// token where the provider discloses them, so that tools can fail fast when a token lacks
+	// scopes. Unlike AuthenticatedUserID, the result isn't cached.
+	TokenInfo(ctx context.Context) (TokenInfo, error)
+
+	// ValidatePermissions probes whether the token can perform the needed operations, so that e.g.
+	// a long reconcile can fail fast before it begins. Every repository is probed once, with cheap
+	// read-only requests. The missing permissions are returned as an AggregateError of the
+	// repositories, matching ErrPermissionDenied.
+	ValidatePermissions(ctx context.Context, needs ...Permission) error
+
+	// SupportedFeatures returns the features of the high-level API this provider supports,
+	// as listed in the Capabilities matrix for the latest server version. The methods needing
+	// any other of the KnownFeatures return ErrNoProviderSupport.
+	SupportedFeatures() []Feature
+
+	// Extension returns the provider-specific extension registered under name (see
+	// RegisterExtension), or nil if there is none. The result should be type-asserted to
+	// the interface exported by the provider package, e.g. c.Extension("github").(github.Extras).
+	Extension(name string) interface{}
+
 	// Raw returns the Go client used under the hood to access the Git provider.
 	Raw() interface{}
 }
@@ -51,6 +84,22 @@ type ResourceClient interface {
 
 	// UserRepositories returns the UserRepositoriesClient handling sets of repositories for a user.
 	UserRepositories() UserRepositoriesClient
+
+	// Search returns the SearchClient searching for repositories and code.
+	Search() SearchClient
+
+	// UserKeys returns the UserKeysClient handling the SSH and GPG keys of the authenticated user.
+	UserKeys() UserKeysClient
+
+	// Invitations returns the InvitationsClient handling the repository invitations of the
+	// authenticated user.
+	Invitations() InvitationsClient
+
+	// Events returns the EventsClient listing the activity of repositories and organizations.
+	Events() EventsClient
+
+	// Tokens returns the TokensClient handling the access tokens of repositories and organizations.
+	Tokens() TokensClient
 }
 
 //
@@ -65,76 +114,226 @@ type OrganizationsClient interface {
 	// ErrNotFound is returned if the resource does not exist.
 	Get(ctx context.Context, o OrganizationRef) (Organization, error)
 
-	// List all top-level organizations the specific user has access to.
+	// List all organizations the specific user has access to.
+	// For GitLab, this includes all subgroups, unless OrganizationListOptions.Depth is set, in
+	// which case only the top-level groups are listed, with subgroups down to the given depth.
 	//
 	// List returns all available organizations, using multiple paginated requests if needed.
-	List(ctx context.Context) ([]Organization, error)
+	List(ctx context.Context, opts ...OrganizationListOption) ([]Organization, error)
 
 	// Children returns the immediate child-organizations for the specific OrganizationRef o.
 	// The OrganizationRef may point to any existing sub-organization.
+	// Deeper descendants can be included by setting OrganizationListOptions.Depth.
 	//
 	// This is not supported in GitHub.
 	//
 	// Children returns all available organizations, using multiple paginated requests if needed.
-	Children(ctx context.Context, o OrganizationRef) ([]Organization, error)
+	Children(ctx context.Context, o OrganizationRef, opts ...OrganizationListOption) ([]Organization, error)
 
 	// Possibly add Create/Update/Delete methods later
 }
 
 // OrgRepositoriesClient operates on repositories for organizations.
 type OrgRepositoriesClient interface {
-	// Get returns the repository for the given reference.
+	// Get returns the repository for the given reference. RepositoryGetOptions.WithStats
+	// populates the statistics of the repository, if FeatureRepositoryStats is supported.
 	//
 	// ErrNotFound is returned if the resource does not exist.
-	Get(ctx context.Context, r OrgRepositoryRef) (OrgRepository, error)
+	Get(ctx context.Context, r OrgRepositoryRef, opts ...RepositoryGetOption) (OrgRepository, error)
 
 	// List all repositories in the given organization.
+	// The repositories can be filtered by name prefix, visibility, archival and activity,
+	// see RepositoryListOptions.
 	//
 	// List returns all available repositories, using multiple paginated requests if needed.
-	List(ctx context.Context, o OrganizationRef) ([]OrgRepository, error)
+	List(ctx context.Context, o OrganizationRef, opts ...RepositoryListOption) ([]OrgRepository, error)
+
+	// ListIter returns an iterator over the repositories in the given organization, which fetches
+	// one page at a time instead of holding all repositories in memory, see OrgRepositoryIterator.
+	// The repositories are filtered like in List.
+	ListIter(ctx context.Context, o OrganizationRef, opts ...RepositoryListOption) *OrgRepositoryIterator
 
 	// Create creates a repository for the given organization, with the data and options.
 	//
 	// ErrAlreadyExists will be returned if the resource already exists.
 	Create(ctx context.Context, r OrgRepositoryRef, req RepositoryInfo, opts ...RepositoryCreateOption) (OrgRepository, error)
 
+	// CreateFromTemplate creates a repository for the given organization, with the files of the
+	// default branch of the template repository, like Create with
+	// RepositoryCreateOptions.TemplateRepository.
+	//
+	// ErrAlreadyExists will be returned if the resource already exists.
+	CreateFromTemplate(ctx context.Context, r OrgRepositoryRef, template RepositoryRef, req RepositoryInfo, opts ...RepositoryCreateOption) (OrgRepository, error)
+
 	// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
 	//
 	// If req doesn't exist under the hood, it is created (actionTaken == true).
 	// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
 	// If req is already the actual state, this is a no-op (actionTaken == false).
 	Reconcile(ctx context.Context, r OrgRepositoryRef, req RepositoryInfo, opts ...RepositoryReconcileOption) (resp OrgRepository, actionTaken bool, err error)
+
+	// DownloadArchive returns an archive of the repository contents at the given Git reference,
+	// which can be a branch, tag or commit SHA. If gitRef is empty, the default branch is used.
+	//
+	// The archive is streamed from the Git provider, the caller is responsible for closing the returned reader.
+	// Interrupted downloads are resumed, see NewResumableReader; reading returns ErrIntegrityCheckFailed
+	// if the archive doesn't match the size or digest sent by the server.
+	// ErrNotFound is returned if the repository or the reference does not exist.
+	DownloadArchive(ctx context.Context, r OrgRepositoryRef, gitRef string, format ArchiveFormat) (io.ReadCloser, error)
 }
 
 // UserRepositoriesClient operates on repositories for users.
 type UserRepositoriesClient interface {
-	// Get returns the repository at the given path.
+	// Get returns the repository at the given path. RepositoryGetOptions.WithStats populates
+	// the statistics of the repository, if FeatureRepositoryStats is supported.
 	//
 	// ErrNotFound is returned if the resource does not exist.
-	Get(ctx context.Context, r UserRepositoryRef) (UserRepository, error)
+	Get(ctx context.Context, r UserRepositoryRef, opts ...RepositoryGetOption) (UserRepository, error)
 
 	// List all repositories for the given user.
+	// The repositories can be filtered by name prefix, visibility, archival and activity,
+	// see RepositoryListOptions.
 	//
 	// List returns all available repositories, using multiple paginated requests if needed.
-	List(ctx context.Context, o UserRef) ([]UserRepository, error)
+	List(ctx context.Context, o UserRef, opts ...RepositoryListOption) ([]UserRepository, error)
+
+	// ListIter returns an iterator over the repositories of the given user, which fetches one
+	// page at a time instead of holding all repositories in memory, see UserRepositoryIterator.
+	// The repositories are filtered like in List.
+	ListIter(ctx context.Context, o UserRef, opts ...RepositoryListOption) *UserRepositoryIterator
 
 	// Create creates a repository for the given user, with the data and options
 	//
 	// ErrAlreadyExists will be returned if the resource already exists.
 	Create(ctx context.Context, r UserRepositoryRef, req RepositoryInfo, opts ...RepositoryCreateOption) (UserRepository, error)
 
+	// CreateFromTemplate creates a repository for the given user, with the files of the default
+	// branch of the template repository, like Create with RepositoryCreateOptions.TemplateRepository.
+	//
+	// ErrAlreadyExists will be returned if the resource already exists.
+	CreateFromTemplate(ctx context.Context, r UserRepositoryRef, template RepositoryRef, req RepositoryInfo, opts ...RepositoryCreateOption) (UserRepository, error)
+
 	// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
 	//
 	// If req doesn't exist under the hood, it is created (actionTaken == true).
 	// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
 	// If req is already the actual state, this is a no-op (actionTaken == false).
 	Reconcile(ctx context.Context, r UserRepositoryRef, req RepositoryInfo, opts ...RepositoryReconcileOption) (resp UserRepository, actionTaken bool, err error)
+
+	// DownloadArchive returns an archive of the repository contents at the given Git reference,
+	// which can be a branch, tag or commit SHA. If gitRef is empty, the default branch is used.
+	//
+	// The archive is streamed from the Git provider, the caller is responsible for closing the returned reader.
+	// Interrupted downloads are resumed, see NewResumableReader; reading returns ErrIntegrityCheckFailed
+	// if the archive doesn't match the size or digest sent by the server.
+	// ErrNotFound is returned if the repository or the reference does not exist.
+	DownloadArchive(ctx context.Context, r UserRepositoryRef, gitRef string, format ArchiveFormat) (io.ReadCloser, error)
+
+	// GetUserLogin returns the UserRef of the authenticated user, which can be used to
+	// get, list, create and reconcile the user's own repositories.
+	GetUserLogin(ctx context.Context) (UserRef, error)
+}
+
+// SearchClient searches for repositories and code across the Git provider.
+type SearchClient interface {
+	// Repositories returns the repositories matching the text or topics of the query, up to
+	// query.Limit results.
+	//
+	// ErrNoProviderSupport is returned if the provider can't search repositories.
+	Repositories(ctx context.Context, query SearchQuery) ([]RepositorySearchResult, error)
+
+	// Code returns the files with content matching the text of the query, up to query.Limit results.
+	//
+	// ErrNoProviderSupport is returned if the provider can't search code.
+	Code(ctx context.Context, query SearchQuery) ([]CodeSearchResult, error)
+}
+
+// UserKeysClient operates on the SSH and GPG keys of the authenticated user, e.g. to bootstrap
+// machine accounts.
+type UserKeysClient interface {
+	// List lists the keys of the given type.
+	//
+	// List returns all available keys, using multiple paginated requests if needed.
+	List(ctx context.Context, keyType UserKeyType) ([]UserKeyInfo, error)
+
+	// Add adds the given key, returning it as stored by the provider.
+	//
+	// ErrAlreadyExists is returned if the key is already in use.
+	Add(ctx context.Context, req UserKeyInfo) (UserKeyInfo, error)
+
+	// Delete removes the key of the given type and ID.
+	//
+	// ErrNotFound is returned if the resource does not exist.
+	Delete(ctx context.Context, keyType UserKeyType, id int64) error
+}
+
+// InvitationsClient operates on the pending repository invitations of the authenticated user,
+// completing the access granting of CollaboratorClient.Add on providers inviting collaborators.
+type InvitationsClient interface {
+	// List lists the pending invitations of the authenticated user. It is empty for providers
+	// that add collaborators directly.
+	//
+	// List returns all available invitations, using multiple paginated requests if needed.
+	List(ctx context.Context) ([]RepositoryInvitation, error)
+
+	// Accept accepts the invitation with the given ID, making the user a collaborator.
+	//
+	// ErrNotFound is returned if there is no such pending invitation.
+	Accept(ctx context.Context, id int64) error
+
+	// Decline declines the invitation with the given ID.
+	//
+	// ErrNotFound is returned if there is no such pending invitation.
+	Decline(ctx context.Context, id int64) error
 }
 
 //
 //	Clients accessed through resource objects.
 //
 
+// EventsClient lists the activity events of repositories and organizations, normalized across
+// providers, e.g. for audit tooling. Events are returned newest first.
+type EventsClient interface {
+	// ListRepository lists the events of the given repository matching opts.
+	//
+	// ErrNotFound is returned if the repository doesn't exist.
+	ListRepository(ctx context.Context, ref RepositoryRef, opts EventListOptions) ([]Event, error)
+
+	// ListOrganization lists the events of the repositories and members of the given organization
+	// matching opts.
+	//
+	// ErrNoProviderSupport is returned if the provider has no organization activity.
+	ListOrganization(ctx context.Context, ref OrganizationRef, opts EventListOptions) ([]Event, error)
+}
+
+// TokensClient operates on the access tokens of repositories and organizations, i.e. GitLab
+// project and group access tokens, e.g. for credential rotation controllers.
+//
+// The owner of the tokens is either a RepositoryRef, for the tokens of a repository, or an
+// OrganizationRef, for the tokens of an organization. ErrInvalidArgument is returned for other
+// owners, and ErrNoProviderSupport by providers without access tokens, see FeatureAccessTokens.
+type TokensClient interface {
+	// List lists the active access tokens of the owner. The token values aren't included.
+	//
+	// List returns all available tokens, using multiple paginated requests if needed.
+	List(ctx context.Context, owner IdentityRef) ([]AccessTokenInfo, error)
+
+	// Create creates an access token for the owner with the name, scopes, access level and
+	// expiry of req. The returned token has the value, which can't be retrieved later.
+	Create(ctx context.Context, owner IdentityRef, req AccessTokenInfo) (AccessTokenInfo, error)
+
+	// Rotate revokes the token with the given ID, and creates a new one with the same name, scopes
+	// and access level, which expires at expiresAt. The returned token has the new value.
+	//
+	// ErrNotFound is returned if the token doesn't exist or is already revoked.
+	Rotate(ctx context.Context, owner IdentityRef, id int64, expiresAt time.Time) (AccessTokenInfo, error)
+
+	// Revoke revokes the token with the given ID.
+	//
+	// ErrNotFound is returned if the token doesn't exist or is already revoked.
+	Revoke(ctx context.Context, owner IdentityRef, id int64) error
+}
+
 // TeamsClient allows reading teams for a specific organization.
 // This client can be accessed through Organization.Teams().
 type TeamsClient interface {
@@ -180,6 +379,44 @@ type TeamAccessClient interface {
 	Reconcile(ctx context.Context, req TeamAccessInfo) (resp TeamAccess, actionTaken bool, err error)
 }
 
+// CollaboratorClient operates on the individual users with direct access to a specific repository,
+// complementing the team-based TeamAccessClient.
+// This client can be accessed through Repository.Collaborators().
+type CollaboratorClient interface {
+	// Get returns the access of the collaborator with the given login.
+	//
+	// ErrNotFound is returned if the user isn't a collaborator, e.g. while an invitation is pending.
+	Get(ctx context.Context, login string) (CollaboratorInfo, error)
+
+	// List lists the users with direct access to the repository. Users with access through a
+	// team or the organization are not included, and neither are pending invitations.
+	//
+	// List returns all available collaborators, using multiple paginated requests if needed.
+	List(ctx context.Context) ([]CollaboratorInfo, error)
+
+	// Add grants the user req.Login access to the repository, or changes the permission of an
+	// existing collaborator.
+	//
+	// Some providers (GitHub) invite users instead of adding them directly; the invitation is
+	// then returned, and the user is only a collaborator once they accept it. Otherwise the
+	// returned invitation is nil.
+	Add(ctx context.Context, req CollaboratorInfo) (*CollaboratorInvitation, error)
+
+	// Remove revokes the direct access of the collaborator with the given login.
+	//
+	// ErrNotFound is returned if the user isn't a collaborator.
+	Remove(ctx context.Context, login string) error
+
+	// ListInvitations lists the pending invitations to the repository. It is empty for
+	// providers that add collaborators directly.
+	ListInvitations(ctx context.Context) ([]CollaboratorInvitation, error)
+
+	// CancelInvitation withdraws the pending invitation with the given ID.
+	//
+	// ErrNotFound is returned if there is no such pending invitation.
+	CancelInvitation(ctx context.Context, id int64) error
+}
+
 // DeployKeyClient operates on the access credential list for a specific repository.
 // This client can be accessed through Repository.DeployKeys().
 type DeployKeyClient interface {
@@ -205,6 +442,14 @@ type DeployKeyClient interface {
 	// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
 	// If req is already the actual state, this is a no-op (actionTaken == false).
 	Reconcile(ctx context.Context, req DeployKeyInfo) (resp DeployKey, actionTaken bool, err error)
+
+	// Rotate replaces the key material of the deploy key with the given name with newKey, keeping
+	// its name and read-only flag. Where the provider allows it, the new key is created before the
+	// old one is deleted, so that the repository stays accessible during the rotation.
+	//
+	// If the deploy key already has the new key material, this is a no-op (actionTaken == false).
+	// ErrNotFound is returned if the resource does not exist.
+	Rotate(ctx context.Context, name string, newKey []byte) (resp DeployKey, actionTaken bool, err error)
 }
 
 // CommitClient operates on the commits list for a specific repository.
@@ -212,16 +457,56 @@ type DeployKeyClient interface {
 type CommitClient interface {
 
 	// ListPage lists repository commits of the given page and page size.
-	ListPage(ctx context.Context, branch string, perPage int, page int) ([]Commit, error)
+	// The commits can be filtered by path, author and time, see CommitListOptions.
+	// Filters that a provider can't apply server-side are applied to each page, so pages
+	// may then contain fewer than perPage commits.
+	//
+	// ErrEmptyRepository is returned if the repository doesn't have any commits yet.
+	ListPage(ctx context.Context, branch string, perPage int, page int, opts ...CommitListOption) ([]Commit, error)
 	// Create creates a commit with the given specifications.
+	//
+	// If the repository is empty, the initial commit is created and the branch is created pointing to it.
 	Create(ctx context.Context, branch string, message string, files []CommitFile) (Commit, error)
+	// ListComments lists the comments on the commit with the given SHA, oldest first, including
+	// the comments on a line of a file of the commit.
+	//
+	// ErrNoProviderSupport is returned by providers without commit comments, see FeatureCommitComments.
+	ListComments(ctx context.Context, sha string) ([]CommitCommentInfo, error)
+	// CreateComment adds a comment with the given body to the commit with the given SHA, e.g. to
+	// attach provenance or attestation metadata to it.
+	//
+	// ErrNoProviderSupport is returned by providers without commit comments, see FeatureCommitComments.
+	CreateComment(ctx context.Context, sha, body string) (CommitCommentInfo, error)
 }
 
 // BranchClient operates on the branches for a specific repository.
 // This client can be accessed through Repository.Branches().
 type BranchClient interface {
-	// Create creates a branch with the given specifications.
+	// Create creates a branch with the given name, pointing to the commit with the given SHA.
 	Create(ctx context.Context, branch, sha string) error
+	// Delete deletes the branch with the given name.
+	//
+	// ErrNotFound is returned if the branch doesn't exist.
+	Delete(ctx context.Context, branch string) error
+	// List lists all branches in the repository, including their protection status.
+	List(ctx context.Context) ([]Branch, error)
+	// SetDefault makes the branch with the given name the default branch of the repository.
+	SetDefault(ctx context.Context, branch string) error
+	// GetRules returns the rules in effect on the branch with the given name, the normalized
+	// subset of the GitHub rulesets, GitLab protected branches or Bitbucket Server ref
+	// restrictions which apply to it. Use the provider extensions or raw clients to manage the
+	// rules themselves, e.g. the GitHub rulesets.
+	//
+	// ErrNoProviderSupport is returned by providers without branch rules, see FeatureBranchRules.
+	GetRules(ctx context.Context, branch string) (BranchRules, error)
+	// SyncForkWithUpstream brings the branch with the given name of this fork up to date with the
+	// branch of the same name of its parent repository, without a local clone. The provider
+	// endpoint is used where there is one, falling back to other strategies, see ForkSyncStrategy.
+	//
+	// ErrInvalidArgument is returned if the repository isn't a fork, ErrForkDiverged if the branch
+	// can't be synchronized, and ErrNoProviderSupport by providers without fork synchronization,
+	// see FeatureForkSync.
+	SyncForkWithUpstream(ctx context.Context, branch string) (ForkSyncResult, error)
 }
 
 // PullRequestClient operates on the pull requests for a specific repository.
@@ -235,11 +520,177 @@ type PullRequestClient interface {
 	Get(ctx context.Context, number int) (PullRequest, error)
 	// Merge merges a pull request with via either the "Squash" or "Merge" method
 	Merge(ctx context.Context, number int, mergeMethod MergeMethod, message string) error
+	// Close closes the pull request without merging it, which is declining it on Bitbucket Server.
+	Close(ctx context.Context, number int) error
+
+	// ListComments lists the general comments of the pull request, oldest first. Review comments
+	// on lines of the diff and system notes are not included.
+	ListComments(ctx context.Context, number int) ([]PullRequestComment, error)
+	// CreateComment adds a comment with the given body to the pull request.
+	CreateComment(ctx context.Context, number int, body string) (PullRequestComment, error)
+	// EditComment replaces the body of a comment of the pull request.
+	//
+	// ErrNotFound is returned if the comment doesn't exist.
+	EditComment(ctx context.Context, number int, id int64, body string) error
+	// DeleteComment deletes a comment of the pull request.
+	//
+	// ErrNotFound is returned if the comment doesn't exist.
+	DeleteComment(ctx context.Context, number int, id int64) error
 }
 
 // FileClient operates on the branches for a specific repository.
 // This client can be accessed through Repository.Branches().
 type FileClient interface {
-	// GetFiles fetch files content from specific path and branch
-	Get(ctx context.Context, path, branch string) ([]*CommitFile, error)
+	// Get fetches the content of the files at the given path, at the given Git reference,
+	// which can be a branch, tag or commit SHA. The files are buffered in memory, use Open for large files.
+	//
+	// ErrEmptyRepository is returned if the repository doesn't have any commits yet.
+	Get(ctx context.Context, path, ref string) ([]*CommitFile, error)
+
+	// Open returns a reader streaming the raw content of the file at the given path, at the given Git
+	// reference, along with the size and blob SHA of the file. The size can be used to enforce size
+	// limits before reading the content. The caller is responsible for closing the returned reader.
+	//
+	// ErrNotFound is returned if the file doesn't exist.
+	Open(ctx context.Context, path, ref string) (io.ReadCloser, *FileInfo, error)
+}
+
+// MergeQueueClient operates on the merge queue for a specific repository, e.g. the GitHub merge
+// queue or GitLab merge trains. Automation should enqueue pull requests, rather than merging them
+// directly, if the base branch requires a merge queue.
+// This client can be accessed through Repository.MergeQueue().
+//
+// ErrNoProviderSupport is returned by providers without merge queues, see FeatureMergeQueue.
+type MergeQueueClient interface {
+	// Enqueue adds the pull request with the given number to the merge queue of its base branch.
+	// The provider merges the pull request once the checks pass in the queue.
+	Enqueue(ctx context.Context, number int) (MergeQueueEntry, error)
+	// Dequeue removes the pull request with the given number from the merge queue.
+	Dequeue(ctx context.Context, number int) error
+	// Get returns the merge queue entry of the pull request with the given number.
+	//
+	// ErrNotFound is returned if the pull request isn't queued.
+	Get(ctx context.Context, number int) (MergeQueueEntry, error)
+	// List lists the entries of the merge queue of the given base branch, in merge order.
+	List(ctx context.Context, baseBranch string) ([]MergeQueueEntry, error)
+}
+
+// ReactionClient operates on the emoji reactions on the issues, pull requests and general
+// comments of a specific repository.
+// This client can be accessed through Repository.Reactions().
+//
+// ErrNoProviderSupport is returned by providers without reactions, see FeatureReactions.
+type ReactionClient interface {
+	// List lists the reactions on the given subject, oldest first.
+	//
+	// ErrNotFound is returned if the subject doesn't exist.
+	List(ctx context.Context, subject ReactionSubject) ([]ReactionInfo, error)
+	// Add adds a reaction with the given content to the subject, as the authenticated user.
+	// If the user already reacted with that content, the existing reaction is returned.
+	//
+	// ErrNotFound is returned if the subject doesn't exist.
+	Add(ctx context.Context, subject ReactionSubject, content ReactionContent) (ReactionInfo, error)
+	// Remove removes the reaction with the given ID from the subject.
+	//
+	// ErrNotFound is returned if the reaction doesn't exist.
+	Remove(ctx context.Context, subject ReactionSubject, id int64) error
+}
+
+// EnvironmentClient operates on the protection rules of the deployment environments of a specific
+// repository, i.e. GitHub environments or GitLab protected environments, so that deployment gates
+// can be reconciled along with the repository.
+// This client can be accessed through Repository.Environments().
+//
+// ErrNoProviderSupport is returned by providers without environment protection, see
+// FeatureEnvironmentProtection.
+type EnvironmentClient interface {
+	// Get returns the protection rules of the environment with the given name.
+	//
+	// ErrNotFound is returned if the environment doesn't exist, or isn't protected on GitLab.
+	Get(ctx context.Context, name string) (EnvironmentInfo, error)
+	// List lists the environments of the repository, or the protected ones on GitLab.
+	List(ctx context.Context) ([]EnvironmentInfo, error)
+	// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+	//
+	// If req doesn't exist under the hood, it is created (actionTaken == true).
+	// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+	// If req is already the actual state, this is a no-op (actionTaken == false).
+	Reconcile(ctx context.Context, req EnvironmentInfo) (resp EnvironmentInfo, actionTaken bool, err error)
+	// Delete deletes the environment on GitHub, or removes its protection on GitLab.
+	//
+	// ErrNotFound is returned if the environment doesn't exist.
+	Delete(ctx context.Context, name string) error
+}
+
+// TagProtectionClient operates on the rules protecting the tags of a specific repository, i.e.
+// GitHub tag protection rules or GitLab protected tags, so that release tags can't be deleted
+// or overwritten.
+// This client can be accessed through Repository.TagProtection().
+//
+// ErrNoProviderSupport is returned by providers without tag protection, see FeatureTagProtection.
+type TagProtectionClient interface {
+	// List lists the tag protection rules of the repository.
+	List(ctx context.Context) ([]TagProtectionInfo, error)
+	// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+	//
+	// If req doesn't exist under the hood, it is created (actionTaken == true).
+	// If req is already the actual state, this is a no-op (actionTaken == false).
+	Reconcile(ctx context.Context, req TagProtectionInfo) (resp TagProtectionInfo, actionTaken bool, err error)
+	// Delete deletes the rule with the given pattern, which unprotects the matching tags.
+	//
+	// ErrNotFound is returned if there is no rule with the pattern.
+	Delete(ctx context.Context, pattern string) error
+}
+
+// ApprovalRuleClient operates on the approval rules of a specific repository, i.e. the GitLab
+// project-level merge request approval rules, which require approvals from eligible approvers
+// before pull requests can be merged. Plain branch protection doesn't cover them on GitLab.
+// This client can be accessed through Repository.ApprovalRules().
+//
+// ErrNoProviderSupport is returned by providers without approval rules, see FeatureApprovalRules.
+type ApprovalRuleClient interface {
+	// List lists the approval rules of the repository.
+	List(ctx context.Context) ([]ApprovalRuleInfo, error)
+	// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+	// The rules are matched by name.
+	//
+	// If req doesn't exist under the hood, it is created (actionTaken == true).
+	// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+	// If req is already the actual state, this is a no-op (actionTaken == false).
+	Reconcile(ctx context.Context, req ApprovalRuleInfo) (resp ApprovalRuleInfo, actionTaken bool, err error)
+	// Delete deletes the rule with the given name.
+	//
+	// ErrNotFound is returned if there is no rule with the name.
+	Delete(ctx context.Context, name string) error
+}
+
+// BadgeClient generates the URLs of the status badges of a specific repository, e.g. for
+// READMEs or generated docs.
+// This client can be accessed through Repository.Badges().
+//
+// ErrNoProviderSupport is returned by providers without badges, see FeatureBadges.
+type BadgeClient interface {
+	// URL returns the canonical URL of the badge image described by req, after checking that
+	// its target exists: the branch and workflow of a pipeline badge, or a release.
+	//
+	// ErrNotFound is returned if the target of the badge doesn't exist.
+	// ErrNoProviderSupport is returned if the provider has no badge of the given kind.
+	URL(ctx context.Context, req BadgeRequest) (string, error)
+}
+
+// MilestoneClient operates on the milestones for a specific repository.
+// This client can be accessed through Repository.Milestones().
+type MilestoneClient interface {
+	// List lists all milestones in the repository, both open and closed
+	List(ctx context.Context) ([]Milestone, error)
+	// Get retrieves an existing milestone by number
+	Get(ctx context.Context, number int) (Milestone, error)
+	// Create creates a milestone with the given specifications.
+	Create(ctx context.Context, req MilestoneInfo) (Milestone, error)
+	// AssignPullRequest assigns the pull request with the given number to the milestone
+	AssignPullRequest(ctx context.Context, number, pullRequestNumber int) error
+	// AssignIssue assigns the issue with the given number to the milestone
+	AssignIssue(ctx context.Context, number, issueNumber int) error
+	// Close closes the milestone with the given number
+	Close(ctx context.Context, number int) error
 }
@@ -0,0 +1,309 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ClientFactory creates a client for the Git provider at baseURL, which has the scheme and host
+// of the instance, e.g. "https://github.example.com". baseURL is nil for the default domain of
+// the provider. The factory sets the domain of the client from baseURL, so opts must not contain
+// WithDomain. token may be empty for anonymous access, or if opts contain WithTokenSource.
+// username is used by providers which authenticate with basic auth, like Bitbucket Server.
+//
+// Out-of-tree providers can register a ClientFactory with RegisterClientFactory, to be created
+// by Build and NewClientFromURL like the built-in providers.
+type ClientFactory func(baseURL *url.URL, username, token string, opts ...ClientOption) (Client, error)
+
+// defaultStashClonePrefix is the first path segment of the clone URLs of Bitbucket Server.
+const defaultStashClonePrefix = "scm"
+
+//nolint:gochecknoglobals
+var (
+	clientFactoriesMu sync.RWMutex
+	clientFactories   = map[ProviderID]ClientFactory{}
+)
+
+// RegisterClientFactory makes the clients of the given provider available through Build and
+// NewClientFromURL. Provider packages register their factory in init(), so the provider
+// package must be imported, e.g. with import _ "github.com/fluxcd/go-git-providers/github".
+//
+// RegisterClientFactory panics if factory is nil, or if a factory is already registered for provider.
+func RegisterClientFactory(provider ProviderID, factory ClientFactory) {
+	clientFactoriesMu.Lock()
+	defer clientFactoriesMu.Unlock()
+
+	if factory == nil {
+		panic(fmt.Sprintf("gitprovider: RegisterClientFactory factory for %s is nil", provider))
+	}
+	if _, ok := clientFactories[provider]; ok {
+		panic(fmt.Sprintf("gitprovider: RegisterClientFactory called twice for %s", provider))
+	}
+	clientFactories[provider] = factory
+}
+
+// ClientFactories returns the sorted IDs of the providers with a registered ClientFactory.
+func ClientFactories() []ProviderID {
+	clientFactoriesMu.RLock()
+	defer clientFactoriesMu.RUnlock()
+
+	ids := make([]ProviderID, 0, len(clientFactories))
+	for id := range clientFactories {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// BuildOptions are the options for creating a client with Build.
+type BuildOptions struct {
+	// Domain is the host name or base URL of the instance, e.g. "gitlab.example.com" or
+	// "https://bitbucket.example.com:8443". The HTTPS scheme is used if none is set.
+	// Default: the public instance of the provider, if there is one.
+	// +optional
+	Domain string
+
+	// Username is the user to authenticate as, for providers which authenticate with basic auth.
+	// +optional
+	Username string
+
+	// Token is the token to authenticate with. Empty for anonymous access, or if ClientOptions
+	// contain WithTokenSource.
+	// +optional
+	Token string
+
+	// ClientOptions are passed to the provider client, and must not contain WithDomain.
+	// +optional
+	ClientOptions []ClientOption
+}
+
+// Build creates a client for the given provider with its registered ClientFactory, e.g. for a
+// provider ID read from configuration. Consumers creating clients with Build support the
+// providers registered by the packages linked into the program, including out-of-tree ones.
+//
+// ErrNoProviderSupport is returned if no ClientFactory is registered for the provider.
+func Build(provider ProviderID, opts BuildOptions) (Client, error) {
+	clientFactoriesMu.RLock()
+	factory, ok := clientFactories[provider]
+	clientFactoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no client factory registered for provider %q: %w", provider, ErrNoProviderSupport)
+	}
+
+	var baseURL *url.URL
+	if opts.Domain != "" {
+		domain := opts.Domain
+		if !strings.Contains(domain, "://") {
+			domain = "https://" + domain
+		}
+		u, err := url.Parse(domain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid domain %q: %w", opts.Domain, err)
+		}
+		if u.Host == "" {
+			return nil, fmt.Errorf("domain %q has no host: %w", opts.Domain, ErrInvalidArgument)
+		}
+		baseURL = &url.URL{Scheme: u.Scheme, Host: u.Host}
+	}
+	return factory(baseURL, opts.Username, opts.Token, opts.ClientOptions...)
+}
+
+// WithProvider makes NewClientFromURL create a client for the given provider, instead of
+// detecting the provider from the host name, e.g. for self-hosted instances with a custom
+// host name. It's ignored by the provider constructors.
+func WithProvider(provider ProviderID) ClientOption {
+	if provider == "" {
+		return optionError(fmt.Errorf("provider cannot be empty: %w", ErrInvalidClientOptions))
+	}
+	return &ClientOptions{Provider: &provider}
+}
+
+// NewClientFromURL creates a client for the Git provider hosting rawURL, which may be the URL of
+// the instance, or of an organization or repository on it. The provider is set with WithProvider,
+// or detected from the host name with DetectProvider. The username is taken from the user info
+// of rawURL, if any, e.g. "https://user@bitbucket.example.com".
+//
+// ErrNoProviderSupport is returned if the provider can't be detected, or no ClientFactory is
+// registered for it.
+func NewClientFromURL(rawURL, token string, opts ...ClientOption) (Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("URL %q has no host: %w", rawURL, ErrURLInvalid)
+	}
+	if u.Scheme == "" {
+		u.Scheme = "https"
+	}
+
+	o, err := MakeClientOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	provider, ok := DetectProvider(u.Hostname())
+	if o.Provider != nil {
+		provider, ok = *o.Provider, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("can't detect the provider of %q, use WithProvider: %w", u.Host, ErrNoProviderSupport)
+	}
+
+	return Build(provider, BuildOptions{
+		Domain:        u.Scheme + "://" + u.Host,
+		Username:      u.User.Username(),
+		Token:         token,
+		ClientOptions: opts,
+	})
+}
+
+// RepositoryFromURL creates a client for the Git provider hosting the repository at rawURL, like
+// NewClientFromURL, and returns the repository. rawURL is the HTTPS clone URL or the web URL of the
+// repository, e.g. "https://github.com/fluxcd/flux2.git" or "https://gitlab.com/group/subgroup/project/-/tree/main".
+// Bitbucket Server URLs like "https://stash.example.com/scm/PRJ/repo.git" and
+// "https://stash.example.com/projects/PRJ/repos/repo/browse" are supported too.
+//
+// The returned repository is an OrgRepository if the repository belongs to an organization, which
+// is looked up if the URL doesn't tell, e.g. "https://github.com/owner/repo".
+//
+// ErrNotFound is returned if the repository does not exist.
+func RepositoryFromURL(ctx context.Context, rawURL, token string, opts ...ClientOption) (UserRepository, error) {
+	c, err := NewClientFromURL(rawURL, token, opts...)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	owner, name, userOwned, err := splitRepositoryPath(c.ProviderID(), u.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, rawURL)
+	}
+
+	orgRef := OrganizationRef{
+		Domain:           c.SupportedDomain(),
+		Organization:     owner[0],
+		SubOrganizations: owner[1:],
+	}
+	// The project key of Bitbucket Server, other providers ignore it
+	orgRef.SetKey(owner[0])
+	// The owner of a top-level path can be a user or an organization, except on Bitbucket Server,
+	// where the URLs of user repositories differ
+	if !userOwned && len(owner) == 1 && c.ProviderID() != ProviderStash {
+		_, err := c.Organizations().Get(ctx, orgRef)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			userOwned = true
+		case err != nil:
+			return nil, err
+		}
+	}
+
+	if userOwned {
+		ref := UserRepositoryRef{
+			UserRef:        UserRef{Domain: orgRef.Domain, UserLogin: strings.TrimPrefix(owner[0], "~")},
+			RepositoryName: name,
+		}
+		return c.UserRepositories().Get(ctx, ref)
+	}
+	return c.OrgRepositories().Get(ctx, OrgRepositoryRef{OrganizationRef: orgRef, RepositoryName: name})
+}
+
+// splitRepositoryPath returns the owner path and the name of the repository from the path of its
+// clone or web URL, and whether the owner is known to be a user.
+func splitRepositoryPath(provider ProviderID, path string) (owner []string, name string, userOwned bool, err error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for _, p := range parts {
+		if p == "" {
+			return nil, "", false, ErrURLInvalid
+		}
+	}
+
+	switch provider {
+	case ProviderGitHub:
+		// Web URLs have more parts after the repository, e.g. "tree/main"
+		if len(parts) > 2 {
+			parts = parts[:2]
+		}
+	case ProviderGitLab:
+		// Web URLs have more parts after a "-" separator, e.g. "-/tree/main"
+		for i, p := range parts {
+			if p == "-" {
+				parts = parts[:i]
+				break
+			}
+		}
+	case ProviderStash:
+		switch {
+		case len(parts) == 3 && parts[0] == defaultStashClonePrefix:
+			// scm/{project key}/{repo}.git, with "~" prefixing the user slug of personal repositories
+			parts = parts[1:]
+			userOwned = strings.HasPrefix(parts[0], "~")
+		case len(parts) >= 4 && parts[0] == "projects" && parts[2] == "repos":
+			parts = []string{parts[1], parts[3]}
+		case len(parts) >= 4 && parts[0] == "users" && parts[2] == "repos":
+			parts = []string{parts[1], parts[3]}
+			userOwned = true
+		default:
+			return nil, "", false, ErrURLInvalid
+		}
+	}
+
+	if len(parts) < 2 {
+		return nil, "", false, ErrURLMissingRepoName
+	}
+	// Never include any .git suffix at the end of the repository name
+	name = strings.TrimSuffix(parts[len(parts)-1], ".git")
+	return parts[:len(parts)-1], name, userOwned, nil
+}
+
+// DetectProvider returns the provider of a host name, and whether it was detected. Besides the
+// public instances, host names with a label containing "github", "gitlab", "bitbucket" or "stash"
+// (for Bitbucket Server) are detected, e.g. "gitlab.example.com" or "github-enterprise.corp",
+// as well as gitea.com and codeberg.org. The public bitbucket.org instance isn't supported.
+func DetectProvider(host string) (ProviderID, bool) {
+	host = strings.ToLower(host)
+	switch host {
+	case "github.com":
+		return ProviderGitHub, true
+	case "gitlab.com":
+		return ProviderGitLab, true
+	case "gitea.com", "codeberg.org":
+		return ProviderGitea, true
+	case "bitbucket.org":
+		return "", false
+	}
+	for _, label := range strings.Split(host, ".") {
+		switch {
+		case strings.Contains(label, "github"):
+			return ProviderGitHub, true
+		case strings.Contains(label, "gitlab"):
+			return ProviderGitLab, true
+		case strings.Contains(label, "bitbucket"), strings.Contains(label, "stash"):
+			return ProviderStash, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,412 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// fakeFactoryClient is the client created by the factory registered by the tests.
+type fakeFactoryClient struct {
+	Client
+	baseURL  string
+	username string
+	token    string
+}
+
+func init() {
+	RegisterClientFactory("fake-factory", func(baseURL *url.URL, username, token string, opts ...ClientOption) (Client, error) {
+		c := &fakeFactoryClient{username: username, token: token}
+		if baseURL != nil {
+			c.baseURL = baseURL.String()
+			opts = append(opts, WithDomain(baseURL.Host))
+		}
+		if _, err := MakeClientOptions(opts...); err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+}
+
+func TestNewClientFromURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		opts    []ClientOption
+		want    *fakeFactoryClient
+		wantErr error
+	}{
+		{
+			name: "provider hint",
+			url:  "https://user@git.example.com:8443/scm/proj/repo.git",
+			opts: []ClientOption{WithProvider("fake-factory")},
+			want: &fakeFactoryClient{baseURL: "https://git.example.com:8443", username: "user", token: "token"},
+		},
+		{
+			name:    "undetected provider",
+			url:     "https://git.example.com/org/repo",
+			wantErr: ErrNoProviderSupport,
+		},
+		{
+			name:    "unregistered provider",
+			url:     "https://gitlab.com/org/repo",
+			wantErr: ErrNoProviderSupport,
+		},
+		{
+			name:    "no host",
+			url:     "org/repo",
+			wantErr: ErrURLInvalid,
+		},
+		{
+			name:    "provider hint twice",
+			url:     "https://git.example.com",
+			opts:    []ClientOption{WithProvider("fake-factory"), WithProvider("fake-factory")},
+			wantErr: ErrInvalidClientOptions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClientFromURL(tt.url, "token", tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewClientFromURL() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := c.(*fakeFactoryClient)
+			if *got != *tt.want {
+				t.Errorf("NewClientFromURL() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider ProviderID
+		opts     BuildOptions
+		want     *fakeFactoryClient
+		wantErr  error
+	}{
+		{
+			name:     "default domain",
+			provider: "fake-factory",
+			opts:     BuildOptions{Token: "token"},
+			want:     &fakeFactoryClient{token: "token"},
+		},
+		{
+			name:     "host name",
+			provider: "fake-factory",
+			opts:     BuildOptions{Domain: "git.example.com", Username: "user"},
+			want:     &fakeFactoryClient{baseURL: "https://git.example.com", username: "user"},
+		},
+		{
+			name:     "base URL",
+			provider: "fake-factory",
+			opts:     BuildOptions{Domain: "http://git.example.com:7990/"},
+			want:     &fakeFactoryClient{baseURL: "http://git.example.com:7990"},
+		},
+		{
+			name:     "domain passed twice",
+			provider: "fake-factory",
+			opts:     BuildOptions{Domain: "git.example.com", ClientOptions: []ClientOption{WithDomain("git.example.com")}},
+			wantErr:  ErrInvalidClientOptions,
+		},
+		{
+			name:     "unregistered provider",
+			provider: "gitea",
+			wantErr:  ErrNoProviderSupport,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Build(tt.provider, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := c.(*fakeFactoryClient)
+			if *got != *tt.want {
+				t.Errorf("Build() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectProvider(t *testing.T) {
+	tests := []struct {
+		host   string
+		want   ProviderID
+		wantOK bool
+	}{
+		{host: "github.com", want: "github", wantOK: true},
+		{host: "GitLab.com", want: "gitlab", wantOK: true},
+		{host: "github-enterprise.corp.example.com", want: "github", wantOK: true},
+		{host: "gitlab.example.com", want: "gitlab", wantOK: true},
+		{host: "stash.example.com", want: "stash", wantOK: true},
+		{host: "bitbucket.example.com", want: "stash", wantOK: true},
+		{host: "codeberg.org", want: "gitea", wantOK: true},
+		{host: "bitbucket.org"},
+		{host: "git.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			got, ok := DetectProvider(tt.host)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("DetectProvider() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBuildPreset(t *testing.T) {
+	tests := []struct {
+		name    string
+		preset  string
+		opts    BuildOptions
+		wantErr error
+	}{
+		{
+			name:    "unknown preset",
+			preset:  "sourceforge",
+			wantErr: ErrInvalidArgument,
+		},
+		{
+			name:    "domain set",
+			preset:  "gitlab",
+			opts:    BuildOptions{Domain: "gitlab.example.com"},
+			wantErr: ErrInvalidArgument,
+		},
+		{
+			name:    "unregistered provider",
+			preset:  "codeberg",
+			wantErr: ErrNoProviderSupport,
+		},
+		{
+			name:    "invalid options",
+			preset:  "codeberg",
+			opts:    BuildOptions{ClientOptions: []ClientOption{WithRequestsPerSecond(-1)}},
+			wantErr: ErrInvalidClientOptions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildPreset(tt.preset, tt.opts); !errors.Is(err, tt.wantErr) {
+				t.Errorf("BuildPreset() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDomainPresets(t *testing.T) {
+	presets := DomainPresets()
+	for i, p := range presets {
+		if i > 0 && presets[i-1].Name >= p.Name {
+			t.Errorf("presets aren't sorted: %q before %q", presets[i-1].Name, p.Name)
+		}
+		if got, ok := LookupDomainPreset(p.Name); !ok || got != p {
+			t.Errorf("LookupDomainPreset(%q) = %+v, %v", p.Name, got, ok)
+		}
+		// The preset domains are detected as their provider, except for Bitbucket Cloud
+		if got, ok := DetectProvider(p.Domain); p.Provider != ProviderBitbucketCloud && (!ok || got != p.Provider) {
+			t.Errorf("DetectProvider(%q) = %q, %v, want %q", p.Domain, got, ok, p.Provider)
+		}
+	}
+}
+
+// reposFactoryClient is the client created by the "fake-repos" factory, serving the repositories
+// of the organizations and users in orgs and users.
+type reposFactoryClient struct {
+	Client
+	domain string
+	orgs   map[string][]string
+	users  map[string][]string
+}
+
+type reposFactoryOrgs struct {
+	OrganizationsClient
+	c *reposFactoryClient
+}
+
+type reposFactoryOrgRepos struct {
+	OrgRepositoriesClient
+	c *reposFactoryClient
+}
+
+type reposFactoryUserRepos struct {
+	UserRepositoriesClient
+	c *reposFactoryClient
+}
+
+type reposFactoryOrgRepo struct {
+	OrgRepository
+	ref OrgRepositoryRef
+}
+
+type reposFactoryUserRepo struct {
+	UserRepository
+	ref UserRepositoryRef
+}
+
+func (c *reposFactoryClient) SupportedDomain() string { return c.domain }
+func (c *reposFactoryClient) ProviderID() ProviderID  { return "fake-repos" }
+func (c *reposFactoryClient) Organizations() OrganizationsClient {
+	return reposFactoryOrgs{c: c}
+}
+func (c *reposFactoryClient) OrgRepositories() OrgRepositoriesClient {
+	return reposFactoryOrgRepos{c: c}
+}
+func (c *reposFactoryClient) UserRepositories() UserRepositoriesClient {
+	return reposFactoryUserRepos{c: c}
+}
+
+func (o reposFactoryOrgs) Get(_ context.Context, ref OrganizationRef) (Organization, error) {
+	if _, ok := o.c.orgs[ref.GetIdentity()]; !ok {
+		return nil, ErrNotFound
+	}
+	return nil, nil
+}
+
+func (r reposFactoryOrgRepos) Get(_ context.Context, ref OrgRepositoryRef, _ ...RepositoryGetOption) (OrgRepository, error) {
+	for _, name := range r.c.orgs[ref.GetIdentity()] {
+		if name == ref.RepositoryName {
+			return reposFactoryOrgRepo{ref: ref}, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r reposFactoryUserRepos) Get(_ context.Context, ref UserRepositoryRef, _ ...RepositoryGetOption) (UserRepository, error) {
+	for _, name := range r.c.users[ref.GetIdentity()] {
+		if name == ref.RepositoryName {
+			return reposFactoryUserRepo{ref: ref}, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func init() {
+	RegisterClientFactory("fake-repos", func(baseURL *url.URL, _, _ string, _ ...ClientOption) (Client, error) {
+		return &reposFactoryClient{
+			domain: baseURL.Host,
+			orgs:   map[string][]string{"fluxcd": {"flux2"}, "fluxcd/charts": {"podinfo"}},
+			users:  map[string][]string{"alice": {"dotfiles"}},
+		}, nil
+	})
+}
+
+func TestRepositoryFromURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    RepositoryRef
+		wantErr error
+	}{
+		{
+			name: "organization repository",
+			url:  "https://git.example.com/fluxcd/flux2.git",
+			want: OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "git.example.com", Organization: "fluxcd", SubOrganizations: []string{}}, RepositoryName: "flux2"},
+		},
+		{
+			name: "sub-organization repository",
+			url:  "https://git.example.com/fluxcd/charts/podinfo",
+			want: OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "git.example.com", Organization: "fluxcd", SubOrganizations: []string{"charts"}}, RepositoryName: "podinfo"},
+		},
+		{
+			name: "user repository",
+			url:  "https://git.example.com/alice/dotfiles",
+			want: UserRepositoryRef{UserRef: UserRef{Domain: "git.example.com", UserLogin: "alice"}, RepositoryName: "dotfiles"},
+		},
+		{
+			name:    "missing repository",
+			url:     "https://git.example.com/alice/other",
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "missing repository name",
+			url:     "https://git.example.com/fluxcd",
+			wantErr: ErrURLMissingRepoName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := RepositoryFromURL(context.Background(), tt.url, "token", WithProvider("fake-repos"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RepositoryFromURL() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var got RepositoryRef
+			switch r := repo.(type) {
+			case reposFactoryOrgRepo:
+				r.ref.key = ""
+				got = r.ref
+			case reposFactoryUserRepo:
+				got = r.ref
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RepositoryFromURL() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_splitRepositoryPath(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      ProviderID
+		path          string
+		wantOwner     []string
+		wantName      string
+		wantUserOwned bool
+		wantErr       error
+	}{
+		{name: "GitHub clone URL", provider: ProviderGitHub, path: "/fluxcd/flux2.git", wantOwner: []string{"fluxcd"}, wantName: "flux2"},
+		{name: "GitHub web URL", provider: ProviderGitHub, path: "/fluxcd/flux2/tree/main/docs", wantOwner: []string{"fluxcd"}, wantName: "flux2"},
+		{name: "GitLab subgroup", provider: ProviderGitLab, path: "/group/sub/project.git", wantOwner: []string{"group", "sub"}, wantName: "project"},
+		{name: "GitLab web URL", provider: ProviderGitLab, path: "/group/sub/project/-/tree/main", wantOwner: []string{"group", "sub"}, wantName: "project"},
+		{name: "Stash clone URL", provider: ProviderStash, path: "/scm/PRJ/repo.git", wantOwner: []string{"PRJ"}, wantName: "repo"},
+		{name: "Stash personal clone URL", provider: ProviderStash, path: "/scm/~alice/repo.git", wantOwner: []string{"~alice"}, wantName: "repo", wantUserOwned: true},
+		{name: "Stash web URL", provider: ProviderStash, path: "/projects/PRJ/repos/repo/browse", wantOwner: []string{"PRJ"}, wantName: "repo"},
+		{name: "Stash personal web URL", provider: ProviderStash, path: "/users/alice/repos/repo/browse", wantOwner: []string{"alice"}, wantName: "repo", wantUserOwned: true},
+		{name: "Stash unknown URL", provider: ProviderStash, path: "/PRJ/repo", wantErr: ErrURLInvalid},
+		{name: "empty part", provider: ProviderGitHub, path: "/fluxcd//flux2", wantErr: ErrURLInvalid},
+		{name: "no repository", provider: ProviderGitLab, path: "/group/-/issues", wantErr: ErrURLMissingRepoName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, name, userOwned, err := splitRepositoryPath(tt.provider, tt.path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("splitRepositoryPath() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(owner, tt.wantOwner) || name != tt.wantName || userOwned != tt.wantUserOwned {
+				t.Errorf("splitRepositoryPath() = %v, %q, %v, want %v, %q, %v", owner, name, userOwned, tt.wantOwner, tt.wantName, tt.wantUserOwned)
+			}
+		})
+	}
+}
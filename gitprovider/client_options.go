@@ -21,9 +21,12 @@ import (
 	"crypto/x509"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/fluxcd/go-git-providers/gitprovider/cache"
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2"
 )
 
@@ -45,6 +48,20 @@ type CommonClientOptions struct {
 	// deleting a repository) are allowed in the Client. Default: false
 	EnableDestructiveAPICalls *bool
 
+	// DryRun is a flag specifying whether Reconcile() calls should only compute the changes they
+	// would apply, without performing any writes. The changes are returned as a *DryRunError.
+	// Default: false
+	DryRun *bool
+
+	// OptimisticConcurrency is a flag specifying whether Update() calls of repositories, team
+	// access and deploy keys check that the object wasn't changed since it was read, returning a
+	// *ConflictError instead of overwriting the concurrent changes. Default: false
+	OptimisticConcurrency *bool
+
+	// OrgRepositoryDefaults are the repository defaults per organization, applied when creating
+	// or reconciling organization repositories. Default: no organization defaults
+	OrgRepositoryDefaults OrgRepositoryDefaults
+
 	// PreChainTransportHook is a function to get a custom RoundTripper that is given as the Transport
 	// to the *http.Client given to the provider-specific Client. It can be set for doing arbitrary
 	// modifications to HTTP requests. "in" might be nil, if so http.DefaultTransport is recommended.
@@ -59,11 +76,77 @@ type CommonClientOptions struct {
 	// Git provider API (in==nil) <-> "Post Chain" (out) <-> Provider Specific (e.g. auth, caching) <-> "Pre Chain" <-> *http.Client
 	PostChainTransportHook ChainableRoundTripperFunc
 
-	// Logger allows the caller to pass a logger for use by the provider
+	// Logger allows the caller to pass a logger for use by the provider. All requests to the
+	// Git provider, and their retries, are logged to it at debug level (V(1)).
 	Logger *logr.Logger
 
-	// CABundle is a []byte containing the CA bundle to use for the client.
+	// CABundle is a []byte containing the PEM-encoded CA bundle to trust, in addition to the
+	// system's certificate pool.
 	CABundle []byte
+
+	// ClientCertificate is presented to servers requesting TLS client authentication, e.g. an
+	// mTLS-terminating proxy in front of the Git provider.
+	ClientCertificate *tls.Certificate
+
+	// Clock is the source of time used for retries, backoff and polling. Default: RealClock
+	Clock Clock
+
+	// RequestsPerSecond limits the rate of outgoing HTTP requests of the client, using a
+	// token-bucket limiter. Requests served from the cache are not limited. Default: unlimited
+	RequestsPerSecond *float64
+
+	// Concurrency is the number of requests aggregate List calls, e.g. listing all repositories
+	// or teams of an organization, may run at once. Default: 1, i.e. requests are sequential
+	Concurrency *int
+
+	// WaitAfterCreate makes the repository Create calls wait up to the given duration for the
+	// created repository to become visible, see WaitUntilVisible. Default: no waiting
+	WaitAfterCreate *time.Duration
+
+	// TracerProvider is used to create an OpenTelemetry client span for every request to the
+	// Git provider. Default: no tracing
+	TracerProvider trace.TracerProvider
+
+	// MetricsRegisterer is the Prometheus registry the request metrics of the client are
+	// registered with. Use WithMetrics to set it. Default: no metrics
+	MetricsRegisterer prometheus.Registerer
+	// metrics holds the collectors registered with MetricsRegisterer.
+	metrics *clientMetrics
+
+	// UploadProgress is called while request bodies, e.g. file contents of new commits, are sent
+	// to the Git provider. Default: no progress reporting
+	UploadProgress UploadProgressFunc
+
+	// UploadBytesPerSecond limits the bandwidth used for sending request bodies to the Git
+	// provider. Default: unlimited
+	UploadBytesPerSecond *int64
+
+	// UserAgent replaces the User-Agent header of all requests. Default: the provider's Go client's
+	UserAgent *string
+
+	// Headers are set on all requests, e.g. "Sudo" for GitLab admin impersonation. They replace
+	// the headers of the same name set by the provider's Go client. The authentication headers,
+	// see WithHeaders, can't be set.
+	Headers http.Header
+
+	// Cassette is the path of the file HTTP interactions are recorded to, or replayed from. Use
+	// WithCassette to set it. Default: no recording
+	Cassette *string
+	// cassette holds the interactions of Cassette.
+	cassette *cassette
+
+	// FailoverEndpoints are the replicas of the Git provider API the requests are spread over,
+	// according to FailoverStrategy. Use WithFailoverEndpoints to set them. Default: no failover
+	FailoverEndpoints []FailoverEndpoint
+	// FailoverStrategy describes how FailoverEndpoints share the requests.
+	FailoverStrategy *FailoverStrategy
+	// failover holds the parsed FailoverEndpoints, and their health.
+	failover *failover
+
+	// SchemaDriftHook is called for the fields of server responses whose type doesn't match the
+	// expected one. Only supported by Bitbucket Server, for list responses. Default: the
+	// mismatches are logged
+	SchemaDriftHook SchemaDriftFunc
 }
 
 // ApplyToCommonClientOptions applies the currently set fields in opts to target. If both opts and
@@ -89,6 +172,30 @@ func (opts *CommonClientOptions) ApplyToCommonClientOptions(target *CommonClient
 		target.EnableDestructiveAPICalls = opts.EnableDestructiveAPICalls
 	}
 
+	if opts.DryRun != nil {
+		// Make sure the user didn't specify the DryRun twice
+		if target.DryRun != nil {
+			return fmt.Errorf("option DryRun already configured: %w", ErrInvalidClientOptions)
+		}
+		target.DryRun = opts.DryRun
+	}
+
+	if opts.OptimisticConcurrency != nil {
+		// Make sure the user didn't specify the OptimisticConcurrency twice
+		if target.OptimisticConcurrency != nil {
+			return fmt.Errorf("option OptimisticConcurrency already configured: %w", ErrInvalidClientOptions)
+		}
+		target.OptimisticConcurrency = opts.OptimisticConcurrency
+	}
+
+	if opts.OrgRepositoryDefaults != nil {
+		// Make sure the user didn't specify the OrgRepositoryDefaults twice
+		if target.OrgRepositoryDefaults != nil {
+			return fmt.Errorf("option OrgRepositoryDefaults already configured: %w", ErrInvalidClientOptions)
+		}
+		target.OrgRepositoryDefaults = opts.OrgRepositoryDefaults
+	}
+
 	if opts.PreChainTransportHook != nil {
 		// Make sure the user didn't specify the PreChainTransportHook twice
 		if target.PreChainTransportHook != nil {
@@ -112,6 +219,13 @@ func (opts *CommonClientOptions) ApplyToCommonClientOptions(target *CommonClient
 		target.Logger = opts.Logger
 	}
 
+	// The PostChainTransportHook replaces the transport the CA bundle is configured in, except the
+	// one of WithCustomCAPostChainTransportHook, which sets both
+	if (opts.CABundle != nil && opts.PostChainTransportHook == nil && target.PostChainTransportHook != nil) ||
+		(opts.PostChainTransportHook != nil && opts.CABundle == nil && target.CABundle != nil) {
+		return fmt.Errorf("option CABundle can't be combined with a PostChainTransportHook: %w", ErrInvalidClientOptions)
+	}
+
 	if opts.CABundle != nil {
 		if target.CABundle != nil {
 			return fmt.Errorf("option CABundle already configured: %w", ErrInvalidClientOptions)
@@ -119,6 +233,108 @@ func (opts *CommonClientOptions) ApplyToCommonClientOptions(target *CommonClient
 		target.CABundle = opts.CABundle
 	}
 
+	if opts.ClientCertificate != nil {
+		if target.ClientCertificate != nil {
+			return fmt.Errorf("option ClientCertificate already configured: %w", ErrInvalidClientOptions)
+		}
+		target.ClientCertificate = opts.ClientCertificate
+	}
+
+	if opts.Clock != nil {
+		if target.Clock != nil {
+			return fmt.Errorf("option Clock already configured: %w", ErrInvalidClientOptions)
+		}
+		target.Clock = opts.Clock
+	}
+
+	if opts.RequestsPerSecond != nil {
+		if target.RequestsPerSecond != nil {
+			return fmt.Errorf("option RequestsPerSecond already configured: %w", ErrInvalidClientOptions)
+		}
+		target.RequestsPerSecond = opts.RequestsPerSecond
+	}
+
+	if opts.Concurrency != nil {
+		if target.Concurrency != nil {
+			return fmt.Errorf("option Concurrency already configured: %w", ErrInvalidClientOptions)
+		}
+		target.Concurrency = opts.Concurrency
+	}
+
+	if opts.WaitAfterCreate != nil {
+		if target.WaitAfterCreate != nil {
+			return fmt.Errorf("option WaitAfterCreate already configured: %w", ErrInvalidClientOptions)
+		}
+		target.WaitAfterCreate = opts.WaitAfterCreate
+	}
+
+	if opts.TracerProvider != nil {
+		if target.TracerProvider != nil {
+			return fmt.Errorf("option TracerProvider already configured: %w", ErrInvalidClientOptions)
+		}
+		target.TracerProvider = opts.TracerProvider
+	}
+
+	if opts.MetricsRegisterer != nil {
+		if target.MetricsRegisterer != nil {
+			return fmt.Errorf("option MetricsRegisterer already configured: %w", ErrInvalidClientOptions)
+		}
+		target.MetricsRegisterer = opts.MetricsRegisterer
+		target.metrics = opts.metrics
+	}
+
+	if opts.UploadProgress != nil {
+		if target.UploadProgress != nil {
+			return fmt.Errorf("option UploadProgress already configured: %w", ErrInvalidClientOptions)
+		}
+		target.UploadProgress = opts.UploadProgress
+	}
+
+	if opts.UploadBytesPerSecond != nil {
+		if target.UploadBytesPerSecond != nil {
+			return fmt.Errorf("option UploadBytesPerSecond already configured: %w", ErrInvalidClientOptions)
+		}
+		target.UploadBytesPerSecond = opts.UploadBytesPerSecond
+	}
+
+	if opts.UserAgent != nil {
+		if target.UserAgent != nil {
+			return fmt.Errorf("option UserAgent already configured: %w", ErrInvalidClientOptions)
+		}
+		target.UserAgent = opts.UserAgent
+	}
+
+	if opts.Headers != nil {
+		if target.Headers != nil {
+			return fmt.Errorf("option Headers already configured: %w", ErrInvalidClientOptions)
+		}
+		target.Headers = opts.Headers
+	}
+
+	if opts.Cassette != nil {
+		if target.Cassette != nil {
+			return fmt.Errorf("option Cassette already configured: %w", ErrInvalidClientOptions)
+		}
+		target.Cassette = opts.Cassette
+		target.cassette = opts.cassette
+	}
+
+	if opts.FailoverEndpoints != nil {
+		if target.FailoverEndpoints != nil {
+			return fmt.Errorf("option FailoverEndpoints already configured: %w", ErrInvalidClientOptions)
+		}
+		target.FailoverEndpoints = opts.FailoverEndpoints
+		target.FailoverStrategy = opts.FailoverStrategy
+		target.failover = opts.failover
+	}
+
+	if opts.SchemaDriftHook != nil {
+		if target.SchemaDriftHook != nil {
+			return fmt.Errorf("option SchemaDriftHook already configured: %w", ErrInvalidClientOptions)
+		}
+		target.SchemaDriftHook = opts.SchemaDriftHook
+	}
+
 	return nil
 }
 
@@ -152,6 +368,13 @@ type ClientOptions struct {
 	// authTransport is a ChainableRoundTripperFunc adding authentication credentials to the transport chain.
 	authTransport ChainableRoundTripperFunc
 
+	// TokenSource is the source of the OAuth2 tokens set with WithTokenSource, for providers that
+	// need the token outside of the HTTP transport chain, e.g. for Git operations.
+	TokenSource oauth2.TokenSource
+
+	// Provider is the provider NewClientFromURL creates a client for, set with WithProvider.
+	Provider *ProviderID
+
 	// enableConditionalRequests will be set if conditional requests should be used.
 	enableConditionalRequests *bool
 }
@@ -170,6 +393,15 @@ func (opts *ClientOptions) ApplyToClientOptions(target *ClientOptions) error {
 			return fmt.Errorf("option authTransport already configured: %w", ErrInvalidClientOptions)
 		}
 		target.authTransport = opts.authTransport
+		target.TokenSource = opts.TokenSource
+	}
+
+	if opts.Provider != nil {
+		// Make sure the user didn't specify the Provider twice
+		if target.Provider != nil {
+			return fmt.Errorf("option Provider already configured: %w", ErrInvalidClientOptions)
+		}
+		target.Provider = opts.Provider
 	}
 
 	if opts.enableConditionalRequests != nil {
@@ -187,10 +419,40 @@ func (opts *ClientOptions) ApplyToClientOptions(target *ClientOptions) error {
 func (opts *ClientOptions) GetTransportChain() (chain []ChainableRoundTripperFunc) {
 	if opts.PostChainTransportHook != nil {
 		chain = append(chain, opts.PostChainTransportHook)
+	} else if opts.CABundle != nil || opts.ClientCertificate != nil {
+		chain = append(chain, tlsTransportFunc(opts.CABundle, opts.ClientCertificate))
+	}
+	if opts.failover != nil {
+		chain = append(chain, failoverTransportFunc(opts.failover, opts.Clock))
+	}
+	if opts.cassette != nil {
+		chain = append(chain, cassetteTransportFunc(opts.cassette))
+	}
+	if opts.UploadProgress != nil || opts.UploadBytesPerSecond != nil {
+		chain = append(chain, uploadTransportFunc(opts.UploadProgress, opts.UploadBytesPerSecond))
+	}
+	if opts.Logger != nil {
+		chain = append(chain, loggingTransportFunc(*opts.Logger))
+	}
+	if opts.metrics != nil {
+		chain = append(chain, metricsTransportFunc(opts.metrics))
+	}
+	if opts.RequestsPerSecond != nil {
+		chain = append(chain, rateLimitTransportFunc(*opts.RequestsPerSecond))
+	}
+	if opts.TracerProvider != nil {
+		chain = append(chain, tracingTransportFunc(opts.TracerProvider))
 	}
 	if opts.authTransport != nil {
 		chain = append(chain, opts.authTransport)
 	}
+	if opts.UserAgent != nil || opts.Headers != nil {
+		userAgent := ""
+		if opts.UserAgent != nil {
+			userAgent = *opts.UserAgent
+		}
+		chain = append(chain, headersTransportFunc(opts.Headers, userAgent))
+	}
 	if opts.enableConditionalRequests != nil && *opts.enableConditionalRequests {
 		// TODO: Provide some kind of debug logging if/when the httpcache is used
 		// One can see if the request hit the cache using: resp.Header[httpcache.XFromCache]
@@ -232,8 +494,15 @@ func WithDomain(domain string) ClientOption {
 	return buildCommonOption(CommonClientOptions{Domain: &domain})
 }
 
-// WithLogger initializes a Client for a custom Stash instance with a logger.
+// WithLogger initializes a Client with a logger. The method, path, response status and latency
+// of all requests to the Git provider, as well as retry attempts, are logged at debug level (V(1)).
+// Secrets are redacted from everything logged, see Redact.
 func WithLogger(log *logr.Logger) ClientOption {
+	// Make sure no secrets are logged
+	if log != nil {
+		redacted := RedactLogger(*log)
+		log = &redacted
+	}
 	return buildCommonOption(CommonClientOptions{Logger: log})
 }
 
@@ -243,6 +512,250 @@ func WithDestructiveAPICalls(destructiveActions bool) ClientOption {
 	return buildCommonOption(CommonClientOptions{EnableDestructiveAPICalls: &destructiveActions})
 }
 
+// WithDryRun makes all Reconcile() calls of the Client compute the changes they would apply,
+// without performing any writes. If the actual state differs from the desired state, Reconcile()
+// returns actionTaken == true and a *DryRunError (matching ErrDryRun) listing the changes.
+// This is useful for plan/apply style workflows.
+//
+// The non-nil error is the plan, not a failure: callers must check for it with errors.As (or
+// errors.Is(err, ErrDryRun)) before handling err as a failed call, and must not expect the
+// returned objects to reflect the changes, as they weren't applied.
+func WithDryRun() ClientOption {
+	return buildCommonOption(CommonClientOptions{DryRun: BoolVar(true)})
+}
+
+// WithOptimisticConcurrency makes the Update() calls of repositories, team access and deploy keys
+// fetch the object first, and return a *ConflictError (matching ErrConflict) if it changed since
+// it was read, instead of overwriting the concurrent changes. Set() only changes the desired state
+// in the object, the check happens when Update() writes it. The Reconcile() calls of objects
+// overwrite the actual state by design, and skip the check.
+//
+// None of the providers support conditional updates of these objects, so a change made between
+// the check and the update is still overwritten.
+func WithOptimisticConcurrency() ClientOption {
+	return buildCommonOption(CommonClientOptions{OptimisticConcurrency: BoolVar(true)})
+}
+
+// WithOrgRepositoryDefaults sets the repository defaults per organization. They are used for the
+// fields left unset when creating or reconciling repositories in the organization, and their
+// create options are applied before the ones given to Create. Use ReconcileOrgDefaults to
+// back-fill them to the existing repositories.
+func WithOrgRepositoryDefaults(defaults OrgRepositoryDefaults) ClientOption {
+	if len(defaults) == 0 {
+		return optionError(fmt.Errorf("org repository defaults cannot be empty: %w", ErrInvalidClientOptions))
+	}
+	for org, d := range defaults {
+		if err := d.ValidateInfo(); err != nil {
+			return optionError(fmt.Errorf("invalid repository defaults for %q: %v: %w", org, err, ErrInvalidClientOptions))
+		}
+	}
+	return buildCommonOption(CommonClientOptions{OrgRepositoryDefaults: defaults})
+}
+
+// WithClock initializes a Client with a custom Clock, used for retries, backoff and polling.
+// This allows testing time-dependent behavior without real time passing.
+func WithClock(clock Clock) ClientOption {
+	// Don't allow an empty value
+	if clock == nil {
+		return optionError(fmt.Errorf("clock cannot be nil: %w", ErrInvalidClientOptions))
+	}
+
+	return buildCommonOption(CommonClientOptions{Clock: clock})
+}
+
+// WithRequestsPerSecond limits the outgoing HTTP requests of the Client to n per second, using a
+// token-bucket limiter allowing bursts of up to max(1, n) requests. This can be used to keep large
+// reconcile loops under e.g. limits imposed by the administrators of GitHub Enterprise.
+// n must be positive.
+func WithRequestsPerSecond(n float64) ClientOption {
+	// Don't allow a non-positive (or NaN) value
+	if !(n > 0) {
+		return optionError(fmt.Errorf("requests per second must be positive, got %v: %w", n, ErrInvalidClientOptions))
+	}
+
+	return buildCommonOption(CommonClientOptions{RequestsPerSecond: &n})
+}
+
+// WithConcurrency lets aggregate List calls of the Client, e.g. listing all repositories, teams
+// or team members of an organization, run up to n requests at once. Pages are fetched concurrently
+// once the first page reveals their number, which GitHub and GitLab report, and the members of
+// each team are fetched concurrently, as are the commits listed with CommitListOptions.WithStats on
+// GitHub. The requests still go through WithRequestsPerSecond, if set.
+// n must be positive.
+func WithConcurrency(n int) ClientOption {
+	// Don't allow a non-positive value
+	if n < 1 {
+		return optionError(fmt.Errorf("concurrency must be positive, got %d: %w", n, ErrInvalidClientOptions))
+	}
+
+	return buildCommonOption(CommonClientOptions{Concurrency: &n})
+}
+
+// WithWaitAfterCreate makes the repository Create calls of the Client, including the ones done by
+// Reconcile, wait up to timeout for the created repository to become visible through the API, as
+// the Git providers are eventually consistent. The polling interval is tuned per provider, and
+// uses the Clock of the Client. If the repository isn't visible in time, Create returns an error
+// wrapping ErrNotFound, although the repository was created. timeout must be positive.
+func WithWaitAfterCreate(timeout time.Duration) ClientOption {
+	// Don't allow a non-positive value
+	if timeout <= 0 {
+		return optionError(fmt.Errorf("wait after create timeout must be positive, got %s: %w", timeout, ErrInvalidClientOptions))
+	}
+
+	return buildCommonOption(CommonClientOptions{WaitAfterCreate: &timeout})
+}
+
+// WithTracerProvider makes the Client create an OpenTelemetry client span, using tp, for every
+// request to the Git provider. The spans are children of the span in the context passed to the
+// API call, are named after the API operation (e.g. "GET /repos/{owner}/{repo}"), and have
+// attributes for the HTTP method, URL and status code, the organization and repository, and
+// the request annotations (see WithRequestAnnotations).
+func WithTracerProvider(tp trace.TracerProvider) ClientOption {
+	// Don't allow an empty value
+	if tp == nil {
+		return optionError(fmt.Errorf("tracer provider cannot be nil: %w", ErrInvalidClientOptions))
+	}
+
+	return buildCommonOption(CommonClientOptions{TracerProvider: tp})
+}
+
+// WithMetrics registers Prometheus metrics for the requests of the Client with r: the
+// gitprovider_requests_total counter, by domain, method and status code, the
+// gitprovider_request_duration_seconds histogram, and the gitprovider_rate_limit_remaining gauge.
+// Several clients can use the same registry.
+func WithMetrics(r prometheus.Registerer) ClientOption {
+	// Don't allow an empty value
+	if r == nil {
+		return optionError(fmt.Errorf("metrics registerer cannot be nil: %w", ErrInvalidClientOptions))
+	}
+
+	m, err := newClientMetrics(r)
+	if err != nil {
+		return optionError(fmt.Errorf("failed to register metrics: %w", err))
+	}
+	return buildCommonOption(CommonClientOptions{MetricsRegisterer: r, metrics: m})
+}
+
+// WithUploadProgress makes the Client call fn while sending request bodies, e.g. the file contents of
+// new commits, to the Git provider.
+func WithUploadProgress(fn UploadProgressFunc) ClientOption {
+	// Don't allow an empty value
+	if fn == nil {
+		return optionError(fmt.Errorf("upload progress func cannot be nil: %w", ErrInvalidClientOptions))
+	}
+
+	return buildCommonOption(CommonClientOptions{UploadProgress: fn})
+}
+
+// WithUploadBandwidthLimit limits the bandwidth the Client uses for sending request bodies to the Git
+// provider to bytesPerSecond, across all concurrent requests. This allows pacing large uploads in
+// environments with constrained egress. bytesPerSecond must be positive.
+func WithUploadBandwidthLimit(bytesPerSecond int64) ClientOption {
+	// Don't allow a non-positive value
+	if bytesPerSecond <= 0 {
+		return optionError(fmt.Errorf("upload bytes per second must be positive, got %d: %w", bytesPerSecond, ErrInvalidClientOptions))
+	}
+
+	return buildCommonOption(CommonClientOptions{UploadBytesPerSecond: &bytesPerSecond})
+}
+
+// WithUserAgent sets the User-Agent header of all requests to the Git provider, e.g. to identify
+// the application in the provider's audit logs.
+func WithUserAgent(userAgent string) ClientOption {
+	// Don't allow an empty value
+	if userAgent == "" {
+		return optionError(fmt.Errorf("user agent cannot be empty: %w", ErrInvalidClientOptions))
+	}
+
+	return buildCommonOption(CommonClientOptions{UserAgent: &userAgent})
+}
+
+// WithHeaders sets static headers on all requests to the Git provider, e.g. "Sudo" for GitLab admin
+// impersonation, or headers required by a corporate proxy. They replace the headers of the same name
+// set by the provider's Go client. The Authorization, Private-Token, Job-Token and Cookie headers,
+// which carry the credentials of the providers, can't be set; use the authentication options of the
+// provider instead.
+func WithHeaders(header http.Header) ClientOption {
+	if len(header) == 0 {
+		return optionError(fmt.Errorf("headers cannot be empty: %w", ErrInvalidClientOptions))
+	}
+
+	// Canonicalize the keys, the header is copied to the requests as-is
+	h := http.Header{}
+	for k, values := range header {
+		for _, v := range values {
+			h.Add(k, v)
+		}
+	}
+	for _, k := range authHeaders {
+		if _, ok := h[k]; ok {
+			return optionError(fmt.Errorf("the %s header cannot be set: %w", k, ErrInvalidClientOptions))
+		}
+	}
+	return buildCommonOption(CommonClientOptions{Headers: h})
+}
+
+// authHeaders are the canonical keys of the headers the providers' Go clients authenticate with.
+var authHeaders = []string{"Authorization", "Private-Token", "Job-Token", "Cookie"}
+
+// WithCassette records the HTTP interactions of the Client with the Git provider to the file at
+// path, or replays them from it, for integration tests that run without network access or
+// credentials, e.g. in CI. If the file doesn't exist, the interactions are recorded, otherwise
+// they are replayed; the GITPROVIDER_CASSETTE_MODE environment variable can be set to "record" or
+// "replay" to force either. Requests are matched by method, URL and body, in the recorded order,
+// so the test must be deterministic, e.g. use fixed repository names. Request headers and cookies
+// aren't recorded. In replay mode, requests without a recorded interaction fail with
+// ErrCassetteInteractionNotFound.
+func WithCassette(path string) ClientOption {
+	// Don't allow an empty value
+	if path == "" {
+		return optionError(fmt.Errorf("cassette path cannot be empty: %w", ErrInvalidClientOptions))
+	}
+
+	c, err := newCassette(path, "")
+	if err != nil {
+		return optionError(fmt.Errorf("failed to open cassette: %w", err))
+	}
+	return buildCommonOption(CommonClientOptions{Cassette: &path, cassette: c})
+}
+
+// WithFailoverEndpoints spreads the requests to the Git provider over several replicas of its API,
+// e.g. self-hosted GitLab nodes in different regions, so that a single node outage doesn't break
+// the Client. Requests to any of the endpoints' hosts are sent to the endpoint chosen by strategy
+// instead. An endpoint is considered unhealthy for FailoverCooldown after a connection error or a
+// 502, 503 or 504 response, and the request is retried on the next endpoint if its body can be
+// replayed. When all the endpoints are unhealthy, they are tried anyway.
+//
+// The health checking is passive only: the endpoints aren't probed in the background, so an
+// endpoint is only found unhealthy by a failed request, and healthy again by a request after the
+// cooldown. The domain of the Client, set with WithDomain, must be the host of one of the
+// endpoints, ErrInvalidClientOptions is returned otherwise.
+func WithFailoverEndpoints(strategy FailoverStrategy, endpoints ...FailoverEndpoint) ClientOption {
+	f, err := newFailover(strategy, endpoints)
+	if err != nil {
+		return optionError(fmt.Errorf("%v: %w", err, ErrInvalidClientOptions))
+	}
+	return buildCommonOption(CommonClientOptions{
+		FailoverEndpoints: endpoints,
+		FailoverStrategy:  &strategy,
+		failover:          f,
+	})
+}
+
+// WithSchemaDriftHook makes the Client call hook for the fields of server responses whose type
+// doesn't match the expected one, e.g. because a self-hosted Git provider runs another version
+// than the client was written for. These fields are left unset, instead of failing the request.
+// Only supported by Bitbucket Server, for list responses, as the GitHub and GitLab responses are
+// decoded by their Go clients.
+func WithSchemaDriftHook(hook SchemaDriftFunc) ClientOption {
+	// Don't allow an empty value
+	if hook == nil {
+		return optionError(fmt.Errorf("schema drift hook cannot be nil: %w", ErrInvalidClientOptions))
+	}
+
+	return buildCommonOption(CommonClientOptions{SchemaDriftHook: hook})
+}
+
 // WithPreChainTransportHook registers a ChainableRoundTripperFunc "before" the cache and authentication
 // transports in the chain. For more information, see NewClient, and gitprovider.CommonClientOptions.PreChainTransportHook.
 func WithPreChainTransportHook(preRoundTripperFunc ChainableRoundTripperFunc) ClientOption {
@@ -276,14 +789,34 @@ func WithOAuth2Token(oauth2Token string) ClientOption {
 	return &ClientOptions{authTransport: oauth2Transport(oauth2Token)}
 }
 
+// WithTokenSource initializes a Client which authenticates using the OAuth2 tokens from ts.
+// Tokens are reused until they expire, after which a new token is requested from ts. This allows
+// using short-lived tokens, e.g. exchanged through OIDC or issued by Vault, in long-running
+// processes. WithTokenSource can't be combined with WithOAuth2Token.
+//
+// For GitLab, pass "oauth2" as the token type and an empty token to NewClient. For Bitbucket
+// Server, pass an empty token to NewStashClient.
+func WithTokenSource(ts oauth2.TokenSource) ClientOption {
+	// Don't allow an empty value
+	if ts == nil {
+		return optionError(fmt.Errorf("token source cannot be nil: %w", ErrInvalidClientOptions))
+	}
+
+	ts = oauth2.ReuseTokenSource(nil, ts)
+	return &ClientOptions{authTransport: tokenSourceTransport(ts), TokenSource: ts}
+}
+
 func oauth2Transport(oauth2Token string) ChainableRoundTripperFunc {
+	// Create a TokenSource of the given access token
+	return tokenSourceTransport(oauth2.ReuseTokenSource(nil, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: oauth2Token})))
+}
+
+func tokenSourceTransport(ts oauth2.TokenSource) ChainableRoundTripperFunc {
 	return func(in http.RoundTripper) http.RoundTripper {
-		// Create a TokenSource of the given access token
-		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: oauth2Token})
 		// Create a Transport, with "in" as the underlying transport, and the given TokenSource
 		return &oauth2.Transport{
 			Base:   in,
-			Source: oauth2.ReuseTokenSource(nil, ts),
+			Source: ts,
 		}
 	}
 }
@@ -303,11 +836,49 @@ func MakeClientOptions(opts ...ClientOption) (*ClientOptions, error) {
 			return nil, err
 		}
 	}
+	// Only the requests to the hosts of the failover endpoints fail over, which must include the domain
+	if o.failover != nil {
+		if err := o.failover.validateDomain(o.Domain); err != nil {
+			return nil, fmt.Errorf("%v: %w", err, ErrInvalidClientOptions)
+		}
+	}
+	// The PostChainTransportHook replaces the transport the client certificate is configured in
+	if o.ClientCertificate != nil && o.PostChainTransportHook != nil {
+		return nil, fmt.Errorf("option ClientCertificate can't be combined with a PostChainTransportHook: %w", ErrInvalidClientOptions)
+	}
 	return o, nil
 }
 
+// WithCABundle makes the client trust the CA certificates in the PEM-encoded caBundle, in addition to
+// the system's certificate pool, e.g. for a Git provider with a certificate issued by an internal CA.
+// It can't be combined with WithPostChainTransportHook, which replaces the transport used for TLS.
+// For Bitbucket Server, the CA bundle is also used for Git operations.
+func WithCABundle(caBundle []byte) ClientOption {
+	if len(caBundle) == 0 {
+		return optionError(fmt.Errorf("caBundle cannot be empty: %w", ErrInvalidClientOptions))
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(caBundle) {
+		return optionError(fmt.Errorf("caBundle doesn't contain any PEM-encoded certificates: %w", ErrInvalidClientOptions))
+	}
+	return buildCommonOption(CommonClientOptions{CABundle: caBundle})
+}
+
+// WithClientCertificate makes the client present the PEM-encoded certificate and private key to servers
+// requesting TLS client authentication, e.g. an mTLS-terminating proxy in front of the Git provider.
+// It can't be combined with WithPostChainTransportHook, which replaces the transport used for TLS.
+// The client certificate isn't used for the Git operations of Bitbucket Server.
+func WithClientCertificate(certPEM, keyPEM []byte) ClientOption {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return optionError(fmt.Errorf("invalid client certificate: %v: %w", err, ErrInvalidClientOptions))
+	}
+	return buildCommonOption(CommonClientOptions{ClientCertificate: &cert})
+}
+
 // WithCustomCAPostChainTransportHook registers a ChainableRoundTripperFunc "after" the cache and authentication
-// transports in the chain.
+// transports in the chain, trusting the CA certificates in caBundle. Prefer WithCABundle, which keeps
+// the proxy and timeout settings of http.DefaultTransport. Neither can be combined with
+// WithPostChainTransportHook.
 func WithCustomCAPostChainTransportHook(caBundle []byte) ClientOption {
 	// Don't allow an empty value
 	if len(caBundle) == 0 {
@@ -317,6 +888,33 @@ func WithCustomCAPostChainTransportHook(caBundle []byte) ClientOption {
 	return buildCommonOption(CommonClientOptions{CABundle: caBundle, PostChainTransportHook: caCustomTransport(caBundle)})
 }
 
+// tlsTransportFunc returns a transport based on http.DefaultTransport, trusting the CA certificates in
+// caBundle in addition to the system's, and presenting cert for TLS client authentication if set.
+func tlsTransportFunc(caBundle []byte, cert *tls.Certificate) ChainableRoundTripperFunc {
+	return func(_ http.RoundTripper) http.RoundTripper {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if len(caBundle) != 0 {
+			// discard error, as we're only using it to check if rootCA is empty
+			rootCAs, _ := x509.SystemCertPool()
+			if rootCAs == nil {
+				rootCAs = x509.NewCertPool()
+			}
+			rootCAs.AppendCertsFromPEM(caBundle)
+			tlsConfig.RootCAs = rootCAs
+		}
+		if cert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*cert}
+		}
+
+		transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+		if t, ok := http.DefaultTransport.(*http.Transport); ok {
+			transport = t.Clone()
+		}
+		transport.TLSClientConfig = tlsConfig
+		return transport
+	}
+}
+
 func caCustomTransport(caBundle []byte) ChainableRoundTripperFunc {
 	return func(_ http.RoundTripper) http.RoundTripper {
 		// discard error, as we're only using it to check if rootCA is empty
@@ -17,12 +17,24 @@ limitations under the License.
 package gitprovider
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/fluxcd/go-git-providers/validation"
+	"golang.org/x/oauth2"
 )
 
 func dummyRoundTripper1(http.RoundTripper) http.RoundTripper { return nil }
@@ -70,6 +82,10 @@ func withPostChainTransportHook(postRoundTripperFunc ChainableRoundTripperFunc)
 	return &CommonClientOptions{PostChainTransportHook: postRoundTripperFunc}
 }
 
+func withClock(clock Clock) commonClientOption {
+	return &CommonClientOptions{Clock: clock}
+}
+
 func Test_makeOptions(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -126,6 +142,16 @@ func Test_makeOptions(t *testing.T) {
 			opts:         []commonClientOption{withPostChainTransportHook(dummyRoundTripper1), withPostChainTransportHook(dummyRoundTripper1)},
 			expectedErrs: []error{ErrInvalidClientOptions},
 		},
+		{
+			name: "withClock",
+			opts: []commonClientOption{withClock(RealClock{})},
+			want: &CommonClientOptions{Clock: RealClock{}},
+		},
+		{
+			name:         "withClock, duplicate",
+			opts:         []commonClientOption{withClock(RealClock{}), withClock(RealClock{})},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -217,6 +243,9 @@ func Test_makeCientOptions(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	requestsPerSecond := 2.5
+	waitAfterCreate := time.Minute
+	orgDefaults := OrgRepositoryDefaults{"fluxcd": {Info: RepositoryInfo{Description: StringVar("managed")}}}
 	tests := []struct {
 		name         string
 		opts         []ClientOption
@@ -242,6 +271,98 @@ func Test_makeCientOptions(t *testing.T) {
 			opts: []ClientOption{WithDestructiveAPICalls(true)},
 			want: buildCommonOption(CommonClientOptions{EnableDestructiveAPICalls: BoolVar(true)}),
 		},
+		{
+			name: "WithDryRun",
+			opts: []ClientOption{WithDryRun()},
+			want: buildCommonOption(CommonClientOptions{DryRun: BoolVar(true)}),
+		},
+		{
+			name: "WithOptimisticConcurrency",
+			opts: []ClientOption{WithOptimisticConcurrency()},
+			want: buildCommonOption(CommonClientOptions{OptimisticConcurrency: BoolVar(true)}),
+		},
+		{
+			name: "WithRequestsPerSecond",
+			opts: []ClientOption{WithRequestsPerSecond(2.5)},
+			want: buildCommonOption(CommonClientOptions{RequestsPerSecond: &requestsPerSecond}),
+		},
+		{
+			name:         "WithRequestsPerSecond, zero",
+			opts:         []ClientOption{WithRequestsPerSecond(0)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name: "WithConcurrency",
+			opts: []ClientOption{WithConcurrency(4)},
+			want: buildCommonOption(CommonClientOptions{Concurrency: IntVar(4)}),
+		},
+		{
+			name:         "WithConcurrency, zero",
+			opts:         []ClientOption{WithConcurrency(0)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithConcurrency, duplicate",
+			opts:         []ClientOption{WithConcurrency(2), WithConcurrency(4)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name: "WithWaitAfterCreate",
+			opts: []ClientOption{WithWaitAfterCreate(time.Minute)},
+			want: buildCommonOption(CommonClientOptions{WaitAfterCreate: &waitAfterCreate}),
+		},
+		{
+			name:         "WithWaitAfterCreate, zero",
+			opts:         []ClientOption{WithWaitAfterCreate(0)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithTracerProvider, nil",
+			opts:         []ClientOption{WithTracerProvider(nil)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithMetrics, nil",
+			opts:         []ClientOption{WithMetrics(nil)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithUploadBandwidthLimit, zero",
+			opts:         []ClientOption{WithUploadBandwidthLimit(0)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithUploadProgress, nil",
+			opts:         []ClientOption{WithUploadProgress(nil)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithDryRun, duplicate",
+			opts:         []ClientOption{WithDryRun(), WithDryRun()},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithOptimisticConcurrency, duplicate",
+			opts:         []ClientOption{WithOptimisticConcurrency(), WithOptimisticConcurrency()},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name: "WithOrgRepositoryDefaults",
+			opts: []ClientOption{WithOrgRepositoryDefaults(orgDefaults)},
+			want: buildCommonOption(CommonClientOptions{OrgRepositoryDefaults: orgDefaults}),
+		},
+		{
+			name:         "WithOrgRepositoryDefaults, empty",
+			opts:         []ClientOption{WithOrgRepositoryDefaults(nil)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name: "WithOrgRepositoryDefaults, invalid visibility",
+			opts: []ClientOption{WithOrgRepositoryDefaults(OrgRepositoryDefaults{
+				"fluxcd": {Info: RepositoryInfo{Visibility: RepositoryVisibilityVar("secret")}},
+			})},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
 		{
 			name: "WithPreChainTransportHook",
 			opts: []ClientOption{WithPreChainTransportHook(dummyRoundTripper1)},
@@ -267,11 +388,96 @@ func Test_makeCientOptions(t *testing.T) {
 			opts: []ClientOption{WithCustomCAPostChainTransportHook(ca)},
 			want: buildCommonOption(CommonClientOptions{CABundle: ca, PostChainTransportHook: caCustomTransport(ca)}),
 		},
+		{
+			name:         "WithCustomCAPostChainTransportHook, with a PostChainTransportHook",
+			opts:         []ClientOption{WithCustomCAPostChainTransportHook(ca), WithPostChainTransportHook(dummyRoundTripper1)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
 		{
 			name:         "WithCustomCAPostChainTransportHook, nil",
 			opts:         []ClientOption{WithCustomCAPostChainTransportHook(nil)},
 			expectedErrs: []error{ErrInvalidClientOptions},
 		},
+		{
+			name: "WithCABundle",
+			opts: []ClientOption{WithCABundle(ca)},
+			want: buildCommonOption(CommonClientOptions{CABundle: ca}),
+		},
+		{
+			name:         "WithCABundle, with a PostChainTransportHook",
+			opts:         []ClientOption{WithCABundle(ca), WithPostChainTransportHook(dummyRoundTripper1)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithCABundle, after a PostChainTransportHook",
+			opts:         []ClientOption{WithPostChainTransportHook(dummyRoundTripper1), WithCABundle(ca)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithCABundle, nil",
+			opts:         []ClientOption{WithCABundle(nil)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithCABundle, not PEM",
+			opts:         []ClientOption{WithCABundle([]byte("not a certificate"))},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithCABundle, duplicate",
+			opts:         []ClientOption{WithCABundle(ca), WithCABundle(ca)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name: "WithUserAgent",
+			opts: []ClientOption{WithUserAgent("my-operator/1.0")},
+			want: buildCommonOption(CommonClientOptions{UserAgent: StringVar("my-operator/1.0")}),
+		},
+		{
+			name:         "WithUserAgent, empty",
+			opts:         []ClientOption{WithUserAgent("")},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithUserAgent, duplicate",
+			opts:         []ClientOption{WithUserAgent("a"), WithUserAgent("b")},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name: "WithHeaders, canonicalized",
+			opts: []ClientOption{WithHeaders(http.Header{"sudo": {"admin"}})},
+			want: buildCommonOption(CommonClientOptions{Headers: http.Header{"Sudo": {"admin"}}}),
+		},
+		{
+			name:         "WithHeaders, empty",
+			opts:         []ClientOption{WithHeaders(nil)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithHeaders, Authorization",
+			opts:         []ClientOption{WithHeaders(http.Header{"authorization": {"Bearer foo"}})},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithHeaders, Private-Token",
+			opts:         []ClientOption{WithHeaders(http.Header{"PRIVATE-TOKEN": {"foo"}})},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithHeaders, Job-Token",
+			opts:         []ClientOption{WithHeaders(http.Header{"job-token": {"foo"}})},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithHeaders, Cookie",
+			opts:         []ClientOption{WithHeaders(http.Header{"Cookie": {"session=foo"}})},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithClientCertificate, invalid",
+			opts:         []ClientOption{WithClientCertificate([]byte("cert"), []byte("key"))},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
 		{
 			name: "WithOAuth2Token",
 			opts: []ClientOption{WithOAuth2Token("foo")},
@@ -282,6 +488,16 @@ func Test_makeCientOptions(t *testing.T) {
 			opts:         []ClientOption{WithOAuth2Token("")},
 			expectedErrs: []error{ErrInvalidClientOptions},
 		},
+		{
+			name:         "WithTokenSource, nil",
+			opts:         []ClientOption{WithTokenSource(nil)},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
+		{
+			name:         "WithTokenSource, exclusive with WithOAuth2Token",
+			opts:         []ClientOption{WithOAuth2Token("foo"), WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "bar"}))},
+			expectedErrs: []error{ErrInvalidClientOptions},
+		},
 		{
 			name: "WithConditionalRequests",
 			opts: []ClientOption{WithConditionalRequests(true)},
@@ -317,3 +533,160 @@ func Test_makeCientOptions(t *testing.T) {
 		})
 	}
 }
+
+// countingTokenSource returns a new token, valid for validity, on every call.
+type countingTokenSource struct {
+	calls    int
+	validity time.Duration
+}
+
+func (ts *countingTokenSource) Token() (*oauth2.Token, error) {
+	ts.calls++
+	return &oauth2.Token{AccessToken: fmt.Sprintf("token-%d", ts.calls), Expiry: time.Now().Add(ts.validity)}, nil
+}
+
+func TestWithTokenSource(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	// Tokens valid for a second expire within the oauth2 expiry delta, so every request gets a new one
+	for _, tt := range []struct {
+		validity time.Duration
+		want     []string
+	}{
+		{validity: time.Hour, want: []string{"Bearer token-1", "Bearer token-1"}},
+		{validity: time.Second, want: []string{"Bearer token-1", "Bearer token-2"}},
+	} {
+		got = nil
+		ts := &countingTokenSource{validity: tt.validity}
+		opts, err := MakeClientOptions(WithTokenSource(ts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := BuildClientFromTransportChain(opts.GetTransportChain())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("validity %v: Authorization headers = %v, want %v", tt.validity, got, tt.want)
+		}
+	}
+
+	// The TokenSource exposed to the providers shares the cached token
+	ts := &countingTokenSource{validity: time.Hour}
+	opts, err := MakeClientOptions(WithTokenSource(ts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if tok, err := opts.TokenSource.Token(); err != nil || tok.AccessToken != "token-1" {
+			t.Errorf("TokenSource.Token() = %v, %v, want token-1", tok, err)
+		}
+	}
+}
+
+// selfSignedCertificate returns a PEM-encoded self-signed certificate and its private key.
+func selfSignedCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gitprovider-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestWithClientCertificate(t *testing.T) {
+	certPEM, keyPEM := selfSignedCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(certPEM)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	tests := []struct {
+		name    string
+		opts    []ClientOption
+		wantErr bool
+	}{
+		{
+			name: "CA bundle and client certificate",
+			opts: []ClientOption{WithCABundle(serverCA), WithClientCertificate(certPEM, keyPEM)},
+		},
+		{
+			name: "CA bundle and client certificate, with a PreChainTransportHook",
+			opts: []ClientOption{
+				WithClientCertificate(certPEM, keyPEM),
+				WithCABundle(serverCA),
+				WithPreChainTransportHook(func(in http.RoundTripper) http.RoundTripper { return in }),
+			},
+		},
+		{
+			name:    "no client certificate",
+			opts:    []ClientOption{WithCABundle(serverCA)},
+			wantErr: true,
+		},
+		{
+			name:    "untrusted server",
+			opts:    []ClientOption{WithClientCertificate(certPEM, keyPEM)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := MakeClientOptions(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := BuildClientFromTransportChain(opts.GetTransportChain())
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.Get(srv.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Get() status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+		})
+	}
+
+	// The client certificate can't be set on a transport from a PostChainTransportHook
+	_, err := MakeClientOptions(WithClientCertificate(certPEM, keyPEM), WithPostChainTransportHook(dummyRoundTripper1))
+	validation.TestExpectErrors(t, "MakeClientOptions", err, ErrInvalidClientOptions)
+}
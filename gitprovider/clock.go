@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time used by the clients for retries, backoff and polling.
+// It can be set using WithClock, in order to make time-dependent behavior testable without
+// real time passing.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Sleep blocks for the given duration. If ctx is done before that, ctx.Err() is returned.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// RealClock implements Clock using the system time. It is the default Clock for all clients.
+type RealClock struct{}
+
+// RealClock implements Clock.
+var _ Clock = RealClock{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep blocks for the given duration, or until ctx is done.
+func (RealClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ClockOrDefault returns c, or RealClock if c is nil.
+func ClockOrDefault(c Clock) Clock {
+	if c == nil {
+		return RealClock{}
+	}
+	return c
+}
@@ -0,0 +1,371 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package codeowners reads, parses, modifies and writes the CODEOWNERS file of a repository
+// through the high-level API, and validates its owners against the organization and the access
+// to the repository, e.g. for access reviews.
+//
+// The format is the common subset of GitHub and GitLab: rules of a gitignore-style pattern and
+// its owners, which are users (@login), teams (@org/team, or @group/subgroup on GitLab) and
+// emails. GitLab sections, e.g. "[Docs] @docs-team", are supported, as are comments, which are
+// kept when the file is written back.
+package codeowners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// DefaultPath is where Write creates the file, unless File.Path is set. Both GitHub and GitLab
+// read it there.
+const DefaultPath = "CODEOWNERS"
+
+// Locations are the paths Read looks for the file at, in order. GitHub and GitLab use the
+// first one found.
+//
+//nolint:gochecknoglobals
+var Locations = []string{".github/CODEOWNERS", ".gitlab/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+var (
+	userOwnerRegex  = regexp.MustCompile(`^@[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	teamOwnerRegex  = regexp.MustCompile(`^@[A-Za-z0-9][A-Za-z0-9._-]*(/[A-Za-z0-9][A-Za-z0-9._-]*)+$`)
+	emailOwnerRegex = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	// sectionRegex matches GitLab section headers, e.g. "^[Docs][2] @docs-team"
+	sectionRegex = regexp.MustCompile(`^\^?\[([^\]]+)\](\[[0-9]+\])?(.*)$`)
+)
+
+// OwnerKind is the kind of an owner.
+type OwnerKind string
+
+const (
+	// OwnerKindUser is a user, e.g. "@octocat"
+	OwnerKindUser = OwnerKind("user")
+	// OwnerKindTeam is a team of an organization, e.g. "@acme/developers"
+	OwnerKindTeam = OwnerKind("team")
+	// OwnerKindEmail is the email of a user, e.g. "octocat@example.com"
+	OwnerKindEmail = OwnerKind("email")
+)
+
+// KindOf returns the kind of the owner, or ErrInvalidArgument if it isn't a valid owner.
+func KindOf(owner string) (OwnerKind, error) {
+	switch {
+	case userOwnerRegex.MatchString(owner):
+		return OwnerKindUser, nil
+	case teamOwnerRegex.MatchString(owner):
+		return OwnerKindTeam, nil
+	case emailOwnerRegex.MatchString(owner):
+		return OwnerKindEmail, nil
+	}
+	return "", fmt.Errorf("invalid owner %q: %w", owner, gitprovider.ErrInvalidArgument)
+}
+
+// Rule assigns owners to the files matching a pattern.
+type Rule struct {
+	// Pattern is the gitignore-style pattern of the files, e.g. "*.go" or "/docs/".
+	Pattern string
+
+	// Owners are the owners of the files. If empty, the files have no owners on GitHub, and the
+	// default owners of the section on GitLab.
+	Owners []string
+
+	// Section is the name of the GitLab section of the rule, or empty.
+	Section string
+
+	// Line is the line of the rule in the parsed file, or 0 if it was added by SetOwners.
+	Line int
+}
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	// Path is where the file was read from, and where Write writes it to.
+	Path string
+
+	lines []*line
+}
+
+// line is a line of the file, which is either a rule, a section header or text kept as-is.
+type line struct {
+	rule    *Rule
+	section *section
+	// comment is the comment after the owners of a rule, including the "#"
+	comment string
+	// text is the line as it was parsed, or empty if the rule changed
+	text string
+}
+
+// section is a GitLab section header.
+type section struct {
+	name   string
+	owners []string
+}
+
+// Parse parses the content of a CODEOWNERS file.
+//
+// ErrInvalidArgument is returned if an owner is invalid.
+func Parse(data []byte) (*File, error) {
+	f := &File{}
+	currentSection := ""
+	for i, text := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		l := &line{text: text}
+		f.lines = append(f.lines, l)
+
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		content, comment := splitComment(trimmed)
+		fields := strings.Fields(content)
+		if m := sectionRegex.FindStringSubmatch(content); m != nil {
+			owners := strings.Fields(m[3])
+			if err := validateOwners(owners); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			currentSection = m[1]
+			l.section = &section{name: currentSection, owners: owners}
+			continue
+		}
+		if err := validateOwners(fields[1:]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		l.rule = &Rule{Pattern: fields[0], Owners: fields[1:], Section: currentSection, Line: i + 1}
+		l.comment = comment
+	}
+	return f, nil
+}
+
+// splitComment splits the comment off a line. Escaped "\#" are part of the pattern.
+func splitComment(s string) (string, string) {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && (i == 0 || s[i-1] != '\\') {
+			return strings.TrimSpace(s[:i]), s[i:]
+		}
+	}
+	return s, ""
+}
+
+func validateOwners(owners []string) error {
+	for _, owner := range owners {
+		if _, err := KindOf(owner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rules returns the rules of the file, in order.
+func (f *File) Rules() []Rule {
+	var rules []Rule
+	for _, l := range f.lines {
+		if l.rule != nil {
+			rule := *l.rule
+			rule.Owners = append([]string{}, rule.Owners...)
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Owners returns the owners of the file at path, relative to the root of the repository. The
+// last matching rule of every section applies, and the owners of the sections are combined.
+func (f *File) Owners(path string) []string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	var order []string
+	matches := map[string][]string{}
+	defaults := map[string][]string{}
+	for _, l := range f.lines {
+		if l.section != nil {
+			defaults[l.section.name] = l.section.owners
+			continue
+		}
+		if l.rule == nil || !matchPattern(l.rule.Pattern, parts) {
+			continue
+		}
+		if _, ok := matches[l.rule.Section]; !ok {
+			order = append(order, l.rule.Section)
+		}
+		owners := l.rule.Owners
+		if len(owners) == 0 && l.rule.Section != "" {
+			owners = defaults[l.rule.Section]
+		}
+		matches[l.rule.Section] = owners
+	}
+
+	var owners []string
+	seen := map[string]bool{}
+	for _, s := range order {
+		for _, owner := range matches[s] {
+			if !seen[owner] {
+				seen[owner] = true
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+// matchPattern returns true if the pattern matches the path, or one of its parent directories.
+func matchPattern(pattern string, parts []string) bool {
+	p := gitignore.ParsePattern(strings.ReplaceAll(pattern, `\#`, "#"), nil)
+	for i := 1; i <= len(parts); i++ {
+		if p.Match(parts[:i], i < len(parts)) == gitignore.Exclude {
+			return true
+		}
+	}
+	return false
+}
+
+// SetOwners sets the owners of the rule with the pattern, outside any section. The last such
+// rule is changed, as it has precedence, or a rule is added before the first section, which is
+// the end of the file without sections.
+//
+// ErrInvalidArgument is returned if the pattern is empty, or an owner is invalid.
+func (f *File) SetOwners(pattern string, owners ...string) error {
+	if pattern == "" || strings.ContainsAny(pattern, " \t") {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, gitprovider.ErrInvalidArgument)
+	}
+	if err := validateOwners(owners); err != nil {
+		return err
+	}
+	owners = append([]string{}, owners...)
+	if l := f.lastRule(pattern); l != nil {
+		l.rule.Owners = owners
+		l.text = ""
+		return nil
+	}
+	// Rules after a section header are part of the section
+	i := f.firstSection()
+	f.lines = append(f.lines[:i], append([]*line{{rule: &Rule{Pattern: pattern, Owners: owners}}}, f.lines[i:]...)...)
+	return nil
+}
+
+// RemoveRule removes the rules with the pattern outside any section, and returns whether there
+// were any.
+func (f *File) RemoveRule(pattern string) bool {
+	lines := f.lines[:0]
+	removed := false
+	for _, l := range f.lines {
+		if l.rule != nil && l.rule.Section == "" && l.rule.Pattern == pattern {
+			removed = true
+			continue
+		}
+		lines = append(lines, l)
+	}
+	f.lines = lines
+	return removed
+}
+
+func (f *File) lastRule(pattern string) *line {
+	var last *line
+	for _, l := range f.lines {
+		if l.rule != nil && l.rule.Section == "" && l.rule.Pattern == pattern {
+			last = l
+		}
+	}
+	return last
+}
+
+// sections returns the GitLab section headers, in order.
+func (f *File) sections() []*section {
+	var sections []*section
+	for _, l := range f.lines {
+		if l.section != nil {
+			sections = append(sections, l.section)
+		}
+	}
+	return sections
+}
+
+// firstSection returns the index of the first section header, or the number of lines.
+func (f *File) firstSection() int {
+	for i, l := range f.lines {
+		if l.section != nil {
+			return i
+		}
+	}
+	return len(f.lines)
+}
+
+// Bytes returns the content of the file. The unchanged lines are kept as-is.
+func (f *File) Bytes() []byte {
+	var b strings.Builder
+	for _, l := range f.lines {
+		switch {
+		case l.rule != nil && l.text == "":
+			b.WriteString(strings.Join(append([]string{l.rule.Pattern}, l.rule.Owners...), " "))
+			if l.comment != "" {
+				b.WriteString(" " + l.comment)
+			}
+		default:
+			b.WriteString(l.text)
+		}
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+// Read reads and parses the CODEOWNERS file of the repository at the given ref, e.g. a branch,
+// from the first of the Locations it exists at.
+//
+// ErrNotFound is returned if there is none.
+func Read(ctx context.Context, repo gitprovider.UserRepository, ref string) (*File, error) {
+	for _, path := range Locations {
+		rc, _, err := repo.Files().Open(ctx, path, ref)
+		if errors.Is(err, gitprovider.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s@%s: %w", path, ref, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s@%s: %w", path, ref, err)
+		}
+		f, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s@%s: %w", path, ref, err)
+		}
+		f.Path = path
+		return f, nil
+	}
+	return nil, fmt.Errorf("no CODEOWNERS file at %s: %w", ref, gitprovider.ErrNotFound)
+}
+
+// Write commits the file to the branch of the repository, at File.Path, or DefaultPath if
+// it's empty.
+func Write(ctx context.Context, repo gitprovider.UserRepository, branch, message string, f *File) (gitprovider.Commit, error) {
+	path := f.Path
+	if path == "" {
+		path = DefaultPath
+	}
+	content := string(f.Bytes())
+	commit, err := repo.Commits().Create(ctx, branch, message, []gitprovider.CommitFile{
+		{Path: gitprovider.StringVar(path), Content: &content},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit %s to the branch %q: %w", path, branch, err)
+	}
+	return commit, nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codeowners
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/fakeprovider"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+const testFile = `# Default owners
+*       @acme/developers
+
+/docs/  @acme/writers docs@example.com # docs team
+*.go    @octocat
+
+[Security][2] @acme/security
+/auth/
+go.mod  @hubot
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(testFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(f.Bytes()); got != testFile {
+		t.Errorf("Bytes() = %q, want the parsed content", got)
+	}
+	want := []Rule{
+		{Pattern: "*", Owners: []string{"@acme/developers"}, Line: 2},
+		{Pattern: "/docs/", Owners: []string{"@acme/writers", "docs@example.com"}, Line: 4},
+		{Pattern: "*.go", Owners: []string{"@octocat"}, Line: 5},
+		{Pattern: "/auth/", Owners: []string{}, Section: "Security", Line: 8},
+		{Pattern: "go.mod", Owners: []string{"@hubot"}, Section: "Security", Line: 9},
+	}
+	if got := f.Rules(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Rules() = %+v, want %+v", got, want)
+	}
+
+	if _, err := Parse([]byte("* @acme/ops foo")); !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Errorf("Parse() with an invalid owner error = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestOwners(t *testing.T) {
+	f, err := Parse([]byte(testFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "README.md", want: []string{"@acme/developers"}},
+		{path: "docs/guide/index.md", want: []string{"@acme/writers", "docs@example.com"}},
+		{path: "docs/main.go", want: []string{"@octocat"}},
+		{path: "auth/token.go", want: []string{"@octocat", "@acme/security"}},
+		{path: "go.mod", want: []string{"@acme/developers", "@hubot"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := f.Owners(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetOwners(t *testing.T) {
+	f, err := Parse([]byte(testFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.SetOwners("/docs/", "@acme/docs"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.SetOwners("/api/", "@acme/api"); err != nil {
+		t.Fatal(err)
+	}
+	if !f.RemoveRule("*.go") || f.RemoveRule("*.go") {
+		t.Error("RemoveRule() should only remove existing rules")
+	}
+	if err := f.SetOwners("/ci/", "not an owner"); !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Errorf("SetOwners() with an invalid owner error = %v, want ErrInvalidArgument", err)
+	}
+	want := `# Default owners
+*       @acme/developers
+
+/docs/ @acme/docs # docs team
+
+/api/ @acme/api
+[Security][2] @acme/security
+/auth/
+go.mod  @hubot
+`
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+}
+
+func TestReadWriteValidate(t *testing.T) {
+	ctx := context.Background()
+	c, err := fakeprovider.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgRef := gitprovider.OrganizationRef{Domain: fakeprovider.DefaultDomain, Organization: "acme"}
+	c.Server().AddOrganization(orgRef, gitprovider.OrganizationInfo{})
+	c.Server().AddTeam(orgRef, "developers", "alice")
+	c.Server().AddTeam(orgRef, "writers", "bob")
+	org, err := c.Organizations().Get(ctx, orgRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := c.OrgRepositories().Create(ctx, gitprovider.OrgRepositoryRef{OrganizationRef: orgRef, RepositoryName: "repo"}, gitprovider.RepositoryInfo{}, &gitprovider.RepositoryCreateOptions{
+		AutoInit: gitprovider.BoolVar(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.TeamAccess().Create(ctx, gitprovider.TeamAccessInfo{Name: "developers", Permission: gitprovider.RepositoryPermissionVar(gitprovider.RepositoryPermissionPush)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.TeamAccess().Create(ctx, gitprovider.TeamAccessInfo{Name: "writers", Permission: gitprovider.RepositoryPermissionVar(gitprovider.RepositoryPermissionPull)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Collaborators().Add(ctx, gitprovider.CollaboratorInfo{Login: "octocat", Permission: gitprovider.RepositoryPermissionVar(gitprovider.RepositoryPermissionAdmin)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Read(ctx, repo, "main"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Fatalf("Read() without CODEOWNERS error = %v, want ErrNotFound", err)
+	}
+	f, err := Parse([]byte("* @acme/developers @alice @octocat\n/docs/ @acme/writers @bob @acme/missing @other/team docs@example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Path = ".github/CODEOWNERS"
+	if _, err := Write(ctx, repo, "main", "Add CODEOWNERS", f); err != nil {
+		t.Fatal(err)
+	}
+	f, err = Read(ctx, repo, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Path != ".github/CODEOWNERS" || len(f.Rules()) != 2 {
+		t.Fatalf("Read() = %s with %+v", f.Path, f.Rules())
+	}
+
+	problems, err := Validate(ctx, repo, org, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Problem{
+		{Line: 2, Pattern: "/docs/", Owner: "@acme/writers", Reason: "team has no write access"},
+		{Line: 2, Pattern: "/docs/", Owner: "@bob", Reason: "user has no write access"},
+		{Line: 2, Pattern: "/docs/", Owner: "@acme/missing", Reason: "team not found"},
+		{Line: 2, Pattern: "/docs/", Owner: "@other/team", Reason: "team of another organization"},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Validate() = %+v, want %+v", problems, want)
+	}
+}
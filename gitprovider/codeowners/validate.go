@@ -0,0 +1,171 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codeowners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// Problem is an owner of a rule that can't own the files.
+type Problem struct {
+	// Line is the line of the rule, or 0 if it was added by SetOwners.
+	Line int
+
+	// Pattern is the pattern of the rule.
+	Pattern string
+
+	// Owner is the owner, e.g. "@acme/developers".
+	Owner string
+
+	// Reason describes the problem.
+	Reason string
+}
+
+// String returns the problem in the "line N: owner: reason" form.
+func (p Problem) String() string {
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Owner, p.Reason)
+}
+
+// Validate checks the users and teams owning the files of repo can review changes: the teams
+// must be teams of org, and like the users, have write access to the repository, directly or
+// through a team. The emails aren't checked, as they can't be resolved to users. org is nil for
+// user repositories, which teams can't own files of.
+//
+// The problems are returned in the order of the rules. An error is returned if the teams or the
+// access to the repository couldn't be listed.
+func Validate(ctx context.Context, repo gitprovider.UserRepository, org gitprovider.Organization, f *File) ([]Problem, error) {
+	a, err := listAccess(ctx, repo, org)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	for _, rule := range f.Rules() {
+		for _, owner := range rule.Owners {
+			if reason := a.check(owner); reason != "" {
+				problems = append(problems, Problem{Line: rule.Line, Pattern: rule.Pattern, Owner: owner, Reason: reason})
+			}
+		}
+	}
+	for _, s := range f.sections() {
+		for _, owner := range s.owners {
+			if reason := a.check(owner); reason != "" {
+				problems = append(problems, Problem{Pattern: "[" + s.name + "]", Owner: owner, Reason: reason})
+			}
+		}
+	}
+	return problems, nil
+}
+
+// access is who can write to a repository.
+type access struct {
+	// orgPath is the path of the organization, e.g. "group/subgroup", or empty
+	orgPath string
+	// teams maps the lowercase names of the teams of the organization to their members
+	teams map[string][]string
+	// teamWriters are the lowercase names of the teams with write access
+	teamWriters map[string]bool
+	// writers are the lowercase logins of the users with write access
+	writers map[string]bool
+}
+
+func listAccess(ctx context.Context, repo gitprovider.UserRepository, org gitprovider.Organization) (*access, error) {
+	a := &access{teams: map[string][]string{}, teamWriters: map[string]bool{}, writers: map[string]bool{}}
+
+	collaborators, err := repo.Collaborators().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the collaborators: %w", err)
+	}
+	for _, c := range collaborators {
+		if c.Permission != nil && canWrite(*c.Permission) {
+			a.writers[strings.ToLower(c.Login)] = true
+		}
+	}
+	if org == nil {
+		return a, nil
+	}
+
+	ref := org.Organization()
+	a.orgPath = strings.ToLower(strings.Join(append([]string{ref.Organization}, ref.SubOrganizations...), "/"))
+	teams, err := org.Teams().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the teams of %s: %w", ref.String(), err)
+	}
+	for _, team := range teams {
+		info := team.Get()
+		a.teams[strings.ToLower(info.Name)] = info.Members
+	}
+	orgRepo, ok := repo.(gitprovider.OrgRepository)
+	if !ok {
+		return a, nil
+	}
+	teamAccess, err := orgRepo.TeamAccess().List(ctx)
+	if err != nil && !errors.Is(err, gitprovider.ErrNoProviderSupport) {
+		return nil, fmt.Errorf("failed to list the team access: %w", err)
+	}
+	for _, ta := range teamAccess {
+		info := ta.Get()
+		if info.Permission == nil || !canWrite(*info.Permission) {
+			continue
+		}
+		name := strings.ToLower(info.Name)
+		a.teamWriters[name] = true
+		for _, member := range a.teams[name] {
+			a.writers[strings.ToLower(member)] = true
+		}
+	}
+	return a, nil
+}
+
+// check returns why the owner can't review changes, or an empty string if it can.
+func (a *access) check(owner string) string {
+	kind, err := KindOf(owner)
+	if err != nil {
+		return "invalid owner"
+	}
+	switch kind {
+	case OwnerKindTeam:
+		path := strings.ToLower(strings.TrimPrefix(owner, "@"))
+		i := strings.LastIndex(path, "/")
+		if a.orgPath == "" {
+			return "teams can't own the files of user repositories"
+		}
+		if path[:i] != a.orgPath {
+			return "team of another organization"
+		}
+		if _, ok := a.teams[path[i+1:]]; !ok {
+			return "team not found"
+		}
+		if !a.teamWriters[path[i+1:]] {
+			return "team has no write access"
+		}
+	case OwnerKindUser:
+		if !a.writers[strings.ToLower(strings.TrimPrefix(owner, "@"))] {
+			return "user has no write access"
+		}
+	}
+	return ""
+}
+
+func canWrite(p gitprovider.RepositoryPermission) bool {
+	return p == gitprovider.RepositoryPermissionPush || p == gitprovider.RepositoryPermissionMaintain || p == gitprovider.RepositoryPermissionAdmin
+}
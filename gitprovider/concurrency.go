@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import "sync"
+
+// RunConcurrently calls fn for every index in [0, n), with up to concurrency calls running at
+// once; a concurrency below 1 runs them one by one. Once a call fails, the calls which haven't
+// started yet are skipped. The error of the lowest failed index is returned, after all started
+// calls returned.
+func RunConcurrently(concurrency, n int, fn func(i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := false
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		mu.Lock()
+		stop := failed
+		mu.Unlock()
+		if stop {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(i); err != nil {
+				errs[i] = err
+				mu.Lock()
+				failed = true
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
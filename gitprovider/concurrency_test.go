@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunConcurrently(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name        string
+		concurrency int
+		n           int
+		failAt      int
+		wantErr     error
+		wantMaxPar  int
+	}{
+		{name: "sequential", concurrency: 1, n: 5, failAt: -1, wantMaxPar: 1},
+		{name: "non-positive concurrency is sequential", concurrency: 0, n: 5, failAt: -1, wantMaxPar: 1},
+		{name: "concurrent", concurrency: 3, n: 9, failAt: -1, wantMaxPar: 3},
+		{name: "nothing to run", concurrency: 3, n: 0, failAt: -1},
+		{name: "failure skips the remaining calls", concurrency: 1, n: 5, failAt: 2, wantErr: errBoom, wantMaxPar: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			running, maxPar := 0, 0
+			ran := make([]bool, tt.n)
+			err := RunConcurrently(tt.concurrency, tt.n, func(i int) error {
+				mu.Lock()
+				running++
+				if running > maxPar {
+					maxPar = running
+				}
+				ran[i] = true
+				mu.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				if i == tt.failAt {
+					return errBoom
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RunConcurrently() error = %v, want %v", err, tt.wantErr)
+			}
+			if maxPar != tt.wantMaxPar {
+				t.Errorf("RunConcurrently() ran up to %d calls in parallel, want %d", maxPar, tt.wantMaxPar)
+			}
+			for i, got := range ran {
+				if want := tt.failAt < 0 || i <= tt.failAt; got != want {
+					t.Errorf("RunConcurrently() ran call %d = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// CreateOrGetOrgRepository creates the organization repository of ref like
+// OrgRepositoriesClient.Create, but if it already exists, the existing repository is returned
+// instead of ErrAlreadyExists. created reports whether the repository was created by this call.
+//
+// Unlike Reconcile, the existing repository is not updated to match req.
+func CreateOrGetOrgRepository(ctx context.Context, c OrgRepositoriesClient, ref OrgRepositoryRef, req RepositoryInfo, opts ...RepositoryCreateOption) (repo OrgRepository, created bool, err error) {
+	repo, err = c.Create(ctx, ref, req, opts...)
+	if err == nil {
+		return repo, true, nil
+	}
+	if !errors.Is(err, ErrAlreadyExists) {
+		return nil, false, err
+	}
+	repo, err = c.Get(ctx, ref)
+	if err != nil {
+		return nil, false, err
+	}
+	return repo, false, nil
+}
+
+// CreateOrGetUserRepository creates the user repository of ref like
+// UserRepositoriesClient.Create, but if it already exists, the existing repository is returned
+// instead of ErrAlreadyExists. created reports whether the repository was created by this call.
+//
+// Unlike Reconcile, the existing repository is not updated to match req.
+func CreateOrGetUserRepository(ctx context.Context, c UserRepositoriesClient, ref UserRepositoryRef, req RepositoryInfo, opts ...RepositoryCreateOption) (repo UserRepository, created bool, err error) {
+	repo, err = c.Create(ctx, ref, req, opts...)
+	if err == nil {
+		return repo, true, nil
+	}
+	if !errors.Is(err, ErrAlreadyExists) {
+		return nil, false, err
+	}
+	repo, err = c.Get(ctx, ref)
+	if err != nil {
+		return nil, false, err
+	}
+	return repo, false, nil
+}
+
+// CreateOrGetDeployKey creates the deploy key of req like DeployKeyClient.Create, but if a key
+// with the name or the key material of req already exists, it is returned instead of
+// ErrAlreadyExists. created reports whether the key was created by this call.
+//
+// Providers like GitHub refuse a key which is in use under another name too, or by another
+// repository. In the latter case, the ErrAlreadyExists error of Create is returned, as there is
+// no key of the repository to return.
+//
+// Unlike Reconcile, the existing key is not recreated if it differs from req, compare the
+// returned key to req if that matters.
+func CreateOrGetDeployKey(ctx context.Context, c DeployKeyClient, req DeployKeyInfo) (key DeployKey, created bool, err error) {
+	key, createErr := c.Create(ctx, req)
+	if createErr == nil {
+		return key, true, nil
+	}
+	if !errors.Is(createErr, ErrAlreadyExists) {
+		return nil, false, createErr
+	}
+	key, err = c.Get(ctx, req.Name)
+	if err == nil {
+		return key, false, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, false, err
+	}
+	// The key material is in use under another name, or by another repository
+	keys, err := c.List(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, key := range keys {
+		if EqualPublicKeys(key.Get().Key, req.Key) {
+			return key, false, nil
+		}
+	}
+	return nil, false, fmt.Errorf("deploy key %q is in use by another repository: %w", req.Name, createErr)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// The fakes embed the interfaces, only the methods used by the CreateOrGet functions are implemented.
+type createOrGetFakeRepos struct {
+	OrgRepositoriesClient
+	existing map[string]bool
+	getErr   error
+}
+
+type createOrGetFakeRepo struct {
+	OrgRepository
+	name string
+}
+
+func (c *createOrGetFakeRepos) Create(_ context.Context, ref OrgRepositoryRef, _ RepositoryInfo, _ ...RepositoryCreateOption) (OrgRepository, error) {
+	if c.existing[ref.RepositoryName] {
+		return nil, fmt.Errorf("repository %q: %w", ref.RepositoryName, ErrAlreadyExists)
+	}
+	c.existing[ref.RepositoryName] = true
+	return &createOrGetFakeRepo{name: ref.RepositoryName}, nil
+}
+
+func (c *createOrGetFakeRepos) Get(_ context.Context, ref OrgRepositoryRef, _ ...RepositoryGetOption) (OrgRepository, error) {
+	if c.getErr != nil {
+		return nil, c.getErr
+	}
+	return &createOrGetFakeRepo{name: ref.RepositoryName}, nil
+}
+
+// createOrGetFakeKeys refuses the keys in use like GitHub, by name, or by key material in the
+// repository or in others.
+type createOrGetFakeKeys struct {
+	DeployKeyClient
+	// existing maps the names of the keys of the repository to their key material
+	existing map[string]string
+	// elsewhere is the key material of the keys of other repositories
+	elsewhere map[string]bool
+	createErr error
+}
+
+type createOrGetFakeKey struct {
+	DeployKey
+	name string
+	key  string
+}
+
+func (k *createOrGetFakeKey) Get() DeployKeyInfo {
+	return DeployKeyInfo{Name: k.name, Key: []byte(k.key)}
+}
+
+func (c *createOrGetFakeKeys) Create(_ context.Context, req DeployKeyInfo) (DeployKey, error) {
+	if c.createErr != nil {
+		return nil, c.createErr
+	}
+	if _, ok := c.existing[req.Name]; ok {
+		return nil, fmt.Errorf("deploy key %q: %w", req.Name, ErrAlreadyExists)
+	}
+	for _, key := range c.existing {
+		if EqualPublicKeys([]byte(key), req.Key) {
+			return nil, fmt.Errorf("key is already in use: %w", ErrAlreadyExists)
+		}
+	}
+	if c.elsewhere[string(req.Key)] {
+		return nil, fmt.Errorf("key is already in use: %w", ErrAlreadyExists)
+	}
+	c.existing[req.Name] = string(req.Key)
+	return &createOrGetFakeKey{name: req.Name, key: string(req.Key)}, nil
+}
+
+func (c *createOrGetFakeKeys) Get(_ context.Context, name string) (DeployKey, error) {
+	key, ok := c.existing[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &createOrGetFakeKey{name: name, key: key}, nil
+}
+
+func (c *createOrGetFakeKeys) List(_ context.Context) ([]DeployKey, error) {
+	keys := make([]DeployKey, 0, len(c.existing))
+	for name, key := range c.existing {
+		keys = append(keys, &createOrGetFakeKey{name: name, key: key})
+	}
+	return keys, nil
+}
+
+func TestCreateOrGetOrgRepository(t *testing.T) {
+	ctx := context.Background()
+	c := &createOrGetFakeRepos{existing: map[string]bool{"existing": true}}
+	org := OrganizationRef{Domain: "example.com", Organization: "org"}
+
+	repo, created, err := CreateOrGetOrgRepository(ctx, c, OrgRepositoryRef{OrganizationRef: org, RepositoryName: "new"}, RepositoryInfo{})
+	if err != nil || !created || repo.(*createOrGetFakeRepo).name != "new" {
+		t.Errorf("CreateOrGetOrgRepository() of a new repository = %v, %v, %v, want it created", repo, created, err)
+	}
+	repo, created, err = CreateOrGetOrgRepository(ctx, c, OrgRepositoryRef{OrganizationRef: org, RepositoryName: "existing"}, RepositoryInfo{})
+	if err != nil || created || repo.(*createOrGetFakeRepo).name != "existing" {
+		t.Errorf("CreateOrGetOrgRepository() of an existing repository = %v, %v, %v, want it fetched", repo, created, err)
+	}
+
+	c.getErr = ErrNotFound
+	if _, _, err := CreateOrGetOrgRepository(ctx, c, OrgRepositoryRef{OrganizationRef: org, RepositoryName: "existing"}, RepositoryInfo{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("CreateOrGetOrgRepository() with a failing Get error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCreateOrGetDeployKey(t *testing.T) {
+	ctx := context.Background()
+	c := &createOrGetFakeKeys{
+		existing:  map[string]string{"existing": "ssh-ed25519 AAAAexisting"},
+		elsewhere: map[string]bool{"ssh-ed25519 AAAAelsewhere": true},
+	}
+
+	key, created, err := CreateOrGetDeployKey(ctx, c, DeployKeyInfo{Name: "new", Key: []byte("ssh-ed25519 AAAAnew")})
+	if err != nil || !created || key.(*createOrGetFakeKey).name != "new" {
+		t.Errorf("CreateOrGetDeployKey() of a new key = %v, %v, %v, want it created", key, created, err)
+	}
+	key, created, err = CreateOrGetDeployKey(ctx, c, DeployKeyInfo{Name: "existing", Key: []byte("ssh-ed25519 AAAAexisting")})
+	if err != nil || created || key.(*createOrGetFakeKey).name != "existing" {
+		t.Errorf("CreateOrGetDeployKey() of an existing key = %v, %v, %v, want it fetched", key, created, err)
+	}
+	// The key material is in use under another name, with another comment
+	key, created, err = CreateOrGetDeployKey(ctx, c, DeployKeyInfo{Name: "renamed", Key: []byte("ssh-ed25519 AAAAexisting ci@example.com")})
+	if err != nil || created || key.(*createOrGetFakeKey).name != "existing" {
+		t.Errorf("CreateOrGetDeployKey() of an existing key under another name = %v, %v, %v, want the existing key", key, created, err)
+	}
+	// The key material is in use by another repository
+	if key, created, err := CreateOrGetDeployKey(ctx, c, DeployKeyInfo{Name: "other-repo", Key: []byte("ssh-ed25519 AAAAelsewhere")}); key != nil || created || !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("CreateOrGetDeployKey() of a key of another repository = %v, %v, %v, want ErrAlreadyExists", key, created, err)
+	}
+
+	c.createErr = ErrInvalidArgument
+	if _, created, err := CreateOrGetDeployKey(ctx, c, DeployKeyInfo{Name: "other"}); created || !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("CreateOrGetDeployKey() with a failing Create = %v, %v, want ErrInvalidArgument", created, err)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// maxSchemaDrifts bounds the number of mismatched fields UnmarshalTolerant removes from a document.
+const maxSchemaDrifts = 100
+
+// SchemaDrift describes a field of a server response whose JSON type doesn't match the type the
+// client expects, e.g. because the self-hosted Git provider runs another version than the client
+// was written for.
+type SchemaDrift struct {
+	// Field is the path of the field from the root of the response, e.g. "values.3.id". Array
+	// indices are only part of the path with Go 1.20 and later, e.g. "values.id" before.
+	Field string
+	// Expected is the Go type of the field, e.g. "int".
+	Expected string
+	// Got is the JSON type of the value, e.g. "string".
+	Got string
+}
+
+// SchemaDriftFunc is called for every mismatched field found while decoding a server response.
+type SchemaDriftFunc func(drift SchemaDrift)
+
+// UnmarshalTolerant decodes the JSON document data into v, like json.Unmarshal, except that the
+// fields whose JSON type doesn't match their Go type are left unset, and reported to hook (if not
+// nil), instead of failing the decoding. Syntax errors are still returned.
+func UnmarshalTolerant(data []byte, v interface{}, hook SchemaDriftFunc) error {
+	err := json.Unmarshal(data, v)
+	var doc interface{}
+	for i := 0; i < maxSchemaDrifts; i++ {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return err
+		}
+		if hook != nil {
+			hook(SchemaDrift{Field: typeErr.Field, Expected: typeErr.Type.String(), Got: typeErr.Value})
+		}
+		// json.Unmarshal decodes the other fields regardless, but only reports the first mismatch,
+		// so remove it from the document to find the next one
+		if doc == nil {
+			if err := json.Unmarshal(data, &doc); err != nil {
+				return err
+			}
+		}
+		if typeErr.Field == "" || !removeJSONField(doc, strings.Split(typeErr.Field, "."), typeErr.Value) {
+			return nil
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return err
+		}
+		err = json.Unmarshal(data, v)
+	}
+	return nil
+}
+
+// removeJSONField removes the values of the JSON type kind at path from doc, which is decoded to
+// interface{}. All the elements of the arrays on the path are traversed, unless the path contains
+// their index. It returns true if a value was removed.
+func removeJSONField(doc interface{}, path []string, kind string) bool {
+	switch node := doc.(type) {
+	case []interface{}:
+		if i, err := strconv.Atoi(path[0]); err == nil {
+			return i >= 0 && i < len(node) && len(path) > 1 && removeJSONField(node[i], path[1:], kind)
+		}
+		removed := false
+		for _, elem := range node {
+			removed = removeJSONField(elem, path, kind) || removed
+		}
+		return removed
+	case map[string]interface{}:
+		value, ok := lookupJSONKey(node, path[0])
+		if !ok {
+			return false
+		}
+		if len(path) > 1 {
+			return removeJSONField(node[value], path[1:], kind)
+		}
+		// The kind of numbers which don't fit the Go type contains the number, e.g. "number 1.5"
+		if !strings.HasPrefix(kind, jsonKind(node[value])) {
+			return false
+		}
+		delete(node, value)
+		return true
+	}
+	return false
+}
+
+// lookupJSONKey returns the key of obj matching name, which is compared case-insensitively like
+// json.Unmarshal does.
+func lookupJSONKey(obj map[string]interface{}, name string) (string, bool) {
+	if _, ok := obj[name]; ok {
+		return name, true
+	}
+	for key := range obj {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// jsonKind returns the JSON type of v, named like in json.UnmarshalTypeError.Value.
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "null"
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestUnmarshalTolerant(t *testing.T) {
+	type project struct {
+		Key  string `json:"key"`
+		Name string `json:"name"`
+	}
+	type repository struct {
+		ID      int     `json:"id"`
+		Name    string  `json:"name"`
+		Public  bool    `json:"public"`
+		Project project `json:"project"`
+	}
+	type list struct {
+		Size   int          `json:"size"`
+		Values []repository `json:"values"`
+	}
+
+	data := []byte(`{"size": 2, "values": [
+		{"id": "1", "name": "a", "public": true, "project": {"key": "P", "name": "p"}},
+		{"id": 2, "name": "b", "public": "yes", "project": {"key": 3, "name": "p"}}
+	]}`)
+	var drifts []SchemaDrift
+	var got list
+	// The array indices are only part of the paths with Go 1.20 and later
+	index := regexp.MustCompile(`\.[0-9]+`)
+	hook := func(d SchemaDrift) {
+		d.Field = index.ReplaceAllString(d.Field, "")
+		drifts = append(drifts, d)
+	}
+	if err := UnmarshalTolerant(data, &got, hook); err != nil {
+		t.Fatal(err)
+	}
+
+	want := list{Size: 2, Values: []repository{
+		{Name: "a", Public: true, Project: project{Key: "P", Name: "p"}},
+		{ID: 2, Name: "b", Project: project{Name: "p"}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalTolerant() = %+v, want %+v", got, want)
+	}
+	wantDrifts := []SchemaDrift{
+		{Field: "values.id", Expected: "int", Got: "string"},
+		{Field: "values.project.key", Expected: "string", Got: "number"},
+		{Field: "values.public", Expected: "bool", Got: "string"},
+	}
+	// The document is re-encoded after each drift, which sorts the keys
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Field < drifts[j].Field })
+	if !reflect.DeepEqual(drifts, wantDrifts) {
+		t.Errorf("drifts = %+v, want %+v", drifts, wantDrifts)
+	}
+
+	if err := UnmarshalTolerant([]byte(`{"size": `), &got, nil); err == nil {
+		t.Error("expected syntax errors to be returned")
+	}
+}
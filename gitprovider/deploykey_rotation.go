@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// RotateDeployKeys rotates the deploy key with the given name to newKey in all the given
+// repositories, see DeployKeyClient.Rotate. Up to concurrency repositories are rotated in
+// parallel; a concurrency below 1 rotates them one by one. The references of the repositories
+// whose key was rotated are returned, in the order of refs.
+//
+// The rotation continues past the failure of a repository, an *AggregateError is returned for
+// the ones which failed, e.g. with ErrNotFound if the repository doesn't have the key. With
+// WithDryRun(), no keys are rotated, and the changes are returned in a single *DryRunError instead.
+func RotateDeployKeys(ctx context.Context, c Client, refs []RepositoryRef, name string, newKey []byte, concurrency int) ([]RepositoryRef, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	rotated := make([]bool, len(refs))
+	dryRunErrs := make([]*DryRunError, len(refs))
+	errs := &AggregateError{}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, ref := range refs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, ref RepositoryRef) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			actionTaken, err := rotateDeployKey(ctx, c, ref, name, newKey)
+			var dryRunErr *DryRunError
+			switch {
+			case errors.As(err, &dryRunErr):
+				dryRunErrs[i] = dryRunErr
+			case err != nil:
+				errs.Add(ref.String(), err)
+			default:
+				rotated[i] = actionTaken
+			}
+		}(i, ref)
+	}
+	wg.Wait()
+
+	var updated []RepositoryRef
+	var dryRun *DryRunError
+	for i, ref := range refs {
+		if rotated[i] {
+			updated = append(updated, ref)
+		}
+		if dryRunErrs[i] != nil {
+			if dryRun == nil {
+				dryRun = NewDryRunError()
+			}
+			dryRun.Changes = append(dryRun.Changes, dryRunErrs[i].Changes...)
+		}
+	}
+	if dryRun != nil {
+		if len(errs.Errors) == 0 {
+			return updated, dryRun
+		}
+		errs.Add(name, dryRun)
+	}
+	return updated, errs.ErrorOrNil()
+}
+
+// rotateDeployKey rotates the deploy key with the given name in the repository of ref.
+func rotateDeployKey(ctx context.Context, c Client, ref RepositoryRef, name string, newKey []byte) (bool, error) {
+	var repo UserRepository
+	var err error
+	switch r := ref.(type) {
+	case OrgRepositoryRef:
+		repo, err = c.OrgRepositories().Get(ctx, r)
+	case UserRepositoryRef:
+		repo, err = c.UserRepositories().Get(ctx, r)
+	default:
+		return false, fmt.Errorf("unsupported repository reference %T: %w", ref, ErrInvalidArgument)
+	}
+	if err != nil {
+		return false, err
+	}
+	_, actionTaken, err := repo.DeployKeys().Rotate(ctx, name, newKey)
+	return actionTaken, err
+}
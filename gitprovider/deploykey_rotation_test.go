@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The fakes embed the interfaces, only the methods used by RotateDeployKeys are implemented.
+type rotationFakeClient struct {
+	Client
+	dryRun bool
+
+	mu       sync.Mutex
+	keys     map[string][]byte
+	inFlight int
+	maxPar   int
+}
+
+func (c *rotationFakeClient) OrgRepositories() OrgRepositoriesClient {
+	return &rotationFakeReposClient{c: c}
+}
+
+type rotationFakeReposClient struct {
+	OrgRepositoriesClient
+	c *rotationFakeClient
+}
+
+func (r *rotationFakeReposClient) Get(_ context.Context, ref OrgRepositoryRef, _ ...RepositoryGetOption) (OrgRepository, error) {
+	return &rotationFakeRepo{c: r.c, name: ref.RepositoryName}, nil
+}
+
+type rotationFakeRepo struct {
+	OrgRepository
+	c    *rotationFakeClient
+	name string
+}
+
+func (r *rotationFakeRepo) DeployKeys() DeployKeyClient {
+	return &rotationFakeKeys{repo: r}
+}
+
+type rotationFakeKeys struct {
+	DeployKeyClient
+	repo *rotationFakeRepo
+}
+
+func (k *rotationFakeKeys) Rotate(_ context.Context, name string, newKey []byte) (DeployKey, bool, error) {
+	c := k.repo.c
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxPar {
+		c.maxPar = c.inFlight
+	}
+	key, ok := c.keys[k.repo.name]
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+	// Give the other rotations a chance to run concurrently
+	time.Sleep(10 * time.Millisecond)
+
+	switch {
+	case !ok:
+		return nil, false, fmt.Errorf("deploy key %q: %w", name, ErrNotFound)
+	case EqualPublicKeys(key, newKey):
+		return nil, false, nil
+	case c.dryRun:
+		return nil, true, NewDryRunError(Change{Type: ChangeTypeUpdate, Resource: "DeployKey"})
+	}
+	c.mu.Lock()
+	c.keys[k.repo.name] = newKey
+	c.mu.Unlock()
+	return nil, true, nil
+}
+
+func TestRotateDeployKeys(t *testing.T) {
+	newRef := func(name string) RepositoryRef {
+		return OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "fluxcd"}, RepositoryName: name}
+	}
+	refs := []RepositoryRef{newRef("a"), newRef("b"), newRef("c"), newRef("missing"), newRef("d")}
+	newKeys := func() map[string][]byte {
+		return map[string][]byte{"a": []byte("old"), "b": []byte("new"), "c": []byte("old"), "d": []byte("old")}
+	}
+
+	c := &rotationFakeClient{keys: newKeys()}
+	rotated, err := RotateDeployKeys(context.Background(), c, refs, "flux", []byte("new"), 2)
+	if want := []RepositoryRef{newRef("a"), newRef("c"), newRef("d")}; !reflect.DeepEqual(rotated, want) {
+		t.Errorf("RotateDeployKeys() = %v, want %v", rotated, want)
+	}
+	aggErr := &AggregateError{}
+	if !errors.As(err, &aggErr) || len(aggErr.Errors) != 1 || aggErr.Errors[0].Target != newRef("missing").String() || !errors.Is(err, ErrNotFound) {
+		t.Errorf("RotateDeployKeys() error = %v, want ErrNotFound for the missing key", err)
+	}
+	if c.maxPar != 2 {
+		t.Errorf("RotateDeployKeys() rotated up to %d keys in parallel, want 2", c.maxPar)
+	}
+
+	// Dry-run changes are merged into one error
+	c = &rotationFakeClient{keys: newKeys(), dryRun: true}
+	rotated, err = RotateDeployKeys(context.Background(), c, refs[:3], "flux", []byte("new"), 0)
+	dryRunErr := &DryRunError{}
+	if len(rotated) != 0 || !errors.As(err, &dryRunErr) || len(dryRunErr.Changes) != 2 {
+		t.Errorf("RotateDeployKeys() = %v, %v, want a dry-run error with 2 changes", rotated, err)
+	}
+	if c.maxPar != 1 {
+		t.Errorf("RotateDeployKeys() rotated up to %d keys in parallel, want 1", c.maxPar)
+	}
+}
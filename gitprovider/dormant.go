@@ -0,0 +1,159 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DormancyOptions specifies how ReportDormantRepositories detects activity.
+type DormancyOptions struct {
+	// InactiveFor is the period without commits after which a repository is reported as dormant.
+	// +required
+	InactiveFor time.Duration
+
+	// AllBranches checks the latest commit of every branch, instead of only the default branch.
+	// This includes the activity on the source branches of pull requests, at the cost of one
+	// request per branch.
+	// Default: false
+	AllBranches bool
+
+	// Clock is the source of the current time. Default: RealClock
+	Clock Clock
+}
+
+// DormantRepository is a repository without activity in the checked period.
+type DormantRepository struct {
+	// Repository is the URL of the repository.
+	Repository string `json:"repository"`
+
+	// LastActivity is the time of the latest commit, or nil if the repository is empty.
+	LastActivity *time.Time `json:"lastActivity,omitempty"`
+
+	// LastActiveBranch is the branch with the latest commit, if any.
+	LastActiveBranch string `json:"lastActiveBranch,omitempty"`
+}
+
+// DormancyReport lists the dormant repositories of an organization. It can be encoded as JSON,
+// e.g. for automation archiving the dormant repositories.
+type DormancyReport struct {
+	// Organization is the URL of the organization.
+	Organization string `json:"organization"`
+
+	// GeneratedAt is the time the report was created at.
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	// InactiveSince is the start of the checked period, repositories without commits after it
+	// are dormant.
+	InactiveSince time.Time `json:"inactiveSince"`
+
+	// Scanned is the number of repositories checked successfully.
+	Scanned int `json:"scanned"`
+
+	// Dormant lists the dormant repositories, the longest inactive first.
+	Dormant []DormantRepository `json:"dormant"`
+}
+
+// ReportDormantRepositories checks the repositories of the organization for commits within
+// opts.InactiveFor, and reports the ones without any. Empty repositories are reported as dormant.
+//
+// The repositories are checked one by one. The ones which couldn't be checked are left out of the
+// report, and returned in an *AggregateError together with the report of the others.
+func ReportDormantRepositories(ctx context.Context, c Client, org OrganizationRef, opts DormancyOptions) (*DormancyReport, error) {
+	if opts.InactiveFor <= 0 {
+		return nil, fmt.Errorf("inactive period must be positive, got %v: %w", opts.InactiveFor, ErrInvalidArgument)
+	}
+	repos, err := c.OrgRepositories().List(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	now := ClockOrDefault(opts.Clock).Now()
+	report := &DormancyReport{
+		Organization:  org.String(),
+		GeneratedAt:   now,
+		InactiveSince: now.Add(-opts.InactiveFor),
+		Dormant:       []DormantRepository{},
+	}
+	errs := &AggregateError{}
+	for _, repo := range repos {
+		last, branch, err := lastActivity(ctx, repo, opts.AllBranches)
+		if err != nil {
+			errs.Add(repo.Repository().String(), err)
+			continue
+		}
+		report.Scanned++
+		if last != nil && last.After(report.InactiveSince) {
+			continue
+		}
+		report.Dormant = append(report.Dormant, DormantRepository{
+			Repository:       repo.Repository().String(),
+			LastActivity:     last,
+			LastActiveBranch: branch,
+		})
+	}
+
+	// Empty repositories first, then the oldest activity
+	sort.SliceStable(report.Dormant, func(i, j int) bool {
+		a, b := report.Dormant[i].LastActivity, report.Dormant[j].LastActivity
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+	return report, errs.ErrorOrNil()
+}
+
+// lastActivity returns the time of the latest commit on the checked branches of the repository,
+// and the branch it's on. A nil time is returned for empty repositories.
+func lastActivity(ctx context.Context, repo UserRepository, allBranches bool) (*time.Time, string, error) {
+	var branches []string
+	if allBranches {
+		list, err := repo.Branches().List(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, b := range list {
+			branches = append(branches, b.Get().Name)
+		}
+	} else if b := repo.Get().DefaultBranch; b != nil {
+		branches = append(branches, *b)
+	}
+
+	var last *time.Time
+	var lastBranch string
+	for _, branch := range branches {
+		commits, err := repo.Commits().ListPage(ctx, branch, 1, 1)
+		if errors.Is(err, ErrEmptyRepository) {
+			return nil, "", nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if len(commits) == 0 {
+			continue
+		}
+		if t := commits[0].Get().CreatedAt; last == nil || t.After(*last) {
+			last, lastBranch = &t, branch
+		}
+	}
+	return last, lastBranch, nil
+}
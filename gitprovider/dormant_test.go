@@ -0,0 +1,184 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// The fakes embed the interfaces, only the methods used by ReportDormantRepositories are implemented.
+type dormantFakeClient struct {
+	Client
+	repos []OrgRepository
+}
+
+func (c *dormantFakeClient) OrgRepositories() OrgRepositoriesClient {
+	return &dormantFakeReposClient{repos: c.repos}
+}
+
+type dormantFakeReposClient struct {
+	OrgRepositoriesClient
+	repos []OrgRepository
+}
+
+func (c *dormantFakeReposClient) List(context.Context, OrganizationRef, ...RepositoryListOption) ([]OrgRepository, error) {
+	return c.repos, nil
+}
+
+type dormantFakeRepo struct {
+	OrgRepository
+	name string
+	// commits maps branch names to the time of their latest commit
+	commits map[string]time.Time
+	err     error
+}
+
+func (r *dormantFakeRepo) Repository() RepositoryRef {
+	return OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "fluxcd"}, RepositoryName: r.name}
+}
+
+func (r *dormantFakeRepo) Get() RepositoryInfo {
+	return RepositoryInfo{DefaultBranch: StringVar("main")}
+}
+
+func (r *dormantFakeRepo) Branches() BranchClient { return &dormantFakeBranches{repo: r} }
+
+func (r *dormantFakeRepo) Commits() CommitClient { return &dormantFakeCommits{repo: r} }
+
+type dormantFakeBranches struct {
+	BranchClient
+	repo *dormantFakeRepo
+}
+
+func (b *dormantFakeBranches) List(context.Context) ([]Branch, error) {
+	var branches []Branch
+	for name := range b.repo.commits {
+		branches = append(branches, dormantFakeBranch{name})
+	}
+	return branches, nil
+}
+
+type dormantFakeBranch struct {
+	name string
+}
+
+func (b dormantFakeBranch) APIObject() interface{} { return nil }
+func (b dormantFakeBranch) Get() BranchInfo        { return BranchInfo{Name: b.name} }
+
+type dormantFakeCommits struct {
+	CommitClient
+	repo *dormantFakeRepo
+}
+
+func (c *dormantFakeCommits) ListPage(_ context.Context, branch string, _, _ int, _ ...CommitListOption) ([]Commit, error) {
+	if c.repo.err != nil {
+		return nil, c.repo.err
+	}
+	if c.repo.commits == nil {
+		return nil, ErrEmptyRepository
+	}
+	t, ok := c.repo.commits[branch]
+	if !ok {
+		return nil, nil
+	}
+	return []Commit{dormantFakeCommit{t}}, nil
+}
+
+type dormantFakeCommit struct {
+	createdAt time.Time
+}
+
+func (c dormantFakeCommit) APIObject() interface{} { return nil }
+func (c dormantFakeCommit) Get() CommitInfo        { return CommitInfo{CreatedAt: c.createdAt} }
+
+func TestReportDormantRepositories(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+	c := &dormantFakeClient{repos: []OrgRepository{
+		&dormantFakeRepo{name: "active", commits: map[string]time.Time{"main": daysAgo(1)}},
+		&dormantFakeRepo{name: "stale", commits: map[string]time.Time{"main": daysAgo(100)}},
+		&dormantFakeRepo{name: "stale-main", commits: map[string]time.Time{"main": daysAgo(200), "feature": daysAgo(2)}},
+		&dormantFakeRepo{name: "empty"},
+		&dormantFakeRepo{name: "broken", commits: map[string]time.Time{"main": daysAgo(1)}, err: errors.New("server error")},
+	}}
+	org := OrganizationRef{Domain: "github.com", Organization: "fluxcd"}
+	clock := &fixedClock{now: now}
+
+	tests := []struct {
+		name        string
+		allBranches bool
+		want        []DormantRepository
+	}{
+		{
+			name: "default branch",
+			want: []DormantRepository{
+				{Repository: "https://github.com/fluxcd/empty"},
+				{Repository: "https://github.com/fluxcd/stale-main", LastActivity: timeVar(daysAgo(200)), LastActiveBranch: "main"},
+				{Repository: "https://github.com/fluxcd/stale", LastActivity: timeVar(daysAgo(100)), LastActiveBranch: "main"},
+			},
+		},
+		{
+			name:        "all branches",
+			allBranches: true,
+			want: []DormantRepository{
+				{Repository: "https://github.com/fluxcd/empty"},
+				{Repository: "https://github.com/fluxcd/stale", LastActivity: timeVar(daysAgo(100)), LastActiveBranch: "main"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ReportDormantRepositories(context.Background(), c, org, DormancyOptions{
+				InactiveFor: 90 * 24 * time.Hour,
+				AllBranches: tt.allBranches,
+				Clock:       clock,
+			})
+			var aggErr *AggregateError
+			if !errors.As(err, &aggErr) || len(aggErr.Errors) != 1 || aggErr.Errors[0].Target != "https://github.com/fluxcd/broken" {
+				t.Errorf("ReportDormantRepositories() error = %v, want the broken repository", err)
+			}
+			if report.Scanned != 4 || !report.InactiveSince.Equal(daysAgo(90)) {
+				t.Errorf("ReportDormantRepositories() scanned %d since %v, want 4 since %v", report.Scanned, report.InactiveSince, daysAgo(90))
+			}
+			if !reflect.DeepEqual(report.Dormant, tt.want) {
+				t.Errorf("ReportDormantRepositories() dormant = %+v, want %+v", report.Dormant, tt.want)
+			}
+		})
+	}
+
+	if _, err := ReportDormantRepositories(context.Background(), c, org, DormancyOptions{}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("ReportDormantRepositories() error = %v, want %v", err, ErrInvalidArgument)
+	}
+}
+
+func timeVar(t time.Time) *time.Time {
+	return &t
+}
+
+// fixedClock is a Clock which always returns the same time.
+type fixedClock struct {
+	RealClock
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
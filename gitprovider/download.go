@@ -0,0 +1,205 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxDownloadResumes is the number of times the providers resume an interrupted archive download.
+const DefaultMaxDownloadResumes = 3
+
+// RangeOpener sends a download request with the given additional headers, and returns the response
+// with its body unread. The headers contain a "Range: bytes=<offset>-" header (and an "If-Range"
+// header) when a download is resumed. Openers for endpoints that can't send these headers can
+// ignore them, in which case the resumed download is restarted and the already read bytes skipped.
+//
+// A non-2xx response should be returned as an error, e.g. ErrNotFound.
+type RangeOpener func(ctx context.Context, header http.Header) (*http.Response, error)
+
+// NewResumableReader opens a download using open, and returns a reader for its content that
+// transparently resumes the download with a range request if reading the response body fails,
+// at most maxResumes times.
+//
+// When the content is read to the end, it is verified against the Content-Length and the SHA-256
+// "Digest" headers of the response, if the server provided them. ErrIntegrityCheckFailed is
+// returned instead of io.EOF if they don't match, or if the content (i.e. its ETag) changed
+// while resuming.
+//
+// The caller is responsible for closing the returned reader.
+func NewResumableReader(ctx context.Context, open RangeOpener, maxResumes int) (io.ReadCloser, error) {
+	resp, err := open(ctx, http.Header{})
+	if err != nil {
+		return nil, err
+	}
+	r := &resumableReader{
+		ctx:         ctx,
+		open:        open,
+		resumesLeft: maxResumes,
+		body:        resp.Body,
+		size:        resp.ContentLength,
+		hash:        sha256.New(),
+	}
+	if etag := resp.Header.Get("ETag"); !strings.HasPrefix(etag, "W/") {
+		// Only strong validators can be used with If-Range
+		r.etag = etag
+	}
+	r.digest = sha256Digest(resp.Header.Values("Digest"))
+	return r, nil
+}
+
+// resumableReader is the io.ReadCloser returned by NewResumableReader.
+type resumableReader struct {
+	ctx         context.Context
+	open        RangeOpener
+	resumesLeft int
+	body        io.ReadCloser
+
+	// offset is the number of bytes returned to the caller so far.
+	offset int64
+	// size is the expected size of the content, or -1 if unknown.
+	size int64
+	// etag is the strong ETag of the content, if any.
+	etag string
+	// digest is the expected SHA-256 sum of the content, if any.
+	digest []byte
+	hash   hash.Hash
+}
+
+// Read implements io.Reader.
+func (r *resumableReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		r.hash.Write(p[:n])
+		if err == io.EOF && r.size >= 0 && r.offset < r.size {
+			// The connection was closed before all content was received
+			err = io.ErrUnexpectedEOF
+		}
+
+		switch {
+		case err == nil:
+			return n, nil
+		case errors.Is(err, io.EOF):
+			return n, r.verify()
+		case r.resumesLeft > 0 && r.ctx.Err() == nil:
+			if resumeErr := r.resume(); resumeErr != nil {
+				return n, fmt.Errorf("failed to resume download after %v: %w", err, resumeErr)
+			}
+			if n > 0 {
+				return n, nil
+			}
+		default:
+			return n, err
+		}
+	}
+}
+
+// Close implements io.Closer.
+func (r *resumableReader) Close() error {
+	return r.body.Close()
+}
+
+// verify returns io.EOF if the content read matches the expected size and digest, and an
+// error wrapping ErrIntegrityCheckFailed otherwise.
+func (r *resumableReader) verify() error {
+	if r.size >= 0 && r.offset != r.size {
+		return fmt.Errorf("received %d bytes, expected %d: %w", r.offset, r.size, ErrIntegrityCheckFailed)
+	}
+	if r.digest != nil && !bytes.Equal(r.hash.Sum(nil), r.digest) {
+		return fmt.Errorf("SHA-256 digest mismatch: %w", ErrIntegrityCheckFailed)
+	}
+	return io.EOF
+}
+
+// resume replaces the body with a new response, continuing at the current offset.
+func (r *resumableReader) resume() error {
+	r.body.Close()
+	r.resumesLeft--
+
+	header := http.Header{}
+	header.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+	if r.etag != "" {
+		header.Set("If-Range", r.etag)
+	}
+	resp, err := r.open(r.ctx, header)
+	if err != nil {
+		return err
+	}
+	r.body = resp.Body
+	if r.etag != "" && resp.Header.Get("ETag") != r.etag {
+		return fmt.Errorf("content changed while resuming: %w", ErrIntegrityCheckFailed)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start := contentRangeStart(resp.Header.Get("Content-Range")); start != r.offset {
+			return fmt.Errorf("server resumed at byte %d, expected %d: %w", start, r.offset, ErrIntegrityCheckFailed)
+		}
+		return nil
+	case http.StatusOK:
+		// The range wasn't applied, skip the content that was already read
+		if _, err := io.CopyN(io.Discard, resp.Body, r.offset); err != nil {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("unexpected status %q: %w", resp.Status, ErrUnexpectedEvent)
+	}
+}
+
+// contentRangeStart returns the first byte position of a "Content-Range: bytes <start>-<end>/<size>"
+// header, or -1 if it can't be parsed.
+func contentRangeStart(contentRange string) int64 {
+	rng := strings.TrimPrefix(contentRange, "bytes ")
+	i := strings.Index(rng, "-")
+	if rng == contentRange || i < 0 {
+		return -1
+	}
+	start, err := strconv.ParseInt(rng[:i], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return start
+}
+
+// sha256Digest returns the SHA-256 sum in the given "Digest: sha-256=<base64>" header values
+// (RFC 3230), or nil if there is none.
+func sha256Digest(values []string) []byte {
+	for _, value := range values {
+		for _, d := range strings.Split(value, ",") {
+			parts := strings.SplitN(strings.TrimSpace(d), "=", 2)
+			if len(parts) != 2 || !strings.EqualFold(parts[0], "sha-256") {
+				continue
+			}
+			if sum, err := base64.StdEncoding.DecodeString(parts[1]); err == nil && len(sum) == sha256.Size {
+				return sum
+			}
+		}
+	}
+	return nil
+}
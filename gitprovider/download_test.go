@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingReader returns an error after n bytes were read from r.
+type failingReader struct {
+	r io.ReadCloser
+	n int
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.n <= 0 {
+		return 0, errors.New("connection reset by peer")
+	}
+	if len(p) > f.n {
+		p = p[:f.n]
+	}
+	n, err := f.r.Read(p)
+	f.n -= n
+	return n, err
+}
+
+func (f *failingReader) Close() error {
+	return f.r.Close()
+}
+
+func TestNewResumableReader(t *testing.T) {
+	content := strings.Repeat("0123456789", 2000)
+	sum := sha256.Sum256([]byte(content))
+	digest := "sha-256=" + base64.StdEncoding.EncodeToString(sum[:])
+
+	tests := []struct {
+		name        string
+		digest      string
+		ignoreRange bool
+		changeETag  bool
+		// failures is the number of times reading the body fails
+		failures  int
+		wantErr   error
+		wantRange []string
+	}{
+		{
+			name:      "no failures",
+			digest:    digest,
+			wantRange: []string{""},
+		},
+		{
+			name:      "resumed with range requests",
+			digest:    digest,
+			failures:  2,
+			wantRange: []string{"", "bytes=3000-", "bytes=6000-"},
+		},
+		{
+			name:        "resumed, server ignores range",
+			digest:      digest,
+			ignoreRange: true,
+			failures:    1,
+			wantRange:   []string{"", "bytes=3000-"},
+		},
+		{
+			name:      "too many failures",
+			failures:  DefaultMaxDownloadResumes + 1,
+			wantErr:   errors.New("connection reset by peer"),
+			wantRange: []string{"", "bytes=3000-", "bytes=6000-", "bytes=9000-"},
+		},
+		{
+			name:      "digest mismatch",
+			digest:    "sha-256=" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size)),
+			wantErr:   ErrIntegrityCheckFailed,
+			wantRange: []string{""},
+		},
+		{
+			name:       "content changed while resuming",
+			changeETag: true,
+			failures:   1,
+			wantErr:    ErrIntegrityCheckFailed,
+			wantRange:  []string{"", "bytes=3000-"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranges []string
+			etag := `"v1"`
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ranges = append(ranges, r.Header.Get("Range"))
+				if tt.ignoreRange {
+					r.Header.Del("Range")
+				}
+				if tt.digest != "" {
+					w.Header().Set("Digest", tt.digest)
+				}
+				w.Header().Set("ETag", etag)
+				if tt.changeETag {
+					etag = `"v2"`
+				}
+				http.ServeContent(w, r, "archive.tar.gz", time.Time{}, strings.NewReader(content))
+			}))
+			defer server.Close()
+
+			failures := tt.failures
+			open := func(ctx context.Context, header http.Header) (*http.Response, error) {
+				req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+				if err != nil {
+					return nil, err
+				}
+				req.Header = header
+				resp, err := server.Client().Do(req)
+				if err != nil {
+					return nil, err
+				}
+				if failures > 0 {
+					failures--
+					resp.Body = &failingReader{r: resp.Body, n: 3000}
+				}
+				return resp, nil
+			}
+
+			r, err := NewResumableReader(context.Background(), open, DefaultMaxDownloadResumes)
+			if err != nil {
+				t.Fatalf("NewResumableReader() error = %v", err)
+			}
+			defer r.Close()
+			got, err := io.ReadAll(r)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("ReadAll() error = %v", err)
+			case tt.wantErr == nil && !bytes.Equal(got, []byte(content)):
+				t.Errorf("ReadAll() returned %d bytes, not matching the content", len(got))
+			case tt.wantErr != nil && (err == nil || !errors.Is(err, tt.wantErr) && !strings.Contains(err.Error(), tt.wantErr.Error())):
+				t.Errorf("ReadAll() error = %v, want %v", err, tt.wantErr)
+			}
+			if strings.Join(ranges, ",") != strings.Join(tt.wantRange, ",") {
+				t.Errorf("requested ranges = %q, want %q", ranges, tt.wantRange)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDryRun is matched (using errors.Is) by the *DryRunError returned from Reconcile() calls
+// of a client created with WithDryRun(), when the actual state differs from the desired state.
+var ErrDryRun = errors.New("dry-run: changes were not applied")
+
+// DryRunError is returned by Reconcile() calls of a client created with WithDryRun(), when
+// the actual state differs from the desired state. It lists the changes that would have been
+// applied; no writes are made. DryRunError matches ErrDryRun using errors.Is.
+type DryRunError struct {
+	// Changes is the set of writes that were skipped.
+	Changes []Change `json:"changes"`
+}
+
+// NewDryRunError returns a *DryRunError for the given changes.
+func NewDryRunError(changes ...Change) *DryRunError {
+	return &DryRunError{Changes: changes}
+}
+
+// Error implements the error interface.
+func (e *DryRunError) Error() string {
+	descs := make([]string, 0, len(e.Changes))
+	for _, c := range e.Changes {
+		descs = append(descs, c.String())
+	}
+	return fmt.Sprintf("%s: %s", ErrDryRun, strings.Join(descs, "; "))
+}
+
+// Is returns true if target is ErrDryRun.
+func (e *DryRunError) Is(target error) bool {
+	return target == ErrDryRun
+}
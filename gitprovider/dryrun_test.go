@@ -0,0 +1,47 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDryRunError(t *testing.T) {
+	ref := OrgRepositoryRef{
+		OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "foo-org"},
+		RepositoryName:  "foo-repo",
+	}
+	err := fmt.Errorf("reconcile: %w", NewDryRunError(
+		NewCreateChange(ref, DeployKeyInfo{Name: "key", Key: []byte("ssh-rsa")}),
+		NewUpdateChange(ref, RepositoryInfo{Description: StringVar("old")}, RepositoryInfo{Description: StringVar("new")}),
+	))
+
+	if !errors.Is(err, ErrDryRun) {
+		t.Errorf("errors.Is(%v, ErrDryRun) = false, want true", err)
+	}
+	var dryRunErr *DryRunError
+	if !errors.As(err, &dryRunErr) {
+		t.Fatalf("errors.As(%v, *DryRunError) = false, want true", err)
+	}
+	want := `reconcile: dry-run: changes were not applied: create DeployKey https://github.com/foo-org/foo-repo "key" (name, key); ` +
+		`update Repository https://github.com/foo-org/foo-repo (description)`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -179,4 +179,235 @@ const (
 
 	// MergeMethodSquash causes a pull request merge to first squash commits
 	MergeMethodSquash = MergeMethod("squash")
+
+	// MergeMethodRebase causes a pull request merge to rebase the commits onto the base branch,
+	// without a merge commit. On GitLab, the merge method of the project must be fast-forward
+	MergeMethodRebase = MergeMethod("rebase")
+)
+
+// knownMergeMethodValues is a map of known MergeMethod values, used for validation.
+//
+//nolint:gochecknoglobals
+var knownMergeMethodValues = map[MergeMethod]struct{}{
+	MergeMethodMerge:  {},
+	MergeMethodSquash: {},
+	MergeMethodRebase: {},
+}
+
+// ValidateMergeMethod validates a given MergeMethod.
+// Use as errs.Append(ValidateMergeMethod(method), method, "FieldName").
+func ValidateMergeMethod(m MergeMethod) error {
+	_, ok := knownMergeMethodValues[m]
+	if !ok {
+		return validation.ErrFieldEnumInvalid
+	}
+	return nil
+}
+
+// MilestoneState is an enum specifying the state of a milestone.
+type MilestoneState string
+
+const (
+	// MilestoneStateOpen means the milestone is open, and issues or pull requests can be assigned to it
+	MilestoneStateOpen = MilestoneState("open")
+
+	// MilestoneStateClosed means the milestone has been closed
+	MilestoneStateClosed = MilestoneState("closed")
 )
+
+// MergeQueueState is an enum specifying the state of a pull request in a merge queue.
+type MergeQueueState string
+
+const (
+	// MergeQueueStateQueued means the pull request is waiting for the entries ahead of it
+	MergeQueueStateQueued = MergeQueueState("queued")
+
+	// MergeQueueStateChecking means the checks are running on the pull request merged with the entries ahead of it
+	MergeQueueStateChecking = MergeQueueState("checking")
+
+	// MergeQueueStateMergeable means the checks passed, and the pull request will be merged
+	MergeQueueStateMergeable = MergeQueueState("mergeable")
+
+	// MergeQueueStateMerged means the pull request has been merged by the queue
+	MergeQueueStateMerged = MergeQueueState("merged")
+
+	// MergeQueueStateFailed means the pull request can't be merged, and will be (or was) removed from the queue
+	MergeQueueStateFailed = MergeQueueState("failed")
+)
+
+// ReactionContent is an enum specifying the emoji of a reaction. Providers supporting more
+// emoji than the ones below report the others under their provider-specific name.
+type ReactionContent string
+
+const (
+	// ReactionContentThumbsUp is the 👍 emoji, commonly used to approve a proposal
+	ReactionContentThumbsUp = ReactionContent("+1")
+
+	// ReactionContentThumbsDown is the 👎 emoji
+	ReactionContentThumbsDown = ReactionContent("-1")
+
+	// ReactionContentLaugh is the 😄 emoji
+	ReactionContentLaugh = ReactionContent("laugh")
+
+	// ReactionContentConfused is the 😕 emoji
+	ReactionContentConfused = ReactionContent("confused")
+
+	// ReactionContentHeart is the ❤️ emoji
+	ReactionContentHeart = ReactionContent("heart")
+
+	// ReactionContentHooray is the 🎉 emoji
+	ReactionContentHooray = ReactionContent("hooray")
+
+	// ReactionContentRocket is the 🚀 emoji
+	ReactionContentRocket = ReactionContent("rocket")
+
+	// ReactionContentEyes is the 👀 emoji
+	ReactionContentEyes = ReactionContent("eyes")
+)
+
+// knownReactionContentValues is a map of known ReactionContent values, used for validation.
+//
+//nolint:gochecknoglobals
+var knownReactionContentValues = map[ReactionContent]struct{}{
+	ReactionContentThumbsUp:   {},
+	ReactionContentThumbsDown: {},
+	ReactionContentLaugh:      {},
+	ReactionContentConfused:   {},
+	ReactionContentHeart:      {},
+	ReactionContentHooray:     {},
+	ReactionContentRocket:     {},
+	ReactionContentEyes:       {},
+}
+
+// ValidateReactionContent validates a given ReactionContent.
+// Use as errs.Append(ValidateReactionContent(content), content, "FieldName").
+func ValidateReactionContent(c ReactionContent) error {
+	_, ok := knownReactionContentValues[c]
+	if !ok {
+		return validation.ErrFieldEnumInvalid
+	}
+	return nil
+}
+
+// ArchiveFormat is an enum specifying the format of a repository archive.
+type ArchiveFormat string
+
+const (
+	// ArchiveFormatTarGz specifies a gzip-compressed tarball
+	ArchiveFormatTarGz = ArchiveFormat("tar.gz")
+
+	// ArchiveFormatZip specifies a zip archive
+	ArchiveFormatZip = ArchiveFormat("zip")
+)
+
+// knownArchiveFormatValues is a map of known ArchiveFormat values, used for validation.
+//nolint:gochecknoglobals
+var knownArchiveFormatValues = map[ArchiveFormat]struct{}{
+	ArchiveFormatTarGz: {},
+	ArchiveFormatZip:   {},
+}
+
+// ValidateArchiveFormat validates a given ArchiveFormat.
+// Use as errs.Append(ValidateArchiveFormat(format), format, "FieldName").
+func ValidateArchiveFormat(f ArchiveFormat) error {
+	_, ok := knownArchiveFormatValues[f]
+	if !ok {
+		return validation.ErrFieldEnumInvalid
+	}
+	return nil
+}
+
+// BadgeKind is an enum specifying the kind of a status badge of a repository.
+type BadgeKind string
+
+const (
+	// BadgeKindPipeline is the status of the latest pipeline (or workflow run) of a branch
+	BadgeKindPipeline = BadgeKind("pipeline")
+
+	// BadgeKindRelease is the version of the latest release
+	BadgeKindRelease = BadgeKind("release")
+)
+
+// knownBadgeKindValues is a map of known BadgeKind values, used for validation.
+//
+//nolint:gochecknoglobals
+var knownBadgeKindValues = map[BadgeKind]struct{}{
+	BadgeKindPipeline: {},
+	BadgeKindRelease:  {},
+}
+
+// ValidateBadgeKind validates a given BadgeKind.
+// Use as errs.Append(ValidateBadgeKind(kind), kind, "FieldName").
+func ValidateBadgeKind(k BadgeKind) error {
+	_, ok := knownBadgeKindValues[k]
+	if !ok {
+		return validation.ErrFieldEnumInvalid
+	}
+	return nil
+}
+
+// UserKeyType is an enum specifying the type of a key of a user.
+type UserKeyType string
+
+const (
+	// UserKeyTypeSSH is an SSH public key, used to access repositories over SSH
+	UserKeyTypeSSH = UserKeyType("ssh")
+
+	// UserKeyTypeGPG is a GPG public key, used to verify signed commits and tags
+	UserKeyTypeGPG = UserKeyType("gpg")
+)
+
+// knownUserKeyTypeValues is a map of known UserKeyType values, used for validation.
+//
+//nolint:gochecknoglobals
+var knownUserKeyTypeValues = map[UserKeyType]struct{}{
+	UserKeyTypeSSH: {},
+	UserKeyTypeGPG: {},
+}
+
+// ValidateUserKeyType validates a given UserKeyType.
+// Use as errs.Append(ValidateUserKeyType(t), t, "FieldName").
+func ValidateUserKeyType(t UserKeyType) error {
+	_, ok := knownUserKeyTypeValues[t]
+	if !ok {
+		return validation.ErrFieldEnumInvalid
+	}
+	return nil
+}
+
+// EventType is an enum specifying the kind of an activity event.
+type EventType string
+
+const (
+	// EventTypePush is a push of commits to a branch
+	EventTypePush = EventType("push")
+
+	// EventTypePullRequest is a pull request being opened, closed or merged
+	EventTypePullRequest = EventType("pull_request")
+
+	// EventTypeMember is a user being added to or removed from a repository or organization
+	EventTypeMember = EventType("member")
+
+	// EventTypeOther is any other event, e.g. a comment or a created tag
+	EventTypeOther = EventType("other")
+)
+
+// knownEventTypeValues is a map of known EventType values, used for validation.
+//
+//nolint:gochecknoglobals
+var knownEventTypeValues = map[EventType]struct{}{
+	EventTypePush:        {},
+	EventTypePullRequest: {},
+	EventTypeMember:      {},
+	EventTypeOther:       {},
+}
+
+// ValidateEventType validates a given EventType.
+// Use as errs.Append(ValidateEventType(t), t, "FieldName").
+func ValidateEventType(t EventType) error {
+	_, ok := knownEventTypeValues[t]
+	if !ok {
+		return validation.ErrFieldEnumInvalid
+	}
+	return nil
+}
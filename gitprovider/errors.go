@@ -17,11 +17,24 @@ limitations under the License.
 package gitprovider
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// MaxHTTPErrorBodySize is the number of bytes of the response body kept in HTTPError.Body.
+const MaxHTTPErrorBodySize = 4096
+
+// requestIDHeaders are the headers the providers return the ID of a request in: GitHub, GitLab,
+// and Bitbucket Server.
+//
+//nolint:gochecknoglobals
+var requestIDHeaders = []string{"X-GitHub-Request-Id", "X-Request-Id", "X-Arequestid"}
+
 var (
 	// ErrNoProviderSupport describes that the provider doesn't support the requested feature.
 	ErrNoProviderSupport = errors.New("no provider support for this feature")
@@ -42,8 +55,38 @@ var (
 	ErrAlreadyExists = errors.New("resource already exists, cannot create object. Use Reconcile() to create it idempotently")
 	// ErrNotFound is returned by .Get() and .Update() calls if the given resource doesn't exist.
 	ErrNotFound = errors.New("the requested resource was not found")
+	// ErrEmptyRepository is returned when reading commits or files of a repository that doesn't have any commits yet.
+	// Use CommitClient.Create to create the initial commit and branch of an empty repository.
+	ErrEmptyRepository = errors.New("the repository is empty, it doesn't have any commits yet")
 	// ErrInvalidServerData is returned when the server returned invalid data, e.g. missing required fields in the response.
 	ErrInvalidServerData = errors.New("got invalid data from server, don't know how to handle")
+	// ErrIntegrityCheckFailed is returned when downloaded content doesn't match the size or digest announced by the server.
+	ErrIntegrityCheckFailed = errors.New("downloaded content failed the integrity check")
+	// ErrOutdatedSuggestion is returned when applying a suggestion to lines which changed since the suggestion was made.
+	ErrOutdatedSuggestion = errors.New("the suggested lines changed since the suggestion was made")
+	// ErrForkDiverged is returned when a branch of a fork can't be synchronized with its upstream,
+	// because it has commits which conflict with, or can't be fast-forwarded to, the upstream branch.
+	ErrForkDiverged = errors.New("the branch of the fork diverged from its upstream")
+	// ErrOperationFailed is returned by Operation.Wait when the long-running operation failed.
+	ErrOperationFailed = errors.New("the operation failed")
+	// ErrPermissionDenied is returned when the token isn't allowed to perform an operation, e.g. for
+	// a 403 Forbidden response because the token lacks a scope, and by Client.ValidatePermissions.
+	ErrPermissionDenied = errors.New("the token lacks a needed permission")
+	// ErrRateLimited is returned when the rate limit of the provider was exceeded. The error is
+	// usually a *RateLimitError too, with the time the limit resets.
+	ErrRateLimited = errors.New("the rate limit of the provider was exceeded")
+	// ErrRepositoryArchived is returned when writing to an archived, read-only repository.
+	ErrRepositoryArchived = errors.New("the repository is archived and read-only")
+	// ErrBranchProtected is returned when a change is refused by the protection rules of a branch.
+	ErrBranchProtected = errors.New("the change was refused by the protection rules of the branch")
+	// ErrMergeConflict is returned when a pull request or branch can't be merged because of conflicts.
+	ErrMergeConflict = errors.New("the changes can't be merged because of conflicts")
+	// ErrValidationFailed is returned when the provider refused a request because of invalid fields.
+	// The error is usually a *ValidationError too, with the invalid fields if the provider reports them.
+	ErrValidationFailed = errors.New("the request failed the server-side validation")
+	// ErrConflict is returned when an object was changed since it was read, by the Update calls of
+	// clients created with WithOptimisticConcurrency. The error is a *ConflictError too.
+	ErrConflict = errors.New("the object was changed since it was read")
 
 	// ErrURLUnsupportedScheme is returned if an URL without the HTTPS scheme is parsed.
 	ErrURLUnsupportedScheme = errors.New("unsupported URL scheme, only HTTPS supported")
@@ -73,6 +116,8 @@ var (
 )
 
 // HTTPError is an error that contains context about the HTTP request/response that failed.
+// The typed errors extending it, e.g. *RateLimitError, can be read as an *HTTPError with
+// errors.As too, to get the details of any failed response.
 type HTTPError struct {
 	// HTTP response that caused this error.
 	Response *http.Response `json:"-"`
@@ -82,11 +127,57 @@ type HTTPError struct {
 	Message string `json:"message"`
 	// Where to find more information about the error.
 	DocumentationURL string `json:"documentationURL"`
+	// StatusCode is the HTTP status code of the response, e.g. 422.
+	StatusCode int `json:"statusCode,omitempty"`
+	// RequestID is the ID the provider assigned to the request, e.g. from the X-GitHub-Request-Id
+	// header, to find it in the logs of the provider or quote it to its support.
+	RequestID string `json:"requestID,omitempty"`
+	// Body is the response body, truncated to MaxHTTPErrorBodySize bytes, with secrets redacted.
+	Body string `json:"body,omitempty"`
+}
+
+// NewHTTPError returns an HTTPError with the status code, request ID and body of resp. If body
+// is nil, the body of resp is read, and restored for its other readers. The provider sets the
+// messages.
+func NewHTTPError(resp *http.Response, body []byte) HTTPError {
+	if resp == nil {
+		return HTTPError{}
+	}
+	httpErr := HTTPError{Response: resp, StatusCode: resp.StatusCode}
+	for _, header := range requestIDHeaders {
+		if id := resp.Header.Get(header); id != "" {
+			httpErr.RequestID = id
+			break
+		}
+	}
+	if body == nil && resp.Body != nil {
+		body, _ = io.ReadAll(resp.Body)
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if len(body) > MaxHTTPErrorBodySize {
+		httpErr.Body = Redact(string(body[:MaxHTTPErrorBodySize])) + "... (truncated)"
+	} else {
+		httpErr.Body = Redact(string(body))
+	}
+	return httpErr
 }
 
 // Error implements the error interface.
 func (e *HTTPError) Error() string {
-	return e.ErrorMessage
+	if e.RequestID != "" {
+		return fmt.Sprintf("%s (request ID %s)", Redact(e.ErrorMessage), e.RequestID)
+	}
+	return Redact(e.ErrorMessage)
+}
+
+// asHTTPError implements the As method of the errors extending HTTPError: a **HTTPError target
+// is set to e.
+func asHTTPError(e *HTTPError, target interface{}) bool {
+	if httpErr, ok := target.(**HTTPError); ok {
+		*httpErr = e
+		return true
+	}
+	return false
 }
 
 // RateLimitError is an error, extending HTTPError, that contains context about rate limits.
@@ -102,6 +193,16 @@ type RateLimitError struct {
 	Reset time.Time `json:"reset"`
 }
 
+// Is makes errors.Is(err, ErrRateLimited) true for a *RateLimitError.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// As makes errors.As(err, &httpErr) set httpErr to the HTTPError of a *RateLimitError.
+func (e *RateLimitError) As(target interface{}) bool {
+	return asHTTPError(&e.HTTPError, target)
+}
+
 // ValidationError is an error, extending HTTPError, that contains context about failed server-side validation.
 type ValidationError struct {
 	// RateLimitError extends HTTPError.
@@ -111,6 +212,16 @@ type ValidationError struct {
 	Errors []ValidationErrorItem `json:"errors"`
 }
 
+// Is makes errors.Is(err, ErrValidationFailed) true for a *ValidationError.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidationFailed
+}
+
+// As makes errors.As(err, &httpErr) set httpErr to the HTTPError of a *ValidationError.
+func (e *ValidationError) As(target interface{}) bool {
+	return asHTTPError(&e.HTTPError, target)
+}
+
 // ValidationErrorItem represents a single invalid field in an invalid request.
 type ValidationErrorItem struct {
 	// Resource on which the error occurred.
@@ -131,3 +242,89 @@ type InvalidCredentialsError struct {
 	// InvalidCredentialsError extends HTTPError.
 	HTTPError `json:",inline"`
 }
+
+// As makes errors.As(err, &httpErr) set httpErr to the HTTPError of an *InvalidCredentialsError.
+func (e *InvalidCredentialsError) As(target interface{}) bool {
+	return asHTTPError(&e.HTTPError, target)
+}
+
+// ConflictError is returned when an object was changed since it was read, instead of overwriting
+// the concurrent changes, see WithOptimisticConcurrency.
+type ConflictError struct {
+	// Object identifies the changed object, e.g. the repository reference.
+	Object string
+	// Read is the state of the object when it was read.
+	Read InfoRequest
+	// Actual is the current state of the object.
+	Actual InfoRequest
+}
+
+// Error implements the error interface.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Object, ErrConflict)
+}
+
+// Is makes errors.Is(err, ErrConflict) true for a *ConflictError.
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// CheckUnchanged returns a *ConflictError if the actual state of the object differs from the
+// state it was read in, comparing them with Equals both ways. The providers call it before
+// updating an object in a client with WithOptimisticConcurrency.
+func CheckUnchanged(object string, read, actual InfoRequest) error {
+	if read.Equals(actual) && actual.Equals(read) {
+		return nil
+	}
+	return &ConflictError{Object: object, Read: read, Actual: actual}
+}
+
+// httpErrorPhrases are the phrases of the provider error messages ClassifyHTTPError falls back
+// to, lowercased. They are phrases rather than words like "archived", so that names in the
+// messages, e.g. of an "archived-charts" repository, don't match.
+//
+//nolint:gochecknoglobals
+var httpErrorPhrases = []struct {
+	phrase string
+	err    error
+}{
+	{phrase: "was archived", err: ErrRepositoryArchived},
+	{phrase: "is archived", err: ErrRepositoryArchived},
+	{phrase: "protected branch", err: ErrBranchProtected},
+	{phrase: "branch is protected", err: ErrBranchProtected},
+	{phrase: "merge conflict", err: ErrMergeConflict},
+	{phrase: "not mergeable", err: ErrMergeConflict},
+	{phrase: "cannot be merged", err: ErrMergeConflict},
+}
+
+// ClassifyHTTPError returns the error of the taxonomy above matching a failed response, or nil if
+// none matches. The providers use it when mapping their HTTP errors, after classifying the
+// response from their structured error fields, so that callers can branch on errors.Is.
+//
+// The response is classified on its status code. The archived repository, protected branch and
+// merge conflict errors share their status codes with other errors though, and the providers
+// mostly don't tell them apart but in the message. Hence, as a last resort, the message of a
+// response with one of these status codes is matched against the phrases the providers use.
+// This breaks if a provider rewords or localizes them, the error is then classified on its
+// status code only, e.g. as ErrPermissionDenied.
+func ClassifyHTTPError(statusCode int, message string) error {
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotAcceptable,
+		http.StatusConflict, http.StatusUnprocessableEntity:
+		msg := strings.ToLower(message)
+		for _, p := range httpErrorPhrases {
+			if strings.Contains(msg, p.phrase) {
+				return p.err
+			}
+		}
+	}
+	switch statusCode {
+	case http.StatusForbidden:
+		return ErrPermissionDenied
+	case http.StatusUnprocessableEntity:
+		return ErrValidationFailed
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ExtensionFactory returns the extension for the given client. It is only called with clients
+// reporting the provider the factory was registered for, but these can be wrappers or fakes, so
+// the factory must check the type of c, and return false if it isn't the provider-specific
+// Client type.
+type ExtensionFactory func(c Client) (interface{}, bool)
+
+//nolint:gochecknoglobals
+var (
+	extensionsMu sync.RWMutex
+	extensions   = map[ProviderID]map[string]ExtensionFactory{}
+)
+
+// RegisterExtension makes provider-specific functionality, that doesn't fit the generic
+// interfaces, available through Client.Extension(name) for clients of the given provider.
+// Provider packages register their extensions in init(). The extension's interface type
+// should be exported by the registering package, for callers to type-assert to.
+//
+// RegisterExtension panics if factory is nil, or if name is already registered for provider.
+func RegisterExtension(provider ProviderID, name string, factory ExtensionFactory) {
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+
+	if factory == nil {
+		panic(fmt.Sprintf("gitprovider: RegisterExtension factory for %s/%s is nil", provider, name))
+	}
+	if _, ok := extensions[provider][name]; ok {
+		panic(fmt.Sprintf("gitprovider: RegisterExtension called twice for %s/%s", provider, name))
+	}
+	if extensions[provider] == nil {
+		extensions[provider] = map[string]ExtensionFactory{}
+	}
+	extensions[provider][name] = factory
+}
+
+// LookupExtension returns the extension registered under name for the provider of c, or
+// nil if there is none, or if c isn't a client the extension supports. Provider clients
+// implement Client.Extension using this function.
+func LookupExtension(c Client, name string) interface{} {
+	extensionsMu.RLock()
+	factory, ok := extensions[c.ProviderID()][name]
+	extensionsMu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+	ext, ok := factory(c)
+	if !ok {
+		return nil
+	}
+	return ext
+}
+
+// Extensions returns the sorted names of the extensions registered for the given provider.
+func Extensions(provider ProviderID) []string {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+
+	names := make([]string, 0, len(extensions[provider]))
+	for name := range extensions[provider] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"reflect"
+	"testing"
+)
+
+// extensionTestClient is a Client for a test-only provider; only ProviderID is implemented.
+type extensionTestClient struct {
+	Client
+}
+
+func (extensionTestClient) ProviderID() ProviderID { return "extension-test" }
+
+func TestExtensions(t *testing.T) {
+	RegisterExtension("extension-test", "b", func(c Client) (interface{}, bool) { return c.ProviderID(), true })
+	RegisterExtension("extension-test", "a", func(Client) (interface{}, bool) { return "a", true })
+	RegisterExtension("extension-test", "typed", func(c Client) (interface{}, bool) {
+		cl, ok := c.(*extensionTestClient)
+		if !ok {
+			return nil, false
+		}
+		return cl, true
+	})
+
+	if got, want := Extensions("extension-test"), []string{"a", "b", "typed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Extensions() = %v, want %v", got, want)
+	}
+	c := extensionTestClient{}
+	if got := LookupExtension(c, "b"); got != ProviderID("extension-test") {
+		t.Errorf("LookupExtension(b) = %v, want %v", got, "extension-test")
+	}
+	if got := LookupExtension(c, "c"); got != nil {
+		t.Errorf("LookupExtension(c) = %v, want nil", got)
+	}
+	// Clients of another type than the extension supports, e.g. wrappers, get no extension
+	if got := LookupExtension(c, "typed"); got != nil {
+		t.Errorf("LookupExtension(typed) of a %T = %v, want nil", c, got)
+	}
+	if got := LookupExtension(&c, "typed"); got != &c {
+		t.Errorf("LookupExtension(typed) of a %T = %v, want the client", &c, got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterExtension didn't panic for a duplicate name")
+		}
+	}()
+	RegisterExtension("extension-test", "a", func(Client) (interface{}, bool) { return nil, true })
+}
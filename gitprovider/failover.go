@@ -0,0 +1,260 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FailoverStrategy describes how the endpoints set with WithFailoverEndpoints share the requests.
+type FailoverStrategy string
+
+const (
+	// FailoverStrategyRoundRobin sends the requests to the healthy endpoints in turn.
+	FailoverStrategyRoundRobin = FailoverStrategy("round-robin")
+	// FailoverStrategyWeightedRandom sends the requests to a random healthy endpoint, with a
+	// probability proportional to its weight.
+	FailoverStrategyWeightedRandom = FailoverStrategy("weighted-random")
+)
+
+// FailoverCooldown is how long an endpoint is considered unhealthy after a failed request.
+const FailoverCooldown = 30 * time.Second
+
+// FailoverEndpoint is a replica of the Git provider API, e.g. a GitLab node in another region.
+type FailoverEndpoint struct {
+	// URL is the base URL of the replica, e.g. "https://gitlab-eu.example.com". Only its scheme
+	// and host are used, the request paths are the same on all replicas.
+	URL string
+
+	// Weight of the endpoint with FailoverStrategyWeightedRandom. Endpoints with a zero weight
+	// are only used when all the others are unhealthy. Default: 1
+	Weight *int
+}
+
+// failoverEndpoint is a parsed FailoverEndpoint, and its health.
+type failoverEndpoint struct {
+	scheme string
+	host   string
+	weight int
+	// unhealthyUntil is the end of the cooldown after the last failed request.
+	unhealthyUntil time.Time
+}
+
+// failover holds the endpoints of WithFailoverEndpoints, and their health.
+type failover struct {
+	strategy  FailoverStrategy
+	endpoints []*failoverEndpoint
+	hosts     map[string]bool
+
+	mu   sync.Mutex
+	next int
+	rand *rand.Rand
+}
+
+// newFailover validates and parses the endpoints.
+func newFailover(strategy FailoverStrategy, endpoints []FailoverEndpoint) (*failover, error) {
+	if strategy != FailoverStrategyRoundRobin && strategy != FailoverStrategyWeightedRandom {
+		return nil, fmt.Errorf("unknown failover strategy %q", strategy)
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no failover endpoints given")
+	}
+	f := &failover{
+		strategy: strategy,
+		hosts:    map[string]bool{},
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+	}
+	for _, e := range endpoints {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid failover endpoint %q: %w", e.URL, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return nil, fmt.Errorf("failover endpoint %q must be a http(s) URL without a path", e.URL)
+		}
+		if f.hosts[u.Host] {
+			return nil, fmt.Errorf("duplicate failover endpoint %q", e.URL)
+		}
+		weight := 1
+		if e.Weight != nil {
+			weight = *e.Weight
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("failover endpoint %q has a negative weight", e.URL)
+		}
+		f.hosts[u.Host] = true
+		f.endpoints = append(f.endpoints, &failoverEndpoint{scheme: u.Scheme, host: u.Host, weight: weight})
+	}
+	return f, nil
+}
+
+// validateDomain checks that the domain of the Client is the host of one of the endpoints, as only
+// the requests to these hosts are sent to the endpoints. The domain may include a scheme.
+func (f *failover) validateDomain(domain *string) error {
+	if domain == nil {
+		return fmt.Errorf("failover endpoints require a custom domain, set to one of the endpoints")
+	}
+	d := *domain
+	if !strings.Contains(d, "://") {
+		d = "https://" + d
+	}
+	u, err := url.Parse(d)
+	if err != nil {
+		return fmt.Errorf("invalid domain %q: %w", *domain, err)
+	}
+	if !f.hosts[u.Host] {
+		return fmt.Errorf("the domain %q isn't one of the failover endpoints, its requests wouldn't fail over", *domain)
+	}
+	return nil
+}
+
+// order returns the endpoints in the order they should be tried for a request: the one chosen by
+// the strategy, the other healthy endpoints, and the unhealthy ones, whose cooldown ends first.
+func (f *failover) order(now time.Time) []*failoverEndpoint {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var healthy, unhealthy []*failoverEndpoint
+	for _, e := range f.endpoints {
+		if now.Before(e.unhealthyUntil) {
+			unhealthy = append(unhealthy, e)
+		} else {
+			healthy = append(healthy, e)
+		}
+	}
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		return unhealthy[i].unhealthyUntil.Before(unhealthy[j].unhealthyUntil)
+	})
+	if len(healthy) == 0 {
+		return unhealthy
+	}
+
+	first := 0
+	switch f.strategy {
+	case FailoverStrategyRoundRobin:
+		first = f.next % len(healthy)
+		f.next++
+	case FailoverStrategyWeightedRandom:
+		first = f.pickWeighted(healthy)
+	}
+	ordered := make([]*failoverEndpoint, 0, len(f.endpoints))
+	ordered = append(ordered, healthy[first:]...)
+	ordered = append(ordered, healthy[:first]...)
+	return append(ordered, unhealthy...)
+}
+
+// pickWeighted returns the index of a random endpoint, with a probability proportional to its
+// weight. The lock must be held.
+func (f *failover) pickWeighted(endpoints []*failoverEndpoint) int {
+	total := 0
+	for _, e := range endpoints {
+		total += e.weight
+	}
+	if total == 0 {
+		return 0
+	}
+	n := f.rand.Intn(total)
+	for i, e := range endpoints {
+		if n < e.weight {
+			return i
+		}
+		n -= e.weight
+	}
+	return 0
+}
+
+// setHealthy marks e as healthy, or unhealthy until the end of the cooldown.
+func (f *failover) setHealthy(e *failoverEndpoint, healthy bool, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if healthy {
+		e.unhealthyUntil = time.Time{}
+	} else {
+		e.unhealthyUntil = now.Add(FailoverCooldown)
+	}
+}
+
+// failoverTransport is a http.RoundTripper sending the requests to the failover endpoints. An
+// endpoint is unhealthy for FailoverCooldown after a connection error or a 502, 503 or 504
+// response, and the request is retried on the next endpoint if its body can be replayed.
+// The health checking is passive only: the endpoints aren't probed, their health is only
+// updated by the requests sent to them.
+type failoverTransport struct {
+	failover *failover
+	clock    Clock
+	next     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests to other hosts, e.g. for uploads, are sent as-is
+	if !t.failover.hosts[req.URL.Host] {
+		return t.next.RoundTrip(req)
+	}
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	endpoints := t.failover.order(t.clock.Now())
+	for i, e := range endpoints {
+		r := req.Clone(req.Context())
+		if i > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		r.URL.Scheme, r.URL.Host, r.Host = e.scheme, e.host, ""
+
+		resp, err := t.next.RoundTrip(r)
+		failed := err != nil || resp.StatusCode == http.StatusBadGateway ||
+			resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusGatewayTimeout
+		// A cancelled request says nothing about the health of the endpoint
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+		t.failover.setHealthy(e, !failed, t.clock.Now())
+		if !failed || !replayable || i == len(endpoints)-1 {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+	// Not reached, there is at least one endpoint
+	return nil, fmt.Errorf("no failover endpoints")
+}
+
+// failoverTransportFunc returns a ChainableRoundTripperFunc that sends the requests to the
+// endpoints of f.
+func failoverTransportFunc(f *failover, clock Clock) ChainableRoundTripperFunc {
+	return func(in http.RoundTripper) http.RoundTripper {
+		if in == nil {
+			in = http.DefaultTransport
+		}
+		return &failoverTransport{failover: f, clock: ClockOrDefault(clock), next: in}
+	}
+}
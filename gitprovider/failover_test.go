@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFailoverTransport(t *testing.T) {
+	var hits []string
+	healthy := true
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits = append(hits, name)
+			body, _ := ioutil.ReadAll(r.Body)
+			if name == "a" && !healthy {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write(body)
+		}))
+	}
+	a, b := newServer("a"), newServer("b")
+	defer a.Close()
+	defer b.Close()
+
+	clock := &fixedClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	opts, err := MakeClientOptions(
+		WithClock(clock),
+		WithDomain(a.URL),
+		WithFailoverEndpoints(FailoverStrategyRoundRobin, FailoverEndpoint{URL: a.URL}, FailoverEndpoint{URL: b.URL}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := BuildClientFromTransportChain(opts.GetTransportChain())
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := func() {
+		t.Helper()
+		// The requests are sent to the host of a, and rewritten by the transport
+		resp, err := c.Post(a.URL+"/api/v4/projects", "text/plain", strings.NewReader("body"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "body" {
+			t.Fatalf("got %d %q, want 200 with the request body", resp.StatusCode, body)
+		}
+	}
+	assertHits := func(want ...string) {
+		t.Helper()
+		if strings.Join(hits, ",") != strings.Join(want, ",") {
+			t.Errorf("hits = %v, want %v", hits, want)
+		}
+		hits = nil
+	}
+
+	post()
+	post()
+	assertHits("a", "b")
+
+	// The request is retried on b, and a isn't used during the cooldown
+	healthy = false
+	post()
+	post()
+	assertHits("a", "b", "b")
+
+	// a is tried again after the cooldown
+	healthy = true
+	clock.now = clock.now.Add(FailoverCooldown)
+	post()
+	post()
+	assertHits("a", "b")
+}
+
+func TestWithFailoverEndpoints(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  FailoverStrategy
+		endpoints []FailoverEndpoint
+		domain    string
+		wantErr   bool
+	}{
+		{
+			name:      "weighted",
+			strategy:  FailoverStrategyWeightedRandom,
+			endpoints: []FailoverEndpoint{{URL: "https://gitlab-eu.example.com"}, {URL: "https://gitlab-us.example.com/", Weight: IntVar(3)}},
+			domain:    "gitlab-us.example.com",
+		},
+		{
+			name:      "domain with scheme",
+			strategy:  FailoverStrategyRoundRobin,
+			endpoints: []FailoverEndpoint{{URL: "https://gitlab-eu.example.com:8443"}},
+			domain:    "https://gitlab-eu.example.com:8443",
+		},
+		{
+			name:      "domain isn't an endpoint",
+			strategy:  FailoverStrategyRoundRobin,
+			endpoints: []FailoverEndpoint{{URL: "https://gitlab-eu.example.com"}, {URL: "https://gitlab-us.example.com"}},
+			domain:    "gitlab.example.com",
+			wantErr:   true,
+		},
+		{
+			name:      "no domain",
+			strategy:  FailoverStrategyRoundRobin,
+			endpoints: []FailoverEndpoint{{URL: "https://gitlab-eu.example.com"}},
+			wantErr:   true,
+		},
+		{
+			name:      "unknown strategy",
+			strategy:  FailoverStrategy("random"),
+			endpoints: []FailoverEndpoint{{URL: "https://gitlab-eu.example.com"}},
+			wantErr:   true,
+		},
+		{
+			name:     "no endpoints",
+			strategy: FailoverStrategyRoundRobin,
+			wantErr:  true,
+		},
+		{
+			name:      "path",
+			strategy:  FailoverStrategyRoundRobin,
+			endpoints: []FailoverEndpoint{{URL: "https://example.com/gitlab"}},
+			wantErr:   true,
+		},
+		{
+			name:      "duplicate",
+			strategy:  FailoverStrategyRoundRobin,
+			endpoints: []FailoverEndpoint{{URL: "https://gitlab.example.com"}, {URL: "https://gitlab.example.com/"}},
+			wantErr:   true,
+		},
+		{
+			name:      "negative weight",
+			strategy:  FailoverStrategyWeightedRandom,
+			endpoints: []FailoverEndpoint{{URL: "https://gitlab.example.com", Weight: IntVar(-1)}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []ClientOption{WithFailoverEndpoints(tt.strategy, tt.endpoints...)}
+			if tt.domain != "" {
+				opts = append(opts, WithDomain(tt.domain))
+			}
+			_, err := MakeClientOptions(opts...)
+			if got := errors.Is(err, ErrInvalidClientOptions); got != tt.wantErr {
+				t.Errorf("MakeClientOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package faults provides a fault-injecting HTTP transport, simulating Git provider outages
+// such as failing requests, slow responses and specific error status codes. It is meant to
+// be used in tests and staging environments, to verify the retry and backoff handling of
+// consumers against realistic provider failure modes.
+package faults
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// ErrInjectedFault is returned by the transport for requests failed on purpose without a response.
+var ErrInjectedFault = errors.New("injected fault")
+
+// Rule describes the faults to inject for the requests matching it.
+type Rule struct {
+	// Method restricts the rule to requests with the given HTTP method.
+	// An empty string matches all methods.
+	Method string
+
+	// PathPattern restricts the rule to requests with an URL path matching the regular expression.
+	// A nil value matches all paths.
+	PathPattern *regexp.Regexp
+
+	// ErrorRate is the probability, between 0 and 1, that a matching request fails.
+	ErrorRate float64
+
+	// StatusCode is the status code of the response returned for failed requests, e.g. 503 or 429.
+	// If zero, failed requests return ErrInjectedFault instead of a response.
+	StatusCode int
+
+	// Header is added to the response returned for failed requests, e.g. to set "Retry-After".
+	Header http.Header
+
+	// Latency is added before every matching request is sent, whether it fails or not.
+	Latency time.Duration
+}
+
+// matches returns true if the rule applies to the given request.
+func (r *Rule) matches(req *http.Request) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+		return false
+	}
+	if r.PathPattern != nil && !r.PathPattern.MatchString(req.URL.Path) {
+		return false
+	}
+	return true
+}
+
+// Injector injects faults into the requests matching its rules.
+// The first rule matching a request is applied, requests without a matching rule are passed through.
+//
+// Register the transport "after" the cache and authentication transports, so faults are seen by
+// the whole chain:
+//
+//	injector := &faults.Injector{Rules: []faults.Rule{{PathPattern: regexp.MustCompile("/repos/"), ErrorRate: 0.2, StatusCode: 503}}}
+//	client, err := github.NewClient(gitprovider.WithPostChainTransportHook(injector.Transport))
+type Injector struct {
+	// Rules are the fault rules, evaluated in order.
+	Rules []Rule
+
+	// Rand returns a pseudo-random number in [0.0,1.0), it is used to decide whether a request fails.
+	// Defaults to rand.Float64, set it to get reproducible failures.
+	Rand func() float64
+
+	// Clock is used to wait for the latency of the matching rule. Defaults to gitprovider.RealClock.
+	Clock gitprovider.Clock
+}
+
+// Transport is a gitprovider.ChainableRoundTripperFunc which injects faults before calling in.
+// If in is nil, http.DefaultTransport is used.
+func (i *Injector) Transport(in http.RoundTripper) http.RoundTripper {
+	if in == nil {
+		in = http.DefaultTransport
+	}
+	return &faultRoundTripper{injector: i, next: in}
+}
+
+// faultRoundTripper is the http.RoundTripper returned by Injector.Transport.
+type faultRoundTripper struct {
+	injector *Injector
+	next     http.RoundTripper
+}
+
+// RoundTrip applies the first rule matching req, then calls the underlying RoundTripper unless
+// the request is failed on purpose.
+func (t *faultRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rule := t.injector.match(req)
+	if rule == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	if rule.Latency > 0 {
+		if err := gitprovider.ClockOrDefault(t.injector.Clock).Sleep(req.Context(), rule.Latency); err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.ErrorRate <= 0 || t.injector.random() >= rule.ErrorRate {
+		return t.next.RoundTrip(req)
+	}
+
+	// Drain and close the request body, as the underlying RoundTripper would have
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		_ = req.Body.Close()
+	}
+
+	if rule.StatusCode == 0 {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, ErrInjectedFault)
+	}
+	return newFaultResponse(req, rule), nil
+}
+
+// match returns the first rule matching req, or nil if there is none.
+func (i *Injector) match(req *http.Request) *Rule {
+	for idx := range i.Rules {
+		if i.Rules[idx].matches(req) {
+			return &i.Rules[idx]
+		}
+	}
+	return nil
+}
+
+func (i *Injector) random() float64 {
+	if i.Rand != nil {
+		return i.Rand()
+	}
+	return rand.Float64() //nolint:gosec
+}
+
+// newFaultResponse builds the response of a request failed with rule.StatusCode.
+// The body is a JSON error message, as returned by the provider APIs.
+func newFaultResponse(req *http.Request, rule *Rule) *http.Response {
+	body := fmt.Sprintf(`{"message":%q}`, ErrInjectedFault.Error())
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	for key, values := range rule.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rule.StatusCode, http.StatusText(rule.StatusCode)),
+		StatusCode:    rule.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
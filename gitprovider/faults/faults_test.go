@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faults
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestInjector_Transport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		rules      []Rule
+		method     string
+		path       string
+		rand       float64
+		wantStatus int
+		wantErr    error
+		wantHeader string
+	}{
+		{
+			name:       "no rules, request passes through",
+			method:     http.MethodGet,
+			path:       "/repos/foo/bar",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "matching rule, injected status code",
+			rules:      []Rule{{PathPattern: regexp.MustCompile("^/repos/"), ErrorRate: 1, StatusCode: http.StatusServiceUnavailable}},
+			method:     http.MethodGet,
+			path:       "/repos/foo/bar",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "matching rule, header is set",
+			rules:      []Rule{{ErrorRate: 1, StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"1"}}}},
+			method:     http.MethodGet,
+			path:       "/repos/foo/bar",
+			wantStatus: http.StatusTooManyRequests,
+			wantHeader: "1",
+		},
+		{
+			name:    "matching rule, transport error",
+			rules:   []Rule{{Method: http.MethodPost, ErrorRate: 1}},
+			method:  http.MethodPost,
+			path:    "/repos/foo/bar",
+			wantErr: ErrInjectedFault,
+		},
+		{
+			name:       "method does not match",
+			rules:      []Rule{{Method: http.MethodPost, ErrorRate: 1, StatusCode: http.StatusInternalServerError}},
+			method:     http.MethodGet,
+			path:       "/repos/foo/bar",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "path does not match",
+			rules:      []Rule{{PathPattern: regexp.MustCompile("^/orgs/"), ErrorRate: 1, StatusCode: http.StatusInternalServerError}},
+			method:     http.MethodGet,
+			path:       "/repos/foo/bar",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "random number above error rate",
+			rules:      []Rule{{ErrorRate: 0.5, StatusCode: http.StatusInternalServerError}},
+			method:     http.MethodGet,
+			path:       "/repos/foo/bar",
+			rand:       0.7,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "first matching rule applies",
+			rules: []Rule{
+				{PathPattern: regexp.MustCompile("/bar$"), ErrorRate: 1, StatusCode: http.StatusBadGateway},
+				{ErrorRate: 1, StatusCode: http.StatusInternalServerError},
+			},
+			method:     http.MethodGet,
+			path:       "/repos/foo/bar",
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector := &Injector{Rules: tt.rules, Rand: func() float64 { return tt.rand }}
+			client := &http.Client{Transport: injector.Transport(nil)}
+
+			req, err := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("client.Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("client.Do() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get("Retry-After"); got != tt.wantHeader {
+				t.Errorf("client.Do() Retry-After = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestInjector_Latency(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	injector := &Injector{Rules: []Rule{{Latency: time.Minute}}}
+	client := &http.Client{Transport: injector.Transport(nil)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("client.Do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
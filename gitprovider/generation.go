@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Timestamps are the times an object was created and last updated, as reported by the provider,
+// and the generation of the object as observed by the client.
+type Timestamps struct {
+	// CreatedAt is the time the object was created, if the provider reports it.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+
+	// UpdatedAt is the time the object was last updated, if the provider reports it.
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+
+	// Generation is 1 for the first state of the object seen through Timestamps, and incremented
+	// for each new state, i.e. with a different UpdatedAt or, if the provider doesn't report it,
+	// different content. Objects returned before a change keep their generation. Generations are
+	// kept by each client, and aren't comparable across clients.
+	Generation int64 `json:"generation"`
+}
+
+// Timestamped is implemented by the objects reporting their Timestamps, i.e. the repositories,
+// deploy keys and pull requests.
+type Timestamped interface {
+	// Timestamps returns the creation and update times, and the generation, of the object.
+	Timestamps() Timestamps
+}
+
+// maxTrackedVersions is the number of latest versions of an object a GenerationTracker keeps.
+// Older versions get a new generation if they are observed again.
+const maxTrackedVersions = 16
+
+// GenerationTracker assigns generations to the objects of a client. It keeps the latest versions
+// of every object it observed, so its memory grows with the number of objects.
+// A nil GenerationTracker doesn't track objects, which are always at generation 1.
+type GenerationTracker struct {
+	mu      sync.Mutex
+	objects map[string][]trackedVersion
+}
+
+// trackedVersion is an observed version of an object, and its generation.
+type trackedVersion struct {
+	version    string
+	generation int64
+}
+
+// NewGenerationTracker returns an empty GenerationTracker.
+func NewGenerationTracker() *GenerationTracker {
+	return &GenerationTracker{objects: map[string][]trackedVersion{}}
+}
+
+// Observe returns the generation of the object with the given key, e.g. its URL, in the given
+// version, see ObjectVersion. A version observed for the first time gets the next generation.
+func (t *GenerationTracker) Observe(key, version string) int64 {
+	if t == nil {
+		return 1
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	versions := t.objects[key]
+	for _, v := range versions {
+		if v.version == version {
+			return v.generation
+		}
+	}
+	generation := int64(1)
+	if len(versions) != 0 {
+		generation = versions[len(versions)-1].generation + 1
+	}
+	if len(versions) == maxTrackedVersions {
+		versions = versions[1:]
+	}
+	t.objects[key] = append(versions, trackedVersion{version: version, generation: generation})
+	return generation
+}
+
+// ObjectVersion returns the version of an object to pass to GenerationTracker.Observe: its update
+// time if the provider reports it, otherwise a hash of its API object.
+func ObjectVersion(updatedAt *time.Time, apiObj interface{}) string {
+	if updatedAt != nil {
+		return updatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(apiObj)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
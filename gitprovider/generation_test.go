@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGenerationTracker_Observe(t *testing.T) {
+	tr := NewGenerationTracker()
+	t1 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	steps := []struct {
+		key     string
+		version string
+		want    int64
+	}{
+		{key: "a", version: ObjectVersion(&t1, nil), want: 1},
+		{key: "a", version: ObjectVersion(&t1, nil), want: 1},
+		{key: "b", version: ObjectVersion(&t1, nil), want: 1},
+		{key: "a", version: ObjectVersion(&t2, nil), want: 2},
+		// An object returned before the change keeps its generation
+		{key: "a", version: ObjectVersion(&t1, nil), want: 1},
+		{key: "b", version: ObjectVersion(nil, map[string]string{"name": "b"}), want: 2},
+		{key: "b", version: ObjectVersion(nil, map[string]string{"name": "b"}), want: 2},
+		{key: "b", version: ObjectVersion(nil, map[string]string{"name": "c"}), want: 3},
+	}
+	for i, step := range steps {
+		if got := tr.Observe(step.key, step.version); got != step.want {
+			t.Errorf("step %d: Observe(%q) = %d, want %d", i, step.key, got, step.want)
+		}
+	}
+
+	// Only the latest versions are kept
+	for i := 0; i < maxTrackedVersions; i++ {
+		tr.Observe("c", fmt.Sprint(i))
+	}
+	if got := tr.Observe("c", "0"); got != 1 {
+		t.Errorf("Observe() of the oldest version = %d, want 1", got)
+	}
+	tr.Observe("c", "new")
+	if got := tr.Observe("c", "0"); got != maxTrackedVersions+2 {
+		t.Errorf("Observe() of a forgotten version = %d, want %d", got, maxTrackedVersions+2)
+	}
+	if got := tr.Observe("c", "2"); got != 3 {
+		t.Errorf("Observe() of a version still tracked = %d, want 3", got)
+	}
+
+	var nilTracker *GenerationTracker
+	if got := nilTracker.Observe("a", "v"); got != 1 {
+		t.Errorf("Observe() on a nil tracker = %d, want 1", got)
+	}
+}
@@ -16,10 +16,15 @@ limitations under the License.
 
 package gitprovider
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
 
 // ProviderID is a typed string for a given Git provider
-// The provider constants are defined in their respective packages.
+// The provider constants are defined in their respective packages, and as Provider* constants
+// in this package.
 type ProviderID string
 
 // InfoRequest is an interface which all {Object}Info objects that can be used as Create() or Reconcile()
@@ -82,6 +87,9 @@ type Reconcilable interface {
 	// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
 	// If req is already the actual state, this is a no-op (actionTaken == false).
 	//
+	// With WithDryRun(), nothing is written: a needed change returns actionTaken == true together
+	// with a *DryRunError matching ErrDryRun, which isn't a failure, see WithDryRun.
+	//
 	// The internal API object will be overridden with the received server data if actionTaken == true.
 	Reconcile(ctx context.Context) (actionTaken bool, err error)
 }
@@ -120,3 +128,30 @@ func ValidateAndDefaultInfo(info DefaultedInfoRequest) error {
 	info.Default()
 	return nil
 }
+
+// InitializeRepository bootstraps an empty repository in one call: it creates the initial commit
+// holding the given files on the given branch, and makes that branch the default branch.
+//
+// ErrAlreadyExists is returned if the repository already has commits.
+func InitializeRepository(ctx context.Context, repo UserRepository, branch, message string, files []CommitFile) (Commit, error) {
+	if len(branch) == 0 || len(files) == 0 {
+		return nil, fmt.Errorf("a branch and at least one file are required: %w", ErrInvalidArgument)
+	}
+	// Make sure the repository is empty, so the initial commit doesn't end up on top of existing history
+	_, err := repo.Commits().ListPage(ctx, branch, 1, 0)
+	if err == nil {
+		return nil, fmt.Errorf("repository %s isn't empty: %w", repo.Repository().String(), ErrAlreadyExists)
+	}
+	if !errors.Is(err, ErrEmptyRepository) {
+		return nil, err
+	}
+
+	commit, err := repo.Commits().Create(ctx, branch, message, files)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.Branches().SetDefault(ctx, branch); err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
@@ -17,8 +17,12 @@ limitations under the License.
 package gitprovider
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
+
+	"github.com/fluxcd/go-git-providers/validation"
 )
 
 func TestValidateAndDefaultInfo(t *testing.T) {
@@ -57,3 +61,100 @@ func TestValidateAndDefaultInfo(t *testing.T) {
 		})
 	}
 }
+
+// fakeInitRepository embeds the UserRepository interface, and only implements what
+// InitializeRepository uses.
+type fakeInitRepository struct {
+	UserRepository
+
+	commits  *fakeInitCommitClient
+	branches *fakeInitBranchClient
+}
+
+func (r *fakeInitRepository) Repository() RepositoryRef {
+	return UserRepositoryRef{UserRef: UserRef{Domain: "example.com", UserLogin: "user"}, RepositoryName: "repo"}
+}
+
+func (r *fakeInitRepository) Commits() CommitClient { return r.commits }
+
+func (r *fakeInitRepository) Branches() BranchClient { return r.branches }
+
+type fakeInitCommitClient struct {
+	CommitClient
+
+	listErr       error
+	createdBranch string
+}
+
+func (c *fakeInitCommitClient) ListPage(context.Context, string, int, int, ...CommitListOption) ([]Commit, error) {
+	return nil, c.listErr
+}
+
+func (c *fakeInitCommitClient) Create(_ context.Context, branch string, _ string, _ []CommitFile) (Commit, error) {
+	c.createdBranch = branch
+	return nil, nil
+}
+
+type fakeInitBranchClient struct {
+	BranchClient
+
+	defaultBranch string
+}
+
+func (c *fakeInitBranchClient) SetDefault(_ context.Context, branch string) error {
+	c.defaultBranch = branch
+	return nil
+}
+
+func TestInitializeRepository(t *testing.T) {
+	files := []CommitFile{{Path: StringVar("README.md"), Content: StringVar("# repo")}}
+	tests := []struct {
+		name         string
+		listErr      error
+		branch       string
+		files        []CommitFile
+		expectedErrs []error
+	}{
+		{
+			name:    "empty repository",
+			listErr: validation.NewMultiError(errors.New("409 Git Repository is empty."), ErrEmptyRepository),
+			branch:  "trunk",
+			files:   files,
+		},
+		{
+			name:         "repository already has commits",
+			branch:       "trunk",
+			files:        files,
+			expectedErrs: []error{ErrAlreadyExists},
+		},
+		{
+			name:         "other list error",
+			listErr:      ErrNotFound,
+			branch:       "trunk",
+			files:        files,
+			expectedErrs: []error{ErrNotFound},
+		},
+		{
+			name:         "no files",
+			listErr:      ErrEmptyRepository,
+			branch:       "trunk",
+			expectedErrs: []error{ErrInvalidArgument},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeInitRepository{
+				commits:  &fakeInitCommitClient{listErr: tt.listErr},
+				branches: &fakeInitBranchClient{},
+			}
+			_, err := InitializeRepository(context.Background(), repo, tt.branch, "Initial commit", tt.files)
+			validation.TestExpectErrors(t, "InitializeRepository", err, tt.expectedErrs...)
+			if len(tt.expectedErrs) != 0 {
+				return
+			}
+			if repo.commits.createdBranch != tt.branch || repo.branches.defaultBranch != tt.branch {
+				t.Errorf("InitializeRepository() created branch %q with default %q, want %q", repo.commits.createdBranch, repo.branches.defaultBranch, tt.branch)
+			}
+		})
+	}
+}
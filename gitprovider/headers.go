@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"net/http"
+)
+
+// headersTransport is a http.RoundTripper that sets static headers, and the User-Agent, on every
+// request. The headers replace the ones set by the provider's Go client.
+type headersTransport struct {
+	header    http.Header
+	userAgent string
+	next      http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *headersTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the given request
+	req = req.Clone(req.Context())
+	for k, v := range t.header {
+		req.Header[k] = v
+	}
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// headersTransportFunc returns a ChainableRoundTripperFunc that sets the header and userAgent,
+// if not empty, on every request.
+func headersTransportFunc(header http.Header, userAgent string) ChainableRoundTripperFunc {
+	return func(in http.RoundTripper) http.RoundTripper {
+		if in == nil {
+			in = http.DefaultTransport
+		}
+		return &headersTransport{header: header, userAgent: userAgent, next: in}
+	}
+}
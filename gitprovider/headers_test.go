@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeadersTransport(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer srv.Close()
+
+	opts, err := MakeClientOptions(
+		WithOAuth2Token("token"),
+		WithUserAgent("my-operator/1.0"),
+		WithHeaders(http.Header{"sudo": {"admin"}, "Proxy-Authorization": {"Basic cHJveHk6cHJveHk="}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := BuildClientFromTransportChain(opts.GetTransportChain())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Set by the provider's Go client, and replaced
+	req.Header.Set("User-Agent", "go-github")
+	req.Header.Set("Sudo", "someone")
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	want := map[string]string{
+		"User-Agent":          "my-operator/1.0",
+		"Sudo":                "admin",
+		"Proxy-Authorization": "Basic cHJveHk6cHJveHk=",
+		"Authorization":       "Bearer token",
+	}
+	for k, v := range want {
+		if got.Get(k) != v || len(got.Values(k)) != 1 {
+			t.Errorf("request header %s = %v, want %q", k, got.Values(k), v)
+		}
+	}
+	// The request of the caller isn't modified
+	if req.Header.Get("User-Agent") != "go-github" || req.Header.Get("Sudo") != "someone" {
+		t.Errorf("the caller's request was modified: %v", req.Header)
+	}
+}
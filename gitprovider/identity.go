@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identity is the account a client's token authenticates as.
+type Identity struct {
+	// ID is the provider-assigned ID of the account, e.g. the numeric user ID.
+	ID string `json:"id"`
+
+	// Login is the username of the account.
+	Login string `json:"login"`
+
+	// Bot is true for bot accounts, e.g. GitHub App bots or GitLab project and group access token bots.
+	Bot bool `json:"bot"`
+}
+
+// Actor is the account which caused an event, e.g. the sender of a webhook delivery.
+// Either the ID or the Login may be empty, if the event doesn't carry it.
+type Actor struct {
+	// ID is the provider-assigned ID of the account.
+	ID string `json:"id,omitempty"`
+
+	// Login is the username of the account.
+	Login string `json:"login,omitempty"`
+}
+
+// EventActor returns the actor itself, so that an Actor can be passed to Client.IsOwnAction.
+func (a Actor) EventActor() Actor {
+	return a
+}
+
+// ActorEvent is an event caused by an account, e.g. a normalized webhook event.
+type ActorEvent interface {
+	// EventActor returns the account which caused the event.
+	EventActor() Actor
+}
+
+// IsActor returns true if the actor is the identity. The IDs are compared if both are known,
+// the logins (case-insensitively) otherwise.
+func (i Identity) IsActor(a Actor) bool {
+	if i.ID != "" && a.ID != "" {
+		return i.ID == a.ID
+	}
+	return a.Login != "" && strings.EqualFold(i.Login, a.Login)
+}
+
+// TokenInfo describes the token a client authenticates with, as far as the provider discloses it.
+type TokenInfo struct {
+	// Principal is the account the token authenticates as.
+	Principal Identity `json:"principal"`
+
+	// Scopes are the scopes granted to the token, e.g. "repo" for classic GitHub personal access
+	// tokens or "api" for GitLab tokens. They are only set if ScopesKnown is true.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// ScopesKnown is false if the provider doesn't disclose the scopes of the token, e.g. for GitHub
+	// fine-grained personal access tokens and App tokens, whose permissions are per repository.
+	ScopesKnown bool `json:"scopesKnown"`
+
+	// ExpiresAt is the time the token expires, or nil if it doesn't expire or the provider doesn't
+	// disclose it.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// MissingScopes returns the given scopes the token hasn't been granted, so that tools can fail fast
+// with a clear error. Nil is returned if the scopes of the token aren't known.
+func (t TokenInfo) MissingScopes(scopes ...string) []string {
+	if !t.ScopesKnown {
+		return nil
+	}
+	granted := make(map[string]struct{}, len(t.Scopes))
+	for _, scope := range t.Scopes {
+		granted[scope] = struct{}{}
+	}
+	var missing []string
+	for _, scope := range scopes {
+		if _, ok := granted[scope]; !ok {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// Expired returns true if the token has expired at the given time.
+func (t TokenInfo) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// IdentityCache caches the Identity of a client's token, which doesn't change for the lifetime of
+// the client. The zero value is ready to use. Providers embed it in their clients, to implement
+// Client.AuthenticatedUserID and Client.IsOwnAction.
+type IdentityCache struct {
+	mu       sync.Mutex
+	identity *Identity
+}
+
+// Get returns the cached identity, or calls fetch and caches its result. Errors are not cached.
+func (c *IdentityCache) Get(ctx context.Context, fetch func(ctx context.Context) (Identity, error)) (Identity, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.identity != nil {
+		return *c.identity, nil
+	}
+	identity, err := fetch(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+	c.identity = &identity
+	return identity, nil
+}
+
+// IsOwnAction returns true if the event was caused by the identity returned by Get.
+func (c *IdentityCache) IsOwnAction(ctx context.Context, event ActorEvent, fetch func(ctx context.Context) (Identity, error)) (bool, error) {
+	identity, err := c.Get(ctx, fetch)
+	if err != nil {
+		return false, err
+	}
+	return identity.IsActor(event.EventActor()), nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIdentity_IsActor(t *testing.T) {
+	identity := Identity{ID: "42", Login: "flux-bot"}
+	tests := []struct {
+		name  string
+		actor Actor
+		want  bool
+	}{
+		{name: "same ID", actor: Actor{ID: "42", Login: "renamed"}, want: true},
+		{name: "other ID", actor: Actor{ID: "43", Login: "flux-bot"}, want: false},
+		{name: "login without ID", actor: Actor{Login: "Flux-Bot"}, want: true},
+		{name: "other login without ID", actor: Actor{Login: "someone"}, want: false},
+		{name: "empty actor", actor: Actor{}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := identity.IsActor(tt.actor); got != tt.want {
+				t.Errorf("IsActor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentityCache(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	errFetch := errors.New("fetch failed")
+	fetch := func(context.Context) (Identity, error) {
+		calls++
+		if calls == 1 {
+			return Identity{}, errFetch
+		}
+		return Identity{ID: "42", Login: "flux-bot"}, nil
+	}
+
+	var c IdentityCache
+	if _, err := c.Get(ctx, fetch); !errors.Is(err, errFetch) {
+		t.Fatalf("Get() error = %v, want %v", err, errFetch)
+	}
+	for i := 0; i < 2; i++ {
+		own, err := c.IsOwnAction(ctx, Actor{ID: "42"}, fetch)
+		if err != nil || !own {
+			t.Errorf("IsOwnAction() = %v, %v, want true", own, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times, want 2", calls)
+	}
+}
+
+func TestTokenInfo_MissingScopes(t *testing.T) {
+	tests := []struct {
+		name string
+		info TokenInfo
+		want []string
+	}{
+		{name: "granted", info: TokenInfo{Scopes: []string{"repo", "read:org"}, ScopesKnown: true}, want: nil},
+		{name: "missing", info: TokenInfo{Scopes: []string{"repo"}, ScopesKnown: true}, want: []string{"read:org"}},
+		{name: "unknown scopes", info: TokenInfo{}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.MissingScopes("repo", "read:org"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MissingScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenInfo_Expired(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+	if (TokenInfo{}).Expired(now) {
+		t.Error("Expired() = true for a token without expiry")
+	}
+	if (TokenInfo{ExpiresAt: &expiresAt}).Expired(now) {
+		t.Error("Expired() = true before the expiry")
+	}
+	if !(TokenInfo{ExpiresAt: &expiresAt}).Expired(expiresAt) {
+		t.Error("Expired() = false at the expiry")
+	}
+}
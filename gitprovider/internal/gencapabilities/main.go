@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// gencapabilities generates the Go representation of the capabilities matrix in the
+// gitprovider package from its JSON source, capabilities.json.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+)
+
+// capability mirrors gitprovider.Capability, without depending on the package being generated.
+type capability struct {
+	Provider         string `json:"provider"`
+	Feature          string `json:"feature"`
+	MinServerVersion string `json:"minServerVersion,omitempty"`
+}
+
+const header = `/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by gencapabilities from %s. DO NOT EDIT.
+
+package gitprovider
+
+// Capabilities is the matrix of features supported by each provider. Use SupportedFeatures to
+// query it, or CapabilitiesJSON to export it.
+//nolint:gochecknoglobals
+var Capabilities = []Capability{
+`
+
+func main() {
+	in := flag.String("in", "capabilities.json", "the JSON source of the capabilities matrix")
+	out := flag.String("out", "zz_generated.capabilities.go", "the Go file to generate")
+	flag.Parse()
+
+	if err := generate(*in, *out); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(in, out string) error {
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	var caps []capability
+	if err := json.Unmarshal(data, &caps); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", in, err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, header, in)
+	seen := map[capability]struct{}{}
+	for _, c := range caps {
+		if c.Provider == "" || c.Feature == "" {
+			return fmt.Errorf("provider and feature are required, got %+v", c)
+		}
+		key := capability{Provider: c.Provider, Feature: c.Feature}
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("duplicate capability %s/%s", c.Provider, c.Feature)
+		}
+		seen[key] = struct{}{}
+
+		fmt.Fprintf(&buf, "\t{Provider: %q, Feature: %s", c.Provider, featureConstName(c.Feature))
+		if c.MinServerVersion != "" {
+			fmt.Fprintf(&buf, ", MinServerVersion: %q", c.MinServerVersion)
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, src, 0o644) //nolint:gosec
+}
+
+// featureConstName returns the name of the Feature constant for the given value,
+// e.g. FeatureOrgRepositories for "org-repositories".
+func featureConstName(feature string) string {
+	name := "Feature"
+	for _, part := range strings.Split(feature, "-") {
+		if part != "" {
+			name += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return name
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import "context"
+
+// OrgRepositoryPageFunc fetches the page of repositories at the given cursor, and returns the
+// cursor of the next page, or 0 if it was the last page. The first page is fetched with cursor 0.
+// The cursor is opaque to the iterator, e.g. the page number or the offset of the first item.
+type OrgRepositoryPageFunc func(ctx context.Context, cursor int) (repos []OrgRepository, next int, err error)
+
+// UserRepositoryPageFunc fetches the page of repositories at the given cursor, and returns the
+// cursor of the next page, or 0 if it was the last page. The first page is fetched with cursor 0.
+// The cursor is opaque to the iterator, e.g. the page number or the offset of the first item.
+type UserRepositoryPageFunc func(ctx context.Context, cursor int) (repos []UserRepository, next int, err error)
+
+// pager keeps track of the pages fetched by an iterator.
+type pager struct {
+	ctx     context.Context
+	cursor  int
+	started bool
+	err     error
+}
+
+// more returns true if there is a page left to fetch.
+func (p *pager) more() bool {
+	return p.err == nil && (!p.started || p.cursor != 0)
+}
+
+// fetched records the result of fetching a page, and returns true if it succeeded.
+func (p *pager) fetched(next int, err error) bool {
+	p.started = true
+	p.cursor = next
+	p.err = err
+	return err == nil
+}
+
+// OrgRepositoryIterator iterates over the repositories of an organization, fetching one page at a
+// time when the previous one is used up. Unlike OrgRepositoriesClient.List, only one page is held
+// in memory, which suits organizations with tens of thousands of repositories:
+//
+//	it := c.OrgRepositories().ListIter(ctx, orgRef)
+//	for it.Next() {
+//		repo := it.Repository()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type OrgRepositoryIterator struct {
+	pager
+	fetch OrgRepositoryPageFunc
+	page  []OrgRepository
+	repo  OrgRepository
+}
+
+// NewOrgRepositoryIterator returns an iterator over the repositories fetched by fetch, which is
+// called with ctx. It's used by the providers to implement OrgRepositoriesClient.ListIter.
+func NewOrgRepositoryIterator(ctx context.Context, fetch OrgRepositoryPageFunc) *OrgRepositoryIterator {
+	return &OrgRepositoryIterator{pager: pager{ctx: ctx}, fetch: fetch}
+}
+
+// Next advances the iterator to the next repository, fetching the next page if needed. It returns
+// false when there are no more repositories, or fetching a page failed, see Err.
+func (it *OrgRepositoryIterator) Next() bool {
+	for len(it.page) == 0 {
+		if !it.more() {
+			it.repo = nil
+			return false
+		}
+		page, next, err := it.fetch(it.ctx, it.cursor)
+		if !it.fetched(next, err) {
+			it.repo = nil
+			return false
+		}
+		it.page = page
+	}
+	it.repo, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Repository returns the current repository, i.e. the one Next advanced to.
+func (it *OrgRepositoryIterator) Repository() OrgRepository {
+	return it.repo
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *OrgRepositoryIterator) Err() error {
+	return it.err
+}
+
+// UserRepositoryIterator iterates over the repositories of a user, fetching one page at a time
+// when the previous one is used up. Unlike UserRepositoriesClient.List, only one page is held in
+// memory. It's used like OrgRepositoryIterator.
+type UserRepositoryIterator struct {
+	pager
+	fetch UserRepositoryPageFunc
+	page  []UserRepository
+	repo  UserRepository
+}
+
+// NewUserRepositoryIterator returns an iterator over the repositories fetched by fetch, which is
+// called with ctx. It's used by the providers to implement UserRepositoriesClient.ListIter.
+func NewUserRepositoryIterator(ctx context.Context, fetch UserRepositoryPageFunc) *UserRepositoryIterator {
+	return &UserRepositoryIterator{pager: pager{ctx: ctx}, fetch: fetch}
+}
+
+// Next advances the iterator to the next repository, fetching the next page if needed. It returns
+// false when there are no more repositories, or fetching a page failed, see Err.
+func (it *UserRepositoryIterator) Next() bool {
+	for len(it.page) == 0 {
+		if !it.more() {
+			it.repo = nil
+			return false
+		}
+		page, next, err := it.fetch(it.ctx, it.cursor)
+		if !it.fetched(next, err) {
+			it.repo = nil
+			return false
+		}
+		it.page = page
+	}
+	it.repo, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Repository returns the current repository, i.e. the one Next advanced to.
+func (it *UserRepositoryIterator) Repository() UserRepository {
+	return it.repo
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *UserRepositoryIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testOrgRepository struct {
+	OrgRepository
+	name string
+}
+
+func TestOrgRepositoryIterator(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name        string
+		pages       map[int][]string
+		next        map[int]int
+		failAt      int
+		want        []string
+		wantCursors []int
+		wantErr     error
+	}{
+		{
+			name:        "no repositories",
+			pages:       map[int][]string{},
+			failAt:      -1,
+			wantCursors: []int{0},
+		},
+		{
+			name:        "one page",
+			pages:       map[int][]string{0: {"a", "b"}},
+			failAt:      -1,
+			want:        []string{"a", "b"},
+			wantCursors: []int{0},
+		},
+		{
+			name:        "pages linked by cursors, with an empty page",
+			pages:       map[int][]string{0: {"a", "b"}, 30: {}, 60: {"c"}},
+			next:        map[int]int{0: 30, 30: 60},
+			failAt:      -1,
+			want:        []string{"a", "b", "c"},
+			wantCursors: []int{0, 30, 60},
+		},
+		{
+			name:        "failing page",
+			pages:       map[int][]string{0: {"a"}, 2: {"b"}},
+			next:        map[int]int{0: 2, 2: 3},
+			failAt:      2,
+			want:        []string{"a"},
+			wantCursors: []int{0, 2},
+			wantErr:     errBoom,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cursors []int
+			it := NewOrgRepositoryIterator(context.Background(), func(_ context.Context, cursor int) ([]OrgRepository, int, error) {
+				cursors = append(cursors, cursor)
+				if cursor == tt.failAt {
+					return nil, 0, errBoom
+				}
+				var repos []OrgRepository
+				for _, name := range tt.pages[cursor] {
+					repos = append(repos, testOrgRepository{name: name})
+				}
+				return repos, tt.next[cursor], nil
+			})
+			var got []string
+			for it.Next() {
+				got = append(got, it.Repository().(testOrgRepository).name)
+			}
+			// Next keeps returning false once done, without fetching again
+			if it.Next() || it.Repository() != nil {
+				t.Errorf("Next() = true after the iteration ended")
+			}
+			if !errors.Is(it.Err(), tt.wantErr) {
+				t.Errorf("Err() = %v, want %v", it.Err(), tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("iterated over %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(cursors, tt.wantCursors) {
+				t.Errorf("fetched the pages at %v, want %v", cursors, tt.wantCursors)
+			}
+		})
+	}
+}
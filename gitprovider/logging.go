@@ -0,0 +1,62 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// loggingTransport is a http.RoundTripper that logs every request sent to the Git provider
+// at debug level (V(1)), including the method, path, response status and latency.
+type loggingTransport struct {
+	log  logr.Logger
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	keysAndValues := []interface{}{
+		"method", req.Method,
+		"path", req.URL.Path,
+		"latency", time.Since(start),
+	}
+	if annotations := RequestAnnotations(req.Context()); annotations != nil {
+		keysAndValues = append(keysAndValues, "annotations", annotations)
+	}
+	if err != nil {
+		t.log.V(1).Info("HTTP request failed", append(keysAndValues, "error", Redact(err.Error()))...)
+		return resp, err
+	}
+	t.log.V(1).Info("HTTP request", append(keysAndValues, "status", resp.StatusCode)...)
+	return resp, nil
+}
+
+// loggingTransportFunc returns a ChainableRoundTripperFunc that logs all requests to log.
+func loggingTransportFunc(log logr.Logger) ChainableRoundTripperFunc {
+	return func(in http.RoundTripper) http.RoundTripper {
+		if in == nil {
+			in = http.DefaultTransport
+		}
+		return &loggingTransport{log: log, next: in}
+	}
+}
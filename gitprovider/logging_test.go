@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+)
+
+func TestLoggingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	var lines []string
+	log := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{Verbosity: 1})
+
+	opts, err := MakeClientOptions(WithLogger(&log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := BuildClientFromTransportChain(opts.GetTransportChain())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRequestAnnotations(context.Background(), map[string]string{"tenant": "foo"})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v3/user", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(lines) != 1 {
+		t.Fatalf("got %d log lines, want 1: %v", len(lines), lines)
+	}
+	for _, want := range []string{`"method"="GET"`, `"path"="/api/v3/user"`, `"status"=418`, `"latency"=`, `"annotations"={"tenant":"foo"}`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("log line %q doesn't contain %s", lines[0], want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// metricsNamespace is the namespace of all metrics recorded by the provider clients.
+	metricsNamespace = "gitprovider"
+)
+
+// rateLimitRemainingHeaders are the response headers holding the number of requests left in
+// the current rate limit window; X-RateLimit-Remaining for GitHub, and RateLimit-Remaining for
+// GitLab and Bitbucket Server.
+//
+//nolint:gochecknoglobals
+var rateLimitRemainingHeaders = []string{"X-RateLimit-Remaining", "RateLimit-Remaining"}
+
+// clientMetrics holds the collectors recording the requests to the Git providers.
+type clientMetrics struct {
+	requests           *prometheus.CounterVec
+	requestDuration    *prometheus.HistogramVec
+	rateLimitRemaining *prometheus.GaugeVec
+}
+
+// newClientMetrics creates the collectors and registers them with r. If the collectors are
+// already registered, e.g. by another client, the registered ones are reused.
+func newClientMetrics(r prometheus.Registerer) (*clientMetrics, error) {
+	m := &clientMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_total",
+			Help:      "Number of requests to the Git provider API, by domain, method and status code. The code is \"error\" if no response was received.",
+		}, []string{"domain", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the requests to the Git provider API, by domain and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"domain", "method"}),
+		rateLimitRemaining: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "rate_limit_remaining",
+			Help:      "Number of requests left in the current rate limit window of the Git provider API, by domain.",
+		}, []string{"domain"}),
+	}
+
+	var ok bool
+	c, err := registerOrReuse(r, m.requests)
+	if err == nil {
+		m.requests, ok = c.(*prometheus.CounterVec)
+	}
+	if ok {
+		c, err = registerOrReuse(r, m.requestDuration)
+		m.requestDuration, ok = c.(*prometheus.HistogramVec)
+	}
+	if ok {
+		c, err = registerOrReuse(r, m.rateLimitRemaining)
+		m.rateLimitRemaining, ok = c.(*prometheus.GaugeVec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("a different collector is already registered as %T: %w", c, ErrInvalidClientOptions)
+	}
+	return m, nil
+}
+
+// registerOrReuse registers c with r, or returns the equal collector that is already registered.
+func registerOrReuse(r prometheus.Registerer, c prometheus.Collector) (prometheus.Collector, error) {
+	if err := r.Register(c); err != nil {
+		are := prometheus.AlreadyRegisteredError{}
+		if errors.As(err, &are) {
+			return are.ExistingCollector, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// metricsTransport is a http.RoundTripper recording metrics for every request to the Git provider.
+type metricsTransport struct {
+	metrics *clientMetrics
+	next    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	domain := req.URL.Host
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.metrics.requestDuration.WithLabelValues(domain, req.Method).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		t.metrics.requests.WithLabelValues(domain, req.Method, "error").Inc()
+		return resp, err
+	}
+	t.metrics.requests.WithLabelValues(domain, req.Method, strconv.Itoa(resp.StatusCode)).Inc()
+	for _, header := range rateLimitRemainingHeaders {
+		if remaining, err := strconv.ParseFloat(resp.Header.Get(header), 64); err == nil {
+			t.metrics.rateLimitRemaining.WithLabelValues(domain).Set(remaining)
+			break
+		}
+	}
+	return resp, nil
+}
+
+// metricsTransportFunc returns a ChainableRoundTripperFunc that records the requests in m.
+func metricsTransportFunc(m *clientMetrics) ChainableRoundTripperFunc {
+	return func(in http.RoundTripper) http.RoundTripper {
+		if in == nil {
+			in = http.DefaultTransport
+		}
+		return &metricsTransport{metrics: m, next: in}
+	}
+}
+
+// ReconcileOutcome is an enum specifying the result of a Reconcile() call.
+type ReconcileOutcome string
+
+const (
+	// ReconcileOutcomeCreated means that the resource didn't exist, and was created.
+	ReconcileOutcomeCreated = ReconcileOutcome("created")
+
+	// ReconcileOutcomeUpdated means that the resource existed, and was updated.
+	ReconcileOutcomeUpdated = ReconcileOutcome("updated")
+
+	// ReconcileOutcomeUnchanged means that the resource was already in the desired state.
+	ReconcileOutcomeUnchanged = ReconcileOutcome("unchanged")
+
+	// ReconcileOutcomeFailed means that the Reconcile() call returned an error.
+	ReconcileOutcomeFailed = ReconcileOutcome("failed")
+)
+
+// ReconcileMetrics counts the outcomes of Reconcile() calls, e.g. of bulk reconcilers managing
+// fleets of repositories, in the gitprovider_reconcile_outcomes_total counter, by resource and
+// outcome. It is safe for concurrent use.
+type ReconcileMetrics struct {
+	outcomes *prometheus.CounterVec
+}
+
+// NewReconcileMetrics creates the reconcile outcome counter, and registers it with r. If the
+// counter is already registered, e.g. by another bulk reconciler, the registered one is reused.
+func NewReconcileMetrics(r prometheus.Registerer) (*ReconcileMetrics, error) {
+	outcomes := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_outcomes_total",
+		Help:      "Number of Reconcile() calls, by resource (e.g. \"Repository\") and outcome (created, updated, unchanged or failed).",
+	}, []string{"resource", "outcome"})
+	c, err := registerOrReuse(r, outcomes)
+	if err != nil {
+		return nil, err
+	}
+	outcomes, ok := c.(*prometheus.CounterVec)
+	if !ok {
+		return nil, fmt.Errorf("a different collector is already registered as %T: %w", c, ErrInvalidClientOptions)
+	}
+	return &ReconcileMetrics{outcomes: outcomes}, nil
+}
+
+// Observe counts a Reconcile() call of the given resource, e.g. "Repository", with the given
+// outcome.
+func (m *ReconcileMetrics) Observe(resource string, outcome ReconcileOutcome) {
+	m.outcomes.WithLabelValues(resource, string(outcome)).Inc()
+}
+
+// Reconcile calls fn, which should make a single Reconcile() call of the given resource with the
+// passed context, and counts its outcome. The changes are recorded to tell creations and updates
+// apart, and passed on to the ChangeRecorder of ctx, if any. For example:
+//
+//	actionTaken, err := m.Reconcile(ctx, "Repository", func(ctx context.Context) (bool, error) {
+//		_, actionTaken, err := c.OrgRepositories().Reconcile(ctx, ref, req)
+//		return actionTaken, err
+//	})
+func (m *ReconcileMetrics) Reconcile(ctx context.Context, resource string, fn func(ctx context.Context) (bool, error)) (bool, error) {
+	rec := &ChangeRecorder{}
+	actionTaken, err := fn(WithChangeRecorder(ctx, rec))
+
+	outcome := ReconcileOutcomeUnchanged
+	for _, action := range rec.Actions() {
+		RecordChange(ctx, action.Change, action.APICalls...)
+		if outcome != ReconcileOutcomeCreated {
+			outcome = ReconcileOutcomeUpdated
+			if action.Type == ChangeTypeCreate {
+				outcome = ReconcileOutcomeCreated
+			}
+		}
+	}
+	switch {
+	case err != nil:
+		outcome = ReconcileOutcomeFailed
+	case actionTaken && outcome == ReconcileOutcomeUnchanged:
+		// The provider didn't record the change
+		outcome = ReconcileOutcomeUpdated
+	case !actionTaken:
+		outcome = ReconcileOutcomeUnchanged
+	}
+	m.Observe(resource, outcome)
+	return actionTaken, err
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	// Two clients share the same registry
+	var clients []*http.Client
+	for i := 0; i < 2; i++ {
+		opts, err := MakeClientOptions(WithMetrics(reg))
+		if err != nil {
+			t.Fatalf("MakeClientOptions() error = %v", err)
+		}
+		client, err := BuildClientFromTransportChain(opts.GetTransportChain())
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, client)
+	}
+
+	for _, path := range []string{"/ok", "/gone", "/ok"} {
+		for _, client := range clients {
+			resp, err := client.Get(server.URL + path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		}
+	}
+
+	m, err := newClientMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(u.Host, http.MethodGet, "200")); got != 4 {
+		t.Errorf("requests_total{code=200} = %v, want 4", got)
+	}
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(u.Host, http.MethodGet, "404")); got != 2 {
+		t.Errorf("requests_total{code=404} = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.rateLimitRemaining.WithLabelValues(u.Host)); got != 4999 {
+		t.Errorf("rate_limit_remaining = %v, want 4999", got)
+	}
+	if got := testutil.CollectAndCount(m.requestDuration); got != 1 {
+		t.Errorf("request_duration_seconds has %d series, want 1", got)
+	}
+	if _, err := reg.Gather(); err != nil {
+		t.Errorf("Gather() error = %v", err)
+	}
+}
+
+func TestReconcileMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m, err := NewReconcileMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The counter can be shared
+	if _, err := NewReconcileMetrics(reg); err != nil {
+		t.Fatal(err)
+	}
+
+	ref := OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "org"}, RepositoryName: "repo"}
+	reconcile := func(changeType ChangeType, actionTaken bool, err error) func(ctx context.Context) (bool, error) {
+		return func(ctx context.Context) (bool, error) {
+			if actionTaken && changeType != "" {
+				RecordChange(ctx, Change{Type: changeType, Resource: "Repository", Repository: ref})
+			}
+			return actionTaken, err
+		}
+	}
+	outer := &ChangeRecorder{}
+	ctx := WithChangeRecorder(context.Background(), outer)
+	for _, fn := range []func(ctx context.Context) (bool, error){
+		reconcile(ChangeTypeCreate, true, nil),
+		reconcile(ChangeTypeUpdate, true, nil),
+		reconcile("", false, nil),
+		reconcile("", false, nil),
+		reconcile("", true, errors.New("boom")),
+	} {
+		_, _ = m.Reconcile(ctx, "Repository", fn)
+	}
+
+	for outcome, want := range map[ReconcileOutcome]float64{
+		ReconcileOutcomeCreated:   1,
+		ReconcileOutcomeUpdated:   1,
+		ReconcileOutcomeUnchanged: 2,
+		ReconcileOutcomeFailed:    1,
+	} {
+		if got := testutil.ToFloat64(m.outcomes.WithLabelValues("Repository", string(outcome))); got != want {
+			t.Errorf("%s outcomes = %v, want %v", outcome, got, want)
+		}
+	}
+	if got := len(outer.Actions()); got != 2 {
+		t.Errorf("outer recorder got %d actions, want 2", got)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package oidc exchanges a workload identity token, e.g. the OIDC token of a Kubernetes service
+// account, for a short-lived Git provider access token using OAuth 2.0 Token Exchange (RFC 8693).
+// This removes the need for static personal access tokens in clusters.
+//
+// The exchange is done by a token endpoint trusting the issuer of the workload identity token,
+// e.g. a self-hosted GitLab OAuth broker or Vault. The returned oauth2.TokenSource refreshes the
+// access token when it expires, and plugs into the provider clients with
+// gitprovider.WithTokenSource:
+//
+//	ts := oidc.NewTokenSource(ctx, oidc.Config{
+//		TokenURL: "https://sts.example.com/token",
+//		Audience: "gitlab.example.com",
+//	})
+//	c, err := gitlab.NewClient("", "oauth2", gitprovider.WithDomain("gitlab.example.com"), gitprovider.WithTokenSource(ts))
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// DefaultServiceAccountTokenFile is the path of the projected Kubernetes service account token.
+	DefaultServiceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	// GrantTypeTokenExchange is the OAuth 2.0 grant type of RFC 8693.
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	// TokenTypeJWT identifies a JWT, like an OIDC ID token, as the subject token.
+	TokenTypeJWT = "urn:ietf:params:oauth:token-type:jwt"
+	// TokenTypeAccessToken identifies an OAuth 2.0 access token, which is requested by default.
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// ErrExchangeFailed is returned, wrapped, if the token endpoint rejected the exchange.
+var ErrExchangeFailed = errors.New("token exchange failed")
+
+// Config configures the exchange of the workload identity token.
+type Config struct {
+	// TokenURL is the token endpoint performing the exchange.
+	// +required
+	TokenURL string
+
+	// SubjectTokenFile is the file the workload identity token is read from. It is read for
+	// every exchange, as the token is rotated by the kubelet.
+	// Default: DefaultServiceAccountTokenFile
+	SubjectTokenFile string
+
+	// SubjectTokenType is the type of the workload identity token. Default: TokenTypeJWT
+	SubjectTokenType string
+
+	// RequestedTokenType is the type of the token to request. Default: TokenTypeAccessToken
+	RequestedTokenType string
+
+	// Audience is the logical name of the Git provider the access token is for, e.g. its domain.
+	// +optional
+	Audience string
+
+	// Scopes are the scopes of the requested access token, e.g. "api" or "read_repository".
+	// +optional
+	Scopes []string
+
+	// ClientID and ClientSecret authenticate the client with the token endpoint, if needed.
+	// +optional
+	ClientID     string
+	ClientSecret string
+
+	// HTTPClient is used for the requests to the token endpoint. Default: http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// NewTokenSource returns an oauth2.TokenSource exchanging the workload identity token for an access
+// token. Tokens are cached until they expire. The context is used for the requests to the token endpoint.
+func NewTokenSource(ctx context.Context, cfg Config) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &exchangeTokenSource{ctx: ctx, cfg: cfg})
+}
+
+// exchangeTokenSource performs a token exchange for every call to Token.
+type exchangeTokenSource struct {
+	ctx context.Context
+	cfg Config
+}
+
+// tokenResponse is the successful response of the token endpoint.
+type tokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	RefreshToken    string `json:"refresh_token"`
+}
+
+// errorResponse is the error response of the token endpoint, see RFC 6749 section 5.2.
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token implements oauth2.TokenSource.
+func (s *exchangeTokenSource) Token() (*oauth2.Token, error) {
+	if s.cfg.TokenURL == "" {
+		return nil, fmt.Errorf("%w: token URL is required", ErrExchangeFailed)
+	}
+	tokenFile := s.cfg.SubjectTokenFile
+	if tokenFile == "" {
+		tokenFile = DefaultServiceAccountTokenFile
+	}
+	subjectToken, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the subject token: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type":           {GrantTypeTokenExchange},
+		"subject_token":        {strings.TrimSpace(string(subjectToken))},
+		"subject_token_type":   {stringOrDefault(s.cfg.SubjectTokenType, TokenTypeJWT)},
+		"requested_token_type": {stringOrDefault(s.cfg.RequestedTokenType, TokenTypeAccessToken)},
+	}
+	if s.cfg.Audience != "" {
+		form.Set("audience", s.cfg.Audience)
+	}
+	if len(s.cfg.Scopes) != 0 {
+		form.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.cfg.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(s.cfg.ClientSecret))
+	}
+
+	client := s.cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			if e.ErrorDescription != "" {
+				return nil, fmt.Errorf("%w: %s: %s", ErrExchangeFailed, e.Error, e.ErrorDescription)
+			}
+			return nil, fmt.Errorf("%w: %s", ErrExchangeFailed, e.Error)
+		}
+		return nil, fmt.Errorf("%w: unexpected status code %d", ErrExchangeFailed, resp.StatusCode)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %v", ErrExchangeFailed, err)
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("%w: no access token in the response", ErrExchangeFailed)
+	}
+
+	tok := &oauth2.Token{
+		AccessToken:  tr.AccessToken,
+		TokenType:    tr.TokenType,
+		RefreshToken: tr.RefreshToken,
+	}
+	// "N_A" is used by RFC 8693 for tokens which aren't access tokens, send them as bearer tokens
+	if strings.EqualFold(tok.TokenType, "N_A") {
+		tok.TokenType = ""
+	}
+	if tr.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+func stringOrDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
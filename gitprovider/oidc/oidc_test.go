@@ -0,0 +1,125 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewTokenSource(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("sa-token-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var exchanges int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exchanges++
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{
+			"grant_type":           GrantTypeTokenExchange,
+			"subject_token_type":   TokenTypeJWT,
+			"requested_token_type": TokenTypeAccessToken,
+			"audience":             "gitlab.example.com",
+			"scope":                "api read_repository",
+		}
+		for k, v := range want {
+			if got := r.PostForm.Get(k); got != v {
+				t.Errorf("form value %s = %q, want %q", k, got, v)
+			}
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "client" || pass != "secret" {
+			t.Errorf("basic auth = %q, %q, want client, secret", user, pass)
+		}
+
+		switch subject := r.PostForm.Get("subject_token"); subject {
+		case "sa-token-1", "sa-token-2":
+			// Expire within the oauth2 expiry delta for the first token, so it's exchanged again
+			expiresIn := 5
+			if subject == "sa-token-2" {
+				expiresIn = 3600
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token":      "access-" + subject,
+				"issued_token_type": TokenTypeAccessToken,
+				"token_type":        "Bearer",
+				"expires_in":        expiresIn,
+			})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "token expired"})
+		}
+	}))
+	defer srv.Close()
+
+	cfg := Config{
+		TokenURL:         srv.URL,
+		SubjectTokenFile: tokenFile,
+		Audience:         "gitlab.example.com",
+		Scopes:           []string{"api", "read_repository"},
+		ClientID:         "client",
+		ClientSecret:     "secret",
+	}
+	ts := NewTokenSource(context.Background(), cfg)
+
+	tok, err := ts.Token()
+	if err != nil || tok.AccessToken != "access-sa-token-1" {
+		t.Fatalf("Token() = %v, %v, want access-sa-token-1", tok, err)
+	}
+
+	// The subject token is rotated, and read again for the next exchange
+	if err := os.WriteFile(tokenFile, []byte("sa-token-2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		tok, err = ts.Token()
+		if err != nil || tok.AccessToken != "access-sa-token-2" || tok.Type() != "Bearer" {
+			t.Fatalf("Token() = %v, %v, want access-sa-token-2", tok, err)
+		}
+	}
+	if !tok.Expiry.After(time.Now().Add(time.Hour - time.Minute)) {
+		t.Errorf("Token() expiry = %v, want in an hour", tok.Expiry)
+	}
+	if exchanges != 2 {
+		t.Errorf("got %d exchanges, want 2, the second token is reused", exchanges)
+	}
+
+	// Rejected exchanges return the error of the token endpoint
+	if err := os.WriteFile(tokenFile, []byte("sa-token-3"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewTokenSource(context.Background(), cfg).Token()
+	if !errors.Is(err, ErrExchangeFailed) || err.Error() != "token exchange failed: invalid_grant: token expired" {
+		t.Errorf("Token() error = %v, want the invalid_grant error", err)
+	}
+
+	// A missing subject token is reported
+	cfg.SubjectTokenFile = filepath.Join(t.TempDir(), "missing")
+	if _, err := NewTokenSource(context.Background(), cfg).Token(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Token() error = %v, want %v", err, os.ErrNotExist)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultOperationPollInterval is the time Operation.Wait waits between status requests, unless
+// given to NewOperation.
+const DefaultOperationPollInterval = 2 * time.Second
+
+// OperationState is the state of a long-running operation of a provider.
+type OperationState string
+
+const (
+	// OperationStatePending means the provider didn't start the operation yet.
+	OperationStatePending = OperationState("pending")
+	// OperationStateRunning means the operation is in progress.
+	OperationStateRunning = OperationState("running")
+	// OperationStateSucceeded means the operation finished successfully.
+	OperationStateSucceeded = OperationState("succeeded")
+	// OperationStateFailed means the operation finished with an error.
+	OperationStateFailed = OperationState("failed")
+)
+
+// Done returns whether the operation finished, successfully or not.
+func (s OperationState) Done() bool {
+	return s == OperationStateSucceeded || s == OperationStateFailed
+}
+
+// OperationStatus is the status of a long-running operation at a point in time.
+type OperationStatus struct {
+	// State is the state of the operation.
+	State OperationState `json:"state"`
+
+	// Progress is the completed percentage of the operation, from 0 to 100. It's nil if the
+	// provider doesn't report progress.
+	Progress *int `json:"progress,omitempty"`
+
+	// Message describes the status, e.g. the error of a failed operation, as reported by the provider.
+	Message string `json:"message,omitempty"`
+
+	// RetryAfter is the time Wait waits before the next status request, instead of the interval
+	// of the Operation, e.g. to back off. Zero means the interval.
+	RetryAfter time.Duration `json:"retryAfter,omitempty"`
+}
+
+// Operation is a long-running operation which providers run asynchronously, e.g. imports,
+// mirror pulls or merges. Calls starting such operations return an Operation, rather than
+// polling for the result themselves, so the caller decides whether and how long to wait.
+type Operation interface {
+	// Status requests the current status of the operation from the provider.
+	Status(ctx context.Context) (OperationStatus, error)
+
+	// Wait blocks until the operation is done, requesting its status periodically, and returns
+	// the final status. If the operation failed, the error wraps ErrOperationFailed. If ctx is
+	// done before that, ctx.Err() is returned.
+	Wait(ctx context.Context) (OperationStatus, error)
+}
+
+// OperationStatusFunc requests the current status of an operation from the provider.
+type OperationStatusFunc func(ctx context.Context) (OperationStatus, error)
+
+// NewOperation returns an Operation which requests its status with fn, every interval in Wait,
+// using clock to wait. If clock is nil, RealClock is used, and if interval isn't positive,
+// DefaultOperationPollInterval is.
+func NewOperation(clock Clock, interval time.Duration, fn OperationStatusFunc) Operation {
+	if interval <= 0 {
+		interval = DefaultOperationPollInterval
+	}
+	return &polledOperation{clock: ClockOrDefault(clock), interval: interval, status: fn}
+}
+
+// NewCompletedOperation returns an Operation which is done already with the given status, for
+// providers which run the operation synchronously.
+func NewCompletedOperation(status OperationStatus) Operation {
+	return &polledOperation{
+		clock: RealClock{},
+		status: func(context.Context) (OperationStatus, error) {
+			return status, nil
+		},
+	}
+}
+
+// polledOperation implements Operation by polling its status.
+type polledOperation struct {
+	clock    Clock
+	interval time.Duration
+	status   OperationStatusFunc
+}
+
+// Status requests the current status of the operation from the provider.
+func (o *polledOperation) Status(ctx context.Context) (OperationStatus, error) {
+	return o.status(ctx)
+}
+
+// Wait requests the status of the operation every interval, or after the RetryAfter of the last
+// status, until it's done.
+func (o *polledOperation) Wait(ctx context.Context) (OperationStatus, error) {
+	for {
+		status, err := o.status(ctx)
+		if err != nil {
+			return status, err
+		}
+		if status.State == OperationStateFailed {
+			return status, fmt.Errorf("%s: %w", status.Message, ErrOperationFailed)
+		}
+		if status.State.Done() {
+			return status, nil
+		}
+		interval := o.interval
+		if status.RetryAfter > 0 {
+			interval = status.RetryAfter
+		}
+		if err := o.clock.Sleep(ctx, interval); err != nil {
+			return status, err
+		}
+	}
+}
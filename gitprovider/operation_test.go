@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// sleepRecorder is a Clock which records the sleeps, without sleeping.
+type sleepRecorder struct {
+	RealClock
+	sleeps []time.Duration
+}
+
+func (c *sleepRecorder) Sleep(ctx context.Context, d time.Duration) error {
+	c.sleeps = append(c.sleeps, d)
+	return ctx.Err()
+}
+
+func TestOperation_Wait(t *testing.T) {
+	progress := func(p int) *int { return &p }
+	tests := []struct {
+		name       string
+		states     []OperationStatus
+		wantState  OperationState
+		wantSleeps int
+		wantErr    error
+	}{
+		{
+			name: "succeeded",
+			states: []OperationStatus{
+				{State: OperationStatePending},
+				{State: OperationStateRunning, Progress: progress(50)},
+				{State: OperationStateSucceeded, Progress: progress(100)},
+			},
+			wantState:  OperationStateSucceeded,
+			wantSleeps: 2,
+		},
+		{
+			name: "failed",
+			states: []OperationStatus{
+				{State: OperationStateRunning},
+				{State: OperationStateFailed, Message: "import failed"},
+			},
+			wantState:  OperationStateFailed,
+			wantSleeps: 1,
+			wantErr:    ErrOperationFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &sleepRecorder{}
+			i := 0
+			op := NewOperation(clock, time.Second, func(context.Context) (OperationStatus, error) {
+				status := tt.states[i]
+				i++
+				return status, nil
+			})
+			status, err := op.Wait(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Wait() error = %v, want %v", err, tt.wantErr)
+			}
+			if status.State != tt.wantState {
+				t.Errorf("Wait() state = %q, want %q", status.State, tt.wantState)
+			}
+			if len(clock.sleeps) != tt.wantSleeps {
+				t.Errorf("Wait() slept %v, want %d times", clock.sleeps, tt.wantSleeps)
+			}
+		})
+	}
+}
+
+func TestOperation_WaitRetryAfter(t *testing.T) {
+	clock := &sleepRecorder{}
+	states := []OperationStatus{
+		{State: OperationStateRunning, RetryAfter: 5 * time.Second},
+		{State: OperationStateRunning},
+		{State: OperationStateSucceeded},
+	}
+	i := 0
+	op := NewOperation(clock, time.Second, func(context.Context) (OperationStatus, error) {
+		status := states[i]
+		i++
+		return status, nil
+	})
+	if _, err := op.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []time.Duration{5 * time.Second, time.Second}; !reflect.DeepEqual(clock.sleeps, want) {
+		t.Errorf("Wait() slept %v, want %v", clock.sleeps, want)
+	}
+}
+
+func TestOperation_WaitCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	op := NewOperation(&sleepRecorder{}, 0, func(context.Context) (OperationStatus, error) {
+		return OperationStatus{State: OperationStateRunning}, nil
+	})
+	if _, err := op.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestNewCompletedOperation(t *testing.T) {
+	op := NewCompletedOperation(OperationStatus{State: OperationStateSucceeded})
+	status, err := op.Wait(context.Background())
+	if err != nil || status.State != OperationStateSucceeded {
+		t.Errorf("Wait() = %+v, %v, want succeeded", status, err)
+	}
+}
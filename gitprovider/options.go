@@ -17,6 +17,9 @@ limitations under the License.
 package gitprovider
 
 import (
+	"strings"
+	"time"
+
 	"github.com/fluxcd/go-git-providers/validation"
 )
 
@@ -44,10 +47,11 @@ type RepositoryCreateOption interface {
 	ApplyToRepositoryCreateOptions(target *RepositoryCreateOptions)
 }
 
-// RepositoryCreateOptions specifies optional options when creating a repository.
+// RepositoryCreateOptions specifies optional options when creating a repository. The name of the
+// default branch of the new repository is set with RepositoryInfo.DefaultBranch.
 type RepositoryCreateOptions struct {
 	// AutoInit can be set to true in order to automatically initialize the Git repo with a
-	// README.md and optionally a license in the first commit.
+	// README.md and optionally a license and .gitignore file in the first commit.
 	// Default: nil (which means "false, don't create")
 	AutoInit *bool
 
@@ -55,6 +59,20 @@ type RepositoryCreateOptions struct {
 	// Default: nil.
 	// Available options: See the LicenseTemplate enum.
 	LicenseTemplate *LicenseTemplate
+
+	// GitignoreTemplate lets the user specify the name of a .gitignore template of the provider,
+	// e.g. "Go", to use when AutoInit is true. Not supported by Bitbucket Server.
+	// Default: nil.
+	GitignoreTemplate *string
+
+	// TemplateRepository is an existing repository on the same instance, which the new repository
+	// is generated from, with the files of its default branch. On GitHub it must be marked as a
+	// template repository, and on GitLab it must be in a group with project templates (GitLab
+	// Premium). Bitbucket Server copies the files of the template to the initial commit instead.
+	// It can't be combined with AutoInit, LicenseTemplate or GitignoreTemplate. See also the
+	// CreateFromTemplate method of the repositories clients.
+	// Default: nil.
+	TemplateRepository RepositoryRef
 }
 
 // ApplyToRepositoryCreateOptions applies the options defined in the options struct to the
@@ -67,6 +85,12 @@ func (opts *RepositoryCreateOptions) ApplyToRepositoryCreateOptions(target *Repo
 	if opts.LicenseTemplate != nil {
 		target.LicenseTemplate = opts.LicenseTemplate
 	}
+	if opts.GitignoreTemplate != nil {
+		target.GitignoreTemplate = opts.GitignoreTemplate
+	}
+	if opts.TemplateRepository != nil {
+		target.TemplateRepository = opts.TemplateRepository
+	}
 }
 
 // ValidateOptions validates that the options are valid.
@@ -75,5 +99,277 @@ func (opts *RepositoryCreateOptions) ValidateOptions() error {
 	if opts.LicenseTemplate != nil {
 		errs.Append(ValidateLicenseTemplate(*opts.LicenseTemplate), *opts.LicenseTemplate, "LicenseTemplate")
 	}
+	if opts.GitignoreTemplate != nil && *opts.GitignoreTemplate == "" {
+		errs.Invalid(*opts.GitignoreTemplate, "GitignoreTemplate")
+	}
+	if opts.TemplateRepository != nil {
+		// The template provides the initial contents
+		if opts.AutoInit != nil && *opts.AutoInit {
+			errs.Invalid(*opts.AutoInit, "AutoInit")
+		}
+		if opts.LicenseTemplate != nil {
+			errs.Invalid(*opts.LicenseTemplate, "LicenseTemplate")
+		}
+		if opts.GitignoreTemplate != nil {
+			errs.Invalid(*opts.GitignoreTemplate, "GitignoreTemplate")
+		}
+	}
+	return errs.Error()
+}
+
+// MakeOrganizationListOptions returns an OrganizationListOptions based off the mutator functions
+// given to e.g. OrganizationsClient.List().
+// validation.ErrFieldInvalid is returned if the depth is zero.
+func MakeOrganizationListOptions(opts ...OrganizationListOption) (OrganizationListOptions, error) {
+	o := &OrganizationListOptions{}
+	for _, opt := range opts {
+		opt.ApplyToOrganizationListOptions(o)
+	}
+	return *o, o.ValidateOptions()
+}
+
+// OrganizationListOption is an interface for applying options to when listing organizations.
+type OrganizationListOption interface {
+	// ApplyToOrganizationListOptions should apply relevant options to the target.
+	ApplyToOrganizationListOptions(target *OrganizationListOptions)
+}
+
+// OrganizationListOptions specifies optional options when listing organizations.
+type OrganizationListOptions struct {
+	// Depth is the number of levels of sub-organizations to return. With a depth of 1, only the
+	// top-level organizations (for List) or the immediate children (for Children) are returned.
+	// A negative depth traverses the full tree of sub-organizations.
+	// Default: nil (List returns all organizations the user has access to, Children uses a depth of 1)
+	Depth *int
+}
+
+// ApplyToOrganizationListOptions applies the options defined in the options struct to the
+// target struct that is being completed.
+func (opts *OrganizationListOptions) ApplyToOrganizationListOptions(target *OrganizationListOptions) {
+	// Go through each field in opts, and apply it to target if set
+	if opts.Depth != nil {
+		target.Depth = opts.Depth
+	}
+}
+
+// ValidateOptions validates that the options are valid.
+func (opts *OrganizationListOptions) ValidateOptions() error {
+	errs := validation.New("OrganizationListOptions")
+	if opts.Depth != nil && *opts.Depth == 0 {
+		errs.Invalid(*opts.Depth, "Depth")
+	}
 	return errs.Error()
 }
+
+// GetDepth returns the depth to traverse, 1 if unset, or a negative value for the full tree.
+func (opts *OrganizationListOptions) GetDepth() int {
+	if opts.Depth == nil {
+		return 1
+	}
+	return *opts.Depth
+}
+
+// MakeCommitListOptions returns a CommitListOptions based off the mutator functions
+// given to e.g. CommitClient.ListPage().
+// validation.ErrFieldInvalid is returned if Since is after Until.
+func MakeCommitListOptions(opts ...CommitListOption) (CommitListOptions, error) {
+	o := &CommitListOptions{}
+	for _, opt := range opts {
+		opt.ApplyToCommitListOptions(o)
+	}
+	return *o, o.ValidateOptions()
+}
+
+// CommitListOption is an interface for applying options to when listing commits.
+type CommitListOption interface {
+	// ApplyToCommitListOptions should apply relevant options to the target.
+	ApplyToCommitListOptions(target *CommitListOptions)
+}
+
+// CommitListOptions specifies optional filters when listing commits.
+type CommitListOptions struct {
+	// Path only returns commits touching the given file or directory.
+	// Default: nil (all commits)
+	Path *string
+
+	// Author only returns commits authored by the given email address, or username where
+	// the provider supports it.
+	// Default: nil (all authors)
+	Author *string
+
+	// Since only returns commits authored at or after the given time.
+	// Default: nil (no lower bound)
+	Since *time.Time
+
+	// Until only returns commits authored at or before the given time.
+	// Default: nil (no upper bound)
+	Until *time.Time
+
+	// WithStats populates CommitInfo.Stats with the number of added and deleted lines.
+	// On GitHub, this costs an extra request per listed commit, so the number of requests is
+	// bounded by the page size, and they run up to WithConcurrency at once.
+	// Default: false
+	WithStats *bool
+}
+
+// ApplyToCommitListOptions applies the options defined in the options struct to the
+// target struct that is being completed.
+func (opts *CommitListOptions) ApplyToCommitListOptions(target *CommitListOptions) {
+	// Go through each field in opts, and apply it to target if set
+	if opts.Path != nil {
+		target.Path = opts.Path
+	}
+	if opts.Author != nil {
+		target.Author = opts.Author
+	}
+	if opts.Since != nil {
+		target.Since = opts.Since
+	}
+	if opts.Until != nil {
+		target.Until = opts.Until
+	}
+	if opts.WithStats != nil {
+		target.WithStats = opts.WithStats
+	}
+}
+
+// ValidateOptions validates that the options are valid.
+func (opts *CommitListOptions) ValidateOptions() error {
+	errs := validation.New("CommitListOptions")
+	if opts.Since != nil && opts.Until != nil && opts.Since.After(*opts.Until) {
+		errs.Invalid(*opts.Since, "Since")
+	}
+	return errs.Error()
+}
+
+// Matches returns true if the commit with the given author name, author email and creation
+// time passes the Author, Since and Until filters. It can be used by providers which can't
+// filter server-side.
+func (opts *CommitListOptions) Matches(authorName, authorEmail string, createdAt time.Time) bool {
+	if opts.Author != nil && !strings.EqualFold(*opts.Author, authorEmail) && !strings.EqualFold(*opts.Author, authorName) {
+		return false
+	}
+	if opts.Since != nil && createdAt.Before(*opts.Since) {
+		return false
+	}
+	if opts.Until != nil && createdAt.After(*opts.Until) {
+		return false
+	}
+	return true
+}
+
+// MakeRepositoryGetOptions returns a RepositoryGetOptions based off the mutator functions
+// given to e.g. OrgRepositoriesClient.Get().
+func MakeRepositoryGetOptions(opts ...RepositoryGetOption) RepositoryGetOptions {
+	o := &RepositoryGetOptions{}
+	for _, opt := range opts {
+		opt.ApplyToRepositoryGetOptions(o)
+	}
+	return *o
+}
+
+// RepositoryGetOption is an interface for applying options to when getting repositories.
+type RepositoryGetOption interface {
+	// ApplyToRepositoryGetOptions should apply relevant options to the target.
+	ApplyToRepositoryGetOptions(target *RepositoryGetOptions)
+}
+
+// RepositoryGetOptions specifies optional data to populate when getting a repository.
+type RepositoryGetOptions struct {
+	// WithStats populates RepositoryInfo.Stats, which takes additional requests.
+	// Default: false
+	WithStats *bool
+}
+
+// ApplyToRepositoryGetOptions applies the options defined in the options struct to the
+// target struct that is being completed.
+func (opts *RepositoryGetOptions) ApplyToRepositoryGetOptions(target *RepositoryGetOptions) {
+	// Go through each field in opts, and apply it to target if set
+	if opts.WithStats != nil {
+		target.WithStats = opts.WithStats
+	}
+}
+
+// MakeRepositoryListOptions returns a RepositoryListOptions based off the mutator functions
+// given to e.g. OrgRepositoriesClient.List().
+// validation.ErrFieldEnumInvalid is returned if the visibility doesn't match known values.
+func MakeRepositoryListOptions(opts ...RepositoryListOption) (RepositoryListOptions, error) {
+	o := &RepositoryListOptions{}
+	for _, opt := range opts {
+		opt.ApplyToRepositoryListOptions(o)
+	}
+	return *o, o.ValidateOptions()
+}
+
+// RepositoryListOption is an interface for applying options to when listing repositories.
+type RepositoryListOption interface {
+	// ApplyToRepositoryListOptions should apply relevant options to the target.
+	ApplyToRepositoryListOptions(target *RepositoryListOptions)
+}
+
+// RepositoryListOptions specifies optional filters when listing repositories. Providers pass
+// the filters they support as query parameters, and apply the rest to the received list.
+type RepositoryListOptions struct {
+	// NamePrefix only returns repositories whose name starts with the given prefix, ignoring case.
+	// Default: nil (all names)
+	NamePrefix *string
+
+	// Visibility only returns repositories with the given visibility.
+	// Default: nil (all visibilities)
+	Visibility *RepositoryVisibility
+
+	// Archived only returns archived repositories if true, or repositories that aren't archived
+	// if false.
+	// Default: nil (both)
+	Archived *bool
+
+	// ActiveSince only returns repositories with activity, e.g. a push, at or after the given time.
+	// On GitHub, only pushes count, as the repositories are listed by their last push to stop early.
+	// Default: nil (no lower bound)
+	ActiveSince *time.Time
+}
+
+// ApplyToRepositoryListOptions applies the options defined in the options struct to the
+// target struct that is being completed.
+func (opts *RepositoryListOptions) ApplyToRepositoryListOptions(target *RepositoryListOptions) {
+	// Go through each field in opts, and apply it to target if set
+	if opts.NamePrefix != nil {
+		target.NamePrefix = opts.NamePrefix
+	}
+	if opts.Visibility != nil {
+		target.Visibility = opts.Visibility
+	}
+	if opts.Archived != nil {
+		target.Archived = opts.Archived
+	}
+	if opts.ActiveSince != nil {
+		target.ActiveSince = opts.ActiveSince
+	}
+}
+
+// ValidateOptions validates that the options are valid.
+func (opts *RepositoryListOptions) ValidateOptions() error {
+	errs := validation.New("RepositoryListOptions")
+	if opts.Visibility != nil {
+		errs.Append(ValidateRepositoryVisibility(*opts.Visibility), *opts.Visibility, "Visibility")
+	}
+	return errs.Error()
+}
+
+// Matches returns true if the repository with the given name, info and last activity passes
+// the filters. Providers use it for the filters they can't pass to the server.
+func (opts *RepositoryListOptions) Matches(name string, info RepositoryInfo, lastActivity time.Time) bool {
+	if opts.NamePrefix != nil && !strings.HasPrefix(strings.ToLower(name), strings.ToLower(*opts.NamePrefix)) {
+		return false
+	}
+	if opts.Visibility != nil && (info.Visibility == nil || *info.Visibility != *opts.Visibility) {
+		return false
+	}
+	if opts.Archived != nil && (info.Archived != nil && *info.Archived) != *opts.Archived {
+		return false
+	}
+	if opts.ActiveSince != nil && lastActivity.Before(*opts.ActiveSince) {
+		return false
+	}
+	return true
+}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/fluxcd/go-git-providers/validation"
 )
@@ -31,6 +32,10 @@ var (
 	partialCreateOpts1     = &RepositoryCreateOptions{AutoInit: BoolVar(false)}
 	partialCreateOpts2     = &RepositoryCreateOptions{LicenseTemplate: LicenseTemplateVar(LicenseTemplateApache2)}
 	invalidRepoCreateOpts  = &RepositoryCreateOptions{LicenseTemplate: &unknownLicenseTemplate}
+	templateCreateOpts     = &RepositoryCreateOptions{TemplateRepository: OrgRepositoryRef{
+		OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "fluxcd"},
+		RepositoryName:  "template",
+	}}
 )
 
 func TestMakeRepositoryCreateOptions(t *testing.T) {
@@ -64,6 +69,21 @@ func TestMakeRepositoryCreateOptions(t *testing.T) {
 			want:        *invalidRepoCreateOpts,
 			expectedErr: validation.ErrFieldEnumInvalid,
 		},
+		{
+			name: "template repository",
+			opts: []RepositoryCreateOption{templateCreateOpts},
+			want: *templateCreateOpts,
+		},
+		{
+			name: "template repository with auto-init",
+			opts: []RepositoryCreateOption{repoCreateOpts1, templateCreateOpts},
+			want: RepositoryCreateOptions{
+				AutoInit:           repoCreateOpts1.AutoInit,
+				LicenseTemplate:    repoCreateOpts1.LicenseTemplate,
+				TemplateRepository: templateCreateOpts.TemplateRepository,
+			},
+			expectedErr: validation.ErrFieldInvalid,
+		},
 		{
 			name: "partial options can form an unit",
 			opts: []RepositoryCreateOption{
@@ -93,3 +113,178 @@ func TestMakeRepositoryCreateOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeOrganizationListOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []OrganizationListOption
+		wantDepth   int
+		expectedErr error
+	}{
+		{
+			name:      "default depth",
+			wantDepth: 1,
+		},
+		{
+			name:      "latter overrides former",
+			opts:      []OrganizationListOption{&OrganizationListOptions{Depth: IntVar(3)}, &OrganizationListOptions{Depth: IntVar(-1)}},
+			wantDepth: -1,
+		},
+		{
+			name:        "zero depth is invalid",
+			opts:        []OrganizationListOption{&OrganizationListOptions{Depth: IntVar(0)}},
+			expectedErr: validation.ErrFieldInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MakeOrganizationListOptions(tt.opts...)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("MakeOrganizationListOptions() error = %v, wanted %v", err, tt.expectedErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.GetDepth() != tt.wantDepth {
+				t.Errorf("MakeOrganizationListOptions().GetDepth() = %d, want %d", got.GetDepth(), tt.wantDepth)
+			}
+		})
+	}
+}
+
+func TestCommitListOptions_Matches(t *testing.T) {
+	jan := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		opts      CommitListOptions
+		email     string
+		createdAt time.Time
+		want      bool
+	}{
+		{
+			name:      "no filters",
+			email:     "alice@example.com",
+			createdAt: jan,
+			want:      true,
+		},
+		{
+			name:      "author email matches case-insensitively",
+			opts:      CommitListOptions{Author: StringVar("Alice@Example.com")},
+			email:     "alice@example.com",
+			createdAt: jan,
+			want:      true,
+		},
+		{
+			name:      "other author",
+			opts:      CommitListOptions{Author: StringVar("bob@example.com")},
+			email:     "alice@example.com",
+			createdAt: jan,
+		},
+		{
+			name:      "within time range",
+			opts:      CommitListOptions{Since: &jan, Until: &mar},
+			createdAt: feb,
+			want:      true,
+		},
+		{
+			name:      "before since",
+			opts:      CommitListOptions{Since: &feb},
+			createdAt: jan,
+		},
+		{
+			name:      "after until",
+			opts:      CommitListOptions{Until: &feb},
+			createdAt: mar,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Matches("alice", tt.email, tt.createdAt); got != tt.want {
+				t.Errorf("CommitListOptions.Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MakeCommitListOptions(&CommitListOptions{Since: &mar, Until: &jan}); !errors.Is(err, validation.ErrFieldInvalid) {
+		t.Errorf("MakeCommitListOptions() error = %v, want %v", err, validation.ErrFieldInvalid)
+	}
+}
+
+func TestRepositoryListOptions_Matches(t *testing.T) {
+	jan := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	private := RepositoryInfo{Visibility: RepositoryVisibilityVar(RepositoryVisibilityPrivate)}
+	archived := RepositoryInfo{Visibility: RepositoryVisibilityVar(RepositoryVisibilityPublic), Archived: BoolVar(true)}
+	tests := []struct {
+		name string
+		opts RepositoryListOptions
+		repo string
+		info RepositoryInfo
+		want bool
+	}{
+		{
+			name: "no filters",
+			repo: "flux",
+			info: archived,
+			want: true,
+		},
+		{
+			name: "name prefix matches case-insensitively",
+			opts: RepositoryListOptions{NamePrefix: StringVar("FLUX-")},
+			repo: "flux-system",
+			info: private,
+			want: true,
+		},
+		{
+			name: "other name",
+			opts: RepositoryListOptions{NamePrefix: StringVar("flux-")},
+			repo: "podinfo",
+			info: private,
+		},
+		{
+			name: "visibility matches",
+			opts: RepositoryListOptions{Visibility: RepositoryVisibilityVar(RepositoryVisibilityPrivate)},
+			repo: "flux",
+			info: private,
+			want: true,
+		},
+		{
+			name: "other visibility",
+			opts: RepositoryListOptions{Visibility: RepositoryVisibilityVar(RepositoryVisibilityPrivate)},
+			repo: "flux",
+			info: archived,
+		},
+		{
+			name: "unset archived is not archived",
+			opts: RepositoryListOptions{Archived: BoolVar(false)},
+			repo: "flux",
+			info: private,
+			want: true,
+		},
+		{
+			name: "archived",
+			opts: RepositoryListOptions{Archived: BoolVar(false)},
+			repo: "flux",
+			info: archived,
+		},
+		{
+			name: "inactive",
+			opts: RepositoryListOptions{ActiveSince: &feb},
+			repo: "flux",
+			info: private,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Matches(tt.repo, tt.info, jan); got != tt.want {
+				t.Errorf("RepositoryListOptions.Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MakeRepositoryListOptions(&RepositoryListOptions{Visibility: RepositoryVisibilityVar("secret")}); !errors.Is(err, validation.ErrFieldEnumInvalid) {
+		t.Errorf("MakeRepositoryListOptions() error = %v, want %v", err, validation.ErrFieldEnumInvalid)
+	}
+}
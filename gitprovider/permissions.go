@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"fmt"
+)
+
+// PermissionScope is an enum specifying a kind of operation a token needs to be allowed to perform,
+// see Client.ValidatePermissions.
+type PermissionScope string
+
+const (
+	// PermissionScopeRepositoryAdmin is managing the settings, team access and collaborators of a repository.
+	PermissionScopeRepositoryAdmin = PermissionScope("repository-admin")
+	// PermissionScopeDeployKeysWrite is managing the deploy keys of a repository.
+	PermissionScopeDeployKeysWrite = PermissionScope("deploy-keys-write")
+	// PermissionScopeContentsWrite is pushing commits and managing the branches of a repository.
+	PermissionScopeContentsWrite = PermissionScope("contents-write")
+	// PermissionScopePullRequestsWrite is creating, updating and merging the pull requests of a repository.
+	PermissionScopePullRequestsWrite = PermissionScope("pull-requests-write")
+)
+
+// requiredRepositoryPermissions maps every PermissionScope to the lowest RepositoryPermission
+// allowing it, used for validation too.
+//
+//nolint:gochecknoglobals
+var requiredRepositoryPermissions = map[PermissionScope]RepositoryPermission{
+	PermissionScopeRepositoryAdmin:   RepositoryPermissionAdmin,
+	PermissionScopeDeployKeysWrite:   RepositoryPermissionAdmin,
+	PermissionScopeContentsWrite:     RepositoryPermissionPush,
+	PermissionScopePullRequestsWrite: RepositoryPermissionPush,
+}
+
+// repositoryPermissionRanks orders the RepositoryPermission values from the lowest to the highest.
+//
+//nolint:gochecknoglobals
+var repositoryPermissionRanks = map[RepositoryPermission]int{
+	RepositoryPermissionPull:     1,
+	RepositoryPermissionTriage:   2,
+	RepositoryPermissionPush:     3,
+	RepositoryPermissionMaintain: 4,
+	RepositoryPermissionAdmin:    5,
+}
+
+// ValidatePermissionScope validates a given PermissionScope.
+func ValidatePermissionScope(s PermissionScope) error {
+	if _, ok := requiredRepositoryPermissions[s]; !ok {
+		return fmt.Errorf("unknown permission scope %q: %w", s, ErrInvalidArgument)
+	}
+	return nil
+}
+
+// RequiredRepositoryPermission returns the lowest RepositoryPermission allowing the operations of
+// the scope, or an empty string if the scope isn't known.
+func (s PermissionScope) RequiredRepositoryPermission() RepositoryPermission {
+	return requiredRepositoryPermissions[s]
+}
+
+// Permission is an operation the token of a client needs to be allowed to perform on a repository,
+// checked up front with Client.ValidatePermissions.
+type Permission struct {
+	// Scope is the kind of operation.
+	Scope PermissionScope
+	// Repository is the repository the operation is performed on.
+	Repository RepositoryRef
+}
+
+// String returns e.g. "deploy-keys-write on github.com/org/repo".
+func (p Permission) String() string {
+	return fmt.Sprintf("%s on %s", p.Scope, p.Repository)
+}
+
+// RepositoryAccess is the access of a token to a repository, as probed by a provider for
+// CheckPermissions.
+type RepositoryAccess struct {
+	// Permission is the highest RepositoryPermission of the token's account on the repository.
+	Permission RepositoryPermission
+	// ReadOnly is true if the token can't write, whatever the permission of its account, e.g. a
+	// GitHub classic token without the repo scope or a GitLab token without the api scope.
+	ReadOnly bool
+	// ReadOnlyReason explains why the token is read-only, e.g. "the token lacks the api scope".
+	ReadOnlyReason string
+}
+
+// CheckPermissions implements Client.ValidatePermissions for the providers: it calls probe once
+// per repository of needs, and checks that the access it returns allows each needed scope. The
+// missing permissions and probe errors are returned as an AggregateError of the repositories;
+// errors.Is(err, ErrPermissionDenied) is true if a permission is missing.
+func CheckPermissions(ctx context.Context, needs []Permission, probe func(ctx context.Context, ref RepositoryRef) (RepositoryAccess, error)) error {
+	for _, need := range needs {
+		if err := ValidatePermissionScope(need.Scope); err != nil {
+			return err
+		}
+		if need.Repository == nil {
+			return fmt.Errorf("permission %s without repository: %w", need.Scope, ErrInvalidArgument)
+		}
+	}
+
+	type probeResult struct {
+		access RepositoryAccess
+		err    error
+	}
+	results := map[string]*probeResult{}
+	aggErr := &AggregateError{}
+	for _, need := range needs {
+		target := need.Repository.String()
+		result, ok := results[target]
+		if !ok {
+			access, err := probe(ctx, need.Repository)
+			result = &probeResult{access: access, err: err}
+			results[target] = result
+			aggErr.Add(target, err)
+		}
+		if result.err != nil {
+			continue
+		}
+
+		required := need.Scope.RequiredRepositoryPermission()
+		switch {
+		case repositoryPermissionRanks[result.access.Permission] < repositoryPermissionRanks[required]:
+			actual := result.access.Permission
+			if actual == "" {
+				actual = "no"
+			}
+			aggErr.Add(target, fmt.Errorf("%s needs the %s permission, the token has %s permission: %w", need.Scope, required, actual, ErrPermissionDenied))
+		case result.access.ReadOnly:
+			aggErr.Add(target, fmt.Errorf("%s needs write access, %s: %w", need.Scope, result.access.ReadOnlyReason, ErrPermissionDenied))
+		}
+	}
+	return aggErr.ErrorOrNil()
+}
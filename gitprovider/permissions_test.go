@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheckPermissions(t *testing.T) {
+	ctx := context.Background()
+	admin := OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "org"}, RepositoryName: "admin"}
+	push := OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "org"}, RepositoryName: "push"}
+	readOnly := OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "org"}, RepositoryName: "read-only"}
+	missing := OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "org"}, RepositoryName: "missing"}
+	calls := map[string]int{}
+	probe := func(_ context.Context, ref RepositoryRef) (RepositoryAccess, error) {
+		calls[ref.GetRepository()]++
+		switch ref.GetRepository() {
+		case "admin":
+			return RepositoryAccess{Permission: RepositoryPermissionAdmin}, nil
+		case "push":
+			return RepositoryAccess{Permission: RepositoryPermissionPush}, nil
+		case "read-only":
+			return RepositoryAccess{Permission: RepositoryPermissionAdmin, ReadOnly: true, ReadOnlyReason: "the token lacks the repo scope"}, nil
+		}
+		return RepositoryAccess{}, ErrNotFound
+	}
+
+	tests := []struct {
+		name        string
+		needs       []Permission
+		wantTargets []string
+		wantErr     error
+	}{
+		{
+			name: "allowed",
+			needs: []Permission{
+				{Scope: PermissionScopeRepositoryAdmin, Repository: admin},
+				{Scope: PermissionScopeDeployKeysWrite, Repository: admin},
+				{Scope: PermissionScopePullRequestsWrite, Repository: push},
+			},
+		},
+		{
+			name:        "missing admin",
+			needs:       []Permission{{Scope: PermissionScopeContentsWrite, Repository: push}, {Scope: PermissionScopeDeployKeysWrite, Repository: push}},
+			wantTargets: []string{push.String()},
+			wantErr:     ErrPermissionDenied,
+		},
+		{
+			name:        "read-only token",
+			needs:       []Permission{{Scope: PermissionScopeContentsWrite, Repository: readOnly}},
+			wantTargets: []string{readOnly.String()},
+			wantErr:     ErrPermissionDenied,
+		},
+		{
+			name:        "probe error",
+			needs:       []Permission{{Scope: PermissionScopeContentsWrite, Repository: missing}, {Scope: PermissionScopeRepositoryAdmin, Repository: missing}},
+			wantTargets: []string{missing.String()},
+			wantErr:     ErrNotFound,
+		},
+		{
+			name:    "unknown scope",
+			needs:   []Permission{{Scope: PermissionScope("issues-write"), Repository: admin}},
+			wantErr: ErrInvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = map[string]int{}
+			err := CheckPermissions(ctx, tt.needs, probe)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("CheckPermissions() error = %v, want %v", err, tt.wantErr)
+			}
+			for repo, n := range calls {
+				if n != 1 {
+					t.Errorf("probe of %s called %d times, want 1", repo, n)
+				}
+			}
+			if tt.wantTargets == nil {
+				return
+			}
+			aggErr := &AggregateError{}
+			if !errors.As(err, &aggErr) {
+				t.Fatalf("CheckPermissions() error = %v, want an AggregateError", err)
+			}
+			if len(aggErr.Errors) != len(tt.wantTargets) {
+				t.Fatalf("CheckPermissions() errors = %v, want errors of %v", aggErr.Errors, tt.wantTargets)
+			}
+			for i, target := range tt.wantTargets {
+				if aggErr.Errors[i].Target != target {
+					t.Errorf("CheckPermissions() error %d target = %s, want %s", i, aggErr.Errors[i].Target, target)
+				}
+			}
+		})
+	}
+}
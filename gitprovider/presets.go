@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// ProviderGitHub is the provider ID of GitHub and GitHub Enterprise, implemented by the
+	// github package.
+	ProviderGitHub = ProviderID("github")
+	// ProviderGitLab is the provider ID of GitLab, implemented by the gitlab package.
+	ProviderGitLab = ProviderID("gitlab")
+	// ProviderStash is the provider ID of Bitbucket Server (a.k.a. Stash), implemented by the
+	// stash package.
+	ProviderStash = ProviderID("stash")
+	// ProviderBitbucketCloud is the provider ID of bitbucket.org. There's no in-tree implementation.
+	ProviderBitbucketCloud = ProviderID("bitbucket")
+	// ProviderGitea is the provider ID of Gitea and Forgejo, e.g. Codeberg. There's no in-tree
+	// implementation.
+	ProviderGitea = ProviderID("gitea")
+)
+
+// DomainPreset describes a well-known public instance of a Git provider.
+type DomainPreset struct {
+	// Name is the name the preset is selected with, e.g. "github".
+	Name string `json:"name"`
+
+	// Provider is the ID of the provider of the instance.
+	Provider ProviderID `json:"provider"`
+
+	// Domain is the host name of the instance, as given to WithDomain.
+	Domain string `json:"domain"`
+
+	// APIBaseURL is the base URL of the REST API of the instance, for reference. The provider
+	// packages derive it from Domain.
+	APIBaseURL string `json:"apiBaseURL"`
+
+	// RequestsPerSecond is the client-side rate limit used by BuildPreset for instances that
+	// throttle or block clients exceeding it, unless set with WithRequestsPerSecond.
+	// Zero means unlimited.
+	RequestsPerSecond float64 `json:"requestsPerSecond,omitempty"`
+}
+
+// domainPresets are the known DomainPresets, by name.
+//
+//nolint:gochecknoglobals
+var domainPresets = map[string]DomainPreset{
+	"github": {
+		Name:       "github",
+		Provider:   ProviderGitHub,
+		Domain:     "github.com",
+		APIBaseURL: "https://api.github.com/",
+	},
+	"gitlab": {
+		Name:       "gitlab",
+		Provider:   ProviderGitLab,
+		Domain:     "gitlab.com",
+		APIBaseURL: "https://gitlab.com/api/v4/",
+	},
+	"bitbucket": {
+		Name:       "bitbucket",
+		Provider:   ProviderBitbucketCloud,
+		Domain:     "bitbucket.org",
+		APIBaseURL: "https://api.bitbucket.org/2.0/",
+	},
+	"gitea": {
+		Name:       "gitea",
+		Provider:   ProviderGitea,
+		Domain:     "gitea.com",
+		APIBaseURL: "https://gitea.com/api/v1/",
+	},
+	"codeberg": {
+		Name:       "codeberg",
+		Provider:   ProviderGitea,
+		Domain:     "codeberg.org",
+		APIBaseURL: "https://codeberg.org/api/v1/",
+		// Codeberg is run by a non-profit on limited hardware, and rate limits API clients
+		RequestsPerSecond: 2,
+	},
+}
+
+// DomainPresets returns the known DomainPresets, sorted by name.
+func DomainPresets() []DomainPreset {
+	presets := make([]DomainPreset, 0, len(domainPresets))
+	for _, p := range domainPresets {
+		presets = append(presets, p)
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+// LookupDomainPreset returns the DomainPreset with the given name, and whether it exists.
+func LookupDomainPreset(name string) (DomainPreset, bool) {
+	p, ok := domainPresets[name]
+	return p, ok
+}
+
+// BuildPreset creates a client for the instance described by the DomainPreset with the given
+// name, e.g. "codeberg", with Build. opts.Domain must be empty, as it's set from the preset.
+// The client-side rate limit of the preset is applied, unless opts set WithRequestsPerSecond.
+//
+// ErrInvalidArgument is returned for unknown presets, and ErrNoProviderSupport if no
+// ClientFactory is registered for the provider of the preset.
+func BuildPreset(name string, opts BuildOptions) (Client, error) {
+	preset, ok := LookupDomainPreset(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown domain preset %q: %w", name, ErrInvalidArgument)
+	}
+	if opts.Domain != "" {
+		return nil, fmt.Errorf("domain %q cannot be set for domain preset %q: %w", opts.Domain, name, ErrInvalidArgument)
+	}
+	opts.Domain = preset.Domain
+
+	if preset.RequestsPerSecond > 0 {
+		o, err := MakeClientOptions(opts.ClientOptions...)
+		if err != nil {
+			return nil, err
+		}
+		if o.RequestsPerSecond == nil {
+			// Don't modify the caller's slice
+			opts.ClientOptions = append(append([]ClientOption{}, opts.ClientOptions...), WithRequestsPerSecond(preset.RequestsPerSecond))
+		}
+	}
+	return Build(preset.Provider, opts)
+}
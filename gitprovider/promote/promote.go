@@ -0,0 +1,302 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package promote copies a file or directory from a commit of a source repository to a branch of
+// a target repository, e.g. to promote a release manifest from a staging to a production GitOps
+// repository. The content is verified against the Git blob SHAs reported by the source provider,
+// and the promotion commit records its provenance in trailers:
+//
+//	Promoted-From: <source repository>@<source commit SHA>
+//	Promoted-Path: <source path>
+//	Content-SHA256: <digest of the promoted content>
+package promote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+const (
+	// PromotedFromTrailer is the trailer recording the source repository and commit.
+	PromotedFromTrailer = "Promoted-From"
+	// PromotedPathTrailer is the trailer recording the promoted path in the source repository.
+	PromotedPathTrailer = "Promoted-Path"
+	// ContentSHA256Trailer is the trailer recording the digest of the promoted content.
+	ContentSHA256Trailer = "Content-SHA256"
+)
+
+var (
+	// ErrHashMismatch is returned if the downloaded content doesn't match the blob SHA reported
+	// by the source provider.
+	ErrHashMismatch = errors.New("the content doesn't match the blob SHA")
+	// ErrDigestMismatch is returned if the digest of the content isn't Promotion.ExpectedSHA256.
+	ErrDigestMismatch = errors.New("the content doesn't match the expected digest")
+)
+
+// Source is a path at a Git reference of a repository.
+type Source struct {
+	// Client is the client for the Git provider of the repository.
+	// +required
+	Client gitprovider.Client
+
+	// Repository is a gitprovider.OrgRepositoryRef or gitprovider.UserRepositoryRef.
+	// +required
+	Repository gitprovider.RepositoryRef
+
+	// Ref is the branch, tag or commit SHA the content is copied from. It's resolved to a commit
+	// SHA once, so all files come from the same commit.
+	// +required
+	Ref string
+
+	// Path is the file or directory copied. The files directly in a directory are copied.
+	// +required
+	Path string
+}
+
+// Target is a path in a branch of a repository.
+type Target struct {
+	// Client is the client for the Git provider of the repository.
+	// +required
+	Client gitprovider.Client
+
+	// Repository is a gitprovider.OrgRepositoryRef or gitprovider.UserRepositoryRef.
+	// +required
+	Repository gitprovider.RepositoryRef
+
+	// Branch is the branch the promotion is committed to.
+	// +required
+	Branch string
+
+	// Path is where the content is written. Default: Source.Path
+	// +optional
+	Path string
+}
+
+// Promotion copies the content of Source to Target.
+//
+// The content is buffered in memory and committed as text, so promotions are meant for
+// manifests and configuration, rather than large binary files. The GitLab commit API is used
+// to create files only, so files which already exist in a GitLab target can't be promoted.
+type Promotion struct {
+	// Source is where the content is copied from.
+	// +required
+	Source Source
+
+	// Target is where the content is copied to.
+	// +required
+	Target Target
+
+	// ExpectedSHA256 is the hex-encoded digest the content must have to be promoted, e.g.
+	// the digest recorded by the promotion to the previous environment.
+	// +optional
+	ExpectedSHA256 string
+
+	// Message is the subject of the commit message. Default: "Promote <path> from <repository>"
+	// +optional
+	Message string
+}
+
+// Result describes the outcome of a promotion.
+type Result struct {
+	// SourceSHA is the commit the content was copied from.
+	SourceSHA string
+
+	// SHA256 is the hex-encoded digest of the content, see Digest.
+	SHA256 string
+
+	// Commit is the commit created in the target branch, or nil if the content was up-to-date.
+	Commit gitprovider.Commit
+
+	// Updated lists the target paths of the files created or changed.
+	Updated []string
+}
+
+// Promote verifies and copies the content once.
+func (p *Promotion) Promote(ctx context.Context) (*Result, error) {
+	if p.Source.Path == "" || p.Source.Ref == "" || p.Target.Branch == "" {
+		return nil, fmt.Errorf("source path, source ref and target branch are required: %w", gitprovider.ErrInvalidArgument)
+	}
+	source, err := getRepository(ctx, p.Source.Client, p.Source.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the source repository: %w", err)
+	}
+	commits, err := source.Commits().ListPage(ctx, p.Source.Ref, 1, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %q: %w", p.Source.Ref, err)
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits at %q: %w", p.Source.Ref, gitprovider.ErrNotFound)
+	}
+	res := &Result{SourceSHA: commits[0].Get().Sha}
+
+	files, err := readVerified(ctx, source.Files(), p.Source.Path, res.SourceSHA)
+	if err != nil {
+		return nil, err
+	}
+	res.SHA256 = Digest(files)
+	if p.ExpectedSHA256 != "" && !strings.EqualFold(p.ExpectedSHA256, res.SHA256) {
+		return nil, fmt.Errorf("%w: got %s, expected %s", ErrDigestMismatch, res.SHA256, p.ExpectedSHA256)
+	}
+
+	target, err := getRepository(ctx, p.Target.Client, p.Target.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the target repository: %w", err)
+	}
+	targetPath := p.Target.Path
+	if targetPath == "" {
+		targetPath = p.Source.Path
+	}
+	var changes []gitprovider.CommitFile
+	for _, rel := range sortedKeys(files) {
+		dest := path.Join(targetPath, rel)
+		upToDate, err := hasBlob(ctx, target.Files(), dest, p.Target.Branch, files[rel])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s from the target branch: %w", dest, err)
+		}
+		if upToDate {
+			continue
+		}
+		content := files[rel]
+		changes = append(changes, gitprovider.CommitFile{Path: gitprovider.StringVar(dest), Content: &content})
+		res.Updated = append(res.Updated, dest)
+	}
+	if len(changes) == 0 {
+		return res, nil
+	}
+
+	subject := p.Message
+	if subject == "" {
+		subject = fmt.Sprintf("Promote %s from %s", p.Source.Path, p.Source.Repository.String())
+	}
+	message := fmt.Sprintf("%s\n\n%s: %s@%s\n%s: %s\n%s: %s", subject,
+		PromotedFromTrailer, p.Source.Repository.String(), res.SourceSHA,
+		PromotedPathTrailer, p.Source.Path,
+		ContentSHA256Trailer, res.SHA256)
+	res.Commit, err = target.Commits().Create(ctx, p.Target.Branch, message, changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit to the target branch: %w", err)
+	}
+	return res, nil
+}
+
+// Digest returns the hex-encoded SHA-256 digest of the content of the files by relative path.
+// The digest of a single file (with the empty path) is the digest of its content, as printed by
+// sha256sum. Otherwise, it's the digest of the sha256sum output for the files, sorted by path.
+func Digest(files map[string]string) string {
+	if content, ok := files[""]; ok && len(files) == 1 {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	h := sha256.New()
+	for _, p := range sortedKeys(files) {
+		sum := sha256.Sum256([]byte(files[p]))
+		fmt.Fprintf(h, "%s  %s\n", hex.EncodeToString(sum[:]), p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readVerified returns the content of the file or the files in the directory at p, by path
+// relative to p. Every file is checked against the blob SHA reported by the provider.
+func readVerified(ctx context.Context, fc gitprovider.FileClient, p, sha string) (map[string]string, error) {
+	content, err := openVerified(ctx, fc, p, sha)
+	if err == nil {
+		return map[string]string{"": content}, nil
+	}
+	if !errors.Is(err, gitprovider.ErrNotFound) && !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		return nil, err
+	}
+
+	// The path may be a directory
+	entries, dirErr := fc.Get(ctx, p, sha)
+	if dirErr != nil || len(entries) == 0 {
+		return nil, fmt.Errorf("failed to get %s@%s: %w", p, sha, err)
+	}
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.Path == nil {
+			continue
+		}
+		content, err := openVerified(ctx, fc, *entry.Path, sha)
+		if err != nil {
+			return nil, err
+		}
+		files[strings.TrimPrefix(strings.TrimPrefix(*entry.Path, strings.TrimSuffix(p, "/")), "/")] = content
+	}
+	return files, nil
+}
+
+// openVerified downloads the file at p, and checks it against its blob SHA.
+func openVerified(ctx context.Context, fc gitprovider.FileClient, p, sha string) (string, error) {
+	rc, info, err := fc.Open(ctx, p, sha)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s@%s: %w", p, sha, err)
+	}
+	if got := plumbing.ComputeHash(plumbing.BlobObject, data).String(); info == nil || got != info.SHA {
+		return "", fmt.Errorf("%w: %s@%s", ErrHashMismatch, p, sha)
+	}
+	return string(data), nil
+}
+
+// hasBlob returns whether the file at p in the branch has the given content. A missing file or
+// an empty repository isn't an error.
+func hasBlob(ctx context.Context, fc gitprovider.FileClient, p, branch, content string) (bool, error) {
+	rc, info, err := fc.Open(ctx, p, branch)
+	if errors.Is(err, gitprovider.ErrNotFound) || errors.Is(err, gitprovider.ErrEmptyRepository) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	rc.Close()
+	return info.SHA == plumbing.ComputeHash(plumbing.BlobObject, []byte(content)).String(), nil
+}
+
+// getRepository returns the repository of the given reference.
+func getRepository(ctx context.Context, c gitprovider.Client, ref gitprovider.RepositoryRef) (gitprovider.UserRepository, error) {
+	switch r := ref.(type) {
+	case gitprovider.OrgRepositoryRef:
+		return c.OrgRepositories().Get(ctx, r)
+	case gitprovider.UserRepositoryRef:
+		return c.UserRepositories().Get(ctx, r)
+	default:
+		return nil, fmt.Errorf("unsupported repository reference %T: %w", ref, gitprovider.ErrInvalidArgument)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
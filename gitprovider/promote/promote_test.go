@@ -0,0 +1,227 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package promote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// fakeRepo is an in-memory repository with a single branch. The fakes embed the interfaces,
+// only the methods used by Promote are implemented.
+type fakeRepo struct {
+	gitprovider.OrgRepository
+	sha     string
+	files   map[string]string
+	corrupt string
+	commits []string
+}
+
+func (r *fakeRepo) Commits() gitprovider.CommitClient { return &fakeCommits{repo: r} }
+func (r *fakeRepo) Files() gitprovider.FileClient     { return &fakeFiles{repo: r} }
+
+type fakeCommits struct {
+	gitprovider.CommitClient
+	repo *fakeRepo
+}
+
+func (c *fakeCommits) ListPage(context.Context, string, int, int, ...gitprovider.CommitListOption) ([]gitprovider.Commit, error) {
+	return []gitprovider.Commit{fakeCommit{gitprovider.CommitInfo{Sha: c.repo.sha}}}, nil
+}
+
+func (c *fakeCommits) Create(_ context.Context, _, message string, files []gitprovider.CommitFile) (gitprovider.Commit, error) {
+	for _, f := range files {
+		c.repo.files[*f.Path] = *f.Content
+	}
+	c.repo.commits = append(c.repo.commits, message)
+	return fakeCommit{gitprovider.CommitInfo{Sha: "new", Message: message}}, nil
+}
+
+type fakeCommit struct {
+	info gitprovider.CommitInfo
+}
+
+func (c fakeCommit) APIObject() interface{}      { return nil }
+func (c fakeCommit) Get() gitprovider.CommitInfo { return c.info }
+
+type fakeFiles struct {
+	gitprovider.FileClient
+	repo *fakeRepo
+}
+
+func (c *fakeFiles) Open(_ context.Context, p, _ string) (io.ReadCloser, *gitprovider.FileInfo, error) {
+	content, ok := c.repo.files[p]
+	if !ok {
+		return nil, nil, gitprovider.ErrNotFound
+	}
+	sha := plumbing.ComputeHash(plumbing.BlobObject, []byte(content)).String()
+	if p == c.repo.corrupt {
+		content += "garbage"
+	}
+	return io.NopCloser(bytes.NewBufferString(content)), &gitprovider.FileInfo{Path: p, SHA: sha}, nil
+}
+
+func (c *fakeFiles) Get(_ context.Context, dir, _ string) ([]*gitprovider.CommitFile, error) {
+	var files []*gitprovider.CommitFile
+	for p := range c.repo.files {
+		if strings.HasPrefix(p, dir+"/") && !strings.Contains(strings.TrimPrefix(p, dir+"/"), "/") {
+			files = append(files, &gitprovider.CommitFile{Path: gitprovider.StringVar(p)})
+		}
+	}
+	if len(files) == 0 {
+		return nil, gitprovider.ErrNotFound
+	}
+	return files, nil
+}
+
+type fakeClient struct {
+	gitprovider.Client
+	repo *fakeRepo
+}
+
+func (c *fakeClient) OrgRepositories() gitprovider.OrgRepositoriesClient {
+	return &fakeReposClient{repo: c.repo}
+}
+
+type fakeReposClient struct {
+	gitprovider.OrgRepositoriesClient
+	repo *fakeRepo
+}
+
+func (c *fakeReposClient) Get(context.Context, gitprovider.OrgRepositoryRef, ...gitprovider.RepositoryGetOption) (gitprovider.OrgRepository, error) {
+	return c.repo, nil
+}
+
+func TestPromotion_Promote(t *testing.T) {
+	ref := func(name string) gitprovider.OrgRepositoryRef {
+		return gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "example.com", Organization: "org"}, RepositoryName: name}
+	}
+	manifestSum := sha256.Sum256([]byte("replicas: 2"))
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		path        string
+		targetPath  string
+		expected    string
+		corrupt     string
+		targetFiles map[string]string
+		wantErr     error
+		wantUpdated []string
+		wantSHA256  string
+	}{
+		{
+			name:        "file",
+			path:        "staging/app.yaml",
+			targetPath:  "production/app.yaml",
+			expected:    hex.EncodeToString(manifestSum[:]),
+			wantUpdated: []string{"production/app.yaml"},
+			wantSHA256:  hex.EncodeToString(manifestSum[:]),
+		},
+		{
+			name:        "directory",
+			path:        "staging",
+			targetPath:  "production",
+			targetFiles: map[string]string{"production/app.yaml": "replicas: 2"},
+			wantUpdated: []string{"production/config.yaml"},
+		},
+		{
+			name:        "up-to-date",
+			path:        "staging/app.yaml",
+			targetFiles: map[string]string{"staging/app.yaml": "replicas: 2"},
+		},
+		{
+			name:    "corrupted download",
+			path:    "staging",
+			corrupt: "staging/config.yaml",
+			wantErr: ErrHashMismatch,
+		},
+		{
+			name:     "unexpected digest",
+			path:     "staging/app.yaml",
+			expected: "0000",
+			wantErr:  ErrDigestMismatch,
+		},
+		{
+			name:    "missing path",
+			path:    "prod",
+			wantErr: gitprovider.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeRepo{sha: "abc123", corrupt: tt.corrupt, files: map[string]string{
+				"staging/app.yaml":    "replicas: 2",
+				"staging/config.yaml": "env: prod",
+			}}
+			target := &fakeRepo{sha: "def456", files: map[string]string{}}
+			for p, c := range tt.targetFiles {
+				target.files[p] = c
+			}
+			p := &Promotion{
+				Source:         Source{Client: &fakeClient{repo: source}, Repository: ref("staging"), Ref: "main", Path: tt.path},
+				Target:         Target{Client: &fakeClient{repo: target}, Repository: ref("production"), Branch: "main", Path: tt.targetPath},
+				ExpectedSHA256: tt.expected,
+			}
+			res, err := p.Promote(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Promote() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(target.commits) != 0 {
+					t.Errorf("failed promotion committed %v", target.commits)
+				}
+				return
+			}
+			sort.Strings(res.Updated)
+			if !reflect.DeepEqual(res.Updated, tt.wantUpdated) {
+				t.Errorf("Updated = %v, want %v", res.Updated, tt.wantUpdated)
+			}
+			if tt.wantSHA256 != "" && res.SHA256 != tt.wantSHA256 {
+				t.Errorf("SHA256 = %s, want %s", res.SHA256, tt.wantSHA256)
+			}
+			if len(tt.wantUpdated) == 0 {
+				if res.Commit != nil || len(target.commits) != 0 {
+					t.Errorf("up-to-date promotion committed %v", target.commits)
+				}
+				return
+			}
+			msg := target.commits[0]
+			for _, trailer := range []string{
+				"Promoted-From: https://example.com/org/staging@abc123",
+				"Promoted-Path: " + tt.path,
+				"Content-SHA256: " + res.SHA256,
+			} {
+				if !strings.Contains(msg, trailer) {
+					t.Errorf("commit message %q doesn't contain %q", msg, trailer)
+				}
+			}
+		})
+	}
+}
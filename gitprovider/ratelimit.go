@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"math"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimitTransport is a http.RoundTripper that waits for a token-bucket limiter before
+// sending each request. Waiting is aborted when the request's context is done.
+type rateLimitTransport struct {
+	limiter *rate.Limiter
+	next    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+// rateLimitTransportFunc returns a ChainableRoundTripperFunc that limits the outgoing requests
+// to requestsPerSecond, allowing bursts of up to max(1, requestsPerSecond) requests.
+// Each call of the returned function creates a new limiter, i.e. one limiter per client.
+func rateLimitTransportFunc(requestsPerSecond float64) ChainableRoundTripperFunc {
+	return func(in http.RoundTripper) http.RoundTripper {
+		if in == nil {
+			in = http.DefaultTransport
+		}
+		burst := int(math.Max(1, requestsPerSecond))
+		return &rateLimitTransport{
+			limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), burst),
+			next:    in,
+		}
+	}
+}
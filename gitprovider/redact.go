@@ -0,0 +1,208 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// RedactedPlaceholder replaces the secrets removed by Redact.
+const RedactedPlaceholder = "[REDACTED]"
+
+// redactionSecretGroup is the name of the capturing group holding the secret in a redaction
+// pattern. If a pattern has no such group, the whole match is the secret.
+const redactionSecretGroup = "secret"
+
+// redactionPatterns are the patterns of the secrets removed by Redact. They are anchored to
+// the formats secrets are sent in, i.e. token prefixes, headers, query parameters and URL
+// userinfo, so that error messages merely mentioning e.g. a "token" are left intact.
+//
+//nolint:gochecknoglobals
+var redactionPatterns = struct {
+	sync.RWMutex
+	list []*regexp.Regexp
+}{
+	list: []*regexp.Regexp{
+		// Authorization headers, e.g. "Authorization: token abc" or "PRIVATE-TOKEN: abc"
+		regexp.MustCompile(`(?i)\b(?:proxy-)?(?:authorization|private-token|x-gitlab-token)"?\s*[:=]\s*"?(?:(?:bearer|basic|token)\s+)?(?P<secret>[A-Za-z0-9\-._~+/]+=*)`),
+		// Bearer credentials. The credential must look like one, i.e. be long or contain
+		// something else than letters, so that "Bearer authentication" isn't redacted
+		regexp.MustCompile(`\bBearer\s+(?P<secret>[A-Za-z0-9\-._~+/]{20,}=*|[A-Za-z0-9\-._~+/]*[0-9\-._~+/][A-Za-z0-9\-._~+/]*=*)`),
+		// GitHub and GitLab tokens
+		regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|glpat-[A-Za-z0-9_\-]{20,})`),
+		// Credentials in query strings and form bodies
+		regexp.MustCompile(`(?i)(?:^|[?&;\s])(?:access_token|private_token|token|client_secret|secret|password)=(?P<secret>[^&\s"'#]+)`),
+		// Passwords in URL userinfo
+		regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.\-]*://[^/\s:@]+:(?P<secret>[^/\s@]+)@`),
+		// Credentials in JSON documents, e.g. webhook secrets
+		regexp.MustCompile(`(?i)"(?:token|secret|password|private_key|privateKey)"\s*:\s*"(?P<secret>[^"]+)"`),
+		// Private keys
+		regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	},
+}
+
+// RegisterRedactionPattern makes Redact remove the matches of the regular expression pattern,
+// e.g. the format of the tokens of an internal service. If the pattern has a capturing group
+// named "secret", only that group is removed, e.g. `api_key=(?P<secret>\w+)`.
+func RegisterRedactionPattern(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid redaction pattern: %w", err)
+	}
+	redactionPatterns.Lock()
+	defer redactionPatterns.Unlock()
+	redactionPatterns.list = append(redactionPatterns.list, re)
+	return nil
+}
+
+// Redact returns s with the secrets it contains, e.g. tokens, webhook secrets and private keys,
+// replaced with RedactedPlaceholder. All the log messages, and the error messages of HTTPError,
+// pass through it. More patterns can be added with RegisterRedactionPattern.
+func Redact(s string) string {
+	redactionPatterns.RLock()
+	defer redactionPatterns.RUnlock()
+	for _, re := range redactionPatterns.list {
+		s = redactMatches(re, s)
+	}
+	return s
+}
+
+// redactMatches replaces the matches of re in s, or their secret group.
+func redactMatches(re *regexp.Regexp, s string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	group := re.SubexpIndex(redactionSecretGroup)
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if group > 0 && m[2*group] >= 0 {
+			start, end = m[2*group], m[2*group+1]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(RedactedPlaceholder)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// redactedError is an error whose message is redacted. It wraps the original error, so
+// that errors.Is and errors.As still work.
+type redactedError struct {
+	err error
+	msg string
+}
+
+// Error implements the error interface.
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the original error.
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// RedactError returns err if its message contains no secrets, and otherwise an error wrapping
+// err, with the redacted message.
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if redacted := Redact(msg); redacted != msg {
+		return &redactedError{err: err, msg: redacted}
+	}
+	return err
+}
+
+// RedactLogger returns a logger which redacts the messages, the values and the errors logged
+// with log. WithLogger applies it to the loggers of the clients.
+func RedactLogger(log logr.Logger) logr.Logger {
+	if _, ok := log.GetSink().(*redactingLogSink); ok || log.GetSink() == nil {
+		return log
+	}
+	return log.WithSink(&redactingLogSink{sink: log.GetSink()})
+}
+
+// redactingLogSink is a logr.LogSink passing everything through Redact before logging it.
+type redactingLogSink struct {
+	sink logr.LogSink
+}
+
+// Init implements logr.LogSink.
+func (s *redactingLogSink) Init(info logr.RuntimeInfo) {
+	// Account for this sink in the call depth
+	info.CallDepth++
+	s.sink.Init(info)
+}
+
+// Enabled implements logr.LogSink.
+func (s *redactingLogSink) Enabled(level int) bool {
+	return s.sink.Enabled(level)
+}
+
+// Info implements logr.LogSink.
+func (s *redactingLogSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.sink.Info(level, Redact(msg), redactValues(keysAndValues)...)
+}
+
+// Error implements logr.LogSink.
+func (s *redactingLogSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.sink.Error(RedactError(err), Redact(msg), redactValues(keysAndValues)...)
+}
+
+// WithValues implements logr.LogSink.
+func (s *redactingLogSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &redactingLogSink{sink: s.sink.WithValues(redactValues(keysAndValues)...)}
+}
+
+// WithName implements logr.LogSink.
+func (s *redactingLogSink) WithName(name string) logr.LogSink {
+	return &redactingLogSink{sink: s.sink.WithName(name)}
+}
+
+// redactValues returns a copy of keysAndValues, with the strings, errors and fmt.Stringers
+// redacted. Other values are logged as-is.
+func redactValues(keysAndValues []interface{}) []interface{} {
+	redacted := make([]interface{}, len(keysAndValues))
+	for i, v := range keysAndValues {
+		switch value := v.(type) {
+		case string:
+			redacted[i] = Redact(value)
+		case error:
+			redacted[i] = RedactError(value)
+		case fmt.Stringer:
+			// Keep the original value if there is nothing to redact, for structured sinks
+			redacted[i] = v
+			if s := value.String(); Redact(s) != s {
+				redacted[i] = Redact(s)
+			}
+		default:
+			redacted[i] = v
+		}
+	}
+	return redacted
+}
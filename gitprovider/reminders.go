@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// reminderMarkerPrefix starts the hidden marker of the comments managed by SetReminder. HTML
+	// comments aren't rendered by the providers, so only the human-readable text is shown.
+	reminderMarkerPrefix = "<!-- gitprovider:reminder "
+	reminderMarkerSuffix = " -->"
+)
+
+// Reminder is scheduling metadata stored on a pull request, e.g. to retry merging it after a
+// given time. Reminders are stored as pull request comments with a hidden, structured marker,
+// which makes them portable across providers and visible to the reviewers.
+type Reminder struct {
+	// Action names the scheduled action, e.g. "retry-merge". A pull request has at most one
+	// reminder per action.
+	// +required
+	Action string `json:"action"`
+
+	// At is the time from which the action is due.
+	// +required
+	At time.Time `json:"at"`
+
+	// Note is a human-readable reason for the reminder, shown in the comment.
+	// +optional
+	Note string `json:"note,omitempty"`
+}
+
+// Due returns true if the reminder is due at the given time.
+func (r Reminder) Due(now time.Time) bool {
+	return !now.Before(r.At)
+}
+
+// ValidateReminder validates the reminder.
+func ValidateReminder(r Reminder) error {
+	if r.Action == "" || strings.ContainsAny(r.Action, " \n") {
+		return fmt.Errorf("reminder action %q must be set, without whitespace: %w", r.Action, ErrInvalidArgument)
+	}
+	if r.At.IsZero() {
+		return fmt.Errorf("reminder %q must have a time: %w", r.Action, ErrInvalidArgument)
+	}
+	return nil
+}
+
+// FormatReminder returns the comment body storing the reminder.
+func FormatReminder(r Reminder) (string, error) {
+	if err := ValidateReminder(r); err != nil {
+		return "", err
+	}
+	r.At = r.At.UTC()
+	// json.Marshal escapes "<" and ">", so the marker can't be terminated early by the note
+	marker, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	text := fmt.Sprintf("Reminder `%s` scheduled for %s.", r.Action, r.At.Format(time.RFC3339))
+	if r.Note != "" {
+		text += " " + r.Note
+	}
+	return reminderMarkerPrefix + string(marker) + reminderMarkerSuffix + "\n" + text, nil
+}
+
+// ParseReminder returns the reminder stored in the comment body, and false if the comment isn't
+// managed by SetReminder.
+func ParseReminder(body string) (Reminder, bool) {
+	if !strings.HasPrefix(body, reminderMarkerPrefix) {
+		return Reminder{}, false
+	}
+	end := strings.Index(body, reminderMarkerSuffix)
+	if end < 0 {
+		return Reminder{}, false
+	}
+	var r Reminder
+	if err := json.Unmarshal([]byte(body[len(reminderMarkerPrefix):end]), &r); err != nil {
+		return Reminder{}, false
+	}
+	if ValidateReminder(r) != nil {
+		return Reminder{}, false
+	}
+	return r, true
+}
+
+// SetReminder stores the reminder on the pull request, replacing the reminder for the same action,
+// e.g. to snooze it to a later time.
+func SetReminder(ctx context.Context, c PullRequestClient, number int, r Reminder) error {
+	body, err := FormatReminder(r)
+	if err != nil {
+		return err
+	}
+	comment, _, err := findReminder(ctx, c, number, r.Action)
+	if err != nil {
+		return err
+	}
+	if comment != nil {
+		return c.EditComment(ctx, number, comment.Get().ID, body)
+	}
+	_, err = c.CreateComment(ctx, number, body)
+	return err
+}
+
+// GetReminder returns the reminder for the action stored on the pull request.
+//
+// ErrNotFound is returned if there is no reminder for the action.
+func GetReminder(ctx context.Context, c PullRequestClient, number int, action string) (Reminder, error) {
+	comment, r, err := findReminder(ctx, c, number, action)
+	if err != nil {
+		return Reminder{}, err
+	}
+	if comment == nil {
+		return Reminder{}, fmt.Errorf("reminder %q on pull request #%d: %w", action, number, ErrNotFound)
+	}
+	return r, nil
+}
+
+// ListReminders returns the reminders stored on the pull request, in the order they were created.
+func ListReminders(ctx context.Context, c PullRequestClient, number int) ([]Reminder, error) {
+	comments, err := c.ListComments(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	reminders := []Reminder{}
+	for _, comment := range comments {
+		if r, ok := ParseReminder(comment.Get().Body); ok {
+			reminders = append(reminders, r)
+		}
+	}
+	return reminders, nil
+}
+
+// ClearReminder removes the reminder for the action from the pull request, e.g. once the action
+// is done. It's a no-op if there is no reminder for the action.
+func ClearReminder(ctx context.Context, c PullRequestClient, number int, action string) error {
+	comment, _, err := findReminder(ctx, c, number, action)
+	if err != nil || comment == nil {
+		return err
+	}
+	return c.DeleteComment(ctx, number, comment.Get().ID)
+}
+
+// findReminder returns the comment storing the reminder for the action, or nil if there is none.
+func findReminder(ctx context.Context, c PullRequestClient, number int, action string) (PullRequestComment, Reminder, error) {
+	comments, err := c.ListComments(ctx, number)
+	if err != nil {
+		return nil, Reminder{}, err
+	}
+	for _, comment := range comments {
+		if r, ok := ParseReminder(comment.Get().Body); ok && r.Action == action {
+			return comment, r, nil
+		}
+	}
+	return nil, Reminder{}, nil
+}
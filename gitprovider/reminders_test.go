@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// commentsPullRequestClient stores the comments of a single pull request in memory.
+type commentsPullRequestClient struct {
+	PullRequestClient
+	comments []PullRequestCommentInfo
+	nextID   int64
+}
+
+type testComment struct{ info PullRequestCommentInfo }
+
+func (c testComment) Get() PullRequestCommentInfo { return c.info }
+func (c testComment) APIObject() interface{}      { return &c.info }
+
+func (c *commentsPullRequestClient) ListComments(_ context.Context, _ int) ([]PullRequestComment, error) {
+	comments := make([]PullRequestComment, 0, len(c.comments))
+	for _, info := range c.comments {
+		comments = append(comments, testComment{info})
+	}
+	return comments, nil
+}
+
+func (c *commentsPullRequestClient) CreateComment(_ context.Context, _ int, body string) (PullRequestComment, error) {
+	c.nextID++
+	info := PullRequestCommentInfo{ID: c.nextID, Body: body}
+	c.comments = append(c.comments, info)
+	return testComment{info}, nil
+}
+
+func (c *commentsPullRequestClient) EditComment(_ context.Context, _ int, id int64, body string) error {
+	for i := range c.comments {
+		if c.comments[i].ID == id {
+			c.comments[i].Body = body
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (c *commentsPullRequestClient) DeleteComment(_ context.Context, _ int, id int64) error {
+	for i := range c.comments {
+		if c.comments[i].ID == id {
+			c.comments = append(c.comments[:i], c.comments[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func TestReminders(t *testing.T) {
+	ctx := context.Background()
+	c := &commentsPullRequestClient{}
+	if _, err := c.CreateComment(ctx, 1, "LGTM"); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := SetReminder(ctx, c, 1, Reminder{Action: "retry-merge", At: at, Note: "CI is down -->"}); err != nil {
+		t.Fatal(err)
+	}
+	// Snoozing replaces the comment, instead of adding another one
+	if err := SetReminder(ctx, c, 1, Reminder{Action: "retry-merge", At: at.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.comments) != 2 || !strings.Contains(c.comments[1].Body, "scheduled for 2021-06-01T13:00:00Z") {
+		t.Fatalf("comments = %+v", c.comments)
+	}
+
+	r, err := GetReminder(ctx, c, 1, "retry-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.At.Equal(at.Add(time.Hour)) || r.Due(at) || !r.Due(at.Add(time.Hour)) {
+		t.Errorf("GetReminder() = %+v", r)
+	}
+	reminders, err := ListReminders(ctx, c, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 1 {
+		t.Errorf("ListReminders() = %+v, want 1 reminder", reminders)
+	}
+
+	if err := ClearReminder(ctx, c, 1, "retry-merge"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClearReminder(ctx, c, 1, "retry-merge"); err != nil {
+		t.Errorf("ClearReminder() of a missing reminder = %v", err)
+	}
+	if _, err := GetReminder(ctx, c, 1, "retry-merge"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetReminder() error = %v, want ErrNotFound", err)
+	}
+	if len(c.comments) != 1 {
+		t.Errorf("comments = %+v, want only the user comment", c.comments)
+	}
+}
+
+func TestParseReminder(t *testing.T) {
+	want := Reminder{Action: "retry-merge", At: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), Note: "<!-- -->"}
+	body, err := FormatReminder(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := ParseReminder(body)
+	if !ok || got != want {
+		t.Errorf("ParseReminder() = %+v, %v, want %+v", got, ok, want)
+	}
+
+	for _, body := range []string{
+		"LGTM",
+		"<!-- gitprovider:reminder {} -->",
+		"<!-- gitprovider:reminder not json -->",
+		"<!-- gitprovider:reminder {\"action\":\"x\"",
+	} {
+		if _, ok := ParseReminder(body); ok {
+			t.Errorf("ParseReminder(%q) = true, want false", body)
+		}
+	}
+	if _, err := FormatReminder(Reminder{Action: "retry merge", At: want.At}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("FormatReminder() error = %v, want ErrInvalidArgument", err)
+	}
+}
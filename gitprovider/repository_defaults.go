@@ -0,0 +1,160 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// RepositoryDefaults is a template of repository settings for an organization, e.g. for
+// governing the visibility and default branch of all repositories in it.
+type RepositoryDefaults struct {
+	// Info holds the default values for the fields of RepositoryInfo. When creating or reconciling
+	// a repository, they are used for the fields left unset in the request, instead of the built-in
+	// defaults. ReconcileOrgDefaults enforces the set fields on existing repositories.
+	// +optional
+	Info RepositoryInfo `json:"info"`
+
+	// CreateOptions are applied when creating a repository, before the options given to Create.
+	// +optional
+	CreateOptions RepositoryCreateOptions `json:"createOptions"`
+}
+
+// ApplyToRepositoryInfo sets the unset fields of target to the default values. The readonly
+// fields, which are only reported by the provider, aren't defaulted. It's a no-op if d is nil.
+func (d *RepositoryDefaults) ApplyToRepositoryInfo(target *RepositoryInfo) {
+	if d == nil {
+		return
+	}
+	if target.Description == nil {
+		target.Description = d.Info.Description
+	}
+	if target.DefaultBranch == nil {
+		target.DefaultBranch = d.Info.DefaultBranch
+	}
+	if target.Visibility == nil {
+		target.Visibility = d.Info.Visibility
+	}
+	if target.Topics == nil {
+		target.Topics = d.Info.Topics
+	}
+	if target.MergeMethods == nil {
+		target.MergeMethods = d.Info.MergeMethods
+	}
+	if target.DeleteBranchOnMerge == nil {
+		target.DeleteBranchOnMerge = d.Info.DeleteBranchOnMerge
+	}
+	if target.Archived == nil {
+		target.Archived = d.Info.Archived
+	}
+}
+
+// MergeCreateOptions returns opts, preceded by the default create options so that opts take
+// precedence. opts is returned as-is if d is nil.
+func (d *RepositoryDefaults) MergeCreateOptions(opts ...RepositoryCreateOption) []RepositoryCreateOption {
+	if d == nil {
+		return opts
+	}
+	return append([]RepositoryCreateOption{&d.CreateOptions}, opts...)
+}
+
+// ValidateInfo validates the default values.
+func (d RepositoryDefaults) ValidateInfo() error {
+	if err := d.Info.ValidateInfo(); err != nil {
+		return err
+	}
+	return d.CreateOptions.ValidateOptions()
+}
+
+// OrgRepositoryDefaults maps organization paths, e.g. "fluxcd" or "fluxcd/engineering" for a
+// GitLab sub-group, to the repository defaults of the organization.
+type OrgRepositoryDefaults map[string]RepositoryDefaults
+
+// For returns the repository defaults for the organization, or nil if there are none. Sub-organizations
+// without their own entry get the defaults of the closest parent organization.
+func (d OrgRepositoryDefaults) For(org OrganizationRef) *RepositoryDefaults {
+	if len(d) == 0 {
+		return nil
+	}
+	path := append([]string{org.Organization}, org.SubOrganizations...)
+	for i := len(path); i > 0; i-- {
+		if defaults, ok := d[strings.Join(path[:i], "/")]; ok {
+			return &defaults
+		}
+	}
+	return nil
+}
+
+// ReconcileOrgDefaults back-fills the repository defaults to all existing repositories of the
+// organization: the fields set in defaults.Info are reconciled on every repository, while the
+// other fields are left as-is. The references of the repositories that were updated are returned.
+//
+// The repositories are reconciled one by one, an *AggregateError is returned for the ones which
+// failed. With WithDryRun(), no repositories are updated, and the changes are returned in a
+// single *DryRunError instead.
+func ReconcileOrgDefaults(ctx context.Context, c Client, org OrganizationRef, defaults RepositoryDefaults) ([]OrgRepositoryRef, error) {
+	if err := defaults.ValidateInfo(); err != nil {
+		return nil, err
+	}
+	repos, err := c.OrgRepositories().List(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []OrgRepositoryRef
+	var dryRun *DryRunError
+	errs := &AggregateError{}
+	for _, repo := range repos {
+		ref, ok := repo.Repository().(OrgRepositoryRef)
+		if !ok {
+			continue
+		}
+		// Listed repositories might not be fully populated, e.g. lack the default branch
+		actual, err := c.OrgRepositories().Get(ctx, ref)
+		if err != nil {
+			errs.Add(ref.String(), err)
+			continue
+		}
+		// The defaults override the actual state, which only fills the fields the defaults leave unset
+		req := defaults.Info
+		(&RepositoryDefaults{Info: actual.Get()}).ApplyToRepositoryInfo(&req)
+		_, actionTaken, err := c.OrgRepositories().Reconcile(ctx, ref, req)
+
+		var dryRunErr *DryRunError
+		switch {
+		case errors.As(err, &dryRunErr):
+			if dryRun == nil {
+				dryRun = NewDryRunError()
+			}
+			dryRun.Changes = append(dryRun.Changes, dryRunErr.Changes...)
+		case err != nil:
+			errs.Add(ref.String(), err)
+		case actionTaken:
+			updated = append(updated, ref)
+		}
+	}
+
+	if dryRun != nil {
+		if len(errs.Errors) == 0 {
+			return updated, dryRun
+		}
+		errs.Add(org.String(), dryRun)
+	}
+	return updated, errs.ErrorOrNil()
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrgRepositoryDefaults_For(t *testing.T) {
+	defaults := OrgRepositoryDefaults{
+		"fluxcd":             {Info: RepositoryInfo{Description: StringVar("fluxcd")}},
+		"fluxcd/engineering": {Info: RepositoryInfo{Description: StringVar("engineering")}},
+	}
+	tests := []struct {
+		name     string
+		defaults OrgRepositoryDefaults
+		org      OrganizationRef
+		want     *string
+	}{
+		{
+			name:     "top-level organization",
+			defaults: defaults,
+			org:      OrganizationRef{Organization: "fluxcd"},
+			want:     StringVar("fluxcd"),
+		},
+		{
+			name:     "sub-organization with own defaults",
+			defaults: defaults,
+			org:      OrganizationRef{Organization: "fluxcd", SubOrganizations: []string{"engineering"}},
+			want:     StringVar("engineering"),
+		},
+		{
+			name:     "nested sub-organization inherits from the closest parent",
+			defaults: defaults,
+			org:      OrganizationRef{Organization: "fluxcd", SubOrganizations: []string{"engineering", "frontend"}},
+			want:     StringVar("engineering"),
+		},
+		{
+			name:     "other organization",
+			defaults: defaults,
+			org:      OrganizationRef{Organization: "kubernetes"},
+		},
+		{
+			name: "no defaults",
+			org:  OrganizationRef{Organization: "fluxcd"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.defaults.For(tt.org)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("For() = %v, want defaults %v", got, tt.want != nil)
+			}
+			if got != nil && *got.Info.Description != *tt.want {
+				t.Errorf("For() description = %q, want %q", *got.Info.Description, *tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryDefaults_Apply(t *testing.T) {
+	d := &RepositoryDefaults{
+		Info: RepositoryInfo{
+			Description: StringVar("managed"),
+			Visibility:  RepositoryVisibilityVar(RepositoryVisibilityInternal),
+		},
+		CreateOptions: RepositoryCreateOptions{
+			AutoInit:        BoolVar(true),
+			LicenseTemplate: LicenseTemplateVar(LicenseTemplateApache2),
+		},
+	}
+
+	// Only the unset fields are defaulted
+	req := RepositoryInfo{Visibility: RepositoryVisibilityVar(RepositoryVisibilityPublic)}
+	d.ApplyToRepositoryInfo(&req)
+	want := RepositoryInfo{
+		Description: StringVar("managed"),
+		Visibility:  RepositoryVisibilityVar(RepositoryVisibilityPublic),
+	}
+	if !reflect.DeepEqual(req, want) {
+		t.Errorf("ApplyToRepositoryInfo() = %+v, want %+v", req, want)
+	}
+
+	// The given create options take precedence
+	o, err := MakeRepositoryCreateOptions(d.MergeCreateOptions(&RepositoryCreateOptions{AutoInit: BoolVar(false)})...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *o.AutoInit || *o.LicenseTemplate != LicenseTemplateApache2 {
+		t.Errorf("MergeCreateOptions() = %+v, want AutoInit false and the default license", o)
+	}
+
+	// Every writable field is defaulted, so that new fields of RepositoryInfo aren't missed
+	d = &RepositoryDefaults{}
+	info := reflect.ValueOf(&d.Info).Elem()
+	for i := 0; i < info.NumField(); i++ {
+		field := info.Field(i)
+		switch field.Kind() {
+		case reflect.Ptr:
+			field.Set(reflect.New(field.Type().Elem()))
+		case reflect.Slice:
+			field.Set(reflect.MakeSlice(field.Type(), 1, 1))
+		default:
+			t.Fatalf("unexpected kind %s of RepositoryInfo.%s", field.Kind(), info.Type().Field(i).Name)
+		}
+	}
+	req = RepositoryInfo{}
+	d.ApplyToRepositoryInfo(&req)
+	got := reflect.ValueOf(req)
+	for i := 0; i < got.NumField(); i++ {
+		field := info.Type().Field(i)
+		readonly := field.Tag.Get("gitprovider") == "readonly"
+		if defaulted := !got.Field(i).IsNil(); defaulted == readonly {
+			t.Errorf("ApplyToRepositoryInfo() defaulted RepositoryInfo.%s = %v, want %v", field.Name, defaulted, !readonly)
+		}
+	}
+
+	// A nil *RepositoryDefaults is a no-op
+	var none *RepositoryDefaults
+	req = RepositoryInfo{}
+	none.ApplyToRepositoryInfo(&req)
+	if !reflect.DeepEqual(req, RepositoryInfo{}) || len(none.MergeCreateOptions()) != 0 {
+		t.Errorf("nil RepositoryDefaults changed the request")
+	}
+}
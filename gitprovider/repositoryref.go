@@ -116,7 +116,7 @@ func (u UserRef) GetType() IdentityType {
 // String returns the HTTPS URL to access the User.
 func (u UserRef) String() string {
 	domain := GetDomainURL(u.GetDomain())
-	return fmt.Sprintf("%s/%s", domain, u.GetIdentity())
+	return fmt.Sprintf("%s/%s", domain, EscapePath(u.GetIdentity()))
 }
 
 // ValidateFields validates its own fields for a given validator.
@@ -188,7 +188,7 @@ func (o OrganizationRef) GetType() IdentityType {
 // String returns the URL to access the Organization.
 func (o OrganizationRef) String() string {
 	domain := GetDomainURL(o.GetDomain())
-	return fmt.Sprintf("%s/%s", domain, o.GetIdentity())
+	return fmt.Sprintf("%s/%s", domain, EscapePath(o.GetIdentity()))
 }
 
 // ValidateFields validates its own fields for a given validator.
@@ -220,7 +220,7 @@ type OrgRepositoryRef struct {
 
 // String returns the HTTPS URL to access the repository.
 func (r OrgRepositoryRef) String() string {
-	return fmt.Sprintf("%s/%s", r.OrganizationRef.String(), r.RepositoryName)
+	return fmt.Sprintf("%s/%s", r.OrganizationRef.String(), EscapePath(r.RepositoryName))
 }
 
 // GetRepository returns the repository name for this repo.
@@ -271,7 +271,7 @@ type UserRepositoryRef struct {
 
 // String returns the URL to access the repository.
 func (r UserRepositoryRef) String() string {
-	return fmt.Sprintf("%s/%s", r.UserRef.String(), r.RepositoryName)
+	return fmt.Sprintf("%s/%s", r.UserRef.String(), EscapePath(r.RepositoryName))
 }
 
 // GetRepository returns the repository name for this repo.
@@ -333,7 +333,7 @@ func ParseTypeSSH(domain, identity, repository string) string {
 	trimmedDomain := domain
 	trimmedDomain = strings.Replace(trimmedDomain, "https://", "", -1)
 	trimmedDomain = strings.Replace(trimmedDomain, "http://", "", -1)
-	return fmt.Sprintf("ssh://git@%s/%s/%s", trimmedDomain, identity, repository)
+	return fmt.Sprintf("ssh://git@%s/%s/%s", trimmedDomain, EscapePath(identity), EscapePath(repository))
 }
 
 // ParseOrganizationURL parses an URL to an organization into a OrganizationRef object.
@@ -0,0 +1,324 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reposync copies the content of a branch from a source repository to a target repository,
+// possibly on another Git provider, e.g. to mirror a GitHub repository to an internal Git server.
+// The sync is one-way: the source archive is downloaded, compared to the target branch, and the
+// differences are committed to the target branch in a single commit.
+//
+// The sync commits record the source commit in a "Source-Commit:" trailer. This makes syncs of an
+// unchanged source a no-op, and allows detecting changes made to the target branch outside of the
+// sync, which are handled according to the ConflictPolicy.
+package reposync
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// sourceCommitTrailer is the trailer of the sync commit messages recording the source commit.
+const sourceCommitTrailer = "Source-Commit: "
+
+// ErrConflict is returned with ConflictPolicyFail, if the target branch was changed outside of the sync.
+var ErrConflict = errors.New("the target branch was changed outside of the sync")
+
+// ConflictPolicy specifies how changes made to the target branch outside of the sync are handled.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyOverwrite makes the target branch content identical to the source, overwriting
+	// changed files and deleting files which don't exist in the source.
+	ConflictPolicyOverwrite = ConflictPolicy("overwrite")
+
+	// ConflictPolicyKeepTargetFiles overwrites the files which exist in the source, but keeps the
+	// files which only exist in the target branch.
+	ConflictPolicyKeepTargetFiles = ConflictPolicy("keep-target-files")
+
+	// ConflictPolicyFail returns ErrConflict if the latest commit of the target branch wasn't made by
+	// the sync. The first sync to a non-empty branch needs another policy.
+	ConflictPolicyFail = ConflictPolicy("fail")
+)
+
+// Endpoint is a branch of a repository on a Git provider.
+type Endpoint struct {
+	// Client is the client for the Git provider of the repository.
+	// +required
+	Client gitprovider.Client
+
+	// Repository is a gitprovider.OrgRepositoryRef or gitprovider.UserRepositoryRef.
+	// +required
+	Repository gitprovider.RepositoryRef
+
+	// Branch is the synced branch.
+	// +required
+	Branch string
+}
+
+// Syncer syncs the content of the Source branch to the Target branch.
+// The target branch must exist, unless the target repository is empty.
+//
+// The content is buffered in memory, and committed as text, so the syncer is meant for
+// repositories of configuration and documentation, rather than large binary files.
+// The GitLab commit API is used to create and delete files only, so GitLab targets can't be
+// synced once files changed in the source.
+type Syncer struct {
+	// Source is the branch the content is copied from.
+	// +required
+	Source Endpoint
+
+	// Target is the branch the content is copied to.
+	// +required
+	Target Endpoint
+
+	// ConflictPolicy specifies how changes made to the target branch outside of the sync are
+	// handled. Default: ConflictPolicyOverwrite
+	ConflictPolicy ConflictPolicy
+
+	// Clock is used for the interval of Run. Default: gitprovider.RealClock
+	Clock gitprovider.Clock
+}
+
+// Result describes the outcome of a sync.
+type Result struct {
+	// SourceSHA is the synced commit of the source branch.
+	SourceSHA string
+
+	// Commit is the commit created in the target branch, or nil if it was up-to-date.
+	Commit gitprovider.Commit
+
+	// Updated lists the paths of the files created or changed in the target branch.
+	Updated []string
+
+	// Deleted lists the paths of the files deleted from the target branch.
+	Deleted []string
+}
+
+// Run syncs on demand, every interval, until ctx is done. The result of every sync is passed to
+// report, if set; failed syncs don't stop Run. ctx.Err() is returned when ctx is done.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration, report func(*Result, error)) error {
+	// The syncs never finish the operation, it's only stopped by ctx
+	op := gitprovider.NewOperation(s.Clock, interval, func(ctx context.Context) (gitprovider.OperationStatus, error) {
+		res, err := s.Sync(ctx)
+		if report != nil {
+			report(res, err)
+		}
+		return gitprovider.OperationStatus{State: gitprovider.OperationStateRunning}, nil
+	})
+	_, err := op.Wait(ctx)
+	return err
+}
+
+// Sync copies the content of the source branch to the target branch once.
+func (s *Syncer) Sync(ctx context.Context) (*Result, error) {
+	policy := s.ConflictPolicy
+	if policy == "" {
+		policy = ConflictPolicyOverwrite
+	}
+	switch policy {
+	case ConflictPolicyOverwrite, ConflictPolicyKeepTargetFiles, ConflictPolicyFail:
+	default:
+		return nil, fmt.Errorf("unknown conflict policy %q: %w", policy, gitprovider.ErrInvalidArgument)
+	}
+
+	source, err := getRepository(ctx, s.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the source repository: %w", err)
+	}
+	sourceHead, err := headCommit(ctx, source, s.Source.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the source branch: %w", err)
+	}
+	if sourceHead == nil {
+		return nil, fmt.Errorf("the source repository is empty: %w", gitprovider.ErrEmptyRepository)
+	}
+	res := &Result{SourceSHA: sourceHead.Get().Sha}
+
+	target, err := getRepository(ctx, s.Target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the target repository: %w", err)
+	}
+	targetHead, err := headCommit(ctx, target, s.Target.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the target branch: %w", err)
+	}
+
+	targetFiles := map[string]string{}
+	if targetHead != nil {
+		syncedSHA, synced := sourceCommit(targetHead.Get().Message)
+		// Nothing changed since the last sync
+		if synced && syncedSHA == res.SourceSHA {
+			return res, nil
+		}
+		if !synced && policy == ConflictPolicyFail {
+			return nil, fmt.Errorf("%w: commit %s", ErrConflict, targetHead.Get().Sha)
+		}
+		if targetFiles, err = archiveFiles(ctx, s.Target, targetHead.Get().Sha); err != nil {
+			return nil, fmt.Errorf("failed to download the target archive: %w", err)
+		}
+	}
+	sourceFiles, err := archiveFiles(ctx, s.Source, res.SourceSHA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the source archive: %w", err)
+	}
+
+	var files []gitprovider.CommitFile
+	for _, p := range sortedKeys(sourceFiles) {
+		if content, ok := targetFiles[p]; ok && content == sourceFiles[p] {
+			continue
+		}
+		content := sourceFiles[p]
+		files = append(files, gitprovider.CommitFile{Path: gitprovider.StringVar(p), Content: &content})
+		res.Updated = append(res.Updated, p)
+	}
+	if policy != ConflictPolicyKeepTargetFiles {
+		for _, p := range sortedKeys(targetFiles) {
+			if _, ok := sourceFiles[p]; !ok {
+				files = append(files, gitprovider.CommitFile{Path: gitprovider.StringVar(p)})
+				res.Deleted = append(res.Deleted, p)
+			}
+		}
+	}
+
+	// The content is already the same, even though it wasn't synced from this source commit
+	if len(files) == 0 {
+		return res, nil
+	}
+	message := fmt.Sprintf("Sync from %s@%s\n\n%s%s", s.Source.Repository.String(), s.Source.Branch, sourceCommitTrailer, res.SourceSHA)
+	res.Commit, err = target.Commits().Create(ctx, s.Target.Branch, message, files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit to the target branch: %w", err)
+	}
+	return res, nil
+}
+
+// getRepository returns the repository of the endpoint.
+func getRepository(ctx context.Context, e Endpoint) (gitprovider.UserRepository, error) {
+	switch ref := e.Repository.(type) {
+	case gitprovider.OrgRepositoryRef:
+		return e.Client.OrgRepositories().Get(ctx, ref)
+	case gitprovider.UserRepositoryRef:
+		return e.Client.UserRepositories().Get(ctx, ref)
+	default:
+		return nil, fmt.Errorf("unsupported repository reference %T: %w", e.Repository, gitprovider.ErrInvalidArgument)
+	}
+}
+
+// headCommit returns the latest commit of the branch, or nil if the repository is empty.
+func headCommit(ctx context.Context, repo gitprovider.UserRepository, branch string) (gitprovider.Commit, error) {
+	commits, err := repo.Commits().ListPage(ctx, branch, 1, 1)
+	if errors.Is(err, gitprovider.ErrEmptyRepository) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("branch %q has no commits: %w", branch, gitprovider.ErrNotFound)
+	}
+	return commits[0], nil
+}
+
+// sourceCommit returns the source commit recorded in a sync commit message, and whether it was found.
+func sourceCommit(message string) (string, bool) {
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, sourceCommitTrailer) {
+			return strings.TrimSpace(strings.TrimPrefix(line, sourceCommitTrailer)), true
+		}
+	}
+	return "", false
+}
+
+// archiveFiles downloads the archive of the endpoint at the given commit, and returns the regular
+// files in it by path.
+func archiveFiles(ctx context.Context, e Endpoint, sha string) (map[string]string, error) {
+	var rc io.ReadCloser
+	var err error
+	switch ref := e.Repository.(type) {
+	case gitprovider.OrgRepositoryRef:
+		rc, err = e.Client.OrgRepositories().DownloadArchive(ctx, ref, sha, gitprovider.ArchiveFormatTarGz)
+	case gitprovider.UserRepositoryRef:
+		rc, err = e.Client.UserRepositories().DownloadArchive(ctx, ref, sha, gitprovider.ArchiveFormatTarGz)
+	default:
+		return nil, fmt.Errorf("unsupported repository reference %T: %w", e.Repository, gitprovider.ErrInvalidArgument)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	gz, err := gzip.NewReader(rc)
+	if err != nil {
+		return nil, err
+	}
+	// GitHub and GitLab put the content in a top-level directory named after the repository and commit
+	stripPrefix := archivePrefixed[e.Client.ProviderID()]
+
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		p := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if stripPrefix {
+			i := strings.Index(p, "/")
+			if i < 0 {
+				continue
+			}
+			p = p[i+1:]
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, tr); err != nil { // #nosec G110
+			return nil, err
+		}
+		files[p] = buf.String()
+	}
+}
+
+// archivePrefixed are the providers which put the archive content in a top-level directory.
+//
+//nolint:gochecknoglobals
+var archivePrefixed = map[gitprovider.ProviderID]bool{
+	"github": true,
+	"gitlab": true,
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
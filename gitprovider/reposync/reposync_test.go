@@ -0,0 +1,236 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reposync
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// fakeRepo is an in-memory repository, with the content of every commit.
+// The fakes embed the interfaces, only the methods used by the Syncer are implemented.
+type fakeRepo struct {
+	gitprovider.OrgRepository
+	providerID gitprovider.ProviderID
+	commits    []gitprovider.CommitInfo
+	snapshots  map[string]map[string]string
+}
+
+func newFakeRepo(providerID gitprovider.ProviderID) *fakeRepo {
+	return &fakeRepo{providerID: providerID, snapshots: map[string]map[string]string{}}
+}
+
+// commit adds a commit with the given message, applying the files to the current content.
+func (r *fakeRepo) commit(message string, files []gitprovider.CommitFile) gitprovider.CommitInfo {
+	content := map[string]string{}
+	if len(r.commits) > 0 {
+		for p, c := range r.snapshots[r.commits[0].Sha] {
+			content[p] = c
+		}
+	}
+	for _, f := range files {
+		if f.Content == nil {
+			delete(content, *f.Path)
+			continue
+		}
+		content[*f.Path] = *f.Content
+	}
+	info := gitprovider.CommitInfo{Sha: fmt.Sprintf("sha%d", len(r.snapshots)+1), Message: message}
+	r.snapshots[info.Sha] = content
+	r.commits = append([]gitprovider.CommitInfo{info}, r.commits...)
+	return info
+}
+
+func (r *fakeRepo) head() map[string]string {
+	if len(r.commits) == 0 {
+		return map[string]string{}
+	}
+	return r.snapshots[r.commits[0].Sha]
+}
+
+func (r *fakeRepo) Commits() gitprovider.CommitClient { return &fakeCommits{repo: r} }
+
+type fakeCommits struct {
+	gitprovider.CommitClient
+	repo *fakeRepo
+}
+
+func (c *fakeCommits) ListPage(_ context.Context, _ string, perPage, _ int, _ ...gitprovider.CommitListOption) ([]gitprovider.Commit, error) {
+	if len(c.repo.commits) == 0 {
+		return nil, gitprovider.ErrEmptyRepository
+	}
+	return []gitprovider.Commit{fakeCommit{c.repo.commits[0]}}, nil
+}
+
+func (c *fakeCommits) Create(_ context.Context, _, message string, files []gitprovider.CommitFile) (gitprovider.Commit, error) {
+	return fakeCommit{c.repo.commit(message, files)}, nil
+}
+
+type fakeCommit struct {
+	info gitprovider.CommitInfo
+}
+
+func (c fakeCommit) APIObject() interface{}      { return nil }
+func (c fakeCommit) Get() gitprovider.CommitInfo { return c.info }
+
+type fakeClient struct {
+	gitprovider.Client
+	repo *fakeRepo
+}
+
+func (c *fakeClient) ProviderID() gitprovider.ProviderID { return c.repo.providerID }
+
+func (c *fakeClient) OrgRepositories() gitprovider.OrgRepositoriesClient {
+	return &fakeReposClient{repo: c.repo}
+}
+
+type fakeReposClient struct {
+	gitprovider.OrgRepositoriesClient
+	repo *fakeRepo
+}
+
+func (c *fakeReposClient) Get(context.Context, gitprovider.OrgRepositoryRef, ...gitprovider.RepositoryGetOption) (gitprovider.OrgRepository, error) {
+	return c.repo, nil
+}
+
+func (c *fakeReposClient) DownloadArchive(_ context.Context, _ gitprovider.OrgRepositoryRef, sha string, _ gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	content, ok := c.repo.snapshots[sha]
+	if !ok {
+		return nil, gitprovider.ErrNotFound
+	}
+	prefix := ""
+	if archivePrefixed[c.repo.providerID] {
+		prefix = "repo-" + sha + "/"
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if prefix != "" {
+		tw.WriteHeader(&tar.Header{Name: prefix, Typeflag: tar.TypeDir, Mode: 0o755})
+	}
+	for p, data := range content {
+		tw.WriteHeader(&tar.Header{Name: prefix + p, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(data))})
+		tw.Write([]byte(data))
+	}
+	tw.Close()
+	gz.Close()
+	return io.NopCloser(&buf), nil
+}
+
+func file(p, content string) gitprovider.CommitFile {
+	return gitprovider.CommitFile{Path: gitprovider.StringVar(p), Content: gitprovider.StringVar(content)}
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	ref := func(name string) gitprovider.OrgRepositoryRef {
+		return gitprovider.OrgRepositoryRef{OrganizationRef: gitprovider.OrganizationRef{Domain: "example.com", Organization: "org"}, RepositoryName: name}
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		policy      ConflictPolicy
+		targetFiles []gitprovider.CommitFile
+		wantErr     error
+		wantUpdated []string
+		wantDeleted []string
+		wantTarget  map[string]string
+	}{
+		{
+			name:        "empty target",
+			wantUpdated: []string{"README.md", "deploy/app.yaml"},
+			wantTarget:  map[string]string{"README.md": "# app", "deploy/app.yaml": "replicas: 2"},
+		},
+		{
+			name:        "overwrite",
+			targetFiles: []gitprovider.CommitFile{file("README.md", "# app"), file("deploy/app.yaml", "replicas: 1"), file("local.txt", "x")},
+			wantUpdated: []string{"deploy/app.yaml"},
+			wantDeleted: []string{"local.txt"},
+			wantTarget:  map[string]string{"README.md": "# app", "deploy/app.yaml": "replicas: 2"},
+		},
+		{
+			name:        "keep target files",
+			policy:      ConflictPolicyKeepTargetFiles,
+			targetFiles: []gitprovider.CommitFile{file("local.txt", "x")},
+			wantUpdated: []string{"README.md", "deploy/app.yaml"},
+			wantTarget:  map[string]string{"README.md": "# app", "deploy/app.yaml": "replicas: 2", "local.txt": "x"},
+		},
+		{
+			name:        "fail on changes outside of the sync",
+			policy:      ConflictPolicyFail,
+			targetFiles: []gitprovider.CommitFile{file("local.txt", "x")},
+			wantErr:     ErrConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newFakeRepo("github")
+			source.commit("init", []gitprovider.CommitFile{file("README.md", "# app"), file("deploy/app.yaml", "replicas: 2")})
+			target := newFakeRepo("stash")
+			if tt.targetFiles != nil {
+				target.commit("local changes", tt.targetFiles)
+			}
+			s := &Syncer{
+				Source:         Endpoint{Client: &fakeClient{repo: source}, Repository: ref("source"), Branch: "main"},
+				Target:         Endpoint{Client: &fakeClient{repo: target}, Repository: ref("target"), Branch: "main"},
+				ConflictPolicy: tt.policy,
+			}
+
+			res, err := s.Sync(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Sync() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if res.Commit == nil || !reflect.DeepEqual(res.Updated, tt.wantUpdated) || !reflect.DeepEqual(res.Deleted, tt.wantDeleted) {
+				t.Errorf("Sync() = %+v, want updated %v and deleted %v", res, tt.wantUpdated, tt.wantDeleted)
+			}
+			if !reflect.DeepEqual(target.head(), tt.wantTarget) {
+				t.Errorf("Sync() target content = %v, want %v", target.head(), tt.wantTarget)
+			}
+			if sha, ok := sourceCommit(res.Commit.Get().Message); !ok || sha != "sha1" {
+				t.Errorf("Sync() commit message %q doesn't record the source commit", res.Commit.Get().Message)
+			}
+
+			// Syncing again without source changes is a no-op, also with ConflictPolicyFail
+			s.ConflictPolicy = ConflictPolicyFail
+			commits := len(target.commits)
+			if res, err := s.Sync(ctx); err != nil || res.Commit != nil || len(target.commits) != commits {
+				t.Errorf("Sync() = %+v, %v, want no commit", res, err)
+			}
+
+			// New source commits are synced
+			source.commit("update", []gitprovider.CommitFile{file("deploy/app.yaml", "replicas: 3")})
+			if res, err := s.Sync(ctx); err != nil || !reflect.DeepEqual(res.Updated, []string{"deploy/app.yaml"}) {
+				t.Errorf("Sync() = %+v, %v, want deploy/app.yaml updated", res, err)
+			}
+			if target.head()["deploy/app.yaml"] != "replicas: 3" {
+				t.Errorf("Sync() target content = %v, want the updated file", target.head())
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package requiredfiles makes sure a set of files, e.g. SECURITY.md, CODEOWNERS or workflow files,
+// exists with the expected content in all repositories of an organization. The default branch of
+// every repository is compared to the expected content, and the drifted files are committed to a
+// branch, with a pull request to the default branch for the repository owners to review.
+//
+// The head branch marks the pending changes: a pull request is opened when the branch is created,
+// and later drift updates the existing branch. The branch should be deleted when the pull request
+// is merged or closed, e.g. with RepositoryInfo.DeleteBranchOnMerge.
+package requiredfiles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+const (
+	// DefaultBranch is the head branch of the pull requests, unless set with Enforcer.Branch.
+	DefaultBranch = "required-files"
+	// DefaultTitle is the title of the pull requests, unless set with Enforcer.Title.
+	DefaultTitle = "Update required files"
+)
+
+// Enforcer makes sure the Files exist with the expected content in the repositories of the
+// Organization. Archived and empty repositories are skipped.
+//
+// The files are committed as text, so they are meant to be configuration and documentation.
+// The GitLab commit API is used to create files only, so files which already exist with other
+// content in a GitLab repository can't be updated.
+type Enforcer struct {
+	// Client is the client for the Git provider of the organization.
+	// +required
+	Client gitprovider.Client
+
+	// Organization is the organization whose repositories are checked.
+	// +required
+	Organization gitprovider.OrganizationRef
+
+	// Files is the expected content by path.
+	// +required
+	Files map[string]string
+
+	// Branch is the head branch of the pull requests. Default: DefaultBranch
+	// +optional
+	Branch string
+
+	// Title is the title of the pull requests, and the subject of their commits.
+	// Default: DefaultTitle
+	// +optional
+	Title string
+
+	// Description is the description of the pull requests.
+	// +optional
+	Description string
+
+	// Filter only checks the repositories it returns true for, if set.
+	// +optional
+	Filter func(gitprovider.OrgRepository) bool
+
+	// Concurrency is the number of repositories checked at once. Default: 1
+	// +optional
+	Concurrency int
+}
+
+// Result describes the outcome for a single repository.
+type Result struct {
+	// Repository is the checked repository.
+	Repository gitprovider.OrgRepositoryRef
+
+	// Skipped is true if the repository is archived or empty.
+	Skipped bool
+
+	// Drifted lists the paths of the files missing or with other content in the default branch.
+	Drifted []string
+
+	// Commit is the commit created in the head branch, or nil if it was up-to-date.
+	Commit gitprovider.Commit
+
+	// PullRequest is the pull request opened for the head branch, or nil if the default branch
+	// was up-to-date or the branch already existed.
+	PullRequest gitprovider.PullRequest
+}
+
+// Enforce checks every repository of the organization once, and returns the results of the
+// repositories which didn't fail, in the order they are listed by the provider. The failures of
+// single repositories don't stop the others, and are returned in a *gitprovider.AggregateError.
+func (e *Enforcer) Enforce(ctx context.Context) ([]*Result, error) {
+	if len(e.Files) == 0 {
+		return nil, fmt.Errorf("no required files: %w", gitprovider.ErrInvalidArgument)
+	}
+	for p := range e.Files {
+		if p == "" || strings.HasPrefix(p, "/") {
+			return nil, fmt.Errorf("invalid required file path %q: %w", p, gitprovider.ErrInvalidArgument)
+		}
+	}
+	repos, err := e.Client.OrgRepositories().List(ctx, e.Organization)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the repositories of %s: %w", e.Organization.String(), err)
+	}
+	if e.Filter != nil {
+		filtered := repos[:0]
+		for _, repo := range repos {
+			if e.Filter(repo) {
+				filtered = append(filtered, repo)
+			}
+		}
+		repos = filtered
+	}
+
+	results := make([]*Result, len(repos))
+	aggErr := &gitprovider.AggregateError{}
+	// Failures are aggregated, so no call fails and all repositories are checked
+	_ = gitprovider.RunConcurrently(e.Concurrency, len(repos), func(i int) error {
+		ref := repos[i].Repository().(gitprovider.OrgRepositoryRef)
+		res, err := e.enforce(ctx, repos[i])
+		if err != nil {
+			aggErr.Add(ref.String(), err)
+			return nil
+		}
+		res.Repository = ref
+		results[i] = res
+		return nil
+	})
+
+	succeeded := make([]*Result, 0, len(results))
+	for _, res := range results {
+		if res != nil {
+			succeeded = append(succeeded, res)
+		}
+	}
+	return succeeded, aggErr.ErrorOrNil()
+}
+
+// enforce checks a single repository.
+func (e *Enforcer) enforce(ctx context.Context, repo gitprovider.OrgRepository) (*Result, error) {
+	info := repo.Get()
+	if info.Archived != nil && *info.Archived || info.DefaultBranch == nil {
+		return &Result{Skipped: true}, nil
+	}
+	defaultBranch := *info.DefaultBranch
+	base, err := headCommit(ctx, repo, defaultBranch)
+	if errors.Is(err, gitprovider.ErrEmptyRepository) {
+		return &Result{Skipped: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the head of %q: %w", defaultBranch, err)
+	}
+
+	res := &Result{}
+	res.Drifted, err = e.drifted(ctx, repo, base.Get().Sha)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Drifted) == 0 {
+		return res, nil
+	}
+
+	branch := e.Branch
+	if branch == "" {
+		branch = DefaultBranch
+	}
+	title := e.Title
+	if title == "" {
+		title = DefaultTitle
+	}
+	head, err := headCommit(ctx, repo, branch)
+	created := errors.Is(err, gitprovider.ErrNotFound)
+	if created {
+		if err := repo.Branches().Create(ctx, branch, base.Get().Sha); err != nil {
+			return nil, fmt.Errorf("failed to create the branch %q: %w", branch, err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get the head of %q: %w", branch, err)
+	}
+
+	// Only commit the files which differ in the head branch
+	toCommit := res.Drifted
+	if !created {
+		if toCommit, err = e.drifted(ctx, repo, head.Get().Sha); err != nil {
+			return nil, err
+		}
+	}
+	if len(toCommit) != 0 {
+		files := make([]gitprovider.CommitFile, 0, len(toCommit))
+		for _, p := range toCommit {
+			content := e.Files[p]
+			files = append(files, gitprovider.CommitFile{Path: gitprovider.StringVar(p), Content: &content})
+		}
+		res.Commit, err = repo.Commits().Create(ctx, branch, title, files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit to the branch %q: %w", branch, err)
+		}
+	}
+
+	if created {
+		res.PullRequest, err = repo.PullRequests().Create(ctx, title, branch, defaultBranch, e.Description)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the pull request: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// drifted returns the sorted paths of the required files which are missing or have other content
+// at the given commit, comparing the Git blob SHAs reported by the provider.
+func (e *Enforcer) drifted(ctx context.Context, repo gitprovider.OrgRepository, sha string) ([]string, error) {
+	var drifted []string
+	for _, p := range sortedKeys(e.Files) {
+		rc, info, err := repo.Files().Open(ctx, p, sha)
+		if errors.Is(err, gitprovider.ErrNotFound) {
+			drifted = append(drifted, p)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s@%s: %w", p, sha, err)
+		}
+		rc.Close()
+		if info == nil || info.SHA != plumbing.ComputeHash(plumbing.BlobObject, []byte(e.Files[p])).String() {
+			drifted = append(drifted, p)
+		}
+	}
+	return drifted, nil
+}
+
+// headCommit returns the latest commit of the branch.
+func headCommit(ctx context.Context, repo gitprovider.UserRepository, branch string) (gitprovider.Commit, error) {
+	commits, err := repo.Commits().ListPage(ctx, branch, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("branch %q has no commits: %w", branch, gitprovider.ErrNotFound)
+	}
+	return commits[0], nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requiredfiles
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/fakeprovider"
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestEnforce(t *testing.T) {
+	ctx := context.Background()
+	c, err := fakeprovider.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	org := gitprovider.OrganizationRef{Domain: fakeprovider.DefaultDomain, Organization: "acme"}
+	c.Server().AddOrganization(org, gitprovider.OrganizationInfo{})
+	newRepo := func(name string, info gitprovider.RepositoryInfo, files map[string]string) gitprovider.OrgRepository {
+		ref := gitprovider.OrgRepositoryRef{OrganizationRef: org, RepositoryName: name}
+		repo, err := c.OrgRepositories().Create(ctx, ref, info, &gitprovider.RepositoryCreateOptions{AutoInit: gitprovider.BoolVar(true)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for p, content := range files {
+			if _, err := repo.Commits().Create(ctx, "main", "add "+p, []gitprovider.CommitFile{{Path: gitprovider.StringVar(p), Content: gitprovider.StringVar(content)}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return repo
+	}
+	newRepo("compliant", gitprovider.RepositoryInfo{}, map[string]string{"SECURITY.md": "report to security@", "CODEOWNERS": "* @acme/owners"})
+	drifted := newRepo("drifted", gitprovider.RepositoryInfo{}, map[string]string{"SECURITY.md": "old"})
+	newRepo("archived", gitprovider.RepositoryInfo{Archived: gitprovider.BoolVar(true)}, nil)
+
+	e := &Enforcer{
+		Client:       c,
+		Organization: org,
+		Files:        map[string]string{"SECURITY.md": "report to security@", "CODEOWNERS": "* @acme/owners"},
+		Concurrency:  2,
+	}
+	results, err := e.Enforce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]*Result{}
+	for _, res := range results {
+		byName[res.Repository.RepositoryName] = res
+	}
+	if len(byName) != 3 {
+		t.Fatalf("Enforce() returned %d results, want 3", len(results))
+	}
+	if res := byName["compliant"]; len(res.Drifted) != 0 || res.PullRequest != nil {
+		t.Errorf("compliant repository result = %+v, want no drift", res)
+	}
+	if res := byName["archived"]; !res.Skipped {
+		t.Errorf("archived repository result = %+v, want skipped", res)
+	}
+	res := byName["drifted"]
+	if want := []string{"CODEOWNERS", "SECURITY.md"}; !reflect.DeepEqual(res.Drifted, want) {
+		t.Errorf("Drifted = %v, want %v", res.Drifted, want)
+	}
+	if res.Commit == nil || res.PullRequest == nil {
+		t.Fatalf("drifted repository result = %+v, want a commit and a pull request", res)
+	}
+	files, err := drifted.Files().Get(ctx, "CODEOWNERS", DefaultBranch)
+	if err != nil || *files[0].Content != "* @acme/owners" {
+		t.Errorf("CODEOWNERS of the head branch = %v, %v", files, err)
+	}
+
+	// The existing branch is updated once the expected content changes, without another pull request
+	results, err = e.Enforce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results {
+		if res.Commit != nil || res.PullRequest != nil {
+			t.Errorf("second Enforce() result for %s = %+v, want no changes", res.Repository.RepositoryName, res)
+		}
+	}
+	e.Files["CODEOWNERS"] = "* @acme/maintainers"
+	e.Filter = func(repo gitprovider.OrgRepository) bool {
+		return repo.Repository().GetRepository() == "drifted"
+	}
+	results, err = e.Enforce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Commit == nil || results[0].PullRequest != nil {
+		t.Errorf("Enforce() after a change = %+v, want a commit to the existing branch", results)
+	}
+	prs, err := drifted.PullRequests().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 1 {
+		t.Errorf("got %d pull requests, want 1", len(prs))
+	}
+}
@@ -58,6 +58,8 @@ type UserRepository interface {
 	Deletable
 	// RepositoryBound returns repository reference details.
 	RepositoryBound
+	// Timestamped returns the creation and update times of the repository.
+	Timestamped
 
 	// Get returns high-level information about this repository.
 	Get() RepositoryInfo
@@ -79,6 +81,30 @@ type UserRepository interface {
 
 	// Files gives access to this specific repository pull requests
 	Files() FileClient
+
+	// Milestones gives access to this specific repository milestones
+	Milestones() MilestoneClient
+
+	// MergeQueue gives access to this specific repository merge queue
+	MergeQueue() MergeQueueClient
+
+	// Collaborators gives access to the individual users with access to this specific repository
+	Collaborators() CollaboratorClient
+
+	// Reactions gives access to the emoji reactions on this specific repository issues and pull requests
+	Reactions() ReactionClient
+
+	// Environments gives access to the protection rules of this specific repository deployment environments
+	Environments() EnvironmentClient
+
+	// Badges gives access to the URLs of this specific repository status badges
+	Badges() BadgeClient
+
+	// TagProtection gives access to the rules protecting this specific repository tags
+	TagProtection() TagProtectionClient
+
+	// ApprovalRules gives access to the pull request approval rules of this specific repository
+	ApprovalRules() ApprovalRuleClient
 }
 
 // OrgRepository describes a repository owned by an organization.
@@ -108,6 +134,8 @@ type DeployKey interface {
 	Deletable
 	// RepositoryBound returns repository reference details.
 	RepositoryBound
+	// Timestamped returns the creation time of the deploy key.
+	Timestamped
 
 	// Get returns high-level information about this deploy key.
 	Get() DeployKeyInfo
@@ -147,12 +175,44 @@ type Commit interface {
 	Get() CommitInfo
 }
 
+// Branch represents a branch of a repository.
+type Branch interface {
+	// Object implements the Object interface,
+	// allowing access to the underlying object returned from the API.
+	Object
+
+	// Get returns high-level information about this branch.
+	Get() BranchInfo
+}
+
 // PullRequest represents a pull request.
 type PullRequest interface {
 	// Object implements the Object interface,
 	// allowing access to the underlying object returned from the API.
 	Object
+	// Timestamped returns the creation and update times of the pull request.
+	Timestamped
 
 	// Get returns high-level information about this pull request.
 	Get() PullRequestInfo
 }
+
+// PullRequestComment represents a comment on a pull request.
+type PullRequestComment interface {
+	// Object implements the Object interface,
+	// allowing access to the underlying object returned from the API.
+	Object
+
+	// Get returns high-level information about this comment.
+	Get() PullRequestCommentInfo
+}
+
+// Milestone represents a milestone.
+type Milestone interface {
+	// Object implements the Object interface,
+	// allowing access to the underlying object returned from the API.
+	Object
+
+	// Get returns high-level information about this milestone.
+	Get() MilestoneInfo
+}
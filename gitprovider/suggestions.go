@@ -0,0 +1,233 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// suggestionMarkerPrefix starts the hidden marker of the comments formatted by FormatSuggestion,
+	// which holds the location of the suggestion.
+	suggestionMarkerPrefix = "<!-- gitprovider:suggestion "
+	suggestionMarkerSuffix = " -->"
+)
+
+// suggestionBlockRegexp matches the opening fence of a suggestion block, as used in review comments
+// on GitHub and GitLab. GitLab suggestions can extend the commented line, e.g. "suggestion:-2+0"
+// spans the two lines above as well.
+var suggestionBlockRegexp = regexp.MustCompile("(?m)^(```+)suggestion(?::-(\\d+)\\+(\\d+))?[ \t]*\r?$")
+
+// Suggestion is a change suggested to a range of lines of a file, e.g. by a reviewer of a pull
+// request. Suggestions are applied as commits to the pull request branch with ApplySuggestion.
+type Suggestion struct {
+	// Path is the path of the file in the repository.
+	// +required
+	Path string `json:"path"`
+
+	// StartLine is the 1-based number of the first suggested line.
+	// +required
+	StartLine int `json:"startLine"`
+
+	// EndLine is the 1-based number of the last suggested line, inclusive.
+	// +required
+	EndLine int `json:"endLine"`
+
+	// Replacement is the content replacing the lines. An empty replacement deletes the lines.
+	// It's stored in the suggestion block of the comment, not in the marker.
+	// +optional
+	Replacement string `json:"-"`
+
+	// Original is the content of the lines the suggestion was made on. If set, ApplySuggestion
+	// fails with ErrOutdatedSuggestion if the lines changed since.
+	// +optional
+	Original *string `json:"original,omitempty"`
+}
+
+// ValidateSuggestion validates the suggestion.
+func ValidateSuggestion(s Suggestion) error {
+	if s.Path == "" {
+		return fmt.Errorf("suggestion must have a path: %w", ErrInvalidArgument)
+	}
+	if s.StartLine < 1 || s.EndLine < s.StartLine {
+		return fmt.Errorf("suggestion lines %d-%d of %q must be a 1-based range: %w", s.StartLine, s.EndLine, s.Path, ErrInvalidArgument)
+	}
+	return nil
+}
+
+// FormatSuggestion returns the comment body of the suggestion, which renders as a suggestion
+// block and can be read back with ParseSuggestion.
+func FormatSuggestion(s Suggestion) (string, error) {
+	if err := ValidateSuggestion(s); err != nil {
+		return "", err
+	}
+	// json.Marshal escapes "<" and ">", so the marker can't be terminated early by the original
+	marker, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	text := fmt.Sprintf("Suggested change to `%s`, lines %d-%d:", s.Path, s.StartLine, s.EndLine)
+	return suggestionMarkerPrefix + string(marker) + suggestionMarkerSuffix + "\n" + text + "\n" + suggestionBlock(s.Replacement), nil
+}
+
+// ParseSuggestion returns the suggestion in the comment body, and false if the comment wasn't
+// formatted by FormatSuggestion.
+func ParseSuggestion(body string) (Suggestion, bool) {
+	if !strings.HasPrefix(body, suggestionMarkerPrefix) {
+		return Suggestion{}, false
+	}
+	end := strings.Index(body, suggestionMarkerSuffix)
+	if end < 0 {
+		return Suggestion{}, false
+	}
+	var s Suggestion
+	if err := json.Unmarshal([]byte(body[len(suggestionMarkerPrefix):end]), &s); err != nil {
+		return Suggestion{}, false
+	}
+	replacement, above, below, ok := parseSuggestionBlock(body[end:])
+	if !ok || above != 0 || below != 0 {
+		return Suggestion{}, false
+	}
+	s.Replacement = replacement
+	if ValidateSuggestion(s) != nil {
+		return Suggestion{}, false
+	}
+	return s, true
+}
+
+// SuggestionFromReviewComment returns the suggestion of a review comment of the provider, which
+// is made on the lines startLine to endLine of the file at path, and false if the comment body
+// doesn't have a suggestion block. For single-line comments, startLine and endLine are equal.
+// GitLab suggestions extending the commented lines, e.g. "suggestion:-2+0", are supported.
+func SuggestionFromReviewComment(path string, startLine, endLine int, body string) (Suggestion, bool) {
+	replacement, above, below, ok := parseSuggestionBlock(body)
+	if !ok {
+		return Suggestion{}, false
+	}
+	s := Suggestion{
+		Path:        path,
+		StartLine:   startLine - above,
+		EndLine:     endLine + below,
+		Replacement: replacement,
+	}
+	if ValidateSuggestion(s) != nil {
+		return Suggestion{}, false
+	}
+	return s, true
+}
+
+// ApplySuggestion commits the suggestion to the given branch of the repository, e.g. the branch
+// of the pull request it was made on. If message is empty, a default commit message is used.
+//
+// ErrOutdatedSuggestion is returned if the suggestion has its original lines set, and they changed.
+// ErrInvalidArgument is returned if the file doesn't have the suggested lines.
+func ApplySuggestion(ctx context.Context, repo UserRepository, branch, message string, s Suggestion) (Commit, error) {
+	if err := ValidateSuggestion(s); err != nil {
+		return nil, err
+	}
+	files, err := repo.Files().Get(ctx, s.Path, branch)
+	if err != nil {
+		return nil, err
+	}
+	var content *string
+	for _, file := range files {
+		if file.Path != nil && *file.Path == s.Path {
+			content = file.Content
+		}
+	}
+	if content == nil {
+		return nil, fmt.Errorf("file %q at %q: %w", s.Path, branch, ErrNotFound)
+	}
+	newContent, err := applySuggestion(*content, s)
+	if err != nil {
+		return nil, err
+	}
+	if message == "" {
+		message = fmt.Sprintf("Apply suggestion to %s", s.Path)
+	}
+	return repo.Commits().Create(ctx, branch, message, []CommitFile{{Path: &s.Path, Content: &newContent}})
+}
+
+// applySuggestion returns the content with the suggested lines replaced.
+func applySuggestion(content string, s Suggestion) (string, error) {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if s.EndLine > len(lines) {
+		return "", fmt.Errorf("suggestion lines %d-%d are out of range, %q has %d lines: %w", s.StartLine, s.EndLine, s.Path, len(lines), ErrInvalidArgument)
+	}
+	suggested := lines[s.StartLine-1 : s.EndLine]
+	if s.Original != nil && strings.TrimSuffix(strings.Join(suggested, ""), "\n") != strings.TrimSuffix(*s.Original, "\n") {
+		return "", fmt.Errorf("lines %d-%d of %q: %w", s.StartLine, s.EndLine, s.Path, ErrOutdatedSuggestion)
+	}
+	replacement := s.Replacement
+	// Keep the line ending of the last suggested line
+	if replacement != "" && !strings.HasSuffix(replacement, "\n") && strings.HasSuffix(suggested[len(suggested)-1], "\n") {
+		replacement += "\n"
+	}
+	return strings.Join(lines[:s.StartLine-1], "") + replacement + strings.Join(lines[s.EndLine:], ""), nil
+}
+
+// suggestionBlock returns the suggestion block with the replacement. The fence is longer than any
+// run of backticks in the replacement, so that it can't be closed early.
+func suggestionBlock(replacement string) string {
+	fence := "```"
+	for strings.Contains(replacement, fence) {
+		fence += "`"
+	}
+	if replacement != "" && !strings.HasSuffix(replacement, "\n") {
+		replacement += "\n"
+	}
+	return fence + "suggestion\n" + replacement + fence
+}
+
+// parseSuggestionBlock returns the replacement of the first suggestion block of the comment body,
+// along with the number of lines the suggestion extends above and below the commented lines.
+func parseSuggestionBlock(body string) (replacement string, above, below int, ok bool) {
+	loc := suggestionBlockRegexp.FindStringSubmatchIndex(body)
+	if loc == nil {
+		return "", 0, 0, false
+	}
+	fence := body[loc[2]:loc[3]]
+	if loc[4] >= 0 {
+		above, _ = strconv.Atoi(body[loc[4]:loc[5]])
+		below, _ = strconv.Atoi(body[loc[6]:loc[7]])
+	}
+	rest := body[loc[1]:]
+	if !strings.HasPrefix(rest, "\n") {
+		return "", 0, 0, false
+	}
+	rest = strings.ReplaceAll(rest[1:], "\r\n", "\n")
+	// The block ends with the first line consisting of the same fence
+	if strings.HasPrefix(rest, fence+"\n") || rest == fence {
+		return "", above, below, true
+	}
+	end := strings.Index(rest, "\n"+fence+"\n")
+	if end < 0 {
+		if !strings.HasSuffix(rest, "\n"+fence) {
+			return "", 0, 0, false
+		}
+		end = len(rest) - len(fence) - 1
+	}
+	return rest[:end+1], above, below, true
+}
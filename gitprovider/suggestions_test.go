@@ -0,0 +1,284 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// suggestionRepository stores the files of a single branch in memory.
+type suggestionRepository struct {
+	UserRepository
+	files   map[string]string
+	message string
+}
+
+func (r *suggestionRepository) Files() FileClient     { return suggestionFiles{repo: r} }
+func (r *suggestionRepository) Commits() CommitClient { return suggestionCommits{repo: r} }
+
+type suggestionFiles struct {
+	FileClient
+	repo *suggestionRepository
+}
+
+func (f suggestionFiles) Get(_ context.Context, path, _ string) ([]*CommitFile, error) {
+	content, ok := f.repo.files[path]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return []*CommitFile{{Path: &path, Content: &content}}, nil
+}
+
+type suggestionCommits struct {
+	CommitClient
+	repo *suggestionRepository
+}
+
+type testCommit struct{ info CommitInfo }
+
+func (c testCommit) Get() CommitInfo        { return c.info }
+func (c testCommit) APIObject() interface{} { return &c.info }
+
+func (c suggestionCommits) Create(_ context.Context, _, message string, files []CommitFile) (Commit, error) {
+	for _, file := range files {
+		c.repo.files[*file.Path] = *file.Content
+	}
+	c.repo.message = message
+	return testCommit{CommitInfo{Message: message}}, nil
+}
+
+func TestFormatSuggestion(t *testing.T) {
+	tests := []struct {
+		name       string
+		suggestion Suggestion
+		wantErr    error
+	}{
+		{
+			name:       "single line",
+			suggestion: Suggestion{Path: "main.go", StartLine: 3, EndLine: 3, Replacement: "\treturn nil\n"},
+		},
+		{
+			name:       "deletion",
+			suggestion: Suggestion{Path: "main.go", StartLine: 3, EndLine: 5},
+		},
+		{
+			name:       "replacement with a code block",
+			suggestion: Suggestion{Path: "README.md", StartLine: 1, EndLine: 2, Replacement: "```go\nfoo()\n```\n"},
+		},
+		{
+			name:       "original lines",
+			suggestion: Suggestion{Path: "main.go", StartLine: 1, EndLine: 1, Replacement: "a\n", Original: StringVar("<!-- b -->")},
+		},
+		{
+			name:       "missing path",
+			suggestion: Suggestion{StartLine: 1, EndLine: 1},
+			wantErr:    ErrInvalidArgument,
+		},
+		{
+			name:       "inverted range",
+			suggestion: Suggestion{Path: "main.go", StartLine: 2, EndLine: 1},
+			wantErr:    ErrInvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := FormatSuggestion(tt.suggestion)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FormatSuggestion() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, ok := ParseSuggestion(body)
+			if !ok {
+				t.Fatalf("ParseSuggestion(%q) failed", body)
+			}
+			if !reflect.DeepEqual(got, tt.suggestion) {
+				t.Errorf("ParseSuggestion() = %#v, want %#v", got, tt.suggestion)
+			}
+		})
+	}
+}
+
+func TestParseSuggestion(t *testing.T) {
+	for _, body := range []string{
+		"LGTM",
+		"```suggestion\nfoo\n```",
+		"<!-- gitprovider:suggestion {\"path\":\"main.go\",\"startLine\":1,\"endLine\":1} -->\nno block",
+		"<!-- gitprovider:suggestion {\"path\":\"main.go\",\"startLine\":0,\"endLine\":1} -->\n```suggestion\nfoo\n```",
+		"<!-- gitprovider:suggestion {\"path\":\"main.go\",\"startLine\":1,\"endLine\":1} -->\n```suggestion\nunterminated\n",
+	} {
+		if s, ok := ParseSuggestion(body); ok {
+			t.Errorf("ParseSuggestion(%q) = %#v, want no suggestion", body, s)
+		}
+	}
+}
+
+func TestSuggestionFromReviewComment(t *testing.T) {
+	tests := []struct {
+		name      string
+		startLine int
+		endLine   int
+		body      string
+		want      Suggestion
+		wantOK    bool
+	}{
+		{
+			name:      "GitHub single line",
+			startLine: 4,
+			endLine:   4,
+			body:      "Nit:\n```suggestion\nfoo := 1\n```\nThanks!",
+			want:      Suggestion{Path: "main.go", StartLine: 4, EndLine: 4, Replacement: "foo := 1\n"},
+			wantOK:    true,
+		},
+		{
+			name:      "GitHub multi-line, CRLF",
+			startLine: 4,
+			endLine:   6,
+			body:      "```suggestion\r\nfoo\r\nbar\r\n```",
+			want:      Suggestion{Path: "main.go", StartLine: 4, EndLine: 6, Replacement: "foo\nbar\n"},
+			wantOK:    true,
+		},
+		{
+			name:      "GitLab range",
+			startLine: 4,
+			endLine:   4,
+			body:      "```suggestion:-2+1\nfoo\n```",
+			want:      Suggestion{Path: "main.go", StartLine: 2, EndLine: 5, Replacement: "foo\n"},
+			wantOK:    true,
+		},
+		{
+			name:      "deletion",
+			startLine: 4,
+			endLine:   4,
+			body:      "```suggestion\n```",
+			want:      Suggestion{Path: "main.go", StartLine: 4, EndLine: 4},
+			wantOK:    true,
+		},
+		{
+			name:      "no suggestion",
+			startLine: 4,
+			endLine:   4,
+			body:      "```go\nfoo\n```",
+		},
+		{
+			name:      "range before the first line",
+			startLine: 1,
+			endLine:   1,
+			body:      "```suggestion:-1+0\nfoo\n```",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SuggestionFromReviewComment("main.go", tt.startLine, tt.endLine, tt.body)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SuggestionFromReviewComment() = %#v, %v, want %#v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestApplySuggestion(t *testing.T) {
+	const content = "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+	tests := []struct {
+		name        string
+		content     string
+		suggestion  Suggestion
+		message     string
+		want        string
+		wantMessage string
+		wantErr     error
+	}{
+		{
+			name:        "replace a line",
+			content:     content,
+			suggestion:  Suggestion{Path: "main.go", StartLine: 4, EndLine: 4, Replacement: "\tprintln(\"world\")"},
+			want:        "package main\n\nfunc main() {\n\tprintln(\"world\")\n}\n",
+			wantMessage: "Apply suggestion to main.go",
+		},
+		{
+			name:        "replace lines with more lines",
+			content:     content,
+			suggestion:  Suggestion{Path: "main.go", StartLine: 3, EndLine: 5, Replacement: "func main() {}\n\nfunc other() {}\n"},
+			message:     "Accept review suggestion",
+			want:        "package main\n\nfunc main() {}\n\nfunc other() {}\n",
+			wantMessage: "Accept review suggestion",
+		},
+		{
+			name:        "delete lines",
+			content:     content,
+			suggestion:  Suggestion{Path: "main.go", StartLine: 2, EndLine: 2},
+			want:        "package main\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+			wantMessage: "Apply suggestion to main.go",
+		},
+		{
+			name:        "last line without newline",
+			content:     "a\nb",
+			suggestion:  Suggestion{Path: "main.go", StartLine: 2, EndLine: 2, Replacement: "c\n"},
+			want:        "a\nc\n",
+			wantMessage: "Apply suggestion to main.go",
+		},
+		{
+			name:        "original lines unchanged",
+			content:     content,
+			suggestion:  Suggestion{Path: "main.go", StartLine: 1, EndLine: 1, Replacement: "package app", Original: StringVar("package main")},
+			want:        "package app\n\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+			wantMessage: "Apply suggestion to main.go",
+		},
+		{
+			name:       "original lines changed",
+			content:    content,
+			suggestion: Suggestion{Path: "main.go", StartLine: 1, EndLine: 1, Replacement: "package app", Original: StringVar("package old")},
+			wantErr:    ErrOutdatedSuggestion,
+		},
+		{
+			name:       "out of range",
+			content:    content,
+			suggestion: Suggestion{Path: "main.go", StartLine: 5, EndLine: 6},
+			wantErr:    ErrInvalidArgument,
+		},
+		{
+			name:       "missing file",
+			suggestion: Suggestion{Path: "other.go", StartLine: 1, EndLine: 1},
+			wantErr:    ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &suggestionRepository{files: map[string]string{"main.go": tt.content}}
+			_, err := ApplySuggestion(context.Background(), repo, "feature", tt.message, tt.suggestion)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApplySuggestion() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if repo.message != "" {
+					t.Errorf("ApplySuggestion() committed %q on error", repo.message)
+				}
+				return
+			}
+			if got := repo.files["main.go"]; got != tt.want {
+				t.Errorf("ApplySuggestion() content = %q, want %q", got, tt.want)
+			}
+			if repo.message != tt.wantMessage {
+				t.Errorf("ApplySuggestion() message = %q, want %q", repo.message, tt.wantMessage)
+			}
+		})
+	}
+}
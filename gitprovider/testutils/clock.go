@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// FakeClock is a gitprovider.Clock where time only passes when Advance is called.
+// It can be given to a client through gitprovider.WithClock, to test retry, backoff and
+// polling behavior deterministically.
+type FakeClock struct {
+	mu       sync.Mutex
+	now      time.Time
+	sleepers []*sleeper
+}
+
+// sleeper is a goroutine blocked in FakeClock.Sleep.
+type sleeper struct {
+	until time.Time
+	done  chan struct{}
+}
+
+// FakeClock implements gitprovider.Clock.
+var _ gitprovider.Clock = &FakeClock{}
+
+// NewFakeClock returns a FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep blocks until the fake time has been advanced by d, or ctx is done.
+func (c *FakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	if d <= 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	s := &sleeper{until: c.now.Add(d), done: make(chan struct{})}
+	c.sleepers = append(c.sleepers, s)
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		c.remove(s)
+		return ctx.Err()
+	case <-s.done:
+		return nil
+	}
+}
+
+// Advance moves the fake time forward by d, waking up all sleepers whose duration has passed.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+
+	remaining := c.sleepers[:0]
+	for _, s := range c.sleepers {
+		if !s.until.After(c.now) {
+			close(s.done)
+			continue
+		}
+		remaining = append(remaining, s)
+	}
+	c.sleepers = remaining
+}
+
+// Sleepers returns the number of goroutines currently blocked in Sleep.
+// It is useful for waiting until the code under test is sleeping before calling Advance.
+func (c *FakeClock) Sleepers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sleepers)
+}
+
+// BlockUntil waits until at least n goroutines are blocked in Sleep, or ctx is done.
+func (c *FakeClock) BlockUntil(ctx context.Context, n int) error {
+	for c.Sleepers() < n {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+	return nil
+}
+
+func (c *FakeClock) remove(s *sleeper) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, other := range c.sleepers {
+		if other == s {
+			c.sleepers = append(c.sleepers[:i], c.sleepers[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// FeatureTarget is what CheckFeatureSupport probes the features of a client with. The
+// organization and repository don't have to exist, they only need to be resource objects of
+// the client, so that the probes can reach the sub-clients.
+type FeatureTarget struct {
+	// Client is the client to probe.
+	Client gitprovider.Client
+	// Organization is used for the features of organizations, e.g. teams.
+	Organization gitprovider.Organization
+	// Repository is used for the features of repositories, e.g. deploy keys.
+	Repository gitprovider.OrgRepository
+}
+
+// FeatureProbe calls a method of the high-level API that needs a specific feature, and returns
+// its error.
+type FeatureProbe func(ctx context.Context, target FeatureTarget) error
+
+// FeatureProbes maps every feature to a probe calling a method that needs it.
+//
+//nolint:gochecknoglobals
+var FeatureProbes = map[gitprovider.Feature]FeatureProbe{
+	gitprovider.FeatureOrganizations: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.Organizations().List(ctx)
+		return err
+	},
+	gitprovider.FeatureSubOrganizations: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.Organizations().Children(ctx, target.Organization.Organization())
+		return err
+	},
+	gitprovider.FeatureTeams: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Organization.Teams().List(ctx)
+		return err
+	},
+	gitprovider.FeatureOrgRepositories: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.OrgRepositories().Get(ctx, orgRepositoryRef(target))
+		return err
+	},
+	gitprovider.FeatureUserRepositories: func(ctx context.Context, target FeatureTarget) error {
+		ref := orgRepositoryRef(target)
+		_, err := target.Client.UserRepositories().Get(ctx, gitprovider.UserRepositoryRef{
+			UserRef:        gitprovider.UserRef{Domain: ref.Domain, UserLogin: ref.Organization},
+			RepositoryName: ref.RepositoryName,
+		})
+		return err
+	},
+	gitprovider.FeatureDeployKeys: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.DeployKeys().List(ctx)
+		return err
+	},
+	gitprovider.FeatureTeamAccess: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.TeamAccess().List(ctx)
+		return err
+	},
+	gitprovider.FeatureCollaborators: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Collaborators().List(ctx)
+		return err
+	},
+	gitprovider.FeatureCommits: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Commits().ListPage(ctx, probeBranch, 1, 1)
+		return err
+	},
+	gitprovider.FeatureCommitStats: func(ctx context.Context, target FeatureTarget) error {
+		withStats := true
+		_, err := target.Repository.Commits().ListPage(ctx, probeBranch, 1, 1, &gitprovider.CommitListOptions{WithStats: &withStats})
+		return err
+	},
+	gitprovider.FeatureBranches: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Branches().List(ctx)
+		return err
+	},
+	gitprovider.FeaturePullRequests: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.PullRequests().List(ctx)
+		return err
+	},
+	gitprovider.FeatureFiles: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Files().Get(ctx, "README.md", probeBranch)
+		return err
+	},
+	gitprovider.FeatureMilestones: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Milestones().List(ctx)
+		return err
+	},
+	gitprovider.FeatureArchiveDownload: func(ctx context.Context, target FeatureTarget) error {
+		rc, err := target.Client.OrgRepositories().DownloadArchive(ctx, orgRepositoryRef(target), probeBranch, gitprovider.ArchiveFormatTarGz)
+		if err != nil {
+			return err
+		}
+		return rc.Close()
+	},
+	gitprovider.FeatureTokenPermissions: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.HasTokenPermission(ctx, gitprovider.TokenPermissionRWRepository)
+		return err
+	},
+	gitprovider.FeatureMergeQueue: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.MergeQueue().List(ctx, probeBranch)
+		return err
+	},
+	gitprovider.FeatureRepositoryTopics: func(ctx context.Context, target FeatureTarget) error {
+		info := target.Repository.Get()
+		info.Topics = []string{"probe"}
+		return target.Repository.Set(info)
+	},
+	gitprovider.FeatureReactions: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Reactions().List(ctx, gitprovider.ReactionSubject{Number: 1})
+		return err
+	},
+	gitprovider.FeatureEnvironmentProtection: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Environments().List(ctx)
+		return err
+	},
+	gitprovider.FeatureRepositorySearch: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.Search().Repositories(ctx, gitprovider.SearchQuery{Text: "probe"})
+		return err
+	},
+	gitprovider.FeatureCodeSearch: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.Search().Code(ctx, gitprovider.SearchQuery{Text: "probe", Repository: orgRepositoryRef(target)})
+		return err
+	},
+	gitprovider.FeatureBadges: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Badges().URL(ctx, gitprovider.BadgeRequest{Kind: gitprovider.BadgeKindPipeline, Workflow: "ci.yaml"})
+		return err
+	},
+	gitprovider.FeatureCommitComments: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Commits().ListComments(ctx, probeSHA)
+		return err
+	},
+	gitprovider.FeatureUserKeys: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.UserKeys().List(ctx, gitprovider.UserKeyTypeSSH)
+		return err
+	},
+	gitprovider.FeatureInvitationAcceptance: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.Invitations().List(ctx)
+		return err
+	},
+	gitprovider.FeatureEvents: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.Events().ListRepository(ctx, orgRepositoryRef(target), gitprovider.EventListOptions{})
+		return err
+	},
+	gitprovider.FeatureForkSync: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Branches().SyncForkWithUpstream(ctx, probeBranch)
+		return err
+	},
+	gitprovider.FeatureTagProtection: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.TagProtection().List(ctx)
+		return err
+	},
+	gitprovider.FeatureBranchRules: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.Branches().GetRules(ctx, probeBranch)
+		return err
+	},
+	gitprovider.FeatureRepositoryStats: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.OrgRepositories().Get(ctx, orgRepositoryRef(target), &gitprovider.RepositoryGetOptions{WithStats: gitprovider.BoolVar(true)})
+		return err
+	},
+	gitprovider.FeatureApprovalRules: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Repository.ApprovalRules().List(ctx)
+		return err
+	},
+	gitprovider.FeatureAccessTokens: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.Tokens().List(ctx, orgRepositoryRef(target))
+		return err
+	},
+	gitprovider.FeatureTokenInfo: func(ctx context.Context, target FeatureTarget) error {
+		_, err := target.Client.TokenInfo(ctx)
+		return err
+	},
+	gitprovider.FeaturePermissionValidation: func(ctx context.Context, target FeatureTarget) error {
+		return target.Client.ValidatePermissions(ctx, gitprovider.Permission{
+			Scope:      gitprovider.PermissionScopeContentsWrite,
+			Repository: orgRepositoryRef(target),
+		})
+	},
+}
+
+const (
+	// probeBranch is the branch the probes pass to methods needing one
+	probeBranch = "main"
+	// probeSHA is the commit the probes pass to methods needing one
+	probeSHA = "0000000000000000000000000000000000000000"
+)
+
+// CheckFeatureSupport runs the probe of every known feature against target, and returns an error
+// for each feature whose behavior diverges from what target.Client.SupportedFeatures() reports:
+// the probes of unsupported features must fail with gitprovider.ErrNoProviderSupport, and the
+// probes of supported features must not. Other errors, e.g. gitprovider.ErrNotFound, are
+// expected, as the target doesn't have to exist. Panicking probes are reported as errors too.
+func CheckFeatureSupport(ctx context.Context, target FeatureTarget) []error {
+	supported := map[gitprovider.Feature]bool{}
+	for _, feature := range target.Client.SupportedFeatures() {
+		supported[feature] = true
+	}
+
+	var errs []error
+	for _, feature := range gitprovider.KnownFeatures() {
+		probe, ok := FeatureProbes[feature]
+		if !ok {
+			errs = append(errs, fmt.Errorf("feature %q has no probe", feature))
+			continue
+		}
+		err := runProbe(ctx, probe, target)
+		noSupport := errors.Is(err, gitprovider.ErrNoProviderSupport)
+		switch {
+		case errors.Is(err, errProbePanicked):
+			errs = append(errs, fmt.Errorf("feature %q: %w", feature, err))
+		case supported[feature] && noSupport:
+			errs = append(errs, fmt.Errorf("feature %q is reported as supported, but got: %w", feature, err))
+		case !supported[feature] && !noSupport:
+			errs = append(errs, fmt.Errorf("feature %q is reported as unsupported, but got %v instead of ErrNoProviderSupport", feature, err))
+		}
+	}
+	return errs
+}
+
+// errProbePanicked is wrapped by the errors of the probes that panicked.
+var errProbePanicked = errors.New("probe panicked")
+
+// runProbe runs probe, turning panics into errors.
+func runProbe(ctx context.Context, probe FeatureProbe, target FeatureTarget) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", errProbePanicked, r)
+		}
+	}()
+	return probe(ctx, target)
+}
+
+// orgRepositoryRef returns the reference of the target repository.
+func orgRepositoryRef(target FeatureTarget) gitprovider.OrgRepositoryRef {
+	ref := target.Repository.Repository()
+	if orgRef, ok := ref.(gitprovider.OrgRepositoryRef); ok {
+		return orgRef
+	}
+	return gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: ref.GetDomain(), Organization: ref.GetIdentity()},
+		RepositoryName:  ref.GetRepository(),
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracerName is the name of the OpenTelemetry tracer used by all provider clients.
+	tracerName = "github.com/fluxcd/go-git-providers"
+
+	// OrganizationAttributeKey is the span attribute holding the organization (or user) a request
+	// operates on, e.g. "fluxcd".
+	OrganizationAttributeKey = attribute.Key("gitprovider.organization")
+	// RepositoryAttributeKey is the span attribute holding the full name of the repository a
+	// request operates on, e.g. "fluxcd/go-git-providers".
+	RepositoryAttributeKey = attribute.Key("gitprovider.repository")
+	// AnnotationAttributePrefix prefixes the span attributes of the request annotations (see
+	// WithRequestAnnotations), e.g. "gitprovider.annotation.tenant".
+	AnnotationAttributePrefix = "gitprovider.annotation."
+)
+
+var (
+	// idSegmentRegexp matches path segments that are numeric IDs or commit SHAs.
+	idSegmentRegexp = regexp.MustCompile(`^([0-9]+|[0-9a-f]{40})$`)
+)
+
+// tracingTransport is a http.RoundTripper that wraps every request to the Git provider in an
+// OpenTelemetry client span.
+type tracingTransport struct {
+	tracer trace.Tracer
+	next   http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	route, attrs := requestRoute(req.URL)
+	u := url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: req.URL.Path}
+	attrs = append(attrs,
+		semconv.HTTPMethodKey.String(req.Method),
+		semconv.HTTPURLKey.String(u.String()),
+		semconv.NetPeerNameKey.String(req.URL.Hostname()),
+	)
+	for k, v := range RequestAnnotations(req.Context()) {
+		attrs = append(attrs, attribute.String(AnnotationAttributePrefix+k, v))
+	}
+
+	ctx, span := t.tracer.Start(req.Context(), req.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(resp.StatusCode))
+	return resp, nil
+}
+
+// tracingTransportFunc returns a ChainableRoundTripperFunc that creates spans using tp.
+func tracingTransportFunc(tp trace.TracerProvider) ChainableRoundTripperFunc {
+	return func(in http.RoundTripper) http.RoundTripper {
+		if in == nil {
+			in = http.DefaultTransport
+		}
+		return &tracingTransport{tracer: tp.Tracer(tracerName), next: in}
+	}
+}
+
+// requestRoute returns a low-cardinality route for the API operation u refers to, e.g.
+// "/repos/{owner}/{repo}/keys/{id}" for "/repos/fluxcd/flux2/keys/1", and the organization
+// and repository attributes for it. The GitHub, GitLab and Bitbucket Server API layouts are
+// recognized, other paths only get their IDs replaced.
+func requestRoute(u *url.URL) (string, []attribute.KeyValue) {
+	segments := strings.Split(u.EscapedPath(), "/")
+	var org, repo string
+	for i := 0; i < len(segments); i++ {
+		next := func(n int) string {
+			if i+n < len(segments) {
+				s, _ := url.PathUnescape(segments[i+n])
+				return s
+			}
+			return ""
+		}
+		switch {
+		case segments[i] == "projects" && next(1) != "" && next(2) == "repos" && next(3) != "":
+			// Bitbucket Server: /rest/api/1.0/projects/{key}/repos/{slug}
+			org, repo = next(1), next(1)+"/"+next(3)
+			segments[i+1], segments[i+3] = "{project}", "{repo}"
+			i += 3
+		case segments[i] == "projects" && strings.Contains(next(1), "/"):
+			// GitLab: /api/v4/projects/{group%2Fsubgroup%2Fproject}
+			repo = next(1)
+			org = repo[:strings.LastIndex(repo, "/")]
+			segments[i+1] = "{project}"
+			i++
+		case segments[i] == "repos" && next(1) != "" && next(2) != "":
+			// GitHub: /repos/{owner}/{repo}
+			org, repo = next(1), next(1)+"/"+next(2)
+			segments[i+1], segments[i+2] = "{owner}", "{repo}"
+			i += 2
+		case (segments[i] == "orgs" || segments[i] == "groups") && next(1) != "":
+			// GitHub: /orgs/{org}, GitLab: /groups/{group}
+			org = next(1)
+			segments[i+1] = "{org}"
+			i++
+		case idSegmentRegexp.MatchString(segments[i]):
+			segments[i] = "{id}"
+		}
+	}
+
+	var attrs []attribute.KeyValue
+	if org != "" {
+		attrs = append(attrs, OrganizationAttributeKey.String(org))
+	}
+	if repo != "" {
+		attrs = append(attrs, RepositoryAttributeKey.String(repo))
+	}
+	return strings.Join(segments, "/"), attrs
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/fluxcd/gone" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	opts, err := MakeClientOptions(WithTracerProvider(tp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := BuildClientFromTransportChain(opts.GetTransportChain())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "reconcile")
+	ctx = WithRequestAnnotations(ctx, map[string]string{"tenant": "foo"})
+	for _, path := range []string{"/repos/fluxcd/flux2/keys/1", "/repos/fluxcd/gone"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	ok, notFound := spans[0], spans[1]
+	if ok.Name() != "GET /repos/{owner}/{repo}/keys/{id}" {
+		t.Errorf("span name = %q, want %q", ok.Name(), "GET /repos/{owner}/{repo}/keys/{id}")
+	}
+	if ok.SpanKind() != trace.SpanKindClient {
+		t.Errorf("span kind = %v, want %v", ok.SpanKind(), trace.SpanKindClient)
+	}
+	if ok.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span isn't a child of the span in the request context")
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range ok.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	for k, want := range map[attribute.Key]attribute.Value{
+		"http.method":                        attribute.StringValue("GET"),
+		"http.status_code":                   attribute.IntValue(200),
+		RepositoryAttributeKey:               attribute.StringValue("fluxcd/flux2"),
+		OrganizationAttributeKey:             attribute.StringValue("fluxcd"),
+		AnnotationAttributePrefix + "tenant": attribute.StringValue("foo"),
+	} {
+		if attrs[k] != want {
+			t.Errorf("attribute %s = %v, want %v", k, attrs[k].Emit(), want.Emit())
+		}
+	}
+	if ok.Status().Code != codes.Unset {
+		t.Errorf("status = %v, want %v", ok.Status().Code, codes.Unset)
+	}
+	if notFound.Status().Code != codes.Error {
+		t.Errorf("status = %v, want %v", notFound.Status().Code, codes.Error)
+	}
+}
+
+func Test_requestRoute(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		wantRoute string
+		wantAttrs []attribute.KeyValue
+	}{
+		{
+			name:      "github repository",
+			url:       "https://api.github.com/repos/fluxcd/flux2/git/commits/0123456789abcdef0123456789abcdef01234567",
+			wantRoute: "/repos/{owner}/{repo}/git/commits/{id}",
+			wantAttrs: []attribute.KeyValue{OrganizationAttributeKey.String("fluxcd"), RepositoryAttributeKey.String("fluxcd/flux2")},
+		},
+		{
+			name:      "github organization",
+			url:       "https://github.example.com/api/v3/orgs/fluxcd/teams",
+			wantRoute: "/api/v3/orgs/{org}/teams",
+			wantAttrs: []attribute.KeyValue{OrganizationAttributeKey.String("fluxcd")},
+		},
+		{
+			name:      "gitlab project",
+			url:       "https://gitlab.com/api/v4/projects/group%2Fsub%2Fproject/deploy_keys/12",
+			wantRoute: "/api/v4/projects/{project}/deploy_keys/{id}",
+			wantAttrs: []attribute.KeyValue{OrganizationAttributeKey.String("group/sub"), RepositoryAttributeKey.String("group/sub/project")},
+		},
+		{
+			name:      "gitlab project by ID",
+			url:       "https://gitlab.com/api/v4/projects/42",
+			wantRoute: "/api/v4/projects/{id}",
+		},
+		{
+			name:      "bitbucket server repository",
+			url:       "https://stash.example.com/rest/api/1.0/projects/PRJ/repos/repo1/branches",
+			wantRoute: "/rest/api/1.0/projects/{project}/repos/{repo}/branches",
+			wantAttrs: []attribute.KeyValue{OrganizationAttributeKey.String("PRJ"), RepositoryAttributeKey.String("PRJ/repo1")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			route, attrs := requestRoute(u)
+			if route != tt.wantRoute {
+				t.Errorf("requestRoute() route = %q, want %q", route, tt.wantRoute)
+			}
+			if !reflect.DeepEqual(attrs, tt.wantAttrs) {
+				t.Errorf("requestRoute() attrs = %v, want %v", attrs, tt.wantAttrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"time"
+
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+const (
+	// defaultEventLimit is the number of events returned if EventListOptions.Limit is unset.
+	defaultEventLimit = 100
+	// maxEventLimit is the largest EventListOptions.Limit, as providers only keep recent events.
+	maxEventLimit = 1000
+)
+
+// Event is an activity event of a repository or organization, normalized across providers.
+type Event struct {
+	// ID identifies the event at the provider.
+	ID string `json:"id"`
+
+	// Type is the kind of the event.
+	Type EventType `json:"type"`
+
+	// Action is what happened, e.g. "opened", "closed" or "merged" for pull requests, and
+	// "added" or "removed" for members. It's empty for pushes.
+	Action string `json:"action,omitempty"`
+
+	// Actor is the login of the user who caused the event.
+	Actor string `json:"actor"`
+
+	// Repository is the repository of the event, if any.
+	Repository RepositoryRef `json:"repository,omitempty"`
+
+	// Branch is the pushed branch of push events.
+	Branch string `json:"branch,omitempty"`
+
+	// PullRequestNumber is the number of the pull request of pull request events.
+	PullRequestNumber int `json:"pullRequestNumber,omitempty"`
+
+	// Member is the login of the added or removed user of member events.
+	Member string `json:"member,omitempty"`
+
+	// CreatedAt is when the event happened.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// EventListOptions filters the events returned by EventsClient.
+type EventListOptions struct {
+	// Types only returns events of the given types. All events are returned if empty.
+	// +optional
+	Types []EventType `json:"types,omitempty"`
+
+	// Since only returns events that happened after the given time.
+	// +optional
+	Since *time.Time `json:"since,omitempty"`
+
+	// Limit is the largest number of events returned, 100 by default and 1000 at most.
+	// +optional
+	Limit int `json:"limit,omitempty"`
+}
+
+// Default defaults the EventListOptions fields.
+func (o *EventListOptions) Default() {
+	if o.Limit == 0 {
+		o.Limit = defaultEventLimit
+	}
+}
+
+// Validate validates the options.
+func (o EventListOptions) Validate() error {
+	validator := validation.New("EventListOptions")
+	for _, t := range o.Types {
+		validator.Append(ValidateEventType(t), t, "Types")
+	}
+	if o.Limit < 0 || o.Limit > maxEventLimit {
+		validator.Invalid(o.Limit, "Limit")
+	}
+	return validator.Error()
+}
+
+// Matches returns whether the event is of one of the requested types.
+func (o EventListOptions) Matches(e Event) bool {
+	if len(o.Types) == 0 {
+		return true
+	}
+	for _, t := range o.Types {
+		if e.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Before returns whether the event happened before the Since time, which ends the listing
+// of events sorted newest first.
+func (o EventListOptions) Before(e Event) bool {
+	return o.Since != nil && !e.CreatedAt.After(*o.Since)
+}
@@ -17,7 +17,12 @@ limitations under the License.
 package gitprovider
 
 import (
+	"encoding/json"
+	"math"
+	"net/url"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/fluxcd/go-git-providers/validation"
@@ -58,6 +63,173 @@ type RepositoryInfo struct {
 	// Default value at POST-time: RepositoryVisibilityPrivate.
 	// +optional
 	Visibility *RepositoryVisibility `json:"visibility"`
+
+	// Topics are the topics (GitHub) or project topics (GitLab) of the repository, used for
+	// classifying and discovering it. Their order doesn't matter. If nil, the topics aren't managed,
+	// i.e. left as-is by Update and Reconcile; an empty slice removes all topics. GitHub only
+	// allows lowercase letters, numbers and hyphens in topics. Not supported by Bitbucket Server.
+	// No default value at POST-time.
+	// +optional
+	Topics []string `json:"topics,omitempty"`
+
+	// MergeMethods are the methods allowed for merging pull requests. Their order doesn't
+	// matter. If nil, they aren't managed. GitLab has a single merge method per project, plus a
+	// squash option: MergeMethodMerge and MergeMethodRebase together mean merge commits of rebased
+	// branches (semi-linear history) there. Not supported by Bitbucket Server.
+	// No default value at POST-time.
+	// +optional
+	MergeMethods []MergeMethod `json:"mergeMethods,omitempty"`
+
+	// DeleteBranchOnMerge makes the Git provider delete the head branches of pull requests when
+	// they're merged. If nil, it isn't managed. Not supported by Bitbucket Server.
+	// No default value at POST-time.
+	// +optional
+	DeleteBranchOnMerge *bool `json:"deleteBranchOnMerge,omitempty"`
+
+	// Archived makes the repository read-only, retiring it without deleting it. Setting it to false
+	// unarchives the repository. If nil, it isn't managed. Not supported by Bitbucket Server.
+	// No default value at POST-time.
+	// +optional
+	Archived *bool `json:"archived,omitempty"`
+
+	// Fork is set if the repository is a fork, with the repository it was forked from. It's
+	// reported by Get, and ignored by Create, Update and Reconcile.
+	// +readonly
+	Fork *RepositoryFork `json:"fork,omitempty" gitprovider:"readonly"`
+
+	// Mirror is set if the repository mirrors another Git repository. It's reported by Get, and
+	// ignored by Create, Update and Reconcile.
+	// +readonly
+	Mirror *RepositoryMirror `json:"mirror,omitempty" gitprovider:"readonly"`
+
+	// Stats contains statistics about the repository. It's reported by Get if requested using
+	// RepositoryGetOptions.WithStats, and ignored by Create, Update and Reconcile.
+	// +readonly
+	Stats *RepositoryStats `json:"stats,omitempty" gitprovider:"readonly"`
+}
+
+// IsFork returns whether the repository is a fork of another repository.
+func (r RepositoryInfo) IsFork() bool {
+	return r.Fork != nil
+}
+
+// IsMirror returns whether the repository mirrors another Git repository.
+func (r RepositoryInfo) IsMirror() bool {
+	return r.Mirror != nil
+}
+
+// RepositoryStats contains statistics about a repository, normalized across providers.
+type RepositoryStats struct {
+	// SizeKB is the size of the Git repository in kilobytes.
+	SizeKB int64 `json:"sizeKB"`
+
+	// Languages are the languages of the files, by decreasing share.
+	Languages []RepositoryLanguage `json:"languages,omitempty"`
+
+	// CommitCount is the number of commits of the default branch.
+	CommitCount int `json:"commitCount"`
+
+	// ContributorCount is the number of users who authored commits of the default branch.
+	ContributorCount int `json:"contributorCount"`
+}
+
+// RepositoryLanguage is the share of a language in the files of a repository.
+type RepositoryLanguage struct {
+	// Name is the name of the language, e.g. "Go".
+	Name string `json:"name"`
+
+	// Percentage is the share of the language, between 0 and 100.
+	Percentage float64 `json:"percentage"`
+}
+
+// NewRepositoryLanguages returns the languages of the given shares, e.g. the bytes of code by
+// language, as percentages sorted by decreasing share, and then by name.
+func NewRepositoryLanguages(shares map[string]float64) []RepositoryLanguage {
+	total := 0.0
+	for _, share := range shares {
+		total += share
+	}
+	if total <= 0 {
+		return nil
+	}
+	languages := make([]RepositoryLanguage, 0, len(shares))
+	for name, share := range shares {
+		languages = append(languages, RepositoryLanguage{Name: name, Percentage: math.Round(share/total*1000) / 10})
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Percentage != languages[j].Percentage {
+			return languages[i].Percentage > languages[j].Percentage
+		}
+		return languages[i].Name < languages[j].Name
+	})
+	return languages
+}
+
+// RepositoryFork describes the repository a fork was created from.
+type RepositoryFork struct {
+	// Parent is the repository the fork was created from, an OrgRepositoryRef or a
+	// UserRepositoryRef. GitLab only reports the path of the parent project, so it's always an
+	// OrgRepositoryRef there. GitHub only reports it for single repositories, so it's nil for
+	// the repositories returned by List.
+	Parent RepositoryRef `json:"parent,omitempty"`
+
+	// Source is the root repository of the fork network, which is the Parent unless the parent
+	// is a fork itself. It's only reported by GitHub.
+	Source RepositoryRef `json:"source,omitempty"`
+}
+
+// UnmarshalJSON decodes the repository references, to a UserRepositoryRef if they have a user
+// login, and an OrgRepositoryRef otherwise.
+func (f *RepositoryFork) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Parent json.RawMessage `json:"parent"`
+		Source json.RawMessage `json:"source"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var err error
+	if f.Parent, err = unmarshalRepositoryRef(raw.Parent); err != nil {
+		return err
+	}
+	f.Source, err = unmarshalRepositoryRef(raw.Source)
+	return err
+}
+
+// unmarshalRepositoryRef decodes a UserRepositoryRef or OrgRepositoryRef, or nil if data is empty.
+func unmarshalRepositoryRef(data json.RawMessage) (RepositoryRef, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var userRef UserRepositoryRef
+	if err := json.Unmarshal(data, &userRef); err != nil {
+		return nil, err
+	}
+	if userRef.UserLogin != "" {
+		return userRef, nil
+	}
+	var orgRef OrgRepositoryRef
+	if err := json.Unmarshal(data, &orgRef); err != nil {
+		return nil, err
+	}
+	return orgRef, nil
+}
+
+// RepositoryMirror describes the upstream of a mirror repository.
+type RepositoryMirror struct {
+	// UpstreamURL is the URL of the mirrored repository, without credentials. It's empty if the
+	// provider doesn't report it, e.g. GitLab.
+	UpstreamURL string `json:"upstreamURL,omitempty"`
+}
+
+// NewRepositoryMirror returns the RepositoryMirror of the given upstream URL, removing the
+// credentials the provider may report in it.
+func NewRepositoryMirror(upstreamURL string) *RepositoryMirror {
+	if u, err := url.Parse(upstreamURL); err == nil && u.User != nil {
+		u.User = nil
+		upstreamURL = u.String()
+	}
+	return &RepositoryMirror{UpstreamURL: upstreamURL}
 }
 
 // Default defaults the Repository, implementing the InfoRequest interface.
@@ -77,13 +249,84 @@ func (r RepositoryInfo) ValidateInfo() error {
 	if r.Visibility != nil {
 		validator.Append(ValidateRepositoryVisibility(*r.Visibility), *r.Visibility, "Visibility")
 	}
+	// Topics must be non-empty and unique
+	seen := make(map[string]bool, len(r.Topics))
+	for _, topic := range r.Topics {
+		if topic == "" || seen[topic] {
+			validator.Invalid(topic, "Topics")
+		}
+		seen[topic] = true
+	}
+	// At least one, known merge method must be allowed
+	if r.MergeMethods != nil && len(r.MergeMethods) == 0 {
+		validator.Invalid(r.MergeMethods, "MergeMethods")
+	}
+	for _, m := range r.MergeMethods {
+		validator.Append(ValidateMergeMethod(m), m, "MergeMethods")
+	}
 	return validator.Error()
 }
 
 // Equals can be used to check if this *Info request (the desired state) matches the actual
-// passed in as the argument.
+// passed in as the argument. The fields without a default value, i.e. Topics, MergeMethods,
+// DeleteBranchOnMerge and Archived, are only compared if they are managed, i.e. not nil in the
+// desired state. The readonly Fork and Mirror aren't compared.
+// Topics and MergeMethods are compared regardless of their order.
 func (r RepositoryInfo) Equals(actual InfoRequest) bool {
-	return reflect.DeepEqual(r, actual)
+	actualInfo, ok := actual.(RepositoryInfo)
+	if !ok {
+		return false
+	}
+	if r.Topics != nil && !EqualTopics(r.Topics, actualInfo.Topics) {
+		return false
+	}
+	if r.MergeMethods != nil && !EqualMergeMethods(r.MergeMethods, actualInfo.MergeMethods) {
+		return false
+	}
+	if r.DeleteBranchOnMerge == nil {
+		actualInfo.DeleteBranchOnMerge = nil
+	}
+	if r.Archived == nil {
+		actualInfo.Archived = nil
+	}
+	r.Topics, actualInfo.Topics = nil, nil
+	r.MergeMethods, actualInfo.MergeMethods = nil, nil
+	r.Fork, actualInfo.Fork = nil, nil
+	r.Mirror, actualInfo.Mirror = nil, nil
+	r.Stats, actualInfo.Stats = nil, nil
+	return reflect.DeepEqual(r, actualInfo)
+}
+
+// EqualTopics returns true if a and b contain the same repository topics, regardless of their
+// order. A nil and an empty slice are equal.
+func EqualTopics(a, b []string) bool {
+	return equalUnordered(a, b)
+}
+
+// EqualMergeMethods returns true if a and b contain the same merge methods, regardless of their
+// order. A nil and an empty slice are equal.
+func EqualMergeMethods(a, b []MergeMethod) bool {
+	as := make([]string, 0, len(a))
+	for _, m := range a {
+		as = append(as, string(m))
+	}
+	bs := make([]string, 0, len(b))
+	for _, m := range b {
+		bs = append(bs, string(m))
+	}
+	return equalUnordered(as, bs)
+}
+
+// equalUnordered returns true if a and b contain the same strings, regardless of their order.
+func equalUnordered(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	return reflect.DeepEqual(sortedA, sortedB)
 }
 
 // TeamAccessInfo implements InfoRequest and DefaultedInfoRequest (with a pointer receiver).
@@ -130,6 +373,84 @@ func (ta TeamAccessInfo) Equals(actual InfoRequest) bool {
 	return reflect.DeepEqual(ta, actual)
 }
 
+// CollaboratorInfo implements InfoRequest and DefaultedInfoRequest (with a pointer receiver).
+var _ InfoRequest = CollaboratorInfo{}
+var _ DefaultedInfoRequest = &CollaboratorInfo{}
+
+// CollaboratorInfo contains high-level information about an individual user's access to a repository.
+type CollaboratorInfo struct {
+	// Login is the username of the user.
+	// +required
+	Login string `json:"login"`
+
+	// Permission describes the permission level the user has on the repository.
+	// Default: pull.
+	// Available options: See the RepositoryPermission enum.
+	// +optional
+	Permission *RepositoryPermission `json:"permission,omitempty"`
+}
+
+// Default defaults the Collaborator fields.
+func (c *CollaboratorInfo) Default() {
+	if c.Permission == nil {
+		c.Permission = RepositoryPermissionVar(defaultRepoPermission)
+	}
+}
+
+// ValidateInfo validates the object at {Object}.Set() and POST-time.
+func (c CollaboratorInfo) ValidateInfo() error {
+	validator := validation.New("Collaborator")
+	// Make sure we've set the login of the user
+	if len(c.Login) == 0 {
+		validator.Required("Login")
+	}
+	// Validate the Permission enum
+	if c.Permission != nil {
+		validator.Append(ValidateRepositoryPermission(*c.Permission), *c.Permission, "Permission")
+	}
+	return validator.Error()
+}
+
+// Equals can be used to check if this *Info request (the desired state) matches the actual
+// passed in as the argument.
+func (c CollaboratorInfo) Equals(actual InfoRequest) bool {
+	return reflect.DeepEqual(c, actual)
+}
+
+// CollaboratorInvitation is a pending invitation for a user to become a collaborator.
+type CollaboratorInvitation struct {
+	// ID identifies the invitation, e.g. for CollaboratorClient.CancelInvitation.
+	ID int64 `json:"id"`
+
+	// Login is the username of the invited user.
+	Login string `json:"login"`
+
+	// Permission is the permission level the user gets once they accept the invitation.
+	Permission RepositoryPermission `json:"permission"`
+
+	// CreatedAt is when the user was invited.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RepositoryInvitation is a pending invitation of the authenticated user to collaborate on a
+// repository.
+type RepositoryInvitation struct {
+	// ID identifies the invitation, e.g. for InvitationsClient.Accept.
+	ID int64 `json:"id"`
+
+	// Repository is the repository the user is invited to.
+	Repository RepositoryRef `json:"repository"`
+
+	// Inviter is the login of the user who sent the invitation.
+	Inviter string `json:"inviter"`
+
+	// Permission is the permission level the user gets once they accept the invitation.
+	Permission RepositoryPermission `json:"permission"`
+
+	// CreatedAt is when the user was invited.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 // DeployKeyInfo implements InfoRequest and DefaultedInfoRequest (with a pointer receiver).
 var _ InfoRequest = DeployKeyInfo{}
 var _ DefaultedInfoRequest = &DeployKeyInfo{}
@@ -175,8 +496,28 @@ func (dk DeployKeyInfo) ValidateInfo() error {
 
 // Equals can be used to check if this *Info request (the desired state) matches the actual
 // passed in as the argument.
+//
+// The keys are compared with EqualPublicKeys, as providers may drop or replace the comment of
+// the key. The name and the read-only flag are compared as-is, so changing either rotates the key.
 func (dk DeployKeyInfo) Equals(actual InfoRequest) bool {
-	return reflect.DeepEqual(dk, actual)
+	other, ok := actual.(DeployKeyInfo)
+	if !ok {
+		return false
+	}
+	return dk.Name == other.Name &&
+		reflect.DeepEqual(dk.ReadOnly, other.ReadOnly) &&
+		EqualPublicKeys(dk.Key, other.Key)
+}
+
+// EqualPublicKeys returns true if a and b are the same public key in the authorized_keys
+// format, e.g. "ssh-ed25519 AAAA... comment". Only the key type and the key material are
+// compared, not the comment or surrounding whitespace.
+func EqualPublicKeys(a, b []byte) bool {
+	af, bf := strings.Fields(string(a)), strings.Fields(string(b))
+	if len(af) < 2 || len(bf) < 2 {
+		return strings.TrimSpace(string(a)) == strings.TrimSpace(string(b))
+	}
+	return af[0] == bf[0] && af[1] == bf[1]
 }
 
 // CommitInfo contains high-level information about a deploy key.
@@ -192,14 +533,86 @@ type CommitInfo struct {
 	// Author is the author of the commit
 	Author string `json:"author"`
 
+	// AuthorEmail is the email address of the author of the commit
+	AuthorEmail string `json:"author_email,omitempty"`
+
+	// Committer is the committer of the commit, which differs from the author for e.g.
+	// cherry-picked commits, or commits made in the web UI
+	Committer string `json:"committer,omitempty"`
+
+	// CommitterEmail is the email address of the committer of the commit
+	CommitterEmail string `json:"committer_email,omitempty"`
+
 	// Message is the commit message
 	Message string `json:"message"`
 
+	// Parents are the SHAs of the parent commits, the first parent first
+	Parents []string `json:"parents,omitempty"`
+
 	// CreatedAt is the time the commit was created
 	CreatedAt time.Time `json:"created_at"`
 
-	// URL is the link for the commit
+	// CommittedAt is the time the commit was committed
+	CommittedAt time.Time `json:"committed_at"`
+
+	// URL is the web link for the commit
 	URL string `json:"url"`
+
+	// Verification is the status of the signature of the commit, if the provider reports it
+	// when listing commits.
+	// +optional
+	Verification *CommitVerification `json:"verification,omitempty"`
+
+	// Stats holds the number of changed lines, if requested using CommitListOptions.WithStats.
+	// +optional
+	Stats *CommitStats `json:"stats,omitempty"`
+}
+
+// CommitVerification contains the status of the signature of a commit.
+type CommitVerification struct {
+	// Verified is true if the commit is signed, and the signature is valid and trusted.
+	Verified bool `json:"verified"`
+
+	// Reason is the provider-specific reason of the status, e.g. "valid" or "unsigned" on GitHub.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// CommitCommentInfo contains high-level information about a comment on a commit.
+type CommitCommentInfo struct {
+	// ID is the provider-assigned ID of the comment. GitLab doesn't return the ID of commit
+	// comments, hence it's 0 there.
+	ID int64 `json:"id"`
+
+	// Body is the (markdown) text of the comment.
+	Body string `json:"body"`
+
+	// Author is the login of the user who wrote the comment.
+	Author string `json:"author"`
+
+	// CreatedAt is the time the comment was created. GitLab doesn't return it for commit comments.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Path is the path of the file the comment is on, if it's on a line of a file.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Line is the line of the file the comment is on, if it's on a line of a file. It's only
+	// returned by GitLab.
+	// +optional
+	Line int `json:"line,omitempty"`
+}
+
+// CommitStats contains the number of lines changed by a commit.
+type CommitStats struct {
+	// Additions is the number of added lines.
+	Additions int `json:"additions"`
+
+	// Deletions is the number of deleted lines.
+	Deletions int `json:"deletions"`
+
+	// Total is the total number of changed lines.
+	Total int `json:"total"`
 }
 
 // CommitFile contains high-level information about a file added to a commit.
@@ -213,11 +626,109 @@ type CommitFile struct {
 	Content *string `json:"content"`
 }
 
+// FileInfo contains high-level information about a file (Git blob) in a repository.
+type FileInfo struct {
+	// Path is the path of the file in the repository.
+	Path string `json:"path"`
+
+	// Size is the size of the file content in bytes.
+	Size int64 `json:"size"`
+
+	// SHA is the Git blob SHA of the file content.
+	SHA string `json:"sha"`
+}
+
+// BranchInfo contains high-level information about a branch.
+type BranchInfo struct {
+	// Name is the name of the branch, e.g. "main".
+	Name string `json:"name"`
+
+	// Sha is the SHA of the commit the branch points to.
+	Sha string `json:"sha"`
+
+	// Protected specifies whether the branch is protected against e.g. force pushes or deletion.
+	Protected bool `json:"protected"`
+
+	// Default specifies whether the branch is the default branch of the repository.
+	Default bool `json:"default"`
+}
+
+// BranchRules are the rules in effect on a branch, normalized from the GitHub rulesets, GitLab
+// protected branches or Bitbucket Server ref restrictions which apply to it. The zero value means
+// the branch isn't protected.
+type BranchRules struct {
+	// BlockDeletion specifies whether the branch can't be deleted.
+	BlockDeletion bool `json:"blockDeletion"`
+
+	// BlockForcePushes specifies whether force pushes to the branch are rejected.
+	BlockForcePushes bool `json:"blockForcePushes"`
+
+	// RequireLinearHistory specifies whether merge commits are rejected.
+	RequireLinearHistory bool `json:"requireLinearHistory"`
+
+	// RequireSignedCommits specifies whether the commits pushed to the branch must be signed.
+	RequireSignedCommits bool `json:"requireSignedCommits"`
+
+	// RequirePullRequest specifies whether changes must be made through pull requests.
+	RequirePullRequest bool `json:"requirePullRequest"`
+
+	// RequiredApprovals is the number of approving reviews pull requests need before merging.
+	RequiredApprovals int `json:"requiredApprovals,omitempty"`
+
+	// RequireCodeOwnerReview specifies whether pull requests need the approval of the code owners
+	// of the changed files.
+	RequireCodeOwnerReview bool `json:"requireCodeOwnerReview,omitempty"`
+
+	// RequiredStatusChecks are the names of the status checks which must pass before merging, sorted.
+	RequiredStatusChecks []string `json:"requiredStatusChecks,omitempty"`
+}
+
+// ForkSyncStrategy is the way a branch of a fork was synchronized with its upstream.
+type ForkSyncStrategy string
+
+const (
+	// ForkSyncStrategyMergeUpstream means the provider merged the upstream branch, with the
+	// GitHub merge-upstream endpoint. The branch is fast-forwarded if it didn't diverge.
+	ForkSyncStrategyMergeUpstream = ForkSyncStrategy("merge-upstream")
+	// ForkSyncStrategyMirrorPull means a pull of the GitLab pull mirror of the fork was started.
+	// The pull is asynchronous, so the branch may not be updated yet when it returns.
+	ForkSyncStrategyMirrorPull = ForkSyncStrategy("mirror-pull")
+	// ForkSyncStrategyMergeRequest means a merge request from the upstream branch into the branch
+	// of the fork was created and merged, which GitLab uses for forks without pull mirror.
+	ForkSyncStrategyMergeRequest = ForkSyncStrategy("merge-request")
+	// ForkSyncStrategyFastForward means the branch was moved to the head of the upstream branch,
+	// which is the fallback if the provider doesn't have an endpoint to synchronize forks.
+	ForkSyncStrategyFastForward = ForkSyncStrategy("fast-forward")
+)
+
+// ForkSyncResult describes the synchronization of a branch of a fork with its upstream.
+type ForkSyncResult struct {
+	// Branch is the name of the synchronized branch.
+	Branch string `json:"branch"`
+
+	// Sha is the SHA of the commit the branch points to after the synchronization.
+	Sha string `json:"sha"`
+
+	// Updated is false if the branch already had all the commits of the upstream branch.
+	Updated bool `json:"updated"`
+
+	// Strategy is the way the branch was synchronized.
+	Strategy ForkSyncStrategy `json:"strategy"`
+
+	// Operation is the synchronization, which is only still running for
+	// ForkSyncStrategyMirrorPull. Wait for it before reading the branch.
+	Operation Operation `json:"-"`
+}
+
 // PullRequestInfo contains high-level information about a pull request.
 type PullRequestInfo struct {
 	// Merged specifes whether or not this pull request has been merged
 	Merged bool `json:"merged"`
 
+	// Closed specifies whether the pull request was closed without being merged, i.e. declined
+	// on Bitbucket Server.
+	Closed bool `json:"closed"`
+
 	// Number is the number of the pull request that can be used to merge
 	Number int `json:"number"`
 
@@ -225,3 +736,351 @@ type PullRequestInfo struct {
 	// +required
 	WebURL string `json:"web_url"`
 }
+
+// PullRequestCommentInfo contains high-level information about a comment on a pull request.
+type PullRequestCommentInfo struct {
+	// ID is the provider-assigned ID of the comment.
+	ID int64 `json:"id"`
+
+	// Body is the (markdown) text of the comment.
+	Body string `json:"body"`
+
+	// Author is the login of the user who wrote the comment.
+	Author string `json:"author"`
+
+	// CreatedAt is the time the comment was created.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MergeQueueEntry contains high-level information about a pull request in a merge queue.
+type MergeQueueEntry struct {
+	// PullRequestNumber is the number of the queued pull request.
+	PullRequestNumber int `json:"pullRequestNumber"`
+
+	// BaseBranch is the branch the pull request is merged into by the queue.
+	BaseBranch string `json:"baseBranch"`
+
+	// Position is the 1-based position of the pull request in the queue, 1 being merged next.
+	// It is 0 if the provider doesn't report positions for the state, e.g. once merged.
+	Position int `json:"position"`
+
+	// State is the state of the pull request in the queue.
+	State MergeQueueState `json:"state"`
+
+	// EnqueuedAt is when the pull request was added to the queue.
+	EnqueuedAt time.Time `json:"enqueuedAt"`
+}
+
+// MilestoneInfo implements InfoRequest.
+var _ InfoRequest = MilestoneInfo{}
+
+// MilestoneInfo contains high-level information about a milestone.
+type MilestoneInfo struct {
+	// Title is the title of the milestone.
+	// +required
+	Title string `json:"title"`
+
+	// Description is the description of the milestone.
+	// +optional
+	Description *string `json:"description,omitempty"`
+
+	// DueDate is the date the milestone is due.
+	// +optional
+	DueDate *time.Time `json:"dueDate,omitempty"`
+
+	// Number is the number of the milestone, unique within the repository. It is set by the server.
+	Number int `json:"number"`
+
+	// State is the state of the milestone, either "open" or "closed". It is set by the server.
+	State MilestoneState `json:"state"`
+
+	// WebURL is the URL of the milestone in the git provider web interface. It is set by the server.
+	WebURL string `json:"web_url"`
+}
+
+// ValidateInfo validates the object at {Object}.Set() and POST-time.
+func (m MilestoneInfo) ValidateInfo() error {
+	validator := validation.New("Milestone")
+	// Make sure we've set the title of the milestone
+	if len(m.Title) == 0 {
+		validator.Required("Title")
+	}
+	return validator.Error()
+}
+
+// Equals can be used to check if this *Info request (the desired state) matches the actual
+// passed in as the argument.
+func (m MilestoneInfo) Equals(actual InfoRequest) bool {
+	return reflect.DeepEqual(m, actual)
+}
+
+// ReactionSubject identifies what a reaction is on: an issue, a pull request, or one of their
+// general comments.
+type ReactionSubject struct {
+	// Number is the number of the issue or pull request.
+	// +required
+	Number int `json:"number"`
+
+	// PullRequest is true if Number is the number of a pull request, rather than an issue.
+	// +optional
+	PullRequest bool `json:"pullRequest,omitempty"`
+
+	// CommentID is the ID of the comment of the issue or pull request the reaction is on. It is 0
+	// for reactions on the issue or pull request itself.
+	// +optional
+	CommentID int64 `json:"commentID,omitempty"`
+}
+
+// ValidateFields validates the subject with the given validator.
+func (s ReactionSubject) ValidateFields(validator validation.Validator) {
+	if s.Number <= 0 {
+		validator.Invalid(s.Number, "Number")
+	}
+	if s.CommentID < 0 {
+		validator.Invalid(s.CommentID, "CommentID")
+	}
+}
+
+// ReactionInfo contains high-level information about an emoji reaction.
+type ReactionInfo struct {
+	// ID is the provider-assigned ID of the reaction.
+	ID int64 `json:"id"`
+
+	// Content is the emoji of the reaction.
+	Content ReactionContent `json:"content"`
+
+	// User is the login of the user who reacted.
+	User string `json:"user"`
+}
+
+// ReactedUsers returns the logins of the users who reacted with the given content, e.g. to
+// count 👍 reactions as approvals. Each user is listed once, in the order of the reactions.
+func ReactedUsers(reactions []ReactionInfo, content ReactionContent) []string {
+	users := []string{}
+	seen := map[string]struct{}{}
+	for _, r := range reactions {
+		if r.Content != content {
+			continue
+		}
+		if _, ok := seen[r.User]; ok {
+			continue
+		}
+		seen[r.User] = struct{}{}
+		users = append(users, r.User)
+	}
+	return users
+}
+
+// EnvironmentInfo implements InfoRequest and DefaultedInfoRequest (with a pointer receiver).
+var _ InfoRequest = EnvironmentInfo{}
+var _ DefaultedInfoRequest = &EnvironmentInfo{}
+
+// maxEnvironmentWaitTimer is the longest wait timer of an environment, 30 days in minutes.
+const maxEnvironmentWaitTimer = 43200
+
+// EnvironmentInfo contains the protection rules of a deployment environment of a repository.
+type EnvironmentInfo struct {
+	// Name is the name of the environment, e.g. "production".
+	// +required
+	Name string `json:"name"`
+
+	// Reviewers are the logins of the users who approve deployments to the environment on GitHub,
+	// or who are allowed to deploy to it on GitLab.
+	// +optional
+	Reviewers []string `json:"reviewers,omitempty"`
+
+	// TeamReviewers are the names of the teams whose members approve deployments to the
+	// environment on GitHub, or are allowed to deploy to it on GitLab. Only repositories owned
+	// by an organization can have team reviewers.
+	// +optional
+	TeamReviewers []string `json:"teamReviewers,omitempty"`
+
+	// WaitTimer is the number of minutes deployments to the environment wait before they start,
+	// up to 43200 (30 days). It's only supported by GitHub.
+	// +optional
+	WaitTimer int `json:"waitTimer,omitempty"`
+}
+
+// Default defaults the Environment fields. The reviewers are sorted, as their order doesn't matter.
+func (e *EnvironmentInfo) Default() {
+	e.Reviewers = sortedOrNil(e.Reviewers)
+	e.TeamReviewers = sortedOrNil(e.TeamReviewers)
+}
+
+// ValidateInfo validates the object at {Object}.Set() and POST-time.
+func (e EnvironmentInfo) ValidateInfo() error {
+	validator := validation.New("Environment")
+	// Make sure we've set the name of the environment
+	if len(e.Name) == 0 {
+		validator.Required("Name")
+	}
+	for _, login := range e.Reviewers {
+		if len(login) == 0 {
+			validator.Invalid(login, "Reviewers")
+		}
+	}
+	for _, team := range e.TeamReviewers {
+		if len(team) == 0 {
+			validator.Invalid(team, "TeamReviewers")
+		}
+	}
+	if e.WaitTimer < 0 || e.WaitTimer > maxEnvironmentWaitTimer {
+		validator.Invalid(e.WaitTimer, "WaitTimer")
+	}
+	return validator.Error()
+}
+
+// Equals can be used to check if this *Info request (the desired state) matches the actual
+// passed in as the argument.
+func (e EnvironmentInfo) Equals(actual InfoRequest) bool {
+	return reflect.DeepEqual(e, actual)
+}
+
+// TagProtectionInfo implements InfoRequest.
+var _ InfoRequest = TagProtectionInfo{}
+
+// TagProtectionInfo is a rule protecting the matching tags of a repository, so that they can't be
+// deleted or overwritten, e.g. release tags. Only maintainers and admins can create them.
+type TagProtectionInfo struct {
+	// Pattern is the name of the protected tags, with "*" wildcards, e.g. "v*".
+	// +required
+	Pattern string `json:"pattern"`
+}
+
+// ValidateInfo validates the object at {Object}.Set() and POST-time.
+func (t TagProtectionInfo) ValidateInfo() error {
+	validator := validation.New("TagProtection")
+	// Make sure we've set the pattern of the rule
+	if len(t.Pattern) == 0 {
+		validator.Required("Pattern")
+	}
+	return validator.Error()
+}
+
+// Equals can be used to check if this *Info request (the desired state) matches the actual
+// passed in as the argument.
+func (t TagProtectionInfo) Equals(actual InfoRequest) bool {
+	return reflect.DeepEqual(t, actual)
+}
+
+// ApprovalRuleInfo implements InfoRequest and DefaultedInfoRequest (with a pointer receiver).
+var _ InfoRequest = ApprovalRuleInfo{}
+var _ DefaultedInfoRequest = &ApprovalRuleInfo{}
+
+// ApprovalRuleInfo is an approval rule of a repository, requiring approvals from the eligible
+// approvers before pull requests into the matching branches can be merged.
+type ApprovalRuleInfo struct {
+	// Name is the name of the rule, unique within the repository.
+	// +required
+	Name string `json:"name"`
+
+	// RequiredApprovals is the number of approvals from the eligible approvers pull requests need.
+	// A rule without required approvals only suggests the approvers.
+	// +optional
+	RequiredApprovals int `json:"requiredApprovals"`
+
+	// Users are the logins of the users who are eligible approvers.
+	// +optional
+	Users []string `json:"users,omitempty"`
+
+	// Groups are the full paths of the groups whose members are eligible approvers.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// Branches are the protected branches the rule applies to. It applies to all branches if empty.
+	// +optional
+	Branches []string `json:"branches,omitempty"`
+
+	// EligibleApprovers are the logins of all the eligible approvers, i.e. the users and the
+	// members of the groups, sorted. It's ignored by Equals.
+	// +readonly
+	EligibleApprovers []string `json:"eligibleApprovers,omitempty"`
+}
+
+// Default defaults the ApprovalRule fields. The users, groups and branches are sorted, as their
+// order doesn't matter.
+func (a *ApprovalRuleInfo) Default() {
+	a.Users = sortedOrNil(a.Users)
+	a.Groups = sortedOrNil(a.Groups)
+	a.Branches = sortedOrNil(a.Branches)
+}
+
+// ValidateInfo validates the object at {Object}.Set() and POST-time.
+func (a ApprovalRuleInfo) ValidateInfo() error {
+	validator := validation.New("ApprovalRule")
+	// Make sure we've set the name of the rule
+	if len(a.Name) == 0 {
+		validator.Required("Name")
+	}
+	if a.RequiredApprovals < 0 {
+		validator.Invalid(a.RequiredApprovals, "RequiredApprovals")
+	}
+	for _, login := range a.Users {
+		if len(login) == 0 {
+			validator.Invalid(login, "Users")
+		}
+	}
+	for _, group := range a.Groups {
+		if len(group) == 0 {
+			validator.Invalid(group, "Groups")
+		}
+	}
+	for _, branch := range a.Branches {
+		if len(branch) == 0 {
+			validator.Invalid(branch, "Branches")
+		}
+	}
+	return validator.Error()
+}
+
+// Equals can be used to check if this *Info request (the desired state) matches the actual
+// passed in as the argument. The eligible approvers are ignored.
+func (a ApprovalRuleInfo) Equals(actual InfoRequest) bool {
+	actualInfo, ok := actual.(ApprovalRuleInfo)
+	if !ok {
+		return false
+	}
+	a.EligibleApprovers, actualInfo.EligibleApprovers = nil, nil
+	return reflect.DeepEqual(a, actualInfo)
+}
+
+// sortedOrNil returns a sorted copy of s, or nil if s is empty.
+func sortedOrNil(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	sorted := append([]string(nil), s...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// BadgeRequest describes a status badge of a repository.
+type BadgeRequest struct {
+	// Kind is the kind of the badge.
+	// +required
+	Kind BadgeKind `json:"kind"`
+
+	// Branch is the branch of a pipeline badge.
+	// Default: the default branch of the repository
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Workflow is the file name (e.g. "ci.yaml") of the GitHub Actions workflow of a pipeline
+	// badge, as GitHub has a badge per workflow. It's ignored by other providers.
+	// +optional
+	Workflow string `json:"workflow,omitempty"`
+}
+
+// Validate validates the badge request.
+func (b BadgeRequest) Validate() error {
+	validator := validation.New("Badge")
+	if len(b.Kind) == 0 {
+		validator.Required("Kind")
+	} else {
+		validator.Append(ValidateBadgeKind(b.Kind), b.Kind, "Kind")
+	}
+	if b.Kind != BadgeKindPipeline && len(b.Branch) != 0 {
+		validator.Invalid(b.Branch, "Branch")
+	}
+	return validator.Error()
+}
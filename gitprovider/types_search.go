@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+const (
+	// defaultSearchLimit is the number of results returned if SearchQuery.Limit is unset.
+	defaultSearchLimit = 100
+	// maxSearchLimit is the largest SearchQuery.Limit, as providers stop paginating searches
+	// after 1000 results.
+	maxSearchLimit = 1000
+)
+
+// SearchQuery is a search for repositories or code, normalized across providers. Each provider
+// translates it to its own query syntax.
+type SearchQuery struct {
+	// Text is the text to search for: in the name and description of repositories, or in the
+	// content of files.
+	// +required for code search
+	Text string `json:"text,omitempty"`
+
+	// Owner only returns results in the repositories of the given organization or user.
+	// +optional
+	Owner IdentityRef `json:"owner,omitempty"`
+
+	// Repository only returns results in the given repository. It's only used by code search.
+	// +optional
+	Repository RepositoryRef `json:"repository,omitempty"`
+
+	// Topics only returns repositories with all the given topics. It's only used by repository search.
+	// +optional
+	Topics []string `json:"topics,omitempty"`
+
+	// Filename only returns files with the given name. It's only used by code search.
+	// +optional
+	Filename string `json:"filename,omitempty"`
+
+	// Limit is the maximum number of results, up to 1000.
+	// Default: 100
+	Limit int `json:"limit,omitempty"`
+}
+
+// Default defaults the SearchQuery fields.
+func (q *SearchQuery) Default() {
+	if q.Limit == 0 {
+		q.Limit = defaultSearchLimit
+	}
+}
+
+// ValidateRepositoryQuery validates the query of a repository search, which needs text or topics.
+func (q SearchQuery) ValidateRepositoryQuery() error {
+	validator := q.validate()
+	if len(q.Text) == 0 && len(q.Topics) == 0 {
+		validator.Required("Text")
+	}
+	return validator.Error()
+}
+
+// ValidateCodeQuery validates the query of a code search, which needs text.
+func (q SearchQuery) ValidateCodeQuery() error {
+	validator := q.validate()
+	if len(q.Text) == 0 {
+		validator.Required("Text")
+	}
+	return validator.Error()
+}
+
+// validate validates the fields of the query used by both searches.
+func (q SearchQuery) validate() validation.Validator {
+	validator := validation.New("SearchQuery")
+	for _, topic := range q.Topics {
+		if len(topic) == 0 {
+			validator.Invalid(topic, "Topics")
+		}
+	}
+	if q.Limit < 0 || q.Limit > maxSearchLimit {
+		validator.Invalid(q.Limit, "Limit")
+	}
+	return validator
+}
+
+// RepositorySearchResult is a repository found by a search.
+type RepositorySearchResult struct {
+	// Repository is the reference to the repository, an OrgRepositoryRef or UserRepositoryRef
+	// depending on its owner.
+	Repository RepositoryRef `json:"repository"`
+
+	// Description is the description of the repository.
+	Description string `json:"description,omitempty"`
+
+	// Topics are the topics of the repository.
+	Topics []string `json:"topics,omitempty"`
+}
+
+// CodeSearchResult is a file found by a code search.
+type CodeSearchResult struct {
+	// Repository is the reference to the repository of the file, an OrgRepositoryRef or
+	// UserRepositoryRef depending on its owner.
+	Repository RepositoryRef `json:"repository"`
+
+	// Path is the path of the file in the repository.
+	Path string `json:"path"`
+
+	// SHA is the blob SHA of the file, if the provider returns it.
+	SHA string `json:"sha,omitempty"`
+}
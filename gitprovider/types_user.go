@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"time"
+
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+// UserKeyInfo is an SSH or GPG public key of the authenticated user.
+type UserKeyInfo struct {
+	// ID is the identifier of the key, assigned by the provider.
+	// +readonly
+	ID int64 `json:"id,omitempty"`
+
+	// Type is the type of the key.
+	// +required
+	Type UserKeyType `json:"type"`
+
+	// Title is the name of an SSH key. GPG keys have no title.
+	// +optional
+	Title string `json:"title,omitempty"`
+
+	// Key is the public key, in the authorized_keys format for SSH keys, or ASCII-armored for
+	// GPG keys.
+	// +required
+	Key string `json:"key"`
+
+	// KeyID is the ID of a GPG key, e.g. "3AA5C34371567BD2", as shown in commit signatures.
+	// +readonly
+	KeyID string `json:"keyID,omitempty"`
+
+	// CreatedAt is the time the key was added.
+	// +readonly
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// AccessTokenInfo is an access token of a repository or organization, authenticating as a bot
+// user with the given access level.
+type AccessTokenInfo struct {
+	// ID is the identifier of the token, assigned by the provider.
+	// +readonly
+	ID int64 `json:"id,omitempty"`
+
+	// Name is the name of the token.
+	// +required
+	Name string `json:"name"`
+
+	// Scopes are the scopes of the token, e.g. "api" or "read_repository".
+	// +required
+	Scopes []string `json:"scopes"`
+
+	// AccessLevel is the permission of the token on the repository or organization.
+	// Default: maintain
+	// +optional
+	AccessLevel *RepositoryPermission `json:"accessLevel,omitempty"`
+
+	// ExpiresAt is the date the token expires. Providers may require or default it.
+	// +optional
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// CreatedAt is the time the token was created.
+	// +readonly
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+
+	// Token is the value of the token, only returned when it's created or rotated.
+	// +readonly
+	Token string `json:"-"`
+}
+
+// Validate validates the token before it is created.
+func (t AccessTokenInfo) Validate() error {
+	validator := validation.New("AccessToken")
+	if len(t.Name) == 0 {
+		validator.Required("Name")
+	}
+	if len(t.Scopes) == 0 {
+		validator.Required("Scopes")
+	}
+	for _, scope := range t.Scopes {
+		if len(scope) == 0 {
+			validator.Invalid(scope, "Scopes")
+		}
+	}
+	if t.AccessLevel != nil {
+		validator.Append(ValidateRepositoryPermission(*t.AccessLevel), *t.AccessLevel, "AccessLevel")
+	}
+	return validator.Error()
+}
+
+// Validate validates the key before it is added.
+func (k UserKeyInfo) Validate() error {
+	validator := validation.New("UserKey")
+	if len(k.Type) == 0 {
+		validator.Required("Type")
+	} else {
+		validator.Append(ValidateUserKeyType(k.Type), k.Type, "Type")
+	}
+	if len(k.Key) == 0 {
+		validator.Required("Key")
+	}
+	if k.Type == UserKeyTypeGPG && len(k.Title) != 0 {
+		validator.Invalid(k.Title, "Title")
+	}
+	return validator.Error()
+}
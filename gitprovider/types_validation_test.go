@@ -18,7 +18,9 @@ package gitprovider
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/fluxcd/go-git-providers/validation"
 )
@@ -100,6 +102,40 @@ func TestRepository_Validate(t *testing.T) {
 			},
 			expectedErrs: []error{validation.ErrFieldEnumInvalid},
 		},
+		{
+			name: "valid topics",
+			repo: RepositoryInfo{
+				Topics: []string{"flux", "gitops"},
+			},
+		},
+		{
+			name: "invalid topics, empty and duplicate",
+			repo: RepositoryInfo{
+				Topics: []string{"", "flux", "flux"},
+			},
+			expectedErrs: []error{validation.ErrFieldInvalid},
+		},
+		{
+			name: "valid merge methods",
+			repo: RepositoryInfo{
+				MergeMethods:        []MergeMethod{MergeMethodRebase, MergeMethodSquash},
+				DeleteBranchOnMerge: BoolVar(true),
+			},
+		},
+		{
+			name: "invalid merge methods, none allowed",
+			repo: RepositoryInfo{
+				MergeMethods: []MergeMethod{},
+			},
+			expectedErrs: []error{validation.ErrFieldInvalid},
+		},
+		{
+			name: "invalid merge methods, unknown enum",
+			repo: RepositoryInfo{
+				MergeMethods: []MergeMethod{MergeMethodMerge, "fast-forward"},
+			},
+			expectedErrs: []error{validation.ErrFieldEnumInvalid},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -108,6 +144,83 @@ func TestRepository_Validate(t *testing.T) {
 	}
 }
 
+func TestRepository_EqualsTopics(t *testing.T) {
+	actual := RepositoryInfo{
+		Description: StringVar("foo-description"),
+		Topics:      []string{"gitops", "flux"},
+	}
+	tests := []struct {
+		name   string
+		topics []string
+		want   bool
+	}{
+		{name: "unmanaged", topics: nil, want: true},
+		{name: "same, in a different order", topics: []string{"flux", "gitops"}, want: true},
+		{name: "different", topics: []string{"flux"}, want: false},
+		{name: "removed", topics: []string{}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := RepositoryInfo{Description: StringVar("foo-description"), Topics: tt.topics}
+			if got := req.Equals(actual); got != tt.want {
+				t.Errorf("RepositoryInfo.Equals() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if !(RepositoryInfo{Topics: []string{}}).Equals(RepositoryInfo{}) {
+		t.Error("expected empty and nil actual topics to be equal")
+	}
+}
+
+func TestRepository_EqualsUnmanagedSettings(t *testing.T) {
+	actual := RepositoryInfo{
+		MergeMethods:        []MergeMethod{MergeMethodMerge, MergeMethodSquash},
+		DeleteBranchOnMerge: BoolVar(false),
+		Archived:            BoolVar(false),
+	}
+	tests := []struct {
+		name string
+		req  RepositoryInfo
+		want bool
+	}{
+		{name: "unmanaged", req: RepositoryInfo{}, want: true},
+		{name: "same methods, in a different order", req: RepositoryInfo{MergeMethods: []MergeMethod{MergeMethodSquash, MergeMethodMerge}}, want: true},
+		{name: "different methods", req: RepositoryInfo{MergeMethods: []MergeMethod{MergeMethodRebase}}, want: false},
+		{name: "same branch deletion", req: RepositoryInfo{DeleteBranchOnMerge: BoolVar(false)}, want: true},
+		{name: "different branch deletion", req: RepositoryInfo{DeleteBranchOnMerge: BoolVar(true)}, want: false},
+		{name: "archived", req: RepositoryInfo{Archived: BoolVar(true)}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.Equals(actual); got != tt.want {
+				t.Errorf("RepositoryInfo.Equals() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeployKey_Equals(t *testing.T) {
+	actual := DeployKeyInfo{Name: "flux", Key: []byte("ssh-ed25519 AAAAC3Nza"), ReadOnly: BoolVar(true)}
+	tests := []struct {
+		name string
+		req  DeployKeyInfo
+		want bool
+	}{
+		{name: "same", req: actual, want: true},
+		{name: "key with comment and newline", req: DeployKeyInfo{Name: "flux", Key: []byte("ssh-ed25519 AAAAC3Nza flux@cluster\n"), ReadOnly: BoolVar(true)}, want: true},
+		{name: "different key", req: DeployKeyInfo{Name: "flux", Key: []byte("ssh-ed25519 AAAAC3Nzb"), ReadOnly: BoolVar(true)}, want: false},
+		{name: "different title", req: DeployKeyInfo{Name: "flux-system", Key: actual.Key, ReadOnly: BoolVar(true)}, want: false},
+		{name: "write access", req: DeployKeyInfo{Name: "flux", Key: actual.Key, ReadOnly: BoolVar(false)}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.Equals(actual); got != tt.want {
+				t.Errorf("DeployKeyInfo.Equals() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestTeamAccess_Validate(t *testing.T) {
 	invalidPermission := RepositoryPermission("unknown")
 	tests := []struct {
@@ -154,3 +267,249 @@ func TestTeamAccess_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestMilestone_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		m            MilestoneInfo
+		expectedErrs []error
+	}{
+		{
+			name: "valid create, required field set",
+			m: MilestoneInfo{
+				Title: "v1.0.0",
+			},
+		},
+		{
+			name: "valid create, with all fields populated",
+			m: MilestoneInfo{
+				Title:       "v1.0.0",
+				Description: StringVar("first stable release"),
+				DueDate:     &time.Time{},
+			},
+		},
+		{
+			name:         "invalid create, missing title",
+			m:            MilestoneInfo{Description: StringVar("first stable release")},
+			expectedErrs: []error{validation.ErrFieldRequired},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidation(t, "Milestone", tt.m.ValidateInfo, tt.expectedErrs)
+		})
+	}
+}
+
+func TestReactedUsers(t *testing.T) {
+	reactions := []ReactionInfo{
+		{ID: 1, Content: ReactionContentThumbsUp, User: "alice"},
+		{ID: 2, Content: ReactionContentEyes, User: "bob"},
+		{ID: 3, Content: ReactionContentThumbsUp, User: "carol"},
+		{ID: 4, Content: ReactionContentThumbsUp, User: "alice"},
+	}
+	if got, want := ReactedUsers(reactions, ReactionContentThumbsUp), []string{"alice", "carol"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReactedUsers() = %v, want %v", got, want)
+	}
+	if got := ReactedUsers(reactions, ReactionContentHeart); len(got) != 0 {
+		t.Errorf("ReactedUsers() = %v, want none", got)
+	}
+}
+
+func TestEnvironment_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		e            EnvironmentInfo
+		expectedErrs []error
+	}{
+		{
+			name: "valid create, required field set",
+			e:    EnvironmentInfo{Name: "production"},
+		},
+		{
+			name: "valid create, with all fields populated",
+			e: EnvironmentInfo{
+				Name:          "production",
+				Reviewers:     []string{"alice"},
+				TeamReviewers: []string{"ops"},
+				WaitTimer:     30,
+			},
+		},
+		{
+			name:         "invalid create, missing name",
+			e:            EnvironmentInfo{Reviewers: []string{"alice"}},
+			expectedErrs: []error{validation.ErrFieldRequired},
+		},
+		{
+			name:         "invalid create, empty reviewer",
+			e:            EnvironmentInfo{Name: "production", Reviewers: []string{""}},
+			expectedErrs: []error{validation.ErrFieldInvalid},
+		},
+		{
+			name:         "invalid create, wait timer too long",
+			e:            EnvironmentInfo{Name: "production", WaitTimer: 43201},
+			expectedErrs: []error{validation.ErrFieldInvalid},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidation(t, "Environment", tt.e.ValidateInfo, tt.expectedErrs)
+		})
+	}
+}
+
+func TestSearchQuery_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		q            SearchQuery
+		code         bool
+		expectedErrs []error
+	}{
+		{
+			name: "valid repository search, topics only",
+			q:    SearchQuery{Topics: []string{"flux"}},
+		},
+		{
+			name: "valid code search",
+			q:    SearchQuery{Text: "kind: Kustomization", Filename: "kustomization.yaml", Limit: 1000},
+			code: true,
+		},
+		{
+			name:         "invalid repository search, no text or topics",
+			q:            SearchQuery{},
+			expectedErrs: []error{validation.ErrFieldRequired},
+		},
+		{
+			name:         "invalid code search, topics only",
+			q:            SearchQuery{Topics: []string{"flux"}},
+			code:         true,
+			expectedErrs: []error{validation.ErrFieldRequired},
+		},
+		{
+			name:         "invalid repository search, empty topic",
+			q:            SearchQuery{Topics: []string{""}},
+			expectedErrs: []error{validation.ErrFieldInvalid},
+		},
+		{
+			name:         "invalid code search, limit too large",
+			q:            SearchQuery{Text: "flux", Limit: 1001},
+			code:         true,
+			expectedErrs: []error{validation.ErrFieldInvalid},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validateFn := tt.q.ValidateRepositoryQuery
+			if tt.code {
+				validateFn = tt.q.ValidateCodeQuery
+			}
+			assertValidation(t, "SearchQuery", validateFn, tt.expectedErrs)
+		})
+	}
+}
+
+func TestBadgeRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		b            BadgeRequest
+		expectedErrs []error
+	}{
+		{
+			name: "valid pipeline badge",
+			b:    BadgeRequest{Kind: BadgeKindPipeline, Branch: "main", Workflow: "ci.yaml"},
+		},
+		{
+			name: "valid release badge",
+			b:    BadgeRequest{Kind: BadgeKindRelease},
+		},
+		{
+			name:         "invalid, missing kind",
+			b:            BadgeRequest{},
+			expectedErrs: []error{validation.ErrFieldRequired},
+		},
+		{
+			name:         "invalid, unknown kind",
+			b:            BadgeRequest{Kind: BadgeKind("coverage")},
+			expectedErrs: []error{validation.ErrFieldEnumInvalid},
+		},
+		{
+			name:         "invalid, branch of a release badge",
+			b:            BadgeRequest{Kind: BadgeKindRelease, Branch: "main"},
+			expectedErrs: []error{validation.ErrFieldInvalid},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidation(t, "Badge", tt.b.Validate, tt.expectedErrs)
+		})
+	}
+}
+
+func TestUserKeyInfo_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		k            UserKeyInfo
+		expectedErrs []error
+	}{
+		{
+			name: "valid ssh key",
+			k:    UserKeyInfo{Type: UserKeyTypeSSH, Title: "bot", Key: "ssh-ed25519 AAAA"},
+		},
+		{
+			name: "valid gpg key",
+			k:    UserKeyInfo{Type: UserKeyTypeGPG, Key: "-----BEGIN PGP PUBLIC KEY BLOCK-----"},
+		},
+		{
+			name:         "invalid, missing type and key",
+			k:            UserKeyInfo{},
+			expectedErrs: []error{validation.ErrFieldRequired},
+		},
+		{
+			name:         "invalid, unknown type",
+			k:            UserKeyInfo{Type: UserKeyType("x509"), Key: "key"},
+			expectedErrs: []error{validation.ErrFieldEnumInvalid},
+		},
+		{
+			name:         "invalid, title of a gpg key",
+			k:            UserKeyInfo{Type: UserKeyTypeGPG, Title: "bot", Key: "key"},
+			expectedErrs: []error{validation.ErrFieldInvalid},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidation(t, "UserKey", tt.k.Validate, tt.expectedErrs)
+		})
+	}
+}
+
+func TestEventListOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		o            EventListOptions
+		expectedErrs []error
+	}{
+		{
+			name: "valid, no filters",
+			o:    EventListOptions{},
+		},
+		{
+			name: "valid, types and limit",
+			o:    EventListOptions{Types: []EventType{EventTypePush, EventTypeMember}, Limit: 1000},
+		},
+		{
+			name:         "invalid, unknown type",
+			o:            EventListOptions{Types: []EventType{"issue"}},
+			expectedErrs: []error{validation.ErrFieldEnumInvalid},
+		},
+		{
+			name:         "invalid, limit too large",
+			o:            EventListOptions{Limit: 1001},
+			expectedErrs: []error{validation.ErrFieldInvalid},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidation(t, "EventListOptions", tt.o.Validate, tt.expectedErrs)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// UploadProgressFunc is called while the body of req is being sent to the Git provider, with the
+// number of bytes sent so far, and the total number of bytes (-1 if unknown).
+type UploadProgressFunc func(req *http.Request, sent, total int64)
+
+// uploadChunkSize is the maximum number of bytes read from a request body at once, when the upload
+// bandwidth is limited.
+const uploadChunkSize = 32 * 1024
+
+// uploadTransport is a http.RoundTripper that reports the progress of request body uploads, and
+// optionally limits their bandwidth.
+type uploadTransport struct {
+	// limiter limits the uploaded bytes per second, shared by all requests of the client. Optional.
+	limiter *rate.Limiter
+	// progress is called as the body is read. Optional.
+	progress UploadProgressFunc
+	next     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *uploadTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return t.next.RoundTrip(req)
+	}
+	// Don't modify the caller's request, as required by the http.RoundTripper contract
+	orig := req
+	req = req.Clone(req.Context())
+	req.Body = t.wrap(orig, orig.Body)
+	if orig.GetBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := orig.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			return t.wrap(orig, body), nil
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+func (t *uploadTransport) wrap(req *http.Request, body io.ReadCloser) io.ReadCloser {
+	return &uploadBody{ReadCloser: body, t: t, req: req, ctx: req.Context()}
+}
+
+// uploadBody is a request body reporting the progress and limiting the bandwidth of the upload.
+type uploadBody struct {
+	io.ReadCloser
+	t    *uploadTransport
+	req  *http.Request
+	ctx  context.Context
+	sent int64
+}
+
+// Read implements io.Reader.
+func (b *uploadBody) Read(p []byte) (int, error) {
+	if b.t.limiter != nil && len(p) > b.t.limiter.Burst() {
+		p = p[:b.t.limiter.Burst()]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.t.limiter != nil {
+		if waitErr := b.t.limiter.WaitN(b.ctx, n); waitErr != nil {
+			return 0, waitErr
+		}
+	}
+	b.sent += int64(n)
+	if b.t.progress != nil && (n > 0 || err == io.EOF) {
+		b.t.progress(b.req, b.sent, b.req.ContentLength)
+	}
+	return n, err
+}
+
+// uploadTransportFunc returns a ChainableRoundTripperFunc reporting the upload progress to progress
+// (if non-nil), and limiting the upload bandwidth to bytesPerSecond (if non-nil).
+func uploadTransportFunc(progress UploadProgressFunc, bytesPerSecond *int64) ChainableRoundTripperFunc {
+	return func(in http.RoundTripper) http.RoundTripper {
+		if in == nil {
+			in = http.DefaultTransport
+		}
+		t := &uploadTransport{progress: progress, next: in}
+		if bytesPerSecond != nil {
+			burst := uploadChunkSize
+			if *bytesPerSecond < uploadChunkSize {
+				burst = int(*bytesPerSecond)
+			}
+			t.limiter = rate.NewLimiter(rate.Limit(*bytesPerSecond), burst)
+		}
+		return t
+	}
+}
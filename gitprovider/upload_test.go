@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadTransport(t *testing.T) {
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received = len(b)
+	}))
+	defer server.Close()
+
+	var progress []int64
+	opts, err := MakeClientOptions(
+		WithUploadProgress(func(req *http.Request, sent, total int64) {
+			if total != 15000 {
+				t.Errorf("progress total = %d, want 15000", total)
+			}
+			progress = append(progress, sent)
+		}),
+		WithUploadBandwidthLimit(10000),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := BuildClientFromTransportChain(opts.GetTransportChain())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader(strings.Repeat("a", 15000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// The first 10000 bytes are the initial burst, the remaining 5000 take half a second
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("upload took %v, want it to be throttled to at least 400ms", elapsed)
+	}
+	if received != 15000 {
+		t.Errorf("server received %d bytes, want 15000", received)
+	}
+	if len(progress) < 2 || progress[len(progress)-1] != 15000 {
+		t.Errorf("progress = %v, want multiple updates ending at 15000", progress)
+	}
+}
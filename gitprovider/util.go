@@ -19,6 +19,7 @@ package gitprovider
 import (
 	"fmt"
 	"net/url"
+	"strings"
 )
 
 // BoolVar returns a pointer to the given bool.
@@ -31,6 +32,11 @@ func StringVar(s string) *string {
 	return &s
 }
 
+// IntVar returns a pointer to the given int.
+func IntVar(i int) *int {
+	return &i
+}
+
 // GetDomainURL returns the domain URL prepended with https:// if a scheme is not set.
 func GetDomainURL(d string) string {
 	parsedURL, _ := url.Parse(d)
@@ -39,3 +45,14 @@ func GetDomainURL(d string) string {
 	}
 	return d
 }
+
+// EscapePath escapes every slash-separated segment of the given path, so that
+// names containing non-ASCII or reserved characters can safely be used in URLs.
+// The slashes separating the segments are kept as-is.
+func EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
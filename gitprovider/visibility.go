@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// maxVisibilityPollInterval caps the time between the checks of WaitUntilVisible.
+	maxVisibilityPollInterval = 5 * time.Second
+)
+
+// visibilityPollIntervals are the times between the first checks of WaitUntilVisible, per
+// provider. GitHub replicates newly created objects within a second or two, but rapid polling
+// may trigger its secondary rate limits. GitLab creates some objects, e.g. imported projects,
+// asynchronously. Bitbucket Server is mostly consistent right away.
+//
+//nolint:gochecknoglobals
+var visibilityPollIntervals = map[ProviderID]time.Duration{
+	ProviderGitHub: 500 * time.Millisecond,
+	ProviderGitLab: 1 * time.Second,
+	ProviderStash:  250 * time.Millisecond,
+}
+
+// VisibilityCheck gets a resource, returning an error wrapping ErrNotFound while the resource is
+// not visible yet.
+type VisibilityCheck func(ctx context.Context) error
+
+// VisibilityWaiter waits for resources to become visible after mutations, as the Git providers are
+// eventually consistent, e.g. a just created repository may be reported as not found for a while.
+type VisibilityWaiter struct {
+	// Clock is the source of time used for the timeout and the time between the checks.
+	// Default: RealClock
+	Clock Clock
+
+	// Interval is the time between the first checks, which is doubled after each check up to
+	// five seconds. Default: one second
+	Interval time.Duration
+}
+
+// NewVisibilityWaiter returns a VisibilityWaiter polling with the interval tuned for the given
+// provider, using clock. A nil clock means RealClock.
+func NewVisibilityWaiter(provider ProviderID, clock Clock) VisibilityWaiter {
+	interval, ok := visibilityPollIntervals[provider]
+	if !ok {
+		interval = time.Second
+	}
+	return VisibilityWaiter{Clock: clock, Interval: interval}
+}
+
+// WaitUntilVisible calls check until it succeeds, i.e. the resource is visible, or returns an
+// error not wrapping ErrNotFound, which is returned right away. If the resource isn't visible
+// within timeout, an error wrapping the last ErrNotFound error is returned. If ctx is done
+// before that, ctx.Err() is returned.
+func (w VisibilityWaiter) WaitUntilVisible(ctx context.Context, check VisibilityCheck, timeout time.Duration) error {
+	clock := ClockOrDefault(w.Clock)
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	deadline := clock.Now().Add(timeout)
+
+	// The interval is doubled after each check, and capped to the time left before the deadline
+	next := interval
+	op := NewOperation(clock, interval, func(ctx context.Context) (OperationStatus, error) {
+		err := check(ctx)
+		if err == nil {
+			return OperationStatus{State: OperationStateSucceeded}, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return OperationStatus{}, err
+		}
+
+		remaining := deadline.Sub(clock.Now())
+		if remaining <= 0 {
+			return OperationStatus{}, fmt.Errorf("resource not visible after %s: %w", timeout, err)
+		}
+		wait := next
+		if wait > remaining {
+			wait = remaining
+		}
+		next *= 2
+		if next > maxVisibilityPollInterval {
+			next = maxVisibilityPollInterval
+		}
+		return OperationStatus{State: OperationStateRunning, RetryAfter: wait}, nil
+	})
+	_, err := op.Wait(ctx)
+	return err
+}
+
+// WaitUntilVisible calls check until it succeeds, or returns an error not wrapping ErrNotFound,
+// for up to timeout. See VisibilityWaiter.WaitUntilVisible for details; the checks are a second
+// apart at first, and use the system time.
+func WaitUntilVisible(ctx context.Context, check VisibilityCheck, timeout time.Duration) error {
+	return VisibilityWaiter{}.WaitUntilVisible(ctx, check, timeout)
+}
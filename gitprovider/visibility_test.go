@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+// steppingClock is a Clock whose Sleep advances the time right away, recording the durations.
+type steppingClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	return c.now
+}
+
+func (c *steppingClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return ctx.Err()
+}
+
+func TestVisibilityWaiter_WaitUntilVisible(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name       string
+		provider   ProviderID
+		results    []error
+		timeout    time.Duration
+		wantErrs   []error
+		wantSleeps []time.Duration
+	}{
+		{
+			name:     "visible right away",
+			provider: ProviderGitHub,
+			results:  []error{nil},
+			timeout:  time.Minute,
+		},
+		{
+			name:       "visible after backoff",
+			provider:   ProviderGitHub,
+			results:    []error{ErrNotFound, fmt.Errorf("get: %w", ErrNotFound), ErrNotFound, nil},
+			timeout:    time.Minute,
+			wantSleeps: []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second},
+		},
+		{
+			name:       "unexpected error",
+			provider:   ProviderGitLab,
+			results:    []error{ErrNotFound, errBoom},
+			timeout:    time.Minute,
+			wantErrs:   []error{errBoom},
+			wantSleeps: []time.Duration{time.Second},
+		},
+		{
+			name:       "timeout",
+			provider:   ProviderGitLab,
+			results:    []error{ErrNotFound, ErrNotFound, ErrNotFound, ErrNotFound},
+			timeout:    4 * time.Second,
+			wantErrs:   []error{ErrNotFound},
+			wantSleeps: []time.Duration{time.Second, 2 * time.Second, time.Second},
+		},
+		{
+			name:       "interval capped",
+			provider:   ProviderID("other"),
+			results:    []error{ErrNotFound, ErrNotFound, ErrNotFound, ErrNotFound, nil},
+			timeout:    time.Minute,
+			wantSleeps: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &steppingClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+			calls := 0
+			err := NewVisibilityWaiter(tt.provider, clock).WaitUntilVisible(context.Background(), func(ctx context.Context) error {
+				err := tt.results[calls]
+				calls++
+				return err
+			}, tt.timeout)
+			validation.TestExpectErrors(t, "WaitUntilVisible", err, tt.wantErrs...)
+			if calls != len(tt.results) {
+				t.Errorf("checks = %d, want %d", calls, len(tt.results))
+			}
+			if fmt.Sprint(clock.sleeps) != fmt.Sprint(tt.wantSleeps) {
+				t.Errorf("sleeps = %v, want %v", clock.sleeps, tt.wantSleeps)
+			}
+		})
+	}
+}
+
+func TestWaitUntilVisible_Context(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := WaitUntilVisible(ctx, func(ctx context.Context) error {
+		return ErrNotFound
+	}, time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitUntilVisible() error = %v, want %v", err, context.Canceled)
+	}
+}
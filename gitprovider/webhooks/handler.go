@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// Handler is an http.Handler which verifies and parses the deliveries of a provider with
+// ParseRequest, and passes the events on to Handle. It responds with:
+//
+//   - 200 OK if the event was handled
+//   - 202 Accepted for the deliveries of unsupported events, which are ignored
+//   - 400 Bad Request if the delivery can't be verified or parsed
+//   - 405 Method Not Allowed for other methods than POST
+//   - 409 Conflict for a delivery ID already seen in the replay window
+//   - 413 Request Entity Too Large if the announced body is larger than MaxBodyBytes
+type Handler struct {
+	// Provider is the Git provider that sends the deliveries.
+	Provider Provider
+
+	// Secret is the secret configured in the webhook. All deliveries are rejected if it is empty.
+	Secret []byte
+
+	// Handle is called with each verified event.
+	Handle func(e *Event)
+
+	// MaxBodyBytes is the largest accepted delivery, also for chunked bodies.
+	// Default: 0 (which means unlimited)
+	MaxBodyBytes int64
+
+	// Replay rejects the deliveries with an ID already seen, if set. It can be shared by the
+	// handlers of several providers. The delivery ID headers aren't covered by the signature, so
+	// this only stops deliveries replayed as captured: an attacker can change or drop the ID.
+	// Default: nil
+	Replay *ReplayWindow
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.MaxBodyBytes > 0 {
+		if r.ContentLength > h.MaxBodyBytes {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		// Also guard against chunked bodies, and a Content-Length which doesn't match the body
+		r.Body = http.MaxBytesReader(w, r.Body, h.MaxBodyBytes)
+	}
+	event, err := ParseRequest(r, h.Provider, h.Secret)
+	ignored := errors.Is(err, ErrUnsupportedEvent)
+	if err != nil && !ignored {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Only verified deliveries are recorded, so that forged requests can't block the real ones
+	if h.Replay != nil && h.Replay.Seen(h.Provider, deliveryID(h.Provider, r.Header)) {
+		http.Error(w, "duplicate delivery", http.StatusConflict)
+		return
+	}
+	if ignored {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if h.Handle != nil {
+		h.Handle(event)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ReplayWindow remembers delivery IDs for a duration, to reject captured deliveries sent again.
+// It is safe for concurrent use.
+//
+// The delivery IDs are read from headers that the providers don't sign, e.g. X-GitHub-Delivery, so
+// a replayed delivery with a changed or removed ID still passes the signature check, and isn't
+// detected. It guards against naive replays only; receivers needing more must make the handling of
+// the events idempotent, e.g. by comparing Event.SHA with the state already applied.
+type ReplayWindow struct {
+	window time.Duration
+	clock  gitprovider.Clock
+
+	mu  sync.Mutex
+	ids map[string]time.Time
+}
+
+// NewReplayWindow returns a ReplayWindow remembering delivery IDs for the given duration, using the
+// clock to tell the time, usually gitprovider.RealClock{}.
+func NewReplayWindow(window time.Duration, clock gitprovider.Clock) *ReplayWindow {
+	return &ReplayWindow{window: window, clock: clock, ids: map[string]time.Time{}}
+}
+
+// Seen records the delivery ID of the provider, and returns true if it was already recorded within
+// the window. Deliveries without an ID can't be told apart, and are never reported as seen.
+func (rw *ReplayWindow) Seen(provider Provider, id string) bool {
+	if id == "" {
+		return false
+	}
+	key := string(provider) + "/" + id
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	now := rw.clock.Now()
+	for k, t := range rw.ids {
+		if now.Sub(t) >= rw.window {
+			delete(rw.ids, k)
+		}
+	}
+	if _, ok := rw.ids[key]; ok {
+		return true
+	}
+	rw.ids[key] = now
+	return false
+}
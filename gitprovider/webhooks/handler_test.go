@@ -0,0 +1,227 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider/testutils"
+)
+
+func TestHandler(t *testing.T) {
+	secret := []byte("s3cr3t")
+	githubPush := `{"ref":"refs/heads/main","after":"abc123","repository":{"html_url":"https://github.com/fluxcd/flux2"}}`
+	gitlabMerge := `{"object_kind":"merge_request","project":{"web_url":"https://gitlab.com/fluxcd/flux2"},` +
+		`"object_attributes":{"iid":7,"action":"open","source_branch":"feature","last_commit":{"id":"def456"}}}`
+
+	tests := []struct {
+		name       string
+		provider   Provider
+		method     string
+		body       string
+		header     map[string]string
+		wantStatus int
+		want       *Event
+	}{
+		{
+			name:     "github push",
+			provider: ProviderGitHub,
+			body:     githubPush,
+			header: map[string]string{
+				"X-GitHub-Event":      "push",
+				"X-GitHub-Delivery":   "1",
+				"X-Hub-Signature-256": "sha256=" + hmacSHA256([]byte(githubPush), secret),
+			},
+			wantStatus: http.StatusOK,
+			want: &Event{
+				Provider:   ProviderGitHub,
+				Type:       EventTypePush,
+				DeliveryID: "1",
+				Repository: "https://github.com/fluxcd/flux2",
+				Ref:        "refs/heads/main",
+				SHA:        "abc123",
+			},
+		},
+		{
+			name:     "github bad signature",
+			provider: ProviderGitHub,
+			body:     githubPush,
+			header: map[string]string{
+				"X-GitHub-Event":      "push",
+				"X-Hub-Signature-256": "sha256=" + hmacSHA256([]byte("tampered"), secret),
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "github unsupported event",
+			provider: ProviderGitHub,
+			body:     `{}`,
+			header: map[string]string{
+				"X-GitHub-Event":      "issue_comment",
+				"X-Hub-Signature-256": "sha256=" + hmacSHA256([]byte(`{}`), secret),
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:     "gitlab merge request",
+			provider: ProviderGitLab,
+			body:     gitlabMerge,
+			header: map[string]string{
+				"X-Gitlab-Event": "Merge Request Hook",
+				"X-Gitlab-Token": string(secret),
+			},
+			wantStatus: http.StatusOK,
+			want: &Event{
+				Provider:          ProviderGitLab,
+				Type:              EventTypePullRequest,
+				Repository:        "https://gitlab.com/fluxcd/flux2",
+				Ref:               "refs/heads/feature",
+				SHA:               "def456",
+				PullRequestNumber: 7,
+				Action:            ActionOpened,
+			},
+		},
+		{
+			name:     "gitlab wrong token",
+			provider: ProviderGitLab,
+			body:     gitlabMerge,
+			header: map[string]string{
+				"X-Gitlab-Event": "Merge Request Hook",
+				"X-Gitlab-Token": "guess",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "get",
+			provider:   ProviderGitHub,
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Event
+			h := &Handler{Provider: tt.provider, Secret: secret, Handle: func(e *Event) { got = e }}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/", strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ServeHTTP() handled %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_Hardening(t *testing.T) {
+	secret := []byte("s3cr3t")
+	body := `{"object_kind":"push","ref":"refs/heads/main","after":"abc123","project":{"web_url":"https://gitlab.com/fluxcd/flux2"}}`
+
+	clock := testutils.NewFakeClock(time.Unix(1600000000, 0))
+	h := &Handler{
+		Provider:     ProviderGitLab,
+		Secret:       secret,
+		MaxBodyBytes: int64(len(body)),
+		Replay:       NewReplayWindow(time.Minute, clock),
+	}
+
+	send := func(body, token, uuid string, chunked bool) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		req.Header.Set("X-Gitlab-Event", "Push Hook")
+		req.Header.Set("X-Gitlab-Token", token)
+		req.Header.Set("X-Gitlab-Event-UUID", uuid)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		token      string
+		uuid       string
+		chunked    bool
+		advance    time.Duration
+		wantStatus int
+	}{
+		{name: "too large", body: body + " ", token: string(secret), uuid: "1", wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "too large, chunked", body: body + " ", token: string(secret), uuid: "1", chunked: true, wantStatus: http.StatusBadRequest},
+		{name: "forged delivery isn't recorded", body: body, token: "guess", uuid: "1", wantStatus: http.StatusBadRequest},
+		{name: "first delivery", body: body, token: string(secret), uuid: "1", wantStatus: http.StatusOK},
+		{name: "replayed delivery", body: body, token: string(secret), uuid: "1", advance: 59 * time.Second, wantStatus: http.StatusConflict},
+		{name: "other delivery", body: body, token: string(secret), uuid: "2", wantStatus: http.StatusOK},
+		{name: "replayed after the window", body: body, token: string(secret), uuid: "1", advance: time.Second, wantStatus: http.StatusOK},
+		{name: "no delivery ID", body: body, token: string(secret), wantStatus: http.StatusOK},
+		{name: "no delivery ID, again", body: body, token: string(secret), wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		if got := send(tt.body, tt.token, tt.uuid, tt.chunked); got != tt.wantStatus {
+			t.Errorf("%s: ServeHTTP() status = %d, want %d", tt.name, got, tt.wantStatus)
+		}
+	}
+}
+
+func TestHandler_EmptySecret(t *testing.T) {
+	body := `{"object_kind":"push","ref":"refs/heads/main","after":"abc123","project":{"web_url":"https://gitlab.com/fluxcd/flux2"}}`
+	handled := false
+	h := &Handler{Provider: ProviderGitLab, Handle: func(e *Event) { handled = true }}
+
+	// GitLab deliveries without a token would match an empty secret
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Gitlab-Event", "Push Hook")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if handled {
+		t.Error("ServeHTTP() handled a delivery without a secret")
+	}
+}
+
+func TestReplayWindow_Providers(t *testing.T) {
+	rw := NewReplayWindow(time.Minute, testutils.NewFakeClock(time.Unix(1600000000, 0)))
+	if rw.Seen(ProviderGitHub, "1") {
+		t.Error("Seen() = true for the first delivery")
+	}
+	if rw.Seen(ProviderGitLab, "1") {
+		t.Error("Seen() = true for the same ID from another provider")
+	}
+	if !rw.Seen(ProviderGitHub, "1") {
+		t.Error("Seen() = false for a replayed delivery")
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v41/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// tagRefPrefix is the prefix of the full names of tags.
+const tagRefPrefix = "refs/tags/"
+
+// pushType returns EventTypeTag for pushed tags, and EventTypePush otherwise.
+func pushType(ref string) EventType {
+	if strings.HasPrefix(ref, tagRefPrefix) {
+		return EventTypeTag
+	}
+	return EventTypePush
+}
+
+// parseGitHub normalizes a GitHub or Gitea payload, as their payloads are compatible.
+func parseGitHub(eventType string, payload []byte) (*Event, error) {
+	switch eventType {
+	case "push":
+		apiObj := &github.PushEvent{}
+		if err := json.Unmarshal(payload, apiObj); err != nil {
+			return nil, fmt.Errorf("failed to parse the push payload: %w", err)
+		}
+		return &Event{
+			Type:       pushType(apiObj.GetRef()),
+			Repository: apiObj.GetRepo().GetHTMLURL(),
+			Ref:        apiObj.GetRef(),
+			Before:     apiObj.GetBefore(),
+			SHA:        apiObj.GetAfter(),
+			Sender:     apiObj.GetSender().GetLogin(),
+		}, nil
+	case "pull_request":
+		apiObj := &github.PullRequestEvent{}
+		if err := json.Unmarshal(payload, apiObj); err != nil {
+			return nil, fmt.Errorf("failed to parse the pull request payload: %w", err)
+		}
+		pr := apiObj.GetPullRequest()
+		action := apiObj.GetAction()
+		switch {
+		case action == "closed" && pr.GetMerged():
+			action = ActionMerged
+		case action == "synchronize" || action == "synchronized":
+			// Gitea names it "synchronized"
+			action = ActionUpdated
+		}
+		return &Event{
+			Type:              EventTypePullRequest,
+			Repository:        apiObj.GetRepo().GetHTMLURL(),
+			Ref:               "refs/heads/" + pr.GetHead().GetRef(),
+			SHA:               pr.GetHead().GetSHA(),
+			PullRequestNumber: apiObj.GetNumber(),
+			BaseBranch:        pr.GetBase().GetRef(),
+			Action:            action,
+			Sender:            apiObj.GetSender().GetLogin(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEvent, eventType)
+	}
+}
+
+// gitlabActions are the normalized names of the merge request actions of GitLab.
+//
+//nolint:gochecknoglobals
+var gitlabActions = map[string]string{
+	"open":   ActionOpened,
+	"update": ActionUpdated,
+	"close":  ActionClosed,
+	"merge":  ActionMerged,
+	"reopen": ActionReopened,
+}
+
+// parseGitLab normalizes a GitLab payload.
+func parseGitLab(eventType string, payload []byte) (*Event, error) {
+	switch gitlab.EventType(eventType) {
+	case gitlab.EventTypePush, gitlab.EventTypeTagPush:
+		// Both payloads have the same fields
+		apiObj := &gitlab.PushEvent{}
+		if err := json.Unmarshal(payload, apiObj); err != nil {
+			return nil, fmt.Errorf("failed to parse the push payload: %w", err)
+		}
+		return &Event{
+			Type:       pushType(apiObj.Ref),
+			Repository: apiObj.Project.WebURL,
+			Ref:        apiObj.Ref,
+			Before:     apiObj.Before,
+			SHA:        apiObj.After,
+			Sender:     apiObj.UserUsername,
+		}, nil
+	case gitlab.EventTypeMergeRequest:
+		apiObj := &gitlab.MergeEvent{}
+		if err := json.Unmarshal(payload, apiObj); err != nil {
+			return nil, fmt.Errorf("failed to parse the merge request payload: %w", err)
+		}
+		attrs := apiObj.ObjectAttributes
+		action, ok := gitlabActions[attrs.Action]
+		if !ok {
+			action = attrs.Action
+		}
+		event := &Event{
+			Type:              EventTypePullRequest,
+			Repository:        apiObj.Project.WebURL,
+			Ref:               "refs/heads/" + attrs.SourceBranch,
+			SHA:               attrs.LastCommit.ID,
+			PullRequestNumber: attrs.IID,
+			BaseBranch:        attrs.TargetBranch,
+			Action:            action,
+		}
+		if apiObj.User != nil {
+			event.Sender = apiObj.User.Username
+		}
+		return event, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEvent, eventType)
+	}
+}
+
+// bitbucketRepository is the repository of a Bitbucket Server payload.
+type bitbucketRepository struct {
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Links struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+// webURL returns the web URL of the repository, or its "PROJECT/slug" if it has no links.
+func (r bitbucketRepository) webURL() string {
+	if len(r.Links.Self) != 0 {
+		return strings.TrimSuffix(r.Links.Self[0].Href, "/browse")
+	}
+	return r.Project.Key + "/" + r.Slug
+}
+
+// bitbucketRef is a branch or tag of a Bitbucket Server payload.
+type bitbucketRef struct {
+	ID           string              `json:"id"`
+	DisplayID    string              `json:"displayId"`
+	LatestCommit string              `json:"latestCommit"`
+	Repository   bitbucketRepository `json:"repository"`
+}
+
+// bitbucketPayload holds the fields of the Bitbucket Server payloads of pushes and pull requests.
+type bitbucketPayload struct {
+	Actor struct {
+		Slug string `json:"slug"`
+	} `json:"actor"`
+	Repository bitbucketRepository `json:"repository"`
+	Changes    []struct {
+		RefID    string `json:"refId"`
+		FromHash string `json:"fromHash"`
+		ToHash   string `json:"toHash"`
+	} `json:"changes"`
+	PullRequest struct {
+		ID      int          `json:"id"`
+		FromRef bitbucketRef `json:"fromRef"`
+		ToRef   bitbucketRef `json:"toRef"`
+	} `json:"pullRequest"`
+}
+
+// bitbucketActions are the normalized actions of the pull request event keys of Bitbucket Server.
+//
+//nolint:gochecknoglobals
+var bitbucketActions = map[string]string{
+	"pr:opened":           ActionOpened,
+	"pr:from_ref_updated": ActionUpdated,
+	"pr:declined":         ActionClosed,
+	"pr:deleted":          ActionClosed,
+	"pr:merged":           ActionMerged,
+}
+
+// parseBitbucketServer normalizes a Bitbucket Server payload. Only the first change of a push of
+// several references is reported.
+func parseBitbucketServer(eventKey string, payload []byte) (*Event, error) {
+	action, isPullRequest := bitbucketActions[eventKey]
+	if eventKey != "repo:refs_changed" && !isPullRequest {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEvent, eventKey)
+	}
+	apiObj := &bitbucketPayload{}
+	if err := json.Unmarshal(payload, apiObj); err != nil {
+		return nil, fmt.Errorf("failed to parse the %s payload: %w", eventKey, err)
+	}
+
+	if isPullRequest {
+		pr := apiObj.PullRequest
+		return &Event{
+			Type:              EventTypePullRequest,
+			Repository:        pr.ToRef.Repository.webURL(),
+			Ref:               pr.FromRef.ID,
+			SHA:               pr.FromRef.LatestCommit,
+			PullRequestNumber: pr.ID,
+			BaseBranch:        pr.ToRef.DisplayID,
+			Action:            action,
+			Sender:            apiObj.Actor.Slug,
+		}, nil
+	}
+	if len(apiObj.Changes) == 0 {
+		return nil, fmt.Errorf("%w: %q without changes", ErrUnsupportedEvent, eventKey)
+	}
+	change := apiObj.Changes[0]
+	return &Event{
+		Type:       pushType(change.RefID),
+		Repository: apiObj.Repository.webURL(),
+		Ref:        change.RefID,
+		Before:     change.FromHash,
+		SHA:        change.ToHash,
+		Sender:     apiObj.Actor.Slug,
+	}, nil
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhooks verifies and parses the webhook deliveries of GitHub, GitLab, Gitea and
+// Bitbucket Server, normalizing push, tag and pull request events into a single Event, so that
+// receivers don't need a parser per provider.
+//
+// Handler is an http.Handler which limits the size of the deliveries, verifies and parses them, and
+// rejects replayed deliveries:
+//
+//	replay := webhooks.NewReplayWindow(10*time.Minute, gitprovider.RealClock{})
+//	http.Handle("/github", &webhooks.Handler{
+//		Provider:     webhooks.ProviderGitHub,
+//		Secret:       secret,
+//		MaxBodyBytes: 5 << 20,
+//		Replay:       replay,
+//		Handle:       func(e *webhooks.Event) { /* ... */ },
+//	})
+//
+// Receivers with other needs can limit the size of the body, and then call ParseRequest:
+//
+//	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+//	event, err := webhooks.ParseRequest(r, webhooks.ProviderGitHub, secret)
+//	if errors.Is(err, webhooks.ErrUnsupportedEvent) { /* acknowledge and ignore */ }
+package webhooks
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/go-github/v41/github"
+)
+
+// Provider is the Git provider that sent a delivery.
+type Provider string
+
+const (
+	// ProviderGitHub is GitHub and GitHub Enterprise Server.
+	ProviderGitHub = Provider("github")
+	// ProviderGitLab is GitLab.
+	ProviderGitLab = Provider("gitlab")
+	// ProviderGitea is Gitea, whose payloads are compatible with the GitHub ones.
+	ProviderGitea = Provider("gitea")
+	// ProviderBitbucketServer is Bitbucket Server, also known as Stash.
+	ProviderBitbucketServer = Provider("bitbucket-server")
+)
+
+// EventType is the normalized kind of a webhook event.
+type EventType string
+
+const (
+	// EventTypePush is sent when commits are pushed to a branch, or a branch is deleted.
+	EventTypePush = EventType("push")
+	// EventTypeTag is sent when a tag is pushed or deleted.
+	EventTypeTag = EventType("tag")
+	// EventTypePullRequest is sent when a pull (or merge) request is opened, updated or closed.
+	EventTypePullRequest = EventType("pull_request")
+)
+
+// The normalized actions of pull request events. The actions without an equivalent on all
+// providers, e.g. "labeled" on GitHub, are passed as sent by the provider.
+const (
+	ActionOpened   = "opened"
+	ActionUpdated  = "updated"
+	ActionClosed   = "closed"
+	ActionMerged   = "merged"
+	ActionReopened = "reopened"
+)
+
+var (
+	// ErrInvalidSignature is returned if the signature or token of a delivery doesn't match the secret.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrUnsupportedEvent is returned for the deliveries of events which aren't normalized, e.g.
+	// issue comments. They should be acknowledged, and ignored.
+	ErrUnsupportedEvent = errors.New("unsupported webhook event")
+)
+
+// Event is a webhook event normalized across providers.
+type Event struct {
+	// Provider is the Git provider that sent the event.
+	Provider Provider `json:"provider"`
+
+	// Type is the kind of event.
+	Type EventType `json:"type"`
+
+	// DeliveryID is the unique ID of the delivery, if the provider sends one, e.g. to reject
+	// replayed deliveries.
+	DeliveryID string `json:"deliveryID,omitempty"`
+
+	// Repository is the web URL of the repository. Bitbucket Server only sends it if the
+	// repository has links, its "PROJECT/slug" is used otherwise.
+	Repository string `json:"repository"`
+
+	// Ref is the full name of the pushed Git reference, e.g. "refs/heads/main", or of the head
+	// branch of the pull request.
+	Ref string `json:"ref"`
+
+	// Before is the commit the pushed Ref pointed to before the push, all zeros if it was created.
+	Before string `json:"before,omitempty"`
+
+	// SHA is the commit the Ref points to after the event, all zeros if it was deleted.
+	SHA string `json:"sha"`
+
+	// PullRequestNumber is the number of the pull request, or zero for pushes.
+	PullRequestNumber int `json:"pullRequestNumber,omitempty"`
+
+	// BaseBranch is the branch the pull request is merged into.
+	BaseBranch string `json:"baseBranch,omitempty"`
+
+	// Action is what happened to the pull request, e.g. ActionOpened.
+	Action string `json:"action,omitempty"`
+
+	// Sender is the login of the user who caused the event.
+	Sender string `json:"sender,omitempty"`
+}
+
+// ParseRequest reads the body of the delivery, verifies it against the secret with
+// VerifySignature, and parses it with Parse. ErrInvalidSignature is returned if the secret is
+// empty, as the deliveries couldn't be told apart from forged ones.
+func ParseRequest(r *http.Request, provider Provider, secret []byte) (*Event, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("%w: no secret is configured", ErrInvalidSignature)
+	}
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the webhook payload: %w", err)
+	}
+	if err := VerifySignature(provider, r.Header, payload, secret); err != nil {
+		return nil, err
+	}
+	return Parse(provider, r.Header, payload)
+}
+
+// VerifySignature verifies that the payload was sent by the provider, with the secret configured
+// in the webhook:
+//
+//   - GitHub: the X-Hub-Signature-256 (or legacy X-Hub-Signature) HMAC of the payload
+//   - GitLab: the X-Gitlab-Token header, which is the secret itself
+//   - Gitea: the X-Gitea-Signature HMAC-SHA256 of the payload
+//   - Bitbucket Server: the X-Hub-Signature HMAC-SHA256 of the payload
+//
+// ErrInvalidSignature is returned if it doesn't match, or if the secret is empty, as an empty
+// GitLab token would match the deliveries without one.
+func VerifySignature(provider Provider, header http.Header, payload, secret []byte) error {
+	if len(secret) == 0 {
+		return fmt.Errorf("%w: no secret is configured", ErrInvalidSignature)
+	}
+	var err error
+	switch provider {
+	case ProviderGitHub:
+		signature := header.Get("X-Hub-Signature-256")
+		if signature == "" {
+			signature = header.Get("X-Hub-Signature")
+		}
+		err = github.ValidateSignature(signature, payload, secret)
+	case ProviderGitLab:
+		if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), secret) != 1 {
+			err = errors.New("the X-Gitlab-Token doesn't match")
+		}
+	case ProviderGitea:
+		err = github.ValidateSignature("sha256="+header.Get("X-Gitea-Signature"), payload, secret)
+	case ProviderBitbucketServer:
+		err = github.ValidateSignature(header.Get("X-Hub-Signature"), payload, secret)
+	default:
+		return fmt.Errorf("unknown provider %q", provider)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// Parse normalizes the payload of a verified delivery, using the event type header of the
+// provider. ErrUnsupportedEvent is returned for the events which aren't pushes, tags or pull
+// requests.
+func Parse(provider Provider, header http.Header, payload []byte) (*Event, error) {
+	var event *Event
+	var err error
+	switch provider {
+	case ProviderGitHub:
+		event, err = parseGitHub(header.Get("X-GitHub-Event"), payload)
+	case ProviderGitLab:
+		event, err = parseGitLab(header.Get("X-Gitlab-Event"), payload)
+	case ProviderGitea:
+		event, err = parseGitHub(header.Get("X-Gitea-Event"), payload)
+	case ProviderBitbucketServer:
+		event, err = parseBitbucketServer(header.Get("X-Event-Key"), payload)
+	default:
+		return nil, fmt.Errorf("unknown provider %q", provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+	event.Provider = provider
+	event.DeliveryID = deliveryID(provider, header)
+	return event, nil
+}
+
+// deliveryID returns the unique ID the provider sent for the delivery, or an empty string if none.
+func deliveryID(provider Provider, header http.Header) string {
+	switch provider {
+	case ProviderGitHub:
+		return header.Get("X-GitHub-Delivery")
+	case ProviderGitLab:
+		// Sent by GitLab 14.1 and later
+		return header.Get("X-Gitlab-Event-UUID")
+	case ProviderGitea:
+		return header.Get("X-Gitea-Delivery")
+	case ProviderBitbucketServer:
+		return header.Get("X-Request-Id")
+	}
+	return ""
+}
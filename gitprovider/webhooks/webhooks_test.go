@@ -0,0 +1,220 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func hmacSHA256(payload, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"ref":"refs/heads/main"}`)
+	secret := []byte("s3cr3t")
+	signature := hmacSHA256(payload, secret)
+	tests := []struct {
+		name     string
+		provider Provider
+		header   http.Header
+		secret   []byte
+		wantErr  bool
+	}{
+		{name: "github", provider: ProviderGitHub, header: http.Header{"X-Hub-Signature-256": {"sha256=" + signature}}},
+		{name: "github invalid", provider: ProviderGitHub, header: http.Header{"X-Hub-Signature-256": {"sha256=" + hmacSHA256(payload, []byte("other"))}}, wantErr: true},
+		{name: "github missing", provider: ProviderGitHub, header: http.Header{}, wantErr: true},
+		{name: "gitlab", provider: ProviderGitLab, header: http.Header{"X-Gitlab-Token": {"s3cr3t"}}},
+		{name: "gitlab invalid", provider: ProviderGitLab, header: http.Header{"X-Gitlab-Token": {"other"}}, wantErr: true},
+		{name: "gitea", provider: ProviderGitea, header: http.Header{"X-Gitea-Signature": {signature}}},
+		{name: "gitea invalid", provider: ProviderGitea, header: http.Header{"X-Gitea-Signature": {"00"}}, wantErr: true},
+		{name: "bitbucket server", provider: ProviderBitbucketServer, header: http.Header{"X-Hub-Signature": {"sha256=" + signature}}},
+		{name: "bitbucket server invalid", provider: ProviderBitbucketServer, header: http.Header{}, wantErr: true},
+		{name: "github empty secret", provider: ProviderGitHub, header: http.Header{"X-Hub-Signature-256": {"sha256=" + hmacSHA256(payload, nil)}}, secret: []byte{}, wantErr: true},
+		{name: "gitlab empty secret", provider: ProviderGitLab, header: http.Header{}, secret: []byte{}, wantErr: true},
+		{name: "gitea empty secret", provider: ProviderGitea, header: http.Header{"X-Gitea-Signature": {hmacSHA256(payload, nil)}}, secret: []byte{}, wantErr: true},
+		{name: "bitbucket server empty secret", provider: ProviderBitbucketServer, header: http.Header{"X-Hub-Signature": {"sha256=" + hmacSHA256(payload, nil)}}, secret: []byte{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := secret
+			if tt.secret != nil {
+				s = tt.secret
+			}
+			err := VerifySignature(tt.provider, tt.header, payload, s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifySignature() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		header   http.Header
+		payload  string
+		want     *Event
+		wantErr  error
+	}{
+		{
+			name:     "github push",
+			provider: ProviderGitHub,
+			header:   http.Header{"X-Github-Event": {"push"}, "X-Github-Delivery": {"abc"}},
+			payload:  `{"ref":"refs/heads/main","before":"111","after":"222","repository":{"html_url":"https://github.com/acme/app"},"sender":{"login":"alice"}}`,
+			want: &Event{
+				Provider: ProviderGitHub, Type: EventTypePush, DeliveryID: "abc", Repository: "https://github.com/acme/app",
+				Ref: "refs/heads/main", Before: "111", SHA: "222", Sender: "alice",
+			},
+		},
+		{
+			name:     "github tag",
+			provider: ProviderGitHub,
+			header:   http.Header{"X-Github-Event": {"push"}},
+			payload:  `{"ref":"refs/tags/v1.0.0","after":"222"}`,
+			want:     &Event{Provider: ProviderGitHub, Type: EventTypeTag, Ref: "refs/tags/v1.0.0", SHA: "222"},
+		},
+		{
+			name:     "github merged pull request",
+			provider: ProviderGitHub,
+			header:   http.Header{"X-Github-Event": {"pull_request"}},
+			payload:  `{"action":"closed","number":7,"pull_request":{"merged":true,"head":{"ref":"feature","sha":"333"},"base":{"ref":"main"}},"repository":{"html_url":"https://github.com/acme/app"},"sender":{"login":"alice"}}`,
+			want: &Event{
+				Provider: ProviderGitHub, Type: EventTypePullRequest, Repository: "https://github.com/acme/app",
+				Ref: "refs/heads/feature", SHA: "333", PullRequestNumber: 7, BaseBranch: "main", Action: ActionMerged, Sender: "alice",
+			},
+		},
+		{
+			name:     "github unsupported",
+			provider: ProviderGitHub,
+			header:   http.Header{"X-Github-Event": {"issues"}},
+			payload:  `{}`,
+			wantErr:  ErrUnsupportedEvent,
+		},
+		{
+			name:     "gitea synchronized pull request",
+			provider: ProviderGitea,
+			header:   http.Header{"X-Gitea-Event": {"pull_request"}, "X-Gitea-Delivery": {"def"}},
+			payload:  `{"action":"synchronized","number":3,"pull_request":{"head":{"ref":"feature","sha":"444"},"base":{"ref":"main"}},"repository":{"html_url":"https://gitea.example.com/acme/app"},"sender":{"login":"bob"}}`,
+			want: &Event{
+				Provider: ProviderGitea, Type: EventTypePullRequest, DeliveryID: "def", Repository: "https://gitea.example.com/acme/app",
+				Ref: "refs/heads/feature", SHA: "444", PullRequestNumber: 3, BaseBranch: "main", Action: ActionUpdated, Sender: "bob",
+			},
+		},
+		{
+			name:     "gitlab tag push",
+			provider: ProviderGitLab,
+			header:   http.Header{"X-Gitlab-Event": {"Tag Push Hook"}},
+			payload:  `{"object_kind":"tag_push","ref":"refs/tags/v1.0.0","before":"000","after":"555","user_username":"carol","project":{"web_url":"https://gitlab.com/acme/app"}}`,
+			want: &Event{
+				Provider: ProviderGitLab, Type: EventTypeTag, Repository: "https://gitlab.com/acme/app",
+				Ref: "refs/tags/v1.0.0", Before: "000", SHA: "555", Sender: "carol",
+			},
+		},
+		{
+			name:     "gitlab merge request",
+			provider: ProviderGitLab,
+			header:   http.Header{"X-Gitlab-Event": {"Merge Request Hook"}},
+			payload:  `{"object_kind":"merge_request","user":{"username":"carol"},"project":{"web_url":"https://gitlab.com/acme/app"},"object_attributes":{"iid":9,"action":"open","source_branch":"feature","target_branch":"main","last_commit":{"id":"666"}}}`,
+			want: &Event{
+				Provider: ProviderGitLab, Type: EventTypePullRequest, Repository: "https://gitlab.com/acme/app",
+				Ref: "refs/heads/feature", SHA: "666", PullRequestNumber: 9, BaseBranch: "main", Action: ActionOpened, Sender: "carol",
+			},
+		},
+		{
+			name:     "bitbucket server push",
+			provider: ProviderBitbucketServer,
+			header:   http.Header{"X-Event-Key": {"repo:refs_changed"}, "X-Request-Id": {"ghi"}},
+			payload:  `{"actor":{"slug":"dave"},"repository":{"slug":"app","project":{"key":"ACME"}},"changes":[{"refId":"refs/heads/main","fromHash":"777","toHash":"888"}]}`,
+			want: &Event{
+				Provider: ProviderBitbucketServer, Type: EventTypePush, DeliveryID: "ghi", Repository: "ACME/app",
+				Ref: "refs/heads/main", Before: "777", SHA: "888", Sender: "dave",
+			},
+		},
+		{
+			name:     "bitbucket server declined pull request",
+			provider: ProviderBitbucketServer,
+			header:   http.Header{"X-Event-Key": {"pr:declined"}},
+			payload:  `{"actor":{"slug":"dave"},"pullRequest":{"id":2,"fromRef":{"id":"refs/heads/feature","latestCommit":"999"},"toRef":{"displayId":"main","repository":{"slug":"app","links":{"self":[{"href":"https://bitbucket.example.com/projects/ACME/repos/app/browse"}]}}}}}`,
+			want: &Event{
+				Provider: ProviderBitbucketServer, Type: EventTypePullRequest, Repository: "https://bitbucket.example.com/projects/ACME/repos/app",
+				Ref: "refs/heads/feature", SHA: "999", PullRequestNumber: 2, BaseBranch: "main", Action: ActionClosed, Sender: "dave",
+			},
+		},
+		{
+			name:     "bitbucket server unsupported",
+			provider: ProviderBitbucketServer,
+			header:   http.Header{"X-Event-Key": {"repo:comment:added"}},
+			payload:  `{}`,
+			wantErr:  ErrUnsupportedEvent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.provider, tt.header, []byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	payload := []byte(`{"ref":"refs/heads/main","after":"222"}`)
+	secret := []byte("s3cr3t")
+	req := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(payload))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hmacSHA256(payload, secret))
+
+	event, err := ParseRequest(req, ProviderGitHub, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != EventTypePush || event.SHA != "222" {
+		t.Errorf("ParseRequest() = %+v", event)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(payload))
+	req.Header.Set("X-GitHub-Event", "push")
+	if _, err := ParseRequest(req, ProviderGitHub, secret); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("ParseRequest() error = %v, want ErrInvalidSignature", err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(payload))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hmacSHA256(payload, nil))
+	if _, err := ParseRequest(req, ProviderGitHub, nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("ParseRequest() with an empty secret error = %v, want ErrInvalidSignature", err)
+	}
+}
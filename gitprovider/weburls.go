@@ -0,0 +1,158 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+// WebURLs builds the web URLs of the pages of a repository, e.g. to link to a commit from a
+// notification, or to a pre-filled pull request form from a comment. The URLs are built from
+// the repository reference without calling the provider, and keep the path prefix of the
+// domain of self-hosted instances, e.g. "https://example.com/gitlab".
+type WebURLs struct {
+	provider ProviderID
+	ref      RepositoryRef
+}
+
+// NewWebURLs returns the WebURLs of the repository on the given provider.
+//
+// ErrNoProviderSupport is returned for providers other than GitHub, GitLab and Stash.
+func NewWebURLs(provider ProviderID, ref RepositoryRef) (WebURLs, error) {
+	if err := validation.ValidateTargets("RepositoryRef", ref); err != nil {
+		return WebURLs{}, err
+	}
+	switch provider {
+	case ProviderGitHub, ProviderGitLab, ProviderStash:
+		return WebURLs{provider: provider, ref: ref}, nil
+	default:
+		return WebURLs{}, fmt.Errorf("no web URL format for provider %q: %w", provider, ErrNoProviderSupport)
+	}
+}
+
+// Repository returns the URL of the repository page.
+func (u WebURLs) Repository() string {
+	if u.provider == ProviderStash {
+		return u.stashRepository() + "/browse"
+	}
+	return u.ref.String()
+}
+
+// Commit returns the URL of the commit with the given SHA.
+func (u WebURLs) Commit(sha string) string {
+	switch u.provider {
+	case ProviderGitLab:
+		return fmt.Sprintf("%s/-/commit/%s", u.ref.String(), sha)
+	case ProviderStash:
+		return fmt.Sprintf("%s/commits/%s", u.stashRepository(), sha)
+	default:
+		return fmt.Sprintf("%s/commit/%s", u.ref.String(), sha)
+	}
+}
+
+// File returns the URL of the file at path, at the given branch, tag or commit SHA. If line
+// is positive, the URL points at that line.
+func (u WebURLs) File(gitRef, path string, line int) string {
+	path = EscapePath(strings.TrimPrefix(path, "/"))
+	var fileURL, anchor string
+	switch u.provider {
+	case ProviderGitLab:
+		fileURL = fmt.Sprintf("%s/-/blob/%s/%s", u.ref.String(), EscapePath(gitRef), path)
+		anchor = fmt.Sprintf("#L%d", line)
+	case ProviderStash:
+		fileURL = fmt.Sprintf("%s/browse/%s?at=%s", u.stashRepository(), path, url.QueryEscape(gitRef))
+		anchor = fmt.Sprintf("#%d", line)
+	default:
+		fileURL = fmt.Sprintf("%s/blob/%s/%s", u.ref.String(), EscapePath(gitRef), path)
+		anchor = fmt.Sprintf("#L%d", line)
+	}
+	if line > 0 {
+		return fileURL + anchor
+	}
+	return fileURL
+}
+
+// Compare returns the URL of the comparison of head with base, i.e. the changes made in head
+// since base. On Stash, base and head are branches.
+func (u WebURLs) Compare(base, head string) string {
+	switch u.provider {
+	case ProviderGitLab:
+		return fmt.Sprintf("%s/-/compare/%s...%s", u.ref.String(), EscapePath(base), EscapePath(head))
+	case ProviderStash:
+		query := url.Values{}
+		query.Set("sourceBranch", stashBranchRef(head))
+		query.Set("targetBranch", stashBranchRef(base))
+		return fmt.Sprintf("%s/compare/diff?%s", u.stashRepository(), query.Encode())
+	default:
+		return fmt.Sprintf("%s/compare/%s...%s", u.ref.String(), EscapePath(base), EscapePath(head))
+	}
+}
+
+// NewPullRequest returns the URL of the form creating a pull request merging the head branch
+// into the base branch, pre-filled with the title and description if they're set. Stash
+// doesn't pre-fill the title and description.
+func (u WebURLs) NewPullRequest(base, head, title, description string) string {
+	query := url.Values{}
+	switch u.provider {
+	case ProviderGitLab:
+		query.Set("merge_request[source_branch]", head)
+		query.Set("merge_request[target_branch]", base)
+		setIfNotEmpty(query, "merge_request[title]", title)
+		setIfNotEmpty(query, "merge_request[description]", description)
+		return fmt.Sprintf("%s/-/merge_requests/new?%s", u.ref.String(), query.Encode())
+	case ProviderStash:
+		query.Set("sourceBranch", stashBranchRef(head))
+		query.Set("targetBranch", stashBranchRef(base))
+		// The create parameter has no value
+		return fmt.Sprintf("%s/pull-requests?create&%s", u.stashRepository(), query.Encode())
+	default:
+		query.Set("expand", "1")
+		setIfNotEmpty(query, "title", title)
+		setIfNotEmpty(query, "body", description)
+		return fmt.Sprintf("%s?%s", u.Compare(base, head), query.Encode())
+	}
+}
+
+// stashRepository returns the base URL of the pages of a Stash repository, which is in a
+// project, or in the personal project of a user.
+func (u WebURLs) stashRepository() string {
+	domain := strings.TrimSuffix(GetDomainURL(u.ref.GetDomain()), "/")
+	owner := "projects"
+	if u.ref.GetType() == IdentityTypeUser {
+		owner = "users"
+	}
+	return fmt.Sprintf("%s/%s/%s/repos/%s", domain, owner, EscapePath(u.ref.GetIdentity()), EscapePath(u.ref.GetRepository()))
+}
+
+// stashBranchRef returns the fully-qualified ref of a branch, as used in Stash URLs.
+func stashBranchRef(branch string) string {
+	if strings.HasPrefix(branch, "refs/") {
+		return branch
+	}
+	return "refs/heads/" + branch
+}
+
+// setIfNotEmpty sets the query parameter if the value isn't empty.
+func setIfNotEmpty(query url.Values, key, value string) {
+	if len(value) != 0 {
+		query.Set(key, value)
+	}
+}
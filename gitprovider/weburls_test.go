@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitprovider
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWebURLs(t *testing.T) {
+	orgRepo := OrgRepositoryRef{
+		OrganizationRef: OrganizationRef{Domain: "https://example.com/gitlab", Organization: "group", SubOrganizations: []string{"sub"}},
+		RepositoryName:  "repo",
+	}
+	userRepo := UserRepositoryRef{UserRef: UserRef{Domain: "stash.example.com:7990", UserLogin: "alice"}, RepositoryName: "repo"}
+
+	tests := []struct {
+		name     string
+		provider ProviderID
+		ref      RepositoryRef
+		build    func(WebURLs) string
+		want     string
+	}{
+		{
+			name:     "github commit",
+			provider: ProviderGitHub,
+			ref:      OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "fluxcd"}, RepositoryName: "flux2"},
+			build:    func(u WebURLs) string { return u.Commit("abc123") },
+			want:     "https://github.com/fluxcd/flux2/commit/abc123",
+		},
+		{
+			name:     "github file at line",
+			provider: ProviderGitHub,
+			ref:      OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "fluxcd"}, RepositoryName: "flux2"},
+			build:    func(u WebURLs) string { return u.File("main", "/cmd/flux/main.go", 42) },
+			want:     "https://github.com/fluxcd/flux2/blob/main/cmd/flux/main.go#L42",
+		},
+		{
+			name:     "github new pull request",
+			provider: ProviderGitHub,
+			ref:      OrgRepositoryRef{OrganizationRef: OrganizationRef{Domain: "github.com", Organization: "fluxcd"}, RepositoryName: "flux2"},
+			build:    func(u WebURLs) string { return u.NewPullRequest("main", "feature/x", "Add x", "") },
+			want:     "https://github.com/fluxcd/flux2/compare/main...feature/x?expand=1&title=Add+x",
+		},
+		{
+			name:     "gitlab commit with a path prefix",
+			provider: ProviderGitLab,
+			ref:      orgRepo,
+			build:    func(u WebURLs) string { return u.Commit("abc123") },
+			want:     "https://example.com/gitlab/group/sub/repo/-/commit/abc123",
+		},
+		{
+			name:     "gitlab file without line",
+			provider: ProviderGitLab,
+			ref:      orgRepo,
+			build:    func(u WebURLs) string { return u.File("v1.0.0", "README.md", 0) },
+			want:     "https://example.com/gitlab/group/sub/repo/-/blob/v1.0.0/README.md",
+		},
+		{
+			name:     "gitlab compare",
+			provider: ProviderGitLab,
+			ref:      orgRepo,
+			build:    func(u WebURLs) string { return u.Compare("main", "dev") },
+			want:     "https://example.com/gitlab/group/sub/repo/-/compare/main...dev",
+		},
+		{
+			name:     "gitlab new merge request",
+			provider: ProviderGitLab,
+			ref:      orgRepo,
+			build:    func(u WebURLs) string { return u.NewPullRequest("main", "dev", "Release", "Notes") },
+			want:     "https://example.com/gitlab/group/sub/repo/-/merge_requests/new?merge_request%5Bdescription%5D=Notes&merge_request%5Bsource_branch%5D=dev&merge_request%5Btarget_branch%5D=main&merge_request%5Btitle%5D=Release",
+		},
+		{
+			name:     "stash repository of a user",
+			provider: ProviderStash,
+			ref:      userRepo,
+			build:    func(u WebURLs) string { return u.Repository() },
+			want:     "https://stash.example.com:7990/users/alice/repos/repo/browse",
+		},
+		{
+			name:     "stash file at line",
+			provider: ProviderStash,
+			ref:      userRepo,
+			build:    func(u WebURLs) string { return u.File("main", "docs/index.md", 3) },
+			want:     "https://stash.example.com:7990/users/alice/repos/repo/browse/docs/index.md?at=main#3",
+		},
+		{
+			name:     "stash new pull request",
+			provider: ProviderStash,
+			ref:      userRepo,
+			build:    func(u WebURLs) string { return u.NewPullRequest("main", "dev", "ignored", "") },
+			want:     "https://stash.example.com:7990/users/alice/repos/repo/pull-requests?create&sourceBranch=refs%2Fheads%2Fdev&targetBranch=refs%2Fheads%2Fmain",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := NewWebURLs(tt.provider, tt.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.build(u); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewWebURLs(ProviderBitbucketCloud, userRepo); !errors.Is(err, ErrNoProviderSupport) {
+		t.Errorf("NewWebURLs() error = %v, want ErrNoProviderSupport", err)
+	}
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gittestutils contains shared scaffolding for end-to-end tests against a live Git provider.
+// It creates uniquely named test repositories, tracks them, and cleans them up with retries.
+// It depends only on the gitprovider interfaces, so it can be used by out-of-tree providers
+// and by downstream projects' integration tests alike.
+package gittestutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// Fixture holds the state of an end-to-end test run against a single Git provider.
+// All repositories created through the Fixture are tracked, and deleted by Cleanup.
+type Fixture struct {
+	// Client is the provider client under test.
+	// +required
+	Client gitprovider.Client
+	// Organization is the organization test repositories are created in.
+	// +optional
+	Organization gitprovider.OrganizationRef
+	// User is the user test repositories are created for.
+	// +optional
+	User gitprovider.UserRef
+	// Prefix is prepended to the names of all generated resources, so that leftovers of
+	// previous runs can be found and deleted.
+	// +required
+	Prefix string
+	// Backoff controls how operations against the provider are retried.
+	// Default: DefaultBackoff.
+	// +optional
+	Backoff *Backoff
+	// Log receives progress messages. Default: os.Stderr.
+	// +optional
+	Log io.Writer
+
+	mu       sync.Mutex
+	orgRepos []gitprovider.OrgRepositoryRef
+	usrRepos []gitprovider.UserRepositoryRef
+}
+
+// NewFixture returns a Fixture for the given client, organization and user, generating names with the given prefix.
+func NewFixture(c gitprovider.Client, org gitprovider.OrganizationRef, user gitprovider.UserRef, prefix string) *Fixture {
+	return &Fixture{
+		Client:       c,
+		Organization: org,
+		User:         user,
+		Prefix:       prefix,
+	}
+}
+
+// Name returns a unique resource name consisting of the Fixture prefix, the given kind (e.g. "repo"),
+// and a random suffix.
+func (f *Fixture) Name(kind string) string {
+	return UniqueName(f.Prefix + "-" + kind)
+}
+
+// CreateOrgRepository creates a repository with the given name in the Fixture organization, and waits until
+// it can be read back. The repository is deleted by Cleanup.
+func (f *Fixture) CreateOrgRepository(ctx context.Context, name string, info gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.OrgRepository, error) {
+	org, err := f.organization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: org, RepositoryName: name}
+
+	f.logf("Creating org repository %s\n", ref.String())
+	if _, err := f.Client.OrgRepositories().Create(ctx, ref, info, opts...); err != nil {
+		return nil, fmt.Errorf("failed to create org repository %q: %w", ref.String(), err)
+	}
+	f.mu.Lock()
+	f.orgRepos = append(f.orgRepos, ref)
+	f.mu.Unlock()
+
+	var repo gitprovider.OrgRepository
+	err = Retry(ctx, f.backoff(), func() error {
+		repo, err = f.Client.OrgRepositories().Get(ctx, ref)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("org repository %q did not become available: %w", ref.String(), err)
+	}
+	return repo, nil
+}
+
+// CreateUserRepository creates a repository with the given name for the Fixture user, and waits until
+// it can be read back. The repository is deleted by Cleanup.
+func (f *Fixture) CreateUserRepository(ctx context.Context, name string, info gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.UserRepository, error) {
+	ref := gitprovider.UserRepositoryRef{UserRef: f.User, RepositoryName: name}
+
+	f.logf("Creating user repository %s\n", ref.String())
+	if _, err := f.Client.UserRepositories().Create(ctx, ref, info, opts...); err != nil {
+		return nil, fmt.Errorf("failed to create user repository %q: %w", ref.String(), err)
+	}
+	f.mu.Lock()
+	f.usrRepos = append(f.usrRepos, ref)
+	f.mu.Unlock()
+
+	var repo gitprovider.UserRepository
+	err := Retry(ctx, f.backoff(), func() (err error) {
+		repo, err = f.Client.UserRepositories().Get(ctx, ref)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("user repository %q did not become available: %w", ref.String(), err)
+	}
+	return repo, nil
+}
+
+// Cleanup deletes all repositories created through the Fixture. Repositories that are already gone
+// are ignored. All deletions are attempted, and the failed ones are returned as a *gitprovider.AggregateError.
+func (f *Fixture) Cleanup(ctx context.Context) error {
+	f.mu.Lock()
+	orgRepos, usrRepos := f.orgRepos, f.usrRepos
+	f.orgRepos, f.usrRepos = nil, nil
+	f.mu.Unlock()
+
+	errs := &gitprovider.AggregateError{}
+	for _, ref := range orgRepos {
+		ref := ref
+		errs.Add(ref.String(), f.deleteRepository(ctx, ref.String(), func() (gitprovider.Deletable, error) {
+			return f.Client.OrgRepositories().Get(ctx, ref)
+		}))
+	}
+	for _, ref := range usrRepos {
+		ref := ref
+		errs.Add(ref.String(), f.deleteRepository(ctx, ref.String(), func() (gitprovider.Deletable, error) {
+			return f.Client.UserRepositories().Get(ctx, ref)
+		}))
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		return fmt.Errorf("failed to clean up: %w", err)
+	}
+	return nil
+}
+
+// CleanupOrgRepositories deletes all repositories in the Fixture organization whose name starts with prefix,
+// including leftovers of previous test runs.
+func (f *Fixture) CleanupOrgRepositories(ctx context.Context, prefix string) error {
+	f.logf("Deleting repos starting with %s in org: %s\n", prefix, f.Organization.String())
+	org, err := f.organization(ctx)
+	if err != nil {
+		return err
+	}
+	var repos []gitprovider.OrgRepository
+	err = Retry(ctx, f.backoff(), func() (err error) {
+		repos, err = f.Client.OrgRepositories().List(ctx, org)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list org repositories: %w", err)
+	}
+
+	errs := &gitprovider.AggregateError{}
+	for _, repo := range repos {
+		repo := repo
+		name := repo.Repository().GetRepository()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		errs.Add(repo.Repository().String(), f.deleteRepository(ctx, repo.Repository().String(), func() (gitprovider.Deletable, error) {
+			return repo, nil
+		}))
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		return fmt.Errorf("failed to clean up: %w", err)
+	}
+	return nil
+}
+
+// CleanupUserRepositories deletes all repositories of the Fixture user whose name starts with prefix,
+// including leftovers of previous test runs.
+func (f *Fixture) CleanupUserRepositories(ctx context.Context, prefix string) error {
+	f.logf("Deleting repos starting with %s for user: %s\n", prefix, f.User.String())
+	var repos []gitprovider.UserRepository
+	err := Retry(ctx, f.backoff(), func() (err error) {
+		repos, err = f.Client.UserRepositories().List(ctx, f.User)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list user repositories: %w", err)
+	}
+
+	errs := &gitprovider.AggregateError{}
+	for _, repo := range repos {
+		repo := repo
+		name := repo.Repository().GetRepository()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		errs.Add(repo.Repository().String(), f.deleteRepository(ctx, repo.Repository().String(), func() (gitprovider.Deletable, error) {
+			return repo, nil
+		}))
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		return fmt.Errorf("failed to clean up: %w", err)
+	}
+	return nil
+}
+
+// deleteRepository deletes the repository returned by get with retries, treating ErrNotFound as success.
+// name is only used for logging, the caller records the error for it.
+func (f *Fixture) deleteRepository(ctx context.Context, name string, get func() (gitprovider.Deletable, error)) error {
+	f.logf("Deleting repository %s\n", name)
+	err := Retry(ctx, f.backoff(), func() error {
+		repo, err := get()
+		if err == nil {
+			err = repo.Delete(ctx)
+		}
+		if errors.Is(err, gitprovider.ErrNotFound) {
+			return nil
+		}
+		return err
+	})
+	return err
+}
+
+// organization resolves the Fixture organization through the provider, as some providers
+// (e.g. Stash) need server-side data in the OrganizationRef.
+func (f *Fixture) organization(ctx context.Context) (gitprovider.OrganizationRef, error) {
+	var org gitprovider.Organization
+	err := Retry(ctx, f.backoff(), func() (err error) {
+		org, err = f.Client.Organizations().Get(ctx, f.Organization)
+		return err
+	})
+	if err != nil {
+		return gitprovider.OrganizationRef{}, fmt.Errorf("failed to get organization %q: %w", f.Organization.String(), err)
+	}
+	return org.Organization(), nil
+}
+
+func (f *Fixture) backoff() Backoff {
+	if f.Backoff == nil {
+		return DefaultBackoff
+	}
+	return *f.Backoff
+}
+
+func (f *Fixture) logf(format string, args ...interface{}) {
+	w := f.Log
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
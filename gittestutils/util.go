@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gittestutils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// Backoff configures how Retry repeats a failing operation.
+type Backoff struct {
+	// Steps is the maximum number of attempts.
+	Steps int
+	// Interval is the wait between the first two attempts.
+	Interval time.Duration
+	// Factor multiplies the interval after every attempt. Values below 1 keep the interval constant.
+	Factor float64
+	// Clock is used for waiting between attempts. Default: gitprovider.RealClock.
+	Clock gitprovider.Clock
+}
+
+// DefaultBackoff retries for up to about a minute, which covers the eventual consistency
+// of the hosted Git providers.
+//
+//nolint:gochecknoglobals
+var DefaultBackoff = Backoff{
+	Steps:    8,
+	Interval: time.Second,
+	Factor:   1.5,
+}
+
+// Retry calls fn until it succeeds, the backoff steps are exhausted, or ctx is done.
+// The last error of fn is returned. All errors are retried, including gitprovider.ErrNotFound,
+// as the providers are eventually consistent; fn should return nil to stop early.
+func Retry(ctx context.Context, b Backoff, fn func() error) error {
+	clock := gitprovider.ClockOrDefault(b.Clock)
+	steps := b.Steps
+	if steps < 1 {
+		steps = 1
+	}
+	interval := b.Interval
+
+	var err error
+	for i := 0; i < steps; i++ {
+		if i > 0 {
+			if sleepErr := clock.Sleep(ctx, interval); sleepErr != nil {
+				return err
+			}
+			if b.Factor > 1 {
+				interval = time.Duration(float64(interval) * b.Factor)
+			}
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+	}
+	return err
+}
+
+// UniqueName returns prefix followed by a dash and a random lower-case hex suffix,
+// which is valid as a repository, branch or team name on all providers.
+func UniqueName(prefix string) string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return prefix + "-" + hex.EncodeToString(b)
+}
+
+// FindOrgRepository returns the repository with the given name, or nil.
+func FindOrgRepository(repos []gitprovider.OrgRepository, name string) gitprovider.OrgRepository {
+	if name == "" {
+		return nil
+	}
+	for _, repo := range repos {
+		if repo.Repository().GetRepository() == name {
+			return repo
+		}
+	}
+	return nil
+}
+
+// FindUserRepository returns the repository with the given name, or nil.
+func FindUserRepository(repos []gitprovider.UserRepository, name string) gitprovider.UserRepository {
+	if name == "" {
+		return nil
+	}
+	for _, repo := range repos {
+		if repo.Repository().GetRepository() == name {
+			return repo
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gittestutils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/gitprovider/testutils"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "succeeds immediately",
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeds after retries",
+			failures:  2,
+			wantCalls: 3,
+		},
+		{
+			name:      "steps exhausted",
+			failures:  10,
+			wantCalls: 4,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := testutils.NewFakeClock(time.Unix(0, 0))
+			b := Backoff{Steps: 4, Interval: time.Second, Factor: 2, Clock: clock}
+
+			calls := 0
+			done := make(chan error)
+			go func() {
+				done <- Retry(context.Background(), b, func() error {
+					calls++
+					if calls <= tt.failures {
+						return gitprovider.ErrNotFound
+					}
+					return nil
+				})
+			}()
+
+			var err error
+			for waiting := true; waiting; {
+				select {
+				case err = <-done:
+					waiting = false
+				default:
+					if clock.Sleepers() > 0 {
+						clock.Advance(time.Minute)
+					}
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Retry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, gitprovider.ErrNotFound) {
+				t.Errorf("Retry() error = %v, want the last error of fn", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Retry() called fn %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestUniqueName(t *testing.T) {
+	valid := regexp.MustCompile(`^test-repo-[0-9a-f]{8}$`)
+	a, b := UniqueName("test-repo"), UniqueName("test-repo")
+	if !valid.MatchString(a) {
+		t.Errorf("UniqueName() = %q, doesn't match %s", a, valid)
+	}
+	if a == b {
+		t.Errorf("UniqueName() returned %q twice", a)
+	}
+}
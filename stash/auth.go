@@ -24,8 +24,23 @@ import (
 	"github.com/go-logr/logr"
 )
 
+func init() {
+	gitprovider.RegisterClientFactory(ProviderID, func(baseURL *url.URL, username, token string, optFns ...gitprovider.ClientOption) (gitprovider.Client, error) {
+		// Bitbucket Server has no public instance, NewStashClient requires a domain
+		if baseURL != nil {
+			optFns = append(optFns, gitprovider.WithDomain(baseURL.String()))
+		}
+		c, err := NewStashClient(username, token, optFns...)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+}
+
 // NewStashClient creates a new Client instance for Stash API endpoints.
 // The client accepts a username+token as an argument, which is used to authenticate.
+// The token may be empty if gitprovider.WithTokenSource is passed, the tokens are then refreshed when needed.
 // The host name is used to construct the base URL for the Stash API.
 // Variadic parameters gitprovider.ClientOption are used to pass additional options to the gitprovider.Client.
 func NewStashClient(username, token string, optFns ...gitprovider.ClientOption) (*ProviderClient, error) {
@@ -58,12 +73,22 @@ func NewStashClient(username, token string, optFns ...gitprovider.ClientOption)
 		return nil, err
 	}
 
-	var stashClient *Client
+	clientOpts := []ClientOptionsFunc{WithAuth(username, token)}
+	if token == "" && opts.TokenSource != nil {
+		// The API requests are authenticated by the transport chain
+		clientOpts = []ClientOptionsFunc{WithTokenSource(username, opts.TokenSource)}
+	}
 	if len(opts.CABundle) != 0 {
-		stashClient, err = NewClient(client, host, nil, logger, WithAuth(username, token), WithCABundle(opts.CABundle))
-	} else {
-		stashClient, err = NewClient(client, host, nil, logger, WithAuth(username, token))
+		clientOpts = append(clientOpts, WithCABundle(opts.CABundle))
+	}
+	if opts.Clock != nil {
+		clientOpts = append(clientOpts, WithClock(opts.Clock))
 	}
+	if opts.SchemaDriftHook != nil {
+		clientOpts = append(clientOpts, WithSchemaDriftHook(opts.SchemaDriftHook))
+	}
+
+	stashClient, err := NewClient(client, host, nil, logger, clientOpts...)
 
 	if err != nil {
 		return nil, err
@@ -75,5 +100,16 @@ func NewStashClient(username, token string, optFns ...gitprovider.ClientOption)
 		destructiveActions = *opts.EnableDestructiveAPICalls
 	}
 
-	return newClient(stashClient, host, token, destructiveActions, logger), nil
+	c := newClient(stashClient, host, token, destructiveActions, logger)
+	// In dry-run mode, Reconcile calls only compute the changes they would apply
+	c.dryRun = opts.DryRun != nil && *opts.DryRun
+	c.optimisticConcurrency = opts.OptimisticConcurrency != nil && *opts.OptimisticConcurrency
+	c.repoDefaults = opts.OrgRepositoryDefaults
+	if opts.Concurrency != nil {
+		c.concurrency = *opts.Concurrency
+	}
+	if opts.WaitAfterCreate != nil {
+		c.waitAfterCreate = *opts.WaitAfterCreate
+	}
+	return c, nil
 }
@@ -153,3 +153,34 @@ func TestDefaultBranch(t *testing.T) {
 		t.Errorf("Branches.Default returned branch:\n%s, want:\n %s", b.ID, d.ID)
 	}
 }
+
+func TestDeleteBranch(t *testing.T) {
+	mux, client := setup(t)
+
+	path := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s", branchUtilsURIprefix, projectsURI, RepositoriesURI, branchesURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected method %s, want %s", r.Method, http.MethodDelete)
+		}
+		b := struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			t.Fatalf("unable to decode request body: %v", err)
+		}
+		if b.Name != "refs/heads/feature" {
+			http.Error(w, "The specified branch does not exist", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ctx := context.Background()
+	if err := client.Branches.Delete(ctx, "prj1", "repo1", "refs/heads/feature"); err != nil {
+		t.Fatalf("Branches.Delete returned error: %v", err)
+	}
+
+	if err := client.Branches.Delete(ctx, "prj1", "repo1", "refs/heads/unknown"); err != ErrNotFound {
+		t.Fatalf("Branches.Delete returned error: %v, want %v", err, ErrNotFound)
+	}
+}
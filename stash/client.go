@@ -29,9 +29,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-logr/logr"
 	"github.com/hashicorp/go-cleanhttp"
 	retryablehttp "github.com/hashicorp/go-retryablehttp"
+	"golang.org/x/oauth2"
 	"golang.org/x/time/rate"
 )
 
@@ -100,8 +103,14 @@ type Client struct {
 	username string
 	// Token used to make authenticated API calls.
 	token string
+	// tokenSource provides the token for git operations when set with WithTokenSource.
+	tokenSource oauth2.TokenSource
 	// caBundle is the CA bundle used to authenticate the server.
 	caBundle []byte
+	// clock is the source of time used for retry backoff and commit dates.
+	clock gitprovider.Clock
+	// schemaDriftHook is called for the mismatched fields of list responses.
+	schemaDriftHook gitprovider.SchemaDriftFunc
 
 	// Services are used to communicate with the different stash endpoints.
 	Users        Users
@@ -113,6 +122,7 @@ type Client struct {
 	Commits      Commits
 	PullRequests PullRequests
 	DeployKeys   DeployKeys
+	Restrictions Restrictions
 }
 
 // RateLimiter is the interface that wraps the basic Wait method.
@@ -133,6 +143,31 @@ func WithCABundle(caBundle []byte) ClientOptionsFunc {
 	}
 }
 
+// WithClock is used to setup the clock used for retry backoff and commit dates.
+func WithClock(clock gitprovider.Clock) ClientOptionsFunc {
+	return func(c *Client) error {
+		if clock == nil {
+			return errors.New("clock cannot be nil")
+		}
+
+		c.clock = clock
+		return nil
+	}
+}
+
+// WithSchemaDriftHook is used to setup the function called for the fields of list responses whose
+// type doesn't match the expected one. By default, they are logged.
+func WithSchemaDriftHook(hook gitprovider.SchemaDriftFunc) ClientOptionsFunc {
+	return func(c *Client) error {
+		if hook == nil {
+			return errors.New("schema drift hook cannot be nil")
+		}
+
+		c.schemaDriftHook = hook
+		return nil
+	}
+}
+
 // WithAuth is used to setup the client authentication.
 func WithAuth(username string, token string) ClientOptionsFunc {
 	return func(c *Client) error {
@@ -150,6 +185,37 @@ func WithAuth(username string, token string) ClientOptionsFunc {
 	}
 }
 
+// WithTokenSource is used to setup the client authentication with refreshable tokens.
+// The API requests are expected to be authenticated by the http.Client transport,
+// the token source is used for the git clone, fetch and push operations.
+func WithTokenSource(username string, ts oauth2.TokenSource) ClientOptionsFunc {
+	return func(c *Client) error {
+		if username == "" {
+			return errors.New("user name is required")
+		}
+
+		if ts == nil {
+			return errors.New("token source is required")
+		}
+
+		c.username = username
+		c.tokenSource = ts
+		return nil
+	}
+}
+
+// basicAuth returns the credentials for git operations, using the current token of the token source if set.
+func (c *Client) basicAuth() (*githttp.BasicAuth, error) {
+	if c.tokenSource == nil {
+		return &githttp.BasicAuth{Username: c.username, Password: c.token}, nil
+	}
+	tok, err := c.tokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return &githttp.BasicAuth{Username: c.username, Password: tok.AccessToken}, nil
+}
+
 // NewClient returns a new Client given a host name an optional http.Client, a logger, http.Header and ClientOptionsFunc.
 // If the http.Client is nil, a default http.Client is used.
 // If the http.Header is nil, a default http.Header is used.
@@ -185,6 +251,7 @@ func NewClient(httpClient *http.Client, host string, header *http.Header, logger
 
 	c := &Client{
 		Logger: logger,
+		clock:  gitprovider.RealClock{},
 	}
 
 	c.Client = &retryablehttp.Client{
@@ -195,6 +262,11 @@ func NewClient(httpClient *http.Client, host string, header *http.Header, logger
 		RetryWaitMin: defaultRetryWaitMin,
 		RetryWaitMax: defaultRetryWaitMax,
 		RetryMax:     defaultRetries,
+		RequestLogHook: func(_ retryablehttp.Logger, req *http.Request, attempt int) {
+			if attempt > 0 {
+				c.Logger.V(1).Info("retrying request", "method", req.Method, "path", req.URL.Path, "attempt", attempt)
+			}
+		},
 	}
 
 	for _, opt := range opts {
@@ -229,6 +301,7 @@ func NewClient(httpClient *http.Client, host string, header *http.Header, logger
 	c.Commits = &CommitsService{Client: c}
 	c.PullRequests = &PullRequestsService{Client: c}
 	c.DeployKeys = &DeployKeysService{Client: c}
+	c.Restrictions = &RestrictionsService{Client: c}
 
 	return c, nil
 }
@@ -284,17 +357,29 @@ func (c *Client) retryHTTPCheck(ctx context.Context, resp *http.Response, err er
 
 // retryHTTPBackoff provides a generic callback for Client.Backoff which
 // will pass through all calls based on the status code of the response.
+// The wait is done through the client clock, using the context of the request if a response is
+// available, and no extra wait is left to retryablehttp.
 func (c *Client) retryHTTPBackoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
-	// Use the rate limit backoff function when we are rate limited.
+	var wait time.Duration
 	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
-		return rateLimitBackoff(min, max, resp)
+		// Use the rate limit backoff function when we are rate limited.
+		wait = rateLimitBackoff(c.clock.Now(), min, max, resp)
+	} else {
+		// Set custom duration when we experience a service interruption.
+		min = 700 * time.Millisecond
+		max = 900 * time.Millisecond
+		wait = retryablehttp.LinearJitterBackoff(min, max, attemptNum, resp)
 	}
 
-	// Set custom duration when we experience a service interruption.
-	min = 700 * time.Millisecond
-	max = 900 * time.Millisecond
+	// Without a response, e.g. on connection errors, the request context isn't available here
+	ctx := context.Background()
+	if resp != nil && resp.Request != nil {
+		ctx = resp.Request.Context()
+	}
 
-	return retryablehttp.LinearJitterBackoff(min, max, attemptNum, resp)
+	// The context error is reported by retryablehttp before the next attempt
+	_ = c.clock.Sleep(ctx, wait)
+	return 0
 }
 
 // rateLimitBackoff provides a callback for Client.Backoff which will use the
@@ -303,10 +388,10 @@ func (c *Client) retryHTTPBackoff(min, max time.Duration, attemptNum int, resp *
 //
 // min and max are mainly used for bounding the jitter that will be added to
 // the reset time retrieved from the headers. But if the final wait time is
-// less then min, min will be used instead.
-func rateLimitBackoff(min, max time.Duration, resp *http.Response) time.Duration {
+// less then min, min will be used instead. The reset time is relative to now.
+func rateLimitBackoff(now time.Time, min, max time.Duration, resp *http.Response) time.Duration {
 	// rnd is used to generate pseudo-random numbers.
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rnd := rand.New(rand.NewSource(now.UnixNano()))
 
 	// First create some jitter bounded by the min and max durations.
 	jitter := time.Duration(rnd.Float64() * float64(max-min))
@@ -315,7 +400,7 @@ func rateLimitBackoff(min, max time.Duration, resp *http.Response) time.Duration
 		if v := resp.Header.Get(headerRateReset); v != "" {
 			if reset, _ := strconv.ParseInt(v, 10, 64); reset > 0 {
 				// Only update min if the given time to wait is longer.
-				if wait := time.Until(time.Unix(reset, 0)); wait > min {
+				if wait := time.Unix(reset, 0).Sub(now); wait > min {
 					min = wait
 				}
 			}
@@ -469,24 +554,7 @@ func (c *Client) NewRequest(ctx context.Context, method string, path string, opt
 // obtaining a connection, sending the request, checking errors and retrying.
 // The response body is closed.
 func (c *Client) Do(request *http.Request) ([]byte, *http.Response, error) {
-	// If not yet configured, try to configure the rate limiter. Fail
-	// silently as the limiter will be disabled in case of an error.
-	c.configureLimiterOnce.Do(func() { c.configureLimiter() })
-
-	// Wait will block until the limiter can obtain a new token.
-	err := c.limiter.Wait(request.Context())
-	if err != nil {
-		return nil, nil, err
-	}
-
-	c.Logger.V(2).Info("request", "method", request.Method, "url", request.URL)
-
-	req, err := retryablehttp.FromRequest(request)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	resp, err := c.Client.Do(req)
+	resp, err := c.send(request)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -505,7 +573,64 @@ func (c *Client) Do(request *http.Request) ([]byte, *http.Response, error) {
 		return resBytes, resp, nil
 	}
 
-	return nil, resp, fmt.Errorf("request %s %s returned status code: %s, %w", request.Method, request.URL, resp.Status, ErrorUnexpectedStatusCode)
+	return nil, resp, newStatusError(request, resp, resBytes)
+}
+
+// DoStream performs a request like Do, but returns the response with its body unread, for streaming
+// large responses. The caller is responsible for closing the response body.
+// ErrNotFound is returned for a 404 Not Found response, and an error wrapping ErrorUnexpectedStatusCode
+// for any other non-2xx response.
+func (c *Client) DoStream(request *http.Request) (*http.Response, error) {
+	resp, err := c.send(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return nil, ErrNotFound
+		}
+		resBytes, _ := getRespBody(resp)
+		return nil, newStatusError(request, resp, resBytes)
+	}
+
+	return resp, nil
+}
+
+// send sends the request, waiting for the rate limiter and retrying as configured.
+func (c *Client) send(request *http.Request) (*http.Response, error) {
+	// If not yet configured, try to configure the rate limiter. Fail
+	// silently as the limiter will be disabled in case of an error.
+	c.configureLimiterOnce.Do(func() { c.configureLimiter() })
+
+	// Wait will block until the limiter can obtain a new token.
+	err := c.limiter.Wait(request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.V(2).Info("request", "method", request.Method, "url", request.URL)
+
+	req, err := retryablehttp.FromRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Client.Do(req)
+}
+
+// unmarshalList decodes the JSON list response data into v. The fields whose type doesn't match,
+// e.g. because of another server version, are left unset and reported to the schema drift hook,
+// or logged, instead of failing the whole list.
+func (c *Client) unmarshalList(data []byte, v interface{}) error {
+	hook := c.schemaDriftHook
+	if hook == nil {
+		hook = func(drift gitprovider.SchemaDrift) {
+			c.Logger.Info("unexpected field type in response", "field", drift.Field, "expected", drift.Expected, "got", drift.Got)
+		}
+	}
+	return gitprovider.UnmarshalTolerant(data, v, hook)
 }
 
 // getRespBody is used to obtain the response body as a []byte.
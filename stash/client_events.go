@@ -0,0 +1,42 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// EventsClient implements the gitprovider.EventsClient interface.
+var _ gitprovider.EventsClient = &EventsClient{}
+
+// EventsClient lists the activity events of repositories and organizations.
+// Stash has no activity API, so every method returns gitprovider.ErrNoProviderSupport.
+type EventsClient struct {
+	*clientContext
+}
+
+// ListRepository lists the events of the given repository.
+func (c *EventsClient) ListRepository(_ context.Context, _ gitprovider.RepositoryRef, _ gitprovider.EventListOptions) ([]gitprovider.Event, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// ListOrganization lists the events of the given organization.
+func (c *EventsClient) ListOrganization(_ context.Context, _ gitprovider.OrganizationRef, _ gitprovider.EventListOptions) ([]gitprovider.Event, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
@@ -101,14 +101,17 @@ func (c *TeamsClient) List(ctx context.Context) ([]gitprovider.Team, error) {
 	}
 
 	teams := make([]gitprovider.Team, len(apiObjs))
-	for i, apiObj := range apiObjs {
-		// Get detailed information about individual teams (including members).
-		// Slug is validated to be non-nil in ListGroupMembers.
-		team, err := c.Get(ctx, apiObj.Group.Name)
+	teamErrs := make([]error, len(apiObjs))
+	// Get detailed information about individual teams (including members).
+	// Slug is validated to be non-nil in ListGroupMembers.
+	// Every team is fetched, so that all failures are reported at once.
+	_ = gitprovider.RunConcurrently(c.concurrency, len(apiObjs), func(i int) error {
+		teams[i], teamErrs[i] = c.Get(ctx, apiObjs[i].Group.Name)
+		return nil
+	})
+	for i, err := range teamErrs {
 		if err != nil {
-			errs = multierror.Append(errs, fmt.Errorf("failed to get team %s: %w", apiObj.Group.Name, err))
-		} else {
-			teams[i] = team
+			errs = multierror.Append(errs, fmt.Errorf("failed to get team %s: %w", apiObjs[i].Group.Name, err))
 		}
 	}
 
@@ -57,8 +57,9 @@ func (c *OrganizationsClient) Get(ctx context.Context, ref gitprovider.Organizat
 }
 
 // List all the organizations the specific user has access to.
+// Stash projects don't have sub-organizations, so OrganizationListOptions.Depth is ignored.
 // List returns all available organizations, using multiple paginated requests if needed.
-func (c *OrganizationsClient) List(ctx context.Context) ([]gitprovider.Organization, error) {
+func (c *OrganizationsClient) List(ctx context.Context, _ ...gitprovider.OrganizationListOption) ([]gitprovider.Organization, error) {
 	// Retrieve all projects
 	apiObjs, err := c.client.Projects.All(ctx)
 	if err != nil {
@@ -93,7 +94,7 @@ func (c *OrganizationsClient) List(ctx context.Context) ([]gitprovider.Organizat
 // Children returns the immediate child-organizations for the specific OrganizationRef o.
 // The OrganizationRef may point to any existing sub-organization.
 // Children returns all available organizations, using multiple paginated requests if needed.
-func (c *OrganizationsClient) Children(_ context.Context, _ gitprovider.OrganizationRef) ([]gitprovider.Organization, error) {
+func (c *OrganizationsClient) Children(_ context.Context, _ gitprovider.OrganizationRef, _ ...gitprovider.OrganizationListOption) ([]gitprovider.Organization, error) {
 	return nil, gitprovider.ErrNoProviderSupport
 }
 
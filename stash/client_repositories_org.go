@@ -17,9 +17,13 @@ limitations under the License.
 package stash
 
 import (
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"time"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/fluxcd/go-git-providers/validation"
@@ -37,12 +41,16 @@ type OrgRepositoriesClient struct {
 }
 
 // Get returns the repository at the given path.
-// ErrNotFound is returned if the resource does not exist.
-func (c *OrgRepositoriesClient) Get(ctx context.Context, ref gitprovider.OrgRepositoryRef) (gitprovider.OrgRepository, error) {
+// ErrNotFound is returned if the resource does not exist. ErrNoProviderSupport is returned
+// for RepositoryGetOptions.WithStats, as Stash doesn't report repository statistics.
+func (c *OrgRepositoriesClient) Get(ctx context.Context, ref gitprovider.OrgRepositoryRef, opts ...gitprovider.RepositoryGetOption) (gitprovider.OrgRepository, error) {
 	// Make sure the OrgRepositoryRef is valid
 	if err := validateOrgRepositoryRef(ref, c.host); err != nil {
 		return nil, err
 	}
+	if o := gitprovider.MakeRepositoryGetOptions(opts...); o.WithStats != nil && *o.WithStats {
+		return nil, fmt.Errorf("repository statistics: %w", gitprovider.ErrNoProviderSupport)
+	}
 
 	slug := ref.Slug()
 	if slug == "" {
@@ -77,12 +85,17 @@ func (c *OrgRepositoriesClient) Get(ctx context.Context, ref gitprovider.OrgRepo
 }
 
 // List all repositories in the given organization.
+// Stash can't filter the repositories of a project, so the filters are applied to the received list.
 // List returns all available repositories, using multiple paginated requests if needed.
-func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.OrganizationRef) ([]gitprovider.OrgRepository, error) {
+func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.OrganizationRef, opts ...gitprovider.RepositoryListOption) ([]gitprovider.OrgRepository, error) {
 	// Make sure the OrganizationRef is valid
 	if err := validateOrganizationRef(ref, c.host); err != nil {
 		return nil, err
 	}
+	o, err := makeRepositoryListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
 
 	apiObjs, err := c.client.Repositories.All(ctx, ref.Key())
 	if err != nil {
@@ -103,6 +116,10 @@ func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.Organi
 	// Traverse the list, and return a list of OrgRepository objects
 	repos := make([]gitprovider.OrgRepository, 0, len(apiObjs))
 	for _, apiObj := range apiObjs {
+		// Stash doesn't return the last activity, which can't be filtered on
+		if !o.Matches(apiObj.Name, repositoryFromAPI(apiObj), time.Time{}) {
+			continue
+		}
 		repoRef := gitprovider.OrgRepositoryRef{
 			OrganizationRef: ref,
 			RepositoryName:  apiObj.Name,
@@ -114,6 +131,68 @@ func (c *OrgRepositoriesClient) List(ctx context.Context, ref gitprovider.Organi
 	return repos, nil
 }
 
+// ListIter returns an iterator over the repositories in the given organization, which fetches
+// one page at a time. The filters are applied to the received pages, like in List.
+func (c *OrgRepositoriesClient) ListIter(ctx context.Context, ref gitprovider.OrganizationRef, opts ...gitprovider.RepositoryListOption) *gitprovider.OrgRepositoryIterator {
+	o, optsErr := gitprovider.MakeRepositoryListOptions(opts...)
+	return gitprovider.NewOrgRepositoryIterator(ctx, func(ctx context.Context, start int) ([]gitprovider.OrgRepository, int, error) {
+		if optsErr != nil {
+			return nil, 0, optsErr
+		}
+		// Make sure the OrganizationRef is valid
+		if err := validateOrganizationRef(ref, c.host); err != nil {
+			return nil, 0, err
+		}
+
+		apiObjs, next, err := listRepositoriesPage(ctx, c.client, ref.Key(), start)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		repos := make([]gitprovider.OrgRepository, 0, len(apiObjs))
+		for _, apiObj := range apiObjs {
+			// Stash doesn't return the last activity, which can't be filtered on
+			if !o.Matches(apiObj.Name, repositoryFromAPI(apiObj), time.Time{}) {
+				continue
+			}
+			repoRef := gitprovider.OrgRepositoryRef{
+				OrganizationRef: ref,
+				RepositoryName:  apiObj.Name,
+			}
+			repoRef.SetSlug(apiObj.Slug)
+
+			repos = append(repos, newOrgRepository(c.clientContext, apiObj, repoRef))
+		}
+		return repos, next, nil
+	})
+}
+
+// listRepositoriesPage returns the page of repositories of the project starting at the given
+// offset, and the start of the next page, or 0 if it's the last page.
+func listRepositoriesPage(ctx context.Context, client *Client, projectKey string, start int) ([]*Repository, int, error) {
+	list, err := client.Repositories.List(ctx, projectKey, &PagingOptions{Start: int64(start), Limit: perPageLimit})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list repositories for %s: %w", projectKey, err)
+	}
+
+	apiObjs := list.GetRepositories()
+	var errs error
+	for _, apiObj := range apiObjs {
+		if err := validateRepositoryAPI(apiObj); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	if errs != nil {
+		return nil, 0, errs
+	}
+
+	if list.IsLast() {
+		return apiObjs, 0, nil
+	}
+	return apiObjs, int(list.NextPageStart), nil
+}
+
 // Create creates a repository for the given organization, with the data and options.
 // ErrAlreadyExists will be returned if the resource already exists.
 func (c *OrgRepositoriesClient) Create(ctx context.Context,
@@ -125,13 +204,20 @@ func (c *OrgRepositoriesClient) Create(ctx context.Context,
 		return nil, err
 	}
 
-	apiObj, err := createRepository(ctx, c.client, ref.Key(), ref, req, opts...)
+	// Use the organization defaults for the unset fields, and the default create options
+	defaults := c.repoDefaults.For(ref.OrganizationRef)
+	defaults.ApplyToRepositoryInfo(&req)
+
+	apiObj, err := createRepository(ctx, c.client, ref.Key(), ref, req, defaults.MergeCreateOptions(opts...)...)
 	if err != nil {
 		if errors.Is(err, ErrAlreadyExists) {
 			return nil, gitprovider.ErrAlreadyExists
 		}
 		return nil, fmt.Errorf("failed to create repository %s/%s: %w", ref.Key(), ref.Slug(), err)
 	}
+	if err := c.waitForRepository(ctx, ref.Key(), apiObj.Slug); err != nil {
+		return nil, err
+	}
 
 	ref.SetSlug(apiObj.Slug)
 
@@ -143,12 +229,23 @@ func (c *OrgRepositoriesClient) Create(ctx context.Context,
 // If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
 // If req is already the actual state, this is a no-op (actionTaken == false).
 func (c *OrgRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.OrgRepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryReconcileOption) (gitprovider.OrgRepository, bool, error) {
+	// Use the organization defaults for the unset fields, instead of the built-in defaults
+	c.repoDefaults.For(ref.OrganizationRef).ApplyToRepositoryInfo(&req)
+
 	actual, err := c.Get(ctx, ref)
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := c.Create(ctx, ref, req, toCreateOpts(opts...)...)
-			return resp, true, err
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateRepository)
+			return resp, true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
@@ -160,6 +257,26 @@ func (c *OrgRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.O
 	return actual, actionTaken, err
 }
 
+// CreateFromTemplate creates a repository for the given organization from the template repository,
+// like Create with RepositoryCreateOptions.TemplateRepository.
+func (c *OrgRepositoriesClient) CreateFromTemplate(ctx context.Context, ref gitprovider.OrgRepositoryRef, template gitprovider.RepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.OrgRepository, error) {
+	return c.Create(ctx, ref, req, append(opts, &gitprovider.RepositoryCreateOptions{TemplateRepository: template})...)
+}
+
+// DownloadArchive returns an archive of the repository contents at the given Git reference,
+// which can be a branch, tag or commit SHA. If gitRef is empty, the default branch is used.
+//
+// The caller is responsible for closing the returned reader.
+// ErrNotFound is returned if the repository or the reference does not exist.
+func (c *OrgRepositoriesClient) DownloadArchive(ctx context.Context, ref gitprovider.OrgRepositoryRef, gitRef string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	// Make sure the RepositoryRef is valid
+	if err := validateOrgRepositoryRef(ref, c.host); err != nil {
+		return nil, err
+	}
+
+	return downloadArchive(ctx, c.client, ref.Key(), ref, gitRef, format)
+}
+
 // update will apply the desired state in this object to the server.
 // ErrNotFound is returned if the resource does not exist.
 func update(ctx context.Context, c *Client, orgKey, repoSlug string, repository *Repository, branchID string) (*Repository, error) {
@@ -183,6 +300,69 @@ func update(ctx context.Context, c *Client, orgKey, repoSlug string, repository
 	return apiObj, nil
 }
 
+func downloadArchive(ctx context.Context, c *Client, orgKey string, ref gitprovider.RepositoryRef, gitRef string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	if err := gitprovider.ValidateArchiveFormat(format); err != nil {
+		return nil, fmt.Errorf("unsupported archive format %q: %w", format, gitprovider.ErrInvalidArgument)
+	}
+
+	_, slug := getStashRefs(ref)
+	if slug == "" {
+		// try with name
+		slug = ref.GetRepository()
+	}
+
+	return gitprovider.NewResumableReader(ctx, func(ctx context.Context, header http.Header) (*http.Response, error) {
+		resp, err := c.Repositories.ArchiveStream(ctx, orgKey, slug, gitRef, string(format), header)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, gitprovider.ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to download archive of repository %s/%s: %w", orgKey, slug, err)
+		}
+		return resp, nil
+	}, gitprovider.DefaultMaxDownloadResumes)
+}
+
+// templateArchive returns a tar archive of the default branch of the template repository, for the
+// initial commit of a repository created from it, see WithArchive. The archive is streamed from
+// the server, so the history, symbolic links and submodules of the template aren't copied.
+func templateArchive(ctx context.Context, c *Client, tmpl gitprovider.RepositoryRef) (io.ReadCloser, error) {
+	projectKey, repoSlug := getStashRefs(tmpl)
+	if r, ok := tmpl.(gitprovider.UserRepositoryRef); ok {
+		projectKey = addTilde(r.UserLogin)
+	}
+	if repoSlug == "" {
+		// try with name
+		repoSlug = tmpl.GetRepository()
+	}
+
+	resp, err := c.Repositories.ArchiveStream(ctx, projectKey, repoSlug, "", string(gitprovider.ArchiveFormatTarGz), nil)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("template repository %s: %w", tmpl.String(), gitprovider.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to download template repository %s: %w", tmpl.String(), err)
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to read the archive of template repository %s: %w", tmpl.String(), err)
+	}
+	return &gzipReadCloser{Reader: gz, body: resp.Body}, nil
+}
+
+// gzipReadCloser decompresses a response body, closing both when closed.
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+// Close closes the gzip reader and the body.
+func (r *gzipReadCloser) Close() error {
+	r.Reader.Close()
+	return r.body.Close()
+}
+
 func deleteRepository(ctx context.Context, c *Client, orgKey, repoSlug string) error {
 	if err := c.Repositories.Delete(ctx, orgKey, repoSlug); err != nil {
 		return fmt.Errorf("failed to delete repository: %w", err)
@@ -197,12 +377,39 @@ func createRepository(ctx context.Context, c *Client, orgKey string, ref gitprov
 	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
 		return nil, err
 	}
+	// Bitbucket Server doesn't have repository topics
+	if len(req.Topics) > 0 {
+		return nil, fmt.Errorf("repository topics: %w", gitprovider.ErrNoProviderSupport)
+	}
+	// The merge settings are part of the pull request settings, which aren't supported
+	if req.MergeMethods != nil || req.DeleteBranchOnMerge != nil {
+		return nil, fmt.Errorf("repository merge settings: %w", gitprovider.ErrNoProviderSupport)
+	}
+	if req.Archived != nil && *req.Archived {
+		return nil, fmt.Errorf("archived repositories: %w", gitprovider.ErrNoProviderSupport)
+	}
 
 	// Assemble the options struct based on the given options
 	opt, err := gitprovider.MakeRepositoryCreateOptions(opts...)
 	if err != nil {
 		return nil, err
 	}
+	// Bitbucket Server doesn't have template repositories, the files of the template are copied
+	// to the initial commit instead
+	var tmplArchive io.ReadCloser
+	if tmpl := opt.TemplateRepository; tmpl != nil {
+		if tmpl.GetDomain() != ref.GetDomain() {
+			return nil, fmt.Errorf("template repository %s must be on %s: %w", tmpl.String(), ref.GetDomain(), gitprovider.ErrInvalidArgument)
+		}
+		if tmplArchive, err = templateArchive(ctx, c, tmpl); err != nil {
+			return nil, err
+		}
+		defer tmplArchive.Close()
+	}
+	// Bitbucket Server doesn't have .gitignore templates
+	if opt.GitignoreTemplate != nil {
+		return nil, fmt.Errorf("gitignore templates: %w", gitprovider.ErrNoProviderSupport)
+	}
 
 	// Convert to the API object and apply the options
 	data := repositoryToAPI(&req, ref)
@@ -222,7 +429,7 @@ func createRepository(ctx context.Context, c *Client, orgKey string, ref gitprov
 
 	var initCommit *CreateCommit
 
-	if opt.AutoInit != nil && *(opt.AutoInit) {
+	if (opt.AutoInit != nil && *(opt.AutoInit)) || opt.TemplateRepository != nil {
 		readmeContents := fmt.Sprintf("# %s\n%s", repo.Name, repo.Description)
 		readmePath, licensePath := "README.md", "LICENSE.md"
 		files := []CommitFile{
@@ -242,15 +449,21 @@ func createRepository(ctx context.Context, c *Client, orgKey string, ref gitprov
 				})
 			}
 		}
+		message, contents := "initial commit", WithFiles(files)
+		// The template provides the initial contents instead
+		if opt.TemplateRepository != nil {
+			message = fmt.Sprintf("initial commit from template %s", opt.TemplateRepository.String())
+			contents = WithArchive(tmplArchive)
+		}
 
 		initCommit, err = NewCommit(
 			WithAuthor(&CommitAuthor{
 				Name:  user.Name,
 				Email: user.EmailAddress,
 			}),
-			WithMessage("initial commit"),
+			WithMessage(message),
 			WithURL(getRepoHTTPref(repo.Links.Clone)),
-			WithFiles(files))
+			contents)
 
 		if err != nil {
 			return nil, fmt.Errorf("failed to create initial commit: %w", err)
@@ -348,6 +561,11 @@ func (c *OrgRepositoriesClient) reconcileRepository(ctx context.Context, actual
 	if req.Equals(new) {
 		return actionTaken, nil
 	}
+	change := gitprovider.NewUpdateChange(actual.Repository(), new, req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
 	// Populate the desired state to the current-actual object
 	err := actual.Set(req)
 	if err != nil {
@@ -357,8 +575,10 @@ func (c *OrgRepositoriesClient) reconcileRepository(ctx context.Context, actual
 	projectKey, repoSlug := getStashRefs(actual.Repository())
 	// Apply the desired state by running Update
 	repo := actual.APIObject().(*Repository)
+	apiCalls := []string{apiCallUpdateRepository}
 	if *req.DefaultBranch != "" && repo.DefaultBranch != *req.DefaultBranch {
 		_, err = update(ctx, c.client, projectKey, repoSlug, repo, *req.DefaultBranch)
+		apiCalls = append(apiCalls, apiCallSetDefaultBranch)
 	} else {
 		_, err = update(ctx, c.client, projectKey, repoSlug, repo, "")
 	}
@@ -367,6 +587,7 @@ func (c *OrgRepositoriesClient) reconcileRepository(ctx context.Context, actual
 		return actionTaken, err
 	}
 
+	gitprovider.RecordChange(ctx, change, apiCalls...)
 	actionTaken = true
 	return actionTaken, nil
 }
@@ -422,3 +643,18 @@ func validateRepositoryAPI(apiObj *Repository) error {
 		}
 	})
 }
+
+// waitForRepository waits for a created repository to become visible, if WithWaitAfterCreate is set.
+func (c *clientContext) waitForRepository(ctx context.Context, orgKey, repoSlug string) error {
+	if c.waitAfterCreate == 0 {
+		return nil
+	}
+	waiter := gitprovider.NewVisibilityWaiter(gitprovider.ProviderStash, c.client.clock)
+	return waiter.WaitUntilVisible(ctx, func(ctx context.Context) error {
+		_, err := c.client.Repositories.Get(ctx, orgKey, repoSlug)
+		if errors.Is(err, ErrNotFound) {
+			return gitprovider.ErrNotFound
+		}
+		return err
+	}, c.waitAfterCreate)
+}
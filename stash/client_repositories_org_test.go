@@ -0,0 +1,155 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/go-logr/logr"
+)
+
+func TestOrgRepositories_ReconcileOrgDefaults(t *testing.T) {
+	mux, client := setup(t)
+
+	descriptions := map[string]string{"a": "managed", "b": "old"}
+	var updated []string
+	reposPath := fmt.Sprintf("%s/%s/PRJ/%s", stashURIprefix, projectsURI, RepositoriesURI)
+	mux.HandleFunc(reposPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&RepositoryList{
+			Paging: Paging{IsLastPage: true},
+			Repositories: []*Repository{
+				{Name: "a", Slug: "a", Description: descriptions["a"]},
+				{Name: "b", Slug: "b", Description: descriptions["b"]},
+			},
+		})
+	})
+	for slug := range descriptions {
+		slug := slug
+		repoPath := fmt.Sprintf("%s/%s", reposPath, slug)
+		mux.HandleFunc(repoPath, func(w http.ResponseWriter, r *http.Request) {
+			repo := &Repository{Name: slug, Slug: slug, Description: descriptions[slug]}
+			if r.Method == http.MethodPut {
+				if err := json.NewDecoder(r.Body).Decode(repo); err != nil {
+					t.Fatalf("unable to decode request body: %v", err)
+				}
+				updated = append(updated, slug)
+			}
+			json.NewEncoder(w).Encode(repo)
+		})
+		mux.HandleFunc(fmt.Sprintf("%s/%s/%s", repoPath, branchesURI, defaultBranchURI), func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(&Branch{ID: "refs/heads/main", DisplayID: "main"})
+		})
+	}
+	mux.HandleFunc(fmt.Sprintf("%s/missing", reposPath), func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "The specified repository does not exist", http.StatusNotFound)
+	})
+
+	defaults := gitprovider.RepositoryDefaults{Info: gitprovider.RepositoryInfo{Description: gitprovider.StringVar("managed")}}
+	p := newClient(client, "stash.example.com", "token", false, logr.Discard())
+	p.repoDefaults = gitprovider.OrgRepositoryDefaults{"prj": defaults}
+	ctx := context.Background()
+	orgRef := gitprovider.OrganizationRef{Domain: "stash.example.com", Organization: "prj"}
+	orgRef.SetKey("PRJ")
+
+	// The defaults are used for the unset fields of new repositories
+	p.dryRun = true
+	ref := gitprovider.OrgRepositoryRef{OrganizationRef: orgRef, RepositoryName: "missing"}
+	_, _, err := p.OrgRepositories().Reconcile(ctx, ref, gitprovider.RepositoryInfo{})
+	var dryRunErr *gitprovider.DryRunError
+	if !errors.As(err, &dryRunErr) || len(dryRunErr.Changes) != 1 {
+		t.Fatalf("Reconcile returned error %v, want a DryRunError", err)
+	}
+	if got := dryRunErr.Changes[0].Fields[0]; got.Field != "description" || got.New != "managed" {
+		t.Errorf("Reconcile would create the repository with %+v, want the default description", got)
+	}
+
+	// Only the repository which differs from the defaults is updated
+	refs, err := gitprovider.ReconcileOrgDefaults(ctx, p, orgRef, defaults)
+	if !errors.As(err, &dryRunErr) || len(dryRunErr.Changes) != 1 || len(refs) != 0 || len(updated) != 0 {
+		t.Fatalf("ReconcileOrgDefaults returned %v, %v, want one change in a DryRunError", refs, err)
+	}
+
+	p.dryRun = false
+	refs, err = gitprovider.ReconcileOrgDefaults(ctx, p, orgRef, defaults)
+	if err != nil {
+		t.Fatalf("ReconcileOrgDefaults returned error: %v", err)
+	}
+	if len(refs) != 1 || refs[0].RepositoryName != "b" || !reflect.DeepEqual(updated, []string{"b"}) {
+		t.Errorf("ReconcileOrgDefaults updated %v (%v), want b", refs, updated)
+	}
+}
+
+func TestTemplateArchive(t *testing.T) {
+	mux, client := setup(t)
+
+	reposPath := fmt.Sprintf("%s/%s/PRJ/%s", stashURIprefix, projectsURI, RepositoriesURI)
+	mux.HandleFunc(fmt.Sprintf("%s/tmpl/%s", reposPath, archiveURI), func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("format"); got != "tar.gz" {
+			t.Errorf("format = %q, want tar.gz", got)
+		}
+		gz := gzip.NewWriter(w)
+		tw := tar.NewWriter(gz)
+		tw.WriteHeader(&tar.Header{Name: "app/", Typeflag: tar.TypeDir, Mode: 0o755})
+		tw.WriteHeader(&tar.Header{Name: "app/config.yaml", Typeflag: tar.TypeReg, Mode: 0o644, Size: 12})
+		tw.Write([]byte("replicas: 2\n"))
+		tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "app/config.yaml"})
+		tw.Close()
+		gz.Close()
+	})
+	mux.HandleFunc(fmt.Sprintf("%s/missing/%s", reposPath, archiveURI), func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "The specified repository does not exist", http.StatusNotFound)
+	})
+
+	orgRef := gitprovider.OrganizationRef{Domain: "stash.example.com", Organization: "prj"}
+	orgRef.SetKey("PRJ")
+	ctx := context.Background()
+
+	archive, err := templateArchive(ctx, client, gitprovider.OrgRepositoryRef{OrganizationRef: orgRef, RepositoryName: "tmpl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	tr := tar.NewReader(archive)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	if want := []string{"app/", "app/config.yaml", "link"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("templateArchive() entries = %v, want %v", names, want)
+	}
+
+	if _, err := templateArchive(ctx, client, gitprovider.OrgRepositoryRef{OrganizationRef: orgRef, RepositoryName: "missing"}); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("templateArchive() of a missing template error = %v, want ErrNotFound", err)
+	}
+}
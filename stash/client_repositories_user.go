@@ -20,6 +20,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/fluxcd/go-git-providers/validation"
@@ -35,12 +37,16 @@ type UserRepositoriesClient struct {
 }
 
 // Get returns the repository at the given path.
-// ErrNotFound is returned if the resource does not exist.
-func (c *UserRepositoriesClient) Get(ctx context.Context, ref gitprovider.UserRepositoryRef) (gitprovider.UserRepository, error) {
+// ErrNotFound is returned if the resource does not exist. ErrNoProviderSupport is returned
+// for RepositoryGetOptions.WithStats, as Stash doesn't report repository statistics.
+func (c *UserRepositoriesClient) Get(ctx context.Context, ref gitprovider.UserRepositoryRef, opts ...gitprovider.RepositoryGetOption) (gitprovider.UserRepository, error) {
 	// Make sure the UserRepositoryRef is valid
 	if err := validateUserRepositoryRef(ref, c.host); err != nil {
 		return nil, err
 	}
+	if o := gitprovider.MakeRepositoryGetOptions(opts...); o.WithStats != nil && *o.WithStats {
+		return nil, fmt.Errorf("repository statistics: %w", gitprovider.ErrNoProviderSupport)
+	}
 
 	// Make sure the UserRef is valid
 	if err := validateUserRef(ref.UserRef, c.host); err != nil {
@@ -80,12 +86,17 @@ func (c *UserRepositoriesClient) Get(ctx context.Context, ref gitprovider.UserRe
 }
 
 // List all repositories for the given user.
+// Stash can't filter the repositories of a user, so the filters are applied to the received list.
 // List returns all available repositories, using multiple paginated requests if needed.
-func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserRef) ([]gitprovider.UserRepository, error) {
+func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserRef, opts ...gitprovider.RepositoryListOption) ([]gitprovider.UserRepository, error) {
 	// Make sure the UserRef is valid
 	if err := validateUserRef(ref, c.host); err != nil {
 		return nil, err
 	}
+	o, err := makeRepositoryListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
 
 	apiObjs, err := c.client.Repositories.All(ctx, addTilde(ref.UserLogin))
 	if err != nil {
@@ -106,6 +117,10 @@ func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserR
 	// Traverse the list, and return a list of UserRepository objects
 	repos := make([]gitprovider.UserRepository, 0, len(apiObjs))
 	for _, apiObj := range apiObjs {
+		// Stash doesn't return the last activity, which can't be filtered on
+		if !o.Matches(apiObj.Name, repositoryFromAPI(apiObj), time.Time{}) {
+			continue
+		}
 		repoRef := gitprovider.UserRepositoryRef{
 			UserRef:        ref,
 			RepositoryName: apiObj.Name,
@@ -117,6 +132,42 @@ func (c *UserRepositoriesClient) List(ctx context.Context, ref gitprovider.UserR
 	return repos, nil
 }
 
+// ListIter returns an iterator over the repositories of the given user, which fetches one page
+// at a time. The filters are applied to the received pages, like in List.
+func (c *UserRepositoriesClient) ListIter(ctx context.Context, ref gitprovider.UserRef, opts ...gitprovider.RepositoryListOption) *gitprovider.UserRepositoryIterator {
+	o, optsErr := gitprovider.MakeRepositoryListOptions(opts...)
+	return gitprovider.NewUserRepositoryIterator(ctx, func(ctx context.Context, start int) ([]gitprovider.UserRepository, int, error) {
+		if optsErr != nil {
+			return nil, 0, optsErr
+		}
+		// Make sure the UserRef is valid
+		if err := validateUserRef(ref, c.host); err != nil {
+			return nil, 0, err
+		}
+
+		apiObjs, next, err := listRepositoriesPage(ctx, c.client, addTilde(ref.UserLogin), start)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		repos := make([]gitprovider.UserRepository, 0, len(apiObjs))
+		for _, apiObj := range apiObjs {
+			// Stash doesn't return the last activity, which can't be filtered on
+			if !o.Matches(apiObj.Name, repositoryFromAPI(apiObj), time.Time{}) {
+				continue
+			}
+			repoRef := gitprovider.UserRepositoryRef{
+				UserRef:        ref,
+				RepositoryName: apiObj.Name,
+			}
+			repoRef.SetSlug(apiObj.Slug)
+
+			repos = append(repos, newUserRepository(c.clientContext, apiObj, repoRef))
+		}
+		return repos, next, nil
+	})
+}
+
 // Create creates a repository for the given organization, with the data and options
 // ErrAlreadyExists will be returned if the resource already exists.
 func (c *UserRepositoriesClient) Create(ctx context.Context,
@@ -135,6 +186,9 @@ func (c *UserRepositoriesClient) Create(ctx context.Context,
 		}
 		return nil, fmt.Errorf("failed to create repository %s/%s: %w", addTilde(ref.UserLogin), ref.RepositoryName, err)
 	}
+	if err := c.waitForRepository(ctx, addTilde(ref.UserLogin), apiObj.Slug); err != nil {
+		return nil, err
+	}
 
 	ref.SetSlug(apiObj.Slug)
 
@@ -151,8 +205,16 @@ func (c *UserRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := c.Create(ctx, ref, req, toCreateOpts(opts...)...)
-			return resp, true, err
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateRepository)
+			return resp, true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
@@ -164,6 +226,26 @@ func (c *UserRepositoriesClient) Reconcile(ctx context.Context, ref gitprovider.
 	return actual, actionTaken, err
 }
 
+// CreateFromTemplate creates a repository for the given user from the template repository,
+// like Create with RepositoryCreateOptions.TemplateRepository.
+func (c *UserRepositoriesClient) CreateFromTemplate(ctx context.Context, ref gitprovider.UserRepositoryRef, template gitprovider.RepositoryRef, req gitprovider.RepositoryInfo, opts ...gitprovider.RepositoryCreateOption) (gitprovider.UserRepository, error) {
+	return c.Create(ctx, ref, req, append(opts, &gitprovider.RepositoryCreateOptions{TemplateRepository: template})...)
+}
+
+// DownloadArchive returns an archive of the repository contents at the given Git reference,
+// which can be a branch, tag or commit SHA. If gitRef is empty, the default branch is used.
+//
+// The caller is responsible for closing the returned reader.
+// ErrNotFound is returned if the repository or the reference does not exist.
+func (c *UserRepositoriesClient) DownloadArchive(ctx context.Context, ref gitprovider.UserRepositoryRef, gitRef string, format gitprovider.ArchiveFormat) (io.ReadCloser, error) {
+	// Make sure the RepositoryRef is valid
+	if err := validateUserRepositoryRef(ref, c.host); err != nil {
+		return nil, err
+	}
+
+	return downloadArchive(ctx, c.client, addTilde(ref.UserLogin), ref, gitRef, format)
+}
+
 func (c *UserRepositoriesClient) reconcileRepository(ctx context.Context, actual gitprovider.UserRepository, req gitprovider.RepositoryInfo) (bool, error) {
 	actionTaken := false
 
@@ -172,6 +254,11 @@ func (c *UserRepositoriesClient) reconcileRepository(ctx context.Context, actual
 	if req.Equals(new) {
 		return actionTaken, nil
 	}
+	change := gitprovider.NewUpdateChange(actual.Repository(), new, req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return true, gitprovider.NewDryRunError(change)
+	}
 	// Populate the desired state to the current-actual object
 	err := actual.Set(req)
 	if err != nil {
@@ -181,8 +268,10 @@ func (c *UserRepositoriesClient) reconcileRepository(ctx context.Context, actual
 	repo := actual.APIObject().(*Repository)
 	ref := actual.Repository().(gitprovider.UserRepositoryRef)
 	// Apply the desired state by running Update
+	apiCalls := []string{apiCallUpdateRepository}
 	if *req.DefaultBranch != "" && repo.DefaultBranch != *req.DefaultBranch {
 		_, err = update(ctx, c.client, addTilde(ref.UserLogin), ref.Slug(), repo, *req.DefaultBranch)
+		apiCalls = append(apiCalls, apiCallSetDefaultBranch)
 	} else {
 		_, err = update(ctx, c.client, addTilde(ref.UserLogin), ref.Slug(), repo, "")
 	}
@@ -191,6 +280,7 @@ func (c *UserRepositoriesClient) reconcileRepository(ctx context.Context, actual
 		return actionTaken, err
 	}
 
+	gitprovider.RecordChange(ctx, change, apiCalls...)
 	actionTaken = true
 
 	return actionTaken, nil
@@ -230,3 +320,32 @@ func addTilde(userName string) string {
 	}
 	return fmt.Sprintf("~%s", userName)
 }
+
+// GetUserLogin returns the UserRef of the authenticated user, whose personal
+// project is addressed as ~{slug}.
+func (c *UserRepositoriesClient) GetUserLogin(ctx context.Context) (gitprovider.UserRef, error) {
+	if c.client.username == "" {
+		return gitprovider.UserRef{}, fmt.Errorf("no authenticated user: %w", gitprovider.ErrNoProviderSupport)
+	}
+
+	user, err := c.client.Users.Get(ctx, c.client.username)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return gitprovider.UserRef{}, gitprovider.ErrNotFound
+		}
+		return gitprovider.UserRef{}, fmt.Errorf("failed to get user %s: %w", c.client.username, err)
+	}
+
+	if err := validateUserAPI(user); err != nil {
+		return gitprovider.UserRef{}, err
+	}
+
+	login := user.Slug
+	if login == "" {
+		login = user.Name
+	}
+	return gitprovider.UserRef{
+		Domain:    c.host,
+		UserLogin: login,
+	}, nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/go-logr/logr"
+)
+
+func TestUserRepositories_GetUserLogin(t *testing.T) {
+	mux, client := setup(t)
+	client.username = "john.doe@example.com"
+
+	mux.HandleFunc(fmt.Sprintf("%s/%s/%s", stashURIprefix, usersURI, client.username), func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&User{Name: "john.doe@example.com", Slug: "john.doe_example.com"})
+	})
+	mux.HandleFunc(fmt.Sprintf("%s/%s/~john.doe_example.com/%s", stashURIprefix, projectsURI, RepositoriesURI), func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&RepositoryList{
+			Paging:       Paging{IsLastPage: true},
+			Repositories: []*Repository{{Name: "dotfiles", Slug: "dotfiles"}},
+		})
+	})
+
+	p := newClient(client, "stash.example.com", "token", false, logr.Discard())
+	ctx := context.Background()
+	userRef, err := p.UserRepositories().GetUserLogin(ctx)
+	if err != nil {
+		t.Fatalf("GetUserLogin returned error: %v", err)
+	}
+	want := gitprovider.UserRef{Domain: "stash.example.com", UserLogin: "john.doe_example.com"}
+	if userRef != want {
+		t.Errorf("GetUserLogin returned %+v, want %+v", userRef, want)
+	}
+
+	// The returned ref is usable to list the user's personal repositories
+	repos, err := p.UserRepositories().List(ctx, userRef)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(repos) != 1 || repos[0].Repository().GetRepository() != "dotfiles" {
+		t.Errorf("List returned %d repositories, want dotfiles", len(repos))
+	}
+
+	client.username = ""
+	if _, err := p.UserRepositories().GetUserLogin(ctx); !errors.Is(err, gitprovider.ErrNoProviderSupport) {
+		t.Errorf("GetUserLogin returned error %v, want %v", err, gitprovider.ErrNoProviderSupport)
+	}
+}
+
+func TestUserRepositories_ReconcileDryRun(t *testing.T) {
+	mux, client := setup(t)
+
+	repoPath := fmt.Sprintf("%s/%s/~john/%s/app", stashURIprefix, projectsURI, RepositoriesURI)
+	mux.HandleFunc(repoPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s request in dry-run mode", r.Method)
+		}
+		json.NewEncoder(w).Encode(&Repository{Name: "app", Slug: "app", Description: "old"})
+	})
+	mux.HandleFunc(fmt.Sprintf("%s/%s/%s", repoPath, branchesURI, defaultBranchURI), func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&Branch{ID: "refs/heads/main", DisplayID: "main"})
+	})
+	mux.HandleFunc(fmt.Sprintf("%s/%s/~john/%s", stashURIprefix, projectsURI, RepositoriesURI), func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request in dry-run mode", r.Method)
+	})
+
+	p := newClient(client, "stash.example.com", "token", false, logr.Discard())
+	p.dryRun = true
+	ctx := context.Background()
+	userRef := gitprovider.UserRef{Domain: "stash.example.com", UserLogin: "john"}
+
+	tests := []struct {
+		name       string
+		repoName   string
+		wantType   gitprovider.ChangeType
+		wantFields []gitprovider.FieldChange
+	}{
+		{
+			name:     "update existing repository",
+			repoName: "app",
+			wantType: gitprovider.ChangeTypeUpdate,
+			wantFields: []gitprovider.FieldChange{
+				{Field: "description", Old: "old", New: "new"},
+			},
+		},
+		{
+			name:     "create missing repository",
+			repoName: "missing",
+			wantType: gitprovider.ChangeTypeCreate,
+			wantFields: []gitprovider.FieldChange{
+				{Field: "description", New: "new"},
+				{Field: "defaultBranch", New: "main"},
+				{Field: "visibility", New: gitprovider.RepositoryVisibilityPrivate},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref := gitprovider.UserRepositoryRef{UserRef: userRef, RepositoryName: tt.repoName}
+			_, actionTaken, err := p.UserRepositories().Reconcile(ctx, ref, gitprovider.RepositoryInfo{
+				Description:   gitprovider.StringVar("new"),
+				DefaultBranch: gitprovider.StringVar("main"),
+				Visibility:    gitprovider.RepositoryVisibilityVar(gitprovider.RepositoryVisibilityPrivate),
+			})
+			if !actionTaken {
+				t.Errorf("Reconcile returned actionTaken == false, want true")
+			}
+			var dryRunErr *gitprovider.DryRunError
+			if !errors.As(err, &dryRunErr) || !errors.Is(err, gitprovider.ErrDryRun) {
+				t.Fatalf("Reconcile returned error %v, want a DryRunError", err)
+			}
+			if len(dryRunErr.Changes) != 1 {
+				t.Fatalf("Reconcile returned %d changes, want 1", len(dryRunErr.Changes))
+			}
+			change := dryRunErr.Changes[0]
+			if change.Type != tt.wantType || change.Resource != "Repository" {
+				t.Errorf("Reconcile returned change %s, want %s Repository", change, tt.wantType)
+			}
+			if !reflect.DeepEqual(change.Fields, tt.wantFields) {
+				t.Errorf("Reconcile returned fields %+v, want %+v", change.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestUserRepositories_ReconcileRecordsChanges(t *testing.T) {
+	mux, client := setup(t)
+
+	repoPath := fmt.Sprintf("%s/%s/~john/%s/app", stashURIprefix, projectsURI, RepositoriesURI)
+	mux.HandleFunc(repoPath, func(w http.ResponseWriter, r *http.Request) {
+		repo := &Repository{Name: "app", Slug: "app", Description: "old"}
+		if r.Method == http.MethodPut {
+			if err := json.NewDecoder(r.Body).Decode(repo); err != nil {
+				t.Fatalf("unable to decode request body: %v", err)
+			}
+		}
+		json.NewEncoder(w).Encode(repo)
+	})
+	mux.HandleFunc(fmt.Sprintf("%s/%s/%s", repoPath, branchesURI, defaultBranchURI), func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&Branch{ID: "refs/heads/main", DisplayID: "main"})
+	})
+
+	p := newClient(client, "stash.example.com", "token", false, logr.Discard())
+	rec := &gitprovider.ChangeRecorder{}
+	ctx := gitprovider.WithChangeRecorder(context.Background(), rec)
+	ref := gitprovider.UserRepositoryRef{
+		UserRef:        gitprovider.UserRef{Domain: "stash.example.com", UserLogin: "john"},
+		RepositoryName: "app",
+	}
+	req := gitprovider.RepositoryInfo{
+		Description:   gitprovider.StringVar("new"),
+		DefaultBranch: gitprovider.StringVar("main"),
+		Visibility:    gitprovider.RepositoryVisibilityVar(gitprovider.RepositoryVisibilityPrivate),
+	}
+
+	if _, actionTaken, err := p.UserRepositories().Reconcile(ctx, ref, req); err != nil || !actionTaken {
+		t.Fatalf("Reconcile returned actionTaken %v, error %v, want true, nil", actionTaken, err)
+	}
+
+	actions := rec.Actions()
+	if len(actions) != 1 {
+		t.Fatalf("Reconcile recorded %d actions, want 1", len(actions))
+	}
+	wantFields := []gitprovider.FieldChange{{Field: "description", Old: "old", New: "new"}}
+	if !reflect.DeepEqual(actions[0].Fields, wantFields) {
+		t.Errorf("Reconcile recorded fields %+v, want %+v", actions[0].Fields, wantFields)
+	}
+	wantCalls := []string{apiCallUpdateRepository}
+	if !reflect.DeepEqual(actions[0].APICalls, wantCalls) {
+		t.Errorf("Reconcile recorded API calls %v, want %v", actions[0].APICalls, wantCalls)
+	}
+}
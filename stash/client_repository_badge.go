@@ -0,0 +1,38 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// BadgeClient implements the gitprovider.BadgeClient interface.
+var _ gitprovider.BadgeClient = &BadgeClient{}
+
+// BadgeClient generates the URLs of the status badges for a specific repository.
+// Stash has no status badges, so URL returns gitprovider.ErrNoProviderSupport.
+type BadgeClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// URL returns the canonical URL of the badge image described by req.
+func (c *BadgeClient) URL(_ context.Context, _ gitprovider.BadgeRequest) (string, error) {
+	return "", gitprovider.ErrNoProviderSupport
+}
@@ -18,6 +18,7 @@ package stash
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -90,6 +91,117 @@ func (c *BranchClient) Create(ctx context.Context, branch, sha string) error {
 	return nil
 }
 
+// Delete deletes the branch with the given name.
+func (c *BranchClient) Delete(ctx context.Context, branch string) error {
+	projectKey, repoSlug := getStashRefs(c.ref)
+
+	// check if it is a user repository
+	// if yes, we need to add a tilde to the user login and use it as the project key
+	if r, ok := c.ref.(gitprovider.UserRepositoryRef); ok {
+		projectKey = addTilde(r.UserLogin)
+	}
+
+	err := c.client.Branches.Delete(ctx, projectKey, repoSlug, fmt.Sprintf("refs/heads/%s", branch))
+	if err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", branch, err)
+	}
+
+	return nil
+}
+
+// List lists all branches in the repository.
+func (c *BranchClient) List(ctx context.Context) ([]gitprovider.Branch, error) {
+	projectKey, repoSlug := getStashRefs(c.ref)
+
+	// check if it is a user repository
+	// if yes, we need to add a tilde to the user login and use it as the project key
+	if r, ok := c.ref.(gitprovider.UserRepositoryRef); ok {
+		projectKey = addTilde(r.UserLogin)
+	}
+
+	apiObjs, err := c.client.Branches.All(ctx, projectKey, repoSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	branches := make([]gitprovider.Branch, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		branches = append(branches, newBranch(apiObj))
+	}
+
+	return branches, nil
+}
+
+// SetDefault makes the branch with the given name the default branch of the repository.
+func (c *BranchClient) SetDefault(ctx context.Context, branch string) error {
+	projectKey, repoSlug := getStashRefs(c.ref)
+
+	// check if it is a user repository
+	// if yes, we need to add a tilde to the user login and use it as the project key
+	if r, ok := c.ref.(gitprovider.UserRepositoryRef); ok {
+		projectKey = addTilde(r.UserLogin)
+	}
+
+	err := c.client.Branches.SetDefault(ctx, projectKey, repoSlug, fmt.Sprintf("refs/heads/%s", branch))
+	if err != nil {
+		return fmt.Errorf("failed to set default branch %s: %w", branch, err)
+	}
+
+	return nil
+}
+
+// GetRules returns the rules in effect on the branch, from the ref restrictions which match it:
+// no-deletes blocks deletion, fast-forward-only blocks force pushes, pull-request-only requires
+// pull requests, and read-only, which prevents all changes, blocks force pushes and requires pull
+// requests. The restrictions of the branching model aren't included. Use Client.Restrictions,
+// through Raw(), to manage the restrictions themselves.
+func (c *BranchClient) GetRules(ctx context.Context, branch string) (gitprovider.BranchRules, error) {
+	projectKey, repoSlug := getStashRefs(c.ref)
+
+	// check if it is a user repository
+	// if yes, we need to add a tilde to the user login and use it as the project key
+	if r, ok := c.ref.(gitprovider.UserRepositoryRef); ok {
+		projectKey = addTilde(r.UserLogin)
+	}
+
+	restrictions, err := c.client.Restrictions.All(ctx, projectKey, repoSlug)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return gitprovider.BranchRules{}, gitprovider.ErrNotFound
+		}
+		return gitprovider.BranchRules{}, fmt.Errorf("failed to list restrictions: %w", err)
+	}
+	return branchRulesFromAPI(restrictions, fmt.Sprintf("refs/heads/%s", branch)), nil
+}
+
+// branchRulesFromAPI normalizes the restrictions which match the given ref.
+func branchRulesFromAPI(restrictions []*Restriction, ref string) gitprovider.BranchRules {
+	result := gitprovider.BranchRules{}
+	for _, r := range restrictions {
+		if !r.Matcher.Matches(ref) {
+			continue
+		}
+		switch r.Type {
+		case RestrictionTypeNoDeletes:
+			result.BlockDeletion = true
+		case RestrictionTypeFastForwardOnly:
+			result.BlockForcePushes = true
+		case RestrictionTypePullRequestOnly:
+			result.RequirePullRequest = true
+		case RestrictionTypeReadOnly:
+			result.BlockForcePushes = true
+			result.RequirePullRequest = true
+		}
+	}
+	return result
+}
+
+// SyncForkWithUpstream returns gitprovider.ErrNoProviderSupport, as the fork synchronization of
+// Bitbucket Server is only available to the web interface.
+func (c *BranchClient) SyncForkWithUpstream(_ context.Context, _ string) (gitprovider.ForkSyncResult, error) {
+	return gitprovider.ForkSyncResult{}, gitprovider.ErrNoProviderSupport
+}
+
 func (c *BranchClient) getDefault(ctx context.Context) (string, error) {
 	projectKey, repoSlug := getStashRefs(c.ref)
 
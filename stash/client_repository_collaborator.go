@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// CollaboratorClient implements the gitprovider.CollaboratorClient interface.
+var _ gitprovider.CollaboratorClient = &CollaboratorClient{}
+
+// CollaboratorClient operates on the users with repository level permissions for a specific repository.
+// Stash grants permissions directly, so there are never pending invitations.
+type CollaboratorClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get returns the repository level permission of the user with the given name.
+// ErrNotFound is returned if the user doesn't have a repository level permission.
+func (c *CollaboratorClient) Get(ctx context.Context, login string) (gitprovider.CollaboratorInfo, error) {
+	collaborators, err := c.List(ctx)
+	if err != nil {
+		return gitprovider.CollaboratorInfo{}, err
+	}
+	for _, collaborator := range collaborators {
+		if strings.EqualFold(collaborator.Login, login) {
+			return collaborator, nil
+		}
+	}
+	return gitprovider.CollaboratorInfo{}, fmt.Errorf("collaborator %q: %w", login, gitprovider.ErrNotFound)
+}
+
+// List lists the users with repository level permissions. Users with project level
+// permissions are not included.
+// List returns all available collaborators, using multiple paginated requests if needed.
+func (c *CollaboratorClient) List(ctx context.Context) ([]gitprovider.CollaboratorInfo, error) {
+	projectKey, repoSlug := getStashRefs(c.ref)
+	perms, err := c.client.Repositories.AllUsersPermission(ctx, projectKey, repoSlug)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, gitprovider.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to list repository user permissions: %w", err)
+	}
+
+	collaborators := make([]gitprovider.CollaboratorInfo, 0, len(perms))
+	for _, perm := range perms {
+		permission, err := getGitProviderPermission(stashPriority[perm.Permission])
+		if err != nil {
+			return nil, err
+		}
+		collaborators = append(collaborators, gitprovider.CollaboratorInfo{
+			Login:      perm.User.Name,
+			Permission: permission,
+		})
+	}
+	return collaborators, nil
+}
+
+// Add grants the user req.Login a repository level permission, or changes the existing one.
+// Triage and maintain are granted as read and write, see normalizeStashPermission.
+// The returned invitation is always nil.
+func (c *CollaboratorClient) Add(ctx context.Context, req gitprovider.CollaboratorInfo) (*gitprovider.CollaboratorInvitation, error) {
+	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
+		return nil, err
+	}
+	permission, err := getStashPermission(normalizeStashPermission(*req.Permission))
+	if err != nil {
+		return nil, err
+	}
+
+	projectKey, repoSlug := getStashRefs(c.ref)
+	err = c.client.Repositories.UpdateRepositoryUserPermission(ctx, projectKey, repoSlug, &RepositoryUserPermission{
+		User:       User{Name: req.Login},
+		Permission: permission,
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, gitprovider.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to update repository user permission: %w", err)
+	}
+	return nil, nil
+}
+
+// Remove revokes the repository level permission of the user with the given name.
+// ErrNotFound is returned if the user doesn't have a repository level permission.
+func (c *CollaboratorClient) Remove(ctx context.Context, login string) error {
+	collaborator, err := c.Get(ctx, login)
+	if err != nil {
+		return err
+	}
+
+	projectKey, repoSlug := getStashRefs(c.ref)
+	if err := c.client.Repositories.RevokeRepositoryUserPermission(ctx, projectKey, repoSlug, collaborator.Login); err != nil {
+		return fmt.Errorf("failed to revoke repository user permission: %w", err)
+	}
+	return nil
+}
+
+// ListInvitations returns an empty list, as Stash grants permissions without invitations.
+func (c *CollaboratorClient) ListInvitations(_ context.Context) ([]gitprovider.CollaboratorInvitation, error) {
+	return []gitprovider.CollaboratorInvitation{}, nil
+}
+
+// CancelInvitation returns ErrNotFound, as Stash grants permissions without invitations.
+func (c *CollaboratorClient) CancelInvitation(_ context.Context, id int64) error {
+	return fmt.Errorf("invitation %d: %w", id, gitprovider.ErrNotFound)
+}
@@ -18,8 +18,12 @@ package stash
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
 	"github.com/fluxcd/go-git-providers/gitprovider"
 )
 
@@ -33,8 +37,17 @@ type CommitClient struct {
 }
 
 // ListPage lists repository commits of the given page and page size.
-func (c *CommitClient) ListPage(ctx context.Context, branch string, perPage, page int) ([]gitprovider.Commit, error) {
-	commitList, err := c.listPage(ctx, branch, perPage, page)
+// The CommitListOptions Author, Since and Until filters are applied to each page, as the
+// Bitbucket Server API can't filter by them. CommitListOptions.WithStats is not supported.
+func (c *CommitClient) ListPage(ctx context.Context, branch string, perPage, page int, opts ...gitprovider.CommitListOption) ([]gitprovider.Commit, error) {
+	o, err := gitprovider.MakeCommitListOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if o.WithStats != nil && *o.WithStats {
+		return nil, fmt.Errorf("commit stats: %w", gitprovider.ErrNoProviderSupport)
+	}
+	commitList, err := c.listPage(ctx, branch, perPage, page, o)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list commits: %w", err)
 	}
@@ -46,7 +59,7 @@ func (c *CommitClient) ListPage(ctx context.Context, branch string, perPage, pag
 	return commits, nil
 }
 
-func (c *CommitClient) listPage(ctx context.Context, branch string, perPage, page int) ([]*commitType, error) {
+func (c *CommitClient) listPage(ctx context.Context, branch string, perPage, page int, opts gitprovider.CommitListOptions) ([]*commitType, error) {
 	projectKey, repoSlug := getStashRefs(c.ref)
 
 	// check if it is a user repository
@@ -55,15 +68,28 @@ func (c *CommitClient) listPage(ctx context.Context, branch string, perPage, pag
 		projectKey = addTilde(r.UserLogin)
 	}
 
-	apiObjs, err := c.client.Commits.ListPage(ctx, projectKey, repoSlug, branch, perPage, page)
+	var path string
+	if opts.Path != nil {
+		path = *opts.Path
+	}
+
+	apiObjs, err := c.client.Commits.ListPage(ctx, projectKey, repoSlug, branch, path, perPage, page)
+	if (err != nil || len(apiObjs) == 0) && c.isEmptyRepository(ctx, projectKey, repoSlug) {
+		return nil, gitprovider.ErrEmptyRepository
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Map the api object to our CommitType type
+	// Map the api object to our CommitType type, filtering by author and time client-side
 	commits := make([]*commitType, 0, len(apiObjs))
 	for _, apiObj := range apiObjs {
-		commits = append(commits, newCommit(apiObj))
+		commit := newCommit(c, apiObj)
+		if !opts.Matches(apiObj.Author.Name, apiObj.Author.EmailAddress, commit.Get().CreatedAt) &&
+			!opts.Matches(apiObj.Author.Slug, apiObj.Author.EmailAddress, commit.Get().CreatedAt) {
+			continue
+		}
+		commits = append(commits, commit)
 	}
 	return commits, nil
 }
@@ -89,11 +115,6 @@ func (c *CommitClient) Create(ctx context.Context, branch string, message string
 	}
 
 	url := getRepoHTTPref(repo.Links.Clone)
-	r, dir, err := c.client.Git.CloneRepository(ctx, url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to clone repository %s: %w", url, err)
-	}
-
 	f := make([]CommitFile, 0, len(files))
 	for _, file := range files {
 		f = append(f, CommitFile{Path: file.Path, Content: file.Content})
@@ -106,6 +127,18 @@ func (c *CommitClient) Create(ctx context.Context, branch string, message string
 		WithMessage(message),
 		WithURL(url),
 		WithFiles(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create commit: %w", err)
+	}
+
+	r, dir, err := c.client.Git.CloneRepository(ctx, url)
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		// The repository doesn't have any commits yet, create the initial commit and branch
+		return c.createInitial(ctx, projectKey, repoSlug, branch, commit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone repository %s: %w", url, err)
+	}
 
 	result, err := c.client.Git.CreateCommit(dir, r, branch, commit)
 	if err != nil {
@@ -127,5 +160,66 @@ func (c *CommitClient) Create(ctx context.Context, branch string, message string
 		return nil, fmt.Errorf("failed to cleanup repository: %w", err)
 	}
 
-	return newCommit(sha), nil
+	return newCommit(c, sha), nil
+}
+
+// createInitial creates the initial commit of an empty repository and pushes it to the given branch.
+func (c *CommitClient) createInitial(ctx context.Context, projectKey, repoSlug, branch string, commit *CreateCommit) (gitprovider.Commit, error) {
+	commit.Author.Date = c.client.clock.Now().Unix()
+	r, dir, err := c.client.Git.InitRepository(commit, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init repository: %w", err)
+	}
+	defer func() {
+		_ = c.client.Git.Cleanup(dir)
+	}()
+
+	head, err := r.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get head: %w", err)
+	}
+
+	// The initial commit is created on the default branch of the local repository,
+	// move it to the requested branch so that only this branch gets pushed.
+	if head.Name() != plumbing.NewBranchReferenceName(branch) {
+		if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), head.Hash())); err != nil {
+			return nil, fmt.Errorf("failed to create branch: %w", err)
+		}
+		if err := r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch))); err != nil {
+			return nil, fmt.Errorf("failed to checkout branch: %w", err)
+		}
+		if err := r.Storer.RemoveReference(head.Name()); err != nil {
+			return nil, fmt.Errorf("failed to remove branch %s: %w", head.Name().Short(), err)
+		}
+	}
+
+	err = c.client.Git.Push(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to push commit: %w", err)
+	}
+
+	apiObj, err := c.client.Commits.Get(ctx, projectKey, repoSlug, head.Hash().String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", head.Hash().String(), err)
+	}
+
+	return newCommit(c, apiObj), nil
+}
+
+// isEmptyRepository returns true if the repository doesn't have any branch, i.e. no commits yet.
+func (c *CommitClient) isEmptyRepository(ctx context.Context, projectKey, repoSlug string) bool {
+	branches, err := c.client.Branches.List(ctx, projectKey, repoSlug, &PagingOptions{Limit: 1})
+	return err == nil && len(branches.GetBranches()) == 0
+}
+
+// ListComments lists the comments on the commit with the given SHA. Stash only lists the
+// comments on a given file of a commit, hence gitprovider.ErrNoProviderSupport is returned.
+func (c *CommitClient) ListComments(_ context.Context, _ string) ([]gitprovider.CommitCommentInfo, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// CreateComment adds a comment with the given body to the commit with the given SHA.
+// gitprovider.ErrNoProviderSupport is returned, like for ListComments.
+func (c *CommitClient) CreateComment(_ context.Context, _, _ string) (gitprovider.CommitCommentInfo, error) {
+	return gitprovider.CommitCommentInfo{}, gitprovider.ErrNoProviderSupport
 }
@@ -152,8 +152,16 @@ func (c *DeployKeyClient) Reconcile(ctx context.Context, req gitprovider.DeployK
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(c.ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := c.Create(ctx, req)
-			return resp, true, err
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallCreateDeployKey)
+			return resp, true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
@@ -164,24 +172,30 @@ func (c *DeployKeyClient) Reconcile(ctx context.Context, req gitprovider.DeployK
 	if req.Equals(actual.Get()) {
 		return actual, false, nil
 	}
+	change := gitprovider.NewUpdateChange(c.ref, actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return actual, true, gitprovider.NewDryRunError(change)
+	}
 
 	// Populate the desired state to the current-actual object
 	if err := actual.Set(req); err != nil {
 		return actual, false, err
 	}
 	// Apply the desired state by running Update
-	_, err = c.update(ctx, actual.Get())
+	_, err = c.update(ctx, actual.APIObject().(*DeployKey), actual.Get())
 	if err != nil {
-		return actual, false, fmt.Errorf("failed to update deploy key %q: %w", req.Name, err)
+		return actual, true, fmt.Errorf("failed to update deploy key %q: %w", req.Name, err)
 	}
+	gitprovider.RecordChange(ctx, change, apiCallDeleteDeployKey, apiCallCreateDeployKey)
 	return actual, true, nil
 }
 
-// update will apply the desired state in this object to the server.
+// update will apply the desired state in this object to the server, replacing the old key.
 // ErrNotFound is returned if the resource does not exist.
-func (c *DeployKeyClient) update(ctx context.Context, req gitprovider.DeployKeyInfo) (*DeployKey, error) {
+func (c *DeployKeyClient) update(ctx context.Context, old *DeployKey, req gitprovider.DeployKeyInfo) (*DeployKey, error) {
 	// Delete the old key and recreate
-	if err := c.delete(ctx, req); err != nil {
+	if err := c.delete(ctx, old); err != nil {
 		return nil, err
 	}
 
@@ -201,7 +215,8 @@ func (c *DeployKeyClient) update(ctx context.Context, req gitprovider.DeployKeyI
 	return apiObj, nil
 }
 
-func (c *DeployKeyClient) delete(ctx context.Context, req gitprovider.DeployKeyInfo) error {
+// delete deletes the given key, by its ID.
+func (c *DeployKeyClient) delete(ctx context.Context, key *DeployKey) error {
 	projectKey, repoSlug := getStashRefs(c.ref)
 
 	// check if it is a user repository
@@ -209,15 +224,49 @@ func (c *DeployKeyClient) delete(ctx context.Context, req gitprovider.DeployKeyI
 		projectKey = addTilde(r.UserLogin)
 	}
 
-	key := deployKeyToAPI(projectKey, repoSlug, &req)
 	// Delete the old key
-	if err := c.client.DeployKeys.Delete(ctx, key.Project.Key, key.Repository.Slug, key.Key.ID); err != nil {
-		return fmt.Errorf("failed to delete deploy key %q: %w", req.Name, err)
+	if err := c.client.DeployKeys.Delete(ctx, projectKey, repoSlug, key.Key.ID); err != nil {
+		return fmt.Errorf("failed to delete deploy key %q: %w", key.Key.Label, err)
 	}
 
 	return nil
 }
 
+// Rotate replaces the key material of the deploy key with the given name. Stash allows several
+// keys with the same label, hence the new key is created before the old one is deleted.
+//
+// ErrNotFound is returned if the resource does not exist.
+func (c *DeployKeyClient) Rotate(ctx context.Context, name string, newKey []byte) (gitprovider.DeployKey, bool, error) {
+	apiObj, err := c.get(ctx, name)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get deploy key %q: %w", name, err)
+	}
+	actual := newDeployKey(c, apiObj)
+	req := actual.Get()
+	if gitprovider.EqualPublicKeys(req.Key, newKey) {
+		return actual, false, nil
+	}
+	req.Key = newKey
+	if err := req.ValidateInfo(); err != nil {
+		return nil, false, err
+	}
+	change := gitprovider.NewUpdateChange(c.ref, actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return actual, true, gitprovider.NewDryRunError(change)
+	}
+
+	resp, err := c.Create(ctx, req)
+	if err != nil {
+		return actual, false, err
+	}
+	if err := c.delete(ctx, apiObj); err != nil {
+		return resp, true, fmt.Errorf("created the new deploy key %q, but failed to delete the old one: %w", name, err)
+	}
+	gitprovider.RecordChange(ctx, change, apiCallCreateDeployKey, apiCallDeleteDeployKey)
+	return resp, true, nil
+}
+
 func deployKeyInfoToAPIObj(info *gitprovider.DeployKeyInfo, apiObj *DeployKey) {
 	if info.ReadOnly != nil {
 		if *info.ReadOnly {
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestDeployKeyRotate(t *testing.T) {
+	mux, client := setup(t)
+
+	var requests []string
+	keysPath := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s", stashURIkeys, projectsURI, RepositoriesURI, deployKeysURI)
+	mux.HandleFunc(keysPath, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" ssh")
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"key": {"id": 2, "text": "ssh-ed25519 BBBB", "label": "flux"}, "permission": "REPO_READ"}`))
+			return
+		}
+		w.Write([]byte(`{"values": [{"key": {"id": 1, "text": "ssh-ed25519 AAAA", "label": "flux"}, "permission": "REPO_READ"}], "isLastPage": true}`))
+	})
+	mux.HandleFunc(keysPath+"/1", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" ssh/1")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: "stash.example.com", Organization: "prj1"},
+		RepositoryName:  "repo1",
+	}
+	ref.SetKey("prj1")
+	ref.SetSlug("repo1")
+	c := &DeployKeyClient{clientContext: &clientContext{client: client}, ref: ref}
+
+	resp, actionTaken, err := c.Rotate(context.Background(), "flux", []byte("ssh-ed25519 BBBB"))
+	if err != nil || !actionTaken {
+		t.Fatalf("Rotate() actionTaken = %v, err = %v", actionTaken, err)
+	}
+	if info := resp.Get(); string(info.Key) != "ssh-ed25519 BBBB" || info.ReadOnly == nil || !*info.ReadOnly {
+		t.Errorf("Rotate() = %+v, want the new read-only key", info)
+	}
+	// The old key is deleted by its ID, once the new key is created
+	if want := []string{"GET ssh", "POST ssh", "DELETE ssh/1"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// EnvironmentClient implements the gitprovider.EnvironmentClient interface.
+var _ gitprovider.EnvironmentClient = &EnvironmentClient{}
+
+// EnvironmentClient operates on the deployment environments for a specific repository.
+// Stash does not have deployment environments, so every method returns gitprovider.ErrNoProviderSupport.
+type EnvironmentClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Get returns the protection rules of the environment with the given name.
+func (c *EnvironmentClient) Get(_ context.Context, _ string) (gitprovider.EnvironmentInfo, error) {
+	return gitprovider.EnvironmentInfo{}, gitprovider.ErrNoProviderSupport
+}
+
+// List lists the environments of the repository.
+func (c *EnvironmentClient) List(_ context.Context) ([]gitprovider.EnvironmentInfo, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+func (c *EnvironmentClient) Reconcile(_ context.Context, _ gitprovider.EnvironmentInfo) (gitprovider.EnvironmentInfo, bool, error) {
+	return gitprovider.EnvironmentInfo{}, false, gitprovider.ErrNoProviderSupport
+}
+
+// Delete deletes the environment with the given name.
+func (c *EnvironmentClient) Delete(_ context.Context, _ string) error {
+	return gitprovider.ErrNoProviderSupport
+}
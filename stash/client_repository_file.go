@@ -18,9 +18,12 @@ package stash
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/go-git/go-git/v5/plumbing"
 )
 
 // FileClient implements the gitprovider.FileClient interface.
@@ -32,7 +35,90 @@ type FileClient struct {
 	ref gitprovider.RepositoryRef
 }
 
-// Get fetches and returns the contents of a file from a given branch and path
-func (c *FileClient) Get(_ context.Context, path, branch string) ([]*gitprovider.CommitFile, error) {
-	return nil, fmt.Errorf("error getting file %s@%s. not implemented in stash yet", path, branch)
+// Get fetches and returns the contents of a file from a given Git reference and path
+func (c *FileClient) Get(_ context.Context, path, ref string) ([]*gitprovider.CommitFile, error) {
+	return nil, fmt.Errorf("error getting file %s@%s. not implemented in stash yet", path, ref)
+}
+
+// maxRawFileSize is the maximum size of a file streamed by FileClient.Open.
+const maxRawFileSize = 100 << 20
+
+// Open returns a reader streaming the raw content of the file at the given path and Git reference,
+// along with the size and blob SHA of the file. The caller is responsible for closing the returned reader.
+// The Stash API doesn't expose blob metadata, so the size is taken from the Content-Length of the
+// response, and the blob SHA is computed while streaming: it is set once the reader reached EOF.
+// Files larger than 100 MiB are refused; reading fails if the content exceeds the announced size.
+//
+// ErrNotFound is returned if the file doesn't exist.
+func (c *FileClient) Open(ctx context.Context, path, ref string) (io.ReadCloser, *gitprovider.FileInfo, error) {
+	projectKey, repoSlug := getStashRefs(c.ref)
+
+	// check if it is a user repository
+	// if yes, we need to add a tilde to the user login and use it as the project key
+	if r, ok := c.ref.(gitprovider.UserRepositoryRef); ok {
+		projectKey = addTilde(r.UserLogin)
+	}
+
+	resp, err := c.client.Repositories.RawStream(ctx, projectKey, repoSlug, path, ref)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil, gitprovider.ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get file %s@%s: %w", path, ref, err)
+	}
+	if resp.ContentLength > maxRawFileSize {
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("file %s@%s is %d bytes, larger than the limit of %d bytes: %w",
+			path, ref, resp.ContentLength, maxRawFileSize, gitprovider.ErrInvalidArgument)
+	}
+
+	info := &gitprovider.FileInfo{
+		Path: path,
+		Size: resp.ContentLength,
+	}
+	return newRawFileReader(resp.Body, info), info, nil
+}
+
+// rawFileReader streams a raw file, failing if it exceeds the size limit, and sets the blob SHA
+// of the file info once the content was fully read.
+type rawFileReader struct {
+	body   io.ReadCloser
+	info   *gitprovider.FileInfo
+	limit  int64
+	read   int64
+	hasher *plumbing.Hasher
+}
+
+func newRawFileReader(body io.ReadCloser, info *gitprovider.FileInfo) *rawFileReader {
+	r := &rawFileReader{
+		body:  body,
+		info:  info,
+		limit: maxRawFileSize,
+	}
+	// The blob SHA hashes the size first, so it can only be computed if the size is known up front
+	if info.Size >= 0 {
+		r.limit = info.Size
+		h := plumbing.NewHasher(plumbing.BlobObject, info.Size)
+		r.hasher = &h
+	}
+	return r
+}
+
+func (r *rawFileReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		return 0, fmt.Errorf("file %s exceeds %d bytes: %w", r.info.Path, r.limit, gitprovider.ErrInvalidServerData)
+	}
+	if r.hasher != nil {
+		r.hasher.Write(p[:n])
+	}
+	if errors.Is(err, io.EOF) && r.hasher != nil && r.read == r.limit {
+		r.info.SHA = r.hasher.Sum().String()
+	}
+	return n, err
+}
+
+func (r *rawFileReader) Close() error {
+	return r.body.Close()
 }
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestFileClient_Open(t *testing.T) {
+	mux, client := setup(t)
+
+	content := "replicas: 2\n"
+	rawPath := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s", stashURIprefix, projectsURI, RepositoriesURI, rawURI)
+	mux.HandleFunc(rawPath+"/app/config.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	})
+	mux.HandleFunc(rawPath+"/large.bin", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(maxRawFileSize+1))
+	})
+	mux.HandleFunc(rawPath+"/lying.txt", func(w http.ResponseWriter, r *http.Request) {
+		// Flush before writing, so the response is chunked without a Content-Length
+		w.(http.Flusher).Flush()
+		w.Write([]byte(content))
+	})
+
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: "stash.example.com", Organization: "prj1"},
+		RepositoryName:  "repo1",
+	}
+	ref.SetKey("prj1")
+	ref.SetSlug("repo1")
+	c := &FileClient{clientContext: &clientContext{client: client}, ref: ref}
+	ctx := context.Background()
+
+	r, info, err := c.Open(ctx, "app/config.yaml", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != int64(len(content)) {
+		t.Errorf("Open() size = %d, want %d", info.Size, len(content))
+	}
+	b, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != content {
+		t.Errorf("Open() content = %q, want %q", string(b), content)
+	}
+	if want := plumbing.ComputeHash(plumbing.BlobObject, []byte(content)).String(); info.SHA != want {
+		t.Errorf("Open() SHA after reading = %q, want %q", info.SHA, want)
+	}
+
+	r, info, err = c.Open(ctx, "lying.txt", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := io.ReadAll(r); err != nil || string(b) != content || info.Size != -1 || info.SHA != "" {
+		t.Errorf("Open() of a file of unknown size = %q, %+v, %v", string(b), info, err)
+	}
+	r.Close()
+
+	if _, _, err := c.Open(ctx, "large.bin", "main"); !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Errorf("Open() of a too large file error = %v, want ErrInvalidArgument", err)
+	}
+	if _, _, err := c.Open(ctx, "missing.txt", "main"); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Open() of a missing file error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRawFileReader_Limit(t *testing.T) {
+	info := &gitprovider.FileInfo{Path: "file.txt", Size: 4}
+	r := newRawFileReader(io.NopCloser(strings.NewReader("too long")), info)
+	if _, err := io.ReadAll(r); !errors.Is(err, gitprovider.ErrInvalidServerData) {
+		t.Errorf("reading more than the announced size error = %v, want ErrInvalidServerData", err)
+	}
+	if info.SHA != "" {
+		t.Errorf("SHA = %q, want it unset", info.SHA)
+	}
+}
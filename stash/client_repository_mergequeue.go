@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// MergeQueueClient implements the gitprovider.MergeQueueClient interface.
+var _ gitprovider.MergeQueueClient = &MergeQueueClient{}
+
+// MergeQueueClient operates on the merge queue for a specific repository.
+// Stash does not have merge queues, so every method returns gitprovider.ErrNoProviderSupport.
+type MergeQueueClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Enqueue adds the pull request with the given number to the merge queue.
+func (c *MergeQueueClient) Enqueue(_ context.Context, _ int) (gitprovider.MergeQueueEntry, error) {
+	return gitprovider.MergeQueueEntry{}, gitprovider.ErrNoProviderSupport
+}
+
+// Dequeue removes the pull request with the given number from the merge queue.
+func (c *MergeQueueClient) Dequeue(_ context.Context, _ int) error {
+	return gitprovider.ErrNoProviderSupport
+}
+
+// Get returns the merge queue entry of the pull request with the given number.
+func (c *MergeQueueClient) Get(_ context.Context, _ int) (gitprovider.MergeQueueEntry, error) {
+	return gitprovider.MergeQueueEntry{}, gitprovider.ErrNoProviderSupport
+}
+
+// List lists the entries of the merge queue of the given base branch.
+func (c *MergeQueueClient) List(_ context.Context, _ string) ([]gitprovider.MergeQueueEntry, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
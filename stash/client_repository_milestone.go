@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// MilestoneClient implements the gitprovider.MilestoneClient interface.
+var _ gitprovider.MilestoneClient = &MilestoneClient{}
+
+// MilestoneClient operates on the milestones for a specific repository.
+// Stash does not have milestones, so every method returns gitprovider.ErrNoProviderSupport.
+type MilestoneClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists all milestones in the repository.
+func (c *MilestoneClient) List(_ context.Context) ([]gitprovider.Milestone, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Get retrieves an existing milestone by number.
+func (c *MilestoneClient) Get(_ context.Context, _ int) (gitprovider.Milestone, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Create creates a milestone with the given specifications.
+func (c *MilestoneClient) Create(_ context.Context, _ gitprovider.MilestoneInfo) (gitprovider.Milestone, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// AssignPullRequest assigns the pull request with the given number to the milestone.
+func (c *MilestoneClient) AssignPullRequest(_ context.Context, _, _ int) error {
+	return gitprovider.ErrNoProviderSupport
+}
+
+// AssignIssue assigns the issue with the given number to the milestone.
+func (c *MilestoneClient) AssignIssue(_ context.Context, _, _ int) error {
+	return gitprovider.ErrNoProviderSupport
+}
+
+// Close closes the milestone with the given number.
+func (c *MilestoneClient) Close(_ context.Context, _ int) error {
+	return gitprovider.ErrNoProviderSupport
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func TestMilestoneClient_NoProviderSupport(t *testing.T) {
+	mux, client := setup(t)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s, Bitbucket Server has no milestones", r.Method, r.URL.Path)
+	})
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: "stash.example.com", Organization: "prj1"},
+		RepositoryName:  "repo1",
+	}
+	c := &MilestoneClient{clientContext: &clientContext{client: client}, ref: ref}
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"List": func() error {
+			_, err := c.List(ctx)
+			return err
+		},
+		"Get": func() error {
+			_, err := c.Get(ctx, 1)
+			return err
+		},
+		"Create": func() error {
+			_, err := c.Create(ctx, gitprovider.MilestoneInfo{Title: "v1.0"})
+			return err
+		},
+		"AssignPullRequest": func() error { return c.AssignPullRequest(ctx, 1, 2) },
+		"AssignIssue":       func() error { return c.AssignIssue(ctx, 1, 2) },
+		"Close":             func() error { return c.Close(ctx, 1) },
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, gitprovider.ErrNoProviderSupport) {
+			t.Errorf("%s() = %v, want ErrNoProviderSupport", name, err)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package stash
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -47,7 +48,7 @@ func (c *PullRequestClient) Get(ctx context.Context, number int) (gitprovider.Pu
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pull request: %w", err)
 	}
-	return newPullRequest(pr), nil
+	return newPullRequest(c.clientContext, pr), nil
 
 }
 
@@ -69,32 +70,43 @@ func (c *PullRequestClient) List(ctx context.Context) ([]gitprovider.PullRequest
 	// Traverse the list, and return a list of OrgRepository objects
 	prs := make([]gitprovider.PullRequest, 0, len(apiObjs))
 	for _, apiObj := range apiObjs {
-		prs = append(prs, newPullRequest(apiObj))
+		prs = append(prs, newPullRequest(c.clientContext, apiObj))
 	}
 
 	return prs, nil
 
 }
 
-// Merge merges the pull request.
-// Stash does not support message and merge strategy options for pull requests automatic merges.
-func (c *PullRequestClient) Merge(ctx context.Context, number int, _ gitprovider.MergeMethod, _ string) error {
-	projectKey, repoSlug := getStashRefs(c.ref)
+// mergeStrategies maps the merge methods to the IDs of the Bitbucket Server merge strategies.
+var mergeStrategies = map[gitprovider.MergeMethod]string{
+	gitprovider.MergeMethodMerge:  "no-ff",
+	gitprovider.MergeMethodSquash: "squash",
+	gitprovider.MergeMethodRebase: "rebase-ff-only",
+}
 
-	// check if it is a user repository
-	// if yes, we need to add a tilde to the user login and use it as the project key
-	if r, ok := c.ref.(gitprovider.UserRepositoryRef); ok {
-		projectKey = addTilde(r.UserLogin)
+// Merge merges the pull request, using the merge strategy matching mergeMethod. The strategy
+// must be enabled for the repository. The default strategy of the repository is used if
+// mergeMethod is empty, and the server generates the commit message if message is empty.
+func (c *PullRequestClient) Merge(ctx context.Context, number int, mergeMethod gitprovider.MergeMethod, message string) error {
+	opts := &MergeOptions{Message: message}
+	if mergeMethod != "" {
+		strategy, ok := mergeStrategies[mergeMethod]
+		if !ok {
+			return fmt.Errorf("unknown merge method %q: %w", mergeMethod, gitprovider.ErrInvalidArgument)
+		}
+		opts.StrategyID = strategy
 	}
 
-	// Get the pull request first
+	projectKey, repoSlug := c.projectAndSlug()
+
+	// Get the pull request first, for its current version
 	pr, err := c.client.PullRequests.Get(ctx, projectKey, repoSlug, number)
 	if err != nil {
 		return fmt.Errorf("failed to get pull request: %w", err)
 	}
 
 	// Merge the pull request
-	_, err = c.client.PullRequests.Merge(ctx, projectKey, repoSlug, pr.ID, pr.Version)
+	_, err = c.client.PullRequests.MergeWithOptions(ctx, projectKey, repoSlug, pr.ID, pr.Version, opts)
 	if err != nil {
 		return err
 	}
@@ -103,6 +115,25 @@ func (c *PullRequestClient) Merge(ctx context.Context, number int, _ gitprovider
 
 }
 
+// Close declines the pull request, closing it without merging.
+func (c *PullRequestClient) Close(ctx context.Context, number int) error {
+	projectKey, repoSlug := c.projectAndSlug()
+
+	// Get the pull request first, for its current version
+	pr, err := c.client.PullRequests.Get(ctx, projectKey, repoSlug, number)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return gitprovider.ErrNotFound
+		}
+		return fmt.Errorf("failed to get pull request: %w", err)
+	}
+
+	if _, err := c.client.PullRequests.Decline(ctx, projectKey, repoSlug, pr.ID, pr.Version); err != nil {
+		return fmt.Errorf("failed to decline pull request: %w", err)
+	}
+	return nil
+}
+
 // Create creates a pull request with the given specifications.
 func (c *PullRequestClient) Create(ctx context.Context, title, branch, baseBranch, description string) (gitprovider.PullRequest, error) {
 	projectKey, repoSlug := getStashRefs(c.ref)
@@ -140,7 +171,7 @@ func (c *PullRequestClient) Create(ctx context.Context, title, branch, baseBranc
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pull request: %w", err)
 	}
-	return newPullRequest(created), nil
+	return newPullRequest(c.clientContext, created), nil
 }
 
 func validatePullRequestsAPI(apiObj *PullRequest) error {
@@ -151,3 +182,88 @@ func validatePullRequestsAPI(apiObj *PullRequest) error {
 		}
 	})
 }
+
+// ListComments lists the general comments of the pull request, oldest first.
+// Comments on the diff and replies to comments are not included.
+func (c *PullRequestClient) ListComments(ctx context.Context, number int) ([]gitprovider.PullRequestComment, error) {
+	projectKey, repoSlug := c.projectAndSlug()
+
+	apiObjs, err := c.client.PullRequests.AllComments(ctx, projectKey, repoSlug, number)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, gitprovider.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to list pull request comments: %w", err)
+	}
+
+	comments := make([]gitprovider.PullRequestComment, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		comments = append(comments, newPullRequestComment(apiObj))
+	}
+	return comments, nil
+}
+
+// CreateComment adds a comment with the given body to the pull request.
+func (c *PullRequestClient) CreateComment(ctx context.Context, number int, body string) (gitprovider.PullRequestComment, error) {
+	projectKey, repoSlug := c.projectAndSlug()
+
+	created, err := c.client.PullRequests.CreateComment(ctx, projectKey, repoSlug, number, body)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, gitprovider.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to create pull request comment: %w", err)
+	}
+	return newPullRequestComment(created), nil
+}
+
+// EditComment replaces the body of a comment of the pull request.
+//
+// ErrNotFound is returned if the comment doesn't exist.
+func (c *PullRequestClient) EditComment(ctx context.Context, number int, id int64, body string) error {
+	projectKey, repoSlug := c.projectAndSlug()
+
+	// Get the comment first, for its current version
+	comment, err := c.client.PullRequests.GetComment(ctx, projectKey, repoSlug, number, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return gitprovider.ErrNotFound
+		}
+		return fmt.Errorf("failed to get pull request comment: %w", err)
+	}
+	comment.Text = body
+	if _, err := c.client.PullRequests.UpdateComment(ctx, projectKey, repoSlug, number, comment); err != nil {
+		return fmt.Errorf("failed to update pull request comment: %w", err)
+	}
+	return nil
+}
+
+// DeleteComment deletes a comment of the pull request.
+//
+// ErrNotFound is returned if the comment doesn't exist.
+func (c *PullRequestClient) DeleteComment(ctx context.Context, number int, id int64) error {
+	projectKey, repoSlug := c.projectAndSlug()
+
+	// Get the comment first, for its current version
+	comment, err := c.client.PullRequests.GetComment(ctx, projectKey, repoSlug, number, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return gitprovider.ErrNotFound
+		}
+		return fmt.Errorf("failed to get pull request comment: %w", err)
+	}
+	if err := c.client.PullRequests.DeleteComment(ctx, projectKey, repoSlug, number, id, comment.Version); err != nil {
+		return fmt.Errorf("failed to delete pull request comment: %w", err)
+	}
+	return nil
+}
+
+// projectAndSlug returns the project key and repository slug of the repository. For user
+// repositories, the project key is the user login with a tilde.
+func (c *PullRequestClient) projectAndSlug() (string, string) {
+	projectKey, repoSlug := getStashRefs(c.ref)
+	if r, ok := c.ref.(gitprovider.UserRepositoryRef); ok {
+		projectKey = addTilde(r.UserLogin)
+	}
+	return projectKey, repoSlug
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/go-logr/logr"
+)
+
+func TestPullRequestClient_MergeAndClose(t *testing.T) {
+	mux, client := setup(t)
+
+	prPath := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s/1", stashURIprefix, projectsURI, RepositoriesURI, pullRequestsURI)
+	mux.HandleFunc(prPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&PullRequest{IDVersion: IDVersion{ID: 1, Version: 3}, Title: "pr", State: "OPEN"})
+	})
+	var merge MergeOptions
+	mux.HandleFunc(prPath+"/"+mergeURI, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Query().Get("version") != "3" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&merge); err != nil {
+			t.Errorf("failed to decode merge options: %v", err)
+		}
+		json.NewEncoder(w).Encode(&PullRequest{IDVersion: IDVersion{ID: 1, Version: 4}, Title: "pr", State: "MERGED"})
+	})
+	declined := false
+	mux.HandleFunc(prPath+"/"+declineURI, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Query().Get("version") != "3" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		declined = true
+		json.NewEncoder(w).Encode(&PullRequest{IDVersion: IDVersion{ID: 1, Version: 4}, Title: "pr", State: "DECLINED"})
+	})
+
+	p := newClient(client, "stash.example.com", "token", false, logr.Discard())
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: "stash.example.com", Organization: "prj1"},
+		RepositoryName:  "repo1",
+	}
+	ref.SetKey("prj1")
+	ref.SetSlug("repo1")
+	c := &PullRequestClient{clientContext: p.clientContext, ref: ref}
+	ctx := context.Background()
+
+	if err := c.Merge(ctx, 1, gitprovider.MergeMethodSquash, "Squashed"); err != nil {
+		t.Fatalf("Merge returned error: %v", err)
+	}
+	if want := (MergeOptions{Message: "Squashed", StrategyID: "squash"}); merge != want {
+		t.Errorf("Merge sent %+v, want %+v", merge, want)
+	}
+	if err := c.Merge(ctx, 1, gitprovider.MergeMethod("octopus"), ""); !errors.Is(err, gitprovider.ErrInvalidArgument) {
+		t.Errorf("Merge returned error %v, want ErrInvalidArgument", err)
+	}
+
+	if err := c.Close(ctx, 1); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if !declined {
+		t.Error("Close didn't decline the pull request")
+	}
+	if err := c.Close(ctx, 2); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Errorf("Close returned error %v, want ErrNotFound", err)
+	}
+
+	info := pullrequestFromAPI(&PullRequest{IDVersion: IDVersion{ID: 5}, State: "DECLINED"})
+	if info.Number != 5 || info.Merged || !info.Closed {
+		t.Errorf("pullrequestFromAPI returned %+v", info)
+	}
+}
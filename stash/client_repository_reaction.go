@@ -0,0 +1,48 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// ReactionClient implements the gitprovider.ReactionClient interface.
+var _ gitprovider.ReactionClient = &ReactionClient{}
+
+// ReactionClient operates on the emoji reactions for a specific repository.
+// Stash does not have emoji reactions, so every method returns gitprovider.ErrNoProviderSupport.
+type ReactionClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists the reactions on the given subject.
+func (c *ReactionClient) List(_ context.Context, _ gitprovider.ReactionSubject) ([]gitprovider.ReactionInfo, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Add adds a reaction with the given content to the subject.
+func (c *ReactionClient) Add(_ context.Context, _ gitprovider.ReactionSubject, _ gitprovider.ReactionContent) (gitprovider.ReactionInfo, error) {
+	return gitprovider.ReactionInfo{}, gitprovider.ErrNoProviderSupport
+}
+
+// Remove removes the reaction with the given ID from the subject.
+func (c *ReactionClient) Remove(_ context.Context, _ gitprovider.ReactionSubject, _ int64) error {
+	return gitprovider.ErrNoProviderSupport
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// TagProtectionClient implements the gitprovider.TagProtectionClient interface.
+var _ gitprovider.TagProtectionClient = &TagProtectionClient{}
+
+// TagProtectionClient operates on the tag protection rules for a specific repository.
+// Stash protects tags with ref restrictions, which are only mapped for branches yet, so every
+// method returns gitprovider.ErrNoProviderSupport.
+type TagProtectionClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// List lists the tag protection rules of the repository.
+func (c *TagProtectionClient) List(_ context.Context) ([]gitprovider.TagProtectionInfo, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
+func (c *TagProtectionClient) Reconcile(_ context.Context, _ gitprovider.TagProtectionInfo) (gitprovider.TagProtectionInfo, bool, error) {
+	return gitprovider.TagProtectionInfo{}, false, gitprovider.ErrNoProviderSupport
+}
+
+// Delete deletes the rule with the given pattern.
+func (c *TagProtectionClient) Delete(_ context.Context, _ string) error {
+	return gitprovider.ErrNoProviderSupport
+}
@@ -181,8 +181,11 @@ func (c *TeamAccessClient) List(ctx context.Context) ([]gitprovider.TeamAccess,
 // The team shall exist in Stash.
 // ErrAlreadyExists will be returned if the resource already exists.
 func (c *TeamAccessClient) Create(ctx context.Context, team gitprovider.TeamAccessInfo) (gitprovider.TeamAccess, error) {
+	if err := gitprovider.ValidateAndDefaultInfo(&team); err != nil {
+		return nil, err
+	}
 	projectKey, repoSlug := getStashRefs(c.ref)
-	permission, err := getStashPermission(*team.Permission)
+	permission, err := getStashPermission(normalizeStashPermission(*team.Permission))
 	if err != nil {
 		return nil, err
 	}
@@ -216,7 +219,7 @@ func (c *TeamAccessClient) Create(ctx context.Context, team gitprovider.TeamAcce
 // Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
 //
 // If req doesn't exist under the hood, it is created (actionTaken == true).
-// If req doesn't equal the actual state, the resource will be deleted and recreated (actionTaken == true).
+// If req doesn't equal the actual state, its permission will be updated in place (actionTaken == true).
 // If req is already the actual state, this is a no-op (actionTaken == false).
 func (c *TeamAccessClient) Reconcile(ctx context.Context,
 	req gitprovider.TeamAccessInfo,
@@ -226,13 +229,24 @@ func (c *TeamAccessClient) Reconcile(ctx context.Context,
 	if err := gitprovider.ValidateAndDefaultInfo(&req); err != nil {
 		return nil, false, err
 	}
+	// Compare against the permission Stash will actually store, so that
+	// levels it can't represent don't show up as a permanent diff
+	req.Permission = gitprovider.RepositoryPermissionVar(normalizeStashPermission(*req.Permission))
 
 	actual, err := c.Get(ctx, req.Name)
 	if err != nil {
 		// Create if not found
 		if errors.Is(err, gitprovider.ErrNotFound) {
+			change := gitprovider.NewCreateChange(c.ref, req)
+			if c.dryRun {
+				return nil, true, gitprovider.NewDryRunError(change)
+			}
 			resp, err := c.Create(ctx, req)
-			return resp, true, err
+			if err != nil {
+				return nil, true, err
+			}
+			gitprovider.RecordChange(ctx, change, apiCallUpdateGroupPermission)
+			return resp, true, nil
 		}
 
 		// Unexpected path, Get should succeed or return NotFound
@@ -243,6 +257,11 @@ func (c *TeamAccessClient) Reconcile(ctx context.Context,
 	if req.Equals(actual.Get()) {
 		return actual, false, nil
 	}
+	change := gitprovider.NewUpdateChange(c.ref, actual.Get(), req)
+	// In dry-run mode, only report what would be updated
+	if c.dryRun {
+		return actual, true, gitprovider.NewDryRunError(change)
+	}
 
 	// Populate the desired state to the current-actual object
 	if err := actual.Set(req); err != nil {
@@ -251,10 +270,11 @@ func (c *TeamAccessClient) Reconcile(ctx context.Context,
 
 	// Update the actual state to be the desired state
 	// by issuing a Create, which uses a PUT underneath.
-	_, err = c.Create(ctx, actual.Get())
+	resp, err := c.Create(ctx, actual.Get())
 	if err != nil {
-		return actual, false, err
+		return actual, true, err
 	}
+	gitprovider.RecordChange(ctx, change, apiCallUpdateGroupPermission)
 
-	return actual, true, nil
+	return resp, true, nil
 }
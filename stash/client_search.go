@@ -0,0 +1,42 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// SearchClient implements the gitprovider.SearchClient interface.
+var _ gitprovider.SearchClient = &SearchClient{}
+
+// SearchClient searches for repositories and code.
+// Stash has no search API, so every method returns gitprovider.ErrNoProviderSupport.
+type SearchClient struct {
+	*clientContext
+}
+
+// Repositories returns the repositories matching the text or topics of the query.
+func (c *SearchClient) Repositories(_ context.Context, _ gitprovider.SearchQuery) ([]gitprovider.RepositorySearchResult, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Code returns the files with content matching the text of the query.
+func (c *SearchClient) Code(_ context.Context, _ gitprovider.SearchQuery) ([]gitprovider.CodeSearchResult, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
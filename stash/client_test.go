@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -30,9 +31,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fluxcd/go-git-providers/gitprovider/testutils"
 	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
 	"github.com/go-logr/zapr"
 	"go.uber.org/zap/zaptest"
+	"golang.org/x/oauth2"
 )
 
 func Test_NewClient(t *testing.T) {
@@ -331,6 +335,136 @@ func Test_DoWithRetry(t *testing.T) {
 	}
 }
 
+func Test_DoWithRetryClock(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	tests := []struct {
+		name       string
+		statusCode int
+		header     http.Header
+		// err is returned instead of a response, if set
+		err error
+		// advance is the time to advance the clock by, before the request is retried
+		advance []time.Duration
+	}{
+		{
+			name:       "service unavailable",
+			statusCode: http.StatusServiceUnavailable,
+			advance:    []time.Duration{time.Second},
+		},
+		{
+			name:       "rate limited until reset",
+			statusCode: http.StatusTooManyRequests,
+			header:     http.Header{http.CanonicalHeaderKey(headerRateReset): []string{fmt.Sprint(start.Add(10 * time.Second).Unix())}},
+			advance:    []time.Duration{5 * time.Second, 6 * time.Second},
+		},
+		{
+			name:    "connection refused",
+			err:     errors.New("dial tcp: connection refused"),
+			advance: []time.Duration{time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := testutils.NewFakeClock(start)
+			attempts := 0
+			c := NewTestClient(t, func(req *http.Request) (*http.Response, error) {
+				attempts++
+				// The first request is made to configure the rate limiter
+				if attempts == 2 && tt.err != nil {
+					return nil, tt.err
+				}
+				if attempts == 2 {
+					return &http.Response{
+						StatusCode: tt.statusCode,
+						Body:       io.NopCloser(bytes.NewBufferString("")),
+						Header:     tt.header,
+						Request:    req,
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString("ok")),
+					Header:     make(http.Header),
+					Request:    req,
+				}, nil
+			}, WithClock(clock))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			request, err := c.NewRequest(ctx, http.MethodGet, "")
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			errCh := make(chan error, 1)
+			go func() {
+				_, _, err := c.Do(request)
+				errCh <- err
+			}()
+
+			for i, d := range tt.advance {
+				if err := clock.BlockUntil(ctx, 1); err != nil {
+					t.Fatalf("request didn't wait for the clock: %v", err)
+				}
+				clock.Advance(d)
+				if i < len(tt.advance)-1 && clock.Sleepers() != 1 {
+					t.Fatalf("request retried before the clock reached the end of the backoff")
+				}
+			}
+
+			if err := <-errCh; err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if attempts != 3 {
+				t.Errorf("expected 3 requests, got %d", attempts)
+			}
+		})
+	}
+}
+
+func Test_DoLogsRetries(t *testing.T) {
+	var lines []string
+	attempts := 0
+	c := NewTestClient(t, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, fmt.Errorf("connection refused, please retry")
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString("ok")),
+			Header:     make(http.Header),
+		}, nil
+	}, func(c *Client) error {
+		c.Client.RetryWaitMin = time.Millisecond
+		c.Client.RetryWaitMax = time.Millisecond
+		c.Logger = funcr.New(func(prefix, args string) {
+			if strings.Contains(args, "retrying request") {
+				lines = append(lines, args)
+			}
+		}, funcr.Options{Verbosity: 1})
+		return nil
+	})
+
+	request, err := c.NewRequest(context.Background(), http.MethodGet, "rest/api/1.0/projects")
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	if _, _, err := c.Do(request); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	// The first failing round trip is the rate limiter configuration, which isn't retried or logged.
+	// The request itself fails once and then succeeds, so exactly one retry is logged, as attempt 1.
+	if len(lines) != 1 {
+		t.Fatalf("got %d retry log lines, want 1: %v", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], `"attempt"=1`) || !strings.Contains(lines[0], `"method"="GET"`) {
+		t.Errorf("unexpected retry log line %q", lines[0])
+	}
+}
+
 func initLogger(t *testing.T) logr.Logger {
 	var log logr.Logger
 	zapLog := zaptest.NewLogger(t)
@@ -347,6 +481,31 @@ func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 //NewTestClient returns a Client with Transport replaced to avoid making real calls
+func Test_basicAuth(t *testing.T) {
+	c, err := NewClient(nil, defaultHost, nil, initLogger(t), WithAuth("user", "static"))
+	if err != nil {
+		t.Fatalf("unexpected error while declaring a client: %v", err)
+	}
+	auth, err := c.basicAuth()
+	if err != nil || auth.Username != "user" || auth.Password != "static" {
+		t.Errorf("basicAuth() = %v, %v, want user:static", auth, err)
+	}
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "refreshed"})
+	c, err = NewClient(nil, defaultHost, nil, initLogger(t), WithTokenSource("user", ts))
+	if err != nil {
+		t.Fatalf("unexpected error while declaring a client: %v", err)
+	}
+	auth, err = c.basicAuth()
+	if err != nil || auth.Username != "user" || auth.Password != "refreshed" {
+		t.Errorf("basicAuth() = %v, %v, want user:refreshed", auth, err)
+	}
+
+	if _, err := NewClient(nil, defaultHost, nil, initLogger(t), WithTokenSource("user", nil)); err == nil {
+		t.Error("expected an error for a nil token source")
+	}
+}
+
 func NewTestClient(t *testing.T, fn RoundTripFunc, opts ...ClientOptionsFunc) *Client {
 	c, err := NewClient(nil, defaultHost, nil, initLogger(t))
 	if err != nil {
@@ -0,0 +1,47 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// UserKeysClient implements the gitprovider.UserKeysClient interface.
+var _ gitprovider.UserKeysClient = &UserKeysClient{}
+
+// UserKeysClient operates on the SSH and GPG keys of the authenticated user.
+// The key APIs of Stash aren't supported yet, so every method returns gitprovider.ErrNoProviderSupport.
+type UserKeysClient struct {
+	*clientContext
+}
+
+// List lists the keys of the given type.
+func (c *UserKeysClient) List(_ context.Context, _ gitprovider.UserKeyType) ([]gitprovider.UserKeyInfo, error) {
+	return nil, gitprovider.ErrNoProviderSupport
+}
+
+// Add adds the given key.
+func (c *UserKeysClient) Add(_ context.Context, _ gitprovider.UserKeyInfo) (gitprovider.UserKeyInfo, error) {
+	return gitprovider.UserKeyInfo{}, gitprovider.ErrNoProviderSupport
+}
+
+// Delete removes the key of the given type and ID.
+func (c *UserKeysClient) Delete(_ context.Context, _ gitprovider.UserKeyType, _ int64) error {
+	return gitprovider.ErrNoProviderSupport
+}
@@ -32,7 +32,7 @@ const (
 // retrieve commits of a repository.
 type Commits interface {
 	List(ctx context.Context, projectKey, repositorySlug, branch string, opts *PagingOptions) (*CommitList, error)
-	ListPage(ctx context.Context, projectKey, repositorySlug, branch string, perPage, page int) ([]*CommitObject, error)
+	ListPage(ctx context.Context, projectKey, repositorySlug, branch, path string, perPage, page int) ([]*CommitObject, error)
 	Get(ctx context.Context, projectKey, repositorySlug, commitID string) (*CommitObject, error)
 }
 
@@ -89,10 +89,35 @@ func (c *CommitList) GetCommits() []*CommitObject {
 // List uses the endpoint "GET /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/commits".
 // https://docs.atlassian.com/bitbucket-server/rest/5.16.0/bitbucket-rest.html
 func (s *CommitsService) List(ctx context.Context, projectKey, repositorySlug, branch string, opts *PagingOptions) (*CommitList, error) {
+	return s.list(ctx, projectKey, repositorySlug, branch, "", opts)
+}
+
+// ListPage retrieves all commits for a given page, optionally only those touching the given path.
+// ListPage uses the endpoint "GET /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/commits?path={path}".
+func (s *CommitsService) ListPage(ctx context.Context, projectKey, repositorySlug, branch, path string, perPage, page int) ([]*CommitObject, error) {
+	start := 0
+	if page > 0 {
+		start = (perPage * page) + 1
+	}
+
+	opts := &PagingOptions{Limit: int64(perPage), Start: int64(start)}
+	list, err := s.list(ctx, projectKey, repositorySlug, branch, path, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Commits, nil
+}
+
+func (s *CommitsService) list(ctx context.Context, projectKey, repositorySlug, branch, path string, opts *PagingOptions) (*CommitList, error) {
 	values := url.Values{}
 	if branch != "" {
 		values.Add("until", branch)
 	}
+	if path != "" {
+		values.Add("path", path)
+	}
 	query := addPaging(values, opts)
 	req, err := s.Client.NewRequest(ctx, http.MethodGet, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, commitsURI), WithQuery(query))
 	if err != nil {
@@ -112,7 +137,7 @@ func (s *CommitsService) List(ctx context.Context, projectKey, repositorySlug, b
 	}
 
 	c := &CommitList{}
-	if err := json.Unmarshal(res, c); err != nil {
+	if err := s.Client.unmarshalList(res, c); err != nil {
 		return nil, fmt.Errorf("list commits for repository failed, unable to unmarshall repository json: %w", err)
 	}
 
@@ -122,24 +147,6 @@ func (s *CommitsService) List(ctx context.Context, projectKey, repositorySlug, b
 	return c, nil
 }
 
-// ListPage retrieves all commits for a given page.
-// This function handles pagination, HTTP error wrapping, and validates the server result.
-func (s *CommitsService) ListPage(ctx context.Context, projectKey, repositorySlug, branch string, perPage, page int) ([]*CommitObject, error) {
-	start := 0
-	if page > 0 {
-		start = (perPage * page) + 1
-	}
-
-	opts := &PagingOptions{Limit: int64(perPage), Start: int64(start)}
-	list, err := s.List(ctx, projectKey, repositorySlug, branch, opts)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return list.Commits, nil
-}
-
 // Get retrieves a stash commit given it's ID i.e a SHA1.
 // Get uses the endpoint "GET /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/commits/{commitID}".
 // https://docs.atlassian.com/bitbucket-server/rest/5.16.0/bitbucket-rest.html
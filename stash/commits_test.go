@@ -19,11 +19,14 @@ package stash
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"path"
 	"testing"
+	"time"
 
+	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -121,3 +124,51 @@ func TestListCommits(t *testing.T) {
 	}
 
 }
+
+func TestListCommitsFiltered(t *testing.T) {
+	commits := []*CommitObject{
+		{ID: "abcdef0123abcdef4567abcdef8987abcdef6543", Author: User{Name: "alice", EmailAddress: "alice@example.com"}, AuthorTimestamp: 1600000000000},
+		{ID: "aerfdef09893abcdef4567abcdef898abcdef652", Author: User{Name: "bob", EmailAddress: "bob@example.com"}, AuthorTimestamp: 1600000000000},
+		{ID: "abcdef3456abcdef4567abcdef8987abcdef6657", Author: User{Name: "alice", EmailAddress: "alice@example.com"}, AuthorTimestamp: 1500000000000},
+	}
+
+	mux, client := setup(t)
+
+	path := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s", stashURIprefix, projectsURI, RepositoriesURI, commitsURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("path") != "docs/README.md" {
+			t.Errorf("unexpected path query %q", r.URL.Query().Get("path"))
+		}
+		w.WriteHeader(http.StatusOK)
+		b := struct {
+			Commits []*CommitObject `json:"values"`
+		}{commits}
+		json.NewEncoder(w).Encode(b)
+	})
+
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: "stash.example.com", Organization: "prj1"},
+		RepositoryName:  "repo1",
+	}
+	ref.SetKey("prj1")
+	ref.SetSlug("repo1")
+	c := &CommitClient{clientContext: &clientContext{client: client}, ref: ref}
+
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	list, err := c.ListPage(context.Background(), "main", 10, 0, &gitprovider.CommitListOptions{
+		Path:   gitprovider.StringVar("docs/README.md"),
+		Author: gitprovider.StringVar("alice@example.com"),
+		Since:  &since,
+	})
+	if err != nil {
+		t.Fatalf("CommitClient.ListPage returned error: %v", err)
+	}
+	if len(list) != 1 || list[0].Get().Sha != commits[0].ID {
+		t.Errorf("CommitClient.ListPage returned %d commits, want only %s", len(list), commits[0].ID)
+	}
+
+	_, err = c.ListPage(context.Background(), "main", 10, 0, &gitprovider.CommitListOptions{WithStats: gitprovider.BoolVar(true)})
+	if !errors.Is(err, gitprovider.ErrNoProviderSupport) {
+		t.Errorf("CommitClient.ListPage returned error %v, want %v", err, gitprovider.ErrNoProviderSupport)
+	}
+}
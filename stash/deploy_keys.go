@@ -111,7 +111,7 @@ func (s *DeployKeysService) List(ctx context.Context, projectKey, repositorySlug
 	}
 
 	keys := &DeployKeyList{}
-	if err := json.Unmarshal(res, keys); err != nil {
+	if err := s.Client.unmarshalList(res, keys); err != nil {
 		return nil, fmt.Errorf("list deploy keys for repository failed, unable to unmarshall json: %w", err)
 	}
 
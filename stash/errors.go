@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+// errorResponse is the body of the failed responses of the REST API.
+type errorResponse struct {
+	Errors []struct {
+		Message       string `json:"message"`
+		ExceptionName string `json:"exceptionName"`
+		// Conflicted is set on the errors of a vetoed merge, if the vetoes include conflicts.
+		Conflicted bool `json:"conflicted"`
+	} `json:"errors"`
+}
+
+// classify returns the error of the gitprovider taxonomy matching the structured fields of the
+// errors, or nil.
+func (r *errorResponse) classify() error {
+	for _, e := range r.Errors {
+		if e.Conflicted {
+			return gitprovider.ErrMergeConflict
+		}
+	}
+	return nil
+}
+
+// newStatusError returns the error of a response with an unexpected status code, wrapping
+// ErrorUnexpectedStatusCode, together with the typed errors of gitprovider matching it, so that
+// callers can branch on errors.Is.
+func newStatusError(request *http.Request, resp *http.Response, body []byte) error {
+	err := fmt.Errorf("request %s %s returned status code: %s, %w", request.Method, request.URL, resp.Status, ErrorUnexpectedStatusCode)
+
+	var messages []string
+	apiErr := &errorResponse{}
+	if json.Unmarshal(body, apiErr) == nil {
+		for _, e := range apiErr.Errors {
+			messages = append(messages, e.Message)
+		}
+	}
+	if body == nil {
+		// The body was read already
+		body = []byte{}
+	}
+	httpErr := gitprovider.NewHTTPError(resp, body)
+	httpErr.ErrorMessage = err.Error()
+	httpErr.Message = strings.Join(messages, "; ")
+	classified := apiErr.classify()
+	if classified == nil {
+		classified = gitprovider.ClassifyHTTPError(resp.StatusCode, httpErr.Message)
+	}
+
+	var typedErr error = &httpErr
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		rateLimitErr := &gitprovider.RateLimitError{HTTPError: httpErr}
+		rateLimitErr.Limit, _ = strconv.Atoi(resp.Header.Get(headerRateLimit))
+		if reset, err := strconv.ParseInt(resp.Header.Get(headerRateReset), 10, 64); err == nil {
+			rateLimitErr.Reset = time.Unix(reset, 0)
+		}
+		typedErr = rateLimitErr
+	case http.StatusUnauthorized, http.StatusForbidden:
+		typedErr = &gitprovider.InvalidCredentialsError{HTTPError: httpErr}
+	case http.StatusUnprocessableEntity:
+		typedErr = &gitprovider.ValidationError{HTTPError: httpErr}
+	}
+	if classified == nil {
+		return validation.NewMultiError(err, typedErr)
+	}
+	return validation.NewMultiError(err, typedErr, classified)
+}
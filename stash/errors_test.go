@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/validation"
+)
+
+func Test_newStatusError(t *testing.T) {
+	request := &http.Request{Method: http.MethodPost, URL: &url.URL{Path: "/rest/api/1.0/projects/prj1/repos/repo1/pull-requests/1/merge"}}
+	tests := []struct {
+		name         string
+		statusCode   int
+		body         string
+		expectedErrs []error
+	}{
+		{
+			name:         "forbidden",
+			statusCode:   http.StatusForbidden,
+			body:         `{"errors": [{"message": "You are not permitted to access this resource"}]}`,
+			expectedErrs: []error{ErrorUnexpectedStatusCode, &gitprovider.InvalidCredentialsError{}, gitprovider.ErrPermissionDenied},
+		},
+		{
+			name:         "archived repository",
+			statusCode:   http.StatusForbidden,
+			body:         `{"errors": [{"message": "Repository prj1/repo1 is archived and cannot be modified."}]}`,
+			expectedErrs: []error{&gitprovider.InvalidCredentialsError{}, gitprovider.ErrRepositoryArchived},
+		},
+		{
+			name:       "merge conflict",
+			statusCode: http.StatusConflict,
+			body: `{"errors": [{"message": "The pull request has conflicts and cannot be merged.",
+				"exceptionName": "com.atlassian.bitbucket.pull.PullRequestMergeVetoedException"}]}`,
+			expectedErrs: []error{&gitprovider.HTTPError{}, gitprovider.ErrMergeConflict},
+		},
+		{
+			name:       "merge conflict, conflicted veto",
+			statusCode: http.StatusConflict,
+			body: `{"errors": [{"message": "Der Pull Request kann nicht gemergt werden.", "conflicted": true,
+				"exceptionName": "com.atlassian.bitbucket.pull.PullRequestMergeVetoedException"}]}`,
+			expectedErrs: []error{&gitprovider.HTTPError{}, gitprovider.ErrMergeConflict},
+		},
+		{
+			name:         "rate limited",
+			statusCode:   http.StatusTooManyRequests,
+			expectedErrs: []error{&gitprovider.RateLimitError{}, gitprovider.ErrRateLimited},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.statusCode, Status: http.StatusText(tt.statusCode), Header: http.Header{}}
+			err := newStatusError(request, resp, []byte(tt.body))
+			validation.TestExpectErrors(t, "newStatusError", err, tt.expectedErrs...)
+		})
+	}
+
+	resp := &http.Response{StatusCode: http.StatusConflict, Status: "409 Conflict", Header: http.Header{"X-Arequestid": []string{"@1A2B3C"}}}
+	err := newStatusError(request, resp, []byte(`{"errors": [{"message": "Rescoped", "conflicted": false}]}`))
+	if errors.Is(err, gitprovider.ErrMergeConflict) {
+		t.Errorf("newStatusError() = %v, want no merge conflict", err)
+	}
+	var httpErr *gitprovider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusConflict || httpErr.RequestID != "@1A2B3C" || httpErr.Message != "Rescoped" {
+		t.Errorf("newStatusError() = %+v, want the status code, request ID and message", httpErr)
+	}
+}
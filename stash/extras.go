@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+const (
+	applicationPropertiesURI = "application-properties"
+)
+
+// ExtrasName is the name the Stash Extras are registered under, for use with Client.Extension.
+const ExtrasName = "stash"
+
+// Extras contains Bitbucket Server-specific functionality that doesn't fit the generic interfaces.
+// It can be accessed using c.Extension(stash.ExtrasName).(stash.Extras), or ExtrasFor(c).
+type Extras interface {
+	// ServerVersion returns the version of the Bitbucket Server, e.g. "7.21.0". It can be
+	// passed to gitprovider.SupportedFeatures.
+	ServerVersion(ctx context.Context) (string, error)
+}
+
+func init() {
+	gitprovider.RegisterExtension(ProviderID, ExtrasName, func(c gitprovider.Client) (interface{}, bool) {
+		cl, ok := c.(*ProviderClient)
+		if !ok {
+			return nil, false
+		}
+		return &extras{cl}, true
+	})
+}
+
+// The API calls reported with the changes of Reconcile calls, see gitprovider.ChangeAction.
+const (
+	apiCallCreateRepository      = "POST /projects/{projectKey}/repos"
+	apiCallUpdateRepository      = "PUT /projects/{projectKey}/repos/{repositorySlug}"
+	apiCallSetDefaultBranch      = "PUT /projects/{projectKey}/repos/{repositorySlug}/branches/default"
+	apiCallUpdateGroupPermission = "PUT /projects/{projectKey}/repos/{repositorySlug}/permissions/groups"
+	apiCallCreateDeployKey       = "POST /projects/{projectKey}/repos/{repositorySlug}/ssh"
+	apiCallDeleteDeployKey       = "DELETE /projects/{projectKey}/repos/{repositorySlug}/ssh/{keyId}"
+)
+
+// ExtrasFor returns the Stash Extras for the given client.
+// ErrNoProviderSupport is returned if c isn't a Stash client.
+func ExtrasFor(c gitprovider.Client) (Extras, error) {
+	e, ok := c.Extension(ExtrasName).(Extras)
+	if !ok {
+		return nil, fmt.Errorf("%s client has no Stash extras: %w", c.ProviderID(), gitprovider.ErrNoProviderSupport)
+	}
+	return e, nil
+}
+
+// extras implements Extras.
+type extras struct {
+	p *ProviderClient
+}
+
+// applicationProperties describes the Bitbucket Server instance.
+type applicationProperties struct {
+	Version     string `json:"version"`
+	BuildNumber string `json:"buildNumber"`
+	DisplayName string `json:"displayName"`
+}
+
+func (e *extras) ServerVersion(ctx context.Context) (string, error) {
+	req, err := e.p.client.NewRequest(ctx, http.MethodGet, newURI(applicationPropertiesURI))
+	if err != nil {
+		return "", fmt.Errorf("get application properties request creation failed: %w", err)
+	}
+	res, _, err := e.p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("get application properties failed: %w", err)
+	}
+
+	props := &applicationProperties{}
+	if err := json.Unmarshal(res, props); err != nil {
+		return "", fmt.Errorf("get application properties failed, unable to unmarshal json: %w", err)
+	}
+	return props.Version, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/go-logr/logr"
+)
+
+func TestExtras_ServerVersion(t *testing.T) {
+	mux, client := setup(t)
+
+	mux.HandleFunc(fmt.Sprintf("%s/%s", stashURIprefix, applicationPropertiesURI), func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&applicationProperties{Version: "7.21.0", BuildNumber: "7021000", DisplayName: "Bitbucket"})
+	})
+
+	p := newClient(client, "stash.example.com", "token", false, logr.Discard())
+	if _, ok := p.Extension(ExtrasName).(Extras); !ok {
+		t.Fatalf("Extension(%q) returned %T, want Extras", ExtrasName, p.Extension(ExtrasName))
+	}
+	if ext := p.Extension("unknown"); ext != nil {
+		t.Errorf("Extension(%q) returned %v, want nil", "unknown", ext)
+	}
+
+	e, err := ExtrasFor(p)
+	if err != nil {
+		t.Fatalf("ExtrasFor returned error: %v", err)
+	}
+	version, err := e.ServerVersion(context.Background())
+	if err != nil {
+		t.Fatalf("ServerVersion returned error: %v", err)
+	}
+	if version != "7.21.0" {
+		t.Errorf("ServerVersion returned %q, want %q", version, "7.21.0")
+	}
+}
+
+func TestExtrasFor_OtherProvider(t *testing.T) {
+	_, client := setup(t)
+	p := newClient(client, "stash.example.com", "token", false, logr.Discard())
+
+	if _, err := ExtrasFor(otherProviderClient{p}); !errors.Is(err, gitprovider.ErrNoProviderSupport) {
+		t.Errorf("ExtrasFor returned error %v, want %v", err, gitprovider.ErrNoProviderSupport)
+	}
+}
+
+func TestExtrasFor_WrappedClient(t *testing.T) {
+	_, client := setup(t)
+	p := newClient(client, "stash.example.com", "token", false, logr.Discard())
+
+	// A wrapper of a Stash client reports the Stash provider ID, but isn't a *ProviderClient
+	if _, err := ExtrasFor(wrappedClient{p}); !errors.Is(err, gitprovider.ErrNoProviderSupport) {
+		t.Errorf("ExtrasFor returned error %v, want %v", err, gitprovider.ErrNoProviderSupport)
+	}
+}
+
+// wrappedClient wraps a client, e.g. to instrument it, and looks its extensions up itself.
+type wrappedClient struct {
+	gitprovider.Client
+}
+
+func (c wrappedClient) Extension(name string) interface{} {
+	return gitprovider.LookupExtension(c, name)
+}
+
+// otherProviderClient reports another provider ID, so no Stash extensions are found for it.
+type otherProviderClient struct {
+	*ProviderClient
+}
+
+func (otherProviderClient) ProviderID() gitprovider.ProviderID {
+	return "other"
+}
+
+func (c otherProviderClient) Extension(name string) interface{} {
+	return gitprovider.LookupExtension(c, name)
+}
@@ -17,12 +17,14 @@ limitations under the License.
 package stash
 
 import (
+	"archive/tar"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -34,7 +36,6 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/cache"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/storage/filesystem"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
@@ -135,6 +136,9 @@ type CreateCommit struct {
 	URL string `json:"url,omitempty"`
 	// Files is the list of files to commit.
 	Files []CommitFile `json:"files,omitempty"`
+	// Archive is a tar archive of more files to commit, streamed to the worktree after Files.
+	// Only its regular files are committed, with their executable bit.
+	Archive io.Reader `json:"-"`
 	// SigningKey denotes a key to sign the commit with. If not nil this key will
 	// be used to sign the commit. The private key must be present and already
 	// decrypted.
@@ -207,6 +211,17 @@ func WithFiles(files []CommitFile) GitCommitOptionsFunc {
 	}
 }
 
+// WithArchive is a currying function for the archive field
+func WithArchive(archive io.Reader) GitCommitOptionsFunc {
+	return func(c *CreateCommit) error {
+		if archive != nil {
+			c.Archive = archive
+			return nil
+		}
+		return errors.New("archive is required")
+	}
+}
+
 // WithSignature is a currying function for the signKey field
 func WithSignature(signKey *openpgp.Entity) GitCommitOptionsFunc {
 	return func(c *CreateCommit) error {
@@ -260,9 +275,14 @@ func (s *GitService) CreateCommit(rPath string, r *git.Repository, branchName st
 	if err != nil {
 		return nil, err
 	}
+	if c.Archive != nil {
+		if err := s.addArchiveFiles(w, rPath, c.Archive); err != nil {
+			return nil, err
+		}
+	}
 
 	// Set the committer & author DATE
-	now := time.Now().Unix()
+	now := s.Client.clock.Now().Unix()
 	c.Author.Date = now
 	if c.Committer != nil {
 		c.Committer.Date = now
@@ -311,6 +331,11 @@ func (s *GitService) CreateCommit(rPath string, r *git.Repository, branchName st
 // CloneRepository clones the repository at the given URL to the given path.
 // The repository will be cloned into a temporary directory which shall be clean up by the caller.
 func (s *GitService) CloneRepository(ctx context.Context, URL string) (r *git.Repository, dir string, err error) {
+	auth, err := s.Client.basicAuth()
+	if err != nil {
+		return nil, "", err
+	}
+
 	dir, err = os.MkdirTemp("", "repo-*")
 	if err != nil {
 		return nil, "", err
@@ -318,16 +343,17 @@ func (s *GitService) CloneRepository(ctx context.Context, URL string) (r *git.Re
 
 	r, err = git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
 		URL:      URL,
-		Auth:     &githttp.BasicAuth{Username: s.Client.username, Password: s.Client.token},
+		Auth:     auth,
 		CABundle: s.Client.caBundle,
 	})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to clone repository: %v", err)
+		os.RemoveAll(dir)
+		return nil, "", fmt.Errorf("failed to clone repository: %w", err)
 	}
 
 	err = r.Fetch(&git.FetchOptions{
 		RefSpecs: []config.RefSpec{"refs/*:refs/*", "HEAD:refs/heads/HEAD"},
-		Auth:     &githttp.BasicAuth{Username: s.Client.username, Password: s.Client.token},
+		Auth:     auth,
 		CABundle: s.Client.caBundle,
 	})
 
@@ -371,6 +397,56 @@ func writeCommitFile(file CommitFile, dir string) error {
 	return nil
 }
 
+// addArchiveFiles streams the regular files of the tar archive to the worktree, and adds them to
+// the staging area. The directories are created for the files, and other entries, e.g. symbolic
+// links, are skipped. The executable bit of the files is kept, the other permissions aren't
+// tracked by git.
+func (s *GitService) addArchiveFiles(w *git.Worktree, dir string, archive io.Reader) error {
+	tr := tar.NewReader(archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || name == ".git" || strings.HasPrefix(name, ".git/") {
+			return fmt.Errorf("invalid path %q in archive: %w", hdr.Name, gitprovider.ErrInvalidServerData)
+		}
+		mode := os.FileMode(0644)
+		if hdr.FileInfo().Mode()&0111 != 0 {
+			mode = 0755
+		}
+		if err := writeArchiveFile(filepath.Join(dir, filepath.FromSlash(name)), tr, mode); err != nil {
+			return err
+		}
+		if _, err := w.Add(name); err != nil {
+			return err
+		}
+	}
+}
+
+// writeArchiveFile writes the contents of r to filename, with mode.
+func writeArchiveFile(filename string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // Cleanup removes the temporary directory created for the repository.
 func (s *GitService) Cleanup(dir string) error {
 	err := os.RemoveAll(dir)
@@ -459,6 +535,12 @@ func (s *GitService) InitRepository(c *CreateCommit, createRemote bool) (r *git.
 	if err != nil {
 		return nil, "", err
 	}
+	if c.Archive != nil {
+		if err := s.addArchiveFiles(w, dir, c.Archive); err != nil {
+			os.RemoveAll(dir)
+			return nil, "", err
+		}
+	}
 
 	if createRemote {
 		rc := &config.RemoteConfig{Name: "origin", URLs: []string{c.URL}}
@@ -536,14 +618,18 @@ func (s *GitService) commit(w *git.Worktree, r *git.Repository, c *CreateCommit)
 
 // Push commits the current changes to the remote repository.
 func (s *GitService) Push(ctx context.Context, r *git.Repository) error {
+	auth, err := s.Client.basicAuth()
+	if err != nil {
+		return err
+	}
 
 	options := &git.PushOptions{
 		RemoteName: "origin",
-		Auth:       &githttp.BasicAuth{Username: s.Client.username, Password: s.Client.token},
+		Auth:       auth,
 		CABundle:   s.Client.caBundle,
 	}
 
-	err := r.PushContext(ctx, options)
+	err = r.PushContext(ctx, options)
 	if err != nil {
 		return fmt.Errorf("failed to push to remote: %w", err)
 	}
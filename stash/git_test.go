@@ -17,11 +17,19 @@ limitations under the License.
 package stash
 
 import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
 )
 
 func TestNewCommit(t *testing.T) {
@@ -218,3 +226,66 @@ func TestCreateCommit(t *testing.T) {
 		t.Errorf("Message mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestInitRepository_Archive(t *testing.T) {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	tw.WriteHeader(&tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0o755})
+	tw.WriteHeader(&tar.Header{Name: "bin/deploy.sh", Typeflag: tar.TypeReg, Mode: 0o755, Size: 10})
+	tw.Write([]byte("#!/bin/sh\n"))
+	tw.WriteHeader(&tar.Header{Name: "config.yaml", Typeflag: tar.TypeReg, Mode: 0o664, Size: 12})
+	tw.Write([]byte("replicas: 2\n"))
+	tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "config.yaml"})
+	tw.Close()
+
+	c, err := NewClient(nil, defaultHost, nil, initLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := NewCommit(
+		WithAuthor(&CommitAuthor{Name: "user1", Email: "user1@users.com"}),
+		WithMessage("initial commit from template"),
+		WithURL("https://stash.example.com/scm/prj1/repo1.git"),
+		WithArchive(&archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, dir, err := c.Git.InitRepository(commit, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Git.Cleanup(dir)
+
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := r.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := obj.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	modes := map[string]filemode.FileMode{}
+	tree.Files().ForEach(func(f *object.File) error {
+		modes[f.Name] = f.Mode
+		return nil
+	})
+	want := map[string]filemode.FileMode{"bin/deploy.sh": filemode.Executable, "config.yaml": filemode.Regular}
+	if !reflect.DeepEqual(modes, want) {
+		t.Errorf("committed files = %v, want %v", modes, want)
+	}
+
+	// Paths out of the worktree are refused
+	archive.Reset()
+	tw = tar.NewWriter(&archive)
+	tw.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Mode: 0o644})
+	tw.Close()
+	commit.Archive = &archive
+	if _, dir, err := c.Git.InitRepository(commit, false); !errors.Is(err, gitprovider.ErrInvalidServerData) {
+		c.Git.Cleanup(dir)
+		t.Errorf("InitRepository() of an archive with ../escape error = %v, want ErrInvalidServerData", err)
+	}
+}
@@ -89,7 +89,7 @@ func (s *GroupsService) List(ctx context.Context, opts *PagingOptions) (*GroupLi
 	g := &GroupList{
 		Groups: []*Group{},
 	}
-	if err := json.Unmarshal(res, g); err != nil {
+	if err := s.Client.unmarshalList(res, g); err != nil {
 		return nil, fmt.Errorf("list groups failed, unable to unmarshal repository list json: , %w", err)
 	}
 
@@ -178,7 +178,7 @@ func (s *GroupsService) ListGroupMembers(ctx context.Context, groupName string,
 		Users:     []*User{},
 	}
 
-	if err := json.Unmarshal(res, m); err != nil {
+	if err := s.Client.unmarshalList(res, m); err != nil {
 		return nil, fmt.Errorf("list group members failed, unable to unmarshal repository list json: , %w", err)
 	}
 
@@ -25,6 +25,7 @@ import (
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/fluxcd/go-git-providers/gitprovider/testutils"
+	"github.com/fluxcd/go-git-providers/gittestutils"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -63,7 +64,7 @@ var _ = Describe("Stash Provider", func() {
 
 		// Generate a repository name which doesn't exist already
 		testOrgRepoName = fmt.Sprintf("test-org-repo-%03d", rand.Intn(1000))
-		for findOrgRepo(repos, testOrgRepoName) != nil {
+		for gittestutils.FindOrgRepository(repos, testOrgRepoName) != nil {
 			testOrgRepoName = fmt.Sprintf("test-org-repo-%03d", rand.Intn(1000))
 		}
 
@@ -208,7 +209,7 @@ var _ = Describe("Stash Provider", func() {
 
 		// Generate an org repo name which doesn't exist already
 		testSharedOrgRepoName = fmt.Sprintf("test-shared-org-repo-%03d", rand.Intn(1000))
-		for findOrgRepo(repos, testSharedOrgRepoName) != nil {
+		for gittestutils.FindOrgRepository(repos, testSharedOrgRepoName) != nil {
 			testSharedOrgRepoName = fmt.Sprintf("test-shared-org-repo-%03d", rand.Intn(1000))
 		}
 
@@ -431,18 +432,6 @@ var _ = Describe("Stash Provider", func() {
 	})
 })
 
-func findOrgRepo(repos []gitprovider.OrgRepository, name string) gitprovider.OrgRepository {
-	if name == "" {
-		return nil
-	}
-	for _, repo := range repos {
-		if repo.Repository().GetRepository() == name {
-			return repo
-		}
-	}
-	return nil
-}
-
 func newOrgRepoRef(orgRef gitprovider.OrganizationRef, repoName string) gitprovider.OrgRepositoryRef {
 	return gitprovider.OrgRepositoryRef{
 		OrganizationRef: orgRef,
@@ -24,6 +24,7 @@ import (
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/fluxcd/go-git-providers/gitprovider/testutils"
+	"github.com/fluxcd/go-git-providers/gittestutils"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -57,7 +58,7 @@ var _ = Describe("Stash Provider", func() {
 
 		// Generate a repository name which doesn't exist already
 		testRepoName = fmt.Sprintf("test-user-repo-%03d", rand.Intn(1000))
-		for findUserRepo(repos, testRepoName) != nil {
+		for gittestutils.FindUserRepository(repos, testRepoName) != nil {
 			testRepoName = fmt.Sprintf("test-user-repo-%03d", rand.Intn(1000))
 		}
 
@@ -232,18 +233,6 @@ var _ = Describe("Stash Provider", func() {
 	})
 })
 
-func findUserRepo(repos []gitprovider.UserRepository, name string) gitprovider.UserRepository {
-	if name == "" {
-		return nil
-	}
-	for _, repo := range repos {
-		if repo.Repository().GetRepository() == name {
-			return repo
-		}
-	}
-	return nil
-}
-
 func newUserRepoRef(userLogin, repoName string) gitprovider.UserRepositoryRef {
 	return gitprovider.UserRepositoryRef{
 		UserRef:        newUserRef(userLogin),
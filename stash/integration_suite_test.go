@@ -22,7 +22,6 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
-	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -38,6 +37,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/fluxcd/go-git-providers/gittestutils"
 )
 
 const (
@@ -250,38 +250,11 @@ func newUserRef(userLogin string) gitprovider.UserRef {
 }
 
 func cleanupOrgRepos(ctx context.Context, prefix string) {
-	fmt.Printf("Deleting repos starting with %s in org: %s\n", prefix, testOrgName)
-	// Get the test organization
-	orgRef := newOrgRef(testOrgName)
-	testOrg, err := client.Organizations().Get(ctx, orgRef)
-	Expect(err).ToNot(HaveOccurred())
-	repos, err := client.OrgRepositories().List(ctx, testOrg.Organization())
-	Expect(err).ToNot(HaveOccurred())
-	for _, repo := range repos {
-		// Delete the test org repo used
-		name := repo.Repository().GetRepository()
-		slug := repo.Repository().(gitprovider.Slugger).Slug()
-		key := repo.Repository().(gitprovider.Keyer).Key()
-		if !strings.HasPrefix(name, prefix) {
-			continue
-		}
-		fmt.Printf("Deleting the %s organization's repository: %s with slug %s\n", key, name, slug)
-		repo.Delete(ctx)
-		Expect(repo.Delete(ctx)).To(Succeed())
-	}
+	fixture := gittestutils.NewFixture(client, newOrgRef(testOrgName), newUserRef(stashUser), "")
+	Expect(fixture.CleanupOrgRepositories(ctx, prefix)).To(Succeed())
 }
 
 func cleanupUserRepos(ctx context.Context, prefix string) {
-	fmt.Printf("Deleting repos starting with %s for user: %s\n", prefix, stashUser)
-	repos, err := client.UserRepositories().List(ctx, newUserRef(stashUser))
-	Expect(err).ToNot(HaveOccurred())
-	for _, repo := range repos {
-		// Delete the test org repo used
-		name := repo.Repository().GetRepository()
-		if !strings.HasPrefix(name, prefix) {
-			continue
-		}
-		fmt.Printf("Deleting the user repo: %s\n", name)
-		Expect(repo.Delete(ctx)).To(Succeed())
-	}
+	fixture := gittestutils.NewFixture(client, newOrgRef(testOrgName), newUserRef(stashUser), "")
+	Expect(fixture.CleanupUserRepositories(ctx, prefix)).To(Succeed())
 }
@@ -109,7 +109,7 @@ func (s *ProjectsService) List(ctx context.Context, opts *PagingOptions) (*Proje
 	p := &ProjectsList{
 		Projects: []*Project{},
 	}
-	if err := json.Unmarshal(res, p); err != nil {
+	if err := s.Client.unmarshalList(res, p); err != nil {
 		return nil, fmt.Errorf("list projects failed, unable to unmarshal repository list json: %w", err)
 	}
 
@@ -276,7 +276,7 @@ func (s *ProjectsService) ListProjectGroupsPermission(ctx context.Context, proje
 		ProjectKey: projectKey,
 		Groups:     []*ProjectGroupPermission{},
 	}
-	if err := json.Unmarshal(res, gp); err != nil {
+	if err := s.Client.unmarshalList(res, gp); err != nil {
 		return nil, fmt.Errorf("list project groups permission failed, unable to unmarshal project groups json: %w", err)
 	}
 
@@ -363,7 +363,7 @@ func (s *ProjectsService) ListProjectUsersPermission(ctx context.Context, projec
 		ProjectKey: projectKey,
 		Users:      []*ProjectUserPermission{},
 	}
-	if err := json.Unmarshal(res, up); err != nil {
+	if err := s.Client.unmarshalList(res, up); err != nil {
 		return nil, fmt.Errorf("list project users permission failed, unable to unmarshal repository list json: %w", err)
 	}
 
@@ -0,0 +1,239 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	activitiesURI = "activities"
+	commentsURI   = "comments"
+)
+
+// Comment is a comment on a pull request
+type Comment struct {
+	// Author is the author of the comment
+	Author User `json:"author,omitempty"`
+	// CreatedDate is the creation date of the comment
+	CreatedDate int64 `json:"createdDate,omitempty"`
+	// ID is the id of the comment
+	ID int64 `json:"id,omitempty"`
+	// Text is the text of the comment
+	Text string `json:"text,omitempty"`
+	// UpdatedDate is the update date of the comment
+	UpdatedDate int64 `json:"updatedDate,omitempty"`
+	// Version is the version of the comment, required to update or delete it
+	Version int `json:"version"`
+}
+
+// CommentAnchor is the location of a comment on the diff of a pull request
+type CommentAnchor struct {
+	// Line is the line of the comment
+	Line int `json:"line,omitempty"`
+	// Path is the path of the file of the comment
+	Path string `json:"path,omitempty"`
+}
+
+// Activity is an activity of a pull request, e.g. a comment being added
+type Activity struct {
+	// Action is the action of the activity, e.g. COMMENTED
+	Action string `json:"action,omitempty"`
+	// Comment is the comment of a COMMENTED activity
+	Comment *Comment `json:"comment,omitempty"`
+	// CommentAction is the action on the comment of a COMMENTED activity, e.g. ADDED
+	CommentAction string `json:"commentAction,omitempty"`
+	// CommentAnchor is set for comments on the diff
+	CommentAnchor *CommentAnchor `json:"commentAnchor,omitempty"`
+	// CreatedDate is the creation date of the activity
+	CreatedDate int64 `json:"createdDate,omitempty"`
+	// ID is the id of the activity
+	ID int64 `json:"id,omitempty"`
+}
+
+// ActivityList is a list of pull request activities
+type ActivityList struct {
+	// Paging is the paging information
+	Paging
+	// Activities are the activities
+	Activities []*Activity `json:"values,omitempty"`
+}
+
+// ListActivities returns the activities of a pull request, newest first.
+// Paging is optional and is enabled by providing a PagingOptions struct.
+// ListActivities uses the endpoint "GET /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/pull-requests/{pullRequestId}/activities".
+func (s *PullRequestsService) ListActivities(ctx context.Context, projectKey, repositorySlug string, prID int, opts *PagingOptions) (*ActivityList, error) {
+	query := addPaging(url.Values{}, opts)
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, pullRequestsURI, strconv.Itoa(prID), activitiesURI), WithQuery(query))
+	if err != nil {
+		return nil, fmt.Errorf("list pull request activities request creation failed: %w", err)
+	}
+	res, resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("list pull request activities failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	a := &ActivityList{}
+	if err := s.Client.unmarshalList(res, a); err != nil {
+		return nil, fmt.Errorf("list pull request activities failed, unable to unmarshal activity list json: %w", err)
+	}
+
+	return a, nil
+}
+
+// AllComments returns the general comments of a pull request, oldest first. Comments on the
+// diff and replies to comments are not included.
+// This function handles pagination, HTTP error wrapping, and validates the server result.
+func (s *PullRequestsService) AllComments(ctx context.Context, projectKey, repositorySlug string, prID int) ([]*Comment, error) {
+	comments := []*Comment{}
+	opts := &PagingOptions{Limit: perPageLimit}
+	err := allPages(opts, func() (*Paging, error) {
+		list, err := s.ListActivities(ctx, projectKey, repositorySlug, prID, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range list.Activities {
+			if a.Action == "COMMENTED" && a.CommentAction == "ADDED" && a.CommentAnchor == nil && a.Comment != nil {
+				comments = append(comments, a.Comment)
+			}
+		}
+		return &list.Paging, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The activities are listed newest first
+	for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+		comments[i], comments[j] = comments[j], comments[i]
+	}
+	return comments, nil
+}
+
+// GetComment retrieves a comment of a pull request given it's ID.
+// GetComment uses the endpoint "GET /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/pull-requests/{pullRequestId}/comments/{commentId}".
+func (s *PullRequestsService) GetComment(ctx context.Context, projectKey, repositorySlug string, prID int, commentID int64) (*Comment, error) {
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, pullRequestsURI, strconv.Itoa(prID), commentsURI, strconv.FormatInt(commentID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("get pull request comment request creation failed: %w", err)
+	}
+	res, resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get pull request comment failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	c := &Comment{}
+	if err := json.Unmarshal(res, c); err != nil {
+		return nil, fmt.Errorf("get pull request comment failed, unable to unmarshal comment json: %w", err)
+	}
+
+	return c, nil
+}
+
+// CreateComment adds a comment with the given text to a pull request.
+// CreateComment uses the endpoint "POST /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/pull-requests/{pullRequestId}/comments".
+func (s *PullRequestsService) CreateComment(ctx context.Context, projectKey, repositorySlug string, prID int, text string) (*Comment, error) {
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	body, err := marshallBody(&Comment{Text: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshall comment: %v", err)
+	}
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, pullRequestsURI, strconv.Itoa(prID), commentsURI), WithBody(body), WithHeader(header))
+	if err != nil {
+		return nil, fmt.Errorf("create pull request comment request creation failed: %w", err)
+	}
+	res, resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("create pull request comment failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	c := &Comment{}
+	if err := json.Unmarshal(res, c); err != nil {
+		return nil, fmt.Errorf("create pull request comment failed, unable to unmarshal comment json: %w", err)
+	}
+
+	return c, nil
+}
+
+// UpdateComment replaces the text of a comment of a pull request. The version of the comment must
+// be the current one.
+// UpdateComment uses the endpoint "PUT /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/pull-requests/{pullRequestId}/comments/{commentId}".
+func (s *PullRequestsService) UpdateComment(ctx context.Context, projectKey, repositorySlug string, prID int, comment *Comment) (*Comment, error) {
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	body, err := marshallBody(&Comment{Text: comment.Text, Version: comment.Version})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshall comment: %v", err)
+	}
+	req, err := s.Client.NewRequest(ctx, http.MethodPut, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, pullRequestsURI, strconv.Itoa(prID), commentsURI, strconv.FormatInt(comment.ID, 10)), WithBody(body), WithHeader(header))
+	if err != nil {
+		return nil, fmt.Errorf("update pull request comment request creation failed: %w", err)
+	}
+	res, resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("update pull request comment failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	c := &Comment{}
+	if err := json.Unmarshal(res, c); err != nil {
+		return nil, fmt.Errorf("update pull request comment failed, unable to unmarshal comment json: %w", err)
+	}
+
+	return c, nil
+}
+
+// DeleteComment deletes a comment of a pull request. The version of the comment must be the current one.
+// DeleteComment uses the endpoint "DELETE /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/pull-requests/{pullRequestId}/comments/{commentId}?version".
+func (s *PullRequestsService) DeleteComment(ctx context.Context, projectKey, repositorySlug string, prID int, commentID int64, version int) error {
+	query := url.Values{
+		"version": []string{strconv.Itoa(version)},
+	}
+	req, err := s.Client.NewRequest(ctx, http.MethodDelete, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, pullRequestsURI, strconv.Itoa(prID), commentsURI, strconv.FormatInt(commentID, 10)), WithQuery(query))
+	if err != nil {
+		return fmt.Errorf("delete pull request comment request creation failed: %w", err)
+	}
+	_, resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete pull request comment failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAllPRComments(t *testing.T) {
+	mux, client := setup(t)
+
+	path := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s/1/%s", stashURIprefix, projectsURI, RepositoriesURI, pullRequestsURI, activitiesURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		// The activities are listed newest first, over two pages
+		list := ActivityList{Paging: Paging{IsLastPage: true}}
+		switch r.URL.Query().Get("start") {
+		case "", "0":
+			list = ActivityList{
+				Paging: Paging{NextPageStart: 2},
+				Activities: []*Activity{
+					{Action: "COMMENTED", CommentAction: "ADDED", Comment: &Comment{ID: 3, Text: "diff", Version: 0}, CommentAnchor: &CommentAnchor{Path: "README.md", Line: 1}},
+					{Action: "COMMENTED", CommentAction: "ADDED", Comment: &Comment{ID: 2, Text: "second", Version: 1}},
+				},
+			}
+		case "2":
+			list.Activities = []*Activity{
+				{Action: "APPROVED"},
+				{Action: "COMMENTED", CommentAction: "ADDED", Comment: &Comment{ID: 1, Text: "first"}},
+			}
+		}
+		json.NewEncoder(w).Encode(list)
+	})
+
+	comments, err := client.PullRequests.AllComments(context.Background(), "prj1", "repo1", 1)
+	if err != nil {
+		t.Fatalf("PullRequests.AllComments returned error: %v", err)
+	}
+	want := []*Comment{{ID: 1, Text: "first"}, {ID: 2, Text: "second", Version: 1}}
+	if diff := cmp.Diff(want, comments); diff != "" {
+		t.Errorf("PullRequests.AllComments returned diff (want -> got):\n%s", diff)
+	}
+}
+
+func TestDeletePRComment(t *testing.T) {
+	mux, client := setup(t)
+
+	path := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s/1/%s/2", stashURIprefix, projectsURI, RepositoriesURI, pullRequestsURI, commentsURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Query().Get("version") != "3" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := client.PullRequests.DeleteComment(context.Background(), "prj1", "repo1", 1, 2, 3); err != nil {
+		t.Fatalf("PullRequests.DeleteComment returned error: %v", err)
+	}
+	if err := client.PullRequests.DeleteComment(context.Background(), "prj1", "repo1", 1, 4, 0); err != ErrNotFound {
+		t.Errorf("PullRequests.DeleteComment returned error %v, want ErrNotFound", err)
+	}
+}
@@ -28,6 +28,7 @@ import (
 const (
 	pullRequestsURI = "pull-requests"
 	mergeURI        = "merge"
+	declineURI      = "decline"
 )
 
 // PullRequests interface defines the methods that can be used to
@@ -39,7 +40,15 @@ type PullRequests interface {
 	Create(ctx context.Context, projectKey, repositorySlug string, pr *CreatePullRequest) (*PullRequest, error)
 	Update(ctx context.Context, projectKey, repositorySlug string, pr *PullRequest) (*PullRequest, error)
 	Merge(ctx context.Context, projectKey, repositorySlug string, prID int, version int) (*PullRequest, error)
+	MergeWithOptions(ctx context.Context, projectKey, repositorySlug string, prID int, version int, opts *MergeOptions) (*PullRequest, error)
+	Decline(ctx context.Context, projectKey, repositorySlug string, prID int, version int) (*PullRequest, error)
 	Delete(ctx context.Context, projectKey, repositorySlug string, IDVersion IDVersion) error
+	ListActivities(ctx context.Context, projectKey, repositorySlug string, prID int, opts *PagingOptions) (*ActivityList, error)
+	AllComments(ctx context.Context, projectKey, repositorySlug string, prID int) ([]*Comment, error)
+	GetComment(ctx context.Context, projectKey, repositorySlug string, prID int, commentID int64) (*Comment, error)
+	CreateComment(ctx context.Context, projectKey, repositorySlug string, prID int, text string) (*Comment, error)
+	UpdateComment(ctx context.Context, projectKey, repositorySlug string, prID int, comment *Comment) (*Comment, error)
+	DeleteComment(ctx context.Context, projectKey, repositorySlug string, prID int, commentID int64, version int) error
 }
 
 // PullRequestsService is a client for communicating with stash pull requests endpoint
@@ -192,7 +201,7 @@ func (s *PullRequestsService) List(ctx context.Context, projectKey, repositorySl
 	}
 
 	p := &PullRequestList{}
-	if err := json.Unmarshal(res, p); err != nil {
+	if err := s.Client.unmarshalList(res, p); err != nil {
 		return nil, fmt.Errorf("list pull requests failed, unable to unmarshal pull request list json: %w", err)
 	}
 
@@ -311,13 +320,41 @@ func (s *PullRequestsService) Update(ctx context.Context, projectKey, repository
 // Merge the pull request with the given ID and version.
 // Merge uses the endpoint "POST /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/pull-requests/{pullRequestId}/merge?version".
 func (s *PullRequestsService) Merge(ctx context.Context, projectKey, repositorySlug string, prID int, version int) (*PullRequest, error) {
+	return s.MergeWithOptions(ctx, projectKey, repositorySlug, prID, version, nil)
+}
+
+// MergeOptions are the optional parameters of a pull request merge.
+type MergeOptions struct {
+	// Message is the commit message of the merge commit, the server generates one if empty.
+	Message string `json:"message,omitempty"`
+	// StrategyID is the ID of the merge strategy, e.g. "no-ff", "squash" or "rebase-ff-only".
+	// The strategy must be enabled for the repository, the default strategy is used if empty.
+	StrategyID string `json:"strategyId,omitempty"`
+}
+
+// MergeWithOptions merges the pull request with the given ID and version, like Merge, using the
+// commit message and merge strategy of opts.
+// MergeWithOptions uses the endpoint "POST /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/pull-requests/{pullRequestId}/merge?version".
+// A 409 Conflict is returned by the server if the pull request is out of date, has conflicts or
+// the merge checks are not met.
+func (s *PullRequestsService) MergeWithOptions(ctx context.Context, projectKey, repositorySlug string, prID int, version int, opts *MergeOptions) (*PullRequest, error) {
 	query := url.Values{
 		"version": []string{strconv.Itoa(version)},
 	}
 
 	header := http.Header{"X-Atlassian-Token": []string{"no-check"}}
+	reqOpts := []RequestOptionFunc{WithQuery(query)}
+	if opts != nil {
+		body, err := marshallBody(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshall merge options: %w", err)
+		}
+		header.Set("Content-Type", "application/json")
+		reqOpts = append(reqOpts, WithBody(body))
+	}
+	reqOpts = append(reqOpts, WithHeader(header))
 
-	req, err := s.Client.NewRequest(ctx, http.MethodPost, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, pullRequestsURI, strconv.Itoa(prID), mergeURI), WithQuery(query), WithHeader(header))
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, pullRequestsURI, strconv.Itoa(prID), mergeURI), reqOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("merge pull request request creation failed: %w", err)
 	}
@@ -331,7 +368,7 @@ func (s *PullRequestsService) Merge(ctx context.Context, projectKey, repositoryS
 	}
 
 	if resp != nil && resp.StatusCode == http.StatusBadRequest {
-		return nil, fmt.Errorf("list commits failed: %s", resp.Status)
+		return nil, fmt.Errorf("merge pull request failed: %s", resp.Status)
 	}
 
 	p := &PullRequest{}
@@ -344,6 +381,43 @@ func (s *PullRequestsService) Merge(ctx context.Context, projectKey, repositoryS
 	return p, nil
 }
 
+// Decline declines the pull request with the given ID and version, closing it without merging.
+// Decline uses the endpoint "POST /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/pull-requests/{pullRequestId}/decline?version".
+// The authenticated user must have REPO_READ permission for the repository.
+func (s *PullRequestsService) Decline(ctx context.Context, projectKey, repositorySlug string, prID int, version int) (*PullRequest, error) {
+	query := url.Values{
+		"version": []string{strconv.Itoa(version)},
+	}
+
+	header := http.Header{"X-Atlassian-Token": []string{"no-check"}}
+
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, pullRequestsURI, strconv.Itoa(prID), declineURI), WithQuery(query), WithHeader(header))
+	if err != nil {
+		return nil, fmt.Errorf("decline pull request request creation failed: %w", err)
+	}
+	res, resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("decline pull request failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusBadRequest {
+		return nil, fmt.Errorf("decline pull request failed: %s", resp.Status)
+	}
+
+	p := &PullRequest{}
+	if err := json.Unmarshal(res, p); err != nil {
+		return nil, fmt.Errorf("decline pull request failed, unable to unmarshal pull request json: %w", err)
+	}
+
+	p.Session.set(resp)
+
+	return p, nil
+}
+
 // Delete deletes the pull request with the given ID
 // Delete uses the endpoint "DELETE /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/pull-requests/{pullRequestId}".
 // To call this resource, users must:
@@ -37,6 +37,8 @@ var (
 const (
 	// RepositoriesURI is the URI for the repositories endpoint
 	RepositoriesURI = "repos"
+	archiveURI      = "archive"
+	rawURI          = "raw"
 )
 
 // Repositories interface defines the operations for working with repositories.
@@ -53,6 +55,10 @@ type RepositoryManager interface {
 	Create(ctx context.Context, projectKey string, repository *Repository) (*Repository, error)
 	Update(ctx context.Context, projectKey, repositorySlug string, repository *Repository) (*Repository, error)
 	Delete(ctx context.Context, projectKey, repoSlug string) error
+	Archive(ctx context.Context, projectKey, repoSlug, at, format string) ([]byte, error)
+	ArchiveStream(ctx context.Context, projectKey, repoSlug, at, format string, header http.Header) (*http.Response, error)
+	Raw(ctx context.Context, projectKey, repoSlug, path, at string) ([]byte, error)
+	RawStream(ctx context.Context, projectKey, repoSlug, path, at string) (*http.Response, error)
 }
 
 // RepositoryPermissionManager interface defines the operations for working with repository permissions.
@@ -62,6 +68,9 @@ type RepositoryPermissionManager interface {
 	AllGroupsPermission(ctx context.Context, projectKey, repositorySlug string) ([]*RepositoryGroupPermission, error)
 	UpdateRepositoryGroupPermission(ctx context.Context, projectKey, repositorySlug string, permission *RepositoryGroupPermission) error
 	ListRepositoryUsersPermission(ctx context.Context, projectKey, repositorySlug string, opts *PagingOptions) (*RepositoryUsers, error)
+	AllUsersPermission(ctx context.Context, projectKey, repositorySlug string) ([]*RepositoryUserPermission, error)
+	UpdateRepositoryUserPermission(ctx context.Context, projectKey, repositorySlug string, permission *RepositoryUserPermission) error
+	RevokeRepositoryUserPermission(ctx context.Context, projectKey, repositorySlug, userName string) error
 }
 
 // RepositoriesService is a client for communicating with stash repositories endpoints
@@ -98,6 +107,8 @@ type Repository struct {
 	StatusMessage string `json:"statusMessage,omitempty"`
 	// DefaultBranch is the default branch of the repository.
 	DefaultBranch string `json:"defaultBranch,omitempty"`
+	// Origin is the repository this repository was forked from, if it's a fork.
+	Origin *Repository `json:"origin,omitempty"`
 }
 
 // RepositoryList is a list of repositories
@@ -139,7 +150,7 @@ func (s *RepositoriesService) List(ctx context.Context, projectKey string, opts
 		Repositories: []*Repository{},
 	}
 
-	if err := json.Unmarshal(res, repos); err != nil {
+	if err := s.Client.unmarshalList(res, repos); err != nil {
 		return nil, fmt.Errorf("list repositories failed, unable to unmarshal repository list json: %w", err)
 	}
 
@@ -294,6 +305,110 @@ func (s *RepositoriesService) Delete(ctx context.Context, projectKey, repoSlug s
 	return nil
 }
 
+// Archive returns an archive of the repository at the given commit, branch or tag.
+// If at is empty, the archive of the default branch is returned.
+// format is one of "zip", "tar", "tar.gz" or "tgz".
+// Archive uses the endpoint "GET /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/archive?at&format".
+func (s *RepositoriesService) Archive(ctx context.Context, projectKey, repoSlug, at, format string) ([]byte, error) {
+	query := url.Values{}
+	if at != "" {
+		query.Add("at", at)
+	}
+	if format != "" {
+		query.Add("format", format)
+	}
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, newURI(projectsURI, projectKey, RepositoriesURI, repoSlug, archiveURI), WithQuery(query))
+	if err != nil {
+		return nil, fmt.Errorf("archive repository request creation failed: %w", err)
+	}
+	res, resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("archive repository failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusBadRequest {
+		return nil, fmt.Errorf("archive repository failed: %s", resp.Status)
+	}
+
+	return res, nil
+}
+
+// ArchiveStream is like Archive, but returns the response with its body unread, for streaming large archives.
+// The given header is added to the request, e.g. to request a range of the archive.
+// The caller is responsible for closing the response body.
+// ArchiveStream uses the endpoint "GET /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/archive?at&format".
+func (s *RepositoriesService) ArchiveStream(ctx context.Context, projectKey, repoSlug, at, format string, header http.Header) (*http.Response, error) {
+	query := url.Values{}
+	if at != "" {
+		query.Add("at", at)
+	}
+	if format != "" {
+		query.Add("format", format)
+	}
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, newURI(projectsURI, projectKey, RepositoriesURI, repoSlug, archiveURI), WithQuery(query), WithHeader(header))
+	if err != nil {
+		return nil, fmt.Errorf("archive repository request creation failed: %w", err)
+	}
+	resp, err := s.Client.DoStream(req)
+	if err != nil {
+		return nil, fmt.Errorf("archive repository failed: %w", err)
+	}
+
+	return resp, nil
+}
+
+// Raw returns the raw content of the file at the given path, at the given commit, branch or tag.
+// If at is empty, the file is read from the default branch.
+// Raw uses the endpoint "GET /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/raw/{path}?at".
+func (s *RepositoriesService) Raw(ctx context.Context, projectKey, repoSlug, path, at string) ([]byte, error) {
+	query := url.Values{}
+	if at != "" {
+		query.Add("at", at)
+	}
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, newURI(projectsURI, projectKey, RepositoriesURI, repoSlug, rawURI, path), WithQuery(query))
+	if err != nil {
+		return nil, fmt.Errorf("get raw file request creation failed: %w", err)
+	}
+	res, resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get raw file failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusBadRequest {
+		return nil, fmt.Errorf("get raw file failed: %s", resp.Status)
+	}
+
+	return res, nil
+}
+
+// RawStream is like Raw, but returns the response with its body unread, for streaming large files.
+// The caller is responsible for closing the response body.
+// RawStream uses the endpoint "GET /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/raw/{path}?at".
+func (s *RepositoriesService) RawStream(ctx context.Context, projectKey, repoSlug, path, at string) (*http.Response, error) {
+	query := url.Values{}
+	if at != "" {
+		query.Add("at", at)
+	}
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, newURI(projectsURI, projectKey, RepositoriesURI, repoSlug, rawURI, path), WithQuery(query))
+	if err != nil {
+		return nil, fmt.Errorf("get raw file request creation failed: %w", err)
+	}
+	resp, err := s.Client.DoStream(req)
+	if err != nil {
+		return nil, fmt.Errorf("get raw file failed: %w", err)
+	}
+
+	return resp, nil
+}
+
 // RepositoryGroupPermission is a permission for a given group.
 // Repository permissions allow you to manage access to a repository
 // beyond that already granted from project permissions.
@@ -382,7 +497,7 @@ func (s *RepositoriesService) ListRepositoryGroupsPermission(ctx context.Context
 	}
 
 	perms := &RepositoryGroups{}
-	if err := json.Unmarshal(res, perms); err != nil {
+	if err := s.Client.unmarshalList(res, perms); err != nil {
 		return nil, fmt.Errorf("list groups permissions for repository failed, unable to unmarshall repository json: %w", err)
 	}
 
@@ -485,7 +600,7 @@ func (s *RepositoriesService) ListRepositoryUsersPermission(ctx context.Context,
 	}
 
 	users := &RepositoryUsers{}
-	if err := json.Unmarshal(res, users); err != nil {
+	if err := s.Client.unmarshalList(res, users); err != nil {
 		return nil, fmt.Errorf("list users permissions for repository failed, unable to unmarshall json: %w", err)
 	}
 
@@ -495,3 +610,72 @@ func (s *RepositoriesService) ListRepositoryUsersPermission(ctx context.Context,
 
 	return users, nil
 }
+
+// AllUsersPermission retrieves all repository users permission.
+// This function handles pagination, HTTP error wrapping, and validates the server result.
+func (s *RepositoriesService) AllUsersPermission(ctx context.Context, projectKey, repositorySlug string) ([]*RepositoryUserPermission, error) {
+	p := []*RepositoryUserPermission{}
+	opts := &PagingOptions{Limit: perPageLimit}
+	err := allPages(opts, func() (*Paging, error) {
+		list, err := s.ListRepositoryUsersPermission(ctx, projectKey, repositorySlug, opts)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, list.GetUsers()...)
+		return &list.Paging, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// UpdateRepositoryUserPermission Promote or demote a user's permission level for the specified repository.
+// UpdateRepositoryUserPermission uses the endpoint "PUT /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/permissions/users?permission&name".
+func (s *RepositoriesService) UpdateRepositoryUserPermission(ctx context.Context, projectKey, repositorySlug string, permission *RepositoryUserPermission) error {
+	query := url.Values{
+		"name":       []string{permission.User.Name},
+		"permission": []string{permission.Permission},
+	}
+	req, err := s.Client.NewRequest(ctx, http.MethodPut, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, userPermisionsURI), WithQuery(query))
+	if err != nil {
+		return fmt.Errorf("add user permissions request creation failed: %w", err)
+	}
+	_, resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("add user permissions to repository failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusBadRequest {
+		return fmt.Errorf("add user permissions to repository failed: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// RevokeRepositoryUserPermission revokes all permissions for the specified repository from a user.
+// RevokeRepositoryUserPermission uses the endpoint "DELETE /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}/permissions/users?name".
+func (s *RepositoriesService) RevokeRepositoryUserPermission(ctx context.Context, projectKey, repositorySlug, userName string) error {
+	query := url.Values{
+		"name": []string{userName},
+	}
+	req, err := s.Client.NewRequest(ctx, http.MethodDelete, newURI(projectsURI, projectKey, RepositoriesURI, repositorySlug, userPermisionsURI), WithQuery(query))
+	if err != nil {
+		return fmt.Errorf("revoke user permissions request creation failed: %w", err)
+	}
+	_, resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("revoke user permissions from repository failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -19,11 +19,14 @@ package stash
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -87,6 +90,52 @@ func TestGetRepository(t *testing.T) {
 	}
 }
 
+func TestGetRepositoryEscaping(t *testing.T) {
+	tests := []struct {
+		name           string
+		projectKey     string
+		repositorySlug string
+		wantRawPath    string
+	}{
+		{
+			name:           "unicode user repository",
+			projectKey:     "~jöhn",
+			repositorySlug: "répo-日本",
+			wantRawPath:    "/rest/api/1.0/projects/~j%C3%B6hn/repos/r%C3%A9po-%E6%97%A5%E6%9C%AC",
+		},
+		{
+			name:           "reserved characters",
+			projectKey:     "prj",
+			repositorySlug: "foo#bar?baz",
+			wantRawPath:    "/rest/api/1.0/projects/prj/repos/foo%23bar%3Fbaz",
+		},
+	}
+
+	mux, client := setup(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := fmt.Sprintf("%s/%s/%s/%s/%s", stashURIprefix, projectsURI, tt.projectKey, RepositoriesURI, tt.repositorySlug)
+			mux.HandleFunc(p, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != tt.wantRawPath {
+					t.Errorf("request path = %q, want %q", r.URL.EscapedPath(), tt.wantRawPath)
+				}
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(&Repository{Slug: tt.repositorySlug})
+			})
+			ctx := context.Background()
+			r, err := client.Repositories.Get(ctx, tt.projectKey, tt.repositorySlug)
+			if err != nil {
+				t.Fatalf("Repositories.Get returned error: %v", err)
+			}
+
+			if diff := cmp.Diff(&Repository{Slug: tt.repositorySlug}, r); diff != "" {
+				t.Fatalf("Repositories.Get returned diff (want -> got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestListRepositories(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -660,3 +709,149 @@ func TestListRepositoryUsersPermission(t *testing.T) {
 	}
 
 }
+
+func TestUpdateAndRevokeRepositoryUserPermission(t *testing.T) {
+	mux, client := setup(t)
+
+	var requests []string
+	path := fmt.Sprintf("%s/%s/testProject/%s/repo1/%s", stashURIprefix, projectsURI, RepositoriesURI, userPermisionsURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, fmt.Sprintf("%s %s", r.Method, r.URL.RawQuery))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ctx := context.Background()
+	err := client.Repositories.UpdateRepositoryUserPermission(ctx, "testProject", "repo1", &RepositoryUserPermission{
+		User:       User{Name: "jcitizen"},
+		Permission: stashPermissionWrite,
+	})
+	if err != nil {
+		t.Fatalf("Repositories.UpdateRepositoryUserPermission returned error: %v", err)
+	}
+	if err := client.Repositories.RevokeRepositoryUserPermission(ctx, "testProject", "repo1", "jcitizen"); err != nil {
+		t.Fatalf("Repositories.RevokeRepositoryUserPermission returned error: %v", err)
+	}
+
+	want := []string{"PUT name=jcitizen&permission=REPO_WRITE", "DELETE name=jcitizen"}
+	if diff := cmp.Diff(want, requests); diff != "" {
+		t.Errorf("requests (want -> got): %s", diff)
+	}
+}
+
+func TestArchiveRepository(t *testing.T) {
+	mux, client := setup(t)
+
+	path := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s", stashURIprefix, projectsURI, RepositoriesURI, archiveURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("at") != "refs/heads/main" {
+			http.Error(w, "The specified ref does not exist", http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("format") != "zip" {
+			t.Errorf("unexpected format %q, want %q", r.URL.Query().Get("format"), "zip")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("archive-content"))
+	})
+
+	ctx := context.Background()
+	b, err := client.Repositories.Archive(ctx, "prj1", "repo1", "refs/heads/main", "zip")
+	if err != nil {
+		t.Fatalf("Repositories.Archive returned error: %v", err)
+	}
+	if string(b) != "archive-content" {
+		t.Errorf("Repositories.Archive returned %q, want %q", string(b), "archive-content")
+	}
+
+	if _, err := client.Repositories.Archive(ctx, "prj1", "repo1", "refs/heads/unknown", "zip"); err != ErrNotFound {
+		t.Fatalf("Repositories.Archive returned error: %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestArchiveRepositoryStream(t *testing.T) {
+	mux, client := setup(t)
+
+	path := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s", stashURIprefix, projectsURI, RepositoriesURI, archiveURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("at") != "refs/heads/main" {
+			http.Error(w, "The specified ref does not exist", http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, "archive.zip", time.Time{}, strings.NewReader("archive-content"))
+	})
+
+	ctx := context.Background()
+	resp, err := client.Repositories.ArchiveStream(ctx, "prj1", "repo1", "refs/heads/main", "zip", http.Header{"Range": []string{"bytes=8-"}})
+	if err != nil {
+		t.Fatalf("Repositories.ArchiveStream returned error: %v", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusPartialContent || string(b) != "content" {
+		t.Errorf("Repositories.ArchiveStream returned %s %q, want %s %q", resp.Status, string(b), "206 Partial Content", "content")
+	}
+
+	if _, err := client.Repositories.ArchiveStream(ctx, "prj1", "repo1", "refs/heads/unknown", "zip", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Repositories.ArchiveStream returned error: %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestRawFile(t *testing.T) {
+	mux, client := setup(t)
+
+	path := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s/dir/file.txt", stashURIprefix, projectsURI, RepositoriesURI, rawURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("at") != "v1.0.0" {
+			http.Error(w, "The specified ref does not exist", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("file-content"))
+	})
+
+	ctx := context.Background()
+	b, err := client.Repositories.Raw(ctx, "prj1", "repo1", "dir/file.txt", "v1.0.0")
+	if err != nil {
+		t.Fatalf("Repositories.Raw returned error: %v", err)
+	}
+	if string(b) != "file-content" {
+		t.Errorf("Repositories.Raw returned %q, want %q", string(b), "file-content")
+	}
+
+	if _, err := client.Repositories.Raw(ctx, "prj1", "repo1", "dir/file.txt", "unknown"); err != ErrNotFound {
+		t.Fatalf("Repositories.Raw returned error: %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestRawFileStream(t *testing.T) {
+	mux, client := setup(t)
+
+	path := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s/dir/file.txt", stashURIprefix, projectsURI, RepositoriesURI, rawURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("at") != "v1.0.0" {
+			http.Error(w, "The specified ref does not exist", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("file-content"))
+	})
+
+	ctx := context.Background()
+	resp, err := client.Repositories.RawStream(ctx, "prj1", "repo1", "dir/file.txt", "v1.0.0")
+	if err != nil {
+		t.Fatalf("Repositories.RawStream returned error: %v", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "file-content" {
+		t.Errorf("Repositories.RawStream returned %q, want %q", string(b), "file-content")
+	}
+
+	if _, err := client.Repositories.RawStream(ctx, "prj1", "repo1", "dir/file.txt", "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Repositories.RawStream returned error: %v, want %v", err, ErrNotFound)
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+func newBranch(apiObj *Branch) *branch {
+	return &branch{
+		b: *apiObj,
+	}
+}
+
+var _ gitprovider.Branch = &branch{}
+
+type branch struct {
+	b Branch
+}
+
+// Get returns high-level information about this branch.
+// The protection status is not reported, as stash manages it through branch restrictions.
+func (b *branch) Get() gitprovider.BranchInfo {
+	return gitprovider.BranchInfo{
+		Name:    b.b.DisplayID,
+		Sha:     b.b.LatestCommit,
+		Default: b.b.IsDefault,
+	}
+}
+
+func (b *branch) APIObject() interface{} {
+	return &b.b
+}
@@ -22,20 +22,27 @@ import (
 	"github.com/fluxcd/go-git-providers/gitprovider"
 )
 
-func newCommit(commit *CommitObject) *commitType {
+func newCommit(c *CommitClient, commit *CommitObject) *commitType {
 	return &commitType{
-		k: *commit,
+		k:   *commit,
+		ref: c.ref,
 	}
 }
 
 var _ gitprovider.Commit = &commitType{}
 
 type commitType struct {
-	k CommitObject
+	k   CommitObject
+	ref gitprovider.RepositoryRef
 }
 
 func (c *commitType) Get() gitprovider.CommitInfo {
-	return commitFromAPI(c.k)
+	info := commitFromAPI(c.k)
+	// Stash doesn't return the web URL of commits
+	if urls, err := gitprovider.NewWebURLs(ProviderID, c.ref); err == nil {
+		info.URL = urls.Commit(info.Sha)
+	}
+	return info
 }
 
 func (c *commitType) APIObject() interface{} {
@@ -43,11 +50,22 @@ func (c *commitType) APIObject() interface{} {
 }
 
 func commitFromAPI(commit CommitObject) gitprovider.CommitInfo {
-	t := time.Unix(commit.AuthorTimestamp, 0)
-	return gitprovider.CommitInfo{
-		Sha:       commit.ID,
-		Author:    commit.Author.Name,
-		Message:   commit.Message,
-		CreatedAt: t,
+	// The author timestamp is in milliseconds since the epoch
+	t := time.UnixMilli(commit.AuthorTimestamp)
+	info := gitprovider.CommitInfo{
+		Sha:            commit.ID,
+		Author:         commit.Author.Name,
+		AuthorEmail:    commit.Author.EmailAddress,
+		Committer:      commit.Committer.Name,
+		CommitterEmail: commit.Committer.EmailAddress,
+		Message:        commit.Message,
+		CreatedAt:      t,
+	}
+	if commit.CommitterTimestamp != 0 {
+		info.CommittedAt = time.UnixMilli(commit.CommitterTimestamp)
+	}
+	for _, parent := range commit.Parents {
+		info.Parents = append(info.Parents, parent.ID)
 	}
+	return info
 }
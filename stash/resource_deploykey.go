@@ -29,8 +29,9 @@ import (
 
 func newDeployKey(c *DeployKeyClient, key *DeployKey) *deployKey {
 	return &deployKey{
-		k: *key,
-		c: c,
+		k:    *key,
+		read: deployKeyFromAPI(key),
+		c:    c,
 	}
 }
 
@@ -38,7 +39,10 @@ var _ gitprovider.DeployKey = &deployKey{}
 
 type deployKey struct {
 	k DeployKey
-	c *DeployKeyClient
+	// read is the state of the deploy key when it was last read from the server, checked by
+	// Update with optimistic concurrency
+	read gitprovider.DeployKeyInfo
+	c    *DeployKeyClient
 }
 
 func (dk *deployKey) Get() gitprovider.DeployKeyInfo {
@@ -63,6 +67,19 @@ func (dk *deployKey) Repository() gitprovider.RepositoryRef {
 	return dk.c.ref
 }
 
+// Timestamps returns the generation of the deploy key. Stash doesn't report when deploy keys
+// were created.
+func (dk *deployKey) Timestamps() gitprovider.Timestamps {
+	key := fmt.Sprintf("%s/keys/%d", dk.c.ref.String(), dk.k.Key.ID)
+	// The sessions differ for every request
+	apiObj := dk.k
+	apiObj.Session = Session{}
+	apiObj.Repository.Session = Session{}
+	return gitprovider.Timestamps{
+		Generation: dk.c.generations.Observe(key, gitprovider.ObjectVersion(nil, &apiObj)),
+	}
+}
+
 // Update will apply the desired state in this object to the server.
 // Only set fields will be respected (i.e. PATCH behaviour).
 // In order to apply changes to this object, use the .Set({Resource}Info) error
@@ -72,22 +89,36 @@ func (dk *deployKey) Repository() gitprovider.RepositoryRef {
 // ErrNotFound is returned if the resource does not exist.
 //
 // The internal API object will be overridden with the received server data.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the deploy key was
+// changed since it was read.
 func (dk *deployKey) Update(ctx context.Context) error {
+	if dk.c.optimisticConcurrency {
+		actual, err := dk.c.Get(ctx, dk.read.Name)
+		if err != nil {
+			return err
+		}
+		object := fmt.Sprintf("%s/keys/%s", dk.c.ref.String(), dk.read.Name)
+		if err := gitprovider.CheckUnchanged(object, dk.read, actual.Get()); err != nil {
+			return err
+		}
+	}
 	// update by calling client
-	apiObj, err := dk.c.update(ctx, dk.Get())
+	apiObj, err := dk.c.update(ctx, &dk.k, dk.Get())
 	if err != nil {
 		// Log the error and return it
 		dk.c.log.V(1).Error(err, "failed to update deploy key", "org", dk.Repository().GetIdentity(), "repo", dk.Repository().GetRepository())
 		return err
 	}
 	dk.k = *apiObj
+	dk.read = deployKeyFromAPI(apiObj)
 	return nil
 }
 
 // Delete deletes a deploy key from the repository.
 // ErrNotFound is returned if the resource does not exist.
 func (dk *deployKey) Delete(ctx context.Context) error {
-	return dk.c.delete(ctx, dk.Get())
+	return dk.c.delete(ctx, &dk.k)
 }
 
 // Reconcile makes sure the desired state in this object (called "req" here) becomes
@@ -17,18 +17,23 @@ limitations under the License.
 package stash
 
 import (
+	"time"
+
 	"github.com/fluxcd/go-git-providers/gitprovider"
 )
 
-func newPullRequest(apiObj *PullRequest) *pullrequest {
+func newPullRequest(ctx *clientContext, apiObj *PullRequest) *pullrequest {
 	return &pullrequest{
-		pr: *apiObj,
+		clientContext: ctx,
+		pr:            *apiObj,
 	}
 }
 
 var _ gitprovider.PullRequest = &pullrequest{}
 
 type pullrequest struct {
+	*clientContext
+
 	pr PullRequest
 }
 
@@ -40,12 +45,58 @@ func (pr *pullrequest) APIObject() interface{} {
 	return &pr.pr
 }
 
+func (pr *pullrequest) Timestamps() gitprovider.Timestamps {
+	ts := gitprovider.Timestamps{}
+	if pr.pr.CreatedDate != 0 {
+		createdAt := time.UnixMilli(pr.pr.CreatedDate)
+		ts.CreatedAt = &createdAt
+	}
+	if pr.pr.UpdatedDate != 0 {
+		updatedAt := time.UnixMilli(pr.pr.UpdatedDate)
+		ts.UpdatedAt = &updatedAt
+	}
+	// The session differs for every request
+	apiObj := pr.pr
+	apiObj.Session = Session{}
+	ts.Generation = pr.generations.Observe(getSelfref(pr.pr.Self), gitprovider.ObjectVersion(ts.UpdatedAt, &apiObj))
+	return ts
+}
+
 func pullrequestFromAPI(apiObj *PullRequest) gitprovider.PullRequestInfo {
 	return gitprovider.PullRequestInfo{
+		Number: apiObj.ID,
+		Merged: apiObj.State == "MERGED",
+		Closed: apiObj.State == "DECLINED",
 		WebURL: getSelfref(apiObj.Self),
 	}
 }
 
+func newPullRequestComment(apiObj *Comment) *pullRequestComment {
+	return &pullRequestComment{
+		c: *apiObj,
+	}
+}
+
+var _ gitprovider.PullRequestComment = &pullRequestComment{}
+
+type pullRequestComment struct {
+	c Comment
+}
+
+func (c *pullRequestComment) Get() gitprovider.PullRequestCommentInfo {
+	return gitprovider.PullRequestCommentInfo{
+		ID:     c.c.ID,
+		Body:   c.c.Text,
+		Author: c.c.Author.Name,
+		// The dates are in milliseconds since the epoch
+		CreatedAt: time.Unix(0, c.c.CreatedDate*int64(time.Millisecond)),
+	}
+}
+
+func (c *pullRequestComment) APIObject() interface{} {
+	return &c.c
+}
+
 func getSelfref(selves []Self) string {
 	if len(selves) == 0 {
 		return "no http ref found"
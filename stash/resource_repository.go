@@ -18,7 +18,9 @@ package stash
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 )
@@ -31,6 +33,7 @@ func newUserRepository(ctx *clientContext, apiObj *Repository, ref gitprovider.R
 			clientContext: ctx,
 		},
 		repository: *apiObj,
+		read:       repositoryFromAPI(apiObj),
 		ref:        ref,
 		deployKeys: &DeployKeyClient{
 			clientContext: ctx,
@@ -52,20 +55,63 @@ func newUserRepository(ctx *clientContext, apiObj *Repository, ref gitprovider.R
 			clientContext: ctx,
 			ref:           ref,
 		},
+		milestones: &MilestoneClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		mergeQueue: &MergeQueueClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		collaborators: &CollaboratorClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		reactions: &ReactionClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		environments: &EnvironmentClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		badges: &BadgeClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		tagProtection: &TagProtectionClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		approvalRules: &ApprovalRuleClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
 	}
 }
 
 var _ gitprovider.UserRepository = &userRepository{}
 
 type userRepository struct {
-	repository   Repository
-	ref          gitprovider.RepositoryRef
-	c            *UserRepositoriesClient
-	deployKeys   *DeployKeyClient
-	branches     *BranchClient
-	pullRequests *PullRequestClient
-	commits      *CommitClient
-	files        *FileClient
+	repository Repository
+	// read is the state of the repository when it was last read from the server, checked by
+	// Update with optimistic concurrency
+	read          gitprovider.RepositoryInfo
+	ref           gitprovider.RepositoryRef
+	c             *UserRepositoriesClient
+	deployKeys    *DeployKeyClient
+	branches      *BranchClient
+	pullRequests  *PullRequestClient
+	commits       *CommitClient
+	files         *FileClient
+	milestones    *MilestoneClient
+	mergeQueue    *MergeQueueClient
+	collaborators *CollaboratorClient
+	reactions     *ReactionClient
+	environments  *EnvironmentClient
+	badges        *BadgeClient
+	tagProtection *TagProtectionClient
+	approvalRules *ApprovalRuleClient
 }
 
 func (r *userRepository) Branches() gitprovider.BranchClient {
@@ -84,14 +130,60 @@ func (r *userRepository) Files() gitprovider.FileClient {
 	return r.files
 }
 
+func (r *userRepository) Milestones() gitprovider.MilestoneClient {
+	return r.milestones
+}
+
+func (r *userRepository) MergeQueue() gitprovider.MergeQueueClient {
+	return r.mergeQueue
+}
+
+func (r *userRepository) Collaborators() gitprovider.CollaboratorClient {
+	return r.collaborators
+}
+
+func (r *userRepository) Reactions() gitprovider.ReactionClient {
+	return r.reactions
+}
+
+func (r *userRepository) Environments() gitprovider.EnvironmentClient {
+	return r.environments
+}
+
+func (r *userRepository) Badges() gitprovider.BadgeClient {
+	return r.badges
+}
+
+func (r *userRepository) TagProtection() gitprovider.TagProtectionClient {
+	return r.tagProtection
+}
+
+func (r *userRepository) ApprovalRules() gitprovider.ApprovalRuleClient {
+	return r.approvalRules
+}
+
 func (r *userRepository) Get() gitprovider.RepositoryInfo {
-	return repositoryFromAPI(&r.repository)
+	info := repositoryFromAPI(&r.repository)
+	if r.repository.Origin != nil {
+		info.Fork = &gitprovider.RepositoryFork{Parent: originRef(r.c.host, r.repository.Origin)}
+	}
+	return info
 }
 
 func (r *userRepository) Set(info gitprovider.RepositoryInfo) error {
 	if err := info.ValidateInfo(); err != nil {
 		return err
 	}
+	if len(info.Topics) > 0 {
+		return fmt.Errorf("repository topics: %w", gitprovider.ErrNoProviderSupport)
+	}
+	if info.MergeMethods != nil || info.DeleteBranchOnMerge != nil {
+		return fmt.Errorf("repository merge settings: %w", gitprovider.ErrNoProviderSupport)
+	}
+	// The repositories are never archived, as far as this client knows
+	if info.Archived != nil && *info.Archived {
+		return fmt.Errorf("archived repositories: %w", gitprovider.ErrNoProviderSupport)
+	}
 	repositoryInfoToAPIObj(&info, &r.repository)
 	return nil
 }
@@ -104,14 +196,31 @@ func (r *userRepository) Repository() gitprovider.RepositoryRef {
 	return r.ref
 }
 
+// Timestamps returns the generation of the repository. Stash doesn't report when repositories
+// were created or updated.
+func (r *userRepository) Timestamps() gitprovider.Timestamps {
+	// The session differs for every request
+	apiObj := r.repository
+	apiObj.Session = Session{}
+	return gitprovider.Timestamps{
+		Generation: r.c.generations.Observe(r.ref.String(), gitprovider.ObjectVersion(nil, &apiObj)),
+	}
+}
+
 func (r *userRepository) DeployKeys() gitprovider.DeployKeyClient {
 	return r.deployKeys
 }
 
 // The internal API object will be overridden with the received server data.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the repository was
+// changed since it was read.
 func (r *userRepository) Update(ctx context.Context) error {
-	// update by calling client
 	ref := r.ref.(gitprovider.UserRepositoryRef)
+	if err := r.checkUnchanged(ctx, addTilde(ref.UserLogin), ref.Slug()); err != nil {
+		return err
+	}
+	// update by calling client
 	apiObj, err := update(ctx, r.c.client, addTilde(ref.UserLogin), ref.Slug(), &r.repository, "")
 	if err != nil {
 		// Log the error and return it
@@ -122,10 +231,27 @@ func (r *userRepository) Update(ctx context.Context) error {
 	}
 
 	r.repository = *apiObj
+	r.read = repositoryFromAPI(apiObj)
 
 	return nil
 }
 
+// checkUnchanged returns a *gitprovider.ConflictError if optimistic concurrency is enabled, and
+// the repository was changed since it was read.
+func (r *userRepository) checkUnchanged(ctx context.Context, projectKey, repoSlug string) error {
+	if !r.c.optimisticConcurrency {
+		return nil
+	}
+	actual, err := r.c.client.Repositories.Get(ctx, projectKey, repoSlug)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return gitprovider.ErrNotFound
+		}
+		return fmt.Errorf("failed to get repository %s/%s: %w", projectKey, repoSlug, err)
+	}
+	return gitprovider.CheckUnchanged(r.ref.String(), r.read, repositoryFromAPI(actual))
+}
+
 // Reconcile makes sure the desired state in this object (called "req" here) becomes
 // the actual state in the backing Git provider.
 
@@ -225,8 +351,14 @@ func (r *orgRepository) Reconcile(ctx context.Context) (bool, error) {
 }
 
 // The internal API object will be overridden with the received server data.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the repository was
+// changed since it was read.
 func (r *orgRepository) Update(ctx context.Context) error {
 	ref := r.ref.(gitprovider.OrgRepositoryRef)
+	if err := r.checkUnchanged(ctx, ref.Key(), ref.Slug()); err != nil {
+		return err
+	}
 	// update by calling client
 	apiObj, err := update(ctx, r.c.client, ref.Key(), ref.Slug(), &r.repository, "")
 	if err != nil {
@@ -238,6 +370,7 @@ func (r *orgRepository) Update(ctx context.Context) error {
 	}
 
 	r.repository = *apiObj
+	r.read = repositoryFromAPI(apiObj)
 
 	return nil
 
@@ -262,6 +395,26 @@ func repositoryFromAPI(apiObj *Repository) gitprovider.RepositoryInfo {
 	return repo
 }
 
+// originRef returns the reference to the repository a fork was created from, a UserRepositoryRef
+// if it's in the personal project of a user.
+func originRef(host string, origin *Repository) gitprovider.RepositoryRef {
+	if origin.Project.Type == "PERSONAL" {
+		ref := gitprovider.UserRepositoryRef{
+			UserRef:        gitprovider.UserRef{Domain: host, UserLogin: strings.ToLower(strings.TrimPrefix(origin.Project.Key, "~"))},
+			RepositoryName: origin.Name,
+		}
+		ref.SetSlug(origin.Slug)
+		return ref
+	}
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: host, Organization: origin.Project.Name},
+		RepositoryName:  origin.Name,
+	}
+	ref.SetKey(origin.Project.Key)
+	ref.SetSlug(origin.Slug)
+	return ref
+}
+
 func repositoryToAPI(repo *gitprovider.RepositoryInfo, ref gitprovider.RepositoryRef) *Repository {
 	apiObj := &Repository{
 		Name:  *gitprovider.StringVar(ref.GetRepository()),
@@ -302,3 +455,17 @@ func (r *orgRepository) GetCloneURL(prefix string, transport gitprovider.Transpo
 		return ""
 	}
 }
+
+// makeRepositoryListOptions returns the filters to list repositories with.
+// ErrNoProviderSupport is returned for the activity filter, as Stash doesn't return the last
+// activity of repositories.
+func makeRepositoryListOptions(opts ...gitprovider.RepositoryListOption) (gitprovider.RepositoryListOptions, error) {
+	o, err := gitprovider.MakeRepositoryListOptions(opts...)
+	if err != nil {
+		return o, err
+	}
+	if o.ActiveSince != nil {
+		return o, fmt.Errorf("stash can't filter repositories by activity: %w", gitprovider.ErrNoProviderSupport)
+	}
+	return o, nil
+}
@@ -18,6 +18,7 @@ package stash
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 )
@@ -46,8 +47,9 @@ var (
 
 func newTeamAccess(c *TeamAccessClient, ta gitprovider.TeamAccessInfo) *teamAccess {
 	return &teamAccess{
-		ta: ta,
-		c:  c,
+		ta:   ta,
+		read: ta,
+		c:    c,
 	}
 }
 
@@ -55,7 +57,10 @@ var _ gitprovider.TeamAccess = &teamAccess{}
 
 type teamAccess struct {
 	ta gitprovider.TeamAccessInfo
-	c  *TeamAccessClient
+	// read is the state of the team access when it was last read from the server, checked by
+	// Update with optimistic concurrency
+	read gitprovider.TeamAccessInfo
+	c    *TeamAccessClient
 }
 
 func (ta *teamAccess) Get() gitprovider.TeamAccessInfo {
@@ -82,7 +87,21 @@ func (ta *teamAccess) Delete(_ context.Context) error {
 	return gitprovider.ErrNoProviderSupport
 }
 
+// Update applies the permission in this object to the server.
+//
+// With optimistic concurrency, a *gitprovider.ConflictError is returned if the team access was
+// changed since it was read.
 func (ta *teamAccess) Update(ctx context.Context) error {
+	if ta.c.optimisticConcurrency {
+		actual, err := ta.c.Get(ctx, ta.read.Name)
+		if err != nil {
+			return err
+		}
+		object := fmt.Sprintf("%s/teams/%s", ta.c.ref.String(), ta.read.Name)
+		if err := gitprovider.CheckUnchanged(object, ta.read, actual.Get()); err != nil {
+			return err
+		}
+	}
 	// Update the actual state to be the desired state
 	// by issuing a Create, which uses a PUT underneath.
 	resp, err := ta.c.Create(ctx, ta.Get())
@@ -93,16 +112,17 @@ func (ta *teamAccess) Update(ctx context.Context) error {
 			"repo", ta.Repository().GetRepository())
 		return err
 	}
+	ta.read = resp.Get()
 	return ta.Set(resp.Get())
 }
 
 // Reconcile makes sure the given desired state (req) becomes the actual state in the backing Git provider.
 //
 // If req doesn't exist under the hood, it is created (actionTaken == true).
-// If req doesn't equal the actual state, the resource will be deleted and recreated (actionTaken == true).
+// If req doesn't equal the actual state, its permission will be updated in place (actionTaken == true).
 // If req is already the actual state, this is a no-op (actionTaken == false).
 func (ta *teamAccess) Reconcile(ctx context.Context) (bool, error) {
-	_, actionTaken, err := ta.c.Reconcile(ctx, ta.ta)
+	actual, actionTaken, err := ta.c.Reconcile(ctx, ta.ta)
 
 	if err != nil {
 		// Log the error and return it
@@ -113,7 +133,8 @@ func (ta *teamAccess) Reconcile(ctx context.Context) (bool, error) {
 		return actionTaken, err
 	}
 
-	return actionTaken, nil
+	ta.read = actual.Get()
+	return actionTaken, ta.Set(actual.Get())
 }
 
 func getGitProviderPermission(permissionLevel int) (*gitprovider.RepositoryPermission, error) {
@@ -139,6 +160,28 @@ func getStashPermissionFromMap(permissionMap map[string]bool) int {
 	return lastPriority
 }
 
+// normalizeStashPermission maps permission to the closest level Stash can represent
+// without granting more than was asked for: triage becomes pull and maintain becomes push.
+// This keeps the desired state equal to what Get reads back once it has been applied.
+func normalizeStashPermission(permission gitprovider.RepositoryPermission) gitprovider.RepositoryPermission {
+	level := 0
+	for key, value := range permissionPriority {
+		if value == permission {
+			level = key
+		}
+	}
+	best := 0
+	for _, v := range stashPriority {
+		if v <= level && v > best {
+			best = v
+		}
+	}
+	if p, ok := permissionPriority[best]; ok {
+		return p
+	}
+	return permission
+}
+
 func getStashPermission(permission gitprovider.RepositoryPermission) (string, error) {
 	for key, value := range permissionPriority {
 		if value == permission {
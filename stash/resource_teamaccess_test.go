@@ -17,6 +17,9 @@ limitations under the License.
 package stash
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"reflect"
 	"testing"
 
@@ -93,3 +96,74 @@ func Test_getStashPermission(t *testing.T) {
 		})
 	}
 }
+
+func Test_normalizeStashPermission(t *testing.T) {
+	tests := []struct {
+		permission gitprovider.RepositoryPermission
+		want       gitprovider.RepositoryPermission
+	}{
+		{gitprovider.RepositoryPermissionPull, gitprovider.RepositoryPermissionPull},
+		{gitprovider.RepositoryPermissionTriage, gitprovider.RepositoryPermissionPull},
+		{gitprovider.RepositoryPermissionPush, gitprovider.RepositoryPermissionPush},
+		{gitprovider.RepositoryPermissionMaintain, gitprovider.RepositoryPermissionPush},
+		{gitprovider.RepositoryPermissionAdmin, gitprovider.RepositoryPermissionAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.permission), func(t *testing.T) {
+			if got := normalizeStashPermission(tt.permission); got != tt.want {
+				t.Errorf("normalizeStashPermission() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeamAccessReconcilePermission(t *testing.T) {
+	mux, client := setup(t)
+
+	permission := stashPermissionRead
+	puts := 0
+	mux.HandleFunc(fmt.Sprintf("%s/projects/prj1/repos/repo1/permissions/groups", stashURIprefix), func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			puts++
+			permission = r.URL.Query().Get("permission")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprintf(w, `{"values": [{"group": {"name": "devs"}, "permission": %q}], "isLastPage": true}`, permission)
+	})
+	mux.HandleFunc(fmt.Sprintf("%s/projects/prj1/permissions/groups", stashURIprefix), func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"values": [], "isLastPage": true}`))
+	})
+
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: "stash.example.com", Organization: "prj1"},
+		RepositoryName:  "repo1",
+	}
+	ref.SetKey("prj1")
+	ref.SetSlug("repo1")
+	c := &TeamAccessClient{clientContext: &clientContext{client: client}, ref: ref}
+
+	rec := &gitprovider.ChangeRecorder{}
+	ctx := gitprovider.WithChangeRecorder(context.Background(), rec)
+
+	// Maintain is stored as write, so a second reconcile must not see a diff
+	req := gitprovider.TeamAccessInfo{Name: "devs", Permission: gitprovider.RepositoryPermissionVar(gitprovider.RepositoryPermissionMaintain)}
+	for i, wantAction := range []bool{true, false} {
+		ta, actionTaken, err := c.Reconcile(ctx, req)
+		if err != nil {
+			t.Fatalf("Reconcile #%d returned error: %v", i, err)
+		}
+		if actionTaken != wantAction {
+			t.Errorf("Reconcile #%d actionTaken = %v, want %v", i, actionTaken, wantAction)
+		}
+		if got := *ta.Get().Permission; got != gitprovider.RepositoryPermissionPush {
+			t.Errorf("Reconcile #%d permission = %v, want %v", i, got, gitprovider.RepositoryPermissionPush)
+		}
+	}
+	if puts != 1 || permission != stashPermissionWrite {
+		t.Errorf("got %d updates to %q, want 1 update to %q", puts, permission, stashPermissionWrite)
+	}
+	if actions := rec.Actions(); len(actions) != 1 || actions[0].Type != gitprovider.ChangeTypeUpdate {
+		t.Errorf("recorded actions = %+v, want a single update", actions)
+	}
+}
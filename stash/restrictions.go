@@ -0,0 +1,307 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// branchPermissionsURIprefix is the prefix of the branch-permissions REST API, used to manage ref restrictions
+	branchPermissionsURIprefix = "/rest/branch-permissions/2.0"
+	restrictionsURI            = "restrictions"
+)
+
+// The types of the ref restrictions.
+const (
+	// RestrictionTypeReadOnly prevents all changes to the matching refs.
+	RestrictionTypeReadOnly = "read-only"
+	// RestrictionTypeNoDeletes prevents the deletion of the matching refs.
+	RestrictionTypeNoDeletes = "no-deletes"
+	// RestrictionTypeFastForwardOnly prevents rewriting the history of the matching refs.
+	RestrictionTypeFastForwardOnly = "fast-forward-only"
+	// RestrictionTypePullRequestOnly prevents changes to the matching refs without a pull request.
+	RestrictionTypePullRequestOnly = "pull-request-only"
+)
+
+// The types of the matchers selecting the refs of a restriction.
+const (
+	// MatcherTypeBranch matches a single branch, by its ID, e.g. "refs/heads/main".
+	MatcherTypeBranch = "BRANCH"
+	// MatcherTypePattern matches the refs by pattern, e.g. "release/*".
+	MatcherTypePattern = "PATTERN"
+	// MatcherTypeModelBranch matches the development or production branch of the branching model.
+	MatcherTypeModelBranch = "MODEL_BRANCH"
+	// MatcherTypeModelCategory matches the branches of a category of the branching model, e.g. "FEATURE".
+	MatcherTypeModelCategory = "MODEL_CATEGORY"
+)
+
+// Restrictions interface defines the methods that can be used to
+// manage the ref restrictions, i.e. branch permissions, of a repository.
+type Restrictions interface {
+	List(ctx context.Context, projectKey, repositorySlug string, opts *PagingOptions) (*RestrictionList, error)
+	All(ctx context.Context, projectKey, repositorySlug string) ([]*Restriction, error)
+	Get(ctx context.Context, projectKey, repositorySlug string, id int) (*Restriction, error)
+	Create(ctx context.Context, projectKey, repositorySlug string, restriction *CreateRestriction) (*Restriction, error)
+	Delete(ctx context.Context, projectKey, repositorySlug string, id int) error
+}
+
+// RestrictionsService is a client for communicating with stash branch permissions endpoint
+// bitbucket-server API docs: https://docs.atlassian.com/bitbucket-server/rest/5.16.0/bitbucket-ref-restriction-rest.html
+type RestrictionsService service
+
+// Restriction is a ref restriction, restricting the changes to the matching refs to the given users and groups.
+type Restriction struct {
+	// Session is the session object for the restriction.
+	Session `json:"sessionInfo,omitempty"`
+	// ID is the ID of the restriction.
+	ID int `json:"id,omitempty"`
+	// Type is one of the RestrictionType constants.
+	Type string `json:"type,omitempty"`
+	// Matcher selects the refs the restriction applies to.
+	Matcher RestrictionMatcher `json:"matcher,omitempty"`
+	// Users are the users exempted from the restriction.
+	Users []*User `json:"users,omitempty"`
+	// Groups are the names of the groups exempted from the restriction.
+	Groups []string `json:"groups,omitempty"`
+}
+
+// CreateRestriction is the request to create a restriction. The users are exempted by name,
+// rather than the User objects of a Restriction.
+type CreateRestriction struct {
+	// Type is one of the RestrictionType constants.
+	Type string `json:"type"`
+	// Matcher selects the refs the restriction applies to.
+	Matcher RestrictionMatcher `json:"matcher"`
+	// Users are the names of the users exempted from the restriction.
+	Users []string `json:"users,omitempty"`
+	// Groups are the names of the groups exempted from the restriction.
+	Groups []string `json:"groups,omitempty"`
+}
+
+// RestrictionMatcher selects the refs a restriction applies to.
+type RestrictionMatcher struct {
+	// ID is the branch ID, e.g. "refs/heads/main", the pattern, e.g. "release/*", the model
+	// branch, e.g. "production", or the model category, e.g. "FEATURE", depending on the type.
+	ID string `json:"id"`
+	// DisplayID is the display name of the ref or pattern, e.g. "main".
+	DisplayID string `json:"displayId,omitempty"`
+	// Type is the type of the matcher.
+	Type RestrictionMatcherType `json:"type"`
+	// Active is true if the matcher is enabled.
+	Active bool `json:"active,omitempty"`
+}
+
+// RestrictionMatcherType is the type of a matcher.
+type RestrictionMatcherType struct {
+	// ID is one of the MatcherType constants.
+	ID string `json:"id"`
+	// Name is the display name of the type, e.g. "Branch".
+	Name string `json:"name,omitempty"`
+}
+
+// RestrictionList is a list of restrictions.
+type RestrictionList struct {
+	// Paging is the paging information.
+	Paging
+	// Restrictions is the list of restrictions.
+	Restrictions []*Restriction `json:"values,omitempty"`
+}
+
+// GetRestrictions returns the list of restrictions.
+func (r *RestrictionList) GetRestrictions() []*Restriction {
+	return r.Restrictions
+}
+
+// List returns the list of restrictions of the repository.
+// Paging is optional and is enabled by providing a PagingOptions struct.
+// A pointer to a RestrictionList struct is returned to retrieve the next page of results.
+// List uses the endpoint "GET /rest/branch-permissions/2.0/projects/{projectKey}/repos/{repositorySlug}/restrictions".
+// https://docs.atlassian.com/bitbucket-server/rest/5.16.0/bitbucket-ref-restriction-rest.html
+func (s *RestrictionsService) List(ctx context.Context, projectKey, repositorySlug string, opts *PagingOptions) (*RestrictionList, error) {
+	query := addPaging(url.Values{}, opts)
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, newPrefixedURI(branchPermissionsURIprefix, projectsURI, projectKey, RepositoriesURI, repositorySlug, restrictionsURI), WithQuery(query))
+	if err != nil {
+		return nil, fmt.Errorf("list restrictions request creation failed: %w", err)
+	}
+	res, resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("list restrictions failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	restrictions := &RestrictionList{}
+	if err := s.Client.unmarshalList(res, restrictions); err != nil {
+		return nil, fmt.Errorf("list restrictions failed, unable to unmarshall json: %w", err)
+	}
+
+	for _, r := range restrictions.GetRestrictions() {
+		r.Session.set(resp)
+	}
+
+	return restrictions, nil
+}
+
+// All retrieves all restrictions of the repository.
+// This function handles pagination, HTTP error wrapping, and validates the server result.
+func (s *RestrictionsService) All(ctx context.Context, projectKey, repositorySlug string) ([]*Restriction, error) {
+	r := []*Restriction{}
+	opts := &PagingOptions{Limit: perPageLimit}
+	err := allPages(opts, func() (*Paging, error) {
+		list, err := s.List(ctx, projectKey, repositorySlug, opts)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, list.GetRestrictions()...)
+		return &list.Paging, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Get retrieves the restriction with the given ID.
+// Get uses the endpoint "GET /rest/branch-permissions/2.0/projects/{projectKey}/repos/{repositorySlug}/restrictions/{id}".
+// https://docs.atlassian.com/bitbucket-server/rest/5.16.0/bitbucket-ref-restriction-rest.html
+func (s *RestrictionsService) Get(ctx context.Context, projectKey, repositorySlug string, id int) (*Restriction, error) {
+	req, err := s.Client.NewRequest(ctx, http.MethodGet, newPrefixedURI(branchPermissionsURIprefix, projectsURI, projectKey, RepositoriesURI, repositorySlug, restrictionsURI, strconv.Itoa(id)))
+	if err != nil {
+		return nil, fmt.Errorf("get restriction request creation failed: %w", err)
+	}
+	res, resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get restriction failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	restriction := &Restriction{}
+	if err := json.Unmarshal(res, restriction); err != nil {
+		return nil, fmt.Errorf("get restriction failed, unable to unmarshall json: %w", err)
+	}
+
+	restriction.Session.set(resp)
+
+	return restriction, nil
+}
+
+// Create creates a restriction. A restriction of the same type and matcher is replaced.
+// Create uses the endpoint "POST /rest/branch-permissions/2.0/projects/{projectKey}/repos/{repositorySlug}/restrictions".
+// https://docs.atlassian.com/bitbucket-server/rest/5.16.0/bitbucket-ref-restriction-rest.html
+func (s *RestrictionsService) Create(ctx context.Context, projectKey, repositorySlug string, restriction *CreateRestriction) (*Restriction, error) {
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	body, err := marshallBody(restriction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshall restriction: %v", err)
+	}
+	req, err := s.Client.NewRequest(ctx, http.MethodPost, newPrefixedURI(branchPermissionsURIprefix, projectsURI, projectKey, RepositoriesURI, repositorySlug, restrictionsURI), WithBody(body), WithHeader(header))
+	if err != nil {
+		return nil, fmt.Errorf("create restriction request creation failed: %w", err)
+	}
+	res, resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("create restriction failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusBadRequest {
+		return nil, fmt.Errorf("create restriction failed: %s", resp.Status)
+	}
+
+	created := &Restriction{}
+	if err := json.Unmarshal(res, created); err != nil {
+		return nil, fmt.Errorf("create restriction failed, unable to unmarshall json: %w", err)
+	}
+
+	created.Session.set(resp)
+
+	return created, nil
+}
+
+// Delete deletes the restriction with the given ID.
+// Delete uses the endpoint "DELETE /rest/branch-permissions/2.0/projects/{projectKey}/repos/{repositorySlug}/restrictions/{id}".
+// https://docs.atlassian.com/bitbucket-server/rest/5.16.0/bitbucket-ref-restriction-rest.html
+func (s *RestrictionsService) Delete(ctx context.Context, projectKey, repositorySlug string, id int) error {
+	req, err := s.Client.NewRequest(ctx, http.MethodDelete, newPrefixedURI(branchPermissionsURIprefix, projectsURI, projectKey, RepositoriesURI, repositorySlug, restrictionsURI, strconv.Itoa(id)))
+	if err != nil {
+		return fmt.Errorf("delete restriction request creation failed: %w", err)
+	}
+	_, resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete restriction failed: %w", err)
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Matches returns true if the matcher selects the given ref, e.g. "refs/heads/main".
+// The matchers of the branching model can't be resolved without the model, so they never match.
+// Patterns match the end of the ref at a path boundary, with "*" and "?" wildcards within a path
+// segment and "**" across segments, so "main" and "release/*" match "refs/heads/main" and
+// "refs/heads/release/1.0".
+func (m RestrictionMatcher) Matches(ref string) bool {
+	switch m.Type.ID {
+	case MatcherTypeBranch:
+		return m.ID == ref
+	case MatcherTypePattern:
+		return patternRegexp(m.ID).MatchString(ref)
+	default:
+		return false
+	}
+}
+
+// patternRegexp returns the regular expression of a ref restriction pattern.
+func patternRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(^|/)")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
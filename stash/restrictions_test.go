@@ -0,0 +1,122 @@
+/*
+Copyright 2021 The Flux CD contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCreateRestriction(t *testing.T) {
+	mux, client := setup(t)
+
+	path := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s", branchPermissionsURIprefix, projectsURI, RepositoriesURI, restrictionsURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		req := &CreateRestriction{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			t.Fatalf("failed to decode restriction: %v", err)
+		}
+		json.NewEncoder(w).Encode(&Restriction{ID: 7, Type: req.Type, Matcher: req.Matcher, Groups: req.Groups})
+	})
+
+	req := &CreateRestriction{
+		Type:    RestrictionTypeNoDeletes,
+		Matcher: RestrictionMatcher{ID: "refs/heads/main", Type: RestrictionMatcherType{ID: MatcherTypeBranch}},
+		Groups:  []string{"admins"},
+	}
+	created, err := client.Restrictions.Create(context.Background(), "prj1", "repo1", req)
+	if err != nil {
+		t.Fatalf("Restrictions.Create returned error: %v", err)
+	}
+	want := &Restriction{ID: 7, Type: req.Type, Matcher: req.Matcher, Groups: req.Groups}
+	if diff := cmp.Diff(want, created); diff != "" {
+		t.Errorf("Restrictions.Create returned diff (want -> got):\n%s", diff)
+	}
+}
+
+func TestRestrictionMatcher(t *testing.T) {
+	tests := []struct {
+		matcher RestrictionMatcher
+		ref     string
+		want    bool
+	}{
+		{RestrictionMatcher{ID: "refs/heads/main", Type: RestrictionMatcherType{ID: MatcherTypeBranch}}, "refs/heads/main", true},
+		{RestrictionMatcher{ID: "refs/heads/main", Type: RestrictionMatcherType{ID: MatcherTypeBranch}}, "refs/heads/maintenance", false},
+		{RestrictionMatcher{ID: "main", Type: RestrictionMatcherType{ID: MatcherTypePattern}}, "refs/heads/main", true},
+		{RestrictionMatcher{ID: "main", Type: RestrictionMatcherType{ID: MatcherTypePattern}}, "refs/heads/domain", false},
+		{RestrictionMatcher{ID: "release/*", Type: RestrictionMatcherType{ID: MatcherTypePattern}}, "refs/heads/release/1.0", true},
+		{RestrictionMatcher{ID: "release/*", Type: RestrictionMatcherType{ID: MatcherTypePattern}}, "refs/heads/release/1.0/hotfix", false},
+		{RestrictionMatcher{ID: "release/**", Type: RestrictionMatcherType{ID: MatcherTypePattern}}, "refs/heads/release/1.0/hotfix", true},
+		{RestrictionMatcher{ID: "v?.0", Type: RestrictionMatcherType{ID: MatcherTypePattern}}, "refs/heads/v1.0", true},
+		{RestrictionMatcher{ID: "production", Type: RestrictionMatcherType{ID: MatcherTypeModelBranch}}, "refs/heads/production", false},
+	}
+	for _, tt := range tests {
+		if got := tt.matcher.Matches(tt.ref); got != tt.want {
+			t.Errorf("%s matcher %q Matches(%q) = %v, want %v", tt.matcher.Type.ID, tt.matcher.ID, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestBranchClient_GetRules(t *testing.T) {
+	mux, client := setup(t)
+
+	path := fmt.Sprintf("%s/%s/prj1/%s/repo1/%s", branchPermissionsURIprefix, projectsURI, RepositoriesURI, restrictionsURI)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&RestrictionList{
+			Paging: Paging{IsLastPage: true},
+			Restrictions: []*Restriction{
+				{ID: 1, Type: RestrictionTypeNoDeletes, Matcher: RestrictionMatcher{ID: "refs/heads/main", Type: RestrictionMatcherType{ID: MatcherTypeBranch}}},
+				{ID: 2, Type: RestrictionTypePullRequestOnly, Matcher: RestrictionMatcher{ID: "*", Type: RestrictionMatcherType{ID: MatcherTypePattern}}},
+				{ID: 3, Type: RestrictionTypeReadOnly, Matcher: RestrictionMatcher{ID: "release/*", Type: RestrictionMatcherType{ID: MatcherTypePattern}}},
+			},
+		})
+	})
+
+	p := newClient(client, "stash.example.com", "token", false, logr.Discard())
+	ref := gitprovider.OrgRepositoryRef{
+		OrganizationRef: gitprovider.OrganizationRef{Domain: "stash.example.com", Organization: "prj1"},
+		RepositoryName:  "repo1",
+	}
+	ref.SetKey("prj1")
+	ref.SetSlug("repo1")
+	c := &BranchClient{clientContext: p.clientContext, ref: ref}
+
+	rules, err := c.GetRules(context.Background(), "main")
+	if err != nil {
+		t.Fatalf("GetRules returned error: %v", err)
+	}
+	if want := (gitprovider.BranchRules{BlockDeletion: true, RequirePullRequest: true}); !cmp.Equal(want, rules) {
+		t.Errorf("GetRules returned %+v, want %+v", rules, want)
+	}
+	rules, err = c.GetRules(context.Background(), "release/1.0")
+	if err != nil {
+		t.Fatalf("GetRules returned error: %v", err)
+	}
+	if want := (gitprovider.BranchRules{BlockForcePushes: true, RequirePullRequest: true}); !cmp.Equal(want, rules) {
+		t.Errorf("GetRules returned %+v, want %+v", rules, want)
+	}
+}
@@ -18,6 +18,10 @@ package stash
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/fluxcd/go-git-providers/gitprovider"
 	"github.com/fluxcd/go-git-providers/validation"
@@ -27,7 +31,7 @@ import (
 // ProviderID is the provider ID for BitBucket Server a.k.a Stash.
 const (
 	// ProviderID is the provider ID for BitBucket Server a.k.a Stash.
-	ProviderID = gitprovider.ProviderID("stash")
+	ProviderID = gitprovider.ProviderStash
 )
 
 func newClient(c *Client, host, token string, destructiveActions bool, logger logr.Logger) *ProviderClient {
@@ -37,6 +41,8 @@ func newClient(c *Client, host, token string, destructiveActions bool, logger lo
 		token:              token,
 		destructiveActions: destructiveActions,
 		log:                logger,
+		concurrency:        1,
+		generations:        gitprovider.NewGenerationTracker(),
 	}
 
 	return &ProviderClient{
@@ -50,6 +56,21 @@ func newClient(c *Client, host, token string, destructiveActions bool, logger lo
 		userRepos: &UserRepositoriesClient{
 			clientContext: ctx,
 		},
+		search: &SearchClient{
+			clientContext: ctx,
+		},
+		userKeys: &UserKeysClient{
+			clientContext: ctx,
+		},
+		invitations: &InvitationsClient{
+			clientContext: ctx,
+		},
+		events: &EventsClient{
+			clientContext: ctx,
+		},
+		tokens: &TokensClient{
+			clientContext: ctx,
+		},
 	}
 }
 
@@ -59,6 +80,19 @@ type clientContext struct {
 	token              string
 	destructiveActions bool
 	log                logr.Logger
+	// dryRun makes Reconcile calls return the changes they would apply, without writing
+	dryRun bool
+	// optimisticConcurrency makes repository Update calls fail if the repository changed since it was read
+	optimisticConcurrency bool
+	// repoDefaults are the repository defaults per organization, applied when creating repositories
+	repoDefaults gitprovider.OrgRepositoryDefaults
+	// concurrency is the number of requests aggregate List calls run at once. Stash pages are
+	// linked by their start offset, hence only fan-outs over listed objects run concurrently.
+	concurrency int
+	// waitAfterCreate is how long repository Create calls wait for the repository to be visible
+	waitAfterCreate time.Duration
+	// generations tracks the generations of the returned objects
+	generations *gitprovider.GenerationTracker
 }
 
 // Client implements the gitprovider.Client interface.
@@ -68,9 +102,16 @@ var _ gitprovider.Client = &ProviderClient{}
 type ProviderClient struct {
 	*clientContext
 
-	orgs      *OrganizationsClient
-	orgRepos  *OrgRepositoriesClient
-	userRepos *UserRepositoriesClient
+	orgs        *OrganizationsClient
+	orgRepos    *OrgRepositoriesClient
+	userRepos   *UserRepositoriesClient
+	search      *SearchClient
+	userKeys    *UserKeysClient
+	invitations *InvitationsClient
+	events      *EventsClient
+	tokens      *TokensClient
+
+	identity gitprovider.IdentityCache
 }
 
 // SupportedDomain returns the host endpoint for this client, e.g. "mystash.com:7990"
@@ -107,11 +148,97 @@ func (p *ProviderClient) UserRepositories() gitprovider.UserRepositoriesClient {
 	return p.userRepos
 }
 
+// Search returns the SearchClient searching for repositories and code.
+func (p *ProviderClient) Search() gitprovider.SearchClient {
+	return p.search
+}
+
+// UserKeys returns the UserKeysClient handling the SSH and GPG keys of the authenticated user.
+func (p *ProviderClient) UserKeys() gitprovider.UserKeysClient {
+	return p.userKeys
+}
+
+// Invitations returns the InvitationsClient handling the repository invitations of the authenticated user.
+func (p *ProviderClient) Invitations() gitprovider.InvitationsClient {
+	return p.invitations
+}
+
+// Events returns the EventsClient listing the activity of repositories and organizations.
+func (p *ProviderClient) Events() gitprovider.EventsClient {
+	return p.events
+}
+
+// Tokens returns the TokensClient handling the access tokens of repositories and organizations.
+func (p *ProviderClient) Tokens() gitprovider.TokensClient {
+	return p.tokens
+}
+
 // HasTokenPermission returns a boolean indicating whether the supplied token has the requested permission.
 func (p *ProviderClient) HasTokenPermission(_ context.Context, _ gitprovider.TokenPermission) (bool, error) {
 	return false, gitprovider.ErrNoProviderSupport
 }
 
+// AuthenticatedUserID returns the identity of the user the client was created for, which is a bot
+// for the service users of access tokens. It's fetched on the first call, and cached by the client.
+// ErrNoProviderSupport is returned if the client was created without a username.
+func (p *ProviderClient) AuthenticatedUserID(ctx context.Context) (gitprovider.Identity, error) {
+	return p.identity.Get(ctx, p.fetchIdentity)
+}
+
+// IsOwnAction returns true if the event was caused by the user the client was created for.
+func (p *ProviderClient) IsOwnAction(ctx context.Context, event gitprovider.ActorEvent) (bool, error) {
+	return p.identity.IsOwnAction(ctx, event, p.fetchIdentity)
+}
+
+// TokenInfo returns the user the client was created for. Stash doesn't disclose the permissions and
+// expiry of tokens, so ScopesKnown is false and ExpiresAt is nil.
+// ErrNoProviderSupport is returned if the client was created without a username.
+func (p *ProviderClient) TokenInfo(ctx context.Context) (gitprovider.TokenInfo, error) {
+	identity, err := p.fetchIdentity(ctx)
+	if err != nil {
+		return gitprovider.TokenInfo{}, err
+	}
+	return gitprovider.TokenInfo{Principal: identity}, nil
+}
+
+// ValidatePermissions returns ErrNoProviderSupport: Stash has no cheap way of probing the
+// permissions of the authenticated user on a repository.
+func (p *ProviderClient) ValidatePermissions(_ context.Context, _ ...gitprovider.Permission) error {
+	return gitprovider.ErrNoProviderSupport
+}
+
+func (p *ProviderClient) fetchIdentity(ctx context.Context) (gitprovider.Identity, error) {
+	if p.client.username == "" {
+		return gitprovider.Identity{}, fmt.Errorf("no authenticated user: %w", gitprovider.ErrNoProviderSupport)
+	}
+	user, err := p.client.Users.Get(ctx, p.client.username)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return gitprovider.Identity{}, gitprovider.ErrNotFound
+		}
+		return gitprovider.Identity{}, fmt.Errorf("failed to get user %s: %w", p.client.username, err)
+	}
+	login := user.Slug
+	if login == "" {
+		login = user.Name
+	}
+	return gitprovider.Identity{
+		ID:    strconv.FormatInt(user.ID, 10),
+		Login: login,
+		Bot:   user.Type == "SERVICE",
+	}, nil
+}
+
+// SupportedFeatures returns the features of the high-level API this provider supports.
+func (p *ProviderClient) SupportedFeatures() []gitprovider.Feature {
+	return gitprovider.SupportedFeatures(ProviderID, "")
+}
+
+// Extension returns the provider-specific extension registered under name, or nil.
+func (p *ProviderClient) Extension(name string) interface{} {
+	return gitprovider.LookupExtension(p, name)
+}
+
 // validateAPIObject creates a Validatior with the specified name, gives it to fn, and
 // depending on if any error was registered with it; either returns nil, or a MultiError
 // with both the validation error and ErrInvalidServerData, to mark that the server data
@@ -24,6 +24,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
 )
 
 const (
@@ -114,7 +116,7 @@ func (s *UsersService) List(ctx context.Context, opts *PagingOptions) (*UserList
 	u := &UserList{
 		Users: []*User{},
 	}
-	if err := json.Unmarshal(res, u); err != nil {
+	if err := s.Client.unmarshalList(res, u); err != nil {
 		return nil, fmt.Errorf("list users failed, unable to unmarshal repository list json, %w", err)
 	}
 
@@ -172,7 +174,18 @@ func addPaging(query url.Values, opts *PagingOptions) url.Values {
 	return query
 }
 
-// newURI builds stash URI
+// newURI builds stash URI.
+// Every element is path escaped, so that project keys, repository slugs and user names
+// containing non-ASCII or reserved characters produce a valid URI.
 func newURI(elements ...string) string {
-	return strings.Join(append([]string{stashURIprefix}, elements...), "/")
+	return newPrefixedURI(stashURIprefix, elements...)
+}
+
+// newPrefixedURI builds a stash URI for a REST API other than the core one, e.g. branch-utils.
+func newPrefixedURI(prefix string, elements ...string) string {
+	escaped := make([]string, 0, len(elements))
+	for _, e := range elements {
+		escaped = append(escaped, gitprovider.EscapePath(e))
+	}
+	return strings.Join(append([]string{prefix}, escaped...), "/")
 }
@@ -26,6 +26,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
 )
 
 // setup sets up a test HTTP server along with a Client configured to talk to that test server.
@@ -146,3 +148,28 @@ func TestUserList(t *testing.T) {
 		t.Errorf("Users.List returned diff (want -> got):\n%s", diff)
 	}
 }
+
+func TestUserListSchemaDrift(t *testing.T) {
+	mux, client := setup(t)
+	var drifts []gitprovider.SchemaDrift
+	if err := WithSchemaDriftHook(func(d gitprovider.SchemaDrift) { drifts = append(drifts, d) })(client); err != nil {
+		t.Fatal(err)
+	}
+
+	path := fmt.Sprintf("%s/users", stashURIprefix)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		// Another server version returns the user ID as a string
+		w.Write([]byte(`{"values": [{"name": "John Citizen", "slug": "jcitizen", "id": "101"}], "isLastPage": true}`))
+	})
+
+	list, err := client.Users.List(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Users.List returned error: %v", err)
+	}
+	if diff := cmp.Diff([]*User{{Name: "John Citizen", Slug: "jcitizen"}}, list.Users); diff != "" {
+		t.Errorf("Users.List returned diff (want -> got):\n%s", diff)
+	}
+	if len(drifts) != 1 || drifts[0].Expected != "int64" || drifts[0].Got != "string" {
+		t.Errorf("drifts = %+v, want the user ID", drifts)
+	}
+}